@@ -1,17 +1,24 @@
 package main
 
 import (
-	"log"
+	"log/slog"
 	stdhttp "net/http"
+	"os"
 	"reflect"
 
 	"github.com/luispfcanales/api-muac/docs"
 	_ "github.com/luispfcanales/api-muac/docs" // Importa los docs generados
+	"github.com/luispfcanales/api-muac/internal/adapters/charts"
+	"github.com/luispfcanales/api-muac/internal/adapters/events"
 	"github.com/luispfcanales/api-muac/internal/adapters/handlers/http"
+	"github.com/luispfcanales/api-muac/internal/adapters/notifier"
 	"github.com/luispfcanales/api-muac/internal/adapters/repositories/postgres"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
 	"github.com/luispfcanales/api-muac/internal/core/services"
+	"github.com/luispfcanales/api-muac/internal/infrastructure/auth"
 	"github.com/luispfcanales/api-muac/internal/infrastructure/config"
+	"github.com/luispfcanales/api-muac/internal/infrastructure/growth"
+	"github.com/luispfcanales/api-muac/internal/infrastructure/logging"
 	"github.com/luispfcanales/api-muac/internal/infrastructure/server"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
@@ -29,42 +36,87 @@ import (
 func main() {
 	// Cargar configuración
 	cfg := config.LoadConfig()
+	logger := logging.New(cfg.LogLevel, cfg.LogFormat)
+	slog.SetDefault(logger)
+	domain.SetPatientAgeRange(cfg.MinPatientAgeMonths, cfg.MaxPatientAgeMonths)
+	auth.SetCost(cfg.BcryptCost)
+	domain.SetRolesRequiringLocality(cfg.LocalityRequiredRoles)
+	domain.SetAllowedWebhookHosts(cfg.WebhookAllowedHosts)
+	domain.SetMaxMeasurementClockSkew(cfg.MaxClockSkew)
+	domain.SetRequireDescriptionForRisk(cfg.RequireRiskDescription)
+	domain.SetMeasurementEditWindow(cfg.MeasurementEditWindow)
+	domain.SetCaseloadLimit(cfg.MaxPatientsPerCaregiver, cfg.CaseloadMode)
+	domain.SetProgramRegion(cfg.ProgramRegion)
+	domain.SetProgramTimeZone(cfg.ProgramTimeZone)
+	domain.SetPatientApprovalRequired(cfg.PatientApprovalRequired)
+	domain.SetSeverityWeights(cfg.SeverityWeightSevere, cfg.SeverityWeightModerate)
+	for muacCode, days := range cfg.ExpectedMeasurementDays {
+		domain.SetExpectedMeasurementDays(muacCode, days)
+	}
+	if cfg.MaintenanceMode {
+		domain.SetMaintenanceMode(true, "")
+	}
+	if reference, err := growth.LoadMuacReference(cfg.WHOMuacReferencePath); err != nil {
+		logger.Warn("no se pudo cargar la referencia OMS, las curvas de crecimiento no estarán disponibles", "error", err)
+	} else {
+		domain.SetMuacReference(reference)
+	}
+	if reference, err := growth.LoadWeightForHeightReference(cfg.WHOWeightForHeightReferencePath); err != nil {
+		logger.Warn("no se pudo cargar la referencia OMS de peso para la talla, el WHZ no estará disponible", "error", err)
+	} else {
+		domain.SetWeightForHeightReference(reference)
+	}
 
 	db, err := config.NewGormDBConnection(cfg)
 	if err != nil {
-		log.Fatalf("Error al conectar a la base de datos: %v", err)
+		logger.Error("error al conectar a la base de datos", "error", err)
+		os.Exit(1)
+	}
+
+	// Conexión de lectura para reportes; si la réplica falla se usa la primaria
+	readDB, err := config.NewGormReadConnection(cfg, db)
+	if err != nil {
+		logger.Warn("no se pudo conectar a la réplica de lectura, se usará la primaria", "error", err)
+		readDB = db
 	}
 
 	// Lista de modelos a migrar
 	modelos := []interface{}{
 		&domain.Role{},
 		&domain.Locality{},
+		&domain.LocalityTarget{},
 		&domain.Patient{},
 		&domain.Tag{},
 		&domain.User{},
 		&domain.Recommendation{},
 		&domain.Measurement{},
+		&domain.MeasurementDeletion{},
+		&domain.MeasurementRelocation{},
+		&domain.AuditEntry{},
+		&domain.QualityAlertAck{},
 		&domain.Notification{},
+		&domain.NotificationRead{},
 		&domain.FAQ{},
 		&domain.Tip{},
 		&domain.Recipe{},
 	}
 
 	// Migrar cada modelo y registrar en el log
-	log.Println("Iniciando migración de modelos...")
+	logger.Info("iniciando migración de modelos")
 	for _, modelo := range modelos {
 		nombreModelo := reflect.TypeOf(modelo).Elem().Name()
-		log.Printf("Migrando modelo: %s", nombreModelo)
 		if err := db.AutoMigrate(modelo); err != nil {
-			log.Fatalf("Error al migrar modelo %s: %v", nombreModelo, err)
+			logger.Error("error al migrar modelo", "model", nombreModelo, "error", err)
+			os.Exit(1)
 		}
-		log.Printf("Modelo %s migrado exitosamente", nombreModelo)
+		logger.Debug("modelo migrado", "model", nombreModelo)
 	}
-	log.Println("Migración completada exitosamente")
+	logger.Info("migración completada", "models", len(modelos))
 
 	// Sembrar datos iniciales
 	if err := config.SeedDatabase(db); err != nil {
-		log.Fatalf("Error al sembrar datos iniciales: %v", err)
+		logger.Error("error al sembrar datos iniciales", "error", err)
+		os.Exit(1)
 	}
 	// Crear repositorios
 	roleRepo := postgres.NewRoleRepository(db)
@@ -76,43 +128,61 @@ func main() {
 	tagRepo := postgres.NewTagRepository(db)
 	measurementRepo := postgres.NewMeasurementRepository(db)
 	patientRepo := postgres.NewPatientRepository(db)
-	reportRepo := postgres.NewReportRepository(db)
+	reportRepo := postgres.NewReportRepository(db, readDB)
 	tipRepo := postgres.NewTipRepository(db)
 	recipeRepo := postgres.NewRecipeRepository(db)
+	auditRepo := postgres.NewAuditRepository(db)
+	qualityAlertRepo := postgres.NewQualityAlertRepository(db)
+	backupRepo := postgres.NewBackupRepository(db)
 
 	// Crear servicios
+	auditService := services.NewAuditService(auditRepo, userRepo, logger)
 	tipService := services.NewTipService(tipRepo)
 	recipeService := services.NewRecipeService(recipeRepo)
-	roleService := services.NewRoleService(roleRepo)
-	userService := services.NewUserService(userRepo, roleRepo)
-	notificationService := services.NewNotificationService(notificationRepo)
+	roleService := services.NewRoleService(roleRepo, logger)
+	userService := services.NewUserService(userRepo, roleRepo, localityRepo, patientRepo, auditService)
+	notificationService := services.NewNotificationService(notificationRepo, userRepo, notifier.NewWebhookSender(cfg.WebhookTimeout), logger)
 	faqService := services.NewFAQService(faqRepo)
 	localityService := services.NewLocalityService(localityRepo)
 	recommendationService := services.NewRecommendationService(recommendationRepo)
 	tagService := services.NewTagService(tagRepo)
-	measurementService := services.NewMeasurementService(measurementRepo, tagRepo, recommendationRepo)
+	contentService := services.NewContentService(tagRepo, recommendationRepo)
+	backupService := services.NewBackupService(backupRepo)
+	riskBroker := events.NewRiskBroker(cfg.RiskStreamMaxClients, logger)
+	measurementService := services.NewMeasurementService(measurementRepo, tagRepo, recommendationRepo, patientRepo, userRepo, auditService, riskBroker, logger)
 	patientService := services.NewPatientService(
 		patientRepo,
 		measurementRepo,
+		userRepo,
 		tipService,
 		recipeService,
+		auditService,
 	)
 
-	fileService := services.NewFileService("uploads", cfg.DNS)
-	reportService := services.NewReportService(reportRepo, fileService)
+	fileService := services.NewFileService("uploads", cfg.DNS, charts.NewPlotRenderer(), logger)
+	syncService := services.NewSyncService(localityService, patientService, measurementService, recommendationService, faqService)
+	reportService := services.NewReportService(reportRepo, userRepo, localityRepo, fileService)
+	taskService := services.NewTaskService(userService, patientService, notificationService)
+	qualityAlertService := services.NewQualityAlertService(qualityAlertRepo, reportService, measurementService, patientService, contentService)
 
 	// Crear manejadores HTTP
 	roleHandler := http.NewRoleHandler(roleService)
-	userHandler := http.NewUserHandler(userService, fileService)
+	userHandler := http.NewUserHandler(userService, patientService, fileService, logger)
 	notificationHandler := http.NewNotificationHandler(notificationService)
-	faqHandler := http.NewFAQHandler(faqService)
-	localityHandler := http.NewLocalityHandler(localityService)
+	faqHandler := http.NewFAQHandler(faqService, fileService, logger)
+	localityHandler := http.NewLocalityHandler(localityService, userService, fileService, logger)
 	recommendationHandler := http.NewRecommendationHandler(recommendationService)
 	tagHandler := http.NewTagHandler(tagService)
 	measurementHandler := http.NewMeasurementHandler(measurementService)
-	patientHandler := http.NewPatientHandler(patientService, measurementService, fileService)
-	reportHandler := http.NewReportHandler(reportService, fileService)
+	patientHandler := http.NewPatientHandler(patientService, measurementService, fileService, logger)
+	reportHandler := http.NewReportHandler(reportService, fileService, logger)
+	riskStreamHandler := http.NewRiskStreamHandler(measurementService, cfg.RiskStreamHeartbeat, logger)
 	tipHandler := http.NewTipHandler(tipService, recipeService)
+	configHandler := http.NewConfigHandler()
+	adminHandler := http.NewAdminHandler(cfg.AdminToken, measurementService, patientService, contentService, auditService, qualityAlertService, backupService, logger)
+	syncHandler := http.NewSyncHandler(syncService)
+	taskHandler := http.NewTaskHandler(taskService, fileService)
+	auditHandler := http.NewAuditHandler(auditService)
 
 	// Configurar rutas
 	mux := stdhttp.NewServeMux()
@@ -146,11 +216,18 @@ func main() {
 	measurementHandler.RegisterRoutes(mux)
 	patientHandler.RegisterRoutes(mux)
 	reportHandler.RegisterRoutes(mux)
+	riskStreamHandler.RegisterRoutes(mux)
 	tipHandler.RegisterRoutes(mux)
+	configHandler.RegisterRoutes(mux)
+	adminHandler.RegisterRoutes(mux)
+	syncHandler.RegisterRoutes(mux)
+	taskHandler.RegisterRoutes(mux)
+	auditHandler.RegisterRoutes(mux)
 
 	// Crear y iniciar servidor
-	srv := server.NewServer(cfg, mux)
+	srv := server.NewServer(cfg, mux, logger)
 	if err := srv.Start(); err != nil {
-		log.Fatalf("Error al iniciar el servidor: %v", err)
+		logger.Error("error al iniciar el servidor", "error", err)
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,175 @@
+package charts
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/vgimg"
+)
+
+// Dimensiones del gráfico MUAC en píxeles
+const (
+	chartWidth  = 800
+	chartHeight = 400
+)
+
+// Rango mínimo del eje Y (cm); se amplía si alguna medición queda fuera
+const (
+	chartMinMuac = 9.0
+	chartMaxMuac = 16.0
+)
+
+// chartSinglePointSpan es el margen a cada lado del eje X cuando todas las mediciones son del mismo momento
+const chartSinglePointSpan = 24 * time.Hour
+
+var (
+	chartSeries     = color.RGBA{33, 33, 33, 255}
+	chartZoneRed    = color.RGBA{255, 205, 210, 255}
+	chartZoneYellow = color.RGBA{255, 243, 196, 255}
+	chartZoneGreen  = color.RGBA{200, 230, 201, 255}
+	chartLineRed    = color.RGBA{198, 40, 40, 255}
+	chartLineYellow = color.RGBA{245, 160, 0, 255}
+	chartLineGreen  = color.RGBA{46, 125, 50, 255}
+)
+
+// plotRenderer implementa IChartRenderer con gonum/plot
+type plotRenderer struct{}
+
+// NewPlotRenderer crea el generador de gráficos basado en gonum/plot
+func NewPlotRenderer() ports.IChartRenderer {
+	return &plotRenderer{}
+}
+
+// RenderMuacChart dibuja la serie MUAC con las zonas de clasificación coloreadas y líneas en los
+// umbrales. Sin mediciones se dibujan solo las zonas; con una sola medición el punto queda centrado
+func (r *plotRenderer) RenderMuacChart(series []domain.SparklinePoint) ([]byte, error) {
+	p := plot.New()
+	p.Y.Label.Text = "MUAC (cm)"
+	p.Y.Min, p.Y.Max = chartMinMuac, chartMaxMuac
+	for _, point := range series {
+		p.Y.Min = math.Min(p.Y.Min, math.Floor(point.MuacValue-0.5))
+		p.Y.Max = math.Max(p.Y.Max, math.Ceil(point.MuacValue+0.5))
+	}
+	p.Y.Tick.Marker = unitTicks{}
+
+	// Eje X proporcional al tiempo, con las fechas de la primera y la última medición; con un solo
+	// punto (o todos a la misma hora) se centra
+	p.X.Padding = 0
+	p.X.Tick.Marker = plot.ConstantTicks{}
+	p.X.Min, p.X.Max = 0, 1
+	if len(series) > 0 {
+		first, last := series[0].MeasuredAt, series[len(series)-1].MeasuredAt
+		ticks := []plot.Tick{{Value: float64(first.Unix()), Label: first.Format("2006-01-02")}}
+		if last.After(first) {
+			ticks = append(ticks, plot.Tick{Value: float64(last.Unix()), Label: last.Format("2006-01-02")})
+		}
+		p.X.Tick.Marker = plot.ConstantTicks(ticks)
+
+		margin := last.Sub(first) / 40
+		if margin <= 0 {
+			margin = chartSinglePointSpan
+		}
+		p.X.Min, p.X.Max = float64(first.Add(-margin).Unix()), float64(last.Add(margin).Unix())
+	}
+
+	// Zonas de clasificación
+	for _, zone := range []struct {
+		from, to float64
+		color    color.Color
+	}{
+		{p.Y.Min, domain.MuacThresholdSevere, chartZoneRed},
+		{domain.MuacThresholdSevere, domain.MuacThresholdNormal, chartZoneYellow},
+		{domain.MuacThresholdNormal, p.Y.Max, chartZoneGreen},
+	} {
+		band, err := plotter.NewPolygon(plotter.XYs{
+			{X: p.X.Min, Y: zone.from}, {X: p.X.Max, Y: zone.from},
+			{X: p.X.Max, Y: zone.to}, {X: p.X.Min, Y: zone.to},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error al generar el gráfico: %w", err)
+		}
+		band.Color = zone.color
+		band.LineStyle.Width = 0
+		p.Add(band)
+	}
+
+	grid := plotter.NewGrid()
+	grid.Vertical.Color = nil
+	p.Add(grid)
+
+	// Umbrales: severo (< 11.5), moderado (hasta 12.4) y normal (≥ 12.5)
+	for _, threshold := range []struct {
+		value float64
+		color color.Color
+	}{
+		{domain.MuacThresholdSevere, chartLineRed},
+		{domain.MuacThresholdModerate, chartLineYellow},
+		{domain.MuacThresholdNormal, chartLineGreen},
+	} {
+		line, err := plotter.NewLine(plotter.XYs{{X: p.X.Min, Y: threshold.value}, {X: p.X.Max, Y: threshold.value}})
+		if err != nil {
+			return nil, fmt.Errorf("error al generar el gráfico: %w", err)
+		}
+		line.Color = threshold.color
+		line.Width = vg.Points(2)
+		p.Add(line)
+	}
+
+	if len(series) > 0 {
+		points := make(plotter.XYs, len(series))
+		for i, point := range series {
+			points[i] = plotter.XY{X: float64(point.MeasuredAt.Unix()), Y: point.MuacValue}
+		}
+
+		line, scatter, err := plotter.NewLinePoints(points)
+		if err != nil {
+			return nil, fmt.Errorf("error al generar el gráfico: %w", err)
+		}
+		line.Color = chartSeries
+		line.Width = vg.Points(2)
+		scatter.GlyphStyleFunc = func(i int) draw.GlyphStyle {
+			return draw.GlyphStyle{Color: chartPointColor(series[i].MuacCode), Radius: vg.Points(5), Shape: draw.CircleGlyph{}}
+		}
+		p.Add(line, scatter)
+	}
+
+	canvas := vgimg.NewWith(vgimg.UseWH(vg.Points(chartWidth), vg.Points(chartHeight)), vgimg.UseDPI(72))
+	p.Draw(draw.New(canvas))
+
+	var buf bytes.Buffer
+	if _, err := (vgimg.PngCanvas{Canvas: canvas}).WriteTo(&buf); err != nil {
+		return nil, fmt.Errorf("error al generar el gráfico: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func chartPointColor(muacCode string) color.RGBA {
+	switch muacCode {
+	case domain.MuacCodeRed:
+		return chartLineRed
+	case domain.MuacCodeYellow:
+		return chartLineYellow
+	default:
+		return chartLineGreen
+	}
+}
+
+// unitTicks marca el eje Y cada 1 cm
+type unitTicks struct{}
+
+func (unitTicks) Ticks(min, max float64) []plot.Tick {
+	var ticks []plot.Tick
+	for value := math.Ceil(min); value <= max; value++ {
+		ticks = append(ticks, plot.Tick{Value: value, Label: fmt.Sprintf("%.0f", value)})
+	}
+	return ticks
+}
@@ -0,0 +1,45 @@
+package charts
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+)
+
+func TestRenderMuacChart(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		series []domain.SparklinePoint
+	}{
+		{"sin mediciones", nil},
+		{"una medición", []domain.SparklinePoint{
+			{MeasuredAt: start, MuacValue: 12.0, MuacCode: domain.MuacCodeYellow},
+		}},
+		{"varias mediciones", []domain.SparklinePoint{
+			{MeasuredAt: start, MuacValue: 11.0, MuacCode: domain.MuacCodeRed},
+			{MeasuredAt: start.AddDate(0, 0, 15), MuacValue: 12.1, MuacCode: domain.MuacCodeYellow},
+			{MeasuredAt: start.AddDate(0, 1, 0), MuacValue: 17.2, MuacCode: domain.MuacCodeGreen},
+		}},
+	}
+
+	renderer := NewPlotRenderer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := renderer.RenderMuacChart(tt.series)
+			if err != nil {
+				t.Fatalf("RenderMuacChart: %v", err)
+			}
+			img, err := png.Decode(bytes.NewReader(content))
+			if err != nil {
+				t.Fatalf("el gráfico no es un PNG válido: %v", err)
+			}
+			if size := img.Bounds().Size(); size.X != chartWidth || size.Y != chartHeight {
+				t.Errorf("se esperaba un gráfico de %dx%d, se obtuvo %dx%d", chartWidth, chartHeight, size.X, size.Y)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package events
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+)
+
+// riskSubscriber es una conexión suscrita con su filtro y su buffer de eventos
+type riskSubscriber struct {
+	filter domain.RiskStreamFilter
+	events chan domain.RiskMeasurementEvent
+}
+
+// riskBroker es un pub/sub en memoria: Publish nunca bloquea y, si un cliente lento tiene el
+// buffer lleno, el evento se descarta solo para ese cliente
+type riskBroker struct {
+	mu          sync.RWMutex
+	subscribers map[*riskSubscriber]struct{}
+	maxClients  int
+	logger      *slog.Logger
+}
+
+// NewRiskBroker crea el broker de mediciones en riesgo; maxClients <= 0 no limita las conexiones
+func NewRiskBroker(maxClients int, logger *slog.Logger) ports.IRiskEventBroker {
+	return &riskBroker{
+		subscribers: make(map[*riskSubscriber]struct{}),
+		maxClients:  maxClients,
+		logger:      logger,
+	}
+}
+
+// Publish entrega el evento a los suscriptores cuyo filtro coincide
+func (b *riskBroker) Publish(event domain.RiskMeasurementEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subscribers {
+		if !sub.filter.Matches(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			b.logger.Warn("evento de riesgo descartado: cliente lento", "measurement_id", event.MeasurementID)
+		}
+	}
+}
+
+// Subscribe registra un suscriptor; la función devuelta lo da de baja y cierra su canal (se puede llamar varias veces)
+func (b *riskBroker) Subscribe(filter domain.RiskStreamFilter) (<-chan domain.RiskMeasurementEvent, func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.maxClients > 0 && len(b.subscribers) >= b.maxClients {
+		return nil, nil, domain.ErrRiskStreamFull
+	}
+
+	sub := &riskSubscriber{
+		filter: filter,
+		events: make(chan domain.RiskMeasurementEvent, domain.RiskStreamBufferSize),
+	}
+	b.subscribers[sub] = struct{}{}
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, sub)
+			close(sub.events)
+			b.mu.Unlock()
+		})
+	}
+	return sub.events, unsubscribe, nil
+}
+
+// HasSubscribers permite evitar armar eventos cuando nadie escucha
+func (b *riskBroker) HasSubscribers() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subscribers) > 0
+}
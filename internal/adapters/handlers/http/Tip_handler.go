@@ -46,8 +46,7 @@ func (h *TipHandler) GetAllTipRecipes(w http.ResponseWriter, r *http.Request) {
 		Age      float64 `json:"age"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSON(w, r, &request) {
 		return
 	}
 
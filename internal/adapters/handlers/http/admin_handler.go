@@ -0,0 +1,628 @@
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+)
+
+// adminTokenHeader es la cabecera que debe traer el token de administración
+const adminTokenHeader = "X-Admin-Token"
+
+// AdminHandler expone operaciones de administración del sistema
+type AdminHandler struct {
+	adminToken         string
+	measurementService ports.IMeasurementService
+	patientService     ports.IPatientService
+	contentService     ports.IContentService
+	auditService       ports.IAuditService
+	qualityService     ports.IQualityAlertService
+	backupService      ports.IBackupService
+	logger             *slog.Logger
+}
+
+// NewAdminHandler crea una nueva instancia de AdminHandler.
+// Con adminToken vacío los endpoints de administración quedan desactivados.
+func NewAdminHandler(adminToken string, measurementService ports.IMeasurementService, patientService ports.IPatientService, contentService ports.IContentService, auditService ports.IAuditService, qualityService ports.IQualityAlertService, backupService ports.IBackupService, logger *slog.Logger) *AdminHandler {
+	return &AdminHandler{
+		adminToken:         adminToken,
+		measurementService: measurementService,
+		patientService:     patientService,
+		contentService:     contentService,
+		auditService:       auditService,
+		qualityService:     qualityService,
+		backupService:      backupService,
+		logger:             logger,
+	}
+}
+
+// RegisterRoutes registra las rutas del manejador
+func (h *AdminHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/admin/maintenance", h.GetMaintenance)
+	mux.HandleFunc("PUT /api/admin/maintenance", h.SetMaintenance)
+	mux.HandleFunc("GET /api/admin/measurements/unclassified", h.GetUnclassifiedMeasurements)
+	mux.HandleFunc("POST /api/admin/measurements/backfill", h.BackfillMeasurements)
+	mux.HandleFunc("GET /api/admin/measurements/classification-mismatches", h.GetClassificationMismatches)
+	mux.HandleFunc("GET /api/admin/patients/duplicate-dnis", h.GetDuplicateDNIs)
+	mux.HandleFunc("GET /api/admin/patients/orphaned", h.GetOrphanedPatients)
+	mux.HandleFunc("GET /api/admin/content/unmapped", h.GetUnmappedContent)
+	mux.HandleFunc("POST /api/admin/content/remap", h.RemapContent)
+	mux.HandleFunc("GET /api/admin/tags/unused", h.GetUnusedTags)
+	mux.HandleFunc("DELETE /api/admin/tags/unused", h.DeleteUnusedTags)
+	mux.HandleFunc("GET /api/admin/quality-alerts", h.GetQualityAlerts)
+	mux.HandleFunc("POST /api/admin/quality-alerts/{id}/acknowledge", h.AcknowledgeQualityAlert)
+	mux.HandleFunc("GET /api/admin/export", h.ExportData)
+	mux.HandleFunc("POST /api/admin/import", h.ImportData)
+}
+
+// authorize verifica el token de administración; responde el error si no es válido
+func (h *AdminHandler) authorize(w http.ResponseWriter, r *http.Request) bool {
+	if h.adminToken == "" {
+		http.Error(w, "Endpoints de administración desactivados (ADMIN_TOKEN no configurado)", http.StatusForbidden)
+		return false
+	}
+	token := r.Header.Get(adminTokenHeader)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) != 1 {
+		http.Error(w, "Token de administración inválido", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// GetMaintenance godoc
+// @Summary Consultar modo mantenimiento
+// @Description Devuelve si el sistema está en modo de solo lectura
+// @Tags administracion
+// @Accept json
+// @Produce json
+// @Success 200 {object} domain.MaintenanceStatus
+// @Router /api/admin/maintenance [get]
+func (h *AdminHandler) GetMaintenance(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(domain.CurrentMaintenance())
+}
+
+// SetMaintenance godoc
+// @Summary Activar o desactivar el modo mantenimiento
+// @Description Con enabled=true todas las peticiones que modifican datos responden 503 y las consultas siguen funcionando. Requiere la cabecera X-Admin-Token.
+// @Tags administracion
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Token de administración"
+// @Param maintenance body object true "enabled (bool) y message (opcional)"
+// @Success 200 {object} domain.MaintenanceStatus
+// @Failure 400 {object} map[string]string "Solicitud inválida"
+// @Failure 401 {object} map[string]string "Token inválido"
+// @Failure 403 {object} map[string]string "Administración desactivada"
+// @Router /api/admin/maintenance [put]
+func (h *AdminHandler) SetMaintenance(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	var req struct {
+		Enabled *bool  `json:"enabled"`
+		Message string `json:"message"`
+	}
+
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.Enabled == nil {
+		http.Error(w, "enabled es requerido", http.StatusBadRequest)
+		return
+	}
+
+	status := domain.SetMaintenanceMode(*req.Enabled, req.Message)
+	h.logger.InfoContext(r.Context(), "auditoría: modo mantenimiento actualizado",
+		"enabled", status.Enabled, "remote_addr", r.RemoteAddr)
+
+	// El token de administración no identifica a una persona; X-User-ID es opcional
+	var actor *uuid.UUID
+	if actorID, err := parseActorID(r); err == nil && actorID != uuid.Nil {
+		actor = &actorID
+	}
+	h.auditService.Record(r.Context(), domain.NewAuditEntry(domain.AuditEntityMaintenance, nil, domain.AuditActionToggle, actor,
+		map[string]interface{}{"enabled": status.Enabled, "message": status.Message, "remote_addr": r.RemoteAddr}))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// GetUnclassifiedMeasurements godoc
+// @Summary Listar mediciones sin clasificar
+// @Description Lista las mediciones sin tag o sin recomendación, registradas antes de la auto-asignación. Requiere X-Admin-Token
+// @Tags administracion
+// @Produce json
+// @Param page query int false "Página (default: 1)"
+// @Param page_size query int false "Tamaño de página (default: 20, máx: 200)"
+// @Success 200 {object} ListResponse{data=[]domain.Measurement}
+// @Failure 401 {object} map[string]string "Token inválido"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/admin/measurements/unclassified [get]
+func (h *AdminHandler) GetUnclassifiedMeasurements(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	page, err := parsePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	measurements, err := h.measurementService.GetUnclassified(r.Context(), page)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeList(w, measurements, page)
+}
+
+// GetClassificationMismatches godoc
+// @Summary Listar mediciones con clasificación desactualizada
+// @Description Lista las mediciones cuyo tag o recomendación tiene un código MUAC distinto del que corresponde hoy a su valor, con el código guardado y el esperado. Las que no tienen tag o recomendación se listan en /unclassified. Requiere X-Admin-Token
+// @Tags administracion
+// @Produce json
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param page query int false "Página (default: 1)"
+// @Param page_size query int false "Tamaño de página (default: 20, máx: 200)"
+// @Success 200 {object} ListResponse{data=[]domain.ClassificationMismatch}
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 401 {object} map[string]string "Token inválido"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/admin/measurements/classification-mismatches [get]
+func (h *AdminHandler) GetClassificationMismatches(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	var localityID *uuid.UUID
+	if localityIDStr := r.URL.Query().Get("locality_id"); localityIDStr != "" {
+		id, err := uuid.Parse(localityIDStr)
+		if err != nil {
+			http.Error(w, "locality_id inválido", http.StatusBadRequest)
+			return
+		}
+		localityID = &id
+	}
+
+	page, err := parsePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mismatches, err := h.measurementService.GetClassificationMismatches(r.Context(), localityID, page)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeList(w, mismatches, page)
+}
+
+// BackfillMeasurements godoc
+// @Summary Completar clasificación de mediciones
+// @Description Asigna el tag y la recomendación que correspondan según el valor MUAC a las mediciones que no los tienen, en una transacción que también crea los tags y recomendaciones que falten. Con dry_run=true no crea ni modifica nada y solo informa cuántas se corregirían, incluidas las que necesitan un tag o recomendación nuevos. Requiere X-Admin-Token
+// @Tags administracion
+// @Produce json
+// @Param dry_run query bool false "Simular sin modificar mediciones"
+// @Success 200 {object} domain.MeasurementBackfill
+// @Failure 400 {object} map[string]string "dry_run inválido"
+// @Failure 401 {object} map[string]string "Token inválido"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/admin/measurements/backfill [post]
+func (h *AdminHandler) BackfillMeasurements(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	dryRun := false
+	if dryRunStr := r.URL.Query().Get("dry_run"); dryRunStr != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(dryRunStr)
+		if err != nil {
+			http.Error(w, "dry_run debe ser true o false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	backfill, err := h.measurementService.BackfillClassification(r.Context(), dryRun)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !dryRun {
+		h.logger.InfoContext(r.Context(), "auditoría: clasificación de mediciones completada",
+			"fixed", backfill.Fixed, "skipped", backfill.Skipped, "remote_addr", r.RemoteAddr)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(backfill)
+}
+
+// GetDuplicateDNIs godoc
+// @Summary Listar DNIs duplicados
+// @Description Agrupa los pacientes cuyo DNI coincide al ignorar espacios, puntos, guiones y mayúsculas (la columna dni es única, así que los duplicados llegan con variaciones de formato), para revisarlos o fusionarlos. Requiere X-Admin-Token
+// @Tags administracion
+// @Produce json
+// @Param X-Admin-Token header string true "Token de administración"
+// @Success 200 {object} ListResponse{data=[]domain.DuplicateDNIGroup}
+// @Failure 401 {object} map[string]string "Token inválido"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/admin/patients/duplicate-dnis [get]
+func (h *AdminHandler) GetDuplicateDNIs(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	groups, err := h.patientService.GetDuplicateDNIs(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeList(w, groups, nil)
+}
+
+// GetOrphanedPatients godoc
+// @Summary Listar pacientes sin apoderado activo
+// @Description Lista los pacientes sin apoderado, con un apoderado que ya no existe o que está desactivado, con el motivo (no_caregiver, caregiver_deleted o caregiver_inactive) y su última medición, para reasignarlos con PUT /api/patients/{id} y user_id. Con locality_id solo se incluyen los de apoderados desactivados de esa localidad (sin apoderado no se conoce la localidad). Requiere X-Admin-Token
+// @Tags administracion
+// @Produce json
+// @Param X-Admin-Token header string true "Token de administración"
+// @Param locality_id query string false "ID de la localidad del apoderado desactivado"
+// @Param page query int false "Página (default: 1)"
+// @Param page_size query int false "Tamaño de página (default: 20, máx: 200)"
+// @Success 200 {object} ListResponse{data=[]domain.OrphanedPatient}
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 401 {object} map[string]string "Token inválido"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/admin/patients/orphaned [get]
+func (h *AdminHandler) GetOrphanedPatients(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	var localityID *uuid.UUID
+	if localityIDStr := r.URL.Query().Get("locality_id"); localityIDStr != "" {
+		id, err := uuid.Parse(localityIDStr)
+		if err != nil {
+			http.Error(w, "locality_id inválido", http.StatusBadRequest)
+			return
+		}
+		localityID = &id
+	}
+
+	page, err := parsePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	patients, err := h.patientService.GetOrphaned(r.Context(), localityID, page)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeList(w, patients, page)
+}
+
+// GetUnmappedContent godoc
+// @Summary Listar contenido sin código MUAC
+// @Description Lista los tags y recomendaciones activos sin código MUAC, que no se usan al clasificar mediciones. Requiere X-Admin-Token
+// @Tags administracion
+// @Produce json
+// @Param X-Admin-Token header string true "Token de administración"
+// @Success 200 {object} domain.UnmappedContent
+// @Failure 401 {object} map[string]string "Token inválido"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/admin/content/unmapped [get]
+func (h *AdminHandler) GetUnmappedContent(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	unmapped, err := h.contentService.GetUnmapped(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(unmapped)
+}
+
+// RemapContent godoc
+// @Summary Reasignar códigos MUAC al contenido
+// @Description Infiere el código MUAC (con su color y prioridad) de los tags y recomendaciones activos sin código a partir del nombre, con las mismas reglas que la reparación al iniciar. Con dry_run=true solo informa qué cambiaría. Requiere X-Admin-Token
+// @Tags administracion
+// @Produce json
+// @Param X-Admin-Token header string true "Token de administración"
+// @Param dry_run query bool false "Simular sin modificar el contenido"
+// @Success 200 {object} domain.ContentRemap
+// @Failure 400 {object} map[string]string "dry_run inválido"
+// @Failure 401 {object} map[string]string "Token inválido"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/admin/content/remap [post]
+func (h *AdminHandler) RemapContent(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	dryRun := false
+	if dryRunStr := r.URL.Query().Get("dry_run"); dryRunStr != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(dryRunStr)
+		if err != nil {
+			http.Error(w, "dry_run debe ser true o false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	remap, err := h.contentService.RemapMuacCodes(r.Context(), dryRun)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !dryRun {
+		h.logger.InfoContext(r.Context(), "auditoría: códigos MUAC del contenido reasignados",
+			"tags", remap.Tags, "recommendations", remap.Recommendations, "unmatched", remap.Unmatched, "remote_addr", r.RemoteAddr)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(remap)
+}
+
+// GetUnusedTags godoc
+// @Summary Listar etiquetas sin mediciones
+// @Description Lista las etiquetas activas que ninguna medición referencia. Las etiquetas base de MUAC (rojo, amarillo, verde y seguimiento) se listan aparte en protected porque no se eliminan. Requiere X-Admin-Token
+// @Tags administracion
+// @Produce json
+// @Param X-Admin-Token header string true "Token de administración"
+// @Success 200 {object} domain.UnusedTags
+// @Failure 401 {object} map[string]string "Token inválido"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/admin/tags/unused [get]
+func (h *AdminHandler) GetUnusedTags(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	unused, err := h.contentService.GetUnusedTags(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(unused)
+}
+
+// DeleteUnusedTags godoc
+// @Summary Eliminar etiquetas sin mediciones
+// @Description Elimina en una transacción las etiquetas activas sin mediciones, omitiendo las etiquetas base de MUAC. Una etiqueta que recibió una medición mientras tanto se conserva. Requiere X-Admin-Token
+// @Tags administracion
+// @Produce json
+// @Param X-Admin-Token header string true "Token de administración"
+// @Success 200 {object} domain.UnusedTagCleanup
+// @Failure 401 {object} map[string]string "Token inválido"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/admin/tags/unused [delete]
+func (h *AdminHandler) DeleteUnusedTags(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	cleanup, err := h.contentService.DeleteUnusedTags(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.InfoContext(r.Context(), "auditoría: etiquetas sin mediciones eliminadas",
+		"deleted", cleanup.Deleted, "protected", len(cleanup.Protected), "remote_addr", r.RemoteAddr)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cleanup)
+}
+
+// GetQualityAlerts godoc
+// @Summary Feed de alertas de calidad de datos
+// @Description Reúne en un solo lugar las mediciones marcadas, las clasificaciones que no coinciden con el valor MUAC, los DNIs duplicados y el contenido sin código MUAC. Por categoría devuelve la cantidad de alertas sin reconocer, cuántas ya se reconocieron y algunos registros de ejemplo. Requiere X-Admin-Token
+// @Tags administracion
+// @Produce json
+// @Param X-Admin-Token header string true "Token de administración"
+// @Param samples query int false "Registros de ejemplo por categoría (default: 5, máx: 50)"
+// @Success 200 {object} domain.QualityAlertFeed
+// @Failure 400 {object} map[string]string "samples inválido"
+// @Failure 401 {object} map[string]string "Token inválido"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/admin/quality-alerts [get]
+func (h *AdminHandler) GetQualityAlerts(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	samples := domain.DefaultQualityAlertSamples
+	if samplesStr := r.URL.Query().Get("samples"); samplesStr != "" {
+		var err error
+		samples, err = strconv.Atoi(samplesStr)
+		if err != nil || samples < 1 || samples > domain.MaxQualityAlertSamples {
+			http.Error(w, "samples debe estar entre 1 y "+strconv.Itoa(domain.MaxQualityAlertSamples), http.StatusBadRequest)
+			return
+		}
+	}
+
+	feed, err := h.qualityService.GetFeed(r.Context(), samples)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(feed)
+}
+
+// AcknowledgeQualityAlert godoc
+// @Summary Reconocer una alerta de calidad
+// @Description Marca la alerta como revisada para que deje de aparecer en el feed. El ID es el que devuelve el feed (categoría:clave); solo se pueden reconocer alertas vigentes. Volver a reconocerla actualiza la nota. Requiere X-Admin-Token; X-User-ID es opcional
+// @Tags administracion
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Token de administración"
+// @Param id path string true "ID de la alerta"
+// @Param acknowledgement body object false "note (opcional)"
+// @Success 200 {object} domain.QualityAlertAck
+// @Failure 400 {object} map[string]string "ID o cuerpo inválido"
+// @Failure 401 {object} map[string]string "Token inválido"
+// @Failure 404 {object} map[string]string "La alerta no existe o ya se resolvió"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/admin/quality-alerts/{id}/acknowledge [post]
+func (h *AdminHandler) AcknowledgeQualityAlert(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	var req struct {
+		Note string `json:"note"`
+	}
+	if r.ContentLength != 0 && !decodeJSON(w, r, &req) {
+		return
+	}
+
+	var actor *uuid.UUID
+	if actorID, err := parseActorID(r); err == nil && actorID != uuid.Nil {
+		actor = &actorID
+	}
+
+	ack, err := h.qualityService.Acknowledge(r.Context(), r.PathValue("id"), req.Note, actor)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidQualityAlertID):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, domain.ErrQualityAlertNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.logger.InfoContext(r.Context(), "auditoría: alerta de calidad reconocida",
+		"alert_id", ack.AlertID, "remote_addr", r.RemoteAddr)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ack)
+}
+
+// ExportData godoc
+// @Summary Exportar todos los datos del programa
+// @Description Descarga un JSON con roles, localidades, etiquetas, recomendaciones, FAQs, usuarios (sin contraseñas), pacientes y mediciones, con sus IDs, para respaldo o migración entre entornos. Formato: {"format":"muac-export","version":1,"exported_at":"...","entities":{"roles":[...],...}}, con cada fila como objeto de columnas y las entidades en orden de dependencia. Se genera en streaming, leyendo todas las tablas de una misma instantánea de la base. Requiere X-Admin-Token
+// @Tags administracion
+// @Produce json
+// @Param X-Admin-Token header string true "Token de administración"
+// @Success 200 {file} file "muac_export_<fecha>.json"
+// @Failure 401 {object} map[string]string "Token inválido"
+// @Failure 403 {object} map[string]string "Administración desactivada"
+// @Router /api/admin/export [get]
+func (h *AdminHandler) ExportData(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	filename := fmt.Sprintf("muac_export_%s.json", time.Now().Format("2006-01-02_15-04-05"))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+
+	h.logger.InfoContext(r.Context(), "auditoría: exportación completa de datos", "remote_addr", r.RemoteAddr)
+
+	// Las cabeceras ya se enviaron: un error deja el archivo incompleto, que la importación rechaza
+	if err := h.backupService.Export(r.Context(), w); err != nil {
+		h.logger.ErrorContext(r.Context(), "error al exportar los datos", "error", err)
+	}
+}
+
+// ImportData godoc
+// @Summary Importar un archivo de exportación
+// @Description Aplica en una sola transacción un archivo generado por GET /api/admin/export, en orden de dependencia y conservando los IDs: las filas nuevas se insertan y las que ya existen con el mismo ID se sobrescriben, por lo que reimportar el mismo archivo es idempotente. Antes de guardar se verifica que cada referencia (rol, localidad, apoderado, paciente, etiqueta, recomendación) apunte a una fila existente o importada; si alguna está rota no se guarda nada y se responde 422 con el detalle. Con dry_run=true se valida e importa todo y se revierte al final. Los hashes de contraseña del archivo se descartan salvo con allow_password_hashes=true; los usuarios nuevos sin contraseña no pueden iniciar sesión hasta asignarles una con PUT /api/users/{id}/password. Se admite en modo mantenimiento. Requiere X-Admin-Token
+// @Tags administracion
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "Token de administración"
+// @Param dry_run query bool false "Validar sin guardar"
+// @Param allow_password_hashes query bool false "Importar los hashes de contraseña del archivo (solo de orígenes confiables)"
+// @Param backup body object true "Archivo de exportación"
+// @Success 200 {object} domain.BackupImportResult
+// @Failure 400 {object} map[string]string "Archivo o parámetros inválidos"
+// @Failure 401 {object} map[string]string "Token inválido"
+// @Failure 403 {object} map[string]string "Administración desactivada"
+// @Failure 422 {object} domain.BackupImportResult "Referencias rotas; no se guardó nada"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/admin/import [post]
+func (h *AdminHandler) ImportData(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	var opts domain.BackupImportOptions
+	query := r.URL.Query()
+	if dryRunStr := query.Get("dry_run"); dryRunStr != "" {
+		var err error
+		opts.DryRun, err = strconv.ParseBool(dryRunStr)
+		if err != nil {
+			http.Error(w, "dry_run debe ser true o false", http.StatusBadRequest)
+			return
+		}
+	}
+	if allowStr := query.Get("allow_password_hashes"); allowStr != "" {
+		var err error
+		opts.AllowSensitiveColumns, err = strconv.ParseBool(allowStr)
+		if err != nil {
+			http.Error(w, "allow_password_hashes debe ser true o false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := h.backupService.Import(r.Context(), r.Body, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrBackupIntegrity):
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(result)
+		case errors.Is(err, domain.ErrInvalidBackup),
+			errors.Is(err, domain.ErrUnsupportedBackupVersion),
+			errors.Is(err, domain.ErrUnknownBackupEntity),
+			errors.Is(err, domain.ErrBackupEntityOrder),
+			errors.Is(err, domain.ErrUnknownBackupColumn):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if result.Committed {
+		h.logger.InfoContext(r.Context(), "auditoría: importación de datos",
+			"entities", len(result.Entities), "password_hashes", opts.AllowSensitiveColumns, "remote_addr", r.RemoteAddr)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,132 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+)
+
+// AuditHandler maneja las consultas de la auditoría
+type AuditHandler struct {
+	auditService ports.IAuditService
+}
+
+// NewAuditHandler crea una nueva instancia de AuditHandler
+func NewAuditHandler(auditService ports.IAuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// RegisterRoutes registra las rutas del manejador
+func (h *AuditHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/audit", h.ListAuditEntries)
+}
+
+// ListAuditEntries godoc
+// @Summary Consultar la auditoría
+// @Description Lista quién cambió qué y cuándo, las entradas más recientes primero. Solo para usuarios ADMINISTRADOR (cabecera X-User-ID)
+// @Tags auditoría
+// @Produce json
+// @Param X-User-ID header string true "Usuario ADMINISTRADOR que consulta"
+// @Param entity query string false "patient, measurement, maintenance o user"
+// @Param entity_id query string false "ID de la entidad"
+// @Param actor query string false "ID del usuario que realizó el cambio"
+// @Param action query string false "update, delete, consent, toggle, approval o recompute"
+// @Param start_date query string false "Desde (YYYY-MM-DD, inclusive)"
+// @Param end_date query string false "Hasta (YYYY-MM-DD, inclusive)"
+// @Param page query int false "Página (por defecto 1)"
+// @Param page_size query int false "Tamaño de página (por defecto 20, máximo 200)"
+// @Success 200 {object} ListResponse{data=[]domain.AuditEntry}
+// @Failure 400 {object} map[string]string "Filtro inválido"
+// @Failure 403 {object} map[string]string "El usuario no es ADMINISTRADOR"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/audit [get]
+func (h *AuditHandler) ListAuditEntries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	actorID, err := parseActorID(r)
+	if err != nil {
+		http.Error(w, "X-User-ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.auditService.List(ctx, actorID, filter, page)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAuditForbidden):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, domain.ErrInvalidAuditEntity),
+			errors.Is(err, domain.ErrInvalidAuditAction),
+			errors.Is(err, domain.ErrInvalidAuditDateRange):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeList(w, entries, page)
+}
+
+// parseAuditFilter lee los filtros de la consulta de auditoría
+func parseAuditFilter(r *http.Request) (*domain.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := &domain.AuditFilter{
+		Entity: query.Get("entity"),
+		Action: query.Get("action"),
+	}
+
+	if value := query.Get("entity_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("entity_id inválido: %v", err)
+		}
+		filter.EntityID = &id
+	}
+
+	if value := query.Get("actor"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("actor inválido: %v", err)
+		}
+		filter.ActorID = &id
+	}
+
+	if value := query.Get("start_date"); value != "" {
+		start, err := time.ParseInLocation(domain.AuditDateLayout, value, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("start_date debe tener el formato YYYY-MM-DD")
+		}
+		filter.StartDate = &start
+	}
+
+	if value := query.Get("end_date"); value != "" {
+		end, err := time.ParseInLocation(domain.AuditDateLayout, value, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("end_date debe tener el formato YYYY-MM-DD")
+		}
+		// end_date es inclusivo: se filtra hasta el inicio del día siguiente
+		end = end.AddDate(0, 0, 1)
+		filter.EndDate = &end
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,41 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+)
+
+// ConfigHandler expone la configuración que necesitan las aplicaciones cliente
+type ConfigHandler struct{}
+
+// NewConfigHandler crea una nueva instancia de ConfigHandler
+func NewConfigHandler() *ConfigHandler {
+	return &ConfigHandler{}
+}
+
+// RegisterRoutes registra las rutas del manejador
+func (h *ConfigHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/config", h.GetClientConfig)
+}
+
+// GetClientConfig godoc
+// @Summary Obtener configuración del cliente
+// @Description Obtiene los parámetros que el cliente debe respetar (rango de edad, umbrales MUAC)
+// @Tags configuracion
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/config [get]
+func (h *ConfigHandler) GetClientConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"patient_age_range": domain.PatientAgeRange,
+		"muac_thresholds": map[string]float64{
+			"severe_malnutrition":   domain.MuacThresholdSevere,
+			"moderate_malnutrition": domain.MuacThresholdModerate,
+			"normal_nutrition":      domain.MuacThresholdNormal,
+		},
+	})
+}
@@ -2,7 +2,10 @@ package http
 
 import (
 	"encoding/json"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
@@ -11,13 +14,17 @@ import (
 
 // FAQHandler maneja las peticiones HTTP relacionadas con preguntas frecuentes
 type FAQHandler struct {
-	faqService ports.IFAQRepository
+	faqService   ports.IFAQRepository
+	excelService ports.IFileService
+	logger       *slog.Logger
 }
 
 // NewFAQHandler crea una nueva instancia de FAQHandler
-func NewFAQHandler(faqService ports.IFAQRepository) *FAQHandler {
+func NewFAQHandler(faqService ports.IFAQRepository, excelService ports.IFileService, logger *slog.Logger) *FAQHandler {
 	return &FAQHandler{
-		faqService: faqService,
+		faqService:   faqService,
+		excelService: excelService,
+		logger:       logger,
 	}
 }
 
@@ -25,6 +32,7 @@ func NewFAQHandler(faqService ports.IFAQRepository) *FAQHandler {
 func (h *FAQHandler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /api/faqs", h.GetAllFAQs)
 	mux.HandleFunc("POST /api/faqs", h.CreateFAQ)
+	mux.HandleFunc("GET /api/faqs/excel", h.GetFAQsExcel)
 	mux.HandleFunc("GET /api/faqs/{id}", h.GetFAQByID)
 	mux.HandleFunc("PUT /api/faqs/{id}", h.UpdateFAQ)
 	mux.HandleFunc("DELETE /api/faqs/{id}", h.DeleteFAQ)
@@ -36,7 +44,7 @@ func (h *FAQHandler) RegisterRoutes(mux *http.ServeMux) {
 // @Tags faqs
 // @Accept json
 // @Produce json
-// @Success 200 {array} domain.FAQ
+// @Success 200 {object} ListResponse{data=[]domain.FAQ}
 // @Failure 500 {object} map[string]string "Error interno del servidor"
 // @Router /api/faqs [get]
 func (h *FAQHandler) GetAllFAQs(w http.ResponseWriter, r *http.Request) {
@@ -48,8 +56,7 @@ func (h *FAQHandler) GetAllFAQs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(faqs)
+	writeList(w, faqs, nil)
 }
 
 // GetFAQByID godoc
@@ -113,8 +120,7 @@ func (h *FAQHandler) CreateFAQ(w http.ResponseWriter, r *http.Request) {
 		Category string `json:"category"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Solicitud inválida", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
@@ -173,8 +179,7 @@ func (h *FAQHandler) UpdateFAQ(w http.ResponseWriter, r *http.Request) {
 		Category string `json:"category"`
 	}
 
-	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Solicitud inválida", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
@@ -241,3 +246,37 @@ func (h *FAQHandler) DeleteFAQ(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// GetFAQsExcel godoc
+// @Summary Exportar preguntas frecuentes a Excel
+// @Description Descarga todas las FAQs agrupadas por categoría en un Excel listo para imprimir
+// @Tags faqs
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Success 200 {file} file
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/faqs/excel [get]
+func (h *FAQHandler) GetFAQsExcel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	groups, err := h.faqService.GetAllGroupedByCategory(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	excelData, err := h.excelService.GenerateFAQsReport(ctx, groups)
+	if err != nil {
+		http.Error(w, "Error al generar Excel de FAQs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("preguntas_frecuentes_%s.xlsx", time.Now().Format("2006-01-02_15-04-05"))
+
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(excelData)))
+
+	if _, err := w.Write(excelData); err != nil {
+		h.logger.ErrorContext(ctx, "error al escribir archivo Excel", "filename", filename, "error", err)
+	}
+}
@@ -2,8 +2,14 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/google/uuid"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
@@ -13,12 +19,18 @@ import (
 // LocalityHandler maneja las peticiones HTTP relacionadas con localidades
 type LocalityHandler struct {
 	localityService ports.ILocalityService
+	userService     ports.IUserService
+	excelService    ports.IFileService
+	logger          *slog.Logger
 }
 
 // NewLocalityHandler crea una nueva instancia de LocalityHandler
-func NewLocalityHandler(localityService ports.ILocalityService) *LocalityHandler {
+func NewLocalityHandler(localityService ports.ILocalityService, userService ports.IUserService, excelService ports.IFileService, logger *slog.Logger) *LocalityHandler {
 	return &LocalityHandler{
 		localityService: localityService,
+		userService:     userService,
+		excelService:    excelService,
+		logger:          logger,
 	}
 }
 
@@ -26,11 +38,36 @@ func NewLocalityHandler(localityService ports.ILocalityService) *LocalityHandler
 func (h *LocalityHandler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /api/localities", h.GetAllLocalities)
 	mux.HandleFunc("POST /api/localities", h.CreateLocality)
+	mux.HandleFunc("POST /api/localities/validate-coordinates", h.ValidateCoordinates)
 	mux.HandleFunc("GET /api/localities/{id}", h.GetLocalityByID)
 	mux.HandleFunc("PUT /api/localities/{id}", h.UpdateLocality)
+	mux.HandleFunc("PUT /api/localities/{id}/target", h.SetLocalityTarget)
 	mux.HandleFunc("DELETE /api/localities/{id}", h.DeleteLocality)
 	mux.HandleFunc("GET /api/localities/name/{name}", h.GetLocalityByName)
 	mux.HandleFunc("GET /api/localities/nearby", h.GetNearbyLocalities)
+	mux.HandleFunc("GET /api/localities/nearby-with-risk", h.GetNearbyLocalitiesWithRisk)
+	mux.HandleFunc("GET /api/localities/{id}/{resource}", h.routeLocalityResource)
+	mux.HandleFunc("GET /api/localities/{id}/patients/excel", h.GetLocalityPatientsExcel)
+}
+
+// localityResources agrupa las subrutas GET /api/localities/{id}/{resource}.
+// Se despachan desde un único patrón porque chocarían en el ServeMux con /api/localities/name/{name}.
+func (h *LocalityHandler) localityResources() map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"measurements":        h.GetLocalityMeasurements,
+		"caregivers":          h.GetLocalityCaregivers,
+		"patient-percentiles": h.GetLocalityPatientPercentiles,
+	}
+}
+
+// routeLocalityResource despacha la subruta solicitada de la localidad
+func (h *LocalityHandler) routeLocalityResource(w http.ResponseWriter, r *http.Request) {
+	handler, ok := h.localityResources()[r.PathValue("resource")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	handler(w, r)
 }
 
 // GetAllLocalities godoc
@@ -39,7 +76,7 @@ func (h *LocalityHandler) RegisterRoutes(mux *http.ServeMux) {
 // @Tags localidades
 // @Accept json
 // @Produce json
-// @Success 200 {array} domain.Locality
+// @Success 200 {object} ListResponse{data=[]domain.Locality}
 // @Failure 500 {object} map[string]string "Error interno del servidor"
 // @Router /api/localities [get]
 func (h *LocalityHandler) GetAllLocalities(w http.ResponseWriter, r *http.Request) {
@@ -51,19 +88,18 @@ func (h *LocalityHandler) GetAllLocalities(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(localities)
+	writeList(w, localities, nil)
 }
 
 // CreateLocality godoc
 // @Summary Crear una nueva localidad
-// @Description Crea una nueva localidad con la información proporcionada
+// @Description Crea una nueva localidad con la información proporcionada. Si se informan coordenadas, se validan y normalizan igual que en /api/localities/validate-coordinates
 // @Tags localidades
 // @Accept json
 // @Produce json
 // @Param locality body object true "Datos de la localidad"
 // @Success 201 {object} domain.Locality
-// @Failure 400 {object} map[string]string "Solicitud inválida"
+// @Failure 400 {object} map[string]string "Solicitud inválida o coordenadas fuera de rango/región"
 // @Failure 500 {object} map[string]string "Error interno del servidor"
 // @Router /api/localities [post]
 func (h *LocalityHandler) CreateLocality(w http.ResponseWriter, r *http.Request) {
@@ -78,8 +114,7 @@ func (h *LocalityHandler) CreateLocality(w http.ResponseWriter, r *http.Request)
 		IsMedicalCenter bool   `json:"is_medical_center"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Solicitud inválida", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
@@ -93,7 +128,14 @@ func (h *LocalityHandler) CreateLocality(w http.ResponseWriter, r *http.Request)
 	)
 
 	if err := h.localityService.Create(ctx, locality); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, domain.ErrEmptyLocalityName),
+			errors.Is(err, domain.ErrInvalidCoordinates),
+			errors.Is(err, domain.ErrCoordinatesOutsideRegion):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -102,6 +144,30 @@ func (h *LocalityHandler) CreateLocality(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(locality)
 }
 
+// ValidateCoordinates godoc
+// @Summary Validar coordenadas de una localidad
+// @Description Lee latitud y longitud (texto, admite coma decimal), comprueba los rangos y si caen en la región del programa; detecta coordenadas invertidas. Es la misma validación que aplica la creación de localidades
+// @Tags localidades
+// @Accept json
+// @Produce json
+// @Param coordinates body object true "Latitud y longitud" example({"latitude":"-12.5933","longitude":"-69.1891"})
+// @Success 200 {object} domain.CoordinateValidation
+// @Failure 400 {object} map[string]string "Cuerpo inválido"
+// @Router /api/localities/validate-coordinates [post]
+func (h *LocalityHandler) ValidateCoordinates(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Latitude  string `json:"latitude"`
+		Longitude string `json:"longitude"`
+	}
+
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(domain.ValidateCoordinates(req.Latitude, req.Longitude))
+}
+
 // GetLocalityByID godoc
 // @Summary Obtener una localidad por ID
 // @Description Obtiene una localidad específica por su ID
@@ -180,8 +246,7 @@ func (h *LocalityHandler) UpdateLocality(w http.ResponseWriter, r *http.Request)
 		IsMedicalCenter *bool  `json:"is_medical_center"`
 	}
 
-	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Solicitud inválida", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
@@ -213,6 +278,63 @@ func (h *LocalityHandler) UpdateLocality(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(locality)
 }
 
+// SetLocalityTarget godoc
+// @Summary Fijar la meta mensual de mediciones de una localidad
+// @Description Crea o reemplaza la meta de mediciones por mes de la localidad; se aplica a todos los meses y el avance se consulta en /api/reports/target-progress
+// @Tags localidades
+// @Accept json
+// @Produce json
+// @Param id path string true "ID de la localidad"
+// @Param X-User-ID header string false "Usuario que fija la meta"
+// @Param target body object true "Meta mensual" example({"monthly_target": 120})
+// @Success 200 {object} domain.LocalityTarget
+// @Failure 400 {object} map[string]string "ID o meta inválida"
+// @Failure 404 {object} map[string]string "Localidad no encontrada"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/localities/{id}/target [put]
+func (h *LocalityHandler) SetLocalityTarget(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	actorID, err := parseActorID(r)
+	if err != nil {
+		http.Error(w, "X-User-ID inválido", http.StatusBadRequest)
+		return
+	}
+	var updatedBy *uuid.UUID
+	if actorID != uuid.Nil {
+		updatedBy = &actorID
+	}
+
+	var req struct {
+		MonthlyTarget int `json:"monthly_target"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	target, err := h.localityService.SetTarget(ctx, id, req.MonthlyTarget, updatedBy)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrLocalityNotFound):
+			http.Error(w, "Localidad no encontrada", http.StatusNotFound)
+		case errors.Is(err, domain.ErrInvalidLocalityTarget):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(target)
+}
+
 // DeleteLocality godoc
 // @Summary Eliminar una localidad
 // @Description Elimina una localidad por su ID
@@ -336,6 +458,250 @@ func (h *LocalityHandler) GetNearbyLocalities(w http.ResponseWriter, r *http.Req
 		localities = []domain.Locality{}
 	}
 
+	writeList(w, localities, nil)
+}
+
+// GetNearbyLocalitiesWithRisk godoc
+// @Summary Localidades cercanas con pacientes en riesgo
+// @Description Lista las localidades dentro del radio, de la más cercana a la más lejana, con la cantidad de pacientes en riesgo (severo y moderado) según su última medición. Un paciente pertenece a la localidad de su apoderado
+// @Tags localidades
+// @Produce json
+// @Param lat query string true "Latitud del punto"
+// @Param lng query string true "Longitud del punto"
+// @Param radius_km query number false "Radio en km (por defecto 10, máximo 200)"
+// @Success 200 {object} map[string]interface{} "data: []domain.NearbyLocalityRisk"
+// @Failure 400 {object} map[string]string "Coordenadas o radio inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/localities/nearby-with-risk [get]
+func (h *LocalityHandler) GetNearbyLocalitiesWithRisk(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	point := domain.ValidateCoordinates(query.Get("lat"), query.Get("lng"))
+	if !point.Valid {
+		http.Error(w, strings.Join(point.Errors, "; "), http.StatusBadRequest)
+		return
+	}
+
+	radius := domain.DefaultNearbyRadiusKm
+	if value := query.Get("radius_km"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 || parsed > domain.MaxNearbyRadiusKm {
+			http.Error(w, fmt.Sprintf("radius_km debe ser mayor a 0 y no exceder %g", domain.MaxNearbyRadiusKm), http.StatusBadRequest)
+			return
+		}
+		radius = parsed
+	}
+
+	localities, err := h.localityService.FindNearbyWithRisk(ctx, *point.Latitude, *point.Longitude, radius)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeList(w, localities, nil)
+}
+
+// GetLocalityMeasurements godoc
+// @Summary Mediciones de una localidad
+// @Description Lista, de la más reciente a la más antigua, las mediciones de los pacientes cuyos apoderados pertenecen a la localidad, con nombres y clasificación MUAC
+// @Tags localidades
+// @Accept json
+// @Produce json
+// @Param id path string true "ID de la localidad"
+// @Param days query int false "Últimos N días (por defecto 30, 0 para todo el historial, máximo 365)"
+// @Param page query int false "Página (por defecto 1)"
+// @Param page_size query int false "Tamaño de página"
+// @Success 200 {object} ListResponse{data=[]domain.LocalityMeasurement}
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 404 {object} map[string]string "Localidad no encontrada"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/localities/{id}/measurements [get]
+func (h *LocalityHandler) GetLocalityMeasurements(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	days := 30
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		days, err = strconv.Atoi(daysStr)
+		if err != nil || days < 0 || days > 365 {
+			http.Error(w, "days debe ser un número entre 0 y 365", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := parsePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	measurements, err := h.localityService.GetMeasurements(ctx, id, days, page)
+	if err != nil {
+		if errors.Is(err, domain.ErrLocalityNotFound) {
+			http.Error(w, "Localidad no encontrada", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeList(w, measurements, page)
+}
+
+// GetLocalityCaregivers godoc
+// @Summary Apoderados activos en una localidad
+// @Description Lista los usuarios con rol APODERADO de la localidad con su cantidad de pacientes asignados y la fecha de su última medición
+// @Tags localidades
+// @Accept json
+// @Produce json
+// @Param id path string true "ID de la localidad"
+// @Param sort query string false "Orden: name (por defecto), patient_load (más pacientes primero) o last_activity"
+// @Success 200 {object} ListResponse{data=[]domain.CaregiverLoad}
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 404 {object} map[string]string "Localidad no encontrada"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/localities/{id}/caregivers [get]
+func (h *LocalityHandler) GetLocalityCaregivers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.localityService.GetByID(ctx, id); err != nil {
+		if errors.Is(err, domain.ErrLocalityNotFound) {
+			http.Error(w, "Localidad no encontrada", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	caregivers, err := h.userService.GetCaregiverLoads(ctx, id, r.URL.Query().Get("sort"))
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidCaregiverSort) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeList(w, caregivers, nil)
+}
+
+// GetLocalityPatientPercentiles godoc
+// @Summary Percentiles de MUAC de los pacientes de una localidad
+// @Description Ordena a los niños de la localidad por el valor de su última medición MUAC, del menor (peor) al mayor, con su rango y su percentil entre los niños medidos de la localidad, para priorizar en términos relativos además de los umbrales.
+// @Description Los empates comparten el rango y el percentil (rango medio). Los niños sin mediciones se cuentan en without_measurements; con menos de 10 niños medidos se marca small_sample
+// @Tags localidades
+// @Produce json
+// @Param id path string true "ID de la localidad"
+// @Success 200 {object} domain.LocalityPercentileReport
+// @Failure 400 {object} map[string]string "ID inválido"
+// @Failure 404 {object} map[string]string "Localidad no encontrada"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/localities/{id}/patient-percentiles [get]
+func (h *LocalityHandler) GetLocalityPatientPercentiles(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.localityService.GetPatientPercentiles(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrLocalityNotFound) {
+			http.Error(w, "Localidad no encontrada", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(localities)
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetLocalityPatientsExcel godoc
+// @Summary Exportar padrón de pacientes de una localidad a Excel
+// @Description Genera un Excel con los pacientes de la localidad, su último MUAC, clasificación, días desde la última medición y apoderado. Las filas se colorean según la clasificación
+// @Tags localidades
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param id path string true "ID de la localidad"
+// @Param as_of query string false "Fecha de corte (YYYY-MM-DD), por defecto hoy"
+// @Success 200 {file} file "Archivo Excel"
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 404 {object} map[string]string "Localidad no encontrada"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/localities/{id}/patients/excel [get]
+func (h *LocalityHandler) GetLocalityPatientsExcel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	asOf := time.Now()
+	if asOfStr := r.URL.Query().Get("as_of"); asOfStr != "" {
+		day, err := time.ParseInLocation("2006-01-02", asOfStr, time.Local)
+		if err != nil {
+			http.Error(w, "Formato de fecha inválido, use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		// Se incluye todo el día indicado
+		asOf = day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	roster, err := h.localityService.GetRoster(ctx, id, asOf)
+	if err != nil {
+		if errors.Is(err, domain.ErrLocalityNotFound) {
+			http.Error(w, "Localidad no encontrada", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	excelData, err := h.excelService.GenerateLocalityRosterReport(ctx, roster)
+	if err != nil {
+		http.Error(w, "Error al generar Excel del padrón: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("padron_%s_%s.xlsx", fileNameSafe(roster.Locality.Name), asOf.Format("2006-01-02"))
+
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(excelData)))
+
+	if _, err := w.Write(excelData); err != nil {
+		h.logger.ErrorContext(ctx, "error al escribir archivo Excel", "filename", filename, "locality_id", id, "error", err)
+	}
+}
+
+// fileNameSafe reduce un nombre a letras, dígitos y guiones bajos para usarlo en Content-Disposition
+func fileNameSafe(name string) string {
+	safe := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return '_'
+	}, strings.TrimSpace(name))
+	if safe == "" {
+		return "localidad"
+	}
+	return safe
 }
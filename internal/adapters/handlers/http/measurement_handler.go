@@ -3,7 +3,11 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -36,39 +40,108 @@ func (h *MeasurementHandler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /api/measurements/tag/{tagId}", h.GetMeasurementsByTagID)
 	mux.HandleFunc("GET /api/measurements/recommendation/{recommendationId}", h.GetMeasurementsByRecommendationID)
 	mux.HandleFunc("GET /api/measurements/date-range", h.GetMeasurementsByDateRange)
+	mux.HandleFunc("GET /api/measurements/search", h.SearchMeasurements)
 	mux.HandleFunc("PUT /api/measurements/{id}/tag/{tagId}", h.AssignTag)
 	mux.HandleFunc("PUT /api/measurements/{id}/recommendation/{recommendationId}", h.AssignRecommendation)
+	mux.HandleFunc("GET /api/measurements/{id}/{resource}", h.routeMeasurementResource)
+	mux.HandleFunc("PUT /api/measurements/{id}/flag", h.FlagMeasurement)
+	mux.HandleFunc("PUT /api/measurements/{id}/unflag", h.UnflagMeasurement)
+	mux.HandleFunc("GET /api/sync/measurements", h.SyncMeasurements)
+	mux.HandleFunc("POST /api/muac/classify-batch", h.ClassifyMuacBatch)
+}
+
+// measurementResources agrupa las subrutas GET /api/measurements/{id}/{resource}.
+// Se despachan desde un único patrón porque chocarían en el ServeMux con /api/measurements/patient/{patientId}
+// y el resto de listados por relación.
+func (h *MeasurementHandler) measurementResources() map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"thresholds": h.GetMeasurementThresholds,
+	}
+}
+
+// routeMeasurementResource despacha la subruta solicitada de la medición
+func (h *MeasurementHandler) routeMeasurementResource(w http.ResponseWriter, r *http.Request) {
+	handler, ok := h.measurementResources()[r.PathValue("resource")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	handler(w, r)
 }
 
 // GetAllMeasurements godoc
 // @Summary Obtener todas las mediciones
-// @Description Obtiene una lista de todas las mediciones registradas en el sistema
+// @Description Obtiene una lista de todas las mediciones registradas en el sistema. Con patient_ids devuelve solo las de esos pacientes
 // @Tags mediciones
 // @Accept json
 // @Produce json
-// @Success 200 {array} domain.Measurement
+// @Param patient_ids query string false "IDs de pacientes separados por coma (máximo 50)"
+// @Success 200 {object} ListResponse{data=[]domain.Measurement}
+// @Failure 400 {object} map[string]string "IDs inválidos o demasiados IDs"
 // @Failure 500 {object} map[string]string "Error interno del servidor"
 // @Router /api/measurements [get]
 func (h *MeasurementHandler) GetAllMeasurements(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	if patientIDsStr := r.URL.Query().Get("patient_ids"); patientIDsStr != "" {
+		h.getMeasurementsByPatientIDs(w, r, patientIDsStr)
+		return
+	}
+
 	measurements, err := h.measurementService.GetAll(ctx)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(measurements)
+	writeList(w, measurements, nil)
+}
+
+// getMeasurementsByPatientIDs atiende GET /api/measurements?patient_ids=a,b,c
+func (h *MeasurementHandler) getMeasurementsByPatientIDs(w http.ResponseWriter, r *http.Request, patientIDsStr string) {
+	var patientIDs []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, part := range strings.Split(patientIDsStr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := uuid.Parse(part)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("ID de paciente inválido: %s", part), http.StatusBadRequest)
+			return
+		}
+		if !seen[id] {
+			seen[id] = true
+			patientIDs = append(patientIDs, id)
+		}
+	}
+
+	if len(patientIDs) > domain.MaxPatientIDsPerQuery {
+		http.Error(w, fmt.Sprintf("Se permiten como máximo %d pacientes por consulta", domain.MaxPatientIDsPerQuery), http.StatusBadRequest)
+		return
+	}
+
+	measurements, err := h.measurementService.GetByPatientIDs(r.Context(), patientIDs)
+	if err != nil {
+		http.Error(w, err.Error(), measurementErrorStatus(err))
+		return
+	}
+
+	writeList(w, measurements, nil)
 }
 
 // GetMeasurementByID godoc
 // @Summary Obtener una medición por ID
-// @Description Obtiene una medición específica por su ID
+// @Description Obtiene una medición específica por su ID. Con expand se controla el detalle de las relaciones:
+// @Description all (por defecto) incluye paciente, usuario, etiqueta y recomendación; none devuelve solo la medición;
+// @Description una lista separada por comas (patient,user,tag,recommendation) incluye solo esas relaciones;
+// @Description detail devuelve la misma estructura que POST /api/patients/{id}/measurements
 // @Tags mediciones
 // @Accept json
 // @Produce json
 // @Param id path string true "ID de la medición"
+// @Param expand query string false "all, none, detail o lista de relaciones (patient,user,tag,recommendation)"
 // @Success 200 {object} domain.Measurement
 // @Failure 400 {object} map[string]string "ID inválido o no proporcionado"
 // @Failure 404 {object} map[string]string "Medición no encontrada"
@@ -89,6 +162,16 @@ func (h *MeasurementHandler) GetMeasurementByID(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	expand := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("expand")))
+	var relations map[string]bool
+	if expand != "detail" {
+		relations, err = parseMeasurementExpand(expand)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	measurement, err := h.measurementService.GetByID(ctx, id)
 	if err != nil {
 		if err == domain.ErrMeasurementNotFound {
@@ -100,9 +183,52 @@ func (h *MeasurementHandler) GetMeasurementByID(w http.ResponseWriter, r *http.R
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	if expand == "detail" {
+		json.NewEncoder(w).Encode(newMeasurementDetail(measurement, measurement.Patient))
+		return
+	}
+
+	if !relations["patient"] {
+		measurement.Patient = nil
+	}
+	if !relations["user"] {
+		measurement.User = nil
+	}
+	if !relations["tag"] {
+		measurement.Tag = nil
+	}
+	if !relations["recommendation"] {
+		measurement.Recommendation = nil
+	}
 	json.NewEncoder(w).Encode(measurement)
 }
 
+// measurementRelations son las relaciones que se pueden solicitar con ?expand= en una medición
+var measurementRelations = []string{"patient", "user", "tag", "recommendation"}
+
+// parseMeasurementExpand interpreta ?expand=: vacío o "all" incluye todas las relaciones y "none" ninguna
+func parseMeasurementExpand(expand string) (map[string]bool, error) {
+	relations := make(map[string]bool, len(measurementRelations))
+	switch expand {
+	case "", "all":
+		for _, name := range measurementRelations {
+			relations[name] = true
+		}
+		return relations, nil
+	case "none":
+		return relations, nil
+	}
+
+	for _, name := range strings.Split(expand, ",") {
+		name = strings.TrimSpace(name)
+		if !slices.Contains(measurementRelations, name) {
+			return nil, fmt.Errorf("relación %q no válida en expand, use all, none, detail o %s", name, strings.Join(measurementRelations, ","))
+		}
+		relations[name] = true
+	}
+	return relations, nil
+}
+
 // GetMeasurementsByPatientID godoc
 // @Summary Obtener mediciones por ID de paciente
 // @Description Obtiene todas las mediciones asociadas a un paciente específico
@@ -110,7 +236,7 @@ func (h *MeasurementHandler) GetMeasurementByID(w http.ResponseWriter, r *http.R
 // @Accept json
 // @Produce json
 // @Param patientId path string true "ID del paciente"
-// @Success 200 {array} domain.Measurement
+// @Success 200 {object} ListResponse{data=[]domain.Measurement}
 // @Failure 400 {object} map[string]string "ID de paciente inválido o no proporcionado"
 // @Failure 500 {object} map[string]string "Error interno del servidor"
 // @Router /api/measurements/patient/{patientId} [get]
@@ -135,8 +261,7 @@ func (h *MeasurementHandler) GetMeasurementsByPatientID(w http.ResponseWriter, r
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(measurements)
+	writeList(w, measurements, nil)
 }
 
 // GetMeasurementsByUserID godoc
@@ -146,7 +271,7 @@ func (h *MeasurementHandler) GetMeasurementsByPatientID(w http.ResponseWriter, r
 // @Accept json
 // @Produce json
 // @Param userId path string true "ID del usuario"
-// @Success 200 {array} domain.Measurement
+// @Success 200 {object} ListResponse{data=[]domain.Measurement}
 // @Failure 400 {object} map[string]string "ID de usuario inválido o no proporcionado"
 // @Failure 500 {object} map[string]string "Error interno del servidor"
 // @Router /api/measurements/user/{userId} [get]
@@ -171,8 +296,7 @@ func (h *MeasurementHandler) GetMeasurementsByUserID(w http.ResponseWriter, r *h
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(measurements)
+	writeList(w, measurements, nil)
 }
 
 // GetMeasurementsByTagID godoc
@@ -182,7 +306,7 @@ func (h *MeasurementHandler) GetMeasurementsByUserID(w http.ResponseWriter, r *h
 // @Accept json
 // @Produce json
 // @Param tagId path string true "ID de la etiqueta"
-// @Success 200 {array} domain.Measurement
+// @Success 200 {object} ListResponse{data=[]domain.Measurement}
 // @Failure 400 {object} map[string]string "ID de etiqueta inválido o no proporcionado"
 // @Failure 500 {object} map[string]string "Error interno del servidor"
 // @Router /api/measurements/tag/{tagId} [get]
@@ -207,8 +331,7 @@ func (h *MeasurementHandler) GetMeasurementsByTagID(w http.ResponseWriter, r *ht
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(measurements)
+	writeList(w, measurements, nil)
 }
 
 // GetMeasurementsByRecommendationID godoc
@@ -218,7 +341,7 @@ func (h *MeasurementHandler) GetMeasurementsByTagID(w http.ResponseWriter, r *ht
 // @Accept json
 // @Produce json
 // @Param recommendationId path string true "ID de la recomendación"
-// @Success 200 {array} domain.Measurement
+// @Success 200 {object} ListResponse{data=[]domain.Measurement}
 // @Failure 400 {object} map[string]string "ID de recomendación inválido o no proporcionado"
 // @Failure 500 {object} map[string]string "Error interno del servidor"
 // @Router /api/measurements/recommendation/{recommendationId} [get]
@@ -243,8 +366,7 @@ func (h *MeasurementHandler) GetMeasurementsByRecommendationID(w http.ResponseWr
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(measurements)
+	writeList(w, measurements, nil)
 }
 
 // GetMeasurementsByDateRange godoc
@@ -255,7 +377,7 @@ func (h *MeasurementHandler) GetMeasurementsByRecommendationID(w http.ResponseWr
 // @Produce json
 // @Param start_date query string true "Fecha de inicio (formato RFC3339)"
 // @Param end_date query string true "Fecha de fin (formato RFC3339)"
-// @Success 200 {array} domain.Measurement
+// @Success 200 {object} ListResponse{data=[]domain.Measurement}
 // @Failure 400 {object} map[string]string "Fechas inválidas o no proporcionadas"
 // @Failure 500 {object} map[string]string "Error interno del servidor"
 // @Router /api/measurements/date-range [get]
@@ -288,8 +410,192 @@ func (h *MeasurementHandler) GetMeasurementsByDateRange(w http.ResponseWriter, r
 		return
 	}
 
+	writeList(w, measurements, nil)
+}
+
+// SearchMeasurements godoc
+// @Summary Buscar mediciones por nombre del paciente
+// @Description Busca las mediciones de los pacientes cuyo nombre y apellido contienen patient_name (mínimo 3 caracteres, sin distinguir mayúsculas), de la más reciente a la más antigua y con su clasificación.
+// @Description Con X-User-ID la búsqueda se limita al alcance del usuario: el supervisor su localidad y el apoderado sus pacientes
+// @Tags mediciones
+// @Produce json
+// @Param patient_name query string true "Parte del nombre o apellido del paciente"
+// @Param locality_id query string false "ID de la localidad"
+// @Param X-User-ID header string false "Usuario que busca"
+// @Param page query int false "Página (default: 1)"
+// @Param page_size query int false "Tamaño de página (default: 20, máximo: 200)"
+// @Success 200 {object} ListResponse{data=[]domain.MeasurementSearchResult}
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 403 {object} map[string]string "Fuera del alcance del usuario"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/measurements/search [get]
+func (h *MeasurementHandler) SearchMeasurements(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	actorID, err := parseActorID(r)
+	if err != nil {
+		http.Error(w, "X-User-ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var localityID *uuid.UUID
+	if localityIDStr := r.URL.Query().Get("locality_id"); localityIDStr != "" {
+		id, err := uuid.Parse(localityIDStr)
+		if err != nil {
+			http.Error(w, "locality_id inválido", http.StatusBadRequest)
+			return
+		}
+		localityID = &id
+	}
+
+	filter, err := domain.NewMeasurementSearchFilter(r.URL.Query().Get("patient_name"), localityID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.measurementService.Search(ctx, actorID, filter, page)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrMeasurementSearchForbidden):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeList(w, results, page)
+}
+
+// SyncMeasurements godoc
+// @Summary Sincronización incremental de mediciones
+// @Description Devuelve las mediciones creadas o actualizadas después de since, para la caché offline de la app.
+// @Description meta.server_time es el cursor a enviar como since en la próxima sincronización y como until en las páginas siguientes.
+// @Description meta.deleted_ids (solo en la página 1) lista las mediciones eliminadas que el cliente debe purgar.
+// @Tags mediciones
+// @Accept json
+// @Produce json
+// @Param since query string false "Cursor de la última sincronización (RFC3339); vacío sincroniza todo"
+// @Param until query string false "server_time devuelto en la página 1, para fijar la ventana al paginar (RFC3339)"
+// @Param locality_id query string false "ID de la localidad"
+// @Param page query int false "Página (por defecto 1)"
+// @Param page_size query int false "Tamaño de página"
+// @Success 200 {object} ListResponse{data=[]domain.Measurement}
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/sync/measurements [get]
+func (h *MeasurementHandler) SyncMeasurements(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filter := &domain.MeasurementSyncFilter{}
+
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		since, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			http.Error(w, "Formato de since inválido. Use RFC3339", http.StatusBadRequest)
+			return
+		}
+		filter.Since = since
+	}
+
+	if untilStr := r.URL.Query().Get("until"); untilStr != "" {
+		until, err := time.Parse(time.RFC3339, untilStr)
+		if err != nil {
+			http.Error(w, "Formato de until inválido. Use RFC3339", http.StatusBadRequest)
+			return
+		}
+		filter.Until = until
+	}
+
+	if localityIDStr := r.URL.Query().Get("locality_id"); localityIDStr != "" {
+		localityID, err := uuid.Parse(localityIDStr)
+		if err != nil {
+			http.Error(w, "locality_id inválido", http.StatusBadRequest)
+			return
+		}
+		filter.LocalityID = &localityID
+	}
+
+	page, err := parsePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	delta, err := h.measurementService.GetSyncDelta(ctx, filter, page)
+	if err != nil {
+		http.Error(w, err.Error(), measurementErrorStatus(err))
+		return
+	}
+
+	response := NewListResponse(delta.Measurements, page)
+	response.Meta = map[string]interface{}{
+		"server_time": delta.ServerTime,
+		"deleted_ids": delta.DeletedIDs,
+	}
+	writeListResponse(w, response)
+}
+
+// GetMeasurementThresholds godoc
+// @Summary Umbrales con que se clasificó una medición
+// @Description Devuelve los umbrales severo/moderado/normal vigentes cuando se tomó la medición, su clasificación con ellos y los rangos de cada código, junto con la clasificación con los umbrales actuales. Las mediciones anteriores al registro de umbrales tienen los actuales
+// @Tags mediciones
+// @Produce json
+// @Param id path string true "ID de la medición"
+// @Success 200 {object} domain.MeasurementThresholds
+// @Failure 400 {object} map[string]string "ID inválido"
+// @Failure 404 {object} map[string]string "Medición no encontrada"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/measurements/{id}/thresholds [get]
+func (h *MeasurementHandler) GetMeasurementThresholds(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	thresholds, err := h.measurementService.GetThresholds(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), measurementErrorStatus(err))
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(measurements)
+	json.NewEncoder(w).Encode(thresholds)
+}
+
+// ClassifyMuacBatch godoc
+// @Summary Clasificar varios valores MUAC
+// @Description Devuelve el código, color, prioridad y rango oficial de cada valor MUAC enviado, sin guardar nada. Los valores fuera de rango se informan con su error sin afectar al resto
+// @Tags mediciones
+// @Accept json
+// @Produce json
+// @Param values body object true "Valores MUAC en cm" example({"values": [11.2, 12.0, 13.5]})
+// @Success 200 {object} ListResponse{data=[]domain.MuacClassification}
+// @Failure 400 {object} map[string]string "Lote vacío, demasiado grande o mal formado"
+// @Router /api/muac/classify-batch [post]
+func (h *MeasurementHandler) ClassifyMuacBatch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Values []float64 `json:"values"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	results, err := h.measurementService.ClassifyBatch(req.Values)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeList(w, results, nil)
 }
 
 // ============= AQUÍ ESTÁN LOS CAMBIOS =============
@@ -307,10 +613,27 @@ func (h *MeasurementHandler) CreateMeasurement(w http.ResponseWriter, r *http.Re
 		// TagID y RecommendationID ahora son opcionales
 		TagID            *uuid.UUID `json:"tag_id,omitempty"`
 		RecommendationID *uuid.UUID `json:"recommendation_id,omitempty"`
+		// Ubicación GPS del dispositivo; sin ella se usa la de la localidad
+		Latitude  *float64 `json:"latitude,omitempty"`
+		Longitude *float64 `json:"longitude,omitempty"`
+		// Lote de la cinta MUAC usada (opcional)
+		TapeBatch string `json:"tape_batch,omitempty"`
+		// Motivo si la etiqueta indicada difiere de la clasificación automática (opcional)
+		OverrideReason string `json:"override_reason,omitempty"`
+	}
+
+	if !decodeJSON(w, r, &req) {
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Solicitud inválida", http.StatusBadRequest)
+	location, err := domain.NewMeasurementLocation(req.Latitude, req.Longitude)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tapeBatch, err := domain.NormalizeTapeBatch(req.TapeBatch)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -323,11 +646,11 @@ func (h *MeasurementHandler) CreateMeasurement(w http.ResponseWriter, r *http.Re
 	if req.TagID == nil && req.RecommendationID == nil {
 		// Intentar usar auto-asignación si está disponible
 		if serviceExtended, ok := h.measurementService.(interface {
-			CreateWithAutoAssignment(ctx context.Context, muacValue float64, description string, patientID, userID uuid.UUID) (*domain.Measurement, error)
+			CreateWithAutoAssignment(ctx context.Context, muacValue float64, description string, patientID, userID uuid.UUID, measuredAt time.Time, location *domain.MeasurementLocation, tapeBatch string) (*domain.Measurement, error)
 		}); ok {
-			measurement, err := serviceExtended.CreateWithAutoAssignment(ctx, req.MuacValue, req.Description, req.PatientID, req.UserID)
+			measurement, err := serviceExtended.CreateWithAutoAssignment(ctx, req.MuacValue, req.Description, req.PatientID, req.UserID, req.Timestamp, location, tapeBatch)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				http.Error(w, err.Error(), measurementErrorStatus(err))
 				return
 			}
 
@@ -372,9 +695,12 @@ func (h *MeasurementHandler) CreateMeasurement(w http.ResponseWriter, r *http.Re
 		req.TagID,
 		req.RecommendationID,
 	)
+	measurement.SetLocation(location)
+	measurement.TapeBatch = tapeBatch
+	measurement.OverrideReason = req.OverrideReason
 
 	if err := h.measurementService.Create(ctx, measurement); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), measurementErrorStatus(err))
 		return
 	}
 
@@ -398,10 +724,11 @@ func (h *MeasurementHandler) CreateMeasurementManual(w http.ResponseWriter, r *h
 		UserID           uuid.UUID  `json:"user_id"`
 		TagID            *uuid.UUID `json:"tag_id,omitempty"`
 		RecommendationID *uuid.UUID `json:"recommendation_id,omitempty"`
+		TapeBatch        string     `json:"tape_batch,omitempty"`
+		OverrideReason   string     `json:"override_reason,omitempty"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Solicitud inválida", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
@@ -419,9 +746,11 @@ func (h *MeasurementHandler) CreateMeasurementManual(w http.ResponseWriter, r *h
 		req.TagID,
 		req.RecommendationID,
 	)
+	measurement.TapeBatch = req.TapeBatch
+	measurement.OverrideReason = req.OverrideReason
 
 	if err := h.measurementService.Create(ctx, measurement); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), measurementErrorStatus(err))
 		return
 	}
 
@@ -433,9 +762,42 @@ func (h *MeasurementHandler) CreateMeasurementManual(w http.ResponseWriter, r *h
 	})
 }
 
+// measurementErrorStatus traduce errores de validación del dominio a códigos HTTP
+func measurementErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrPatientNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrPatientAgeOutOfRange),
+		errors.Is(err, domain.ErrEmptyAgeOverrideNote),
+		errors.Is(err, domain.ErrInvalidMuacValue),
+		errors.Is(err, domain.ErrEmptyPatientID),
+		errors.Is(err, domain.ErrEmptyUserID),
+		errors.Is(err, domain.ErrTooManyPatientIDs),
+		errors.Is(err, domain.ErrInvalidSyncCursor),
+		errors.Is(err, domain.ErrFutureMeasurementTime),
+		errors.Is(err, domain.ErrEmptyRiskDescription),
+		errors.Is(err, domain.ErrEmptyFlagReason),
+		errors.Is(err, domain.ErrInvalidTapeBatch),
+		errors.Is(err, domain.ErrInvalidOverrideReason):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrMeasurementNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrMeasurementActorRequired):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrPatientConsentWithdrawn):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrMeasurementNotOwner),
+		errors.Is(err, domain.ErrMeasurementEditExpired):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // ============= RESTO DE MÉTODOS SIN CAMBIOS =============
 
-// UpdateMeasurement actualiza una medición
+// UpdateMeasurement actualiza una medición.
+// Requiere la cabecera X-User-ID; un apoderado solo puede editar sus mediciones recientes
 func (h *MeasurementHandler) UpdateMeasurement(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -451,6 +813,12 @@ func (h *MeasurementHandler) UpdateMeasurement(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	actorID, err := parseActorID(r)
+	if err != nil {
+		http.Error(w, "X-User-ID inválido", http.StatusBadRequest)
+		return
+	}
+
 	var req struct {
 		MuacValue        float64   `json:"muac_value"`
 		Description      string    `json:"description"`
@@ -460,8 +828,7 @@ func (h *MeasurementHandler) UpdateMeasurement(w http.ResponseWriter, r *http.Re
 		RecommendationID uuid.UUID `json:"recommendation_id"`
 	}
 
-	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Solicitud inválida", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
@@ -484,8 +851,8 @@ func (h *MeasurementHandler) UpdateMeasurement(w http.ResponseWriter, r *http.Re
 		&req.RecommendationID,
 	)
 
-	if err := h.measurementService.Update(ctx, measurement); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.measurementService.Update(ctx, actorID, measurement); err != nil {
+		http.Error(w, err.Error(), measurementErrorStatus(err))
 		return
 	}
 
@@ -493,7 +860,8 @@ func (h *MeasurementHandler) UpdateMeasurement(w http.ResponseWriter, r *http.Re
 	json.NewEncoder(w).Encode(measurement)
 }
 
-// DeleteMeasurement elimina una medición por su ID
+// DeleteMeasurement elimina una medición por su ID.
+// Requiere la cabecera X-User-ID; un apoderado solo puede eliminar sus mediciones recientes
 func (h *MeasurementHandler) DeleteMeasurement(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -509,13 +877,19 @@ func (h *MeasurementHandler) DeleteMeasurement(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	err = h.measurementService.Delete(ctx, id)
+	actorID, err := parseActorID(r)
+	if err != nil {
+		http.Error(w, "X-User-ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	err = h.measurementService.Delete(ctx, actorID, id)
 	if err != nil {
 		if err == domain.ErrMeasurementNotFound {
 			http.Error(w, "Medición no encontrada", http.StatusNotFound)
 			return
 		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), measurementErrorStatus(err))
 		return
 	}
 
@@ -621,3 +995,72 @@ func (h *MeasurementHandler) AssignRecommendation(w http.ResponseWriter, r *http
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// FlagMeasurement godoc
+// @Summary Marcar una medición para revisión
+// @Description Marca manualmente una medición como posible error de registro para que aparezca en /api/reports/flagged-measurements
+// @Tags mediciones
+// @Accept json
+// @Produce json
+// @Param id path string true "ID de la medición"
+// @Param body body object true "Motivo de la marca" example({"reason": "Valor ingresado en mm"})
+// @Success 200 {object} domain.Measurement
+// @Failure 400 {object} map[string]string "ID inválido o motivo vacío"
+// @Failure 404 {object} map[string]string "Medición no encontrada"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/measurements/{id}/flag [put]
+func (h *MeasurementHandler) FlagMeasurement(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	measurement, err := h.measurementService.Flag(ctx, id, req.Reason)
+	if err != nil {
+		http.Error(w, err.Error(), measurementErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(measurement)
+}
+
+// UnflagMeasurement godoc
+// @Summary Quitar la marca de revisión
+// @Description Quita la marca de posible error de registro de una medición ya revisada
+// @Tags mediciones
+// @Produce json
+// @Param id path string true "ID de la medición"
+// @Success 200 {object} domain.Measurement
+// @Failure 400 {object} map[string]string "ID inválido"
+// @Failure 404 {object} map[string]string "Medición no encontrada"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/measurements/{id}/unflag [put]
+func (h *MeasurementHandler) UnflagMeasurement(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	measurement, err := h.measurementService.Unflag(ctx, id)
+	if err != nil {
+		http.Error(w, err.Error(), measurementErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(measurement)
+}
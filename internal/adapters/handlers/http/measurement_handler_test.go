@@ -0,0 +1,125 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+)
+
+// fakeMeasurementService devuelve una medición fija con todas sus relaciones cargadas
+type fakeMeasurementService struct {
+	ports.IMeasurementService
+	measurement *domain.Measurement
+}
+
+func (f *fakeMeasurementService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Measurement, error) {
+	if id != f.measurement.ID {
+		return nil, domain.ErrMeasurementNotFound
+	}
+	// Copia para que el handler pueda quitar relaciones sin afectar otros casos
+	m := *f.measurement
+	return &m, nil
+}
+
+func newExpandedMeasurement() *domain.Measurement {
+	tagID, recommendationID := uuid.New(), uuid.New()
+	patient := &domain.Patient{ID: uuid.New(), Name: "Ana", Lastname: "Quispe", Age: 2}
+	user := &domain.User{ID: uuid.New(), Name: "Rosa"}
+	return &domain.Measurement{
+		ID:               uuid.New(),
+		MuacValue:        11.8,
+		PatientID:        patient.ID,
+		UserID:           user.ID,
+		TagID:            &tagID,
+		RecommendationID: &recommendationID,
+		CreatedAt:        time.Now(),
+		Patient:          patient,
+		User:             user,
+		Tag:              &domain.Tag{ID: tagID, Name: "Riesgo moderado"},
+		Recommendation:   &domain.Recommendation{ID: recommendationID, Name: "Control semanal"},
+	}
+}
+
+func TestGetMeasurementByIDExpand(t *testing.T) {
+	measurement := newExpandedMeasurement()
+	mux := http.NewServeMux()
+	NewMeasurementHandler(&fakeMeasurementService{measurement: measurement}).RegisterRoutes(mux)
+
+	tests := []struct {
+		name       string
+		expand     string
+		wantStatus int
+		want       []string
+	}{
+		{"por defecto incluye todo", "", http.StatusOK, []string{"patient", "user", "tag", "recommendation"}},
+		{"all incluye todo", "all", http.StatusOK, []string{"patient", "user", "tag", "recommendation"}},
+		{"lista parcial", "tag,recommendation", http.StatusOK, []string{"tag", "recommendation"}},
+		{"none", "none", http.StatusOK, nil},
+		{"relación desconocida", "locality", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/measurements/"+measurement.ID.String()+"?expand="+tt.expand, nil)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, se esperaba %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var body map[string]json.RawMessage
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("respuesta no es JSON: %v", err)
+			}
+			included := map[string]bool{}
+			for _, name := range tt.want {
+				included[name] = true
+			}
+			for _, name := range measurementRelations {
+				raw, present := body[name]
+				loaded := present && string(raw) != "null"
+				if loaded != included[name] {
+					t.Errorf("%s incluido = %v, se esperaba %v", name, loaded, included[name])
+				}
+			}
+		})
+	}
+}
+
+func TestGetMeasurementByIDExpandNested(t *testing.T) {
+	measurement := newExpandedMeasurement()
+	mux := http.NewServeMux()
+	NewMeasurementHandler(&fakeMeasurementService{measurement: measurement}).RegisterRoutes(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/measurements/"+measurement.ID.String()+"?expand=all", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	var got domain.Measurement
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("respuesta no es JSON: %v", err)
+	}
+	if got.Patient == nil || got.Patient.ID != measurement.Patient.ID {
+		t.Errorf("patient = %+v, se esperaba el paciente %s", got.Patient, measurement.Patient.ID)
+	}
+	if got.User == nil || got.User.ID != measurement.User.ID {
+		t.Errorf("user = %+v, se esperaba el usuario %s", got.User, measurement.User.ID)
+	}
+	if got.Tag == nil || got.Tag.Name != "Riesgo moderado" {
+		t.Errorf("tag = %+v, se esperaba la etiqueta anidada", got.Tag)
+	}
+	if got.Recommendation == nil || got.Recommendation.Name != "Control semanal" {
+		t.Errorf("recommendation = %+v, se esperaba la recomendación anidada", got.Recommendation)
+	}
+}
@@ -2,7 +2,9 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
@@ -29,6 +31,9 @@ func (h *NotificationHandler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("PUT /api/notifications/{id}", h.UpdateNotification)
 	mux.HandleFunc("DELETE /api/notifications/{id}", h.DeleteNotification)
 	mux.HandleFunc("PUT /api/notifications/{id}/visible", h.SetVisibility)
+	mux.HandleFunc("POST /api/notifications/{id}/resend", h.ResendNotification)
+	mux.HandleFunc("GET /api/announcements/active", h.GetActiveAnnouncements)
+	mux.HandleFunc("PUT /api/users/{id}/notifications/read-all", h.MarkAllRead)
 }
 
 // GetNotifications godoc
@@ -37,7 +42,7 @@ func (h *NotificationHandler) RegisterRoutes(mux *http.ServeMux) {
 // @Tags notificaciones
 // @Accept json
 // @Produce json
-// @Success 200 {array} domain.Notification
+// @Success 200 {object} ListResponse{data=[]domain.Notification}
 // @Failure 500 {object} map[string]string "Error interno del servidor"
 // @Router /api/notifications [get]
 func (h *NotificationHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
@@ -47,8 +52,25 @@ func (h *NotificationHandler) GetNotifications(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(notifications)
+	writeList(w, notifications, nil)
+}
+
+// GetActiveAnnouncements godoc
+// @Summary Obtener los avisos activos
+// @Description Devuelve las notificaciones visibles sin destinatario cuya ventana (starts_at y expires_at, opcionales) incluye el momento actual, las más recientes primero. Es el feed del banner de la app; los avisos se administran con el CRUD de notificaciones
+// @Tags notificaciones
+// @Produce json
+// @Success 200 {object} ListResponse{data=[]domain.Notification}
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/announcements/active [get]
+func (h *NotificationHandler) GetActiveAnnouncements(w http.ResponseWriter, r *http.Request) {
+	announcements, err := h.notificationService.GetActiveAnnouncements(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeList(w, announcements, nil)
 }
 
 // GetNotificationByID godoc
@@ -92,24 +114,29 @@ func (h *NotificationHandler) GetNotificationByID(w http.ResponseWriter, r *http
 
 // CreateNotification godoc
 // @Summary Crear una nueva notificación
-// @Description Crea una nueva notificación con la información proporcionada
+// @Description Crea una nueva notificación con la información proporcionada. Con recipient_id y kind (red_alert, reminder o broadcast,
+// @Description por defecto broadcast) la entrega al destino respeta las preferencias del usuario: si desactivó ese tipo queda OMITIDA
 // @Tags notificaciones
 // @Accept json
 // @Produce json
 // @Param notification body object true "Datos de la notificación"
 // @Success 201 {object} domain.Notification
-// @Failure 400 {object} map[string]string "Solicitud inválida"
+// @Failure 400 {object} map[string]string "Solicitud inválida, tipo inválido o destinatario inexistente"
 // @Failure 500 {object} map[string]string "Error interno del servidor"
 // @Router /api/notifications [post]
 func (h *NotificationHandler) CreateNotification(w http.ResponseWriter, r *http.Request) {
 	var notificationDTO struct {
-		Title   string `json:"title"`
-		Body    string `json:"body"`
-		Visible bool   `json:"visible"`
+		Title       string     `json:"title"`
+		Body        string     `json:"body"`
+		Visible     bool       `json:"visible"`
+		Target      string     `json:"target"`
+		Kind        string     `json:"kind"`
+		RecipientID *uuid.UUID `json:"recipient_id"`
+		StartsAt    *time.Time `json:"starts_at"`
+		ExpiresAt   *time.Time `json:"expires_at"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&notificationDTO); err != nil {
-		http.Error(w, "Error al decodificar el cuerpo de la petición", http.StatusBadRequest)
+	if !decodeJSON(w, r, &notificationDTO) {
 		return
 	}
 
@@ -118,6 +145,9 @@ func (h *NotificationHandler) CreateNotification(w http.ResponseWriter, r *http.
 		notificationDTO.Body,
 		notificationDTO.Visible,
 	)
+	notification.SetTarget(notificationDTO.Target)
+	notification.SetRecipient(notificationDTO.RecipientID, notificationDTO.Kind)
+	notification.SetWindow(notificationDTO.StartsAt, notificationDTO.ExpiresAt)
 
 	if err := notification.Validate(); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -125,6 +155,10 @@ func (h *NotificationHandler) CreateNotification(w http.ResponseWriter, r *http.
 	}
 
 	if err := h.notificationService.Create(r.Context(), notification); err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			http.Error(w, "recipient_id no corresponde a un usuario", http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -161,13 +195,14 @@ func (h *NotificationHandler) UpdateNotification(w http.ResponseWriter, r *http.
 	}
 
 	var notificationDTO struct {
-		Title   string `json:"title"`
-		Body    string `json:"body"`
-		Visible bool   `json:"visible"`
+		Title     string     `json:"title"`
+		Body      string     `json:"body"`
+		Visible   bool       `json:"visible"`
+		StartsAt  *time.Time `json:"starts_at"`
+		ExpiresAt *time.Time `json:"expires_at"`
 	}
 
-	if err = json.NewDecoder(r.Body).Decode(&notificationDTO); err != nil {
-		http.Error(w, "Error al decodificar el cuerpo de la petición", http.StatusBadRequest)
+	if !decodeJSON(w, r, &notificationDTO) {
 		return
 	}
 
@@ -186,6 +221,7 @@ func (h *NotificationHandler) UpdateNotification(w http.ResponseWriter, r *http.
 		notificationDTO.Body,
 		notificationDTO.Visible,
 	)
+	notification.SetWindow(notificationDTO.StartsAt, notificationDTO.ExpiresAt)
 
 	if err := notification.Validate(); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -245,7 +281,7 @@ func (h *NotificationHandler) DeleteNotification(w http.ResponseWriter, r *http.
 // @Accept json
 // @Produce json
 // @Param id path string true "ID de la notificación"
-// @Param visibility body object true "Estado de visibilidad" 
+// @Param visibility body object true "Estado de visibilidad"
 // @Success 200 {object} domain.Notification
 // @Failure 400 {object} map[string]string "ID inválido o solicitud inválida"
 // @Failure 404 {object} map[string]string "Notificación no encontrada"
@@ -268,8 +304,7 @@ func (h *NotificationHandler) SetVisibility(w http.ResponseWriter, r *http.Reque
 		Visible bool `json:"visible"`
 	}
 
-	if err = json.NewDecoder(r.Body).Decode(&visibilityDTO); err != nil {
-		http.Error(w, "Error al decodificar el cuerpo de la petición", http.StatusBadRequest)
+	if !decodeJSON(w, r, &visibilityDTO) {
 		return
 	}
 
@@ -293,3 +328,91 @@ func (h *NotificationHandler) SetVisibility(w http.ResponseWriter, r *http.Reque
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(notification)
 }
+
+// ResendNotification godoc
+// @Summary Reenviar una notificación
+// @Description Reintenta la entrega de una notificación fallida a su destino registrado (webhook). Registra el número de intentos y el último error (solo el código de estado del destino)
+// @Tags notificaciones
+// @Accept json
+// @Produce json
+// @Param id path string true "ID de la notificación"
+// @Success 200 {object} domain.Notification
+// @Failure 400 {object} map[string]string "ID inválido, notificación sin destino o destino no permitido"
+// @Failure 404 {object} map[string]string "Notificación no encontrada"
+// @Failure 409 {object} map[string]string "Notificación ya entregada"
+// @Failure 429 {object} map[string]string "Límite de intentos alcanzado"
+// @Failure 502 {object} map[string]string "El destino no aceptó la notificación"
+// @Router /api/notifications/{id}/resend [post]
+func (h *NotificationHandler) ResendNotification(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	notification, err := h.notificationService.Resend(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotificationNotFound):
+			http.Error(w, "Notificación no encontrada", http.StatusNotFound)
+		case errors.Is(err, domain.ErrNotificationNoTarget), errors.Is(err, domain.ErrInvalidNotificationTarget):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, domain.ErrNotificationAlreadySent):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, domain.ErrNotificationResendLimit):
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+		case errors.Is(err, domain.ErrNotificationDelivery):
+			http.Error(w, err.Error(), http.StatusBadGateway)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notification)
+}
+
+// MarkAllRead godoc
+// @Summary Marcar como leídas todas las notificaciones de un usuario
+// @Description Marca en una sola operación los comunicados y las notificaciones dirigidas al usuario, visibles y sin leer, y devuelve cuántas marcó. Con before (RFC3339) solo se marcan las creadas hasta esa fecha
+// @Tags notificaciones
+// @Produce json
+// @Param id path string true "ID del usuario"
+// @Param before query string false "Solo notificaciones creadas hasta esta fecha (RFC3339)"
+// @Success 200 {object} domain.NotificationsReadResult
+// @Failure 400 {object} map[string]string "ID o fecha inválidos"
+// @Failure 404 {object} map[string]string "Usuario no encontrado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/users/{id}/notifications/read-all [put]
+func (h *NotificationHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID de usuario inválido", http.StatusBadRequest)
+		return
+	}
+
+	var before *time.Time
+	if beforeStr := r.URL.Query().Get("before"); beforeStr != "" {
+		parsed, err := time.Parse(time.RFC3339, beforeStr)
+		if err != nil {
+			http.Error(w, "Formato de before inválido. Use RFC3339", http.StatusBadRequest)
+			return
+		}
+		before = &parsed
+	}
+
+	result, err := h.notificationService.MarkAllRead(r.Context(), userID, before)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUserNotFound):
+			http.Error(w, "Usuario no encontrado", http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,35 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+)
+
+// parsePagination parsea los query parameters page y page_size
+func parsePagination(r *http.Request) (*domain.Pagination, error) {
+	page, pageSize := 1, domain.DefaultPageSize
+
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		p, err := strconv.Atoi(pageStr)
+		if err != nil || p < 1 {
+			return nil, fmt.Errorf("page debe ser un número mayor a 0")
+		}
+		page = p
+	}
+
+	if sizeStr := r.URL.Query().Get("page_size"); sizeStr != "" {
+		s, err := strconv.Atoi(sizeStr)
+		if err != nil || s < 1 {
+			return nil, fmt.Errorf("page_size debe ser un número mayor a 0")
+		}
+		if s > domain.MaxPageSize {
+			return nil, fmt.Errorf("page_size no puede ser mayor a %d", domain.MaxPageSize)
+		}
+		pageSize = s
+	}
+
+	return domain.NewPagination(page, pageSize), nil
+}
@@ -1,14 +1,17 @@
 package http
 
 import (
+	"context"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
@@ -20,14 +23,16 @@ type PatientHandler struct {
 	patientService     ports.IPatientService
 	measurementService ports.IMeasurementService
 	fileService        ports.IFileService // Agregar servicio de archivos
+	logger             *slog.Logger
 }
 
 // NewPatientHandler crea una nueva instancia de PatientHandler
-func NewPatientHandler(patientService ports.IPatientService, measurementService ports.IMeasurementService, fileService ports.IFileService) *PatientHandler {
+func NewPatientHandler(patientService ports.IPatientService, measurementService ports.IMeasurementService, fileService ports.IFileService, logger *slog.Logger) *PatientHandler {
 	return &PatientHandler{
 		patientService:     patientService,
 		measurementService: measurementService,
 		fileService:        fileService,
+		logger:             logger,
 	}
 }
 
@@ -36,37 +41,103 @@ func (h *PatientHandler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /api/patients", h.GetAllPatients)
 	// mux.HandleFunc("POST /api/patients", h.CreatePatient)
 	mux.HandleFunc("GET /api/patients/patients-in-risk", h.GetPatientsInRisk)
+	mux.HandleFunc("GET /api/patients/triage", h.GetPatientsTriage)
+	mux.HandleFunc("GET /api/patients/export", h.ExportPatients)
 	mux.HandleFunc("POST /api/patients/with-file", h.CreatePatientWithFile)
+	mux.HandleFunc("POST /api/patients/import/validate", h.ValidatePatientImport)
 	mux.HandleFunc("GET /api/patients/{id}", h.GetPatientByID)
+	mux.HandleFunc("GET /api/patients/{id}/{resource}", h.routePatientResource)
 	mux.HandleFunc("PUT /api/patients/{id}", h.UpdatePatientWithFile)
+	mux.HandleFunc("PUT /api/patients/{id}/consent", h.UpdatePatientConsent)
+	mux.HandleFunc("PUT /api/patients/{id}/approval", h.UpdatePatientApproval)
+	mux.HandleFunc("POST /api/patients/{id}/{action}", h.routePatientAction)
 	mux.HandleFunc("DELETE /api/patients/{id}", h.DeletePatient)
 	mux.HandleFunc("GET /api/patients/dni/{dni}", h.GetPatientByDNI)
+	mux.HandleFunc("GET /api/patients/dni/{dni}/check", h.CheckPatientDNI)
 	mux.HandleFunc("GET /api/patients/father/{fatherId}", h.GetPatientsByFatherID)
 	mux.HandleFunc("GET /api/patients/measurements/{id}", h.GetPatientMeasurements)
 	mux.HandleFunc("POST /api/patients/measurements/{id}", h.AddPatientMeasurement)
 	// mux.HandleFunc("POST /api/patients/upload-dni/{id}", h.UploadPatientDNI)
 }
 
+// patientResources agrupa las subrutas GET /api/patients/{id}/{resource}.
+// Se despachan desde un único patrón porque rutas como /api/patients/{id}/status
+// chocan en el ServeMux con /api/patients/dni/{dni}, /father/{fatherId} y /measurements/{id}.
+func (h *PatientHandler) patientResources() map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"status":                   h.GetPatientStatus,
+		"sparkline":                h.GetPatientSparkline,
+		"simulate":                 h.SimulatePatientMeasurement,
+		"compliance":               h.GetPatientCompliance,
+		"chart.png":                h.GetPatientChart,
+		"full":                     h.GetPatientRecord,
+		"growth-plot-data":         h.GetPatientGrowthPlotData,
+		"anthropometry-check":      h.GetPatientAnthropometryCheck,
+		"next-step-recommendation": h.GetPatientNextStepRecommendation,
+	}
+}
+
+// routePatientResource despacha la subruta solicitada del paciente
+func (h *PatientHandler) routePatientResource(w http.ResponseWriter, r *http.Request) {
+	handler, ok := h.patientResources()[r.PathValue("resource")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	handler(w, r)
+}
+
+// patientActions agrupa las subrutas POST /api/patients/{id}/{action}, por el mismo
+// choque con /api/patients/measurements/{id} que patientResources.
+func (h *PatientHandler) patientActions() map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"diff":      h.DiffPatient,
+		"recompute": h.RecomputePatient,
+	}
+}
+
+// routePatientAction despacha la acción solicitada sobre el paciente
+func (h *PatientHandler) routePatientAction(w http.ResponseWriter, r *http.Request) {
+	handler, ok := h.patientActions()[r.PathValue("action")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	handler(w, r)
+}
+
 // GetAllPatients godoc
 // @Summary Obtener todos los pacientes
 // @Description Obtiene una lista de todos los pacientes registrados en el sistema
 // @Tags pacientes
 // @Accept json
 // @Produce json
-// @Success 200 {array} domain.Patient
+// @Param expand query string false "Relaciones a incluir separadas por coma: latest_measurement, user, locality"
+// @Success 200 {object} ListResponse{data=[]domain.Patient}
+// @Failure 400 {object} map[string]string "Relación a expandir no permitida"
 // @Failure 500 {object} map[string]string "Error interno del servidor"
 // @Router /api/patients [get]
 func (h *PatientHandler) GetAllPatients(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	patients, err := h.patientService.GetAll(ctx)
+	expand, err := domain.ParsePatientExpand(r.URL.Query().Get("expand"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var patients []*domain.Patient
+	if expand.IsEmpty() {
+		patients, err = h.patientService.GetAll(ctx)
+	} else {
+		patients, err = h.patientService.GetAllWithRelations(ctx, expand)
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(patients)
+	writeList(w, patients, nil)
 }
 
 // GetPatientByID godoc
@@ -110,6 +181,360 @@ func (h *PatientHandler) GetPatientByID(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(patient)
 }
 
+// GetPatientStatus godoc
+// @Summary Obtener el estado actual de un paciente
+// @Description Obtiene el código MUAC, color, etiqueta, valor y fecha de la última medición del paciente
+// @Tags pacientes
+// @Accept json
+// @Produce json
+// @Param id path string true "ID del paciente"
+// @Success 200 {object} domain.PatientStatus
+// @Failure 400 {object} map[string]string "ID inválido o no proporcionado"
+// @Failure 404 {object} map[string]string "Paciente no encontrado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/patients/{id}/status [get]
+func (h *PatientHandler) GetPatientStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	idStr := r.PathValue("id")
+	if idStr == "" {
+		http.Error(w, "ID de paciente no proporcionado", http.StatusBadRequest)
+		return
+	}
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	status, err := h.patientService.GetStatus(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrPatientNotFound) {
+			http.Error(w, "Paciente no encontrado", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// GetPatientRecord godoc
+// @Summary Obtener la ficha del paciente para una referencia
+// @Description Devuelve los datos del niño, su última medición con la clasificación y el contacto (nombre, teléfono y localidad) del apoderado responsable.
+// @Description Los pacientes se asocian a su padre o madre a través del apoderado (user_id); si no tiene, caregiver es null. No incluye la imagen del DNI ni notas internas
+// @Tags pacientes
+// @Produce json
+// @Param id path string true "ID del paciente"
+// @Success 200 {object} domain.PatientRecord
+// @Failure 400 {object} map[string]string "ID inválido"
+// @Failure 404 {object} map[string]string "Paciente no encontrado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/patients/{id}/full [get]
+func (h *PatientHandler) GetPatientRecord(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	record, err := h.patientService.GetRecord(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrPatientNotFound) {
+			http.Error(w, "Paciente no encontrado", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(record)
+}
+
+// GetPatientCompliance godoc
+// @Summary Obtener el cumplimiento de controles de un paciente
+// @Description Compara los días entre mediciones con la frecuencia recomendada para cada clasificación: intervalo esperado, intervalo promedio, controles a tiempo y atrasados, y porcentaje de cumplimiento. Con menos de dos mediciones sufficient es false
+// @Tags pacientes
+// @Produce json
+// @Param id path string true "ID del paciente"
+// @Success 200 {object} domain.PatientCompliance
+// @Failure 400 {object} map[string]string "ID inválido"
+// @Failure 404 {object} map[string]string "Paciente no encontrado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/patients/{id}/compliance [get]
+func (h *PatientHandler) GetPatientCompliance(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	compliance, err := h.patientService.GetCompliance(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrPatientNotFound) {
+			http.Error(w, "Paciente no encontrado", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(compliance)
+}
+
+// GetPatientSparkline godoc
+// @Summary Obtener la tendencia compacta de MUAC de un paciente
+// @Description Devuelve hasta N puntos de la serie MUAC conservando la primera, la última medición y los cruces de umbral
+// @Tags pacientes
+// @Accept json
+// @Produce json
+// @Param id path string true "ID del paciente"
+// @Param points query int false "Número máximo de puntos (por defecto 10, máximo 100)"
+// @Success 200 {object} ListResponse{data=[]domain.SparklinePoint}
+// @Failure 400 {object} map[string]string "ID o parámetro inválido"
+// @Failure 404 {object} map[string]string "Paciente no encontrado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/patients/{id}/sparkline [get]
+func (h *PatientHandler) GetPatientSparkline(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	idStr := r.PathValue("id")
+	if idStr == "" {
+		http.Error(w, "ID de paciente no proporcionado", http.StatusBadRequest)
+		return
+	}
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	points := domain.DefaultSparklinePoints
+	if pointsStr := r.URL.Query().Get("points"); pointsStr != "" {
+		points, err = strconv.Atoi(pointsStr)
+		if err != nil || points < 2 || points > domain.MaxSparklinePoints {
+			http.Error(w, fmt.Sprintf("points debe estar entre 2 y %d", domain.MaxSparklinePoints), http.StatusBadRequest)
+			return
+		}
+	}
+
+	sparkline, err := h.patientService.GetSparkline(ctx, id, points)
+	if err != nil {
+		if errors.Is(err, domain.ErrPatientNotFound) {
+			http.Error(w, "Paciente no encontrado", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeList(w, sparkline, nil)
+}
+
+// GetPatientGrowthPlotData godoc
+// @Summary Datos para graficar el MUAC del paciente sobre las curvas OMS
+// @Description Devuelve las mediciones del paciente como puntos (edad en meses, MUAC y z-score) y las curvas OMS de perímetro braquial para la edad (z-score -3 a +3, de 6 a 59 meses) del sexo del paciente, o de ambos sexos si el género registrado no se reconoce.
+// @Description Las mediciones fuera de 6-59 meses de edad se cuentan en excluded. Sin referencia OMS configurada reference_available es false y no hay curvas
+// @Tags pacientes
+// @Produce json
+// @Param id path string true "ID del paciente"
+// @Success 200 {object} domain.GrowthPlotData
+// @Failure 400 {object} map[string]string "ID inválido"
+// @Failure 404 {object} map[string]string "Paciente no encontrado"
+// @Failure 422 {object} map[string]string "El paciente no tiene una fecha de nacimiento válida"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/patients/{id}/growth-plot-data [get]
+func (h *PatientHandler) GetPatientGrowthPlotData(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	data, err := h.patientService.GetGrowthPlotData(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrPatientNotFound):
+			http.Error(w, "Paciente no encontrado", http.StatusNotFound)
+		case errors.Is(err, domain.ErrGrowthPlotBirthDate):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
+
+// GetPatientAnthropometryCheck godoc
+// @Summary Contrastar el peso para la talla con el MUAC
+// @Description Interpreta el peso y la talla registrados del paciente (acepta coma decimal y unidades kg, g, cm, m o mm), calcula el IMC y el z-score de peso para la talla (WHZ) con las tablas OMS (longitud antes de los 24 meses, talla después) y lo clasifica con los cortes -3 y -2.
+// @Description Si la clasificación WHZ no coincide con la de la última medición MUAC marca discrepancy: whz_more_severe cuando el MUAC no detecta la emaciación. Los indicadores que no se pueden calcular quedan en null con el motivo en issues; sin referencia OMS configurada reference_available es false
+// @Tags pacientes
+// @Produce json
+// @Param id path string true "ID del paciente"
+// @Success 200 {object} domain.AnthropometryCheck
+// @Failure 400 {object} map[string]string "ID inválido"
+// @Failure 404 {object} map[string]string "Paciente no encontrado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/patients/{id}/anthropometry-check [get]
+func (h *PatientHandler) GetPatientAnthropometryCheck(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	check, err := h.patientService.GetAnthropometryCheck(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrPatientNotFound) {
+			http.Error(w, "Paciente no encontrado", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(check)
+}
+
+// GetPatientChart godoc
+// @Summary Gráfico MUAC del paciente
+// @Description Devuelve un PNG con la serie de mediciones MUAC, las zonas severo/moderado/normal coloreadas y líneas en los umbrales. Sin mediciones devuelve solo las zonas
+// @Tags pacientes
+// @Produce png
+// @Param id path string true "ID del paciente"
+// @Success 200 {file} file "Gráfico PNG"
+// @Failure 400 {object} map[string]string "ID inválido"
+// @Failure 404 {object} map[string]string "Paciente no encontrado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/patients/{id}/chart.png [get]
+func (h *PatientHandler) GetPatientChart(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	series, err := h.patientService.GetSparkline(ctx, id, domain.MaxSparklinePoints)
+	if err != nil {
+		if errors.Is(err, domain.ErrPatientNotFound) {
+			http.Error(w, "Paciente no encontrado", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	chart, err := h.fileService.GeneratePatientMuacChart(ctx, series)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=muac_%s.png", id))
+	w.Header().Set("Content-Length", strconv.Itoa(len(chart)))
+	w.Write(chart)
+}
+
+// SimulatePatientMeasurement godoc
+// @Summary Simular una medición para un paciente
+// @Description Indica qué clasificación, tag y recomendación obtendría el paciente con el valor MUAC dado, comparado con su última medición. No guarda nada
+// @Tags pacientes
+// @Accept json
+// @Produce json
+// @Param id path string true "ID del paciente"
+// @Param value query number true "Valor MUAC a simular (cm)"
+// @Success 200 {object} domain.MeasurementSimulation
+// @Failure 400 {object} map[string]string "ID o valor inválido"
+// @Failure 404 {object} map[string]string "Paciente no encontrado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/patients/{id}/simulate [get]
+func (h *PatientHandler) SimulatePatientMeasurement(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	idStr := r.PathValue("id")
+	if idStr == "" {
+		http.Error(w, "ID de paciente no proporcionado", http.StatusBadRequest)
+		return
+	}
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	valueStr := r.URL.Query().Get("value")
+	if valueStr == "" {
+		http.Error(w, "El parámetro value es requerido", http.StatusBadRequest)
+		return
+	}
+
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil || !domain.IsValidMuacValue(value) {
+		http.Error(w, "Valor MUAC inválido", http.StatusBadRequest)
+		return
+	}
+
+	simulation, err := h.measurementService.Simulate(ctx, id, value)
+	if err != nil {
+		http.Error(w, err.Error(), measurementErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(simulation)
+}
+
+// GetPatientNextStepRecommendation godoc
+// @Summary Recomendación si el estado del paciente empeora
+// @Description Con fines educativos, devuelve la recomendación de la clasificación un paso peor que la última medición del paciente (verde a amarillo, amarillo a rojo), con el rango de esa clasificación. Si ya está en rojo devuelve la recomendación de seguimiento. No guarda nada
+// @Tags pacientes
+// @Produce json
+// @Param id path string true "ID del paciente"
+// @Success 200 {object} domain.NextStepRecommendation
+// @Failure 400 {object} map[string]string "ID inválido"
+// @Failure 404 {object} map[string]string "Paciente no encontrado o sin mediciones"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/patients/{id}/next-step-recommendation [get]
+func (h *PatientHandler) GetPatientNextStepRecommendation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	next, err := h.measurementService.GetNextStepRecommendation(ctx, id)
+	if err != nil {
+		http.Error(w, err.Error(), measurementErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(next)
+}
+
 // GetPatientByDNI godoc
 // @Summary Obtener un paciente por DNI
 // @Description Obtiene un paciente específico por su número de DNI
@@ -155,6 +580,202 @@ func (h *PatientHandler) GetPatientByDNI(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(Response)
 }
 
+// applyPatientForm aplica sobre una copia del paciente los campos del formulario de edición;
+// solo se actualizan los campos que se proporcionan. Indica si cambia el apoderado asignado
+func applyPatientForm(r *http.Request, existingPatient *domain.Patient) (domain.Patient, bool, error) {
+	updatedPatient := *existingPatient // Copia del paciente existente
+
+	// Actualizar campos si se proporcionan
+	if name := r.FormValue("name"); name != "" {
+		updatedPatient.Name = name
+	}
+	if lastname := r.FormValue("lastname"); lastname != "" {
+		updatedPatient.Lastname = lastname
+	}
+	if dni := r.FormValue("dni"); dni != "" {
+		updatedPatient.DNI = dni
+	}
+	if gender := r.FormValue("gender"); gender != "" {
+		updatedPatient.Gender = gender
+	}
+	if birthDate := r.FormValue("birth_date"); birthDate != "" {
+		updatedPatient.BirthDate = birthDate
+	}
+	if armSize := r.FormValue("arm_size"); armSize != "" {
+		updatedPatient.ArmSize = armSize
+	}
+	if weight := r.FormValue("weight"); weight != "" {
+		updatedPatient.Weight = weight
+	}
+	if size := r.FormValue("size"); size != "" {
+		updatedPatient.Size = size
+	}
+	if description := r.FormValue("description"); description != "" {
+		updatedPatient.Description = description
+	}
+
+	// Actualizar age si se proporciona
+	if ageStr := r.FormValue("age"); ageStr != "" {
+		age, err := strconv.ParseFloat(ageStr, 64)
+		if err != nil {
+			return updatedPatient, false, errors.New("Edad debe ser un número válido")
+		}
+		updatedPatient.Age = age
+	}
+
+	// Reasignar a otro apoderado si se proporciona
+	reassigned := false
+	if userIDStr := r.FormValue("user_id"); userIDStr != "" {
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			return updatedPatient, false, errors.New("user_id debe ser un UUID válido")
+		}
+		reassigned = existingPatient.UserID == nil || *existingPatient.UserID != userID
+		updatedPatient.UserID = &userID
+		updatedPatient.User = nil // Evita que GORM restaure el apoderado anterior al guardar
+	}
+
+	// El consentimiento solo cambia con PUT /api/patients/{id}/consent, que exige motivo y deja auditoría;
+	// se tolera que el formulario reenvíe el valor actual
+	if consentStr := r.FormValue("consent_given"); consentStr != "" && (consentStr == "true") != existingPatient.ConsentGiven {
+		return updatedPatient, false, errors.New("consent_given no se modifica al editar el paciente; use PUT /api/patients/{id}/consent")
+	}
+
+	// Actualizar excepción de edad si se proporciona
+	if overrideStr := r.FormValue("age_override"); overrideStr != "" {
+		updatedPatient.SetAgeOverride(overrideStr == "true", r.FormValue("age_override_note"))
+	}
+
+	return updatedPatient, reassigned, nil
+}
+
+// DiffPatient godoc
+// @Summary Previsualizar cambios de un paciente
+// @Description Recibe los mismos campos que la edición del paciente (multipart o urlencoded, solo se consideran los que se proporcionan) y devuelve campo por campo el valor actual y el propuesto, sin guardar nada. El archivo DNI no se compara. Sin cambios devuelve una lista vacía
+// @Tags pacientes
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "ID del paciente"
+// @Success 200 {object} domain.PatientDiff
+// @Failure 400 {object} map[string]string "Datos inválidos"
+// @Failure 404 {object} map[string]string "Paciente no encontrado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/patients/{id}/diff [post]
+func (h *PatientHandler) DiffPatient(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID de paciente inválido", http.StatusBadRequest)
+		return
+	}
+
+	existingPatient, err := h.patientService.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrPatientNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := r.ParseMultipartForm(1 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		http.Error(w, "Error al parsear formulario", http.StatusBadRequest)
+		return
+	}
+
+	proposed, _, err := applyPatientForm(r, existingPatient)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(domain.DiffPatients(existingPatient, &proposed))
+}
+
+// CheckPatientDNI godoc
+// @Summary Validar un DNI
+// @Description Indica si el DNI tiene un formato válido (8 dígitos; se ignoran espacios, puntos y guiones) y si está disponible (sin paciente registrado), para validar el formulario mientras se escribe. Siempre responde 200 con el resultado
+// @Tags pacientes
+// @Produce json
+// @Param dni path string true "DNI a validar"
+// @Success 200 {object} domain.DNICheck
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/patients/dni/{dni}/check [get]
+func (h *PatientHandler) CheckPatientDNI(w http.ResponseWriter, r *http.Request) {
+	check, err := h.patientService.CheckDNI(r.Context(), r.PathValue("dni"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(check)
+}
+
+// ValidatePatientImport godoc
+// @Summary Validar un archivo de importación de pacientes
+// @Description Aplica a cada fila del CSV las mismas validaciones que la importación, sin guardar nada, para corregir la planilla antes de importarla. Columnas: dni, name y lastname (obligatorias), gender, birth_date, age y description. Las filas con un DNI ya registrado o repetido en el archivo se marcan como duplicadas. Máximo 2 MB y 1000 filas
+// @Tags pacientes
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Archivo CSV"
+// @Success 200 {object} domain.PatientImportValidation
+// @Failure 400 {object} map[string]string "Archivo inválido"
+// @Failure 413 {object} map[string]string "Archivo demasiado grande"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/patients/import/validate [post]
+func (h *PatientHandler) ValidatePatientImport(w http.ResponseWriter, r *http.Request) {
+	rows, ok := h.readPatientImport(w, r)
+	if !ok {
+		return
+	}
+
+	validation, err := h.patientService.ValidatePatientImport(r.Context(), rows)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(validation)
+}
+
+// readPatientImport lee el CSV del campo file aplicando los límites de la importación;
+// si falla responde el error y devuelve false
+func (h *PatientHandler) readPatientImport(w http.ResponseWriter, r *http.Request) ([]domain.PatientImportRow, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, domain.PatientImportMaxBytes+(64<<10)) // margen para el multipart
+	if err := r.ParseMultipartForm(domain.PatientImportMaxBytes); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("El archivo supera el máximo de %d MB", domain.PatientImportMaxBytes>>20), http.StatusRequestEntityTooLarge)
+			return nil, false
+		}
+		http.Error(w, "Error al procesar el formulario", http.StatusBadRequest)
+		return nil, false
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Se requiere el archivo CSV en el campo file", http.StatusBadRequest)
+		return nil, false
+	}
+	defer file.Close()
+	if header.Size > domain.PatientImportMaxBytes {
+		http.Error(w, fmt.Sprintf("El archivo supera el máximo de %d MB", domain.PatientImportMaxBytes>>20), http.StatusRequestEntityTooLarge)
+		return nil, false
+	}
+
+	rows, err := domain.ReadPatientImport(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return rows, true
+}
+
 // CreatePatient godoc
 // @Summary Crear un nuevo paciente
 // @Description Crea un nuevo paciente con la información proporcionada
@@ -162,8 +783,10 @@ func (h *PatientHandler) GetPatientByDNI(w http.ResponseWriter, r *http.Request)
 // @Accept json
 // @Produce json
 // @Param patient body object true "Datos del paciente"
+// @Param X-User-ID header string false "ADMINISTRADOR que autoriza age_override (obligatorio si se envía)"
 // @Success 201 {object} domain.Patient
 // @Failure 400 {object} map[string]string "Solicitud inválida"
+// @Failure 403 {object} map[string]string "age_override sin un ADMINISTRADOR"
 // @Failure 500 {object} map[string]string "Error interno del servidor"
 // @Router /api/patients [post]
 //
@@ -230,6 +853,15 @@ func (h *PatientHandler) CreatePatientWithFile(w http.ResponseWriter, r *http.Re
 		&userID,
 	)
 
+	// Excepción administrativa al rango de edad (requiere un ADMINISTRADOR y nota de auditoría)
+	var overrideActor uuid.UUID
+	if r.FormValue("age_override") == "true" {
+		if overrideActor, err = h.authorizeAgeOverride(w, r); err != nil {
+			return
+		}
+		patient.SetAgeOverride(true, r.FormValue("age_override_note"))
+	}
+
 	// Variable para rastrear el ID del archivo subido
 	var uploadedFileID string
 
@@ -254,14 +886,14 @@ func (h *PatientHandler) CreatePatientWithFile(w http.ResponseWriter, r *http.Re
 
 		// Validar que el ID extraído es un UUID válido
 		if _, err := uuid.Parse(uploadedFileID); err != nil {
-			log.Printf("[ Error ]: ID de archivo inválido extraído de URL %s -> %s", fileInfo.URL, uploadedFileID)
+			h.logger.ErrorContext(ctx, "ID de archivo inválido extraído de URL", "url", fileInfo.URL, "file_id", uploadedFileID)
 			// Intentar eliminar el archivo con el ID inválido de todos modos
 			h.fileService.DeleteFileIfExists(ctx, uploadedFileID)
 			http.Error(w, "Error interno al procesar archivo", http.StatusInternalServerError)
 			return
 		}
 
-		log.Printf("[ Info ]: Archivo subido exitosamente - ID: %s, URL: %s", uploadedFileID, fileInfo.URL)
+		h.logger.InfoContext(ctx, "archivo DNI subido", "file_id", uploadedFileID, "url", fileInfo.URL)
 	}
 
 	// Validar el paciente
@@ -269,9 +901,9 @@ func (h *PatientHandler) CreatePatientWithFile(w http.ResponseWriter, r *http.Re
 		// Si hay un archivo subido, eliminarlo
 		if uploadedFileID != "" {
 			if deleteErr := h.fileService.DeleteFileIfExists(ctx, uploadedFileID); deleteErr != nil {
-				log.Printf("[ Error al eliminar archivo DNI tras validación fallida ]: %v", deleteErr)
+				h.logger.ErrorContext(ctx, "error al eliminar archivo DNI tras validación fallida", "file_id", uploadedFileID, "error", deleteErr)
 			} else {
-				log.Printf("[ Archivo DNI eliminado tras validación fallida ]: %s", uploadedFileID)
+				h.logger.InfoContext(ctx, "archivo DNI eliminado tras validación fallida", "file_id", uploadedFileID)
 			}
 		}
 		http.Error(w, "Datos del paciente inválidos: "+err.Error(), http.StatusBadRequest)
@@ -283,12 +915,21 @@ func (h *PatientHandler) CreatePatientWithFile(w http.ResponseWriter, r *http.Re
 		// Si hay un archivo subido y falla la creación del paciente, eliminarlo
 		if uploadedFileID != "" {
 			if deleteErr := h.fileService.DeleteFileIfExists(ctx, uploadedFileID); deleteErr != nil {
-				log.Printf("[ Error al eliminar archivo DNI tras fallo en creación ]: %v", deleteErr)
+				h.logger.ErrorContext(ctx, "error al eliminar archivo DNI tras fallo en creación", "file_id", uploadedFileID, "error", deleteErr)
 			} else {
-				log.Printf("[ Archivo DNI eliminado exitosamente tras fallo en creación ]: %s", uploadedFileID)
+				h.logger.InfoContext(ctx, "archivo DNI eliminado tras fallo en creación", "file_id", uploadedFileID)
 			}
 		}
 
+		if errors.Is(err, domain.ErrPatientAgeOutOfRange) {
+			http.Error(w, "Datos del paciente inválidos: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, domain.ErrCaseloadExceeded) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
 		// Determinar el tipo de error para dar mejor feedback
 		errorMessage := err.Error()
 		if strings.Contains(strings.ToLower(errorMessage), "duplicate") ||
@@ -302,22 +943,65 @@ func (h *PatientHandler) CreatePatientWithFile(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	// Obtener el paciente completo por ID (con todas las relaciones)
-	createdPatient, err := h.patientService.GetByID(ctx, patient.ID)
+	if patient.AgeOverride {
+		h.patientService.RecordAgeOverride(ctx, patient, overrideActor)
+	}
+
+	// Obtener el paciente completo por ID (con todas las relaciones)
+	createdPatient, err := h.patientService.GetByID(ctx, patient.ID)
+	if err != nil {
+		h.logger.WarnContext(ctx, "paciente creado pero error al obtener datos completos", "entity_id", patient.ID, "error", err)
+		// No eliminar archivo aquí porque el paciente se creó exitosamente
+		http.Error(w, "Paciente creado pero error al obtener datos completos", http.StatusInternalServerError)
+		return
+	}
+
+	// Respuesta exitosa
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	response := map[string]interface{}{
+		"message": "Paciente creado exitosamente",
+		"patient": createdPatient,
+	}
+	if caseload := h.caseloadWarning(ctx, userID); caseload != nil {
+		response["caseload_warning"] = caseload
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// authorizeAgeOverride exige que la excepción de edad la registre un ADMINISTRADOR identificado con
+// X-User-ID; si no, responde el error y lo devuelve
+func (h *PatientHandler) authorizeAgeOverride(w http.ResponseWriter, r *http.Request) (uuid.UUID, error) {
+	actorID, err := parseActorID(r)
+	if err != nil {
+		http.Error(w, "X-User-ID inválido", http.StatusBadRequest)
+		return uuid.Nil, err
+	}
+	if err := h.patientService.AuthorizeAgeOverride(r.Context(), actorID); err != nil {
+		if errors.Is(err, domain.ErrAgeOverrideForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return uuid.Nil, err
+	}
+	return actorID, nil
+}
+
+// caseloadWarning devuelve la carga del apoderado si supera el límite configurado (nil en otro caso)
+func (h *PatientHandler) caseloadWarning(ctx context.Context, userID uuid.UUID) *domain.Caseload {
+	if domain.MaxPatientsPerCaregiver == 0 {
+		return nil
+	}
+	caseload, err := h.patientService.GetCaseload(ctx, userID)
 	if err != nil {
-		log.Printf("[ Warning ]: Paciente creado pero error al obtener datos completos: %v", err)
-		// No eliminar archivo aquí porque el paciente se creó exitosamente
-		http.Error(w, "Paciente creado pero error al obtener datos completos", http.StatusInternalServerError)
-		return
+		h.logger.WarnContext(ctx, "no se pudo obtener la carga del apoderado", "caregiver_id", userID, "error", err)
+		return nil
 	}
-
-	// Respuesta exitosa
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Paciente creado exitosamente",
-		"patient": createdPatient,
-	})
+	if !caseload.Exceeded {
+		return nil
+	}
+	return caseload
 }
 
 // UpdatePatientWithFile godoc
@@ -328,9 +1012,12 @@ func (h *PatientHandler) CreatePatientWithFile(w http.ResponseWriter, r *http.Re
 // @Produce json
 // @Param id path string true "ID del paciente"
 // @Param patient body object true "Datos actualizados del paciente"
+// @Param X-User-ID header string false "ADMINISTRADOR que autoriza age_override (obligatorio si cambia)"
 // @Success 200 {object} domain.Patient
 // @Failure 400 {object} map[string]string "ID inválido o solicitud inválida"
 // @Failure 404 {object} map[string]string "Paciente no encontrado"
+// @Failure 409 {object} map[string]string "DNI duplicado o apoderado en su límite de pacientes (modo block)"
+// @Failure 403 {object} map[string]string "age_override sin un ADMINISTRADOR"
 // @Failure 500 {object} map[string]string "Error interno del servidor"
 // @Router /api/patients/{id} [put]
 // UpdatePatientWithFile actualiza un paciente existente con sus datos y opcionalmente su archivo DNI
@@ -359,52 +1046,20 @@ func (h *PatientHandler) UpdatePatientWithFile(w http.ResponseWriter, r *http.Re
 	}
 
 	// Parsear y validar campos opcionales (solo actualizar si se proporcionan)
-	updatedPatient := *existingPatient // Copia del paciente existente
-
-	// Actualizar campos si se proporcionan
-	if name := r.FormValue("name"); name != "" {
-		updatedPatient.Name = name
-	}
-	if lastname := r.FormValue("lastname"); lastname != "" {
-		updatedPatient.Lastname = lastname
-	}
-	if dni := r.FormValue("dni"); dni != "" {
-		updatedPatient.DNI = dni
-	}
-	if gender := r.FormValue("gender"); gender != "" {
-		updatedPatient.Gender = gender
-	}
-	if birthDate := r.FormValue("birth_date"); birthDate != "" {
-		updatedPatient.BirthDate = birthDate
-	}
-	if armSize := r.FormValue("arm_size"); armSize != "" {
-		updatedPatient.ArmSize = armSize
-	}
-	if weight := r.FormValue("weight"); weight != "" {
-		updatedPatient.Weight = weight
-	}
-	if size := r.FormValue("size"); size != "" {
-		updatedPatient.Size = size
-	}
-	if description := r.FormValue("description"); description != "" {
-		updatedPatient.Description = description
+	updatedPatient, reassigned, err := applyPatientForm(r, existingPatient)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-
-	// Actualizar age si se proporciona
-	if ageStr := r.FormValue("age"); ageStr != "" {
-		if age, err := strconv.ParseFloat(ageStr, 64); err == nil {
-			updatedPatient.Age = age
-		} else {
-			http.Error(w, "Edad debe ser un número válido", http.StatusBadRequest)
+	overrideChanged := updatedPatient.AgeOverride != existingPatient.AgeOverride ||
+		updatedPatient.AgeOverrideNote != existingPatient.AgeOverrideNote
+	var overrideActor uuid.UUID
+	if overrideChanged {
+		if overrideActor, err = h.authorizeAgeOverride(w, r); err != nil {
 			return
 		}
 	}
 
-	// Actualizar consent_given si se proporciona
-	if consentStr := r.FormValue("consent_given"); consentStr != "" {
-		updatedPatient.ConsentGiven = consentStr == "true"
-	}
-
 	// Variable para rastrear el ID del nuevo archivo subido
 	var newUploadedFileID string
 	var oldFileIDToDelete string
@@ -435,14 +1090,14 @@ func (h *PatientHandler) UpdatePatientWithFile(w http.ResponseWriter, r *http.Re
 
 		// Validar que el ID extraído es un UUID válido
 		if _, err := uuid.Parse(newUploadedFileID); err != nil {
-			log.Printf("[ Error ]: ID de archivo inválido extraído de URL %s -> %s", fileInfo.URL, newUploadedFileID)
+			h.logger.ErrorContext(ctx, "ID de archivo inválido extraído de URL", "url", fileInfo.URL, "file_id", newUploadedFileID)
 			// Intentar eliminar el archivo con el ID inválido
 			h.fileService.DeleteFileIfExists(ctx, newUploadedFileID)
 			http.Error(w, "Error interno al procesar archivo", http.StatusInternalServerError)
 			return
 		}
 
-		log.Printf("[ Info ]: Nuevo archivo subido exitosamente - ID: %s, URL: %s", newUploadedFileID, fileInfo.URL)
+		h.logger.InfoContext(ctx, "nuevo archivo DNI subido", "file_id", newUploadedFileID, "url", fileInfo.URL)
 	}
 
 	// Validar el paciente actualizado
@@ -450,9 +1105,9 @@ func (h *PatientHandler) UpdatePatientWithFile(w http.ResponseWriter, r *http.Re
 		// Si hay un nuevo archivo subido, eliminarlo
 		if newUploadedFileID != "" {
 			if deleteErr := h.fileService.DeleteFileIfExists(ctx, newUploadedFileID); deleteErr != nil {
-				log.Printf("[ Error al eliminar nuevo archivo DNI tras validación fallida ]: %v", deleteErr)
+				h.logger.ErrorContext(ctx, "error al eliminar nuevo archivo DNI tras validación fallida", "file_id", newUploadedFileID, "error", deleteErr)
 			} else {
-				log.Printf("[ Nuevo archivo DNI eliminado tras validación fallida ]: %s", newUploadedFileID)
+				h.logger.InfoContext(ctx, "nuevo archivo DNI eliminado tras validación fallida", "file_id", newUploadedFileID)
 			}
 		}
 		http.Error(w, "Datos del paciente inválidos: "+err.Error(), http.StatusBadRequest)
@@ -464,12 +1119,21 @@ func (h *PatientHandler) UpdatePatientWithFile(w http.ResponseWriter, r *http.Re
 		// Si hay un nuevo archivo subido y falla la actualización, eliminarlo
 		if newUploadedFileID != "" {
 			if deleteErr := h.fileService.DeleteFileIfExists(ctx, newUploadedFileID); deleteErr != nil {
-				log.Printf("[ Error al eliminar nuevo archivo DNI tras fallo en actualización ]: %v", deleteErr)
+				h.logger.ErrorContext(ctx, "error al eliminar nuevo archivo DNI tras fallo en actualización", "file_id", newUploadedFileID, "error", deleteErr)
 			} else {
-				log.Printf("[ Nuevo archivo DNI eliminado exitosamente tras fallo en actualización ]: %s", newUploadedFileID)
+				h.logger.InfoContext(ctx, "nuevo archivo DNI eliminado tras fallo en actualización", "file_id", newUploadedFileID)
 			}
 		}
 
+		if errors.Is(err, domain.ErrPatientAgeOutOfRange) {
+			http.Error(w, "Datos del paciente inválidos: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, domain.ErrCaseloadExceeded) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
 		// Determinar el tipo de error para dar mejor feedback
 		errorMessage := err.Error()
 		if strings.Contains(strings.ToLower(errorMessage), "duplicate") ||
@@ -483,19 +1147,23 @@ func (h *PatientHandler) UpdatePatientWithFile(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	if overrideChanged {
+		h.patientService.RecordAgeOverride(ctx, &updatedPatient, overrideActor)
+	}
+
 	// Si la actualización fue exitosa y había un archivo anterior, eliminarlo
 	if oldFileIDToDelete != "" && newUploadedFileID != "" {
 		if deleteErr := h.fileService.DeleteFileIfExists(ctx, oldFileIDToDelete); deleteErr != nil {
-			log.Printf("[ Warning ]: No se pudo eliminar archivo DNI anterior: %v", deleteErr)
+			h.logger.WarnContext(ctx, "no se pudo eliminar archivo DNI anterior", "file_id", oldFileIDToDelete, "error", deleteErr)
 		} else {
-			log.Printf("[ Info ]: Archivo DNI anterior eliminado exitosamente: %s", oldFileIDToDelete)
+			h.logger.InfoContext(ctx, "archivo DNI anterior eliminado", "file_id", oldFileIDToDelete)
 		}
 	}
 
 	// Obtener el paciente actualizado completo (con todas las relaciones)
 	finalPatient, err := h.patientService.GetByID(ctx, updatedPatient.ID)
 	if err != nil {
-		log.Printf("[ Warning ]: Paciente actualizado pero error al obtener datos completos: %v", err)
+		h.logger.WarnContext(ctx, "paciente actualizado pero error al obtener datos completos", "entity_id", id, "error", err)
 		http.Error(w, "Paciente actualizado pero error al obtener datos completos", http.StatusInternalServerError)
 		return
 	}
@@ -503,10 +1171,191 @@ func (h *PatientHandler) UpdatePatientWithFile(w http.ResponseWriter, r *http.Re
 	// Respuesta exitosa
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	response := map[string]interface{}{
 		"message": "Paciente actualizado exitosamente",
 		"patient": finalPatient,
-	})
+	}
+	if reassigned {
+		if caseload := h.caseloadWarning(ctx, *updatedPatient.UserID); caseload != nil {
+			response["caseload_warning"] = caseload
+		}
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// UpdatePatientConsent godoc
+// @Summary Actualizar el consentimiento del paciente
+// @Description Registra el retiro (given=false, con motivo obligatorio) o un nuevo consentimiento (given=true) de la familia, con fecha y usuario (cabecera X-User-ID). Mientras el consentimiento esté retirado se rechaza el registro de mediciones
+// @Tags pacientes
+// @Accept json
+// @Produce json
+// @Param id path string true "ID del paciente"
+// @Param X-User-ID header string false "Usuario que registra el cambio"
+// @Param consent body object true "Consentimiento" example({"given":false,"reason":"La familia se mudó fuera de la comunidad"})
+// @Success 200 {object} domain.Patient
+// @Failure 400 {object} map[string]string "ID inválido, cuerpo inválido o falta el motivo del retiro"
+// @Failure 404 {object} map[string]string "Paciente no encontrado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/patients/{id}/consent [put]
+func (h *PatientHandler) UpdatePatientConsent(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	actorID, err := parseActorID(r)
+	if err != nil {
+		http.Error(w, "X-User-ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Given  *bool  `json:"given"`
+		Reason string `json:"reason"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Given == nil {
+		http.Error(w, "given es obligatorio", http.StatusBadRequest)
+		return
+	}
+
+	var actor *uuid.UUID
+	if actorID != uuid.Nil {
+		actor = &actorID
+	}
+
+	patient, err := h.patientService.UpdateConsent(ctx, id, *req.Given, req.Reason, actor)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrPatientNotFound):
+			http.Error(w, "Paciente no encontrado", http.StatusNotFound)
+		case errors.Is(err, domain.ErrEmptyConsentReason):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.logger.InfoContext(ctx, "auditoría: consentimiento del paciente actualizado",
+		"entity_id", patient.ID, "consent_given", patient.ConsentGiven, "reason", patient.ConsentReason)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(patient)
+}
+
+// RecomputePatient godoc
+// @Summary Recalcular los datos derivados de un paciente
+// @Description Vuelve a calcular la edad a partir de la fecha de nacimiento (útil tras corregirla o tras correcciones masivas) y devuelve el paciente con su última medición. Es idempotente y queda registrado en la auditoría con el usuario de la cabecera X-User-ID
+// @Tags pacientes
+// @Produce json
+// @Param id path string true "ID del paciente"
+// @Param X-User-ID header string false "Usuario que solicita el recálculo"
+// @Success 200 {object} domain.Patient
+// @Failure 400 {object} map[string]string "ID inválido"
+// @Failure 404 {object} map[string]string "Paciente no encontrado"
+// @Failure 422 {object} map[string]string "Fecha de nacimiento inválida"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/patients/{id}/recompute [post]
+func (h *PatientHandler) RecomputePatient(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	actorID, err := parseActorID(r)
+	if err != nil {
+		http.Error(w, "X-User-ID inválido", http.StatusBadRequest)
+		return
+	}
+	var actor *uuid.UUID
+	if actorID != uuid.Nil {
+		actor = &actorID
+	}
+
+	patient, err := h.patientService.Recompute(ctx, id, actor)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrPatientNotFound):
+			http.Error(w, "Paciente no encontrado", http.StatusNotFound)
+		case errors.Is(err, domain.ErrRecomputeBirthDate):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(patient)
+}
+
+// UpdatePatientApproval godoc
+// @Summary Aprobar o rechazar un paciente
+// @Description Un SUPERVISOR o ADMINISTRADOR (cabecera X-User-ID) aprueba o rechaza un paciente registrado por un apoderado, con fecha y usuario. El rechazo exige una nota. Solo aplica si PATIENT_APPROVAL_REQUIRED está activo; en otro caso los pacientes se crean aprobados
+// @Tags pacientes
+// @Accept json
+// @Produce json
+// @Param id path string true "ID del paciente"
+// @Param X-User-ID header string true "Supervisor o administrador que revisa"
+// @Param approval body object true "Aprobación" example({"status":"rejected","note":"El DNI no corresponde al menor"})
+// @Success 200 {object} domain.Patient
+// @Failure 400 {object} map[string]string "Estado inválido o falta la nota del rechazo"
+// @Failure 403 {object} map[string]string "El usuario no es SUPERVISOR ni ADMINISTRADOR"
+// @Failure 404 {object} map[string]string "Paciente no encontrado"
+// @Failure 409 {object} map[string]string "El paciente ya tiene ese estado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/patients/{id}/approval [put]
+func (h *PatientHandler) UpdatePatientApproval(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	actorID, err := parseActorID(r)
+	if err != nil {
+		http.Error(w, "X-User-ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Status string `json:"status"`
+		Note   string `json:"note"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	patient, err := h.patientService.UpdateApproval(ctx, id, req.Status, req.Note, actorID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrPatientNotFound):
+			http.Error(w, "Paciente no encontrado", http.StatusNotFound)
+		case errors.Is(err, domain.ErrApprovalForbidden):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, domain.ErrInvalidApprovalStatus), errors.Is(err, domain.ErrEmptyRejectionNote):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, domain.ErrApprovalUnchanged):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(patient)
 }
 
 // DeletePatient godoc
@@ -571,8 +1420,7 @@ func (h *PatientHandler) GetPatientsByFatherID(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(patients)
+	writeList(w, patients, nil)
 }
 
 // GetPatientMeasurements obtiene las mediciones de un paciente específico
@@ -597,8 +1445,7 @@ func (h *PatientHandler) GetPatientMeasurements(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(measurements)
+	writeList(w, measurements, nil)
 }
 
 // // AddPatientMeasurement añade una nueva medición a un paciente
@@ -674,10 +1521,11 @@ func (h *PatientHandler) AddPatientMeasurement(w http.ResponseWriter, r *http.Re
 		MuacValue   float64   `json:"muac_value" validate:"required,gt=0"`
 		Description string    `json:"description"`
 		UserID      uuid.UUID `json:"user_id" validate:"required"`
+		MeasuredAt  time.Time `json:"measured_at"` // opcional: hora del dispositivo (offline)
+		TapeBatch   string    `json:"tape_batch"`  // opcional: lote de la cinta MUAC
 	}
 
-	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Solicitud inválida: "+err.Error(), http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
@@ -697,6 +1545,12 @@ func (h *PatientHandler) AddPatientMeasurement(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	tapeBatch, err := domain.NormalizeTapeBatch(req.TapeBatch)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Verificar que el paciente existe
 	patient, err := h.patientService.GetByID(ctx, patientID)
 	if err != nil {
@@ -715,17 +1569,25 @@ func (h *PatientHandler) AddPatientMeasurement(w http.ResponseWriter, r *http.Re
 		req.Description,
 		patientID,
 		req.UserID,
+		req.MeasuredAt,
+		nil,
+		tapeBatch,
 	)
 
 	if err != nil {
 		// Manejar diferentes tipos de errores
 		switch {
+		case errors.Is(err, domain.ErrPatientAgeOutOfRange), errors.Is(err, domain.ErrEmptyAgeOverrideNote),
+			errors.Is(err, domain.ErrFutureMeasurementTime), errors.Is(err, domain.ErrEmptyRiskDescription):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, domain.ErrPatientConsentWithdrawn):
+			http.Error(w, err.Error(), http.StatusConflict)
 		case strings.Contains(err.Error(), "valor MUAC inválido"):
 			http.Error(w, err.Error(), http.StatusBadRequest)
 		case strings.Contains(err.Error(), "usuario no encontrado"):
 			http.Error(w, "Usuario no encontrado", http.StatusNotFound)
 		default:
-			log.Printf("Error creando medición con auto-asignación: %v", err)
+			h.logger.ErrorContext(ctx, "error creando medición con auto-asignación", "patient_id", patientID, "error", err)
 			http.Error(w, "Error interno del servidor", http.StatusInternalServerError)
 		}
 		return
@@ -735,50 +1597,13 @@ func (h *PatientHandler) AddPatientMeasurement(w http.ResponseWriter, r *http.Re
 	response := map[string]interface{}{
 		"success": true,
 		"message": "Medición agregada exitosamente con clasificación automática",
-		"data": map[string]interface{}{
-			"measurement": map[string]interface{}{
-				"id":          measurement.ID,
-				"muac_value":  measurement.MuacValue,
-				"description": measurement.Description,
-				"patient_id":  measurement.PatientID,
-				"user_id":     measurement.UserID,
-				"created_at":  measurement.CreatedAt,
-			},
-			"patient": map[string]interface{}{
-				"id":       patient.ID,
-				"name":     patient.Name,
-				"lastname": patient.Lastname,
-			},
-			"classification": map[string]interface{}{
-				"tag": map[string]interface{}{
-					"id":          measurement.Tag.ID,
-					"name":        measurement.Tag.Name,
-					"description": measurement.Tag.Description,
-					"color":       measurement.Tag.Color,
-					"muac_code":   measurement.Tag.MuacCode,
-					"priority":    measurement.Tag.Priority,
-				},
-				"recommendation": map[string]interface{}{
-					"id":                    measurement.Recommendation.ID,
-					"name":                  measurement.Recommendation.Name,
-					"description":           measurement.Recommendation.Description,
-					"recommendation_umbral": measurement.Recommendation.RecommendationUmbral,
-					"priority":              measurement.Recommendation.Priority,
-					"color_code":            measurement.Recommendation.ColorCode,
-					"muac_code":             measurement.Recommendation.MuacCode,
-				},
-			},
-			"muac_analysis": map[string]interface{}{
-				"risk_level":     domain.GetMuacRiskLevel(req.MuacValue),
-				"threshold_info": getMuacThresholdInfo(req.MuacValue),
-			},
-		},
+		"data":    newMeasurementDetail(measurement, patient),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding response: %v", err)
+		h.logger.ErrorContext(ctx, "error al codificar respuesta", "entity_id", measurement.ID, "error", err)
 	}
 }
 
@@ -803,13 +1628,133 @@ func (h *PatientHandler) GetPatientsInRisk(w http.ResponseWriter, r *http.Reques
 		totalPatients += len(user.Patients)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message":        "Pacientes en riesgo obtenidos exitosamente",
-		"count":          len(users),
-		"patients_count": totalPatients,
-		"data":           users,
+	response := NewListResponse(users, nil)
+	response.Meta = map[string]interface{}{"patients_count": totalPatients}
+	writeListResponse(w, response)
+}
+
+// GetPatientsTriage godoc
+// @Summary Obtener pacientes para triaje
+// @Description Lista pacientes ordenados por severidad (MUAC ascendente) y recencia de su última medición
+// @Tags pacientes
+// @Accept json
+// @Produce json
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param user_id query string false "ID del usuario para filtrar"
+// @Param page query int false "Página (default: 1)"
+// @Param page_size query int false "Tamaño de página (default: 20)"
+// @Success 200 {object} ListResponse{data=[]domain.TriagePatient}
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/patients/triage [get]
+func (h *PatientHandler) GetPatientsTriage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// El triaje considera todas las mediciones salvo que se pida una ventana
+	if r.URL.Query().Get("days") == "" {
+		filters.Days = 0
+	}
+
+	page, err := parsePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	patients, err := h.patientService.GetTriage(ctx, filters, page)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeList(w, patients, page)
+}
+
+// ExportPatients godoc
+// @Summary Exportar pacientes a CSV
+// @Description Descarga todos los pacientes visibles para el usuario (X-User-ID) con datos demográficos, apoderado, localidad, cantidad de mediciones y la última medición con su clasificación. El administrador exporta todo, el supervisor su localidad y el apoderado sus pacientes. El archivo se genera en streaming, sin paginación
+// @Tags pacientes
+// @Produce text/csv
+// @Param X-User-ID header string true "Usuario que exporta"
+// @Param format query string false "Formato del archivo (solo csv)"
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param user_id query string false "ID del apoderado para filtrar"
+// @Success 200 {file} file "Archivo CSV"
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 403 {object} map[string]string "Fuera del alcance del usuario"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/patients/export [get]
+func (h *PatientHandler) ExportPatients(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if format := r.URL.Query().Get("format"); format != "" && format != "csv" {
+		http.Error(w, "format solo admite csv", http.StatusBadRequest)
+		return
+	}
+
+	actorID, err := parseActorID(r)
+	if err != nil {
+		http.Error(w, "X-User-ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Las cabeceras se envían con la primera fila, para poder responder con un error
+	// si el usuario no tiene permiso o la consulta falla antes de empezar
+	filename := fmt.Sprintf("pacientes_%s.csv", time.Now().Format("2006-01-02_15-04-05"))
+	var writer *csv.Writer
+	start := func() error {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+		writer = csv.NewWriter(w)
+		return writer.Write(domain.PatientExportHeader)
+	}
+
+	rows := 0
+	err = h.patientService.ExportPatients(ctx, actorID, filters, func(row domain.PatientExportRow) error {
+		if writer == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		rows++
+		return writer.Write(row.Record())
 	})
+	if err != nil {
+		if writer != nil {
+			h.logger.ErrorContext(ctx, "exportación de pacientes interrumpida", "filename", filename, "rows", rows, "error", err)
+			writer.Flush()
+			return
+		}
+		switch {
+		case errors.Is(err, domain.ErrPatientExportForbidden):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if writer == nil {
+		if err := start(); err != nil {
+			h.logger.ErrorContext(ctx, "error al escribir exportación de pacientes", "filename", filename, "error", err)
+			return
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		h.logger.ErrorContext(ctx, "error al escribir exportación de pacientes", "filename", filename, "rows", rows, "error", err)
+	}
 }
 
 // parseFilters parsea los query parameters a filtros
@@ -869,6 +1814,62 @@ func (h *PatientHandler) parseFilters(r *http.Request) (*domain.ReportFilters, e
 	return filters, nil
 }
 
+// newMeasurementDetail arma la vista detallada de una medición (datos, paciente, clasificación y análisis MUAC).
+// La comparten AddPatientMeasurement y GetMeasurementByID con ?expand=detail para que el cliente reciba la misma forma.
+func newMeasurementDetail(measurement *domain.Measurement, patient *domain.Patient) map[string]interface{} {
+	detail := map[string]interface{}{
+		"measurement": map[string]interface{}{
+			"id":            measurement.ID,
+			"muac_value":    measurement.MuacValue,
+			"description":   measurement.Description,
+			"patient_id":    measurement.PatientID,
+			"user_id":       measurement.UserID,
+			"created_at":    measurement.CreatedAt,
+			"time_adjusted": measurement.TimeAdjusted,
+			"tape_batch":    measurement.TapeBatch,
+		},
+		"muac_analysis": map[string]interface{}{
+			"risk_level":     domain.GetMuacRiskLevel(measurement.MuacValue),
+			"threshold_info": getMuacThresholdInfo(measurement.MuacValue),
+		},
+	}
+
+	if patient != nil {
+		detail["patient"] = map[string]interface{}{
+			"id":       patient.ID,
+			"name":     patient.Name,
+			"lastname": patient.Lastname,
+		}
+	}
+
+	// Las mediciones aún sin clasificar no tienen etiqueta ni recomendación
+	classification := map[string]interface{}{"tag": nil, "recommendation": nil}
+	if measurement.Tag != nil {
+		classification["tag"] = map[string]interface{}{
+			"id":          measurement.Tag.ID,
+			"name":        measurement.Tag.Name,
+			"description": measurement.Tag.Description,
+			"color":       measurement.Tag.Color,
+			"muac_code":   measurement.Tag.MuacCode,
+			"priority":    measurement.Tag.Priority,
+		}
+	}
+	if measurement.Recommendation != nil {
+		classification["recommendation"] = map[string]interface{}{
+			"id":                    measurement.Recommendation.ID,
+			"name":                  measurement.Recommendation.Name,
+			"description":           measurement.Recommendation.Description,
+			"recommendation_umbral": measurement.Recommendation.RecommendationUmbral,
+			"priority":              measurement.Recommendation.Priority,
+			"color_code":            measurement.Recommendation.ColorCode,
+			"muac_code":             measurement.Recommendation.MuacCode,
+		}
+	}
+	detail["classification"] = classification
+
+	return detail
+}
+
 // getMuacThresholdInfo proporciona información contextual sobre los umbrales MUAC
 func getMuacThresholdInfo(muacValue float64) map[string]interface{} {
 	info := map[string]interface{}{
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+)
+
+func newPatientFormRequest(values url.Values) *http.Request {
+	req := httptest.NewRequest(http.MethodPut, "/api/patients/"+uuid.NewString(), strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestApplyPatientFormConsent(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  bool
+		consent  string
+		wantErr  bool
+		wantKeep bool
+	}{
+		{"sin consent_given", true, "", false, true},
+		{"reenvía el valor actual", true, "true", false, true},
+		{"reenvía el retiro actual", false, "false", false, false},
+		{"intenta retirar", true, "false", true, true},
+		{"intenta volver a consentir", false, "true", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := &domain.Patient{ID: uuid.New(), Name: "Ana", Lastname: "Quispe", ConsentGiven: tt.current}
+			values := url.Values{"name": {"Ana María"}}
+			if tt.consent != "" {
+				values.Set("consent_given", tt.consent)
+			}
+
+			updated, _, err := applyPatientForm(newPatientFormRequest(values), existing)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && updated.ConsentGiven != tt.wantKeep {
+				t.Errorf("consent_given = %v, se esperaba %v", updated.ConsentGiven, tt.wantKeep)
+			}
+		})
+	}
+}
@@ -2,7 +2,10 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
@@ -27,7 +30,10 @@ func (h *RecommendationHandler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("POST /api/recommendations", h.CreateRecommendation)
 	mux.HandleFunc("GET /api/recommendations/{id}", h.GetRecommendationByID)
 	mux.HandleFunc("PUT /api/recommendations/{id}", h.UpdateRecommendation)
+	mux.HandleFunc("PATCH /api/recommendations/{id}/muac", h.PatchRecommendationMuac)
 	mux.HandleFunc("DELETE /api/recommendations/{id}", h.DeleteRecommendation)
+	mux.HandleFunc("POST /api/recommendations/{id}/propagate", h.PropagateRecommendation)
+	mux.HandleFunc("POST /api/recommendations/{id}/impact", h.RecommendationImpact)
 	mux.HandleFunc("GET /api/recommendations/name/{name}", h.GetRecommendationByName)
 	mux.HandleFunc("GET /api/recommendations/umbral/{umbral}", h.GetRecommendationsByUmbral)
 }
@@ -38,7 +44,7 @@ func (h *RecommendationHandler) RegisterRoutes(mux *http.ServeMux) {
 // @Tags recomendaciones
 // @Accept json
 // @Produce json
-// @Success 200 {array} domain.Recommendation
+// @Success 200 {object} ListResponse{data=[]domain.Recommendation}
 // @Failure 500 {object} map[string]string "Error interno del servidor"
 // @Router /api/recommendations [get]
 func (h *RecommendationHandler) GetAllRecommendations(w http.ResponseWriter, r *http.Request) {
@@ -50,8 +56,7 @@ func (h *RecommendationHandler) GetAllRecommendations(w http.ResponseWriter, r *
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(recommendations)
+	writeList(w, recommendations, nil)
 }
 
 // CreateRecommendation godoc
@@ -74,8 +79,7 @@ func (h *RecommendationHandler) CreateRecommendation(w http.ResponseWriter, r *h
 		Umbral      string `json:"recommendation_umbral"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Solicitud inválida", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
@@ -166,8 +170,7 @@ func (h *RecommendationHandler) UpdateRecommendation(w http.ResponseWriter, r *h
 		Umbral      string `json:"recommendation_umbral"`
 	}
 
-	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Solicitud inválida", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
@@ -192,6 +195,108 @@ func (h *RecommendationHandler) UpdateRecommendation(w http.ResponseWriter, r *h
 	json.NewEncoder(w).Encode(recommendation)
 }
 
+// PatchRecommendationMuac godoc
+// @Summary Editar los campos MUAC de una recomendación
+// @Description Actualiza parcialmente min_value, max_value, priority, color_code y muac_code; los campos omitidos conservan su valor
+// @Description y min_value o max_value en null quitan ese límite. El umbral se regenera a partir del rango.
+// @Description Cambiar el rango no reasigna mediciones existentes: usar POST /api/recommendations/{id}/propagate.
+// @Tags recomendaciones
+// @Accept json
+// @Produce json
+// @Param id path string true "ID de la recomendación"
+// @Param recommendation body object true "Campos MUAC a modificar"
+// @Success 200 {object} domain.Recommendation
+// @Failure 400 {object} map[string]string "ID inválido, rango inválido (min > max) o código MUAC inválido"
+// @Failure 404 {object} map[string]string "Recomendación no encontrada"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/recommendations/{id}/muac [patch]
+func (h *RecommendationHandler) PatchRecommendationMuac(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	// RawMessage distingue un campo omitido (se conserva) de uno en null (se quita el límite)
+	var req struct {
+		MinValue  json.RawMessage `json:"min_value"`
+		MaxValue  json.RawMessage `json:"max_value"`
+		Priority  *int            `json:"priority"`
+		ColorCode *string         `json:"color_code"`
+		MuacCode  *string         `json:"muac_code"`
+	}
+
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	recommendation, err := h.recommendationService.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrRecommendationNotFound) {
+			http.Error(w, "Recomendación no encontrada", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	minValue, err := patchFloat(recommendation.MinValue, req.MinValue)
+	if err != nil {
+		http.Error(w, "min_value debe ser numérico o null", http.StatusBadRequest)
+		return
+	}
+	maxValue, err := patchFloat(recommendation.MaxValue, req.MaxValue)
+	if err != nil {
+		http.Error(w, "max_value debe ser numérico o null", http.StatusBadRequest)
+		return
+	}
+	priority := recommendation.Priority
+	if req.Priority != nil {
+		priority = *req.Priority
+	}
+	colorCode := recommendation.ColorCode
+	if req.ColorCode != nil {
+		colorCode = *req.ColorCode
+	}
+	muacCode := recommendation.MuacCode
+	if req.MuacCode != nil {
+		muacCode = strings.ToUpper(strings.TrimSpace(*req.MuacCode))
+	}
+
+	err = recommendation.UpdateMuacRecommendation("", "", minValue, maxValue, priority, colorCode, muacCode)
+	if err == nil {
+		err = h.recommendationService.Update(ctx, recommendation)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidMuacRange), errors.Is(err, domain.ErrInvalidMuacValue),
+			errors.Is(err, domain.ErrInvalidPriority), errors.Is(err, domain.ErrInvalidMuacCode),
+			errors.Is(err, domain.ErrInvalidTagColor):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recommendation)
+}
+
+// patchFloat aplica un campo numérico opcional: omitido conserva current, null lo quita
+func patchFloat(current *float64, raw json.RawMessage) (*float64, error) {
+	if len(raw) == 0 {
+		return current, nil
+	}
+	var value *float64
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
 // DeleteRecommendation godoc
 // @Summary Eliminar una recomendación
 // @Description Elimina una recomendación por su ID
@@ -274,7 +379,7 @@ func (h *RecommendationHandler) GetRecommendationByName(w http.ResponseWriter, r
 // @Accept json
 // @Produce json
 // @Param umbral path string true "Umbral de la recomendación"
-// @Success 200 {array} domain.Recommendation
+// @Success 200 {object} ListResponse{data=[]domain.Recommendation}
 // @Failure 400 {object} map[string]string "Umbral no proporcionado"
 // @Failure 500 {object} map[string]string "Error interno del servidor"
 // @Router /api/recommendations/umbral/{umbral} [get]
@@ -293,6 +398,128 @@ func (h *RecommendationHandler) GetRecommendationsByUmbral(w http.ResponseWriter
 		return
 	}
 
+	writeList(w, recommendations, nil)
+}
+
+// PropagateRecommendation godoc
+// @Summary Propagar una recomendación a las mediciones
+// @Description Reasigna la recomendación a todas las mediciones cuyo valor MUAC cae en su rango (útil tras cambiar el rango).
+// @Description Los cambios de texto no requieren propagación: las mediciones referencian la recomendación por ID.
+// @Description Con dry_run=true solo devuelve cuántas mediciones se verían afectadas.
+// @Tags recomendaciones
+// @Accept json
+// @Produce json
+// @Param id path string true "ID de la recomendación"
+// @Param dry_run query bool false "Simular sin modificar mediciones"
+// @Success 200 {object} domain.RecommendationPropagation
+// @Failure 400 {object} map[string]string "ID inválido o recomendación sin rango MUAC"
+// @Failure 404 {object} map[string]string "Recomendación no encontrada"
+// @Failure 409 {object} map[string]string "Recomendación inactiva"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/recommendations/{id}/propagate [post]
+func (h *RecommendationHandler) PropagateRecommendation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	dryRun := false
+	if dryRunStr := r.URL.Query().Get("dry_run"); dryRunStr != "" {
+		dryRun, err = strconv.ParseBool(dryRunStr)
+		if err != nil {
+			http.Error(w, "dry_run debe ser true o false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	propagation, err := h.recommendationService.Propagate(ctx, id, dryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecommendationNotFound):
+			http.Error(w, "Recomendación no encontrada", http.StatusNotFound)
+		case errors.Is(err, domain.ErrRecommendationNoRange):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, domain.ErrRecommendationInactive):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(recommendations)
-}
\ No newline at end of file
+	json.NewEncoder(w).Encode(propagation)
+}
+
+// RecommendationImpact godoc
+// @Summary Analizar el impacto de cambiar el rango MUAC de una recomendación
+// @Description Recibe min_value y max_value propuestos (omitido conserva el actual, null quita el límite) y devuelve cuántas
+// @Description mediciones que hoy referencian la recomendación dejarían de coincidir con el nuevo rango, a qué recomendación
+// @Description pasarían y una muestra de hasta 20 mediciones. No guarda cambios.
+// @Tags recomendaciones
+// @Accept json
+// @Produce json
+// @Param id path string true "ID de la recomendación"
+// @Param range body object true "Rango MUAC propuesto"
+// @Success 200 {object} domain.RecommendationImpact
+// @Failure 400 {object} map[string]string "ID o rango inválido"
+// @Failure 404 {object} map[string]string "Recomendación no encontrada"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/recommendations/{id}/impact [post]
+func (h *RecommendationHandler) RecommendationImpact(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		MinValue json.RawMessage `json:"min_value"`
+		MaxValue json.RawMessage `json:"max_value"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	recommendation, err := h.recommendationService.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrRecommendationNotFound) {
+			http.Error(w, "Recomendación no encontrada", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	minValue, err := patchFloat(recommendation.MinValue, req.MinValue)
+	if err != nil {
+		http.Error(w, "min_value debe ser numérico o null", http.StatusBadRequest)
+		return
+	}
+	maxValue, err := patchFloat(recommendation.MaxValue, req.MaxValue)
+	if err != nil {
+		http.Error(w, "max_value debe ser numérico o null", http.StatusBadRequest)
+		return
+	}
+
+	impact, err := h.recommendationService.Impact(ctx, id, minValue, maxValue)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecommendationNotFound):
+			http.Error(w, "Recomendación no encontrada", http.StatusNotFound)
+		case errors.Is(err, domain.ErrInvalidMuacRange), errors.Is(err, domain.ErrInvalidMuacValue):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(impact)
+}
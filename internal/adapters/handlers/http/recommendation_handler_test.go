@@ -0,0 +1,112 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+)
+
+// fakeRecommendationService guarda en memoria una recomendación y registra las actualizaciones
+type fakeRecommendationService struct {
+	ports.IRecommendationService
+	recommendation *domain.Recommendation
+	updated        bool
+}
+
+func (f *fakeRecommendationService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Recommendation, error) {
+	if id != f.recommendation.ID {
+		return nil, domain.ErrRecommendationNotFound
+	}
+	r := *f.recommendation
+	return &r, nil
+}
+
+func (f *fakeRecommendationService) Update(ctx context.Context, recommendation *domain.Recommendation) error {
+	f.updated = true
+	f.recommendation = recommendation
+	return nil
+}
+
+func TestPatchRecommendationMuac(t *testing.T) {
+	minValue, maxValue := 11.5, 12.5
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantMin    *float64
+		wantMax    *float64
+		wantCode   string
+	}{
+		{"rango válido", `{"min_value": 11.0, "max_value": 12.0}`, http.StatusOK, ptrFloat(11.0), ptrFloat(12.0), domain.MuacCodeYellow},
+		{"código en minúsculas", `{"muac_code": " muac-r1 "}`, http.StatusOK, &minValue, &maxValue, domain.MuacCodeRed},
+		{"null quita el límite", `{"max_value": null}`, http.StatusOK, &minValue, nil, domain.MuacCodeYellow},
+		{"min mayor que max", `{"min_value": 13.0, "max_value": 12.0}`, http.StatusBadRequest, nil, nil, ""},
+		{"min mayor que el max guardado", `{"min_value": 13.0}`, http.StatusBadRequest, nil, nil, ""},
+		{"código MUAC inválido", `{"muac_code": "NARANJA"}`, http.StatusBadRequest, nil, nil, ""},
+		{"min no numérico", `{"min_value": "once"}`, http.StatusBadRequest, nil, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeRecommendationService{recommendation: &domain.Recommendation{
+				ID:        uuid.New(),
+				Name:      "Control semanal",
+				MinValue:  &minValue,
+				MaxValue:  &maxValue,
+				Priority:  2,
+				ColorCode: "#FFC107",
+				MuacCode:  domain.MuacCodeYellow,
+			}}
+			mux := http.NewServeMux()
+			NewRecommendationHandler(service).RegisterRoutes(mux)
+
+			req := httptest.NewRequest(http.MethodPatch, "/api/recommendations/"+service.recommendation.ID.String()+"/muac", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, se esperaba %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				if service.updated {
+					t.Fatal("se guardó una recomendación inválida")
+				}
+				return
+			}
+
+			var got domain.Recommendation
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("respuesta no es JSON: %v", err)
+			}
+			if !equalFloatPtr(got.MinValue, tt.wantMin) || !equalFloatPtr(got.MaxValue, tt.wantMax) {
+				t.Errorf("rango = %v-%v, se esperaba %v-%v", got.MinValue, got.MaxValue, tt.wantMin, tt.wantMax)
+			}
+			if got.MuacCode != tt.wantCode {
+				t.Errorf("muac_code = %q, se esperaba %q", got.MuacCode, tt.wantCode)
+			}
+			if got.Name != "Control semanal" || got.Priority != 2 {
+				t.Errorf("los campos omitidos deben conservarse, got name=%q priority=%d", got.Name, got.Priority)
+			}
+		})
+	}
+}
+
+func ptrFloat(v float64) *float64 {
+	return &v
+}
+
+func equalFloatPtr(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -2,9 +2,11 @@
 package http
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
@@ -18,13 +20,15 @@ import (
 type ReportHandler struct {
 	reportService ports.IReportService
 	excelService  ports.IFileService
+	logger        *slog.Logger
 }
 
 // NewReportHandler crea una nueva instancia de ReportHandler
-func NewReportHandler(reportService ports.IReportService, excelService ports.IFileService) *ReportHandler {
+func NewReportHandler(reportService ports.IReportService, excelService ports.IFileService, logger *slog.Logger) *ReportHandler {
 	return &ReportHandler{
 		reportService: reportService,
 		excelService:  excelService,
+		logger:        logger,
 	}
 }
 
@@ -37,6 +41,37 @@ func (h *ReportHandler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /api/reports/user-activity", h.GetUserActivity)
 	mux.HandleFunc("GET /api/reports/risk-patients-coordinates", h.GetRiskPatientsCoordinates)
 	mux.HandleFunc("GET /api/reports/risk-patients/excel", h.GetRiskPatientsExcel)
+	mux.HandleFunc("GET /api/reports/monthly/{year}/{month}/excel", h.GetMonthlyExcel)
+	mux.HandleFunc("GET /api/reports/recommendation-usage", h.GetRecommendationUsage)
+	mux.HandleFunc("GET /api/reports/recovery-rate", h.GetRecoveryRate)
+	mux.HandleFunc("GET /api/reports/prevalence", h.GetPrevalence)
+	mux.HandleFunc("GET /api/reports/seasonal-trend", h.GetSeasonalTrend)
+	mux.HandleFunc("GET /api/reports/age-distribution", h.GetAgeDistribution)
+	mux.HandleFunc("GET /api/reports/aging-out", h.GetAgingOut)
+	mux.HandleFunc("GET /api/reports/registrations-timeline", h.GetRegistrationsTimeline)
+	mux.HandleFunc("GET /api/reports/counters", h.GetCounters)
+	mux.HandleFunc("GET /api/reports/measurement-intervals", h.GetMeasurementIntervals)
+	mux.HandleFunc("GET /api/reports/flagged-measurements", h.GetFlaggedMeasurements)
+	mux.HandleFunc("GET /api/reports/uncovered-localities", h.GetUncoveredLocalities)
+	mux.HandleFunc("GET /api/reports/measurement-heatcells", h.GetMeasurementHeatcells)
+	mux.HandleFunc("GET /api/reports/active-localities", h.GetActiveLocalities)
+	mux.HandleFunc("GET /api/reports/measurements-by-hour", h.GetMeasurementsByHour)
+	mux.HandleFunc("GET /api/reports/alert-response-times", h.GetAlertResponseTimes)
+	mux.HandleFunc("GET /api/reports/period-comparison", h.GetPeriodComparison)
+	mux.HandleFunc("GET /api/reports/caseload-distribution", h.GetCaseloadDistribution)
+	mux.HandleFunc("GET /api/reports/transitions/export", h.ExportTransitions)
+	mux.HandleFunc("GET /api/reports/pending-review", h.GetPendingReview)
+	mux.HandleFunc("GET /api/reports/gps-coverage", h.GetGPSCoverage)
+	mux.HandleFunc("GET /api/reports/by-tape-batch", h.GetTapeBatchReport)
+	mux.HandleFunc("GET /api/reports/locality-severity-index", h.GetLocalitySeverityIndex)
+	mux.HandleFunc("GET /api/reports/override-analysis", h.GetOverrideAnalysis)
+	mux.HandleFunc("GET /api/reports/my-dashboard", h.GetMyDashboard)
+	mux.HandleFunc("GET /api/reports/data-completeness", h.GetDataCompleteness)
+	mux.HandleFunc("GET /api/reports/target-progress", h.GetTargetProgress)
+	mux.HandleFunc("GET /api/reports/caregiver-accuracy", h.GetCaregiverAccuracy)
+	mux.HandleFunc("GET /api/reports/time-to-recovery", h.GetTimeToRecovery)
+	mux.HandleFunc("GET /api/users/{id}/leaderboard", h.GetCaregiverLeaderboard)
+	mux.HandleFunc("GET /api/users/{id}/reliability-trend", h.GetReliabilityTrend)
 }
 
 // GetDashboard godoc
@@ -46,7 +81,9 @@ func (h *ReportHandler) RegisterRoutes(mux *http.ServeMux) {
 // @Accept json
 // @Produce json
 // @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param user_id query string false "ID del apoderado: solo sus pacientes asignados"
 // @Param days query int false "Número de días hacia atrás (default: 30)"
+// @Param approved_only query bool false "Solo pacientes aprobados"
 // @Success 200 {object} domain.DashboardReport
 // @Failure 400 {object} map[string]string "Parámetros inválidos"
 // @Failure 500 {object} map[string]string "Error interno del servidor"
@@ -70,6 +107,41 @@ func (h *ReportHandler) GetDashboard(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(report)
 }
 
+// GetMyDashboard godoc
+// @Summary Obtener el resumen de inicio del apoderado
+// @Description Devuelve, solo para los pacientes asignados al usuario de X-User-ID, el total de pacientes, su distribución por estado según la última medición, los pacientes sin medir, las mediciones de la semana y del mes en curso y los controles vencidos
+// @Tags reports
+// @Produce json
+// @Param X-User-ID header string true "Usuario que consulta"
+// @Success 200 {object} domain.CaregiverDashboard
+// @Failure 400 {object} map[string]string "X-User-ID inválido"
+// @Failure 401 {object} map[string]string "Usuario no identificado o inactivo"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/my-dashboard [get]
+func (h *ReportHandler) GetMyDashboard(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	actorID, err := parseActorID(r)
+	if err != nil {
+		http.Error(w, "X-User-ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	dashboard, err := h.reportService.GetMyDashboard(ctx, actorID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrDashboardActorRequired):
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dashboard)
+}
+
 // GetPatientsByLocality godoc
 // @Summary Obtener pacientes agrupados por localidad
 // @Description Obtiene estadísticas de pacientes organizadas por localidad
@@ -78,6 +150,7 @@ func (h *ReportHandler) GetDashboard(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param locality_id query string false "ID de la localidad para filtrar"
 // @Param days query int false "Número de días hacia atrás (default: 30)"
+// @Param approved_only query bool false "Solo pacientes aprobados"
 // @Param limit query int false "Límite de resultados (default: 100)"
 // @Success 200 {object} domain.PatientsByLocalityReport
 // @Failure 400 {object} map[string]string "Parámetros inválidos"
@@ -213,7 +286,68 @@ func (h *ReportHandler) GetRiskPatientsExcel(w http.ResponseWriter, r *http.Requ
 
 	// Escribir archivo
 	if _, err := w.Write(excelData); err != nil {
-		log.Printf("Error al escribir archivo Excel: %v", err)
+		h.logger.ErrorContext(ctx, "error al escribir archivo Excel", "filename", filename, "error", err)
+		return
+	}
+}
+
+// GetMonthlyExcel godoc
+// @Summary Descargar el reporte mensual por apoderado
+// @Description Excel con las mediciones, los niños distintos medidos y las detecciones en riesgo (rojo o amarillo) de cada apoderado en el mes calendario (zona horaria del programa), y una hoja resumen por localidad
+// @Tags reports
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param year path int true "Año"
+// @Param month path int true "Mes (1 a 12)"
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param user_id query string false "ID del apoderado para filtrar"
+// @Success 200 {file} file "reporte_mensual_AAAA-MM.xlsx"
+// @Failure 400 {object} map[string]string "Año o mes inválido"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/monthly/{year}/{month}/excel [get]
+func (h *ReportHandler) GetMonthlyExcel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	year, err := strconv.Atoi(r.PathValue("year"))
+	if err != nil {
+		http.Error(w, "Año inválido", http.StatusBadRequest)
+		return
+	}
+	month, err := strconv.Atoi(r.PathValue("month"))
+	if err != nil {
+		http.Error(w, "Mes inválido", http.StatusBadRequest)
+		return
+	}
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.reportService.GetMonthlyReport(ctx, filters, year, month)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidReportMonth) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	excelData, err := h.excelService.GenerateMonthlyReport(ctx, report)
+	if err != nil {
+		http.Error(w, "Error al generar reporte Excel: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("reporte_mensual_%04d-%02d.xlsx", year, month)
+
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(excelData)))
+
+	if _, err := w.Write(excelData); err != nil {
+		h.logger.ErrorContext(ctx, "error al escribir archivo Excel", "filename", filename, "error", err)
 		return
 	}
 }
@@ -234,9 +368,7 @@ func (h *ReportHandler) GetRiskPatientsCoordinates(w http.ResponseWriter, r *htt
 		return
 	}
 
-	// Respuesta simple: solo el array de coordenadas
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(coordinates)
+	writeList(w, coordinates, nil)
 }
 
 // GetUserActivity godoc
@@ -248,6 +380,7 @@ func (h *ReportHandler) GetRiskPatientsCoordinates(w http.ResponseWriter, r *htt
 // @Param locality_id query string false "ID de la localidad para filtrar"
 // @Param user_id query string false "ID del usuario para filtrar"
 // @Param days query int false "Número de días hacia atrás (default: 30)"
+// @Param approved_only query bool false "Solo pacientes aprobados"
 // @Param limit query int false "Límite de resultados (default: 50)"
 // @Success 200 {object} domain.UserActivityReport
 // @Failure 400 {object} map[string]string "Parámetros inválidos"
@@ -277,59 +410,1238 @@ func (h *ReportHandler) GetUserActivity(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(report)
 }
 
-// parseFilters parsea los query parameters a filtros
-func (h *ReportHandler) parseFilters(r *http.Request) (*domain.ReportFilters, error) {
-	filters := &domain.ReportFilters{}
+// GetRecommendationUsage godoc
+// @Summary Obtener uso de recomendaciones
+// @Description Obtiene cuántas mediciones activaron cada recomendación y su distribución por localidad
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param user_id query string false "ID del usuario para filtrar"
+// @Param days query int false "Número de días hacia atrás (default: 30)"
+// @Param limit query int false "Límite de recomendaciones"
+// @Success 200 {object} domain.RecommendationUsageReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/recommendation-usage [get]
+func (h *ReportHandler) GetRecommendationUsage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-	// Locality ID
-	if localityIDStr := r.URL.Query().Get("locality_id"); localityIDStr != "" {
-		localityID, err := uuid.Parse(localityIDStr)
-		if err != nil {
-			return nil, fmt.Errorf("locality_id inválido: %v", err)
-		}
-		filters.LocalityID = &localityID
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	// User ID
-	if userIDStr := r.URL.Query().Get("user_id"); userIDStr != "" {
-		userID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			return nil, fmt.Errorf("user_id inválido: %v", err)
-		}
-		filters.UserID = &userID
+	report, err := h.reportService.GetRecommendationUsageReport(ctx, filters)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	// Days
-	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
-		days, err := strconv.Atoi(daysStr)
-		if err != nil {
-			return nil, fmt.Errorf("days debe ser un número válido: %v", err)
-		}
-		if days < 0 {
-			return nil, fmt.Errorf("days no puede ser negativo")
-		}
-		if days > 365 {
-			return nil, fmt.Errorf("days no puede ser mayor a 365")
-		}
-		filters.Days = days
-	} else {
-		filters.Days = 30 // Por defecto últimos 30 días
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetRecoveryRate godoc
+// @Summary Obtener tasa de recuperación
+// @Description Obtiene cuántos niños estuvieron en riesgo (rojo o amarillo) en la ventana y cuántos tienen su última medición en verde
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param user_id query string false "ID del apoderado para filtrar"
+// @Param days query int false "Número de días hacia atrás (default: 30)"
+// @Success 200 {object} domain.RecoveryRateReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/recovery-rate [get]
+func (h *ReportHandler) GetRecoveryRate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	// Limit
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		limit, err := strconv.Atoi(limitStr)
-		if err != nil {
-			return nil, fmt.Errorf("limit debe ser un número válido: %v", err)
+	report, err := h.reportService.GetRecoveryRateReport(ctx, filters)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetPrevalence godoc
+// @Summary Obtener prevalencia de SAM y MAM
+// @Description Estima la proporción de niños cuya última medición en la ventana es severa (SAM) o moderada (MAM), con intervalos de confianza de Wilson al 95% y el tamaño de muestra. Con muestras pequeñas los intervalos son amplios y small_sample es true
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param days query int false "Número de días hacia atrás (default: 30)"
+// @Success 200 {object} domain.PrevalenceReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/prevalence [get]
+func (h *ReportHandler) GetPrevalence(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.reportService.GetPrevalenceReport(ctx, filters)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetSeasonalTrend godoc
+// @Summary Obtener tendencia estacional de riesgo
+// @Description Devuelve los 12 meses calendario con la prevalencia de riesgo (severo + moderado) de los últimos years años, según la última medición de cada niño en cada mes (zona horaria del programa): el promedio entre años, la prevalencia sobre todos los niños medidos del mes y el tamaño de muestra, para ver patrones estacionales y planificar intervenciones. No usa el parámetro days
+// @Tags reports
+// @Produce json
+// @Param years query int false "Años hacia atrás, 1 a 10 (default: 3)"
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param user_id query string false "ID del apoderado para filtrar"
+// @Param approved_only query bool false "Solo pacientes aprobados"
+// @Success 200 {object} domain.SeasonalTrendReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/seasonal-trend [get]
+func (h *ReportHandler) GetSeasonalTrend(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	years := domain.DefaultSeasonalTrendYears
+	if value := r.URL.Query().Get("years"); value != "" {
+		years, err = strconv.Atoi(value)
+		if err != nil || years < 1 || years > domain.MaxSeasonalTrendYears {
+			http.Error(w, fmt.Sprintf("years debe estar entre 1 y %d", domain.MaxSeasonalTrendYears), http.StatusBadRequest)
+			return
 		}
-		if limit < 0 {
-			return nil, fmt.Errorf("limit no puede ser negativo")
+	}
+
+	report, err := h.reportService.GetSeasonalTrend(ctx, filters, years)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetAgeDistribution godoc
+// @Summary Obtener la distribución por edad de los pacientes
+// @Description Cuenta los pacientes registrados por bandas de meses cumplidos (6-11, 12-23, 24-35, 36-47, 48-59) a partir de la fecha de nacimiento. Los pacientes sin fecha o con fecha inválida o futura se cuentan en unknown, y los que tienen fecha válida fuera de 6-59 meses en out_of_range
+// @Tags reports
+// @Produce json
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Success 200 {object} domain.AgeDistributionReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/age-distribution [get]
+func (h *ReportHandler) GetAgeDistribution(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.reportService.GetAgeDistribution(ctx, filters)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetAgingOut godoc
+// @Summary Obtener los pacientes que egresan por edad
+// @Description Lista los pacientes que cumplen 60 meses (fin del monitoreo MUAC) entre hoy y within_days días, calculado desde la fecha de nacimiento, con la clasificación de su última medición, del egreso más próximo al más lejano. Los pacientes sin fecha de nacimiento o con fecha inválida o futura se excluyen y se cuentan en missing_birth_date; los que ya cumplieron 60 meses, en already_aged_out
+// @Tags reports
+// @Produce json
+// @Param within_days query int false "Días hacia adelante (default: 30, máximo: 365)"
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param user_id query string false "ID del apoderado para filtrar"
+// @Success 200 {object} domain.AgingOutReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/aging-out [get]
+func (h *ReportHandler) GetAgingOut(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	withinDays := domain.DefaultAgingOutWithinDays
+	if value := r.URL.Query().Get("within_days"); value != "" {
+		withinDays, err = strconv.Atoi(value)
+		if err != nil || withinDays < 0 || withinDays > domain.MaxAgingOutWithinDays {
+			http.Error(w, fmt.Sprintf("within_days debe estar entre 0 y %d", domain.MaxAgingOutWithinDays), http.StatusBadRequest)
+			return
 		}
-		if limit > 1000 {
-			return nil, fmt.Errorf("limit no puede ser mayor a 1000")
+	}
+
+	report, err := h.reportService.GetAgingOut(ctx, filters, withinDays)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetMeasurementHeatcells godoc
+// @Summary Obtener celdas de calor de mediciones
+// @Description Agrupa las mediciones (por coordenadas de la localidad) en una grilla y devuelve el centroide, la cantidad y la severidad promedio de cada celda, para capas de calor en mapas de gran escala
+// @Tags reports
+// @Produce json
+// @Param precision query int false "Decimales de la grilla, 0 a 4 (default: 1, ≈ 11 km)"
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param user_id query string false "ID del apoderado para filtrar"
+// @Param days query int false "Número de días hacia atrás (default: 30)"
+// @Success 200 {object} domain.MeasurementHeatcellsReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/measurement-heatcells [get]
+func (h *ReportHandler) GetMeasurementHeatcells(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	precision := domain.DefaultHeatcellPrecision
+	if precisionStr := r.URL.Query().Get("precision"); precisionStr != "" {
+		precision, err = strconv.Atoi(precisionStr)
+		if err != nil || precision < 0 || precision > domain.MaxHeatcellPrecision {
+			http.Error(w, fmt.Sprintf("precision debe ser un número entre 0 y %d", domain.MaxHeatcellPrecision), http.StatusBadRequest)
+			return
 		}
-		filters.Limit = limit
 	}
 
-	return filters, nil
+	report, err := h.reportService.GetMeasurementHeatcells(ctx, filters, precision)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetMeasurementsByHour godoc
+// @Summary Obtener mediciones por hora del día
+// @Description Cuenta las mediciones por hora del día (0 a 23) en la zona horaria del programa, para conocer en qué horarios miden los apoderados. Devuelve siempre las 24 horas, incluidas las que no tienen mediciones
+// @Tags reports
+// @Produce json
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param user_id query string false "ID del apoderado para filtrar"
+// @Param days query int false "Número de días hacia atrás (default: 30)"
+// @Success 200 {object} domain.MeasurementsByHourReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/measurements-by-hour [get]
+func (h *ReportHandler) GetMeasurementsByHour(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.reportService.GetMeasurementsByHour(ctx, filters)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetAlertResponseTimes godoc
+// @Summary Obtener tiempos de respuesta a alertas rojas
+// @Description Para cada medición en alerta roja de la ventana, mide los días hasta la siguiente medición del paciente (aún no se registran derivaciones). Devuelve promedio, mediana, extremos y un histograma; las alertas sin acción posterior se cuentan en unanswered y no entran en los promedios
+// @Tags reports
+// @Produce json
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param user_id query string false "ID del apoderado para filtrar"
+// @Param days query int false "Número de días hacia atrás (default: 30)"
+// @Success 200 {object} domain.AlertResponseTimesReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/alert-response-times [get]
+func (h *ReportHandler) GetAlertResponseTimes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.reportService.GetAlertResponseTimes(ctx, filters)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetPeriodComparison godoc
+// @Summary Comparar el periodo en curso con el anterior
+// @Description Devuelve el valor de la métrica desde el inicio de la semana o del mes (zona horaria del programa) y el del mismo tramo del periodo anterior, con la diferencia, la variación porcentual (null si el periodo anterior es 0) y la tendencia. measurements cuenta mediciones; risk, pacientes con alguna medición en rojo o amarillo
+// @Tags reports
+// @Produce json
+// @Param metric query string false "measurements o risk (default: measurements)"
+// @Param period query string false "week o month (default: month)"
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param user_id query string false "ID del apoderado para filtrar"
+// @Success 200 {object} domain.PeriodComparisonReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/period-comparison [get]
+func (h *ReportHandler) GetPeriodComparison(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	metric := r.URL.Query().Get("metric")
+	if metric == "" {
+		metric = domain.PeriodMetricMeasurements
+	}
+	if !domain.IsValidPeriodMetric(metric) {
+		http.Error(w, "metric debe ser measurements o risk", http.StatusBadRequest)
+		return
+	}
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = domain.TimelineIntervalMonth
+	}
+	if !domain.IsValidTimelineInterval(period) {
+		http.Error(w, "period debe ser week o month", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.reportService.GetPeriodComparison(ctx, filters, metric, period)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetCaseloadDistribution godoc
+// @Summary Obtener la carga de niños por apoderado
+// @Description Por localidad (sin contar centros médicos) devuelve el promedio, mínimo y máximo de niños por apoderado activo y el total de niños de la localidad, ordenado de mayor a menor promedio.
+// @Description Las localidades sin apoderados activos aparecen al final con no_caregivers=true
+// @Tags reports
+// @Produce json
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param approved_only query bool false "Contar solo pacientes aprobados"
+// @Success 200 {object} domain.CaseloadDistributionReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/caseload-distribution [get]
+func (h *ReportHandler) GetCaseloadDistribution(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.reportService.GetCaseloadDistribution(ctx, filters)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetTargetProgress godoc
+// @Summary Obtener el avance de las metas mensuales
+// @Description Compara las mediciones del mes de cada localidad (por la localidad del apoderado que midió) con su meta mensual, con el porcentaje de avance y si va al ritmo de la parte del mes transcurrida.
+// @Description Sin locality_id incluye todas las localidades con meta; sin year y month usa el mes en curso
+// @Tags reports
+// @Produce json
+// @Param locality_id query string false "ID de la localidad"
+// @Param year query int false "Año (default: el actual)"
+// @Param month query int false "Mes de 1 a 12 (default: el actual)"
+// @Success 200 {object} domain.TargetProgressReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 404 {object} map[string]string "La localidad no tiene meta"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/target-progress [get]
+func (h *ReportHandler) GetTargetProgress(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	if loc, err := time.LoadLocation(domain.ProgramTimeZone); err == nil {
+		now = now.In(loc)
+	}
+	year, month := now.Year(), int(now.Month())
+	if value := r.URL.Query().Get("year"); value != "" {
+		if year, err = strconv.Atoi(value); err != nil {
+			http.Error(w, "Año inválido", http.StatusBadRequest)
+			return
+		}
+	}
+	if value := r.URL.Query().Get("month"); value != "" {
+		if month, err = strconv.Atoi(value); err != nil {
+			http.Error(w, "Mes inválido", http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := h.reportService.GetTargetProgress(ctx, filters, year, month)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidReportMonth):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, domain.ErrLocalityTargetNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetGPSCoverage godoc
+// @Summary Obtener la cobertura GPS de las mediciones
+// @Description Cuenta las mediciones de la ventana con ubicación GPS dentro de la región del programa, con GPS fuera de la región y sin GPS (usan la ubicación de la localidad), con sus porcentajes.
+// @Description El desglose por apoderado empieza por quienes menos usan el GPS, para recordarles activarlo
+// @Tags reports
+// @Produce json
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param user_id query string false "ID del apoderado para filtrar"
+// @Param days query int false "Número de días hacia atrás (default: 30)"
+// @Success 200 {object} domain.GPSCoverageReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/gps-coverage [get]
+func (h *ReportHandler) GetGPSCoverage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.reportService.GetGPSCoverage(ctx, filters)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetTapeBatchReport godoc
+// @Summary Obtener las mediciones por lote de cinta MUAC
+// @Description Cuenta por lote de cinta las mediciones de la ventana, los niños medidos, el MUAC promedio y la distribución rojo/amarillo/verde. Se marcan como anómalos los lotes con al menos 20 mediciones cuyo promedio se aleja 0,5 cm o más del resto de lotes, para investigar o retirar cintas defectuosas.
+// @Description Las mediciones sin lote se cuentan aparte en unrecorded
+// @Tags reports
+// @Produce json
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param user_id query string false "ID del apoderado para filtrar"
+// @Param days query int false "Número de días hacia atrás (default: 30)"
+// @Success 200 {object} domain.TapeBatchReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/by-tape-batch [get]
+func (h *ReportHandler) GetTapeBatchReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.reportService.GetTapeBatchReport(ctx, filters)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetActiveLocalities godoc
+// @Summary Obtener las localidades con mediciones en un rango de fechas
+// @Description Devuelve las localidades con al menos una medición entre start_date (inclusive) y end_date (exclusivo), con sus coordenadas, la cantidad de mediciones y de niños medidos, ordenadas por nombre. Al ser un rango semiabierto, ventanas consecutivas no cuentan dos veces la misma medición, lo que permite animar la actividad en el mapa. La localidad se toma del usuario asignado al paciente
+// @Tags reports
+// @Produce json
+// @Param start_date query string true "Inicio del rango (RFC3339)"
+// @Param end_date query string true "Fin del rango, exclusivo (RFC3339)"
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Success 200 {object} domain.ActiveLocalitiesReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/active-localities [get]
+func (h *ReportHandler) GetActiveLocalities(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	startDateStr := r.URL.Query().Get("start_date")
+	endDateStr := r.URL.Query().Get("end_date")
+	if startDateStr == "" || endDateStr == "" {
+		http.Error(w, "start_date y end_date son requeridos", http.StatusBadRequest)
+		return
+	}
+	startDate, err := time.Parse(time.RFC3339, startDateStr)
+	if err != nil {
+		http.Error(w, "start_date debe tener el formato RFC3339", http.StatusBadRequest)
+		return
+	}
+	endDate, err := time.Parse(time.RFC3339, endDateStr)
+	if err != nil {
+		http.Error(w, "end_date debe tener el formato RFC3339", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.reportService.GetActiveLocalities(ctx, filters, startDate, endDate)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidReportRange) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetOverrideAnalysis godoc
+// @Summary Obtener el análisis de reclasificaciones manuales
+// @Description Compara la etiqueta de cada medición (roja, amarilla o verde) con la clasificación automática según los umbrales guardados en la medición. Devuelve cuántas mediciones fueron reclasificadas a mano y su porcentaje, cuántas quedaron más o menos graves que la clasificación automática y la distribución de los motivos indicados en override_reason. Sirve para evaluar si los umbrales coinciden con el criterio clínico
+// @Tags reports
+// @Produce json
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param user_id query string false "ID del usuario que midió"
+// @Param days query int false "Número de días hacia atrás (default: 30)"
+// @Success 200 {object} domain.OverrideAnalysisReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/override-analysis [get]
+func (h *ReportHandler) GetOverrideAnalysis(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.reportService.GetOverrideAnalysis(ctx, filters)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetLocalitySeverityIndex godoc
+// @Summary Obtener el índice de severidad por localidad
+// @Description Calcula para cada localidad score = peso_rojo × % rojo + peso_amarillo × % amarillo, con los porcentajes sobre los pacientes cuya última medición cae en la ventana (los no medidos no cuentan). Por defecto el rojo pesa 3 y el amarillo 1 (SEVERITY_WEIGHT_SEVERE y SEVERITY_WEIGHT_MODERATE); se pueden ajustar por consulta.
+// @Description Devuelve los componentes de cada localidad y las ordena de mayor a menor score
+// @Tags reports
+// @Produce json
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param days query int false "Número de días hacia atrás (default: 30)"
+// @Param severe_weight query number false "Peso de los casos rojos (default: configuración)"
+// @Param moderate_weight query number false "Peso de los casos amarillos (default: configuración)"
+// @Success 200 {object} domain.LocalitySeverityReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/locality-severity-index [get]
+func (h *ReportHandler) GetLocalitySeverityIndex(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	weights := domain.SeverityWeights
+	for param, weight := range map[string]*float64{
+		"severe_weight":   &weights.Severe,
+		"moderate_weight": &weights.Moderate,
+	} {
+		value := r.URL.Query().Get(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 {
+			http.Error(w, param+" debe ser un número mayor o igual a 0", http.StatusBadRequest)
+			return
+		}
+		*weight = parsed
+	}
+
+	report, err := h.reportService.GetLocalitySeverityIndex(ctx, filters, weights)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetDataCompleteness godoc
+// @Summary Obtener la completitud de los datos
+// @Description Devuelve el porcentaje de pacientes con cada campo opcional registrado (birth_date, gender, weight, size, arm_size, dni_image) y de mediciones con descripción y con GPS, con el total usado como denominador. Se evalúan todos los registros, sin ventana de días
+// @Tags reports
+// @Produce json
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param approved_only query bool false "Solo pacientes aprobados"
+// @Success 200 {object} domain.DataCompletenessReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/data-completeness [get]
+func (h *ReportHandler) GetDataCompleteness(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.reportService.GetDataCompleteness(ctx, filters)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetPendingReview godoc
+// @Summary Obtener la cola de revisión del supervisor
+// @Description Devuelve los pacientes pendientes de aprobación y las mediciones marcadas para revisión, del más antiguo al más reciente y hasta limit de cada uno, con los totales por estado.
+// @Description El supervisor ve solo su localidad; el administrador todas o las de locality_id
+// @Tags reports
+// @Produce json
+// @Param X-User-ID header string true "Usuario SUPERVISOR o ADMINISTRADOR"
+// @Param locality_id query string false "ID de la localidad (el supervisor solo puede indicar la suya)"
+// @Param limit query int false "Máximo de pacientes y de mediciones (default: 100)"
+// @Success 200 {object} domain.PendingReviewReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 403 {object} map[string]string "Usuario sin acceso a la cola"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/pending-review [get]
+func (h *ReportHandler) GetPendingReview(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	actorID, err := parseActorID(r)
+	if err != nil {
+		http.Error(w, "X-User-ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.reportService.GetPendingReview(ctx, actorID, filters.LocalityID, filters.Limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrPendingReviewForbidden):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// ExportTransitions godoc
+// @Summary Exportar cambios de clasificación a CSV
+// @Description Descarga una fila por cada medición cuya clasificación MUAC cambió respecto de la medición anterior del paciente, con los códigos, valores y fechas de ambas y la dirección (MEJORA o DETERIORO).
+// @Description La medición anterior puede quedar fuera del rango. Sin start_date se exportan los últimos days días. El archivo se genera en streaming
+// @Tags reports
+// @Produce text/csv
+// @Param format query string false "Formato del archivo (solo csv)"
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param user_id query string false "ID del apoderado para filtrar"
+// @Param approved_only query bool false "Solo pacientes aprobados"
+// @Param days query int false "Número de días hacia atrás si no se indica start_date (default: 30)"
+// @Param start_date query string false "Fecha inicial (YYYY-MM-DD)"
+// @Param end_date query string false "Fecha final inclusiva (YYYY-MM-DD, default: hoy)"
+// @Success 200 {file} file "Archivo CSV"
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/transitions/export [get]
+func (h *ReportHandler) ExportTransitions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if format := r.URL.Query().Get("format"); format != "" && format != "csv" {
+		http.Error(w, "format solo admite csv", http.StatusBadRequest)
+		return
+	}
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	from, to := now.AddDate(0, 0, -filters.Days), now
+	if value := r.URL.Query().Get("start_date"); value != "" {
+		from, err = time.ParseInLocation(domain.AuditDateLayout, value, time.Local)
+		if err != nil {
+			http.Error(w, "start_date debe tener el formato YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+	if value := r.URL.Query().Get("end_date"); value != "" {
+		end, err := time.ParseInLocation(domain.AuditDateLayout, value, time.Local)
+		if err != nil {
+			http.Error(w, "end_date debe tener el formato YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		// end_date es inclusivo: se filtra hasta el inicio del día siguiente
+		to = end.AddDate(0, 0, 1)
+	}
+
+	// Las cabeceras se envían con la primera fila, para poder responder con un error
+	// si la consulta falla antes de empezar
+	filename := fmt.Sprintf("transiciones_%s.csv", now.Format("2006-01-02_15-04-05"))
+	var writer *csv.Writer
+	start := func() error {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+		writer = csv.NewWriter(w)
+		return writer.Write(domain.ClassificationTransitionHeader)
+	}
+
+	rows := 0
+	err = h.reportService.ExportClassificationTransitions(ctx, filters, from, to, func(transition domain.ClassificationTransition) error {
+		if writer == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		rows++
+		return writer.Write(transition.Record())
+	})
+	if err != nil {
+		if writer != nil {
+			h.logger.ErrorContext(ctx, "exportación de transiciones interrumpida", "filename", filename, "rows", rows, "error", err)
+			writer.Flush()
+			return
+		}
+		switch {
+		case errors.Is(err, domain.ErrInvalidReportRange):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if writer == nil {
+		if err := start(); err != nil {
+			h.logger.ErrorContext(ctx, "error al escribir exportación de transiciones", "filename", filename, "error", err)
+			return
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		h.logger.ErrorContext(ctx, "error al escribir exportación de transiciones", "filename", filename, "rows", rows, "error", err)
+	}
+}
+
+// GetUncoveredLocalities godoc
+// @Summary Listar localidades sin cobertura
+// @Description Lista las localidades (sin contar centros médicos) que no tienen ningún usuario activo asignado, con sus coordenadas para ubicarlas en el mapa
+// @Tags reports
+// @Produce json
+// @Success 200 {object} ListResponse{data=[]domain.UncoveredLocality}
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/uncovered-localities [get]
+func (h *ReportHandler) GetUncoveredLocalities(w http.ResponseWriter, r *http.Request) {
+	localities, err := h.reportService.GetUncoveredLocalities(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeList(w, localities, nil)
+}
+
+// GetFlaggedMeasurements godoc
+// @Summary Listar mediciones marcadas para revisión
+// @Description Lista las mediciones marcadas como posible error de registro (automáticamente por un cambio brusco respecto a la medición anterior, o de forma manual), con el valor previo y la diferencia que originó la marca
+// @Tags reports
+// @Produce json
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param user_id query string false "ID del usuario que registró la medición"
+// @Param page query int false "Página (default: 1)"
+// @Param page_size query int false "Tamaño de página (default: 20, máx: 200)"
+// @Success 200 {object} ListResponse{data=[]domain.FlaggedMeasurement}
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/flagged-measurements [get]
+func (h *ReportHandler) GetFlaggedMeasurements(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	flagged, err := h.reportService.GetFlaggedMeasurements(ctx, filters, page)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeList(w, flagged, page)
+}
+
+// GetMeasurementIntervals godoc
+// @Summary Obtener intervalos entre mediciones
+// @Description Obtiene el promedio, la mediana y el histograma de días entre mediciones consecutivas de cada paciente, para evaluar la regularidad del seguimiento. Los pacientes con una sola medición se excluyen.
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param user_id query string false "ID del apoderado para filtrar"
+// @Param days query int false "Número de días hacia atrás (default: 30)"
+// @Success 200 {object} domain.MeasurementIntervalsReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/measurement-intervals [get]
+func (h *ReportHandler) GetMeasurementIntervals(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.reportService.GetMeasurementIntervalsReport(ctx, filters)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetCounters godoc
+// @Summary Obtener contadores para insignias
+// @Description Obtiene conteos ligeros (pacientes, en riesgo, mediciones de hoy, apoderados activos) para la cabecera del panel. Se recalculan como máximo cada 30 segundos.
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param approved_only query bool false "Solo pacientes aprobados"
+// @Success 200 {object} domain.CountersReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 404 {object} map[string]string "Localidad no encontrada"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/counters [get]
+func (h *ReportHandler) GetCounters(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.reportService.GetCountersReport(ctx, filters)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrLocalityNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(domain.CountersCacheTTL.Seconds())))
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetRegistrationsTimeline godoc
+// @Summary Obtener registros de pacientes en el tiempo
+// @Description Obtiene el número de pacientes nuevos por semana o mes, incluyendo periodos sin registros
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param interval query string false "Intervalo: week o month (default: week)"
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param days query int false "Número de días hacia atrás (default: 30, 0 = desde el primer registro)"
+// @Success 200 {object} domain.RegistrationsTimelineReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/registrations-timeline [get]
+func (h *ReportHandler) GetRegistrationsTimeline(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		interval = domain.TimelineIntervalWeek
+	}
+	if !domain.IsValidTimelineInterval(interval) {
+		http.Error(w, "interval debe ser week o month", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.reportService.GetRegistrationsTimelineReport(ctx, filters, interval)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetCaregiverLeaderboard godoc
+// @Summary Obtener ranking de apoderados de la localidad
+// @Description Obtiene la posición del usuario y los apoderados con más mediciones de su localidad en los últimos N días. Los pares se muestran con nombre abreviado
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param id path string true "ID del usuario"
+// @Param days query int false "Número de días hacia atrás (default: 30)"
+// @Param limit query int false "Cantidad de apoderados a mostrar (default: 10)"
+// @Success 200 {object} domain.LeaderboardReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos o usuario sin localidad"
+// @Failure 404 {object} map[string]string "Usuario no encontrado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/users/{id}/leaderboard [get]
+func (h *ReportHandler) GetCaregiverLeaderboard(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// El ranking siempre se calcula sobre la localidad del usuario
+	filters.LocalityID = nil
+	filters.UserID = nil
+
+	report, err := h.reportService.GetCaregiverLeaderboard(ctx, userID, filters)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUserNotFound):
+			http.Error(w, "Usuario no encontrado", http.StatusNotFound)
+		case errors.Is(err, domain.ErrUserNoLocality):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetReliabilityTrend godoc
+// @Summary Obtener la tendencia de confiabilidad de un apoderado
+// @Description Devuelve por semana o mes, del periodo más antiguo al más reciente, las mediciones del usuario, cuántas siguen marcadas como posible error y su porcentaje, y la precisión frente a las re-mediciones de supervisores dentro de window_hours (diferencia media absoluta, sesgo y máximo; pairs 0 si no hubo re-mediciones). Incluye los periodos sin mediciones
+// @Tags reports
+// @Produce json
+// @Param id path string true "ID del usuario"
+// @Param interval query string false "week o month (default: month)"
+// @Param days query int false "Número de días hacia atrás (default: 180, máximo: 365)"
+// @Param window_hours query int false "Horas para aceptar la re-medición del supervisor (default: 48, máximo: 168)"
+// @Success 200 {object} domain.ReliabilityTrendReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 404 {object} map[string]string "Usuario no encontrado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/users/{id}/reliability-trend [get]
+func (h *ReportHandler) GetReliabilityTrend(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.URL.Query().Get("days") == "" {
+		filters.Days = domain.DefaultReliabilityTrendDays
+	}
+
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		interval = domain.TimelineIntervalMonth
+	}
+	if !domain.IsValidTimelineInterval(interval) {
+		http.Error(w, "interval debe ser week o month", http.StatusBadRequest)
+		return
+	}
+
+	windowHours := domain.DefaultAccuracyWindowHours
+	if value := r.URL.Query().Get("window_hours"); value != "" {
+		windowHours, err = strconv.Atoi(value)
+		if err != nil || windowHours <= 0 || windowHours > domain.MaxAccuracyWindowHours {
+			http.Error(w, fmt.Sprintf("window_hours debe estar entre 1 y %d", domain.MaxAccuracyWindowHours), http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := h.reportService.GetReliabilityTrend(ctx, userID, filters, interval, windowHours)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			http.Error(w, "Usuario no encontrado", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// parseFilters parsea los query parameters a filtros
+func (h *ReportHandler) parseFilters(r *http.Request) (*domain.ReportFilters, error) {
+	filters := &domain.ReportFilters{}
+
+	// Locality ID
+	if localityIDStr := r.URL.Query().Get("locality_id"); localityIDStr != "" {
+		localityID, err := uuid.Parse(localityIDStr)
+		if err != nil {
+			return nil, fmt.Errorf("locality_id inválido: %v", err)
+		}
+		filters.LocalityID = &localityID
+	}
+
+	// User ID
+	if userIDStr := r.URL.Query().Get("user_id"); userIDStr != "" {
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			return nil, fmt.Errorf("user_id inválido: %v", err)
+		}
+		filters.UserID = &userID
+	}
+
+	// Solo pacientes aprobados
+	if approvedStr := r.URL.Query().Get("approved_only"); approvedStr != "" {
+		approvedOnly, err := strconv.ParseBool(approvedStr)
+		if err != nil {
+			return nil, fmt.Errorf("approved_only debe ser true o false")
+		}
+		filters.ApprovedOnly = approvedOnly
+	}
+
+	// Days
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		days, err := strconv.Atoi(daysStr)
+		if err != nil {
+			return nil, fmt.Errorf("days debe ser un número válido: %v", err)
+		}
+		if days < 0 {
+			return nil, fmt.Errorf("days no puede ser negativo")
+		}
+		if days > 365 {
+			return nil, fmt.Errorf("days no puede ser mayor a 365")
+		}
+		filters.Days = days
+	} else {
+		filters.Days = 30 // Por defecto últimos 30 días
+	}
+
+	// Limit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			return nil, fmt.Errorf("limit debe ser un número válido: %v", err)
+		}
+		if limit < 0 {
+			return nil, fmt.Errorf("limit no puede ser negativo")
+		}
+		if limit > 1000 {
+			return nil, fmt.Errorf("limit no puede ser mayor a 1000")
+		}
+		filters.Limit = limit
+	}
+
+	return filters, nil
+}
+
+// GetCaregiverAccuracy godoc
+// @Summary Obtener la precisión de los apoderados frente a las re-mediciones de supervisores
+// @Description Empareja cada medición de un apoderado con la primera medición de un supervisor al mismo paciente dentro de la ventana y calcula la diferencia media absoluta, el sesgo (apoderado - supervisor) y la diferencia máxima.
+// @Description Las mediciones sin re-medición del supervisor en la ventana se excluyen
+// @Tags reports
+// @Produce json
+// @Param user_id query string false "ID del apoderado para filtrar"
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param days query int false "Número de días hacia atrás (default: 30)"
+// @Param window_hours query int false "Horas para aceptar la re-medición del supervisor (default: 48, máximo: 168)"
+// @Success 200 {object} domain.CaregiverAccuracyReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/caregiver-accuracy [get]
+func (h *ReportHandler) GetCaregiverAccuracy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	windowHours := domain.DefaultAccuracyWindowHours
+	if value := r.URL.Query().Get("window_hours"); value != "" {
+		windowHours, err = strconv.Atoi(value)
+		if err != nil || windowHours <= 0 || windowHours > domain.MaxAccuracyWindowHours {
+			http.Error(w, fmt.Sprintf("window_hours debe estar entre 1 y %d", domain.MaxAccuracyWindowHours), http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := h.reportService.GetCaregiverAccuracy(ctx, filters, windowHours)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetTimeToRecovery godoc
+// @Summary Obtener el tiempo hasta la recuperación
+// @Description Para los niños que pasaron de rojo o amarillo a un verde sostenido (al menos dos mediciones verdes seguidas hasta la última), calcula el promedio y la mediana de días desde la primera detección en riesgo hasta la primera medición de esa racha verde.
+// @Description Los niños cuya última medición sigue en riesgo se cuentan en ongoing y los que tienen una sola medición verde al final en unconfirmed; ninguno entra en los promedios. small_sample indica menos de 10 recuperados. Usa el historial completo de cada niño, sin ventana de días
+// @Tags reports
+// @Produce json
+// @Param locality_id query string false "ID de la localidad para filtrar"
+// @Param user_id query string false "ID del apoderado para filtrar"
+// @Param approved_only query bool false "Solo pacientes aprobados"
+// @Success 200 {object} domain.TimeToRecoveryReport
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/reports/time-to-recovery [get]
+func (h *ReportHandler) GetTimeToRecovery(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := h.parseFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.reportService.GetTimeToRecovery(ctx, filters)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
 }
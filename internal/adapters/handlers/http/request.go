@@ -0,0 +1,48 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// decodeJSON decodifica el cuerpo JSON de la petición en dst y escribe el error HTTP si falla:
+//   - 415 si se envía un Content-Type distinto de application/json
+//   - 400 distinguiendo cuerpo vacío de JSON mal formado
+//
+// Devuelve false cuando ya se respondió con error y el handler debe terminar.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || mediaType != "application/json" {
+			http.Error(w, "Content-Type no soportado: se espera application/json", http.StatusUnsupportedMediaType)
+			return false
+		}
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		if errors.Is(err, io.EOF) {
+			http.Error(w, "El cuerpo de la petición está vacío", http.StatusBadRequest)
+			return false
+		}
+		http.Error(w, "JSON mal formado: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// actorIDHeader identifica al usuario que realiza la operación
+const actorIDHeader = "X-User-ID"
+
+// parseActorID obtiene el usuario que realiza la operación; devuelve uuid.Nil si no se envió
+func parseActorID(r *http.Request) (uuid.UUID, error) {
+	value := r.Header.Get(actorIDHeader)
+	if value == "" {
+		return uuid.Nil, nil
+	}
+	return uuid.Parse(value)
+}
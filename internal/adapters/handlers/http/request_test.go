@@ -0,0 +1,97 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantOK      bool
+		wantStatus  int
+	}{
+		{"json válido", "application/json", `{"name":"Ana"}`, true, http.StatusOK},
+		{"json con charset", "application/json; charset=utf-8", `{"name":"Ana"}`, true, http.StatusOK},
+		{"sin content type", "", `{"name":"Ana"}`, true, http.StatusOK},
+		{"content type incorrecto", "text/plain", `{"name":"Ana"}`, false, http.StatusUnsupportedMediaType},
+		{"formulario", "application/x-www-form-urlencoded", `name=Ana`, false, http.StatusUnsupportedMediaType},
+		{"cuerpo vacío", "application/json", ``, false, http.StatusBadRequest},
+		{"json mal formado", "application/json", `{"name":`, false, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+
+			var dst struct {
+				Name string `json:"name"`
+			}
+			ok := decodeJSON(rec, req, &dst)
+
+			if ok != tt.wantOK {
+				t.Fatalf("decodeJSON = %v, se esperaba %v", ok, tt.wantOK)
+			}
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, se esperaba %d", rec.Code, tt.wantStatus)
+			}
+			if ok && dst.Name != "Ana" {
+				t.Fatalf("name = %q, se esperaba %q", dst.Name, "Ana")
+			}
+		})
+	}
+}
+
+func TestDecodeJSONEmptyBodyMessage(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(""))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	var dst map[string]interface{}
+	decodeJSON(rec, req, &dst)
+
+	if !strings.Contains(rec.Body.String(), "vacío") {
+		t.Fatalf("cuerpo = %q, se esperaba el mensaje de cuerpo vacío", rec.Body.String())
+	}
+}
+
+func TestParseActorID(t *testing.T) {
+	id := uuid.New()
+
+	tests := []struct {
+		name    string
+		header  string
+		want    uuid.UUID
+		wantErr bool
+	}{
+		{"sin cabecera", "", uuid.Nil, false},
+		{"uuid válido", id.String(), id, false},
+		{"uuid inválido", "abc", uuid.Nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(actorIDHeader, tt.header)
+			}
+			got, err := parseActorID(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("parseActorID = %s, se esperaba %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+)
+
+// ListResponse es el sobre estándar para todas las respuestas de listas.
+//
+// Contrato:
+//   - data: siempre es un arreglo (vacío si no hay resultados, nunca null)
+//   - count: número de elementos incluidos en data
+//   - pagination: solo presente en endpoints paginados (page, page_size, total)
+//   - meta: datos agregados propios del endpoint, solo cuando aplica
+//   - timestamp: momento en que el servidor generó la respuesta
+//
+// Las respuestas de un único recurso se siguen devolviendo como el objeto sin envolver.
+type ListResponse struct {
+	Data       interface{}            `json:"data"`
+	Count      int                    `json:"count"`
+	Pagination *domain.Pagination     `json:"pagination,omitempty"`
+	Meta       map[string]interface{} `json:"meta,omitempty"`
+	Timestamp  time.Time              `json:"timestamp"`
+}
+
+// NewListResponse construye el sobre a partir de un slice cualquiera
+func NewListResponse(data interface{}, pagination *domain.Pagination) *ListResponse {
+	count := 0
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Slice {
+		if v.IsNil() {
+			data = []interface{}{}
+		} else {
+			count = v.Len()
+		}
+	}
+
+	return &ListResponse{
+		Data:       data,
+		Count:      count,
+		Pagination: pagination,
+		Timestamp:  time.Now(),
+	}
+}
+
+// writeList escribe una lista usando el sobre estándar
+func writeList(w http.ResponseWriter, data interface{}, pagination *domain.Pagination) {
+	writeListResponse(w, NewListResponse(data, pagination))
+}
+
+// writeListResponse escribe un sobre ya construido (p.ej. con meta)
+func writeListResponse(w http.ResponseWriter, response *ListResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,131 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+)
+
+// RiskStreamHandler envía por Server-Sent Events las mediciones en riesgo a los dashboards
+type RiskStreamHandler struct {
+	measurementService ports.IMeasurementService
+	heartbeat          time.Duration
+	logger             *slog.Logger
+}
+
+// NewRiskStreamHandler crea una nueva instancia de RiskStreamHandler
+func NewRiskStreamHandler(measurementService ports.IMeasurementService, heartbeat time.Duration, logger *slog.Logger) *RiskStreamHandler {
+	if heartbeat <= 0 {
+		heartbeat = domain.DefaultRiskStreamHeartbeat
+	}
+	return &RiskStreamHandler{
+		measurementService: measurementService,
+		heartbeat:          heartbeat,
+		logger:             logger,
+	}
+}
+
+// RegisterRoutes registra las rutas del manejador
+func (h *RiskStreamHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/reports/risk-stream", h.StreamRiskMeasurements)
+}
+
+// StreamRiskMeasurements godoc
+// @Summary Seguir en vivo las mediciones en riesgo
+// @Description Stream Server-Sent Events (text/event-stream) que emite un evento risk-measurement por cada medición en rojo o amarillo registrada.
+// @Description El supervisor recibe solo las de su localidad; el administrador todas o las de locality_id. Cada heartbeat se envía un comentario para mantener viva la conexión.
+// @Description Si se alcanzó el máximo de conexiones responde 503 con Retry-After: el dashboard puede volver a consultar los reportes mientras tanto.
+// @Tags reports
+// @Produce text/event-stream
+// @Param X-User-ID header string true "Usuario SUPERVISOR o ADMINISTRADOR"
+// @Param locality_id query string false "ID de la localidad (solo administrador)"
+// @Success 200 {object} domain.RiskMeasurementEvent "Un evento por medición"
+// @Failure 400 {object} map[string]string "Parámetros inválidos"
+// @Failure 403 {object} map[string]string "Usuario sin acceso al stream"
+// @Failure 503 {object} map[string]string "Máximo de conexiones alcanzado"
+// @Router /api/reports/risk-stream [get]
+func (h *RiskStreamHandler) StreamRiskMeasurements(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	actorID, err := parseActorID(r)
+	if err != nil {
+		http.Error(w, "X-User-ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var localityID *uuid.UUID
+	if localityIDStr := r.URL.Query().Get("locality_id"); localityIDStr != "" {
+		id, err := uuid.Parse(localityIDStr)
+		if err != nil {
+			http.Error(w, "locality_id inválido", http.StatusBadRequest)
+			return
+		}
+		localityID = &id
+	}
+
+	events, unsubscribe, err := h.measurementService.SubscribeRiskStream(ctx, actorID, localityID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRiskStreamForbidden):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, domain.ErrRiskStreamFull):
+			w.Header().Set("Retry-After", "30")
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	defer unsubscribe()
+
+	// La conexión queda abierta: se quita el WriteTimeout del servidor para esta respuesta
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Evita el buffer de nginx
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", (5 * time.Second).Milliseconds())
+	if err := rc.Flush(); err != nil {
+		h.logger.ErrorContext(ctx, "el stream de riesgo no admite flush", "error", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				h.logger.ErrorContext(ctx, "error al serializar evento de riesgo", "error", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: risk-measurement\ndata: %s\n\n", event.MeasurementID, data); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -48,7 +48,7 @@ func (h *RoleHandler) RegisterRoutes(mux *http.ServeMux) {
 // @Tags roles
 // @Accept json
 // @Produce json
-// @Success 200 {array} domain.Role
+// @Success 200 {object} ListResponse{data=[]domain.Role}
 // @Failure 500 {object} map[string]string "Error interno del servidor"
 // @Router /api/roles [get]
 func (h *RoleHandler) GetAllRoles(w http.ResponseWriter, r *http.Request) {
@@ -60,8 +60,7 @@ func (h *RoleHandler) GetAllRoles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(roles)
+	writeList(w, roles, nil)
 }
 
 // GetRoleByID godoc
@@ -120,8 +119,7 @@ func (h *RoleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	var req CreateRoleRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Solicitud inválida", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
@@ -169,8 +167,7 @@ func (h *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req UpdateRoleRequest
-	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Solicitud inválida", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
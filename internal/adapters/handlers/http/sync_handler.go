@@ -0,0 +1,62 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+)
+
+// SyncHandler maneja las peticiones de sincronización offline
+type SyncHandler struct {
+	syncService ports.ISyncService
+}
+
+// NewSyncHandler crea una nueva instancia de SyncHandler
+func NewSyncHandler(syncService ports.ISyncService) *SyncHandler {
+	return &SyncHandler{
+		syncService: syncService,
+	}
+}
+
+// RegisterRoutes registra las rutas del manejador
+func (h *SyncHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/sync/bootstrap", h.GetBootstrap)
+}
+
+// GetBootstrap godoc
+// @Summary Descargar paquete inicial de una localidad
+// @Description Devuelve en una sola respuesta la localidad, sus pacientes, las mediciones de esos pacientes, las recomendaciones activas y las preguntas frecuentes, para la primera sincronización offline. Si truncated es true, completar las mediciones con /api/sync/measurements usando server_time como cursor
+// @Tags sync
+// @Produce json
+// @Param locality_id query string true "ID de la localidad"
+// @Success 200 {object} domain.SyncBootstrapBundle
+// @Failure 400 {object} map[string]string "locality_id inválido"
+// @Failure 404 {object} map[string]string "Localidad no encontrada"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/sync/bootstrap [get]
+func (h *SyncHandler) GetBootstrap(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	localityID, err := uuid.Parse(r.URL.Query().Get("locality_id"))
+	if err != nil {
+		http.Error(w, "locality_id inválido", http.StatusBadRequest)
+		return
+	}
+
+	bundle, err := h.syncService.GetBootstrap(ctx, localityID)
+	if err != nil {
+		if errors.Is(err, domain.ErrLocalityNotFound) {
+			http.Error(w, "Localidad no encontrada", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bundle)
+}
@@ -2,7 +2,9 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
@@ -24,9 +26,11 @@ func NewTagHandler(tagService ports.ITagService) *TagHandler {
 // RegisterRoutes registra las rutas del manejador
 func (h *TagHandler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /api/tags", h.GetAllTags)
+	mux.HandleFunc("GET /api/tags/overview", h.GetTagsOverview)
 	mux.HandleFunc("POST /api/tags", h.CreateTag)
 	mux.HandleFunc("GET /api/tags/{id}", h.GetTagByID)
 	mux.HandleFunc("PUT /api/tags/{id}", h.UpdateTag)
+	mux.HandleFunc("PATCH /api/tags/{id}/muac", h.PatchTagMuac)
 	mux.HandleFunc("DELETE /api/tags/{id}", h.DeleteTag)
 	mux.HandleFunc("GET /api/tags/name/{name}", h.GetTagByName)
 }
@@ -37,7 +41,7 @@ func (h *TagHandler) RegisterRoutes(mux *http.ServeMux) {
 // @Tags etiquetas
 // @Accept json
 // @Produce json
-// @Success 200 {array} domain.Tag
+// @Success 200 {object} ListResponse{data=[]domain.Tag}
 // @Failure 500 {object} map[string]string "Error interno del servidor"
 // @Router /api/tags [get]
 func (h *TagHandler) GetAllTags(w http.ResponseWriter, r *http.Request) {
@@ -49,8 +53,43 @@ func (h *TagHandler) GetAllTags(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(tags)
+	writeList(w, tags, nil)
+}
+
+// GetTagsOverview godoc
+// @Summary Obtener resumen de etiquetas
+// @Description Lista las etiquetas ordenadas por prioridad clínica con el número de mediciones que clasifica cada una
+// @Tags etiquetas
+// @Accept json
+// @Produce json
+// @Param active query string false "Filtrar por estado: true (por defecto), false o all"
+// @Success 200 {object} ListResponse{data=[]domain.TagOverview}
+// @Failure 400 {object} map[string]string "Filtro inválido"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/tags/overview [get]
+func (h *TagHandler) GetTagsOverview(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	active := new(bool)
+	*active = true
+	switch activeStr := r.URL.Query().Get("active"); activeStr {
+	case "", "true":
+	case "false":
+		*active = false
+	case "all":
+		active = nil
+	default:
+		http.Error(w, "active debe ser true, false o all", http.StatusBadRequest)
+		return
+	}
+
+	overview, err := h.tagService.GetOverview(ctx, active)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeList(w, overview, nil)
 }
 
 // GetTagByID godoc
@@ -148,8 +187,7 @@ func (h *TagHandler) CreateTag(w http.ResponseWriter, r *http.Request) {
 		Description string `json:"description"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Solicitud inválida", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
@@ -198,8 +236,7 @@ func (h *TagHandler) UpdateTag(w http.ResponseWriter, r *http.Request) {
 		Description string `json:"description"`
 	}
 
-	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Solicitud inválida", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
@@ -224,6 +261,77 @@ func (h *TagHandler) UpdateTag(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(tag)
 }
 
+// PatchTagMuac godoc
+// @Summary Editar los campos MUAC de una etiqueta
+// @Description Actualiza parcialmente color, muac_code y priority; los campos omitidos conservan su valor
+// @Tags etiquetas
+// @Accept json
+// @Produce json
+// @Param id path string true "ID de la etiqueta"
+// @Param tag body object true "Campos MUAC a modificar"
+// @Success 200 {object} domain.Tag
+// @Failure 400 {object} map[string]string "ID inválido, color, código MUAC o prioridad inválidos"
+// @Failure 404 {object} map[string]string "Etiqueta no encontrada"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/tags/{id}/muac [patch]
+func (h *TagHandler) PatchTagMuac(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Color    string `json:"color"`
+		MuacCode string `json:"muac_code"`
+		Priority *int   `json:"priority"`
+	}
+
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	// Una prioridad explícita en 0 se rechaza; omitida se conserva
+	priority := 0
+	if req.Priority != nil {
+		if *req.Priority == 0 {
+			http.Error(w, domain.ErrInvalidTagPriority.Error(), http.StatusBadRequest)
+			return
+		}
+		priority = *req.Priority
+	}
+
+	tag, err := h.tagService.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrTagNotFound) {
+			http.Error(w, "Etiqueta no encontrada", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = tag.UpdateMuacTag("", "", strings.TrimSpace(req.Color), strings.ToUpper(strings.TrimSpace(req.MuacCode)), priority)
+	if err == nil {
+		err = h.tagService.Update(ctx, tag)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidTagColor), errors.Is(err, domain.ErrInvalidMuacCode),
+			errors.Is(err, domain.ErrInvalidTagPriority):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag)
+}
+
 // DeleteTag godoc
 // @Summary Eliminar una etiqueta
 // @Description Elimina una etiqueta por su ID
@@ -262,4 +370,4 @@ func (h *TagHandler) DeleteTag(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.WriteHeader(http.StatusNoContent)
-}
\ No newline at end of file
+}
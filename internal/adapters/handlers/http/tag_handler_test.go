@@ -0,0 +1,108 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+)
+
+// fakeTagService guarda en memoria una etiqueta y registra las actualizaciones
+type fakeTagService struct {
+	ports.ITagService
+	tag     *domain.Tag
+	updated bool
+}
+
+func (f *fakeTagService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Tag, error) {
+	if id != f.tag.ID {
+		return nil, domain.ErrTagNotFound
+	}
+	t := *f.tag
+	return &t, nil
+}
+
+func (f *fakeTagService) Update(ctx context.Context, tag *domain.Tag) error {
+	f.updated = true
+	f.tag = tag
+	return nil
+}
+
+func TestPatchTagMuac(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		wantStatus   int
+		wantColor    string
+		wantCode     string
+		wantPriority int
+	}{
+		{"cambia todo", `{"color": "#D32F2F", "muac_code": "muac-r1", "priority": 1}`, http.StatusOK, "#D32F2F", domain.MuacCodeRed, 1},
+		{"omitidos se conservan", `{"priority": 5}`, http.StatusOK, "#FFC107", domain.MuacCodeYellow, 5},
+		{"color inválido", `{"color": "amarillo"}`, http.StatusBadRequest, "", "", 0},
+		{"código MUAC inválido", `{"muac_code": "MUAC-X9"}`, http.StatusBadRequest, "", "", 0},
+		{"prioridad en cero", `{"priority": 0}`, http.StatusBadRequest, "", "", 0},
+		{"prioridad fuera de rango", `{"priority": 11}`, http.StatusBadRequest, "", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeTagService{tag: &domain.Tag{
+				ID:       uuid.New(),
+				Name:     "Riesgo moderado",
+				Color:    "#FFC107",
+				MuacCode: domain.MuacCodeYellow,
+				Priority: 2,
+			}}
+			mux := http.NewServeMux()
+			NewTagHandler(service).RegisterRoutes(mux)
+
+			req := httptest.NewRequest(http.MethodPatch, "/api/tags/"+service.tag.ID.String()+"/muac", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, se esperaba %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				if service.updated {
+					t.Fatal("se guardó una etiqueta inválida")
+				}
+				return
+			}
+
+			var got domain.Tag
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("respuesta no es JSON: %v", err)
+			}
+			if got.Color != tt.wantColor || got.MuacCode != tt.wantCode || got.Priority != tt.wantPriority {
+				t.Errorf("got color=%q muac_code=%q priority=%d, se esperaba %q %q %d",
+					got.Color, got.MuacCode, got.Priority, tt.wantColor, tt.wantCode, tt.wantPriority)
+			}
+			if got.Name != "Riesgo moderado" {
+				t.Errorf("name = %q, debe conservarse", got.Name)
+			}
+		})
+	}
+}
+
+func TestPatchTagMuacNotFound(t *testing.T) {
+	service := &fakeTagService{tag: &domain.Tag{ID: uuid.New()}}
+	mux := http.NewServeMux()
+	NewTagHandler(service).RegisterRoutes(mux)
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/tags/"+uuid.NewString()+"/muac", strings.NewReader(`{"priority": 3}`))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, se esperaba 404", rec.Code)
+	}
+}
@@ -0,0 +1,124 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+)
+
+// TaskHandler maneja las peticiones de la lista de pendientes del apoderado
+type TaskHandler struct {
+	taskService ports.ITaskService
+	fileService ports.IFileService
+}
+
+// NewTaskHandler crea una nueva instancia de TaskHandler
+func NewTaskHandler(taskService ports.ITaskService, fileService ports.IFileService) *TaskHandler {
+	return &TaskHandler{
+		taskService: taskService,
+		fileService: fileService,
+	}
+}
+
+// RegisterRoutes registra las rutas del manejador
+func (h *TaskHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/users/{id}/tasks", h.GetCaregiverTasks)
+	mux.HandleFunc("GET /api/users/{id}/session-roster", h.GetSessionRoster)
+}
+
+// GetCaregiverTasks godoc
+// @Summary Obtener los pendientes de un apoderado
+// @Description Devuelve en una sola lista, ordenada por urgencia, los controles vencidos según la frecuencia recomendada, los pacientes asignados sin mediciones y las notificaciones visibles de los últimos 7 días que el usuario aún no leyó: comunicados y las dirigidas a él, de los tipos que acepta según sus preferencias. Sin pendientes devuelve una lista vacía
+// @Tags usuarios
+// @Produce json
+// @Param id path string true "ID del usuario"
+// @Success 200 {object} ListResponse{data=[]domain.CaregiverTask}
+// @Failure 400 {object} map[string]string "ID inválido"
+// @Failure 404 {object} map[string]string "Usuario no encontrado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/users/{id}/tasks [get]
+func (h *TaskHandler) GetCaregiverTasks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID de usuario inválido", http.StatusBadRequest)
+		return
+	}
+
+	tasks, err := h.taskService.GetCaregiverTasks(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			http.Error(w, "Usuario no encontrado", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeList(w, tasks, nil)
+}
+
+// GetSessionRoster godoc
+// @Summary Lista de pacientes para una jornada de medición
+// @Description Devuelve los pacientes asignados al apoderado con DNI, edad y última medición, ordenados de más a menos atrasados (days_overdue negativo indica días que faltan para el control). Con format=xlsx descarga la lista imprimible con columnas en blanco para la lectura del día
+// @Tags usuarios
+// @Produce json
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param id path string true "ID del usuario"
+// @Param format query string false "json (por defecto) o xlsx"
+// @Success 200 {object} domain.SessionRoster
+// @Failure 400 {object} map[string]string "ID o formato inválido"
+// @Failure 404 {object} map[string]string "Usuario no encontrado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/users/{id}/session-roster [get]
+func (h *TaskHandler) GetSessionRoster(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID de usuario inválido", http.StatusBadRequest)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "xlsx" {
+		http.Error(w, "format debe ser json o xlsx", http.StatusBadRequest)
+		return
+	}
+
+	roster, err := h.taskService.GetSessionRoster(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			http.Error(w, "Usuario no encontrado", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if format != "xlsx" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(roster)
+		return
+	}
+
+	excelData, err := h.fileService.GenerateSessionRosterReport(ctx, roster)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("jornada_%s_%s.xlsx", fileNameSafe(roster.CaregiverName), time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	w.Header().Set("Content-Length", strconv.Itoa(len(excelData)))
+	w.Write(excelData)
+}
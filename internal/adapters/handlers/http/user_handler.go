@@ -2,25 +2,30 @@ package http
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
 
 	"github.com/google/uuid"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
 	"github.com/luispfcanales/api-muac/internal/core/ports"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/luispfcanales/api-muac/internal/infrastructure/auth"
 )
 
 // UserHandler maneja las peticiones HTTP relacionadas con usuarios
 type UserHandler struct {
-	userService ports.IUserService
+	userService    ports.IUserService
+	patientService ports.IPatientService
 	// excelService ports.IFileService
+	logger *slog.Logger
 }
 
 // NewUserHandler crea una nueva instancia de UserHandler
-func NewUserHandler(userService ports.IUserService, excelService ports.IFileService) *UserHandler {
+func NewUserHandler(userService ports.IUserService, patientService ports.IPatientService, excelService ports.IFileService, logger *slog.Logger) *UserHandler {
 	return &UserHandler{
-		userService: userService,
+		userService:    userService,
+		patientService: patientService,
+		logger:         logger,
 		// excelService: excelService,
 	}
 }
@@ -29,6 +34,7 @@ func NewUserHandler(userService ports.IUserService, excelService ports.IFileServ
 func (h *UserHandler) RegisterRoutes(mux *http.ServeMux) {
 	// mux.HandleFunc("GET /api/users/reporte/excel", h.GetApoderados)
 	mux.HandleFunc("GET /api/users", h.GetUsers)
+	mux.HandleFunc("GET /api/users/without-locality", h.GetUsersWithoutLocality)
 	mux.HandleFunc("POST /api/users/login", h.Login)
 	mux.HandleFunc("POST /api/users", h.CreateUser)
 	mux.HandleFunc("GET /api/users/{id}", h.GetUserByID)
@@ -36,6 +42,10 @@ func (h *UserHandler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("DELETE /api/users/{id}", h.DeleteUser)
 	mux.HandleFunc("PUT /api/users/{id}/password", h.UpdatePassword)
 	mux.HandleFunc("PUT /api/users/{id}/role", h.UpdateRole)
+	mux.HandleFunc("PUT /api/users/{id}/locality", h.UpdateLocality)
+	mux.HandleFunc("GET /api/users/{id}/notification-preferences", h.GetNotificationPreferences)
+	mux.HandleFunc("PUT /api/users/{id}/notification-preferences", h.UpdateNotificationPreferences)
+	mux.HandleFunc("GET /api/users/{id}/caseload", h.GetCaseload)
 }
 
 func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
@@ -44,9 +54,7 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		Password        string `json:"password"`
 	}
 
-	err := json.NewDecoder(r.Body).Decode(&loginRequest)
-	if err != nil {
-		http.Error(w, "Error en los datos de entrada", http.StatusBadRequest)
+	if !decodeJSON(w, r, &loginRequest) {
 		return
 	}
 
@@ -55,17 +63,29 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		loginRequest.UsernameOrEmail,
 	)
 	if err != nil {
-		log.Println(err)
+		h.logger.InfoContext(r.Context(), "intento de login con usuario inexistente", "error", err)
 		http.Error(w, "Usuario o contraseñas incorrectos", http.StatusUnauthorized)
 		return
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(loginRequest.Password))
+	err = auth.CheckPassword(user.PasswordHash, loginRequest.Password)
 	if err != nil {
 		http.Error(w, "Usuario o contraseña incorrectos", http.StatusUnauthorized)
 		return
 	}
 
+	// Re-hashear con el costo vigente si el hash almacenado es más débil
+	if auth.NeedsRehash(user.PasswordHash) {
+		if newHash, err := auth.HashPassword(loginRequest.Password); err == nil {
+			if err := h.userService.UpdatePassword(r.Context(), user.ID, newHash); err != nil {
+				h.logger.WarnContext(r.Context(), "no se pudo actualizar el hash de la contraseña",
+					"entity_id", user.ID, "error", err)
+			} else {
+				user.PasswordHash = newHash
+			}
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
 }
@@ -76,7 +96,7 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 // @Tags usuarios
 // @Accept json
 // @Produce json
-// @Success 200 {array} domain.User
+// @Success 200 {object} ListResponse{data=[]domain.User}
 // @Failure 500 {object} map[string]string "Error interno del servidor"
 // @Router /api/users [get]
 func (h *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
@@ -97,8 +117,26 @@ func (h *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(users)
+	writeList(w, users, nil)
+}
+
+// GetUsersWithoutLocality godoc
+// @Summary Obtener usuarios sin localidad
+// @Description Lista los usuarios sin localidad asignada para que un administrador los corrija. En los reportes por localidad estos usuarios se agrupan como "Sin localidad"
+// @Tags usuarios
+// @Accept json
+// @Produce json
+// @Success 200 {object} ListResponse{data=[]domain.User}
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/users/without-locality [get]
+func (h *UserHandler) GetUsersWithoutLocality(w http.ResponseWriter, r *http.Request) {
+	users, err := h.userService.GetWithoutLocality(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeList(w, users, nil)
 }
 
 // func (h *UserHandler) GetApoderados(w http.ResponseWriter, r *http.Request) {
@@ -204,18 +242,16 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		RoleID uuid.UUID `json:"role_id"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&userDTO); err != nil {
-		http.Error(w, "Error al decodificar el cuerpo de la petición", http.StatusBadRequest)
+	if !decodeJSON(w, r, &userDTO) {
 		return
 	}
 
 	// Hashear la contraseña usando bcrypt
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(userDTO.Password), bcrypt.DefaultCost)
+	passwordHash, err := auth.HashPassword(userDTO.Password)
 	if err != nil {
 		http.Error(w, "Error al hashear la contraseña", http.StatusInternalServerError)
 		return
 	}
-	passwordHash := string(hashedPassword)
 
 	user := domain.NewUser(
 		userDTO.Name,
@@ -231,6 +267,10 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	)
 
 	if err := h.userService.Create(r.Context(), user); err != nil {
+		if errors.Is(err, domain.ErrLocalityRequired) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -284,8 +324,7 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		LocalityID *uuid.UUID `json:"locality_id,omitempty"`
 	}
 
-	if err = json.NewDecoder(r.Body).Decode(&userDTO); err != nil {
-		http.Error(w, "Error al decodificar el cuerpo de la petición", http.StatusBadRequest)
+	if !decodeJSON(w, r, &userDTO) {
 		return
 	}
 
@@ -300,12 +339,11 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Hashear la nueva contraseña
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(userDTO.Password), bcrypt.DefaultCost)
+	passwordHash, err := auth.HashPassword(userDTO.Password)
 	if err != nil {
 		http.Error(w, "Error al hashear la contraseña", http.StatusInternalServerError)
 		return
 	}
-	passwordHash := string(hashedPassword)
 
 	if err := h.userService.UpdatePassword(r.Context(), id, passwordHash); err != nil {
 		if err == domain.ErrUserNotFound {
@@ -329,6 +367,10 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	)
 
 	if err := h.userService.Update(r.Context(), user); err != nil {
+		if errors.Is(err, domain.ErrLocalityRequired) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -410,18 +452,16 @@ func (h *UserHandler) UpdatePassword(w http.ResponseWriter, r *http.Request) {
 		Password string `json:"password"`
 	}
 
-	if err = json.NewDecoder(r.Body).Decode(&passwordDTO); err != nil {
-		http.Error(w, "Error al decodificar el cuerpo de la petición", http.StatusBadRequest)
+	if !decodeJSON(w, r, &passwordDTO) {
 		return
 	}
 
 	// Hashear la nueva contraseña
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(passwordDTO.Password), bcrypt.DefaultCost)
+	passwordHash, err := auth.HashPassword(passwordDTO.Password)
 	if err != nil {
 		http.Error(w, "Error al hashear la contraseña", http.StatusInternalServerError)
 		return
 	}
-	passwordHash := string(hashedPassword)
 
 	if err := h.userService.UpdatePassword(r.Context(), id, passwordHash); err != nil {
 		if err == domain.ErrUserNotFound {
@@ -436,6 +476,105 @@ func (h *UserHandler) UpdatePassword(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"message": "Contraseña actualizada"})
 }
 
+// GetNotificationPreferences godoc
+// @Summary Obtener preferencias de notificación
+// @Description Obtiene qué tipos de notificación (alertas rojas, recordatorios, comunicados) acepta el usuario
+// @Tags usuarios
+// @Accept json
+// @Produce json
+// @Param id path string true "ID del usuario"
+// @Success 200 {object} domain.NotificationPreferences
+// @Failure 400 {object} map[string]string "ID inválido"
+// @Failure 404 {object} map[string]string "Usuario no encontrado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/users/{id}/notification-preferences [get]
+func (h *UserHandler) GetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID de usuario inválido", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.GetByID(r.Context(), id)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			http.Error(w, "Usuario no encontrado", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user.NotificationPreferences)
+}
+
+// UpdateNotificationPreferences godoc
+// @Summary Actualizar preferencias de notificación
+// @Description Activa o desactiva tipos de notificación; los campos omitidos conservan su valor actual
+// @Tags usuarios
+// @Accept json
+// @Produce json
+// @Param id path string true "ID del usuario"
+// @Param preferences body object true "red_alerts, reminders y broadcasts (bool, opcionales)"
+// @Success 200 {object} domain.NotificationPreferences
+// @Failure 400 {object} map[string]string "ID inválido o solicitud inválida"
+// @Failure 404 {object} map[string]string "Usuario no encontrado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/users/{id}/notification-preferences [put]
+func (h *UserHandler) UpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID de usuario inválido", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		RedAlerts  *bool `json:"red_alerts"`
+		Reminders  *bool `json:"reminders"`
+		Broadcasts *bool `json:"broadcasts"`
+	}
+
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	user, err := h.userService.GetByID(ctx, id)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			http.Error(w, "Usuario no encontrado", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	preferences := user.NotificationPreferences
+	if req.RedAlerts != nil {
+		preferences.RedAlerts = *req.RedAlerts
+	}
+	if req.Reminders != nil {
+		preferences.Reminders = *req.Reminders
+	}
+	if req.Broadcasts != nil {
+		preferences.Broadcasts = *req.Broadcasts
+	}
+
+	if err := h.userService.UpdateNotificationPreferences(ctx, id, preferences); err != nil {
+		if err == domain.ErrUserNotFound {
+			http.Error(w, "Usuario no encontrado", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preferences)
+}
+
 // UpdateRole godoc
 // @Summary Actualizar rol de un usuario
 // @Description Actualiza el rol de un usuario específico
@@ -466,8 +605,7 @@ func (h *UserHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
 		RoleID uuid.UUID `json:"role_id"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&roleDTO); err != nil {
-		http.Error(w, "Error al decodificar el cuerpo de la petición", http.StatusBadRequest)
+	if !decodeJSON(w, r, &roleDTO) {
 		return
 	}
 
@@ -476,9 +614,117 @@ func (h *UserHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Usuario no encontrado", http.StatusNotFound)
 			return
 		}
+		if errors.Is(err, domain.ErrLocalityRequired) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// UpdateLocality godoc
+// @Summary Cambiar la localidad de un apoderado
+// @Description Mueve al usuario a otra localidad. Sus pacientes lo siguen: los reportes obtienen la localidad del apoderado, así que sus pacientes y su historial de mediciones pasan a contarse en la nueva localidad. Queda registrado en la auditoría con el usuario de X-User-ID
+// @Tags usuarios
+// @Accept json
+// @Produce json
+// @Param id path string true "ID del usuario"
+// @Param X-User-ID header string false "Usuario que realiza el cambio"
+// @Param locality body object true "Nueva localidad" example({"locality_id":"00000000-0000-0000-0000-000000000000"})
+// @Success 200 {object} domain.LocalityMove
+// @Failure 400 {object} map[string]string "ID inválido o locality_id no proporcionado"
+// @Failure 404 {object} map[string]string "Usuario o localidad no encontrados"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/users/{id}/locality [put]
+func (h *UserHandler) UpdateLocality(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID de usuario inválido", http.StatusBadRequest)
+		return
+	}
+
+	actorID, err := parseActorID(r)
+	if err != nil {
+		http.Error(w, "X-User-ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		LocalityID uuid.UUID `json:"locality_id"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.LocalityID == uuid.Nil {
+		http.Error(w, "locality_id es obligatorio", http.StatusBadRequest)
+		return
+	}
+
+	var actor *uuid.UUID
+	if actorID != uuid.Nil {
+		actor = &actorID
+	}
+
+	move, err := h.userService.UpdateLocality(ctx, id, req.LocalityID, actor)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUserNotFound):
+			http.Error(w, "Usuario no encontrado", http.StatusNotFound)
+		case errors.Is(err, domain.ErrLocalityNotFound):
+			http.Error(w, "Localidad no encontrada", http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	h.logger.InfoContext(ctx, "auditoría: localidad del usuario actualizada",
+		"entity_id", move.UserID, "locality_id", move.LocalityID, "affected_patients", move.AffectedPatients)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(move)
+}
+
+// GetCaseload godoc
+// @Summary Obtener la carga de pacientes de un apoderado
+// @Description Devuelve cuántos pacientes tiene asignados el usuario, el límite configurado (0 = sin límite), el modo (warn o block) y si lo supera
+// @Tags usuarios
+// @Produce json
+// @Param id path string true "ID del usuario"
+// @Success 200 {object} domain.Caseload
+// @Failure 400 {object} map[string]string "ID inválido"
+// @Failure 404 {object} map[string]string "Usuario no encontrado"
+// @Failure 500 {object} map[string]string "Error interno del servidor"
+// @Router /api/users/{id}/caseload [get]
+func (h *UserHandler) GetCaseload(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID de usuario inválido", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.userService.GetByID(ctx, id); err != nil {
+		if err == domain.ErrUserNotFound {
+			http.Error(w, "Usuario no encontrado", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	caseload, err := h.patientService.GetCaseload(ctx, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(caseload)
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+	"github.com/luispfcanales/api-muac/internal/infrastructure/auth"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakeUserService implementa solo lo que usa Login; el resto de IUserService no se invoca
+type fakeUserService struct {
+	ports.IUserService
+	user        *domain.User
+	updatedHash string
+}
+
+func (f *fakeUserService) GetByUsernameOrEmail(ctx context.Context, usernameOrEmail string) (*domain.User, error) {
+	return f.user, nil
+}
+
+func (f *fakeUserService) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	f.updatedHash = passwordHash
+	return nil
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestLoginRehashesWeakPassword(t *testing.T) {
+	previous := auth.Cost()
+	t.Cleanup(func() { auth.SetCost(previous) })
+
+	auth.SetCost(bcrypt.MinCost)
+	weakHash, err := auth.HashPassword("secreto")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	auth.SetCost(bcrypt.MinCost + 1)
+
+	users := &fakeUserService{user: &domain.User{ID: uuid.New(), PasswordHash: weakHash}}
+	handler := NewUserHandler(users, nil, nil, discardLogger())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users/login",
+		strings.NewReader(`{"username_or_email":"ana","password":"secreto"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.Login(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, se esperaba 200: %s", rec.Code, rec.Body.String())
+	}
+	if users.updatedHash == "" {
+		t.Fatal("el login no volvió a guardar el hash")
+	}
+	if c, _ := bcrypt.Cost([]byte(users.updatedHash)); c != bcrypt.MinCost+1 {
+		t.Fatalf("costo del nuevo hash = %d, se esperaba %d", c, bcrypt.MinCost+1)
+	}
+	if err := auth.CheckPassword(users.updatedHash, "secreto"); err != nil {
+		t.Fatalf("el nuevo hash no valida la contraseña: %v", err)
+	}
+}
+
+func TestLoginKeepsCurrentHash(t *testing.T) {
+	previous := auth.Cost()
+	t.Cleanup(func() { auth.SetCost(previous) })
+
+	auth.SetCost(bcrypt.MinCost)
+	hash, err := auth.HashPassword("secreto")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+
+	users := &fakeUserService{user: &domain.User{ID: uuid.New(), PasswordHash: hash}}
+	handler := NewUserHandler(users, nil, nil, discardLogger())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users/login",
+		strings.NewReader(`{"username_or_email":"ana","password":"secreto"}`))
+	rec := httptest.NewRecorder()
+	handler.Login(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, se esperaba 200", rec.Code)
+	}
+	if users.updatedHash != "" {
+		t.Fatal("el login guardó un hash aunque el costo no cambió")
+	}
+}
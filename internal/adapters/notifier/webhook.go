@@ -0,0 +1,122 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+)
+
+// errForbiddenAddress se devuelve al intentar conectar con una IP que no es pública
+var errForbiddenAddress = errors.New("el destino resuelve a una dirección no permitida")
+
+// cgnatPrefix es el rango de NAT de operador (RFC 6598), que no es alcanzable desde internet
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// webhookSender entrega notificaciones haciendo POST JSON a la URL registrada en Target
+type webhookSender struct {
+	client *http.Client
+}
+
+// NewWebhookSender crea un emisor de notificaciones vía webhook. Solo se conecta a IPs públicas
+// (verificadas tras resolver el DNS, por lo que también aplica a redirecciones y re-resoluciones) y no
+// sigue redirecciones
+func NewWebhookSender(timeout time.Duration) ports.INotificationSender {
+	return newWebhookSender(timeout, publicAddressOnly)
+}
+
+// newWebhookSender crea el emisor con la verificación de direcciones indicada
+func newWebhookSender(timeout time.Duration, control func(network, address string, c syscall.RawConn) error) *webhookSender {
+	dialer := &net.Dialer{Timeout: timeout, Control: control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &webhookSender{
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// publicAddressOnly rechaza la conexión si la IP resuelta es de loopback, privada, de enlace local,
+// multicast o no especificada
+func publicAddressOnly(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return errForbiddenAddress
+	}
+	if !isPublicAddr(addrPort.Addr()) {
+		return errForbiddenAddress
+	}
+	return nil
+}
+
+// isPublicAddr indica si la IP es enrutable en internet
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified() &&
+		!cgnatPrefix.Contains(addr)
+}
+
+// Send envía la notificación a su destino; cualquier respuesta fuera de 2xx se considera fallo. Del
+// destino solo se registra el código de estado, nunca el cuerpo de la respuesta
+func (s *webhookSender) Send(ctx context.Context, notification *domain.Notification) error {
+	if err := domain.ValidateNotificationTarget(notification.Target); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":         notification.ID,
+		"title":      notification.Title,
+		"body":       notification.Body,
+		"created_at": notification.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("error al serializar notificación: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, notification.Target, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("destino de notificación inválido: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		if errors.Is(err, errForbiddenAddress) {
+			return errForbiddenAddress
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return errors.New("tiempo de espera agotado al contactar el destino")
+		}
+		return errors.New("error al contactar el destino")
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("el destino respondió %d", resp.StatusCode)
+	}
+	return nil
+}
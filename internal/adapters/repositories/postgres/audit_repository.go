@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+	"gorm.io/gorm"
+)
+
+// auditRepository implementa la interfaz IAuditRepository usando GORM
+type auditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository crea una nueva instancia de AuditRepository
+func NewAuditRepository(db *gorm.DB) ports.IAuditRepository {
+	return &auditRepository{
+		db: db,
+	}
+}
+
+// Create guarda una entrada de auditoría
+func (r *auditRepository) Create(ctx context.Context, entry *domain.AuditEntry) error {
+	if err := r.db.WithContext(ctx).Create(entry).Error; err != nil {
+		return fmt.Errorf("error al registrar auditoría: %w", err)
+	}
+	return nil
+}
+
+// List obtiene una página de entradas filtradas, las más recientes primero
+func (r *auditRepository) List(ctx context.Context, filter *domain.AuditFilter, page *domain.Pagination) ([]*domain.AuditEntry, error) {
+	query := r.db.WithContext(ctx).Model(&domain.AuditEntry{})
+
+	if filter.Entity != "" {
+		query = query.Where("entity = ?", filter.Entity)
+	}
+	if filter.EntityID != nil {
+		query = query.Where("entity_id = ?", *filter.EntityID)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.StartDate != nil {
+		query = query.Where("created_at >= ?", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		query = query.Where("created_at < ?", *filter.EndDate)
+	}
+
+	// Sesión nueva para reutilizar la consulta en el conteo y en la página
+	query = query.Session(&gorm.Session{})
+
+	if err := query.Count(&page.Total).Error; err != nil {
+		return nil, fmt.Errorf("error al contar entradas de auditoría: %w", err)
+	}
+
+	var entries []*domain.AuditEntry
+	err := query.
+		Order("created_at DESC, id").
+		Limit(page.PageSize).
+		Offset(page.Offset()).
+		Find(&entries).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener entradas de auditoría: %w", err)
+	}
+	return entries, nil
+}
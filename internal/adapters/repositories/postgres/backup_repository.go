@@ -0,0 +1,196 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+	"gorm.io/gorm"
+)
+
+// backupRepository implementa la interfaz IBackupRepository usando GORM
+type backupRepository struct {
+	db *gorm.DB
+}
+
+// NewBackupRepository crea una nueva instancia de BackupRepository
+func NewBackupRepository(db *gorm.DB) ports.IBackupRepository {
+	return &backupRepository{
+		db: db,
+	}
+}
+
+// tableColumns obtiene las columnas de la tabla en el orden de la base de datos
+func tableColumns(db *gorm.DB, table string) ([]string, error) {
+	columnTypes, err := db.Migrator().ColumnTypes(table)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener columnas de %s: %w", table, err)
+	}
+	columns := make([]string, 0, len(columnTypes))
+	for _, columnType := range columnTypes {
+		columns = append(columns, columnType.Name())
+	}
+	return columns, nil
+}
+
+// backupExporter implementa IBackupExporter sobre la transacción de una exportación
+type backupExporter struct {
+	tx *gorm.DB
+}
+
+// Export ejecuta fn en una transacción REPEATABLE READ de solo lectura, de modo que todas las tablas
+// salen de la misma instantánea y las referencias entre ellas son consistentes
+func (r *backupRepository) Export(ctx context.Context, fn func(exporter ports.IBackupExporter) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&backupExporter{tx: tx})
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+}
+
+// StreamTable recorre las filas de la entidad ordenadas por id sin cargarlas todas en memoria
+func (e *backupExporter) StreamTable(entity domain.BackupEntity, fn func(row map[string]interface{}) error) error {
+	db := e.tx
+	columns, err := tableColumns(db, entity.Table)
+	if err != nil {
+		return err
+	}
+
+	omitted := make(map[string]bool, len(entity.OmitColumns))
+	for _, column := range entity.OmitColumns {
+		omitted[column] = true
+	}
+	selected := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if !omitted[column] {
+			selected = append(selected, column)
+		}
+	}
+
+	rows, err := db.Table(entity.Table).Select(selected).Order("id").Rows()
+	if err != nil {
+		return fmt.Errorf("error al exportar %s: %w", entity.Name, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		row := make(map[string]interface{}, len(selected))
+		if err := db.ScanRows(rows, &row); err != nil {
+			return fmt.Errorf("error al leer fila de %s: %w", entity.Name, err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error al exportar %s: %w", entity.Name, err)
+	}
+	return nil
+}
+
+// backupImporter implementa IBackupImporter sobre la transacción de una importación
+type backupImporter struct {
+	tx      *gorm.DB
+	columns map[string]map[string]bool // Columnas conocidas por tabla
+}
+
+// Import ejecuta fn en una transacción; si fn devuelve error se revierte todo
+func (r *backupRepository) Import(ctx context.Context, fn func(importer ports.IBackupImporter) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&backupImporter{tx: tx, columns: make(map[string]map[string]bool)})
+	})
+}
+
+// ExistingIDs devuelve cuáles de los ids existen en la tabla de la entidad
+func (i *backupImporter) ExistingIDs(entity domain.BackupEntity, ids []string) (map[string]bool, error) {
+	existing := make(map[string]bool, len(ids))
+	if len(ids) == 0 {
+		return existing, nil
+	}
+
+	var found []string
+	if err := i.tx.Table(entity.Table).Where("id IN ?", ids).Pluck("id", &found).Error; err != nil {
+		return nil, fmt.Errorf("error al buscar ids de %s: %w", entity.Name, err)
+	}
+	for _, id := range found {
+		existing[strings.ToLower(id)] = true
+	}
+	return existing, nil
+}
+
+// Upsert inserta las filas nuevas y sobrescribe las existentes por id. Se ejecuta en dos sentencias
+// para que las ImportDefaults no pisen columnas de filas que ya existen (por ejemplo, la contraseña)
+func (i *backupImporter) Upsert(entity domain.BackupEntity, rows []map[string]interface{}) (int64, int64, error) {
+	if len(rows) == 0 {
+		return 0, 0, nil
+	}
+	if err := i.checkColumns(entity, rows); err != nil {
+		return 0, 0, err
+	}
+
+	ids := make([]string, len(rows))
+	for n, row := range rows {
+		ids[n], _ = row["id"].(string)
+	}
+	existing, err := i.ExistingIDs(entity, ids)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var inserted, updated int64
+	for _, row := range rows {
+		id, _ := row["id"].(string)
+		if existing[id] {
+			values := make(map[string]interface{}, len(row))
+			for column, value := range row {
+				if column != "id" {
+					values[column] = value
+				}
+			}
+			if len(values) > 0 {
+				if err := i.tx.Table(entity.Table).Where("id = ?", id).Updates(values).Error; err != nil {
+					return 0, 0, fmt.Errorf("error al actualizar %s %s: %w", entity.Name, id, err)
+				}
+			}
+			updated++
+			continue
+		}
+
+		for column, value := range entity.ImportDefaults {
+			if _, ok := row[column]; !ok {
+				row[column] = value
+			}
+		}
+		if err := i.tx.Table(entity.Table).Create(row).Error; err != nil {
+			return 0, 0, fmt.Errorf("error al importar %s %s: %w", entity.Name, id, err)
+		}
+		existing[id] = true // Un id repetido en el archivo actualiza la fila recién insertada
+		inserted++
+	}
+	return inserted, updated, nil
+}
+
+// checkColumns rechaza las filas con columnas que no existen en la tabla
+func (i *backupImporter) checkColumns(entity domain.BackupEntity, rows []map[string]interface{}) error {
+	columns, ok := i.columns[entity.Table]
+	if !ok {
+		names, err := tableColumns(i.tx, entity.Table)
+		if err != nil {
+			return err
+		}
+		columns = make(map[string]bool, len(names))
+		for _, name := range names {
+			columns[name] = true
+		}
+		i.columns[entity.Table] = columns
+	}
+	for _, row := range rows {
+		for column := range row {
+			if !columns[column] {
+				return fmt.Errorf("%w: %s.%s", domain.ErrUnknownBackupColumn, entity.Name, column)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDB abre la base de TEST_DATABASE_URL, migra los modelos y devuelve una transacción que se revierte
+// al terminar la prueba, para no dejar datos. Sin la variable la prueba se omite
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL no definida: se omiten las pruebas contra PostgreSQL")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("no se pudo conectar a la base de pruebas: %v", err)
+	}
+	tx := db.Begin()
+	if tx.Error != nil {
+		t.Fatalf("no se pudo iniciar la transacción: %v", tx.Error)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+
+	err = tx.AutoMigrate(
+		&domain.Role{},
+		&domain.Locality{},
+		&domain.Patient{},
+		&domain.Tag{},
+		&domain.User{},
+		&domain.Recommendation{},
+		&domain.Measurement{},
+		&domain.MeasurementDeletion{},
+		&domain.MeasurementRelocation{},
+	)
+	if err != nil {
+		t.Fatalf("error al migrar modelos: %v", err)
+	}
+	return tx
+}
+
+// Datos mínimos para las pruebas contra PostgreSQL; cada uno falla la prueba si no se puede crear
+
+func createLocality(t *testing.T, db *gorm.DB, name string) *domain.Locality {
+	t.Helper()
+	locality := &domain.Locality{ID: uuid.New(), Name: name}
+	mustCreate(t, db, locality)
+	return locality
+}
+
+func createCaregiver(t *testing.T, db *gorm.DB, localityID uuid.UUID) *domain.User {
+	t.Helper()
+	role := &domain.Role{ID: uuid.New(), Name: "APODERADO"}
+	mustCreate(t, db, role)
+	suffix := uuid.NewString()[:8]
+	user := &domain.User{
+		ID:                      uuid.New(),
+		Name:                    "Ana",
+		LastName:                "Quispe",
+		Username:                "ana-" + suffix,
+		Email:                   "ana-" + suffix + "@example.com",
+		DNI:                     suffix,
+		PasswordHash:            "!",
+		Active:                  true,
+		RoleID:                  role.ID,
+		LocalityID:              &localityID,
+		NotificationPreferences: domain.DefaultNotificationPreferences(),
+	}
+	mustCreate(t, db, user)
+	return user
+}
+
+func createPatient(t *testing.T, db *gorm.DB, caregiverID uuid.UUID) *domain.Patient {
+	t.Helper()
+	patient := domain.NewPatient("Luz", "Mamani", "F", "", "", "", "", "", 2, uuid.NewString()[:8], true, &caregiverID)
+	patient.ApprovalStatus = domain.PatientApprovalApproved
+	mustCreate(t, db, patient)
+	return patient
+}
+
+func createMeasurement(t *testing.T, db *gorm.DB, patientID, userID uuid.UUID, muac float64) *domain.Measurement {
+	t.Helper()
+	measurement := &domain.Measurement{ID: uuid.New(), MuacValue: muac, PatientID: patientID, UserID: userID, CreatedAt: time.Now()}
+	mustCreate(t, db, measurement)
+	return measurement
+}
+
+func mustCreate(t *testing.T, db *gorm.DB, value interface{}) {
+	t.Helper()
+	if err := db.Create(value).Error; err != nil {
+		t.Fatalf("error al crear %T: %v", value, err)
+	}
+}
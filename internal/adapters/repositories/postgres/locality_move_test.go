@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+	"gorm.io/gorm"
+)
+
+// localityTotal devuelve cuántos pacientes agrupa el reporte por localidad en la localidad indicada
+func localityTotal(t *testing.T, report *domain.PatientsByLocalityReport, localityID uuid.UUID) int {
+	t.Helper()
+	for _, data := range report.LocalityData {
+		if data.LocalityID == localityID {
+			return data.Total
+		}
+	}
+	return 0
+}
+
+func TestReportGroupingFollowsCaregiverMove(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	from := createLocality(t, db, "Iberia")
+	to := createLocality(t, db, "Iñapari")
+	caregiver := createCaregiver(t, db, from.ID)
+	patient := createPatient(t, db, caregiver.ID)
+	createMeasurement(t, db, patient.ID, caregiver.ID, 11.0)
+
+	reports := NewReportRepository(db, db)
+	users := NewUserRepository(db)
+
+	if err := users.UpdateLocality(ctx, caregiver.ID, to.ID); err != nil {
+		t.Fatalf("UpdateLocality: %v", err)
+	}
+
+	for _, tt := range []struct {
+		locality *domain.Locality
+		want     int
+	}{{from, 0}, {to, 1}} {
+		byLocality, err := reports.GetPatientsByLocality(ctx, &domain.ReportFilters{LocalityID: &tt.locality.ID})
+		if err != nil {
+			t.Fatalf("GetPatientsByLocality: %v", err)
+		}
+		if got := localityTotal(t, byLocality, tt.locality.ID); got != tt.want {
+			t.Errorf("pacientes agrupados en %s = %d, se esperaba %d", tt.locality.Name, got, tt.want)
+		}
+
+		dashboard, err := reports.GetDashboardData(ctx, &domain.ReportFilters{LocalityID: &tt.locality.ID})
+		if err != nil {
+			t.Fatalf("GetDashboardData: %v", err)
+		}
+		if dashboard.TotalPatients != int64(tt.want) || dashboard.PatientsAtRisk != int64(tt.want) {
+			t.Errorf("dashboard de %s = %d pacientes y %d en riesgo, se esperaba %d",
+				tt.locality.Name, dashboard.TotalPatients, dashboard.PatientsAtRisk, tt.want)
+		}
+	}
+}
+
+func TestDeletedMeasurementsFollowCaregiverMove(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	from := createLocality(t, db, "Iberia")
+	to := createLocality(t, db, "Iñapari")
+	caregiver := createCaregiver(t, db, from.ID)
+	patient := createPatient(t, db, caregiver.ID)
+	deleted := createMeasurement(t, db, patient.ID, caregiver.ID, 12.0)
+	createMeasurement(t, db, patient.ID, caregiver.ID, 12.8)
+
+	measurements := NewMeasurementRepository(db)
+	if err := measurements.Delete(ctx, deleted.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := NewUserRepository(db).UpdateLocality(ctx, caregiver.ID, to.ID); err != nil {
+		t.Fatalf("UpdateLocality: %v", err)
+	}
+
+	window := func(localityID uuid.UUID) *domain.MeasurementSyncFilter {
+		return &domain.MeasurementSyncFilter{Since: time.Time{}, Until: time.Now().Add(time.Hour), LocalityID: &localityID}
+	}
+	for _, tt := range []struct {
+		locality *domain.Locality
+		want     bool
+	}{{from, false}, {to, true}} {
+		ids, err := measurements.GetDeletedSince(ctx, window(tt.locality.ID))
+		if err != nil {
+			t.Fatalf("GetDeletedSince: %v", err)
+		}
+		found := false
+		for _, id := range ids {
+			found = found || id == deleted.ID
+		}
+		if found != tt.want {
+			t.Errorf("eliminación visible en %s = %v, se esperaba %v", tt.locality.Name, found, tt.want)
+		}
+	}
+}
+
+// syncedIDs devuelve las mediciones que la sincronización de la localidad entrega como modificadas y
+// como eliminadas
+func syncedIDs(t *testing.T, measurements ports.IMeasurementRepository, localityID uuid.UUID) (changed, deleted map[uuid.UUID]bool) {
+	t.Helper()
+	ctx := context.Background()
+	filter := &domain.MeasurementSyncFilter{Since: time.Now().Add(-time.Hour), Until: time.Now().Add(time.Hour), LocalityID: &localityID}
+
+	page := &domain.Pagination{Page: 1, PageSize: 100}
+	rows, err := measurements.GetChangedSince(ctx, filter, page)
+	if err != nil {
+		t.Fatalf("GetChangedSince: %v", err)
+	}
+	changed = make(map[uuid.UUID]bool)
+	for _, m := range rows {
+		changed[m.ID] = true
+	}
+
+	ids, err := measurements.GetDeletedSince(ctx, filter)
+	if err != nil {
+		t.Fatalf("GetDeletedSince: %v", err)
+	}
+	deleted = make(map[uuid.UUID]bool)
+	for _, id := range ids {
+		deleted[id] = true
+	}
+	return changed, deleted
+}
+
+func TestSyncFollowsMeasurementRelocation(t *testing.T) {
+	tests := []struct {
+		name string
+		move func(t *testing.T, db *gorm.DB, caregiver *domain.User, patient *domain.Patient, to *domain.Locality)
+	}{
+		{"cambio de localidad del apoderado", func(t *testing.T, db *gorm.DB, caregiver *domain.User, patient *domain.Patient, to *domain.Locality) {
+			if err := NewUserRepository(db).UpdateLocality(context.Background(), caregiver.ID, to.ID); err != nil {
+				t.Fatalf("UpdateLocality: %v", err)
+			}
+		}},
+		{"reasignación del paciente", func(t *testing.T, db *gorm.DB, caregiver *domain.User, patient *domain.Patient, to *domain.Locality) {
+			other := createCaregiver(t, db, to.ID)
+			patient.UserID = &other.ID
+			if err := NewPatientRepository(db).Update(context.Background(), patient); err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDB(t)
+			from := createLocality(t, db, "Iberia")
+			to := createLocality(t, db, "Iñapari")
+			caregiver := createCaregiver(t, db, from.ID)
+			patient := createPatient(t, db, caregiver.ID)
+			measurement := createMeasurement(t, db, patient.ID, caregiver.ID, 11.0)
+			// La medición se sincronizó antes del traslado: fuera de la ventana de sus cambios
+			if err := db.Model(measurement).UpdateColumn("updated_at", time.Now().Add(-2*time.Hour)).Error; err != nil {
+				t.Fatalf("error al fechar la medición: %v", err)
+			}
+
+			tt.move(t, db, caregiver, patient, to)
+
+			measurements := NewMeasurementRepository(db)
+			changed, deleted := syncedIDs(t, measurements, from.ID)
+			if changed[measurement.ID] || !deleted[measurement.ID] {
+				t.Errorf("en el origen: modificada = %v, eliminada = %v; se esperaba solo eliminada", changed[measurement.ID], deleted[measurement.ID])
+			}
+			changed, deleted = syncedIDs(t, measurements, to.ID)
+			if !changed[measurement.ID] || deleted[measurement.ID] {
+				t.Errorf("en el destino: modificada = %v, eliminada = %v; se esperaba solo modificada", changed[measurement.ID], deleted[measurement.ID])
+			}
+		})
+	}
+}
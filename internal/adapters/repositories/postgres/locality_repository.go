@@ -7,11 +7,13 @@ import (
 	"math"
 	"sort"
 	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
 	"github.com/luispfcanales/api-muac/internal/core/ports"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // localityRepository implementa la interfaz ILocalityRepository usando GORM
@@ -140,6 +142,87 @@ func (r *localityRepository) FindNearby(ctx context.Context, lat, lng float64, r
 	return nearbyLocalities, nil
 }
 
+// FindNearbyWithRisk obtiene las localidades (centros médicos o no) dentro del radio, ordenadas por
+// distancia, con los pacientes de sus apoderados cuya última medición está en riesgo
+func (r *localityRepository) FindNearbyWithRisk(ctx context.Context, lat, lng float64, radiusKm float64) ([]domain.NearbyLocalityRisk, error) {
+	var allLocalities []domain.Locality
+	if err := r.db.WithContext(ctx).Find(&allLocalities).Error; err != nil {
+		return nil, fmt.Errorf("error al obtener localidades: %w", err)
+	}
+
+	nearby := make([]domain.NearbyLocalityRisk, 0)
+	ids := make([]uuid.UUID, 0)
+	for _, loc := range allLocalities {
+		locLat, err := strconv.ParseFloat(loc.Latitude, 64)
+		if err != nil {
+			continue
+		}
+		locLng, err := strconv.ParseFloat(loc.Longitude, 64)
+		if err != nil {
+			continue
+		}
+
+		distance := haversine(lat, lng, locLat, locLng)
+		if distance > radiusKm {
+			continue
+		}
+		nearby = append(nearby, domain.NearbyLocalityRisk{
+			LocalityID:      loc.ID,
+			Name:            loc.Name,
+			Latitude:        loc.Latitude,
+			Longitude:       loc.Longitude,
+			IsMedicalCenter: loc.IsMedicalCenter,
+			DistanceKm:      math.Round(distance*100) / 100,
+		})
+		ids = append(ids, loc.ID)
+	}
+	if len(nearby) == 0 {
+		return nearby, nil
+	}
+
+	latest := r.db.
+		Select("DISTINCT ON (m.patient_id) m.patient_id, m.muac_value, u.locality_id").
+		Table("measurements m").
+		Joins("JOIN patients p ON m.patient_id = p.id").
+		Joins("JOIN users u ON p.user_id = u.id").
+		Where("u.locality_id IN ?", ids).
+		Order("m.patient_id, m.created_at DESC")
+
+	var counts []struct {
+		LocalityID uuid.UUID
+		Severe     int64
+		Moderate   int64
+	}
+	err := r.db.WithContext(ctx).
+		Select(`lm.locality_id,
+			COUNT(CASE WHEN lm.muac_value < ? THEN 1 END) as severe,
+			COUNT(CASE WHEN lm.muac_value >= ? AND lm.muac_value < ? THEN 1 END) as moderate`,
+			domain.MuacThresholdSevere, domain.MuacThresholdSevere, domain.MuacThresholdNormal).
+		Table("(?) lm", latest).
+		Group("lm.locality_id").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al contar pacientes en riesgo por localidad: %w", err)
+	}
+
+	byLocality := make(map[uuid.UUID]int, len(nearby))
+	for i := range nearby {
+		byLocality[nearby[i].LocalityID] = i
+	}
+	for _, c := range counts {
+		if i, ok := byLocality[c.LocalityID]; ok {
+			nearby[i].Severe = c.Severe
+			nearby[i].Moderate = c.Moderate
+			nearby[i].AtRisk = c.Severe + c.Moderate
+		}
+	}
+
+	sort.SliceStable(nearby, func(i, j int) bool {
+		return nearby[i].DistanceKm < nearby[j].DistanceKm
+	})
+	return nearby, nil
+}
+
 // Función Haversine implementada en Go
 func haversine(lat1, lon1, lat2, lon2 float64) float64 {
 	const R = 6371 // Radio de la Tierra en km
@@ -151,3 +234,96 @@ func haversine(lat1, lon1, lat2, lon2 float64) float64 {
 	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
 	return R * c
 }
+
+// GetMeasurements obtiene, paginadas y de la más reciente a la más antigua, las mediciones
+// de los pacientes cuyos apoderados pertenecen a la localidad
+func (r *localityRepository) GetMeasurements(ctx context.Context, localityID uuid.UUID, days int, page *domain.Pagination) ([]domain.LocalityMeasurement, error) {
+	query := r.db.WithContext(ctx).
+		Table("measurements m").
+		Joins("JOIN patients p ON m.patient_id = p.id").
+		Joins("JOIN users u ON p.user_id = u.id").
+		Where("u.locality_id = ?", localityID)
+
+	if days > 0 {
+		since := time.Now().AddDate(0, 0, -days)
+		query = query.Where("m.created_at >= ?", since)
+	}
+
+	// Sesión nueva para reutilizar la consulta en el conteo y en la página
+	query = query.Session(&gorm.Session{})
+
+	if err := query.Count(&page.Total).Error; err != nil {
+		return nil, fmt.Errorf("error al contar mediciones de la localidad: %w", err)
+	}
+
+	var measurements []domain.LocalityMeasurement
+	err := query.
+		Select(`
+			m.id as measurement_id,
+			m.muac_value,
+			p.id as patient_id,
+			CONCAT(p.name, ' ', p.lastname) as patient_name,
+			u.id as caregiver_id,
+			CONCAT(u.name, ' ', u.lastname) as caregiver_name,
+			m.created_at as measured_at
+		`).
+		Order("m.created_at DESC, m.id").
+		Offset(page.Offset()).
+		Limit(page.PageSize).
+		Scan(&measurements).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener mediciones de la localidad: %w", err)
+	}
+
+	for i := range measurements {
+		measurements[i].Classify()
+	}
+	return measurements, nil
+}
+
+// GetRoster obtiene los pacientes de la localidad registrados hasta asOf, con su última medición a esa fecha
+func (r *localityRepository) GetRoster(ctx context.Context, localityID uuid.UUID, asOf time.Time) ([]domain.LocalityRosterEntry, error) {
+	latest := r.db.
+		Table("measurements").
+		Select("DISTINCT ON (patient_id) patient_id, muac_value, created_at").
+		Where("created_at <= ?", asOf).
+		Order("patient_id, created_at DESC")
+
+	var entries []domain.LocalityRosterEntry
+	err := r.db.WithContext(ctx).
+		Table("patients p").
+		Select(`
+			p.id as patient_id,
+			CONCAT(p.name, ' ', p.lastname) as patient_name,
+			p.dni,
+			p.gender,
+			p.age,
+			p.birth_date,
+			CONCAT(u.name, ' ', u.lastname) as caregiver_name,
+			lm.muac_value,
+			lm.created_at as last_measured_at
+		`).
+		Joins("JOIN users u ON p.user_id = u.id").
+		Joins("LEFT JOIN (?) lm ON lm.patient_id = p.id", latest).
+		Where("u.locality_id = ? AND p.created_at <= ?", localityID, asOf).
+		Order("p.lastname, p.name").
+		Scan(&entries).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener padrón de la localidad: %w", err)
+	}
+	return entries, nil
+}
+
+// SaveTarget crea o reemplaza la meta mensual de la localidad
+func (r *localityRepository) SaveTarget(ctx context.Context, target *domain.LocalityTarget) error {
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "locality_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"monthly_target", "updated_by", "updated_at"}),
+		}).
+		Create(target).Error
+	if err != nil {
+		return fmt.Errorf("error al guardar meta de la localidad: %w", err)
+	}
+	return nil
+}
@@ -39,6 +39,7 @@ func (r *measurementRepository) GetByID(ctx context.Context, id uuid.UUID) (*dom
 	result := r.db.WithContext(ctx).
 		Preload("Patient").
 		Preload("User").
+		Preload("User.Role").
 		Preload("Tag").
 		Preload("Recommendation").
 		Where("ID = ?", id).
@@ -70,6 +71,97 @@ func (r *measurementRepository) GetByPatientID(ctx context.Context, patientID uu
 	return measurements, nil
 }
 
+// GetByPatientIDs obtiene las mediciones de varios pacientes en una sola consulta
+func (r *measurementRepository) GetByPatientIDs(ctx context.Context, patientIDs []uuid.UUID) ([]*domain.Measurement, error) {
+	var measurements []*domain.Measurement
+	result := r.db.WithContext(ctx).
+		Preload("Patient").
+		Preload("User").
+		Preload("Tag").
+		Preload("Recommendation").
+		Where("PATIENT_ID IN ?", patientIDs).
+		Order("patient_id, created_at DESC").
+		Find(&measurements)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("error al obtener mediciones por IDs de pacientes: %w", result.Error)
+	}
+	return measurements, nil
+}
+
+// GetLatestByPatientID obtiene la medición más reciente de un paciente
+func (r *measurementRepository) GetLatestByPatientID(ctx context.Context, patientID uuid.UUID) (*domain.Measurement, error) {
+	var measurement domain.Measurement
+	result := r.db.WithContext(ctx).
+		Where("patient_id = ?", patientID).
+		Order("created_at DESC").
+		First(&measurement)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrMeasurementNotFound
+		}
+		return nil, fmt.Errorf("error al obtener la última medición del paciente: %w", result.Error)
+	}
+	return &measurement, nil
+}
+
+// GetLatestBeforeByPatientID obtiene la última medición de un paciente tomada antes de before, para
+// comparar con ella una medición registrada con fecha pasada
+func (r *measurementRepository) GetLatestBeforeByPatientID(ctx context.Context, patientID uuid.UUID, before time.Time) (*domain.Measurement, error) {
+	var measurement domain.Measurement
+	result := r.db.WithContext(ctx).
+		Where("patient_id = ? AND created_at < ?", patientID, before).
+		Order("created_at DESC").
+		First(&measurement)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrMeasurementNotFound
+		}
+		return nil, fmt.Errorf("error al obtener la medición anterior del paciente: %w", result.Error)
+	}
+	return &measurement, nil
+}
+
+// latestByPatientChunkSize es la cantidad de pacientes por consulta en GetLatestByPatientIDs, muy por
+// debajo del límite de 65535 parámetros por sentencia de Postgres
+const latestByPatientChunkSize = 1000
+
+// chunkUUIDs divide ids en tramos de como máximo size elementos
+func chunkUUIDs(ids []uuid.UUID, size int) [][]uuid.UUID {
+	chunks := make([][]uuid.UUID, 0, (len(ids)+size-1)/size)
+	for start := 0; start < len(ids); start += size {
+		end := min(start+size, len(ids))
+		chunks = append(chunks, ids[start:end])
+	}
+	return chunks
+}
+
+// GetLatestByPatientIDs obtiene la medición más reciente de cada paciente indicado, indexada por paciente.
+// Los IDs se consultan por tramos para no superar el límite de parámetros de la sentencia
+func (r *measurementRepository) GetLatestByPatientIDs(ctx context.Context, patientIDs []uuid.UUID) (map[uuid.UUID]*domain.Measurement, error) {
+	latest := make(map[uuid.UUID]*domain.Measurement, len(patientIDs))
+
+	for _, chunk := range chunkUUIDs(patientIDs, latestByPatientChunkSize) {
+		var measurements []*domain.Measurement
+		result := r.db.WithContext(ctx).
+			Raw(`SELECT DISTINCT ON (patient_id) * FROM measurements
+				WHERE patient_id IN ?
+				ORDER BY patient_id, created_at DESC`, chunk).
+			Scan(&measurements)
+
+		if result.Error != nil {
+			return nil, fmt.Errorf("error al obtener las últimas mediciones de los pacientes: %w", result.Error)
+		}
+
+		for _, m := range measurements {
+			latest[m.PatientID] = m
+		}
+	}
+	return latest, nil
+}
+
 // GetByUserID obtiene mediciones por ID de usuario
 func (r *measurementRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Measurement, error) {
 	var measurements []*domain.Measurement
@@ -185,14 +277,295 @@ func (r *measurementRepository) Update(ctx context.Context, measurement *domain.
 	return nil
 }
 
-// Delete elimina una medición por su ID
+// Delete elimina una medición por su ID dejando registro de la eliminación para la sincronización
 func (r *measurementRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	result := r.db.WithContext(ctx).Delete(&domain.Measurement{}, "ID = ?", id)
-	if result.Error != nil {
-		return fmt.Errorf("error al eliminar medición: %w", result.Error)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := recordMeasurementDeletions(tx, "m.id = ?", id); err != nil {
+			return err
+		}
+
+		result := tx.Delete(&domain.Measurement{}, "ID = ?", id)
+		if result.Error != nil {
+			return fmt.Errorf("error al eliminar medición: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrMeasurementNotFound
+		}
+		return nil
+	})
+}
+
+// recordMeasurementDeletions guarda, dentro de la transacción, las mediciones que cumplen
+// la condición y están por eliminarse. La localidad del apoderado en ese momento solo se usa
+// al sincronizar si luego se elimina también el paciente (ver GetDeletedSince)
+func recordMeasurementDeletions(tx *gorm.DB, condition string, args ...interface{}) error {
+	err := tx.Exec(`
+		INSERT INTO measurement_deletions (measurement_id, patient_id, locality_id, deleted_at)
+		SELECT m.id, m.patient_id, u.locality_id, NOW()
+		FROM measurements m
+		JOIN patients p ON m.patient_id = p.id
+		LEFT JOIN users u ON p.user_id = u.id
+		WHERE `+condition+`
+		ON CONFLICT (measurement_id) DO UPDATE SET deleted_at = EXCLUDED.deleted_at
+	`, args...).Error
+	if err != nil {
+		return fmt.Errorf("error al registrar mediciones eliminadas: %w", err)
 	}
-	if result.RowsAffected == 0 {
-		return domain.ErrMeasurementNotFound
+	return nil
+}
+
+// recordMeasurementRelocations guarda, dentro de la transacción y antes del cambio, las mediciones que
+// cumplen la condición y dejan su localidad actual para pasar a toLocalityID (nil si quedan sin
+// localidad). GetDeletedSince las informa como eliminadas a la localidad de origen y GetChangedSince
+// como modificadas a la de destino
+func recordMeasurementRelocations(tx *gorm.DB, toLocalityID *uuid.UUID, condition string, args ...interface{}) error {
+	err := tx.Exec(`
+		INSERT INTO measurement_relocations (measurement_id, from_locality_id, patient_id, to_locality_id, moved_at)
+		SELECT m.id, u.locality_id, m.patient_id, CAST(? AS uuid), NOW()
+		FROM measurements m
+		JOIN patients p ON m.patient_id = p.id
+		JOIN users u ON p.user_id = u.id
+		WHERE u.locality_id IS NOT NULL AND u.locality_id IS DISTINCT FROM CAST(? AS uuid) AND `+condition+`
+		ON CONFLICT (measurement_id, from_locality_id) DO UPDATE
+		SET to_locality_id = EXCLUDED.to_locality_id, moved_at = EXCLUDED.moved_at
+	`, append([]interface{}{toLocalityID, toLocalityID}, args...)...).Error
+	if err != nil {
+		return fmt.Errorf("error al registrar mediciones trasladadas: %w", err)
 	}
 	return nil
 }
+
+// GetChangedSince obtiene, paginadas, las mediciones creadas o actualizadas dentro de la ventana de sincronización
+// Con localidad también incluye las que llegaron a ella en la ventana por un cambio de localidad
+func (r *measurementRepository) GetChangedSince(ctx context.Context, filter *domain.MeasurementSyncFilter, page *domain.Pagination) ([]*domain.Measurement, error) {
+	query := r.db.WithContext(ctx).Model(&domain.Measurement{})
+
+	if filter.LocalityID != nil {
+		patientsInLocality := r.db.
+			Table("patients p").
+			Select("p.id").
+			Joins("JOIN users u ON p.user_id = u.id").
+			Where("u.locality_id = ?", *filter.LocalityID)
+		arrivals := r.db.
+			Table("measurement_relocations").
+			Select("measurement_id").
+			Where("to_locality_id = ? AND moved_at > ? AND moved_at <= ?", *filter.LocalityID, filter.Since, filter.Until)
+		query = query.
+			Where("measurements.patient_id IN (?)", patientsInLocality).
+			Where("((measurements.updated_at > ? AND measurements.updated_at <= ?) OR measurements.id IN (?))",
+				filter.Since, filter.Until, arrivals)
+	} else {
+		query = query.Where("measurements.updated_at > ? AND measurements.updated_at <= ?", filter.Since, filter.Until)
+	}
+
+	// Sesión nueva para reutilizar la consulta en el conteo y en la página
+	query = query.Session(&gorm.Session{})
+
+	if err := query.Count(&page.Total).Error; err != nil {
+		return nil, fmt.Errorf("error al contar mediciones modificadas: %w", err)
+	}
+
+	var measurements []*domain.Measurement
+	result := query.
+		Preload("Tag").
+		Preload("Recommendation").
+		Order("measurements.updated_at ASC, measurements.id ASC").
+		Offset(page.Offset()).
+		Limit(page.PageSize).
+		Find(&measurements)
+	if result.Error != nil {
+		return nil, fmt.Errorf("error al obtener mediciones modificadas: %w", result.Error)
+	}
+	return measurements, nil
+}
+
+// GetDeletedSince obtiene los IDs de las mediciones eliminadas dentro de la ventana de sincronización.
+// La localidad se resuelve al leer a través del paciente y su apoderado, igual que en los reportes, para
+// que siga los cambios de localidad; si el paciente ya se eliminó se usa la registrada al eliminar. Con
+// localidad también incluye las mediciones que salieron de ella en la ventana (ver recordMeasurementRelocations)
+func (r *measurementRepository) GetDeletedSince(ctx context.Context, filter *domain.MeasurementSyncFilter) ([]uuid.UUID, error) {
+	query := r.db.WithContext(ctx).
+		Table("measurement_deletions d").
+		Where("d.deleted_at > ? AND d.deleted_at <= ?", filter.Since, filter.Until)
+
+	if filter.LocalityID != nil {
+		query = query.
+			Joins("LEFT JOIN patients p ON p.id = d.patient_id").
+			Joins("LEFT JOIN users u ON u.id = p.user_id").
+			Where("CASE WHEN p.id IS NULL THEN d.locality_id ELSE u.locality_id END = ?", *filter.LocalityID)
+	}
+
+	var ids []uuid.UUID
+	if err := query.Order("d.deleted_at ASC").Pluck("d.measurement_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("error al obtener mediciones eliminadas: %w", err)
+	}
+	if filter.LocalityID == nil {
+		return ids, nil
+	}
+
+	// Las que salieron de la localidad por un cambio de localidad y no volvieron a ella
+	var relocated []uuid.UUID
+	err := r.db.WithContext(ctx).
+		Table("measurement_relocations r").
+		Joins("LEFT JOIN measurements m ON m.id = r.measurement_id").
+		Joins("LEFT JOIN patients p ON p.id = m.patient_id").
+		Joins("LEFT JOIN users u ON u.id = p.user_id").
+		Where("r.from_locality_id = ? AND r.moved_at > ? AND r.moved_at <= ?", *filter.LocalityID, filter.Since, filter.Until).
+		Where("u.locality_id IS DISTINCT FROM ?", *filter.LocalityID).
+		Order("r.moved_at ASC").
+		Pluck("r.measurement_id", &relocated).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener mediciones trasladadas: %w", err)
+	}
+
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		seen[id] = true
+	}
+	for _, id := range relocated {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// GetUnclassified obtiene las mediciones sin tag o sin recomendación; con page nil devuelve todas
+func (r *measurementRepository) GetUnclassified(ctx context.Context, page *domain.Pagination) ([]*domain.Measurement, error) {
+	query := r.db.WithContext(ctx).
+		Model(&domain.Measurement{}).
+		Where("tag_id IS NULL OR recommendation_id IS NULL").
+		Session(&gorm.Session{})
+
+	if page != nil {
+		if err := query.Count(&page.Total).Error; err != nil {
+			return nil, fmt.Errorf("error al contar mediciones sin clasificar: %w", err)
+		}
+		query = query.Offset(page.Offset()).Limit(page.PageSize)
+	}
+
+	var measurements []*domain.Measurement
+	if err := query.Order("created_at ASC, id ASC").Find(&measurements).Error; err != nil {
+		return nil, fmt.Errorf("error al obtener mediciones sin clasificar: %w", err)
+	}
+	return measurements, nil
+}
+
+// GetStoredClassifications obtiene los códigos MUAC del tag y la recomendación de las mediciones
+// clasificadas (con al menos uno de los dos), opcionalmente de una localidad
+func (r *measurementRepository) GetStoredClassifications(ctx context.Context, localityID *uuid.UUID) ([]domain.MeasurementStoredClassification, error) {
+	query := r.db.WithContext(ctx).
+		Table("measurements m").
+		Select(`m.id as measurement_id, m.patient_id, m.muac_value, m.created_at,
+			CASE WHEN t.id IS NULL THEN NULL ELSE COALESCE(t.muac_code, '') END as tag_muac_code,
+			CASE WHEN rec.id IS NULL THEN NULL ELSE COALESCE(rec.muac_code, '') END as recommendation_muac_code`).
+		Joins("LEFT JOIN tags t ON m.tag_id = t.id").
+		Joins("LEFT JOIN recommendations rec ON m.recommendation_id = rec.id").
+		Where("m.tag_id IS NOT NULL OR m.recommendation_id IS NOT NULL")
+
+	if localityID != nil {
+		query = query.
+			Joins("JOIN patients p ON m.patient_id = p.id").
+			Joins("JOIN users u ON p.user_id = u.id").
+			Where("u.locality_id = ?", *localityID)
+	}
+
+	var rows []domain.MeasurementStoredClassification
+	if err := query.Order("m.created_at ASC, m.id ASC").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("error al obtener clasificación de mediciones: %w", err)
+	}
+	return rows, nil
+}
+
+// ApplyClassificationFixes completa tag y recomendación en una transacción, sin pisar valores ya asignados.
+// Los tags y recomendaciones nuevos de las correcciones se crean en la misma transacción
+func (r *measurementRepository) ApplyClassificationFixes(ctx context.Context, fixes []domain.MeasurementClassificationFix) (int64, error) {
+	var fixed int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		created := make(map[uuid.UUID]bool)
+		for _, fix := range fixes {
+			if fix.NewTag != nil && !created[fix.NewTag.ID] {
+				if err := tx.Create(fix.NewTag).Error; err != nil {
+					return fmt.Errorf("error al crear tag MUAC: %w", err)
+				}
+				created[fix.NewTag.ID] = true
+			}
+			if fix.NewRecommendation != nil && !created[fix.NewRecommendation.ID] {
+				if err := tx.Create(fix.NewRecommendation).Error; err != nil {
+					return fmt.Errorf("error al crear recomendación MUAC: %w", err)
+				}
+				created[fix.NewRecommendation.ID] = true
+			}
+
+			result := tx.Model(&domain.Measurement{}).
+				Where("id = ?", fix.MeasurementID).
+				Updates(map[string]interface{}{
+					"tag_id":            gorm.Expr("COALESCE(tag_id, ?)", fix.TagID),
+					"recommendation_id": gorm.Expr("COALESCE(recommendation_id, ?)", fix.RecommendationID),
+					"updated_at":        time.Now(),
+				})
+			if result.Error != nil {
+				return fmt.Errorf("error al clasificar medición %s: %w", fix.MeasurementID, result.Error)
+			}
+			fixed += result.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return fixed, nil
+}
+
+// Search obtiene, paginadas, las mediciones de los pacientes cuyo nombre completo contiene el texto
+// buscado (sin distinguir mayúsculas), de la más reciente a la más antigua
+func (r *measurementRepository) Search(ctx context.Context, filter *domain.MeasurementSearchFilter, page *domain.Pagination) ([]domain.MeasurementSearchResult, error) {
+	query := r.db.WithContext(ctx).
+		Table("measurements m").
+		Joins("JOIN patients p ON m.patient_id = p.id").
+		Joins("LEFT JOIN users u ON p.user_id = u.id").
+		Joins("LEFT JOIN localities l ON u.locality_id = l.id").
+		Where("CONCAT(p.name, ' ', p.lastname) ILIKE ?", filter.Pattern())
+
+	if filter.LocalityID != nil {
+		query = query.Where("u.locality_id = ?", *filter.LocalityID)
+	}
+	if filter.CaregiverID != nil {
+		query = query.Where("p.user_id = ?", *filter.CaregiverID)
+	}
+
+	// Sesión nueva para reutilizar la consulta en el conteo y en la página
+	query = query.Session(&gorm.Session{})
+
+	if err := query.Count(&page.Total).Error; err != nil {
+		return nil, fmt.Errorf("error al contar mediciones por nombre de paciente: %w", err)
+	}
+
+	var results []domain.MeasurementSearchResult
+	err := query.
+		Select(`
+			m.id as measurement_id,
+			m.muac_value,
+			p.id as patient_id,
+			CONCAT(p.name, ' ', p.lastname) as patient_name,
+			p.dni as patient_dni,
+			u.id as caregiver_id,
+			COALESCE(CONCAT(u.name, ' ', u.lastname), '') as caregiver_name,
+			u.locality_id,
+			COALESCE(l.name, '') as locality_name,
+			m.created_at as measured_at
+		`).
+		Order("m.created_at DESC, m.id").
+		Offset(page.Offset()).
+		Limit(page.PageSize).
+		Scan(&results).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar mediciones por nombre de paciente: %w", err)
+	}
+
+	for i := range results {
+		results[i].Classify()
+	}
+	return results, nil
+}
@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestChunkUUIDs(t *testing.T) {
+	ids := make([]uuid.UUID, 2500)
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+
+	tests := []struct {
+		name  string
+		ids   []uuid.UUID
+		sizes []int
+	}{
+		{"sin ids", nil, []int{}},
+		{"menos que un tramo", ids[:10], []int{10}},
+		{"tramo exacto", ids[:1000], []int{1000}},
+		{"varios tramos", ids, []int{1000, 1000, 500}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := chunkUUIDs(tt.ids, latestByPatientChunkSize)
+			if len(chunks) != len(tt.sizes) {
+				t.Fatalf("tramos = %d, se esperaban %d", len(chunks), len(tt.sizes))
+			}
+			seen := 0
+			for i, chunk := range chunks {
+				if len(chunk) != tt.sizes[i] {
+					t.Errorf("tramo %d: len = %d, se esperaba %d", i, len(chunk), tt.sizes[i])
+				}
+				for j, id := range chunk {
+					if id != tt.ids[seen+j] {
+						t.Fatalf("tramo %d: el orden de los IDs no se conserva", i)
+					}
+				}
+				seen += len(chunk)
+			}
+		})
+	}
+}
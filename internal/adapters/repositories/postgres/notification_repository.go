@@ -2,6 +2,8 @@ package postgres
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
@@ -48,6 +50,23 @@ func (r *notificationRepository) GetAll(ctx context.Context) ([]*domain.Notifica
 	return notifications, nil
 }
 
+// GetActive obtiene los comunicados visibles (sin destinatario) cuya ventana incluye now, los más
+// recientes primero
+func (r *notificationRepository) GetActive(ctx context.Context, now time.Time) ([]*domain.Notification, error) {
+	var notifications []*domain.Notification
+	err := r.db.WithContext(ctx).
+		Where("visible = ?", true).
+		Where("recipient_id IS NULL").
+		Where("starts_at IS NULL OR starts_at <= ?", now).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Order("created_at DESC").
+		Find(&notifications).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener avisos activos: %w", err)
+	}
+	return notifications, nil
+}
+
 // Update actualiza una notificación existente
 func (r *notificationRepository) Update(ctx context.Context, notification *domain.Notification) error {
 	result := r.db.WithContext(ctx).Save(notification)
@@ -60,14 +79,53 @@ func (r *notificationRepository) Update(ctx context.Context, notification *domai
 	return nil
 }
 
-// Delete elimina una notificación por su ID
+// Delete elimina una notificación por su ID junto con sus lecturas
 func (r *notificationRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	result := r.db.WithContext(ctx).Delete(&domain.Notification{}, id)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("notification_id = ?", id).Delete(&domain.NotificationRead{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&domain.Notification{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrNotificationNotFound
+		}
+		return nil
+	})
+}
+
+// MarkAllRead marca como leídas, en una sola consulta, las notificaciones visibles para el usuario
+// (comunicados o dirigidas a él) creadas hasta before que no había leído; devuelve cuántas marcó
+func (r *notificationRepository) MarkAllRead(ctx context.Context, userID uuid.UUID, before, readAt time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Exec(`
+		INSERT INTO notification_reads (notification_id, user_id, read_at)
+		SELECT n.id, ?, ?
+		FROM notifications n
+		WHERE n.visible = true AND n.created_at <= ?
+			AND (n.recipient_id IS NULL OR n.recipient_id = ?)
+		ON CONFLICT (notification_id, user_id) DO NOTHING`,
+		userID, readAt, before, userID)
 	if result.Error != nil {
-		return result.Error
+		return 0, fmt.Errorf("error al marcar notificaciones como leídas: %w", result.Error)
 	}
-	if result.RowsAffected == 0 {
-		return domain.ErrNotificationNotFound
+	return result.RowsAffected, nil
+}
+
+// GetUnreadSince obtiene las notificaciones visibles para el usuario (comunicados o dirigidas a él)
+// creadas después de since que aún no leyó
+func (r *notificationRepository) GetUnreadSince(ctx context.Context, userID uuid.UUID, since time.Time) ([]*domain.Notification, error) {
+	var notifications []*domain.Notification
+	err := r.db.WithContext(ctx).
+		Where("visible = ?", true).
+		Where("recipient_id IS NULL OR recipient_id = ?", userID).
+		Where("created_at > ?", since).
+		Where("NOT EXISTS (SELECT 1 FROM notification_reads nr WHERE nr.notification_id = notifications.id AND nr.user_id = ?)", userID).
+		Order("created_at DESC").
+		Find(&notifications).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener notificaciones sin leer: %w", err)
 	}
-	return nil
+	return notifications, nil
 }
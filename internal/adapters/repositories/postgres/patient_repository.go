@@ -72,6 +72,39 @@ func (r *patientRepository) GetByDNI(ctx context.Context, dni string) (*domain.P
 	return &patient, nil
 }
 
+// ExistsByDNI indica si hay un paciente con el DNI (consulta por el índice único de dni)
+func (r *patientRepository) ExistsByDNI(ctx context.Context, dni string) (bool, error) {
+	var exists bool
+	err := r.db.WithContext(ctx).
+		Raw("SELECT EXISTS(SELECT 1 FROM patients WHERE dni = ?)", dni).
+		Scan(&exists).Error
+	if err != nil {
+		return false, fmt.Errorf("error al verificar DNI: %w", err)
+	}
+	return exists, nil
+}
+
+// GetExistingDNIs devuelve cuáles de los DNIs ya están registrados, en una sola consulta
+func (r *patientRepository) GetExistingDNIs(ctx context.Context, dnis []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(dnis) == 0 {
+		return existing, nil
+	}
+
+	var found []string
+	err := r.db.WithContext(ctx).
+		Model(&domain.Patient{}).
+		Where("dni IN ?", dnis).
+		Pluck("dni", &found).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al verificar DNIs: %w", err)
+	}
+	for _, dni := range found {
+		existing[dni] = true
+	}
+	return existing, nil
+}
+
 // GetAll obtiene todos los pacientes
 func (r *patientRepository) GetAll(ctx context.Context) ([]*domain.Patient, error) {
 	var patients []*domain.Patient
@@ -82,11 +115,102 @@ func (r *patientRepository) GetAll(ctx context.Context) ([]*domain.Patient, erro
 	return patients, nil
 }
 
-// Update actualiza un paciente existente
+// GetAllWithRelations obtiene todos los pacientes precargando solo las relaciones solicitadas
+func (r *patientRepository) GetAllWithRelations(ctx context.Context, expand domain.PatientExpand) ([]*domain.Patient, error) {
+	var patients []*domain.Patient
+	query := r.db.WithContext(ctx)
+
+	if expand.Locality {
+		query = query.Preload("User.Locality")
+	} else if expand.User {
+		query = query.Preload("User")
+	}
+
+	if result := query.Find(&patients); result.Error != nil {
+		return nil, fmt.Errorf("error al obtener pacientes: %w", result.Error)
+	}
+	return patients, nil
+}
+
+// Update actualiza un paciente existente. Si se reasigna a un apoderado de otra localidad, sus
+// mediciones quedan registradas como trasladadas
 func (r *patientRepository) Update(ctx context.Context, patient *domain.Patient) error {
-	result := r.db.WithContext(ctx).Save(patient)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var toLocalityID *uuid.UUID
+		if patient.UserID != nil {
+			var caregiver domain.User
+			err := tx.Select("locality_id").Where("id = ?", *patient.UserID).First(&caregiver).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("error al obtener localidad del apoderado: %w", err)
+			}
+			toLocalityID = caregiver.LocalityID
+		}
+		if err := recordMeasurementRelocations(tx, toLocalityID, "p.id = ?", patient.ID); err != nil {
+			return err
+		}
+
+		result := tx.Save(patient)
+		if result.Error != nil {
+			return fmt.Errorf("error al actualizar paciente: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrPatientNotFound
+		}
+		return nil
+	})
+}
+
+// UpdateAge guarda solo la edad recalculada del paciente
+func (r *patientRepository) UpdateAge(ctx context.Context, patient *domain.Patient) error {
+	result := r.db.WithContext(ctx).Model(&domain.Patient{}).
+		Where("id = ?", patient.ID).
+		Updates(map[string]interface{}{
+			"age":        patient.Age,
+			"updated_at": patient.UpdatedAt,
+		})
 	if result.Error != nil {
-		return fmt.Errorf("error al actualizar paciente: %w", result.Error)
+		return fmt.Errorf("error al actualizar edad del paciente: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrPatientNotFound
+	}
+	return nil
+}
+
+// UpdateConsent guarda solo el consentimiento y sus campos de auditoría
+func (r *patientRepository) UpdateConsent(ctx context.Context, patient *domain.Patient) error {
+	result := r.db.WithContext(ctx).Model(&domain.Patient{}).
+		Where("id = ?", patient.ID).
+		Updates(map[string]interface{}{
+			"consent_given":      patient.ConsentGiven,
+			"consent_date":       patient.ConsentDate,
+			"consent_reason":     patient.ConsentReason,
+			"consent_updated_at": patient.ConsentUpdatedAt,
+			"consent_updated_by": patient.ConsentUpdatedBy,
+			"updated_at":         patient.UpdatedAt,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("error al actualizar consentimiento del paciente: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrPatientNotFound
+	}
+	return nil
+}
+
+// UpdateApproval guarda solo el estado de aprobación y su auditoría
+func (r *patientRepository) UpdateApproval(ctx context.Context, patient *domain.Patient) error {
+	result := r.db.WithContext(ctx).Model(&domain.Patient{}).
+		Where("id = ?", patient.ID).
+		Updates(map[string]interface{}{
+			"approval_status":     patient.ApprovalStatus,
+			"approval_note":       patient.ApprovalNote,
+			"approval_updated_at": patient.ApprovalUpdatedAt,
+			"approval_updated_by": patient.ApprovalUpdatedBy,
+			"updated_at":          patient.UpdatedAt,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("error al actualizar aprobación del paciente: %w", result.Error)
 	}
 	if result.RowsAffected == 0 {
 		return domain.ErrPatientNotFound
@@ -109,7 +233,10 @@ func (r *patientRepository) Update(ctx context.Context, patient *domain.Patient)
 // Delete elimina un paciente por su ID junto con todas sus mediciones
 func (r *patientRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// Primero eliminar todas las mediciones del paciente
+		// Primero eliminar todas las mediciones del paciente, dejando registro para la sincronización
+		if err := recordMeasurementDeletions(tx, "m.patient_id = ?", id); err != nil {
+			return err
+		}
 		result := tx.Where("patient_id = ?", id).Delete(&domain.Measurement{})
 		if result.Error != nil {
 			return fmt.Errorf("error al eliminar mediciones del paciente: %w", result.Error)
@@ -143,6 +270,49 @@ func (r *patientRepository) GetByFatherID(ctx context.Context, fatherID uuid.UUI
 	return patients, nil
 }
 
+// GetByLocalityID obtiene los pacientes de los apoderados de una localidad, hasta limit registros
+func (r *patientRepository) GetByLocalityID(ctx context.Context, localityID uuid.UUID, limit int) ([]*domain.Patient, error) {
+	var patients []*domain.Patient
+	result := r.db.WithContext(ctx).
+		Joins("JOIN users u ON patients.user_id = u.id").
+		Where("u.locality_id = ?", localityID).
+		Order("patients.lastname, patients.name").
+		Limit(limit).
+		Find(&patients)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("error al obtener pacientes por localidad: %w", result.Error)
+	}
+	return patients, nil
+}
+
+// CountByUserID cuenta los pacientes asignados a un usuario
+func (r *patientRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	result := r.db.WithContext(ctx).
+		Model(&domain.Patient{}).
+		Where("user_id = ?", userID).
+		Count(&count)
+	if result.Error != nil {
+		return 0, fmt.Errorf("error al contar pacientes del usuario: %w", result.Error)
+	}
+	return count, nil
+}
+
+// GetByUserID obtiene los pacientes asignados a un usuario
+func (r *patientRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Patient, error) {
+	var patients []*domain.Patient
+	result := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("lastname, name").
+		Find(&patients)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("error al obtener pacientes del usuario: %w", result.Error)
+	}
+	return patients, nil
+}
+
 // GetMeasurements obtiene todas las mediciones de un paciente específico
 func (r *patientRepository) GetMeasurements(ctx context.Context, patientID uuid.UUID) ([]*domain.Measurement, error) {
 	var measurements []*domain.Measurement
@@ -223,6 +393,67 @@ func (r *patientRepository) GetUsersWithRiskPatients(ctx context.Context, filter
 	return users, nil
 }
 
+// GetTriage obtiene pacientes ordenados por un puntaje compuesto de severidad
+// (valor MUAC de la última medición) y recencia (días desde esa medición)
+func (r *patientRepository) GetTriage(ctx context.Context, filters *domain.ReportFilters, page *domain.Pagination) ([]domain.TriagePatient, error) {
+	var patients []domain.TriagePatient
+
+	daysAgo := "EXTRACT(EPOCH FROM (NOW() - m.created_at)) / 86400"
+
+	query := r.db.WithContext(ctx).
+		Table("patients p").
+		Joins(`JOIN measurements m ON p.id = m.patient_id AND m.id = (
+			SELECT id FROM measurements m2 
+			WHERE m2.patient_id = p.id 
+			ORDER BY m2.created_at DESC 
+			LIMIT 1
+		)`).
+		Joins("LEFT JOIN users u ON p.user_id = u.id").
+		Joins("LEFT JOIN localities l ON u.locality_id = l.id")
+
+	if filters != nil {
+		if filters.LocalityID != nil {
+			query = query.Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.UserID != nil {
+			query = query.Where("p.user_id = ?", *filters.UserID)
+		}
+		if filters.Days > 0 {
+			since := time.Now().AddDate(0, 0, -filters.Days)
+			query = query.Where("m.created_at >= ?", since)
+		}
+	}
+
+	// Sesión nueva para reutilizar la consulta en el conteo y en la página
+	query = query.Session(&gorm.Session{})
+
+	if err := query.Count(&page.Total).Error; err != nil {
+		return nil, fmt.Errorf("error al contar pacientes para triaje: %w", err)
+	}
+
+	err := query.
+		Select(`
+			p.id as patient_id,
+			CONCAT(p.name, ' ', p.lastname) as patient_name,
+			p.age,
+			m.muac_value,
+			COALESCE(l.name, 'Sin localidad') as locality_name,
+			CONCAT(u.name, ' ', u.lastname) as user_name,
+			m.created_at as last_measure,
+			FLOOR(`+daysAgo+`) as days_ago,
+			m.muac_value + (`+daysAgo+`) * ? as score
+		`, domain.TriageRecencyWeight).
+		Order("score ASC, m.muac_value ASC").
+		Offset(page.Offset()).
+		Limit(page.PageSize).
+		Scan(&patients).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener triaje de pacientes: %w", err)
+	}
+
+	return patients, nil
+}
+
 // GetPatientsInRisk obtiene todos los pacientes en riesgo con todos sus datos - CORREGIDO
 // func (r *patientRepository) GetPatientsInRisk(ctx context.Context, filters *domain.ReportFilters) ([]*domain.Patient, error) {
 // 	var patients []*domain.Patient
@@ -297,3 +528,155 @@ func (r *patientRepository) GetUsersWithRiskPatients(ctx context.Context, filter
 
 // 	return patients, nil
 // }
+
+// GetOrphaned obtiene, paginados, los pacientes sin apoderado o cuyo apoderado no existe o está
+// desactivado. Con localityID solo se incluyen los de apoderados desactivados de esa localidad
+func (r *patientRepository) GetOrphaned(ctx context.Context, localityID *uuid.UUID, page *domain.Pagination) ([]domain.OrphanedPatient, error) {
+	query := r.db.WithContext(ctx).
+		Table("patients p").
+		Joins("LEFT JOIN users u ON p.user_id = u.id").
+		Joins("LEFT JOIN localities l ON u.locality_id = l.id").
+		Where("(u.id IS NULL OR u.active = ?)", false)
+
+	if localityID != nil {
+		query = query.Where("u.locality_id = ?", *localityID)
+	}
+
+	// Sesión nueva para reutilizar la consulta en el conteo y en la página
+	query = query.Session(&gorm.Session{})
+
+	if err := query.Count(&page.Total).Error; err != nil {
+		return nil, fmt.Errorf("error al contar pacientes sin apoderado: %w", err)
+	}
+
+	var patients []domain.OrphanedPatient
+	err := query.
+		Select(`p.id as patient_id, p.name, p.lastname, p.dni, p.user_id,
+			COALESCE(u.name || ' ' || u.lastname, '') as caregiver_name,
+			u.locality_id, COALESCE(l.name, '') as locality_name,
+			CASE WHEN p.user_id IS NULL THEN ? WHEN u.id IS NULL THEN ? ELSE ? END as reason,
+			(SELECT MAX(m.created_at) FROM measurements m WHERE m.patient_id = p.id) as last_measured_at,
+			p.created_at`,
+			domain.OrphanReasonNoCaregiver, domain.OrphanReasonCaregiverDeleted, domain.OrphanReasonCaregiverInactive).
+		Order("p.created_at, p.id").
+		Offset(page.Offset()).
+		Limit(page.PageSize).
+		Scan(&patients).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener pacientes sin apoderado: %w", err)
+	}
+	return patients, nil
+}
+
+// normalizedDNIExpr compara DNIs ignorando espacios, puntos, guiones y mayúsculas
+const normalizedDNIExpr = "UPPER(REGEXP_REPLACE(dni, '[^0-9A-Za-z]', '', 'g'))"
+
+// GetDuplicateDNIs obtiene los grupos de pacientes cuyo DNI normalizado se repite.
+// Si se adopta borrado lógico, ambas consultas deben excluir los registros eliminados
+func (r *patientRepository) GetDuplicateDNIs(ctx context.Context) ([]domain.DuplicateDNIGroup, error) {
+	var keys []struct {
+		DNI   string
+		Count int
+	}
+	err := r.db.WithContext(ctx).
+		Model(&domain.Patient{}).
+		Select(normalizedDNIExpr + " as dni, COUNT(*) as count").
+		Where("dni IS NOT NULL AND " + normalizedDNIExpr + " <> ''").
+		Group(normalizedDNIExpr).
+		Having("COUNT(*) > 1").
+		Order("count DESC, dni").
+		Scan(&keys).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar DNIs duplicados: %w", err)
+	}
+	if len(keys) == 0 {
+		return []domain.DuplicateDNIGroup{}, nil
+	}
+
+	dnis := make([]string, len(keys))
+	for i, key := range keys {
+		dnis[i] = key.DNI
+	}
+
+	var rows []struct {
+		domain.DuplicateDNIPatient
+		NormalizedDNI string
+	}
+	err = r.db.WithContext(ctx).
+		Model(&domain.Patient{}).
+		Select("id as patient_id, name, lastname, dni, user_id, created_at, "+normalizedDNIExpr+" as normalized_dni").
+		Where(normalizedDNIExpr+" IN ?", dnis).
+		Order("created_at").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener pacientes con DNI duplicado: %w", err)
+	}
+
+	groups := make([]domain.DuplicateDNIGroup, len(keys))
+	index := make(map[string]int, len(keys))
+	for i, key := range keys {
+		groups[i] = domain.DuplicateDNIGroup{DNI: key.DNI, Count: key.Count, Patients: []domain.DuplicateDNIPatient{}}
+		index[key.DNI] = i
+	}
+	for _, row := range rows {
+		if i, ok := index[row.NormalizedDNI]; ok {
+			groups[i].Patients = append(groups[i].Patients, row.DuplicateDNIPatient)
+		}
+	}
+	return groups, nil
+}
+
+// StreamExport recorre los pacientes con su apoderado, localidad, cantidad de mediciones y última
+// medición en una sola consulta, llamando a fn por cada fila sin cargar el resultado completo en memoria
+func (r *patientRepository) StreamExport(ctx context.Context, filters *domain.ReportFilters, fn func(domain.PatientExportRow) error) error {
+	query := r.db.WithContext(ctx).
+		Table("patients p").
+		Select(`
+			p.id as patient_id, p.name, p.lastname, p.dni, p.gender, p.age, p.birth_date,
+			p.consent_given, p.created_at,
+			u.id as caregiver_id,
+			COALESCE(u.name || ' ' || u.lastname, '') as caregiver_name,
+			COALESCE(l.name, '') as locality_name,
+			COALESCE(mc.total, 0) as measurement_count,
+			lm.muac_value as latest_muac,
+			lm.created_at as latest_measured_at
+		`).
+		Joins("LEFT JOIN users u ON p.user_id = u.id").
+		Joins("LEFT JOIN localities l ON u.locality_id = l.id").
+		Joins("LEFT JOIN (SELECT patient_id, COUNT(*) as total FROM measurements GROUP BY patient_id) mc ON mc.patient_id = p.id").
+		Joins(`LEFT JOIN LATERAL (
+			SELECT muac_value, created_at FROM measurements
+			WHERE patient_id = p.id
+			ORDER BY created_at DESC
+			LIMIT 1
+		) lm ON true`)
+
+	if filters != nil {
+		if filters.LocalityID != nil {
+			query = query.Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.UserID != nil {
+			query = query.Where("p.user_id = ?", *filters.UserID)
+		}
+	}
+
+	rows, err := query.Order("p.created_at, p.id").Rows()
+	if err != nil {
+		return fmt.Errorf("error al exportar pacientes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row domain.PatientExportRow
+		if err := r.db.ScanRows(rows, &row); err != nil {
+			return fmt.Errorf("error al leer paciente exportado: %w", err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error al exportar pacientes: %w", err)
+	}
+	return nil
+}
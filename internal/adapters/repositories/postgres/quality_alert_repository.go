@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// qualityAlertRepository implementa la interfaz IQualityAlertRepository usando GORM
+type qualityAlertRepository struct {
+	db *gorm.DB
+}
+
+// NewQualityAlertRepository crea una nueva instancia de QualityAlertRepository
+func NewQualityAlertRepository(db *gorm.DB) ports.IQualityAlertRepository {
+	return &qualityAlertRepository{
+		db: db,
+	}
+}
+
+// GetAcknowledgedIDs obtiene los IDs de las alertas reconocidas
+func (r *qualityAlertRepository) GetAcknowledgedIDs(ctx context.Context) (map[string]bool, error) {
+	var ids []string
+	if err := r.db.WithContext(ctx).Model(&domain.QualityAlertAck{}).Pluck("alert_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("error al obtener alertas reconocidas: %w", err)
+	}
+
+	acknowledged := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		acknowledged[id] = true
+	}
+	return acknowledged, nil
+}
+
+// Acknowledge guarda el reconocimiento; si la alerta ya estaba reconocida actualiza la nota y el usuario
+func (r *qualityAlertRepository) Acknowledge(ctx context.Context, ack *domain.QualityAlertAck) error {
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "alert_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"note", "acknowledged_by"}),
+		}).
+		Create(ack).Error
+	if err != nil {
+		return fmt.Errorf("error al reconocer alerta de calidad: %w", err)
+	}
+	return nil
+}
@@ -100,4 +100,82 @@ func (r *recommendationRepository) Delete(ctx context.Context, id uuid.UUID) err
 		return domain.ErrRecommendationNotFound
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// PropagateToMeasurements asigna la recomendación a las mediciones cuyo valor MUAC cae en su rango.
+// En modo dryRun solo cuenta las mediciones afectadas sin modificarlas.
+func (r *recommendationRepository) PropagateToMeasurements(ctx context.Context, recommendation *domain.Recommendation, dryRun bool) (int64, error) {
+	var affected int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&domain.Measurement{}).
+			Where("(recommendation_id IS NULL OR recommendation_id <> ?)", recommendation.ID)
+		if recommendation.MinValue != nil {
+			query = query.Where("muac_value >= ?", *recommendation.MinValue)
+		}
+		if recommendation.MaxValue != nil {
+			query = query.Where("muac_value < ?", *recommendation.MaxValue)
+		}
+
+		if dryRun {
+			return query.Count(&affected).Error
+		}
+
+		result := query.Update("recommendation_id", recommendation.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		affected = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error al propagar recomendación a mediciones: %w", err)
+	}
+	return affected, nil
+}
+
+// GetReferencingMeasurements obtiene las mediciones que referencian la recomendación, de la más reciente a la más antigua
+func (r *recommendationRepository) GetReferencingMeasurements(ctx context.Context, id uuid.UUID) ([]domain.RecommendationMeasurementRef, error) {
+	var refs []domain.RecommendationMeasurementRef
+	err := r.db.WithContext(ctx).
+		Model(&domain.Measurement{}).
+		Select("id AS measurement_id, patient_id, muac_value, created_at").
+		Where("recommendation_id = ?", id).
+		Order("created_at DESC").
+		Scan(&refs).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener mediciones de la recomendación: %w", err)
+	}
+	return refs, nil
+}
+
+// GetUnmapped obtiene las recomendaciones activas sin código MUAC
+func (r *recommendationRepository) GetUnmapped(ctx context.Context) ([]*domain.Recommendation, error) {
+	var recommendations []*domain.Recommendation
+	result := r.db.WithContext(ctx).
+		Where("active = ? AND (muac_code IS NULL OR muac_code = '')", true).
+		Order("name").
+		Find(&recommendations)
+	if result.Error != nil {
+		return nil, fmt.Errorf("error al obtener recomendaciones sin código MUAC: %w", result.Error)
+	}
+	return recommendations, nil
+}
+
+// UpdateMuacMapping asigna código MUAC, color y prioridad a la recomendación
+func (r *recommendationRepository) UpdateMuacMapping(ctx context.Context, id uuid.UUID, mapping domain.MuacMapping) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.Recommendation{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"muac_code":  mapping.MuacCode,
+			"color_code": mapping.Color,
+			"priority":   mapping.Priority,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("error al asignar código MUAC a la recomendación: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrRecommendationNotFound
+	}
+	return nil
+}
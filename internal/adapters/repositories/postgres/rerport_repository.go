@@ -3,8 +3,11 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,17 +18,354 @@ import (
 
 // reportRepository implementa la interfaz IReportRepository usando GORM
 type reportRepository struct {
-	db *gorm.DB
+	db     *gorm.DB // primaria: cualquier escritura futura debe ir aquí
+	readDB *gorm.DB // réplica de solo lectura para las agregaciones
 }
 
-// NewReportRepository crea una nueva instancia de ReportRepository
-func NewReportRepository(db *gorm.DB) ports.IReportRepository {
+// NewReportRepository crea una nueva instancia de ReportRepository.
+// Si readDB es nil las consultas se ejecutan sobre la conexión primaria.
+func NewReportRepository(db, readDB *gorm.DB) ports.IReportRepository {
+	if readDB == nil {
+		readDB = db
+	}
 	return &reportRepository{
-		db: db,
+		db:     db,
+		readDB: readDB,
+	}
+}
+
+// GetRecoveryRate calcula, a partir del historial de mediciones de la ventana, cuántos niños
+// estuvieron en riesgo (rojo o amarillo) y cuántos de ellos tienen su última medición en verde
+func (r *reportRepository) GetRecoveryRate(ctx context.Context, filters *domain.ReportFilters) (*domain.RecoveryRateReport, error) {
+	scoped := r.readDB.WithContext(ctx).
+		Select("m.patient_id, m.muac_value, m.created_at").
+		Table("measurements m").
+		Joins("JOIN patients p ON m.patient_id = p.id").
+		Joins("LEFT JOIN users u ON p.user_id = u.id")
+
+	if filters != nil {
+		if filters.LocalityID != nil {
+			scoped = scoped.Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.ApprovedOnly {
+			scoped = scoped.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
+		if filters.UserID != nil {
+			scoped = scoped.Where("p.user_id = ?", *filters.UserID)
+		}
+		if filters.Days > 0 {
+			since := time.Now().AddDate(0, 0, -filters.Days)
+			scoped = scoped.Where("m.created_at >= ?", since)
+		}
+	}
+
+	// Por paciente: valor mínimo y valor de la última medición dentro de la ventana
+	history := r.readDB.
+		Select(`
+			sm.patient_id,
+			MIN(sm.muac_value) as min_value,
+			(ARRAY_AGG(sm.muac_value ORDER BY sm.created_at DESC))[1] as latest_value
+		`).
+		Table("(?) sm", scoped).
+		Group("sm.patient_id")
+
+	var counts struct {
+		AtRisk    int64
+		Recovered int64
+	}
+
+	err := r.readDB.WithContext(ctx).
+		Select(`
+			COUNT(CASE WHEN h.min_value < ? THEN 1 END) as at_risk,
+			COUNT(CASE WHEN h.min_value < ? AND h.latest_value >= ? THEN 1 END) as recovered
+		`, domain.MuacThresholdNormal, domain.MuacThresholdNormal, domain.MuacThresholdNormal).
+		Table("(?) h", history).
+		Scan(&counts).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al calcular tasa de recuperación: %w", err)
+	}
+
+	return &domain.RecoveryRateReport{
+		AtRisk:       counts.AtRisk,
+		Recovered:    counts.Recovered,
+		StillAtRisk:  counts.AtRisk - counts.Recovered,
+		RecoveryRate: r.calculatePercentage(int(counts.Recovered), float64(counts.AtRisk)),
+	}, nil
+}
+
+// GetPrevalenceCounts cuenta los niños con medición en la ventana y, según su última medición,
+// cuántos están en estado severo y moderado
+func (r *reportRepository) GetPrevalenceCounts(ctx context.Context, filters *domain.ReportFilters) (*domain.PrevalenceReport, error) {
+	latest := r.readDB.
+		Select("DISTINCT ON (m.patient_id) m.patient_id, m.muac_value").
+		Table("measurements m").
+		Joins("JOIN patients p ON m.patient_id = p.id").
+		Joins("LEFT JOIN users u ON p.user_id = u.id").
+		Order("m.patient_id, m.created_at DESC")
+
+	if filters != nil {
+		if filters.LocalityID != nil {
+			latest = latest.Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.ApprovedOnly {
+			latest = latest.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
+		if filters.UserID != nil {
+			latest = latest.Where("p.user_id = ?", *filters.UserID)
+		}
+		if filters.Days > 0 {
+			since := time.Now().AddDate(0, 0, -filters.Days)
+			latest = latest.Where("m.created_at >= ?", since)
+		}
+	}
+
+	var counts struct {
+		Total    int64
+		Severe   int64
+		Moderate int64
+	}
+
+	err := r.readDB.WithContext(ctx).
+		Select(`
+			COUNT(*) as total,
+			COUNT(CASE WHEN lm.muac_value < ? THEN 1 END) as severe,
+			COUNT(CASE WHEN lm.muac_value >= ? AND lm.muac_value < ? THEN 1 END) as moderate
+		`, domain.MuacThresholdSevere, domain.MuacThresholdSevere, domain.MuacThresholdNormal).
+		Table("(?) lm", latest).
+		Scan(&counts).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al calcular prevalencia: %w", err)
+	}
+
+	return &domain.PrevalenceReport{
+		SampleSize: counts.Total,
+		Severe:     domain.PrevalenceEstimate{Cases: counts.Severe},
+		Moderate:   domain.PrevalenceEstimate{Cases: counts.Moderate},
+	}, nil
+}
+
+// GetSeasonalCounts cuenta por año y mes (en ProgramTimeZone) desde since los niños medidos y,
+// según su última medición de cada mes, cuántos estaban en estado severo o moderado
+func (r *reportRepository) GetSeasonalCounts(ctx context.Context, filters *domain.ReportFilters, since time.Time) ([]domain.SeasonalMonthCount, error) {
+	snapshots := r.readDB.
+		Select(`DISTINCT ON (m.patient_id, year, month)
+			EXTRACT(YEAR FROM m.created_at AT TIME ZONE ?)::int as year,
+			EXTRACT(MONTH FROM m.created_at AT TIME ZONE ?)::int as month,
+			m.muac_value`, domain.ProgramTimeZone, domain.ProgramTimeZone).
+		Table("measurements m").
+		Joins("JOIN patients p ON m.patient_id = p.id").
+		Joins("LEFT JOIN users u ON p.user_id = u.id").
+		Where("m.created_at >= ?", since).
+		Order("m.patient_id, year, month, m.created_at DESC")
+
+	if filters != nil {
+		if filters.LocalityID != nil {
+			snapshots = snapshots.Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.ApprovedOnly {
+			snapshots = snapshots.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
+		if filters.UserID != nil {
+			snapshots = snapshots.Where("p.user_id = ?", *filters.UserID)
+		}
+	}
+
+	var counts []domain.SeasonalMonthCount
+	err := r.readDB.WithContext(ctx).
+		Select(`s.year, s.month, COUNT(*) as patients,
+			COUNT(CASE WHEN s.muac_value < ? THEN 1 END) as severe,
+			COUNT(CASE WHEN s.muac_value >= ? AND s.muac_value < ? THEN 1 END) as moderate
+		`, domain.MuacThresholdSevere, domain.MuacThresholdSevere, domain.MuacThresholdNormal).
+		Table("(?) s", snapshots).
+		Group("s.year, s.month").
+		Order("s.year, s.month").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al calcular prevalencia por mes: %w", err)
+	}
+	return counts, nil
+}
+
+// GetPatientBirthDates obtiene la fecha de nacimiento (texto tal como se registró) de los pacientes,
+// filtrando por la localidad o el apoderado asignado
+func (r *reportRepository) GetPatientBirthDates(ctx context.Context, filters *domain.ReportFilters) ([]string, error) {
+	query := r.readDB.WithContext(ctx).
+		Table("patients p").
+		Joins("LEFT JOIN users u ON p.user_id = u.id")
+
+	if filters != nil {
+		if filters.LocalityID != nil {
+			query = query.Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.ApprovedOnly {
+			query = query.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
+		if filters.UserID != nil {
+			query = query.Where("p.user_id = ?", *filters.UserID)
+		}
+	}
+
+	var birthDates []string
+	if err := query.Pluck("COALESCE(p.birth_date, '')", &birthDates).Error; err != nil {
+		return nil, fmt.Errorf("error al obtener fechas de nacimiento: %w", err)
+	}
+	return birthDates, nil
+}
+
+// GetAgingOutCandidates obtiene los pacientes con su fecha de nacimiento y su última medición, para
+// calcular en el dominio cuándo cumplen la edad de egreso
+func (r *reportRepository) GetAgingOutCandidates(ctx context.Context, filters *domain.ReportFilters) ([]domain.AgingOutCandidate, error) {
+	query := r.readDB.WithContext(ctx).
+		Select(`
+			p.id as patient_id,
+			CONCAT(p.name, ' ', p.lastname) as patient_name,
+			COALESCE(p.birth_date, '') as birth_date,
+			p.user_id,
+			COALESCE(CONCAT(u.name, ' ', u.lastname), '') as user_name,
+			u.locality_id,
+			COALESCE(l.name, 'Sin localidad') as locality_name,
+			latest_m.muac_value as last_muac_value,
+			latest_m.created_at as last_measured_at
+		`).
+		Table("patients p").
+		Joins("LEFT JOIN users u ON p.user_id = u.id").
+		Joins("LEFT JOIN localities l ON u.locality_id = l.id").
+		Joins(`LEFT JOIN LATERAL (
+				SELECT muac_value, created_at
+				FROM measurements m
+				WHERE m.patient_id = p.id
+				ORDER BY m.created_at DESC
+				LIMIT 1
+			) latest_m ON true`)
+
+	if filters != nil {
+		if filters.LocalityID != nil {
+			query = query.Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.ApprovedOnly {
+			query = query.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
+		if filters.UserID != nil {
+			query = query.Where("p.user_id = ?", *filters.UserID)
+		}
+	}
+
+	var candidates []domain.AgingOutCandidate
+	if err := query.Scan(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("error al obtener pacientes por egresar: %w", err)
+	}
+	return candidates, nil
+}
+
+// GetRegistrationsTimeline cuenta pacientes nuevos por periodo (date_trunc sobre created_at),
+// incluyendo los periodos sin registros
+func (r *reportRepository) GetRegistrationsTimeline(ctx context.Context, filters *domain.ReportFilters, interval string) (*domain.RegistrationsTimelineReport, error) {
+	patients := r.readDB.WithContext(ctx).
+		Select("p.id, p.created_at").
+		Table("patients p").
+		Joins("LEFT JOIN users u ON p.user_id = u.id")
+
+	if filters != nil && filters.LocalityID != nil {
+		patients = patients.Where("u.locality_id = ?", *filters.LocalityID)
+	}
+	if filters != nil && filters.ApprovedOnly {
+		patients = patients.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+	}
+
+	// Sin ventana de días se parte del primer registro
+	var since time.Time
+	if filters != nil && filters.Days > 0 {
+		since = time.Now().AddDate(0, 0, -filters.Days)
+	} else {
+		var first *time.Time
+		if err := r.readDB.WithContext(ctx).Table("patients").Select("MIN(created_at)").Scan(&first).Error; err != nil {
+			return nil, fmt.Errorf("error al obtener el primer registro de pacientes: %w", err)
+		}
+		since = time.Now()
+		if first != nil {
+			since = *first
+		}
+	}
+	patients = patients.Where("p.created_at >= ?", since)
+
+	var buckets []domain.TimelineBucket
+	err := r.readDB.WithContext(ctx).
+		Select("b.period, COUNT(fp.id) as total").
+		Table("generate_series(date_trunc(?, ?::timestamp), date_trunc(?, NOW()), ('1 ' || ?)::interval) AS b(period)",
+			interval, since, interval, interval).
+		Joins("LEFT JOIN (?) fp ON date_trunc(?, fp.created_at) = b.period", patients, interval).
+		Group("b.period").
+		Order("b.period").
+		Scan(&buckets).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener línea de tiempo de registros: %w", err)
+	}
+
+	report := &domain.RegistrationsTimelineReport{
+		Interval: interval,
+		Buckets:  buckets,
+	}
+	for _, b := range buckets {
+		report.Total += b.Total
+	}
+	return report, nil
+}
+
+// GetLocalityCaregiverActivity obtiene el número de mediciones de cada apoderado de la localidad
+// del usuario (el propio usuario siempre se incluye aunque tenga otro rol)
+func (r *reportRepository) GetLocalityCaregiverActivity(ctx context.Context, userID uuid.UUID, filters *domain.ReportFilters) (string, []domain.CaregiverActivity, error) {
+	var user struct {
+		LocalityID   *uuid.UUID
+		LocalityName *string
+	}
+
+	result := r.readDB.WithContext(ctx).
+		Select("u.locality_id, l.name as locality_name").
+		Table("users u").
+		Joins("LEFT JOIN localities l ON u.locality_id = l.id").
+		Where("u.id = ?", userID).
+		Limit(1).
+		Scan(&user)
+	if result.Error != nil {
+		return "", nil, fmt.Errorf("error al obtener localidad del usuario: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return "", nil, domain.ErrUserNotFound
+	}
+	if user.LocalityID == nil {
+		return "", nil, domain.ErrUserNoLocality
+	}
+
+	measurementsJoin := "LEFT JOIN measurements m ON m.user_id = u.id"
+	var joinArgs []interface{}
+	if filters != nil && filters.Days > 0 {
+		measurementsJoin += " AND m.created_at >= ?"
+		joinArgs = append(joinArgs, time.Now().AddDate(0, 0, -filters.Days))
+	}
+
+	var activity []domain.CaregiverActivity
+	err := r.readDB.WithContext(ctx).
+		Select("u.id as user_id, u.name, u.lastname, COUNT(m.id) as measurements").
+		Table("users u").
+		Joins("JOIN roles ro ON u.role_id = ro.id").
+		Joins(measurementsJoin, joinArgs...).
+		Where("u.locality_id = ?", *user.LocalityID).
+		Where("ro.name = ? OR u.id = ?", "APODERADO", userID).
+		Group("u.id, u.name, u.lastname").
+		Order("measurements DESC, u.name, u.lastname").
+		Scan(&activity).Error
+	if err != nil {
+		return "", nil, fmt.Errorf("error al obtener actividad de apoderados: %w", err)
+	}
+
+	localityName := ""
+	if user.LocalityName != nil {
+		localityName = *user.LocalityName
 	}
+	return localityName, activity, nil
 }
 
-// // GetDashboardData obtiene los datos principales del dashboard
+// GetDashboardData obtiene los datos principales del dashboard
 // func (r *reportRepository) GetDashboardData(ctx context.Context, filters *domain.ReportFilters) (*domain.DashboardReport, error) {
 // 	report := &domain.DashboardReport{}
 
@@ -85,18 +425,40 @@ func NewReportRepository(db *gorm.DB) ports.IReportRepository {
 // 	return report, nil
 // }
 
+// localityRow son los conteos de pacientes por estado de una localidad
+type localityRow struct {
+	LocalityID   uuid.UUID
+	LocalityName string
+	Total        int64
+	Normal       int64
+	Moderate     int64
+	Severe       int64
+}
+
+// appendUnassignedLocality agrega al final el grupo "Sin localidad" con los conteos de los usuarios
+// sin localidad asignada; si no tiene pacientes no se agrega
+func appendUnassignedLocality(localities []localityRow, unassigned localityRow) []localityRow {
+	if unassigned.Total == 0 {
+		return localities
+	}
+	unassigned.LocalityID = uuid.Nil
+	unassigned.LocalityName = domain.UnassignedLocalityName
+	return append(localities, unassigned)
+}
+
 // GetPatientsByLocality obtiene pacientes agrupados por localidad
 func (r *reportRepository) GetPatientsByLocality(ctx context.Context, filters *domain.ReportFilters) (*domain.PatientsByLocalityReport, error) {
-	var localities []struct {
-		LocalityID   uuid.UUID
-		LocalityName string
-		Total        int64
-		Normal       int64
-		Moderate     int64
-		Severe       int64
+	var localities []localityRow
+
+	// Con approved_only el filtro va en el JOIN para no perder las localidades sin pacientes aprobados
+	patientJoin := "LEFT JOIN patients p ON u.id = p.user_id"
+	var patientJoinArgs []interface{}
+	if filters != nil && filters.ApprovedOnly {
+		patientJoin += " AND p.approval_status = ?"
+		patientJoinArgs = append(patientJoinArgs, domain.PatientApprovalApproved)
 	}
 
-	query := r.db.WithContext(ctx).
+	query := r.readDB.WithContext(ctx).
 		Select(`
 			l.id as locality_id,
 			l.name as locality_name,
@@ -107,7 +469,7 @@ func (r *reportRepository) GetPatientsByLocality(ctx context.Context, filters *d
 		`).
 		Table("localities l").
 		Joins("LEFT JOIN users u ON l.id = u.locality_id").
-		Joins("LEFT JOIN patients p ON u.id = p.user_id").
+		Joins(patientJoin, patientJoinArgs...).
 		Joins(`LEFT JOIN measurements m ON p.id = m.patient_id AND m.id = (
 			SELECT id FROM measurements m2 
 			WHERE m2.patient_id = p.id 
@@ -131,6 +493,42 @@ func (r *reportRepository) GetPatientsByLocality(ctx context.Context, filters *d
 		return nil, fmt.Errorf("error al obtener datos por localidad: %w", err)
 	}
 
+	// Los usuarios sin localidad se agrupan en "Sin localidad" para no perder sus pacientes
+	if filters == nil || filters.LocalityID == nil {
+		var unassigned localityRow
+
+		unassignedQuery := r.readDB.WithContext(ctx).
+			Select(`
+				COUNT(DISTINCT p.id) as total,
+				COUNT(CASE WHEN m.muac_value >= 12.5 THEN 1 END) as normal,
+				COUNT(CASE WHEN m.muac_value >= 11.5 AND m.muac_value < 12.5 THEN 1 END) as moderate,
+				COUNT(CASE WHEN m.muac_value < 11.5 THEN 1 END) as severe
+			`).
+			Table("users u").
+			Joins("JOIN patients p ON u.id = p.user_id").
+			Joins(`LEFT JOIN measurements m ON p.id = m.patient_id AND m.id = (
+				SELECT id FROM measurements m2 
+				WHERE m2.patient_id = p.id 
+				ORDER BY m2.created_at DESC 
+				LIMIT 1
+			)`).
+			Where("u.locality_id IS NULL")
+
+		if filters != nil && filters.Days > 0 {
+			since := time.Now().AddDate(0, 0, -filters.Days)
+			unassignedQuery = unassignedQuery.Where("m.created_at >= ?", since)
+		}
+		if filters != nil && filters.ApprovedOnly {
+			unassignedQuery = unassignedQuery.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
+
+		if err := unassignedQuery.Scan(&unassigned).Error; err != nil {
+			return nil, fmt.Errorf("error al obtener datos de usuarios sin localidad: %w", err)
+		}
+
+		localities = appendUnassignedLocality(localities, unassigned)
+	}
+
 	// Convertir a estructura de respuesta
 	report := &domain.PatientsByLocalityReport{
 		LocalityData: make([]domain.LocalityData, len(localities)),
@@ -169,7 +567,7 @@ func (r *reportRepository) GetPatientsByLocality(ctx context.Context, filters *d
 func (r *reportRepository) GetRecentMeasurements(ctx context.Context, filters *domain.ReportFilters) (*domain.RecentMeasurementsReport, error) {
 	var measurements []domain.RecentMeasurement
 
-	query := r.db.WithContext(ctx).
+	query := r.readDB.WithContext(ctx).
 		Select(`
 			m.id,
 			CONCAT(p.name, ' ', p.lastname) as patient_name,
@@ -186,7 +584,7 @@ func (r *reportRepository) GetRecentMeasurements(ctx context.Context, filters *d
 				ELSE '#dc3545'
 			END as color_code,
 			CONCAT(u.name, ' ', u.lastname) as user_name,
-			l.name as locality_name,
+			COALESCE(l.name, 'Sin localidad') as locality_name,
 			m.created_at
 		`).
 		Table("measurements m").
@@ -200,6 +598,9 @@ func (r *reportRepository) GetRecentMeasurements(ctx context.Context, filters *d
 		if filters.LocalityID != nil {
 			query = query.Where("u.locality_id = ?", *filters.LocalityID)
 		}
+		if filters.ApprovedOnly {
+			query = query.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
 		if filters.UserID != nil {
 			query = query.Where("m.user_id = ?", *filters.UserID)
 		}
@@ -239,7 +640,7 @@ func (r *reportRepository) GetRiskPatients(ctx context.Context, filters *domain.
 		LastMeasure  time.Time
 	}
 
-	query := r.db.WithContext(ctx).
+	query := r.readDB.WithContext(ctx).
 		Select(`
 			p.id as patient_id,
 			CONCAT(p.name, ' ', p.lastname) as patient_name,
@@ -250,7 +651,7 @@ func (r *reportRepository) GetRiskPatients(ctx context.Context, filters *domain.
 				WHEN m.muac_value >= 11.5 AND m.muac_value < 12.5 THEN 'MUAC-Y1'
 				WHEN m.muac_value < 11.5 THEN 'MUAC-R1'
 			END as muac_code,
-			l.name as locality_name,
+			COALESCE(l.name, 'Sin localidad') as locality_name,
 			CONCAT(u.name, ' ', u.lastname) as user_name,
 			m.created_at as last_measure
 		`).
@@ -271,6 +672,9 @@ func (r *reportRepository) GetRiskPatients(ctx context.Context, filters *domain.
 		if filters.LocalityID != nil {
 			query = query.Where("u.locality_id = ?", *filters.LocalityID)
 		}
+		if filters.ApprovedOnly {
+			query = query.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
 		if filters.UserID != nil {
 			query = query.Where("p.user_id = ?", *filters.UserID)
 		}
@@ -330,7 +734,7 @@ func (r *reportRepository) GetRiskPatientsCoordinates(ctx context.Context, filte
 		Longitude string `json:"longitude"`
 	}
 
-	query := r.db.WithContext(ctx).
+	query := r.readDB.WithContext(ctx).
 		Select(`
 			l.latitude,
 			l.longitude
@@ -355,6 +759,9 @@ func (r *reportRepository) GetRiskPatientsCoordinates(ctx context.Context, filte
 		if filters.LocalityID != nil {
 			query = query.Where("u.locality_id = ?", *filters.LocalityID)
 		}
+		if filters.ApprovedOnly {
+			query = query.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
 		if filters.UserID != nil {
 			query = query.Where("p.user_id = ?", *filters.UserID)
 		}
@@ -388,11 +795,22 @@ func (r *reportRepository) GetRiskPatientsCoordinates(ctx context.Context, filte
 func (r *reportRepository) GetUserActivity(ctx context.Context, filters *domain.ReportFilters) (*domain.UserActivityReport, error) {
 	var users []domain.UserStats
 
-	query := r.db.WithContext(ctx).
+	// Con approved_only solo cuentan los pacientes aprobados y las mediciones de esos pacientes
+	patientJoin := "LEFT JOIN patients p ON u.id = p.user_id"
+	measurementJoin := "LEFT JOIN measurements m ON u.id = m.user_id"
+	var patientJoinArgs, measurementJoinArgs []interface{}
+	if filters != nil && filters.ApprovedOnly {
+		patientJoin += " AND p.approval_status = ?"
+		patientJoinArgs = append(patientJoinArgs, domain.PatientApprovalApproved)
+		measurementJoin += " AND m.patient_id IN (SELECT id FROM patients WHERE approval_status = ?)"
+		measurementJoinArgs = append(measurementJoinArgs, domain.PatientApprovalApproved)
+	}
+
+	query := r.readDB.WithContext(ctx).
 		Select(`
 			u.id as user_id,
 			CONCAT(u.name, ' ', u.lastname) as user_name,
-			l.name as locality_name,
+			COALESCE(l.name, 'Sin localidad') as locality_name,
 			COUNT(DISTINCT p.id) as total_patients,
 			COUNT(m.id) as total_measures,
 			MAX(m.created_at) as last_activity,
@@ -400,8 +818,8 @@ func (r *reportRepository) GetUserActivity(ctx context.Context, filters *domain.
 		`, time.Now().AddDate(0, 0, -7)).
 		Table("users u").
 		Joins("LEFT JOIN localities l ON u.locality_id = l.id").
-		Joins("LEFT JOIN patients p ON u.id = p.user_id").
-		Joins("LEFT JOIN measurements m ON u.id = m.user_id").
+		Joins(patientJoin, patientJoinArgs...).
+		Joins(measurementJoin, measurementJoinArgs...).
 		Group("u.id, u.name, u.lastname, l.name").
 		Order("total_measures DESC")
 
@@ -431,36 +849,158 @@ func (r *reportRepository) GetUserActivity(ctx context.Context, filters *domain.
 	}, nil
 }
 
+// GetRecommendationUsage obtiene el número de mediciones que activaron cada recomendación
+// y su distribución por localidad (incluye recomendaciones sin uso)
+func (r *reportRepository) GetRecommendationUsage(ctx context.Context, filters *domain.ReportFilters) (*domain.RecommendationUsageReport, error) {
+	// Mediciones filtradas con la localidad del usuario que midió
+	measurements := r.readDB.WithContext(ctx).
+		Select("m.id, m.recommendation_id, u.locality_id").
+		Table("measurements m").
+		Joins("JOIN users u ON m.user_id = u.id").
+		Where("m.recommendation_id IS NOT NULL")
+
+	if filters != nil {
+		if filters.LocalityID != nil {
+			measurements = measurements.Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.UserID != nil {
+			measurements = measurements.Where("m.user_id = ?", *filters.UserID)
+		}
+		if filters.Days > 0 {
+			since := time.Now().AddDate(0, 0, -filters.Days)
+			measurements = measurements.Where("m.created_at >= ?", since)
+		}
+	}
+
+	var recommendations []struct {
+		RecommendationID uuid.UUID
+		Name             string
+		MuacCode         string
+		Active           bool
+		Total            int64
+	}
+
+	query := r.readDB.WithContext(ctx).
+		Select(`
+			r.id as recommendation_id,
+			r.name,
+			r.muac_code,
+			r.active,
+			COUNT(fm.id) as total
+		`).
+		Table("recommendations r").
+		Joins("LEFT JOIN (?) fm ON fm.recommendation_id = r.id", measurements).
+		Group("r.id, r.name, r.muac_code, r.active").
+		Order("total DESC, r.name")
+
+	if filters != nil && filters.Limit > 0 {
+		query = query.Limit(filters.Limit)
+	}
+
+	if err := query.Scan(&recommendations).Error; err != nil {
+		return nil, fmt.Errorf("error al obtener uso de recomendaciones: %w", err)
+	}
+
+	var distribution []struct {
+		RecommendationID uuid.UUID
+		LocalityID       *uuid.UUID
+		LocalityName     string
+		Total            int64
+	}
+
+	err := r.readDB.WithContext(ctx).
+		Select(`
+			fm.recommendation_id,
+			fm.locality_id,
+			COALESCE(l.name, 'Sin localidad') as locality_name,
+			COUNT(fm.id) as total
+		`).
+		Table("(?) fm", measurements).
+		Joins("LEFT JOIN localities l ON fm.locality_id = l.id").
+		Group("fm.recommendation_id, fm.locality_id, l.name").
+		Order("total DESC").
+		Scan(&distribution).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener distribución por localidad: %w", err)
+	}
+
+	byRecommendation := make(map[uuid.UUID][]domain.LocalityUsage)
+	for _, d := range distribution {
+		byRecommendation[d.RecommendationID] = append(byRecommendation[d.RecommendationID], domain.LocalityUsage{
+			LocalityID:   d.LocalityID,
+			LocalityName: d.LocalityName,
+			Total:        d.Total,
+		})
+	}
+
+	report := &domain.RecommendationUsageReport{
+		Recommendations: make([]domain.RecommendationUsage, len(recommendations)),
+	}
+
+	for i, rec := range recommendations {
+		byLocality := byRecommendation[rec.RecommendationID]
+		if byLocality == nil {
+			byLocality = []domain.LocalityUsage{}
+		}
+
+		report.Recommendations[i] = domain.RecommendationUsage{
+			RecommendationID:  rec.RecommendationID,
+			Name:              rec.Name,
+			MuacCode:          rec.MuacCode,
+			Active:            rec.Active,
+			TotalMeasurements: rec.Total,
+			ByLocality:        byLocality,
+		}
+	}
+
+	return report, nil
+}
+
 // Funciones helper
 // GetDashboardData obtiene los datos principales del dashboard
 func (r *reportRepository) GetDashboardData(ctx context.Context, filters *domain.ReportFilters) (*domain.DashboardReport, error) {
 	report := &domain.DashboardReport{}
 
 	// Total de pacientes (todos los registrados)
-	patientQuery := r.db.WithContext(ctx).Model(&domain.Patient{})
+	patientQuery := r.readDB.WithContext(ctx).Model(&domain.Patient{})
 	if filters != nil && filters.LocalityID != nil {
 		patientQuery = patientQuery.Joins("JOIN users u ON patients.user_id = u.id").
 			Where("u.locality_id = ?", *filters.LocalityID)
 	}
+	if filters != nil && filters.UserID != nil {
+		patientQuery = patientQuery.Where("patients.user_id = ?", *filters.UserID)
+	}
+	if filters != nil && filters.ApprovedOnly {
+		patientQuery = patientQuery.Where("patients.approval_status = ?", domain.PatientApprovalApproved)
+	}
 
 	if err := patientQuery.Count(&report.TotalPatients).Error; err != nil {
 		return nil, fmt.Errorf("error al contar pacientes: %w", err)
 	}
 
 	// Total de mediciones (suma de TODAS las mediciones de todos los pacientes)
-	measureQuery := r.db.WithContext(ctx).Model(&domain.Measurement{})
+	measureQuery := r.readDB.WithContext(ctx).Model(&domain.Measurement{})
 	if filters != nil && filters.LocalityID != nil {
 		measureQuery = measureQuery.Joins("JOIN patients p ON measurements.patient_id = p.id").
 			Joins("JOIN users u ON p.user_id = u.id").
 			Where("u.locality_id = ?", *filters.LocalityID)
 	}
+	if filters != nil && filters.UserID != nil {
+		// Mediciones de los pacientes asignados al usuario, las haya tomado él u otro
+		measureQuery = measureQuery.Where("measurements.patient_id IN (?)",
+			r.readDB.Table("patients").Select("id").Where("user_id = ?", *filters.UserID))
+	}
+	if filters != nil && filters.ApprovedOnly {
+		measureQuery = measureQuery.Where("measurements.patient_id IN (?)",
+			r.readDB.Table("patients").Select("id").Where("approval_status = ?", domain.PatientApprovalApproved))
+	}
 
 	if err := measureQuery.Count(&report.TotalMeasurements).Error; err != nil {
 		return nil, fmt.Errorf("error al contar mediciones: %w", err)
 	}
 
 	// Total de usuarios
-	userQuery := r.db.WithContext(ctx).Model(&domain.User{})
+	userQuery := r.readDB.WithContext(ctx).Model(&domain.User{})
 	if filters != nil && filters.LocalityID != nil {
 		userQuery = userQuery.Where("locality_id = ?", *filters.LocalityID)
 	}
@@ -490,7 +1030,7 @@ func (r *reportRepository) getStatusDistribution(ctx context.Context, filters *d
 	}
 
 	// Query para obtener la última medición de cada paciente y clasificarla
-	query := r.db.WithContext(ctx).
+	query := r.readDB.WithContext(ctx).
 		Select(`
 			COUNT(DISTINCT p.id) as total,
 			SUM(CASE WHEN latest_m.muac_value >= 12.5 THEN 1 ELSE 0 END) as normal,
@@ -513,6 +1053,12 @@ func (r *reportRepository) getStatusDistribution(ctx context.Context, filters *d
 		query = query.Joins("JOIN users u ON p.user_id = u.id").
 			Where("u.locality_id = ?", *filters.LocalityID)
 	}
+	if filters != nil && filters.UserID != nil {
+		query = query.Where("p.user_id = ?", *filters.UserID)
+	}
+	if filters != nil && filters.ApprovedOnly {
+		query = query.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+	}
 
 	if err := query.Scan(&result).Error; err != nil {
 		return nil, err
@@ -541,3 +1087,921 @@ func (r *reportRepository) calculatePercentage(count int, total float64) float64
 	}
 	return (float64(count) / total) * 100
 }
+
+// GetMeasurementHeatPoints agrupa las mediciones por las coordenadas de la localidad del apoderado
+func (r *reportRepository) GetMeasurementHeatPoints(ctx context.Context, filters *domain.ReportFilters) ([]domain.HeatPoint, error) {
+	query := r.readDB.WithContext(ctx).
+		Table("measurements m").
+		Select(`l.latitude, l.longitude, COUNT(*) as count,
+			SUM(CASE WHEN m.muac_value < ? THEN 3 WHEN m.muac_value < ? THEN 2 ELSE 1 END) as severity_sum`,
+			domain.MuacThresholdSevere, domain.MuacThresholdNormal).
+		Joins("JOIN patients p ON m.patient_id = p.id").
+		Joins("JOIN users u ON p.user_id = u.id").
+		Joins("JOIN localities l ON u.locality_id = l.id").
+		Where("l.latitude IS NOT NULL AND l.latitude != ''").
+		Where("l.longitude IS NOT NULL AND l.longitude != ''")
+
+	if filters != nil {
+		if filters.LocalityID != nil {
+			query = query.Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.ApprovedOnly {
+			query = query.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
+		if filters.UserID != nil {
+			query = query.Where("p.user_id = ?", *filters.UserID)
+		}
+		if filters.Days > 0 {
+			since := time.Now().AddDate(0, 0, -filters.Days)
+			query = query.Where("m.created_at >= ?", since)
+		}
+	}
+
+	var points []domain.HeatPoint
+	if err := query.Group("l.latitude, l.longitude").Scan(&points).Error; err != nil {
+		return nil, fmt.Errorf("error al obtener puntos de calor: %w", err)
+	}
+	return points, nil
+}
+
+// GetMeasurementCountsByHour cuenta las mediciones por hora del día en la zona horaria del
+// programa; solo devuelve las horas con mediciones
+func (r *reportRepository) GetMeasurementCountsByHour(ctx context.Context, filters *domain.ReportFilters) ([]domain.HourBucket, error) {
+	query := r.readDB.WithContext(ctx).
+		Table("measurements m").
+		Select("EXTRACT(HOUR FROM m.created_at AT TIME ZONE ?)::int as hour, COUNT(*) as count", domain.ProgramTimeZone).
+		Joins("JOIN patients p ON m.patient_id = p.id").
+		Joins("LEFT JOIN users u ON p.user_id = u.id")
+
+	if filters != nil {
+		if filters.LocalityID != nil {
+			query = query.Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.ApprovedOnly {
+			query = query.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
+		if filters.UserID != nil {
+			query = query.Where("p.user_id = ?", *filters.UserID)
+		}
+		if filters.Days > 0 {
+			since := time.Now().AddDate(0, 0, -filters.Days)
+			query = query.Where("m.created_at >= ?", since)
+		}
+	}
+
+	var buckets []domain.HourBucket
+	if err := query.Group("1").Order("1").Scan(&buckets).Error; err != nil {
+		return nil, fmt.Errorf("error al obtener mediciones por hora: %w", err)
+	}
+	return buckets, nil
+}
+
+// GetUncoveredLocalities obtiene las localidades sin usuarios activos asignados (se excluyen los centros médicos)
+func (r *reportRepository) GetUncoveredLocalities(ctx context.Context) ([]domain.UncoveredLocality, error) {
+	var localities []domain.UncoveredLocality
+	err := r.readDB.WithContext(ctx).
+		Table("localities l").
+		Select(`l.id as locality_id, l.name as locality_name, l.latitude, l.longitude,
+			(SELECT COUNT(*) FROM patients p JOIN users iu ON p.user_id = iu.id WHERE iu.locality_id = l.id) as patients`).
+		Joins("LEFT JOIN users u ON u.locality_id = l.id AND u.active = ?", true).
+		Where("u.id IS NULL AND l.is_medical_center = ?", false).
+		Order("l.name").
+		Scan(&localities).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener localidades sin cobertura: %w", err)
+	}
+	return localities, nil
+}
+
+// GetActiveLocalities obtiene las localidades con mediciones en [from, to), con la cantidad de
+// mediciones y de niños medidos. La localidad se toma del usuario asignado al paciente
+func (r *reportRepository) GetActiveLocalities(ctx context.Context, filters *domain.ReportFilters, from, to time.Time) ([]domain.ActiveLocality, error) {
+	query := r.readDB.WithContext(ctx).
+		Table("measurements m").
+		Select(`l.id as locality_id, l.name as locality_name,
+			COALESCE(l.latitude, '') as latitude, COALESCE(l.longitude, '') as longitude,
+			COUNT(m.id) as measurements, COUNT(DISTINCT m.patient_id) as patients`).
+		Joins("JOIN patients p ON m.patient_id = p.id").
+		Joins("JOIN users u ON p.user_id = u.id").
+		Joins("JOIN localities l ON u.locality_id = l.id").
+		Where("m.created_at >= ? AND m.created_at < ?", from, to)
+
+	if filters != nil {
+		if filters.LocalityID != nil {
+			query = query.Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.ApprovedOnly {
+			query = query.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
+	}
+
+	var localities []domain.ActiveLocality
+	err := query.
+		Group("l.id, l.name, l.latitude, l.longitude").
+		Order("l.name").
+		Scan(&localities).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener localidades activas: %w", err)
+	}
+	return localities, nil
+}
+
+// GetFlaggedMeasurements obtiene las mediciones marcadas para revisión, de la más reciente a la más antigua
+func (r *reportRepository) GetFlaggedMeasurements(ctx context.Context, filters *domain.ReportFilters, page *domain.Pagination) ([]domain.FlaggedMeasurement, error) {
+	query := r.readDB.WithContext(ctx).
+		Table("measurements m").
+		Joins("JOIN patients p ON m.patient_id = p.id").
+		Joins("LEFT JOIN users u ON p.user_id = u.id").
+		Where("m.is_flagged = ?", true)
+
+	if filters != nil {
+		if filters.LocalityID != nil {
+			query = query.Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.ApprovedOnly {
+			query = query.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
+		if filters.UserID != nil {
+			query = query.Where("m.user_id = ?", *filters.UserID)
+		}
+	}
+
+	// Sesión nueva para reutilizar la consulta en el conteo y en la página
+	query = query.Session(&gorm.Session{})
+
+	if err := query.Count(&page.Total).Error; err != nil {
+		return nil, fmt.Errorf("error al contar mediciones marcadas: %w", err)
+	}
+
+	var flagged []domain.FlaggedMeasurement
+	err := query.
+		Select(`m.id as measurement_id, m.patient_id, p.name || ' ' || p.lastname as patient_name,
+			m.user_id, u.locality_id, m.muac_value, m.flag_previous_value as previous_value,
+			m.flag_delta as delta, m.flag_reason, m.flagged_at, m.created_at as measured_at`).
+		Order("m.flagged_at DESC NULLS LAST, m.id").
+		Offset(page.Offset()).
+		Limit(page.PageSize).
+		Scan(&flagged).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener mediciones marcadas: %w", err)
+	}
+	return flagged, nil
+}
+
+// GetRiskPatientHistories obtiene todas las mediciones de los pacientes que alguna vez estuvieron en
+// rojo o amarillo, sin límite de fecha para no cortar episodios largos
+func (r *reportRepository) GetRiskPatientHistories(ctx context.Context, filters *domain.ReportFilters) ([]domain.MuacHistoryPoint, error) {
+	atRisk := r.readDB.
+		Table("measurements").
+		Select("DISTINCT patient_id").
+		Where("muac_value < ?", domain.MuacThresholdNormal)
+
+	query := r.readDB.WithContext(ctx).
+		Select("m.patient_id, m.muac_value, m.created_at as measured_at").
+		Table("measurements m").
+		Joins("JOIN patients p ON m.patient_id = p.id").
+		Joins("LEFT JOIN users u ON p.user_id = u.id").
+		Where("m.patient_id IN (?)", atRisk)
+
+	if filters != nil {
+		if filters.LocalityID != nil {
+			query = query.Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.ApprovedOnly {
+			query = query.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
+		if filters.UserID != nil {
+			query = query.Where("p.user_id = ?", *filters.UserID)
+		}
+	}
+
+	var history []domain.MuacHistoryPoint
+	if err := query.Order("m.patient_id, m.created_at").Scan(&history).Error; err != nil {
+		return nil, fmt.Errorf("error al obtener historial de pacientes en riesgo: %w", err)
+	}
+	return history, nil
+}
+
+// GetMeasurementTimes obtiene las fechas de medición de cada paciente ordenadas cronológicamente
+func (r *reportRepository) GetMeasurementTimes(ctx context.Context, filters *domain.ReportFilters) ([]domain.MeasurementTime, error) {
+	query := r.readDB.WithContext(ctx).
+		Select("m.patient_id, m.created_at as measured_at").
+		Table("measurements m").
+		Joins("JOIN patients p ON m.patient_id = p.id").
+		Joins("LEFT JOIN users u ON p.user_id = u.id")
+
+	if filters != nil {
+		if filters.LocalityID != nil {
+			query = query.Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.ApprovedOnly {
+			query = query.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
+		if filters.UserID != nil {
+			query = query.Where("p.user_id = ?", *filters.UserID)
+		}
+		if filters.Days > 0 {
+			since := time.Now().AddDate(0, 0, -filters.Days)
+			query = query.Where("m.created_at >= ?", since)
+		}
+	}
+
+	var times []domain.MeasurementTime
+	if err := query.Order("m.patient_id, m.created_at").Scan(&times).Error; err != nil {
+		return nil, fmt.Errorf("error al obtener fechas de medición: %w", err)
+	}
+	return times, nil
+}
+
+// GetRedAlertResponses obtiene las mediciones en alerta roja de la ventana con la fecha de la
+// siguiente medición del paciente (sin límite de fecha, para no perder respuestas tardías)
+func (r *reportRepository) GetRedAlertResponses(ctx context.Context, filters *domain.ReportFilters) ([]domain.AlertResponse, error) {
+	query := r.readDB.WithContext(ctx).
+		Select(`m.id as measurement_id, m.patient_id, m.created_at as alert_at,
+			(SELECT MIN(m2.created_at) FROM measurements m2
+			 WHERE m2.patient_id = m.patient_id AND m2.created_at > m.created_at) as responded_at`).
+		Table("measurements m").
+		Joins("JOIN patients p ON m.patient_id = p.id").
+		Joins("LEFT JOIN users u ON p.user_id = u.id").
+		Where("m.muac_value < ?", domain.MuacThresholdSevere)
+
+	if filters != nil {
+		if filters.LocalityID != nil {
+			query = query.Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.ApprovedOnly {
+			query = query.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
+		if filters.UserID != nil {
+			query = query.Where("p.user_id = ?", *filters.UserID)
+		}
+		if filters.Days > 0 {
+			since := time.Now().AddDate(0, 0, -filters.Days)
+			query = query.Where("m.created_at >= ?", since)
+		}
+	}
+
+	var responses []domain.AlertResponse
+	if err := query.Order("m.created_at").Scan(&responses).Error; err != nil {
+		return nil, fmt.Errorf("error al obtener respuestas a alertas rojas: %w", err)
+	}
+	return responses, nil
+}
+
+// CountPeriodMetric cuenta la métrica de la comparación entre periodos en [from, to); la misma consulta
+// sirve para ambos periodos. Los filtros de días no aplican: la ventana la dan from y to
+func (r *reportRepository) CountPeriodMetric(ctx context.Context, metric string, filters *domain.ReportFilters, from, to time.Time) (int64, error) {
+	query := r.readDB.WithContext(ctx).
+		Table("measurements m").
+		Joins("JOIN patients p ON m.patient_id = p.id").
+		Joins("LEFT JOIN users u ON p.user_id = u.id").
+		Where("m.created_at >= ? AND m.created_at < ?", from, to)
+
+	if filters != nil {
+		if filters.LocalityID != nil {
+			query = query.Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.ApprovedOnly {
+			query = query.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
+		if filters.UserID != nil {
+			query = query.Where("p.user_id = ?", *filters.UserID)
+		}
+	}
+
+	switch metric {
+	case domain.PeriodMetricRisk:
+		query = query.Select("COUNT(DISTINCT m.patient_id)").Where("m.muac_value < ?", domain.MuacThresholdNormal)
+	default:
+		query = query.Select("COUNT(*)")
+	}
+
+	var count int64
+	if err := query.Scan(&count).Error; err != nil {
+		return 0, fmt.Errorf("error al contar %s del periodo: %w", metric, err)
+	}
+	return count, nil
+}
+
+// CountOverdueFollowups cuenta los pacientes medidos cuyo control venció a now según la frecuencia
+// recomendada para la clasificación de su última medición (ExpectedMeasurementDays), como NewPatientTask
+func (r *reportRepository) CountOverdueFollowups(ctx context.Context, filters *domain.ReportFilters, now time.Time) (int64, error) {
+	query := r.readDB.WithContext(ctx).
+		Table("patients p").
+		Select("COUNT(*)").
+		Joins(`JOIN LATERAL (
+				SELECT muac_value, created_at
+				FROM measurements m
+				WHERE m.patient_id = p.id
+				ORDER BY m.created_at DESC
+				LIMIT 1
+			) latest_m ON true`).
+		Where(`latest_m.created_at + (CASE
+				WHEN latest_m.muac_value < ? THEN ?
+				WHEN latest_m.muac_value < ? THEN ?
+				ELSE ? END) * INTERVAL '1 day' <= ?`,
+			domain.MuacThresholdSevere, domain.ExpectedMeasurementDays[domain.MuacCodeRed],
+			domain.MuacThresholdNormal, domain.ExpectedMeasurementDays[domain.MuacCodeYellow],
+			domain.ExpectedMeasurementDays[domain.MuacCodeGreen], now)
+
+	if filters != nil {
+		if filters.LocalityID != nil {
+			query = query.Joins("JOIN users u ON p.user_id = u.id").
+				Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.UserID != nil {
+			query = query.Where("p.user_id = ?", *filters.UserID)
+		}
+		if filters.ApprovedOnly {
+			query = query.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
+	}
+
+	var count int64
+	if err := query.Scan(&count).Error; err != nil {
+		return 0, fmt.Errorf("error al contar controles vencidos: %w", err)
+	}
+	return count, nil
+}
+
+// monthlyStatsSelect son las columnas de MonthlyStats sobre las mediciones m
+const monthlyStatsSelect = `COUNT(m.id) AS measurements,
+	COUNT(DISTINCT m.patient_id) AS children,
+	COUNT(m.id) FILTER (WHERE m.muac_value < ?) AS risk_detections,
+	COUNT(m.id) FILTER (WHERE m.muac_value < ?) AS red_detections`
+
+// GetMonthlyStats obtiene las cifras de las mediciones en [from, to) por apoderado que las registró,
+// por localidad del apoderado y el total de niños distintos
+func (r *reportRepository) GetMonthlyStats(ctx context.Context, filters *domain.ReportFilters, from, to time.Time) ([]domain.CaregiverMonthlyStats, []domain.LocalityMonthlyStats, int64, error) {
+	base := func() *gorm.DB {
+		query := r.readDB.WithContext(ctx).
+			Table("measurements m").
+			Joins("JOIN users u ON m.user_id = u.id").
+			Joins("JOIN patients p ON m.patient_id = p.id").
+			Joins("LEFT JOIN localities l ON u.locality_id = l.id").
+			Where("m.created_at >= ? AND m.created_at < ?", from, to)
+		if filters != nil {
+			if filters.LocalityID != nil {
+				query = query.Where("u.locality_id = ?", *filters.LocalityID)
+			}
+			if filters.UserID != nil {
+				query = query.Where("m.user_id = ?", *filters.UserID)
+			}
+			if filters.ApprovedOnly {
+				query = query.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+			}
+		}
+		return query
+	}
+
+	var caregivers []domain.CaregiverMonthlyStats
+	err := base().
+		Select("u.id AS caregiver_id, CONCAT(u.name, ' ', u.lastname) AS caregiver_name, u.locality_id, COALESCE(l.name, '') AS locality_name, "+monthlyStatsSelect,
+			domain.MuacThresholdNormal, domain.MuacThresholdSevere).
+		Group("u.id, u.name, u.lastname, u.locality_id, l.name").
+		Order("locality_name, caregiver_name").
+		Scan(&caregivers).Error
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("error al obtener reporte mensual por apoderado: %w", err)
+	}
+
+	var localities []domain.LocalityMonthlyStats
+	err = base().
+		Select("u.locality_id, COALESCE(l.name, '') AS locality_name, COUNT(DISTINCT m.user_id) AS caregivers, "+monthlyStatsSelect,
+			domain.MuacThresholdNormal, domain.MuacThresholdSevere).
+		Group("u.locality_id, l.name").
+		Order("locality_name").
+		Scan(&localities).Error
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("error al obtener reporte mensual por localidad: %w", err)
+	}
+
+	var children int64
+	if err := base().Select("COUNT(DISTINCT m.patient_id)").Scan(&children).Error; err != nil {
+		return nil, nil, 0, fmt.Errorf("error al contar niños del reporte mensual: %w", err)
+	}
+
+	return caregivers, localities, children, nil
+}
+
+// GetCounters ejecuta en paralelo los conteos ligeros de la pantalla de inicio
+func (r *reportRepository) GetCounters(ctx context.Context, filters *domain.ReportFilters) (*domain.CountersReport, error) {
+	var localityID *uuid.UUID
+	approvedOnly := false
+	if filters != nil {
+		localityID = filters.LocalityID
+		approvedOnly = filters.ApprovedOnly
+	}
+
+	// patientsScope limita la consulta a pacientes de apoderados de la localidad y, con approved_only,
+	// a pacientes aprobados
+	patientsScope := func(query *gorm.DB, patientColumn string) *gorm.DB {
+		if localityID == nil && !approvedOnly {
+			return query
+		}
+		scoped := r.readDB.Table("patients p").Select("p.id")
+		if localityID != nil {
+			scoped = scoped.Joins("JOIN users u ON p.user_id = u.id").Where("u.locality_id = ?", *localityID)
+		}
+		if approvedOnly {
+			scoped = scoped.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
+		return query.Where(patientColumn+" IN (?)", scoped)
+	}
+
+	report := &domain.CountersReport{}
+	year, month, day := time.Now().Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+
+	counts := []struct {
+		name  string
+		dest  *int64
+		query func() *gorm.DB
+	}{
+		{"pacientes", &report.TotalPatients, func() *gorm.DB {
+			return patientsScope(r.readDB.WithContext(ctx).Table("patients"), "patients.id")
+		}},
+		{"pacientes en riesgo", &report.PatientsAtRisk, func() *gorm.DB {
+			latest := patientsScope(r.readDB.
+				Table("measurements").
+				Select("DISTINCT ON (patient_id) patient_id, muac_value").
+				Order("patient_id, created_at DESC"), "measurements.patient_id")
+			return r.readDB.WithContext(ctx).Table("(?) lm", latest).Where("lm.muac_value < ?", domain.MuacThresholdNormal)
+		}},
+		{"mediciones de hoy", &report.MeasurementsToday, func() *gorm.DB {
+			return patientsScope(r.readDB.WithContext(ctx).Table("measurements").Where("created_at >= ?", today), "measurements.patient_id")
+		}},
+		{"apoderados activos", &report.ActiveCaregivers, func() *gorm.DB {
+			query := r.readDB.WithContext(ctx).
+				Table("users u").
+				Joins("JOIN roles ro ON u.role_id = ro.id").
+				Where("ro.name = ? AND u.active = ?", "APODERADO", true)
+			if localityID != nil {
+				query = query.Where("u.locality_id = ?", *localityID)
+			}
+			return query
+		}},
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(counts))
+	for i, c := range counts {
+		wg.Add(1)
+		go func(i int, name string, dest *int64, query *gorm.DB) {
+			defer wg.Done()
+			if err := query.Count(dest).Error; err != nil {
+				errs[i] = fmt.Errorf("error al contar %s: %w", name, err)
+			}
+		}(i, c.name, c.dest, c.query())
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// GetCaseloadByLocality obtiene por localidad (sin contar centros médicos) el promedio, mínimo y máximo
+// de niños por apoderado activo y el total de niños registrados por usuarios de la localidad
+func (r *reportRepository) GetCaseloadByLocality(ctx context.Context, filters *domain.ReportFilters) ([]domain.LocalityCaseload, error) {
+	approvedOnly := filters != nil && filters.ApprovedOnly
+
+	// Niños por apoderado activo, incluidos los apoderados sin niños
+	caseloads := r.readDB.
+		Table("users u").
+		Select("u.id, u.locality_id, COUNT(p.id) AS patients").
+		Joins("JOIN roles ro ON u.role_id = ro.id")
+	if approvedOnly {
+		caseloads = caseloads.Joins("LEFT JOIN patients p ON p.user_id = u.id AND p.approval_status = ?", domain.PatientApprovalApproved)
+	} else {
+		caseloads = caseloads.Joins("LEFT JOIN patients p ON p.user_id = u.id")
+	}
+	caseloads = caseloads.
+		Where("ro.name = ? AND u.active = ?", "APODERADO", true).
+		Group("u.id, u.locality_id")
+
+	// Todos los niños de la localidad, aunque su apoderado esté inactivo o tenga otro rol
+	children := r.readDB.
+		Table("patients p").
+		Select("u.locality_id, COUNT(p.id) AS total").
+		Joins("JOIN users u ON p.user_id = u.id").
+		Group("u.locality_id")
+	if approvedOnly {
+		children = children.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+	}
+
+	query := r.readDB.WithContext(ctx).
+		Table("localities l").
+		Select(`l.id AS locality_id, l.name AS locality_name,
+			COUNT(c.id) AS caregivers,
+			COALESCE(MAX(ch.total), 0) AS children,
+			COALESCE(SUM(c.patients), 0) AS assigned,
+			COALESCE(AVG(c.patients), 0) AS average_caseload,
+			COALESCE(MIN(c.patients), 0) AS min_caseload,
+			COALESCE(MAX(c.patients), 0) AS max_caseload`).
+		Joins("LEFT JOIN (?) c ON c.locality_id = l.id", caseloads).
+		Joins("LEFT JOIN (?) ch ON ch.locality_id = l.id", children).
+		Where("l.is_medical_center = ?", false).
+		Group("l.id, l.name")
+	if filters != nil && filters.LocalityID != nil {
+		query = query.Where("l.id = ?", *filters.LocalityID)
+	}
+
+	var localities []domain.LocalityCaseload
+	if err := query.Scan(&localities).Error; err != nil {
+		return nil, fmt.Errorf("error al obtener carga de niños por localidad: %w", err)
+	}
+	return localities, nil
+}
+
+// StreamTransitionMeasurements recorre las mediciones registradas en [from, to) con la medición anterior
+// de cada paciente, ordenadas por paciente y fecha, llamando a fn por cada fila sin cargar el resultado en memoria
+func (r *reportRepository) StreamTransitionMeasurements(ctx context.Context, filters *domain.ReportFilters, from, to time.Time, fn func(domain.TransitionMeasurement) error) error {
+	// La medición anterior se calcula sobre todo el historial del paciente, antes de filtrar por fecha
+	history := r.readDB.
+		Table("measurements m").
+		Select(`m.id AS measurement_id, m.patient_id,
+			p.name || ' ' || p.lastname AS patient_name,
+			COALESCE(l.name, '') AS locality_name,
+			m.muac_value, m.created_at AS measured_at,
+			LAG(m.id) OVER (PARTITION BY m.patient_id ORDER BY m.created_at, m.id) AS previous_measurement_id,
+			LAG(m.muac_value) OVER (PARTITION BY m.patient_id ORDER BY m.created_at, m.id) AS previous_value,
+			LAG(m.created_at) OVER (PARTITION BY m.patient_id ORDER BY m.created_at, m.id) AS previous_measured_at`).
+		Joins("JOIN patients p ON m.patient_id = p.id").
+		Joins("LEFT JOIN users u ON p.user_id = u.id").
+		Joins("LEFT JOIN localities l ON u.locality_id = l.id")
+
+	if filters != nil {
+		if filters.LocalityID != nil {
+			history = history.Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.ApprovedOnly {
+			history = history.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
+		if filters.UserID != nil {
+			history = history.Where("p.user_id = ?", *filters.UserID)
+		}
+	}
+
+	rows, err := r.readDB.WithContext(ctx).
+		Table("(?) t", history).
+		Where("t.previous_measurement_id IS NOT NULL").
+		Where("t.measured_at >= ? AND t.measured_at < ?", from, to).
+		Order("t.patient_id, t.measured_at, t.measurement_id").
+		Rows()
+	if err != nil {
+		return fmt.Errorf("error al exportar transiciones de clasificación: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row domain.TransitionMeasurement
+		if err := r.readDB.ScanRows(rows, &row); err != nil {
+			return fmt.Errorf("error al leer medición de transición: %w", err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error al exportar transiciones de clasificación: %w", err)
+	}
+	return nil
+}
+
+// GetPendingReview obtiene los pacientes pendientes de aprobación y las mediciones marcadas para revisión de
+// la localidad (nil = todas), del más antiguo al más reciente y hasta limit de cada uno, con los totales sin límite
+func (r *reportRepository) GetPendingReview(ctx context.Context, localityID *uuid.UUID, limit int) (*domain.PendingReviewReport, error) {
+	patients := func() *gorm.DB {
+		query := r.readDB.WithContext(ctx).
+			Table("patients p").
+			Joins("LEFT JOIN users u ON p.user_id = u.id").
+			Where("p.approval_status = ?", domain.PatientApprovalPending)
+		if localityID != nil {
+			query = query.Where("u.locality_id = ?", *localityID)
+		}
+		return query
+	}
+	measurements := func() *gorm.DB {
+		query := r.readDB.WithContext(ctx).
+			Table("measurements m").
+			Joins("JOIN patients p ON m.patient_id = p.id").
+			Joins("LEFT JOIN users u ON p.user_id = u.id").
+			Where("m.is_flagged = ?", true)
+		if localityID != nil {
+			query = query.Where("u.locality_id = ?", *localityID)
+		}
+		return query
+	}
+
+	report := &domain.PendingReviewReport{}
+
+	if err := patients().Count(&report.Counts.PendingPatients).Error; err != nil {
+		return nil, fmt.Errorf("error al contar pacientes pendientes de aprobación: %w", err)
+	}
+	if err := measurements().Count(&report.Counts.FlaggedMeasurements).Error; err != nil {
+		return nil, fmt.Errorf("error al contar mediciones marcadas: %w", err)
+	}
+	report.Counts.Total = report.Counts.PendingPatients + report.Counts.FlaggedMeasurements
+
+	err := patients().
+		Select(`p.id as patient_id, p.name || ' ' || p.lastname as patient_name, p.dni,
+			u.id as caregiver_id, COALESCE(u.name || ' ' || u.lastname, '') as caregiver_name,
+			u.locality_id, p.created_at as registered_at`).
+		Order("p.created_at, p.id").
+		Limit(limit).
+		Scan(&report.Patients).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener pacientes pendientes de aprobación: %w", err)
+	}
+
+	err = measurements().
+		Select(`m.id as measurement_id, m.patient_id, p.name || ' ' || p.lastname as patient_name,
+			m.user_id, u.locality_id, m.muac_value, m.flag_previous_value as previous_value,
+			m.flag_delta as delta, m.flag_reason, m.flagged_at, m.created_at as measured_at`).
+		Order("m.flagged_at NULLS FIRST, m.created_at, m.id").
+		Limit(limit).
+		Scan(&report.Measurements).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener mediciones marcadas: %w", err)
+	}
+
+	return report, nil
+}
+
+// GetGPSCoverageByCaregiver cuenta por apoderado las mediciones con GPS dentro de region, con GPS fuera
+// de ella y sin GPS; la localidad y la ventana de días se aplican sobre el apoderado y la fecha de la medición
+func (r *reportRepository) GetGPSCoverageByCaregiver(ctx context.Context, filters *domain.ReportFilters, region domain.RegionBounds) ([]domain.CaregiverGPSCoverage, error) {
+	const withinRegion = "m.latitude BETWEEN ? AND ? AND m.longitude BETWEEN ? AND ?"
+
+	query := r.readDB.WithContext(ctx).
+		Table("measurements m").
+		Select(`u.id AS caregiver_id, CONCAT(u.name, ' ', u.lastname) AS caregiver_name,
+			u.locality_id, COALESCE(l.name, '') AS locality_name,
+			COUNT(m.id) AS measurements,
+			COUNT(m.id) FILTER (WHERE m.latitude IS NOT NULL AND m.longitude IS NOT NULL AND `+withinRegion+`) AS with_gps,
+			COUNT(m.id) FILTER (WHERE m.latitude IS NOT NULL AND m.longitude IS NOT NULL AND NOT (`+withinRegion+`)) AS outside_region,
+			COUNT(m.id) FILTER (WHERE m.latitude IS NULL OR m.longitude IS NULL) AS fallback`,
+			region.MinLatitude, region.MaxLatitude, region.MinLongitude, region.MaxLongitude,
+			region.MinLatitude, region.MaxLatitude, region.MinLongitude, region.MaxLongitude).
+		Joins("JOIN users u ON m.user_id = u.id").
+		Joins("JOIN patients p ON m.patient_id = p.id").
+		Joins("LEFT JOIN localities l ON u.locality_id = l.id")
+
+	if filters != nil {
+		if filters.LocalityID != nil {
+			query = query.Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.UserID != nil {
+			query = query.Where("m.user_id = ?", *filters.UserID)
+		}
+		if filters.ApprovedOnly {
+			query = query.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
+		if filters.Days > 0 {
+			since := time.Now().AddDate(0, 0, -filters.Days)
+			query = query.Where("m.created_at >= ?", since)
+		}
+	}
+
+	var caregivers []domain.CaregiverGPSCoverage
+	err := query.
+		Group("u.id, u.name, u.lastname, u.locality_id, l.name").
+		Scan(&caregivers).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener cobertura GPS por apoderado: %w", err)
+	}
+	return caregivers, nil
+}
+
+// GetTapeBatchStats cuenta las mediciones de la ventana por lote de cinta (las que no lo registraron quedan
+// con lote vacío) con la suma de MUAC y la distribución por clasificación
+func (r *reportRepository) GetTapeBatchStats(ctx context.Context, filters *domain.ReportFilters) ([]domain.TapeBatchStats, error) {
+	query := r.readDB.WithContext(ctx).
+		Table("measurements m").
+		Select(`COALESCE(m.tape_batch, '') AS tape_batch,
+			COUNT(m.id) AS measurements,
+			COUNT(DISTINCT m.patient_id) AS children,
+			COALESCE(SUM(m.muac_value), 0) AS muac_sum,
+			COUNT(m.id) FILTER (WHERE m.muac_value < ?) AS red,
+			COUNT(m.id) FILTER (WHERE m.muac_value >= ? AND m.muac_value < ?) AS yellow,
+			COUNT(m.id) FILTER (WHERE m.muac_value >= ?) AS green`,
+			domain.MuacThresholdSevere, domain.MuacThresholdSevere, domain.MuacThresholdNormal, domain.MuacThresholdNormal).
+		Joins("JOIN users u ON m.user_id = u.id").
+		Joins("JOIN patients p ON m.patient_id = p.id")
+
+	if filters != nil {
+		if filters.LocalityID != nil {
+			query = query.Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.UserID != nil {
+			query = query.Where("m.user_id = ?", *filters.UserID)
+		}
+		if filters.ApprovedOnly {
+			query = query.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
+		if filters.Days > 0 {
+			since := time.Now().AddDate(0, 0, -filters.Days)
+			query = query.Where("m.created_at >= ?", since)
+		}
+	}
+
+	var batches []domain.TapeBatchStats
+	err := query.
+		Group("COALESCE(m.tape_batch, '')").
+		Scan(&batches).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener mediciones por lote de cinta: %w", err)
+	}
+	return batches, nil
+}
+
+// GetClassificationOverrideCounts cuenta las mediciones con etiqueta por clasificación automática
+// (calculada con los umbrales guardados en cada medición), código de la etiqueta y motivo
+func (r *reportRepository) GetClassificationOverrideCounts(ctx context.Context, filters *domain.ReportFilters) ([]domain.ClassificationOverrideCount, error) {
+	query := r.readDB.WithContext(ctx).
+		Table("measurements m").
+		Select(`CASE
+				WHEN m.muac_value >= m.threshold_normal THEN ?
+				WHEN m.muac_value >= m.threshold_severe THEN ?
+				ELSE ? END AS computed_code,
+			t.muac_code AS assigned_code,
+			COALESCE(m.override_reason, '') AS reason,
+			COUNT(m.id) AS measurements`,
+			domain.MuacCodeGreen, domain.MuacCodeYellow, domain.MuacCodeRed).
+		Joins("JOIN tags t ON m.tag_id = t.id").
+		Joins("JOIN users u ON m.user_id = u.id").
+		Joins("JOIN patients p ON m.patient_id = p.id").
+		Where("t.muac_code IN ?", []string{domain.MuacCodeRed, domain.MuacCodeYellow, domain.MuacCodeGreen})
+
+	if filters != nil {
+		if filters.LocalityID != nil {
+			query = query.Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.UserID != nil {
+			query = query.Where("m.user_id = ?", *filters.UserID)
+		}
+		if filters.ApprovedOnly {
+			query = query.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
+		if filters.Days > 0 {
+			since := time.Now().AddDate(0, 0, -filters.Days)
+			query = query.Where("m.created_at >= ?", since)
+		}
+	}
+
+	var counts []domain.ClassificationOverrideCount
+	err := query.
+		Group("computed_code, t.muac_code, COALESCE(m.override_reason, '')").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener reclasificaciones manuales: %w", err)
+	}
+	return counts, nil
+}
+
+// filledColumn cuenta las filas con el texto de la columna no vacío
+func filledColumn(column, alias string) string {
+	return fmt.Sprintf("COUNT(*) FILTER (WHERE NULLIF(TRIM(%s), '') IS NOT NULL) AS %s", column, alias)
+}
+
+// GetDataCompleteness cuenta los pacientes con cada campo opcional registrado y las mediciones con
+// descripción y con GPS. La localidad se toma del usuario asignado al paciente
+func (r *reportRepository) GetDataCompleteness(ctx context.Context, filters *domain.ReportFilters) (domain.PatientCompletenessCounts, domain.MeasurementCompletenessCounts, error) {
+	var patients domain.PatientCompletenessCounts
+	var measurements domain.MeasurementCompletenessCounts
+
+	scope := func(query *gorm.DB) *gorm.DB {
+		if filters == nil {
+			return query
+		}
+		if filters.LocalityID != nil {
+			query = query.Joins("JOIN users u ON p.user_id = u.id").
+				Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.ApprovedOnly {
+			query = query.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
+		return query
+	}
+
+	patientQuery := r.readDB.WithContext(ctx).
+		Table("patients p").
+		Select(strings.Join([]string{
+			"COUNT(*) AS total",
+			filledColumn("p.birth_date", "birth_date"),
+			filledColumn("p.gender", "gender"),
+			filledColumn("p.weight", "weight"),
+			filledColumn("p.size", "size"),
+			filledColumn("p.arm_size", "arm_size"),
+			filledColumn("p.url_dni", "dni_image"),
+		}, ", "))
+	if err := scope(patientQuery).Scan(&patients).Error; err != nil {
+		return patients, measurements, fmt.Errorf("error al contar campos de pacientes: %w", err)
+	}
+
+	measurementQuery := r.readDB.WithContext(ctx).
+		Table("measurements m").
+		Select(strings.Join([]string{
+			"COUNT(*) AS total",
+			filledColumn("m.description", "description"),
+			"COUNT(*) FILTER (WHERE m.latitude IS NOT NULL AND m.longitude IS NOT NULL) AS gps",
+		}, ", ")).
+		Joins("JOIN patients p ON m.patient_id = p.id")
+	if err := scope(measurementQuery).Scan(&measurements).Error; err != nil {
+		return patients, measurements, fmt.Errorf("error al contar campos de mediciones: %w", err)
+	}
+
+	return patients, measurements, nil
+}
+
+// GetAccuracyPairs empareja cada medición de un apoderado con la primera medición de un supervisor
+// al mismo paciente dentro de la ventana. El rol es el actual del usuario que midió; las mediciones
+// sin re-medición del supervisor quedan fuera
+func (r *reportRepository) GetAccuracyPairs(ctx context.Context, filters *domain.ReportFilters, window time.Duration) ([]domain.AccuracyPair, error) {
+	supervisor := r.readDB.
+		Table("measurements sm").
+		Select("sm.muac_value, sm.created_at").
+		Joins("JOIN users su ON sm.user_id = su.id").
+		Joins("JOIN roles sr ON su.role_id = sr.id").
+		Where("sr.name = ?", "SUPERVISOR").
+		Where("sm.patient_id = m.patient_id").
+		Where("sm.created_at >= m.created_at AND sm.created_at <= m.created_at + make_interval(secs => ?)", window.Seconds()).
+		Order("sm.created_at").
+		Limit(1)
+
+	query := r.readDB.WithContext(ctx).
+		Table("measurements m").
+		Select(`u.id AS caregiver_id, CONCAT(u.name, ' ', u.lastname) AS caregiver_name,
+			m.patient_id, m.muac_value AS caregiver_value, m.created_at AS caregiver_at,
+			s.muac_value AS supervisor_value, s.created_at AS supervisor_at`).
+		Joins("JOIN users u ON m.user_id = u.id").
+		Joins("JOIN roles ur ON u.role_id = ur.id").
+		Joins("JOIN patients p ON m.patient_id = p.id").
+		Joins("JOIN LATERAL (?) s ON true", supervisor).
+		Where("ur.name = ?", "APODERADO")
+
+	if filters != nil {
+		if filters.LocalityID != nil {
+			query = query.Where("u.locality_id = ?", *filters.LocalityID)
+		}
+		if filters.UserID != nil {
+			query = query.Where("m.user_id = ?", *filters.UserID)
+		}
+		if filters.ApprovedOnly {
+			query = query.Where("p.approval_status = ?", domain.PatientApprovalApproved)
+		}
+		if filters.Days > 0 {
+			since := time.Now().AddDate(0, 0, -filters.Days)
+			query = query.Where("m.created_at >= ?", since)
+		}
+	}
+
+	var pairs []domain.AccuracyPair
+	if err := query.Order("m.created_at").Scan(&pairs).Error; err != nil {
+		return nil, fmt.Errorf("error al obtener re-mediciones de supervisores: %w", err)
+	}
+	return pairs, nil
+}
+
+// GetReliabilityPeriods cuenta por periodo (date_trunc sobre created_at) las mediciones del usuario
+// desde since y cuántas siguen marcadas, incluyendo los periodos sin mediciones
+func (r *reportRepository) GetReliabilityPeriods(ctx context.Context, userID uuid.UUID, interval string, since time.Time) ([]domain.ReliabilityPeriod, error) {
+	measurements := r.readDB.
+		Table("measurements m").
+		Select("m.id, m.created_at, m.is_flagged").
+		Where("m.user_id = ? AND m.created_at >= ?", userID, since)
+
+	var periods []domain.ReliabilityPeriod
+	err := r.readDB.WithContext(ctx).
+		Select(`b.period, COUNT(fm.id) as measurements,
+			COUNT(fm.id) FILTER (WHERE fm.is_flagged) as flagged`).
+		Table("generate_series(date_trunc(?, ?::timestamptz), date_trunc(?, NOW()), ('1 ' || ?)::interval) AS b(period)",
+			interval, since, interval, interval).
+		Joins("LEFT JOIN (?) fm ON date_trunc(?, fm.created_at) = b.period", measurements, interval).
+		Group("b.period").
+		Order("b.period").
+		Scan(&periods).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener mediciones por periodo: %w", err)
+	}
+	return periods, nil
+}
+
+// GetLocalityTargets obtiene las metas mensuales de las localidades (nil = todas las que tienen meta)
+func (r *reportRepository) GetLocalityTargets(ctx context.Context, localityID *uuid.UUID) ([]domain.LocalityTargetProgress, error) {
+	query := r.readDB.WithContext(ctx).
+		Table("locality_targets t").
+		Select("t.locality_id, l.name AS locality_name, t.monthly_target").
+		Joins("JOIN localities l ON t.locality_id = l.id")
+	if localityID != nil {
+		query = query.Where("t.locality_id = ?", *localityID)
+	}
+
+	var targets []domain.LocalityTargetProgress
+	if err := query.Order("l.name").Scan(&targets).Error; err != nil {
+		return nil, fmt.Errorf("error al obtener metas de localidades: %w", err)
+	}
+	return targets, nil
+}
@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+)
+
+func TestAppendUnassignedLocality(t *testing.T) {
+	assigned := []localityRow{{LocalityID: uuid.New(), LocalityName: "Centro", Total: 4, Normal: 3, Severe: 1}}
+
+	t.Run("sin pacientes no agrega el grupo", func(t *testing.T) {
+		got := appendUnassignedLocality(assigned, localityRow{})
+		if len(got) != 1 {
+			t.Fatalf("len = %d, se esperaba 1", len(got))
+		}
+	})
+
+	t.Run("agrega el grupo al final con sus conteos", func(t *testing.T) {
+		unassigned := localityRow{LocalityID: uuid.New(), Total: 3, Normal: 1, Moderate: 1, Severe: 1}
+		got := appendUnassignedLocality(assigned, unassigned)
+		if len(got) != 2 {
+			t.Fatalf("len = %d, se esperaba 2", len(got))
+		}
+		last := got[1]
+		if last.LocalityID != uuid.Nil {
+			t.Errorf("LocalityID = %s, se esperaba uuid.Nil", last.LocalityID)
+		}
+		if last.LocalityName != domain.UnassignedLocalityName {
+			t.Errorf("LocalityName = %q, se esperaba %q", last.LocalityName, domain.UnassignedLocalityName)
+		}
+		if last.Total != 3 || last.Normal != 1 || last.Moderate != 1 || last.Severe != 1 {
+			t.Errorf("conteos = %+v, se esperaban 3/1/1/1", last)
+		}
+		if got[0].LocalityName != "Centro" {
+			t.Errorf("las localidades asignadas deben ir primero, got %q", got[0].LocalityName)
+		}
+	})
+}
@@ -90,4 +90,101 @@ func (r *tagRepository) Delete(ctx context.Context, id uuid.UUID) error {
 		return domain.ErrTagNotFound
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// CountMeasurementsByTag cuenta cuántas mediciones tiene asignadas cada etiqueta
+func (r *tagRepository) CountMeasurementsByTag(ctx context.Context) (map[uuid.UUID]int64, error) {
+	var rows []struct {
+		TagID uuid.UUID
+		Total int64
+	}
+
+	err := r.db.WithContext(ctx).
+		Table("measurements").
+		Select("tag_id, COUNT(*) as total").
+		Where("tag_id IS NOT NULL").
+		Group("tag_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al contar mediciones por etiqueta: %w", err)
+	}
+
+	counts := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.TagID] = row.Total
+	}
+	return counts, nil
+}
+
+// GetUnmapped obtiene las etiquetas activas sin código MUAC
+func (r *tagRepository) GetUnmapped(ctx context.Context) ([]*domain.Tag, error) {
+	var tags []*domain.Tag
+	result := r.db.WithContext(ctx).
+		Where("active = ? AND (muac_code IS NULL OR muac_code = '')", true).
+		Order("name").
+		Find(&tags)
+	if result.Error != nil {
+		return nil, fmt.Errorf("error al obtener etiquetas sin código MUAC: %w", result.Error)
+	}
+	return tags, nil
+}
+
+// UpdateMuacMapping asigna código MUAC, color y prioridad a la etiqueta
+func (r *tagRepository) UpdateMuacMapping(ctx context.Context, id uuid.UUID, mapping domain.MuacMapping) error {
+	result := r.db.WithContext(ctx).
+		Model(&domain.Tag{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"muac_code": mapping.MuacCode,
+			"color":     mapping.Color,
+			"priority":  mapping.Priority,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("error al asignar código MUAC a la etiqueta: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrTagNotFound
+	}
+	return nil
+}
+
+// unusedTagCondition selecciona las etiquetas que ninguna medición referencia
+const unusedTagCondition = "NOT EXISTS (SELECT 1 FROM measurements m WHERE m.tag_id = tags.id)"
+
+// GetUnused obtiene las etiquetas activas sin mediciones asignadas
+func (r *tagRepository) GetUnused(ctx context.Context) ([]*domain.Tag, error) {
+	var tags []*domain.Tag
+	result := r.db.WithContext(ctx).
+		Where("active = ?", true).
+		Where(unusedTagCondition).
+		Order("name").
+		Find(&tags)
+	if result.Error != nil {
+		return nil, fmt.Errorf("error al obtener etiquetas sin mediciones: %w", result.Error)
+	}
+	return tags, nil
+}
+
+// DeleteUnused elimina en una transacción las etiquetas indicadas que sigan sin mediciones;
+// las que recibieron una medición entre la consulta y el borrado se conservan
+func (r *tagRepository) DeleteUnused(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id IN ?", ids).
+			Where(unusedTagCondition).
+			Delete(&domain.Tag{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error al eliminar etiquetas sin mediciones: %w", err)
+	}
+	return deleted, nil
+}
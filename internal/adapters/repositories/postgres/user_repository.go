@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
@@ -111,6 +112,66 @@ func (r *userRepository) GetByRole(ctx context.Context, roleName string, localit
 	return users, nil
 }
 
+// GetWithoutLocality obtiene los usuarios sin localidad asignada
+func (r *userRepository) GetWithoutLocality(ctx context.Context) ([]*domain.User, error) {
+	var users []*domain.User
+	result := r.db.WithContext(ctx).
+		Preload("Role").
+		Where("locality_id IS NULL").
+		Order("created_at DESC").
+		Find(&users)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("error al obtener usuarios sin localidad: %w", result.Error)
+	}
+	return users, nil
+}
+
+// caregiverSortOrder traduce el criterio de orden del dominio a SQL
+var caregiverSortOrder = map[string]string{
+	domain.CaregiverSortName:         "u.name ASC, u.lastname ASC",
+	domain.CaregiverSortPatientLoad:  "patient_count DESC, u.name ASC",
+	domain.CaregiverSortLastActivity: "last_activity_at DESC NULLS LAST, u.name ASC",
+}
+
+// GetCaregiverLoads obtiene los usuarios del rol en la localidad con su cantidad de pacientes
+// asignados y la fecha de su última medición
+func (r *userRepository) GetCaregiverLoads(ctx context.Context, roleName string, localityID uuid.UUID, sortBy string) ([]domain.CaregiverLoad, error) {
+	patientCounts := r.db.
+		Table("patients").
+		Select("user_id, COUNT(*) as patient_count").
+		Group("user_id")
+
+	lastActivity := r.db.
+		Table("measurements").
+		Select("user_id, MAX(created_at) as last_activity_at").
+		Group("user_id")
+
+	var caregivers []domain.CaregiverLoad
+	err := r.db.WithContext(ctx).
+		Table("users u").
+		Select(`
+			u.id as user_id,
+			u.name,
+			u.lastname as last_name,
+			u.phone,
+			u.email,
+			u.active,
+			COALESCE(pc.patient_count, 0) as patient_count,
+			la.last_activity_at
+		`).
+		Joins("JOIN roles ro ON u.role_id = ro.id").
+		Joins("LEFT JOIN (?) pc ON pc.user_id = u.id", patientCounts).
+		Joins("LEFT JOIN (?) la ON la.user_id = u.id", lastActivity).
+		Where("ro.name = ? AND u.locality_id = ?", roleName, localityID).
+		Order(caregiverSortOrder[sortBy]).
+		Scan(&caregivers).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener carga de apoderados: %w", err)
+	}
+	return caregivers, nil
+}
+
 // GetAll obtiene todos los usuarios con sus relaciones, opcionalmente filtrados por localidad
 func (r *userRepository) GetAll(ctx context.Context, localityID *uuid.UUID) ([]*domain.User, error) {
 	var users []*domain.User
@@ -139,19 +200,47 @@ func (r *userRepository) GetAll(ctx context.Context, localityID *uuid.UUID) ([]*
 	return users, nil
 }
 
-// Update actualiza un usuario existente
+// Update actualiza un usuario existente. Si cambia la localidad, las mediciones de sus pacientes quedan
+// registradas como trasladadas
 func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
-	result := r.db.WithContext(ctx).Save(user)
-	if result.Error != nil {
-		return fmt.Errorf("error al actualizar usuario: %w", result.Error)
-	}
-	if result.RowsAffected == 0 {
-		return domain.ErrUserNotFound
-	}
-	return nil
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := recordMeasurementRelocations(tx, user.LocalityID, "p.user_id = ?", user.ID); err != nil {
+			return err
+		}
+		result := tx.Save(user)
+		if result.Error != nil {
+			return fmt.Errorf("error al actualizar usuario: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrUserNotFound
+		}
+		return nil
+	})
+}
+
+// UpdateLocality cambia solo la localidad del usuario (Save restauraría la localidad precargada) y
+// registra como trasladadas las mediciones de sus pacientes
+func (r *userRepository) UpdateLocality(ctx context.Context, id uuid.UUID, localityID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := recordMeasurementRelocations(tx, &localityID, "p.user_id = ?", id); err != nil {
+			return err
+		}
+		result := tx.Model(&domain.User{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{
+				"locality_id": localityID,
+				"updated_at":  time.Now(),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("error al actualizar localidad del usuario: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrUserNotFound
+		}
+		return nil
+	})
 }
 
-// Delete elimina un usuario por su ID
 func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	result := r.db.WithContext(ctx).Delete(&domain.User{}, "id = ?", id)
 	if result.Error != nil {
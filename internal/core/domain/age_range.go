@@ -0,0 +1,76 @@
+package domain
+
+import "fmt"
+
+// ============= RANGO DE EDAD PERMITIDO =============
+const (
+	DefaultMinAgeMonths = 6  // La cinta MUAC no aplica a menores de 6 meses
+	DefaultMaxAgeMonths = 59 // Hasta antes de cumplir 5 años
+)
+
+// AgeRange representa el rango de edad (en meses) admitido por el sistema
+type AgeRange struct {
+	MinMonths int `json:"min_months"`
+	MaxMonths int `json:"max_months"`
+}
+
+// PatientAgeRange es el rango vigente; se configura al iniciar la aplicación
+var PatientAgeRange = AgeRange{
+	MinMonths: DefaultMinAgeMonths,
+	MaxMonths: DefaultMaxAgeMonths,
+}
+
+// SetPatientAgeRange actualiza el rango vigente, ignorando valores incoherentes
+func SetPatientAgeRange(minMonths, maxMonths int) {
+	if minMonths < 0 || maxMonths <= 0 || minMonths > maxMonths {
+		return
+	}
+	PatientAgeRange = AgeRange{MinMonths: minMonths, MaxMonths: maxMonths}
+}
+
+// ContainsYears indica si una edad expresada en años cae dentro del rango
+func (r AgeRange) ContainsYears(ageYears float64) bool {
+	months := ageYears * 12
+	// Se admite todo el mes MaxMonths (p.ej. 59 meses y días)
+	return months >= float64(r.MinMonths) && months < float64(r.MaxMonths+1)
+}
+
+// ContainsMonths indica si una edad en meses cumplidos cae dentro del rango
+func (r AgeRange) ContainsMonths(months int) bool {
+	return months >= r.MinMonths && months <= r.MaxMonths
+}
+
+// MinYears devuelve el límite inferior en años
+func (r AgeRange) MinYears() float64 {
+	return float64(r.MinMonths) / 12
+}
+
+// MaxYears devuelve el límite superior (exclusivo) en años
+func (r AgeRange) MaxYears() float64 {
+	return float64(r.MaxMonths+1) / 12
+}
+
+// Describe devuelve el rango en texto legible para mensajes y FAQs
+func (r AgeRange) Describe() string {
+	return fmt.Sprintf("entre %d y %d meses", r.MinMonths, r.MaxMonths)
+}
+
+// ValidateAgeYears valida una edad en años contra el rango vigente
+func ValidateAgeYears(ageYears float64) error {
+	if !PatientAgeRange.ContainsYears(ageYears) {
+		return ageOutOfRange()
+	}
+	return nil
+}
+
+// ValidateAgeMonths valida una edad en meses cumplidos contra el rango vigente
+func ValidateAgeMonths(months int) error {
+	if !PatientAgeRange.ContainsMonths(months) {
+		return ageOutOfRange()
+	}
+	return nil
+}
+
+func ageOutOfRange() error {
+	return fmt.Errorf("%w: la edad debe estar %s", ErrPatientAgeOutOfRange, PatientAgeRange.Describe())
+}
@@ -0,0 +1,372 @@
+package domain
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Tablas OMS de peso para la longitud (niños medidos acostados) y para la talla (de pie)
+const (
+	WFHTableLength = "length"
+	WFHTableHeight = "height"
+)
+
+// Cortes del z-score de peso para la talla (WHZ) según la OMS
+const (
+	WHZThresholdSevere   = -3.0 // < -3 = emaciación severa
+	WHZThresholdModerate = -2.0 // -3 a -2 = emaciación moderada
+)
+
+const (
+	// wfhLengthMaxMonths es la edad desde la que se usa la tabla de talla en lugar de la de longitud
+	wfhLengthMaxMonths = 24
+	// wfhLengthMaxCm es la medida bajo la cual se usa la tabla de longitud si no se conoce la edad
+	wfhLengthMaxCm = 87.0
+	// wfhMaxMonths es la última edad cubierta por las tablas OMS de peso para la talla
+	wfhMaxMonths = 59
+)
+
+// Rangos plausibles del peso (kg) y la talla (cm) registrados de un niño menor de 5 años
+const (
+	MinPatientWeightKg = 0.5
+	MaxPatientWeightKg = 60
+	MinPatientHeightCm = 30
+	MaxPatientHeightCm = 130
+)
+
+// Discrepancias entre la clasificación por MUAC y por WHZ
+const (
+	DiscrepancyWHZMoreSevere  = "whz_more_severe"  // El MUAC no detecta la emaciación que muestra el WHZ
+	DiscrepancyMuacMoreSevere = "muac_more_severe" // El MUAC indica más riesgo que el WHZ
+)
+
+// WeightForHeightReference son las tablas OMS de peso para la longitud y para la talla, por tabla
+// (WFHTableLength o WFHTableHeight), sexo y medida en décimas de centímetro
+type WeightForHeightReference map[string]map[string]map[int]GrowthLMS
+
+// WHOWeightForHeightReference es la referencia vigente; vacía hasta que se carga al iniciar la aplicación
+var WHOWeightForHeightReference = WeightForHeightReference{}
+
+// SetWeightForHeightReference reemplaza la referencia vigente
+func SetWeightForHeightReference(reference WeightForHeightReference) {
+	WHOWeightForHeightReference = reference
+}
+
+// Available indica si la referencia tiene alguna tabla para el sexo
+func (r WeightForHeightReference) Available(sex string) bool {
+	return len(r[WFHTableLength][sex]) > 0 || len(r[WFHTableHeight][sex]) > 0
+}
+
+// Lookup devuelve los parámetros LMS de la tabla para el sexo y la medida, redondeada a 0,1 cm
+func (r WeightForHeightReference) Lookup(table, sex string, heightCm float64) (GrowthLMS, bool) {
+	lms, ok := r[table][sex][int(math.Round(heightCm*10))]
+	return lms, ok
+}
+
+// ParseWeightForHeightReference agrega a reference las tablas OMS de peso para la longitud o la talla,
+// separadas por comas o tabuladores con cabecera sex,length,l,m,s o sex,height,l,m,s (como wflanthro.txt
+// y wfhanthro.txt del paquete igrowup). Si hay columna lorh (L o H) define la tabla de cada fila; si no,
+// la define el nombre de la columna de medida. El sexo es 1 (niño) o 2 (niña)
+func ParseWeightForHeightReference(r io.Reader, reference WeightForHeightReference) error {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("error al leer la referencia OMS de peso para la talla: %w", err)
+	}
+	reader := newReferenceReader(string(content))
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("error al leer la cabecera de la referencia OMS de peso para la talla: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	table := WFHTableLength
+	measureColumn, ok := columns["length"]
+	if !ok {
+		if measureColumn, ok = columns["height"]; !ok {
+			return fmt.Errorf("la referencia OMS de peso para la talla debe tener la columna length o height")
+		}
+		table = WFHTableHeight
+	}
+	for _, name := range []string{"sex", "l", "m", "s"} {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("la referencia OMS de peso para la talla debe tener la columna %s", name)
+		}
+	}
+	lorhColumn, hasLorh := columns["lorh"]
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error al leer la referencia OMS de peso para la talla (línea %d): %w", line, err)
+		}
+
+		values := make(map[string]float64, 5)
+		for _, name := range []string{"sex", "l", "m", "s"} {
+			if values[name], err = parseReferenceValue(record, columns[name]); err != nil {
+				return fmt.Errorf("referencia OMS de peso para la talla línea %d, columna %s: %w", line, name, err)
+			}
+		}
+		measure, err := parseReferenceValue(record, measureColumn)
+		if err != nil {
+			return fmt.Errorf("referencia OMS de peso para la talla línea %d, medida: %w", line, err)
+		}
+
+		rowTable := table
+		if hasLorh && lorhColumn < len(record) {
+			switch strings.ToUpper(strings.TrimSpace(record[lorhColumn])) {
+			case "L":
+				rowTable = WFHTableLength
+			case "H":
+				rowTable = WFHTableHeight
+			}
+		}
+
+		var sex string
+		switch int(values["sex"]) {
+		case 1:
+			sex = GrowthSexMale
+		case 2:
+			sex = GrowthSexFemale
+		default:
+			return fmt.Errorf("referencia OMS de peso para la talla línea %d: sexo %v desconocido", line, values["sex"])
+		}
+		if values["m"] <= 0 || values["s"] <= 0 {
+			return fmt.Errorf("referencia OMS de peso para la talla línea %d: M y S deben ser positivos", line)
+		}
+		if reference[rowTable] == nil {
+			reference[rowTable] = make(map[string]map[int]GrowthLMS)
+		}
+		if reference[rowTable][sex] == nil {
+			reference[rowTable][sex] = make(map[int]GrowthLMS)
+		}
+		reference[rowTable][sex][int(math.Round(measure*10))] = GrowthLMS{L: values["l"], M: values["m"], S: values["s"]}
+	}
+	return nil
+}
+
+// RestrictedZScore calcula el z-score como la OMS para los indicadores de peso: más allá de ±3 no se
+// usa la curva LMS sino la distancia entre las curvas de ±2 y ±3, para no exagerar los valores extremos
+func (p GrowthLMS) RestrictedZScore(value float64) float64 {
+	z := p.ZScore(value)
+	switch {
+	case z > 3:
+		sd3 := p.ValueAt(3)
+		return 3 + (value-sd3)/(sd3-p.ValueAt(2))
+	case z < -3:
+		sd3 := p.ValueAt(-3)
+		return -3 + (value-sd3)/(p.ValueAt(-2)-sd3)
+	}
+	return z
+}
+
+// ClassifyWHZ clasifica un z-score de peso para la talla con los mismos códigos que el MUAC
+func ClassifyWHZ(z float64) string {
+	switch {
+	case z < WHZThresholdSevere:
+		return MuacCodeRed
+	case z < WHZThresholdModerate:
+		return MuacCodeYellow
+	default:
+		return MuacCodeGreen
+	}
+}
+
+// splitMeasure separa el número y la unidad de textos como "12,5 kg", "12.5kg" o "85 cm"; acepta coma
+// o punto decimal
+func splitMeasure(text string) (float64, string, bool) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	end := strings.IndexFunc(text, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.' && r != ','
+	})
+	number, unit := text, ""
+	if end >= 0 {
+		number, unit = text[:end], strings.TrimSpace(text[end:])
+	}
+	if strings.Count(number, ".")+strings.Count(number, ",") > 1 {
+		return 0, "", false
+	}
+	value, err := strconv.ParseFloat(strings.Replace(number, ",", ".", 1), 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return value, strings.TrimSuffix(unit, "."), true
+}
+
+// ParseWeightKg interpreta el peso registrado del paciente en kilogramos. Acepta kg o gramos; sin
+// unidad, los valores desde 500 se toman como gramos. Fuera del rango plausible devuelve false
+func ParseWeightKg(text string) (float64, bool) {
+	value, unit, ok := splitMeasure(text)
+	if !ok {
+		return 0, false
+	}
+	switch unit {
+	case "", "kg", "kgs", "k", "kilo", "kilos", "kilogramo", "kilogramos":
+		if unit == "" && value >= 500 {
+			value /= 1000
+		}
+	case "g", "gr", "grs", "gramo", "gramos":
+		value /= 1000
+	default:
+		return 0, false
+	}
+	if value < MinPatientWeightKg || value > MaxPatientWeightKg {
+		return 0, false
+	}
+	return math.Round(value*1000) / 1000, true
+}
+
+// ParseHeightCm interpreta la talla registrada del paciente (campo size) en centímetros. Acepta cm,
+// metros o milímetros; sin unidad, los valores menores a 3 se toman como metros. Fuera del rango
+// plausible devuelve false
+func ParseHeightCm(text string) (float64, bool) {
+	value, unit, ok := splitMeasure(text)
+	if !ok {
+		return 0, false
+	}
+	switch unit {
+	case "", "cm", "cms", "centimetro", "centimetros", "centímetro", "centímetros":
+		if unit == "" && value < 3 {
+			value *= 100
+		}
+	case "m", "mt", "mts", "metro", "metros":
+		value *= 100
+	case "mm":
+		value /= 10
+	default:
+		return 0, false
+	}
+	if value < MinPatientHeightCm || value > MaxPatientHeightCm {
+		return 0, false
+	}
+	return math.Round(value*10) / 10, true
+}
+
+// AnthropometryMuac es la última medición MUAC del paciente, con su clasificación por valor
+type AnthropometryMuac struct {
+	MeasurementID uuid.UUID `json:"measurement_id"`
+	Value         float64   `json:"value"`
+	MuacCode      string    `json:"muac_code"`
+	MeasuredAt    time.Time `json:"measured_at"`
+}
+
+// AnthropometryCheck contrasta el peso para la talla del paciente con su clasificación MUAC
+type AnthropometryCheck struct {
+	PatientID          uuid.UUID          `json:"patient_id"`
+	Sex                string             `json:"sex"`        // Vacío si el género registrado no se reconoce
+	AgeMonths          *int               `json:"age_months"` // nil sin fecha de nacimiento válida
+	Weight             string             `json:"weight"`     // Tal como se registró
+	Height             string             `json:"height"`     // Campo size, tal como se registró
+	WeightKg           *float64           `json:"weight_kg"`
+	HeightCm           *float64           `json:"height_cm"`
+	BMI                *float64           `json:"bmi"` // kg/m²
+	ReferenceAvailable bool               `json:"reference_available"`
+	WHZTable           string             `json:"whz_table,omitempty"` // length (acostado) o height (de pie)
+	WHZ                *float64           `json:"whz"`
+	WHZCode            string             `json:"whz_code,omitempty"` // Mismos códigos que el MUAC
+	Muac               *AnthropometryMuac `json:"muac"`               // nil sin mediciones
+	Discrepancy        bool               `json:"discrepancy"`
+	DiscrepancyType    string             `json:"discrepancy_type,omitempty"` // whz_more_severe o muac_more_severe
+	Issues             []string           `json:"issues"`                     // Por qué no se pudo calcular algún indicador
+	CheckedAt          time.Time          `json:"checked_at"`
+}
+
+// NewAnthropometryCheck calcula el IMC y el z-score de peso para la talla (WHZ) del paciente con las
+// tablas OMS y los compara con la clasificación de su última medición MUAC. Antes de los 24 meses se
+// usa la tabla de longitud y después la de talla; sin edad, la de longitud bajo 87 cm. El peso y la
+// talla del paciente no tienen fecha: se asume que están al día con la última medición
+func NewAnthropometryCheck(patient *Patient, latest *Measurement, reference WeightForHeightReference, now time.Time) *AnthropometryCheck {
+	check := &AnthropometryCheck{
+		PatientID: patient.ID,
+		Sex:       GrowthSex(patient.Gender),
+		Weight:    patient.Weight,
+		Height:    patient.Size,
+		Issues:    []string{},
+		CheckedAt: now,
+	}
+	check.ReferenceAvailable = check.Sex != "" && reference.Available(check.Sex)
+
+	if latest != nil {
+		code, _, _ := ClassifyMuacValue(latest.MuacValue)
+		check.Muac = &AnthropometryMuac{
+			MeasurementID: latest.ID,
+			Value:         latest.MuacValue,
+			MuacCode:      code,
+			MeasuredAt:    latest.CreatedAt,
+		}
+	} else {
+		check.Issues = append(check.Issues, "el paciente no tiene mediciones MUAC")
+	}
+
+	if birthDate, ok := ParseBirthDate(patient.BirthDate); ok {
+		if months := AgeInMonths(birthDate, now); months >= 0 {
+			check.AgeMonths = &months
+		}
+	}
+
+	weight, weightOK := ParseWeightKg(patient.Weight)
+	if weightOK {
+		check.WeightKg = &weight
+	} else {
+		check.Issues = append(check.Issues, "peso vacío, ilegible o fuera de rango")
+	}
+	height, heightOK := ParseHeightCm(patient.Size)
+	if heightOK {
+		check.HeightCm = &height
+	} else {
+		check.Issues = append(check.Issues, "talla vacía, ilegible o fuera de rango")
+	}
+	if !weightOK || !heightOK {
+		return check
+	}
+	bmi := math.Round(weight/math.Pow(height/100, 2)*10) / 10
+	check.BMI = &bmi
+
+	switch {
+	case check.Sex == "":
+		check.Issues = append(check.Issues, "género no reconocido: no se puede elegir la tabla OMS")
+		return check
+	case !check.ReferenceAvailable:
+		check.Issues = append(check.Issues, "referencia OMS de peso para la talla no configurada")
+		return check
+	case check.AgeMonths != nil && *check.AgeMonths > wfhMaxMonths:
+		check.Issues = append(check.Issues, "el WHZ solo se calcula hasta los 59 meses")
+		return check
+	}
+
+	table := WFHTableHeight
+	if (check.AgeMonths != nil && *check.AgeMonths < wfhLengthMaxMonths) || (check.AgeMonths == nil && height < wfhLengthMaxCm) {
+		table = WFHTableLength
+	}
+	lms, ok := reference.Lookup(table, check.Sex, height)
+	if !ok {
+		check.Issues = append(check.Issues, "talla fuera del rango de la tabla OMS")
+		return check
+	}
+	whz := math.Round(lms.RestrictedZScore(weight)*100) / 100
+	check.WHZTable = table
+	check.WHZ = &whz
+	check.WHZCode = ClassifyWHZ(whz)
+
+	if check.Muac != nil && check.Muac.MuacCode != check.WHZCode {
+		check.Discrepancy = true
+		if muacCodePriority(check.WHZCode) > muacCodePriority(check.Muac.MuacCode) {
+			check.DiscrepancyType = DiscrepancyWHZMoreSevere
+		} else {
+			check.DiscrepancyType = DiscrepancyMuacMoreSevere
+		}
+	}
+	return check
+}
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Entidades registradas en la auditoría
+const (
+	AuditEntityPatient     = "patient"
+	AuditEntityMeasurement = "measurement"
+	AuditEntityMaintenance = "maintenance"
+	AuditEntityUser        = "user"
+)
+
+// Acciones registradas en la auditoría
+const (
+	AuditActionUpdate      = "update"
+	AuditActionDelete      = "delete"
+	AuditActionConsent     = "consent"
+	AuditActionToggle      = "toggle"
+	AuditActionApproval    = "approval"
+	AuditActionRecompute   = "recompute"
+	AuditActionAgeOverride = "age_override"
+)
+
+// AuditDateLayout es el formato de start_date y end_date en las consultas de auditoría
+const AuditDateLayout = "2006-01-02"
+
+var auditEntities = map[string]bool{
+	AuditEntityPatient:     true,
+	AuditEntityMeasurement: true,
+	AuditEntityMaintenance: true,
+	AuditEntityUser:        true,
+}
+
+var auditActions = map[string]bool{
+	AuditActionUpdate:      true,
+	AuditActionDelete:      true,
+	AuditActionConsent:     true,
+	AuditActionToggle:      true,
+	AuditActionApproval:    true,
+	AuditActionRecompute:   true,
+	AuditActionAgeOverride: true,
+}
+
+// AuditEntry registra quién cambió qué y cuándo
+type AuditEntry struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	Entity    string     `json:"entity" gorm:"type:varchar(50);not null;index:idx_audit_entity"`
+	EntityID  *uuid.UUID `json:"entity_id,omitempty" gorm:"type:uuid;index:idx_audit_entity"`
+	Action    string     `json:"action" gorm:"type:varchar(50);not null"`
+	ActorID   *uuid.UUID `json:"actor_id,omitempty" gorm:"type:uuid;index"`
+	Details   string     `json:"details,omitempty" gorm:"type:text"` // JSON con los datos del cambio
+	CreatedAt time.Time  `json:"created_at" gorm:"not null;index"`
+}
+
+// TableName especifica el nombre de la tabla para GORM
+func (AuditEntry) TableName() string {
+	return "audit_entries"
+}
+
+// NewAuditEntry crea una entrada de auditoría; details se serializa como JSON y puede ser nil
+func NewAuditEntry(entity string, entityID *uuid.UUID, action string, actorID *uuid.UUID, details map[string]interface{}) *AuditEntry {
+	entry := &AuditEntry{
+		ID:        uuid.New(),
+		Entity:    entity,
+		EntityID:  entityID,
+		Action:    action,
+		ActorID:   actorID,
+		CreatedAt: time.Now(),
+	}
+	if len(details) > 0 {
+		if data, err := json.Marshal(details); err == nil {
+			entry.Details = string(data)
+		}
+	}
+	return entry
+}
+
+// AuditFilter delimita la consulta de auditoría; los campos vacíos no filtran.
+// EndDate es exclusivo (inicio del día siguiente a end_date)
+type AuditFilter struct {
+	Entity    string
+	EntityID  *uuid.UUID
+	ActorID   *uuid.UUID
+	Action    string
+	StartDate *time.Time
+	EndDate   *time.Time
+}
+
+// Validate comprueba entidad, acción y el orden de las fechas
+func (f *AuditFilter) Validate() error {
+	if f.Entity != "" && !auditEntities[f.Entity] {
+		return ErrInvalidAuditEntity
+	}
+	if f.Action != "" && !auditActions[f.Action] {
+		return ErrInvalidAuditAction
+	}
+	if f.StartDate != nil && f.EndDate != nil && !f.StartDate.Before(*f.EndDate) {
+		return ErrInvalidAuditDateRange
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Formato de la exportación completa de datos del programa
+const (
+	BackupFormat  = "muac-export"
+	BackupVersion = 1
+)
+
+// UnusablePasswordHash se guarda en los usuarios nuevos importados sin contraseña: la exportación no
+// la incluye, así que no pueden iniciar sesión hasta que se les asigne una con PUT /api/users/{id}/password
+const UnusablePasswordHash = "!"
+
+// BackupImportBatchSize es la cantidad de filas que se leen del archivo antes de validar sus referencias
+// y buscar sus ids existentes en una sola consulta; cada fila se inserta o actualiza después por separado
+const BackupImportBatchSize = 500
+
+// MaxBackupViolations es la cantidad de referencias rotas que se detallan en el resultado
+const MaxBackupViolations = 100
+
+// BackupImportOptions controla una importación
+type BackupImportOptions struct {
+	DryRun bool // Valida e importa dentro de la transacción y la revierte al final
+
+	// AllowSensitiveColumns acepta las columnas sensibles del archivo (hashes de contraseña); si es
+	// false se descartan, porque un archivo de origen no confiable podría fijar contraseñas conocidas
+	AllowSensitiveColumns bool
+}
+
+// BackupReference es una columna que referencia el id de otra entidad
+type BackupReference struct {
+	Column string
+	Entity string
+}
+
+// BackupEntity es una tabla incluida en la exportación
+type BackupEntity struct {
+	Name        string   // Clave en el archivo
+	Table       string   // Tabla de la base de datos
+	OmitColumns []string // Columnas que nunca se exportan
+
+	// Columnas que solo se importan con BackupImportOptions.AllowSensitiveColumns
+	SensitiveColumns []string
+
+	// Valores para las columnas obligatorias que faltan al insertar filas nuevas; no se aplican a las
+	// filas que ya existen
+	ImportDefaults map[string]interface{}
+
+	// References son las columnas que deben apuntar a una fila existente de otra entidad
+	References []BackupReference
+}
+
+// BackupEntities son las entidades exportadas, en orden de dependencia: cada una solo referencia a
+// las anteriores, de modo que la importación puede insertarlas en el mismo orden
+var BackupEntities = []BackupEntity{
+	{Name: "roles", Table: Role{}.TableName()},
+	{Name: "localities", Table: Locality{}.TableName()},
+	{Name: "tags", Table: Tag{}.TableName()},
+	{Name: "recommendations", Table: Recommendation{}.TableName()},
+	{Name: "faqs", Table: FAQ{}.TableName()},
+	{
+		Name:             "users",
+		Table:            User{}.TableName(),
+		OmitColumns:      []string{"password_hash"},
+		SensitiveColumns: []string{"password_hash"},
+		ImportDefaults:   map[string]interface{}{"password_hash": UnusablePasswordHash},
+		References: []BackupReference{
+			{Column: "role_id", Entity: "roles"},
+			{Column: "locality_id", Entity: "localities"},
+		},
+	},
+	{
+		Name:       "patients",
+		Table:      Patient{}.TableName(),
+		References: []BackupReference{{Column: "user_id", Entity: "users"}},
+	},
+	{
+		Name:  "measurements",
+		Table: Measurement{}.TableName(),
+		References: []BackupReference{
+			{Column: "patient_id", Entity: "patients"},
+			{Column: "user_id", Entity: "users"},
+			{Column: "tag_id", Entity: "tags"},
+			{Column: "recommendation_id", Entity: "recommendations"},
+		},
+	},
+}
+
+// BackupEntityIndex devuelve la posición de la entidad en BackupEntities; -1 si no existe
+func BackupEntityIndex(name string) int {
+	for i, entity := range BackupEntities {
+		if entity.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// PrepareImportRow adapta una fila leída del archivo para importarla: descarta las columnas sensibles
+// salvo que se permitan, pasa los números como texto para que la base los convierta al tipo de la
+// columna y los objetos y listas como JSON. El id es obligatorio y, como las referencias, debe ser un UUID
+func (e BackupEntity) PrepareImportRow(row map[string]interface{}, allowSensitive bool) error {
+	if !allowSensitive {
+		for _, column := range e.SensitiveColumns {
+			delete(row, column)
+		}
+	}
+	for column, value := range row {
+		switch v := value.(type) {
+		case json.Number:
+			row[column] = v.String()
+		case map[string]interface{}, []interface{}:
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			row[column] = string(encoded)
+		}
+	}
+
+	id, _ := row["id"].(string)
+	if _, err := uuid.Parse(id); err != nil {
+		return fmt.Errorf("%w: fila de %s sin id válido", ErrInvalidBackup, e.Name)
+	}
+	id = strings.ToLower(id)
+	row["id"] = id
+	for _, ref := range e.References {
+		value, ok := row[ref.Column]
+		if !ok || value == nil {
+			continue
+		}
+		refID, _ := value.(string)
+		if _, err := uuid.Parse(refID); err != nil {
+			return fmt.Errorf("%w: %s.%s no es un UUID en la fila %s", ErrInvalidBackup, e.Name, ref.Column, id)
+		}
+	}
+	return nil
+}
+
+// ReferencedID devuelve el id al que apunta la columna de la fila; vacío si es nula
+func (r BackupReference) ReferencedID(row map[string]interface{}) string {
+	id, _ := row[r.Column].(string)
+	return strings.ToLower(id)
+}
+
+// BackupHeader son los metadatos al inicio del archivo de exportación
+type BackupHeader struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+// BackupEntityResult es el resultado de importar una entidad
+type BackupEntityResult struct {
+	Entity   string `json:"entity"`
+	Read     int64  `json:"read"`
+	Inserted int64  `json:"inserted"`
+	Updated  int64  `json:"updated"`  // Ya existían con el mismo ID y se sobrescribieron
+	Rejected int64  `json:"rejected"` // Con referencias rotas; no se importan
+}
+
+// BackupViolation es una referencia del archivo que no apunta a ninguna fila existente ni importada
+type BackupViolation struct {
+	Entity           string `json:"entity"`
+	RowID            string `json:"row_id"`
+	Column           string `json:"column"`
+	ReferencedEntity string `json:"referenced_entity"`
+	MissingID        string `json:"missing_id"`
+}
+
+// BackupImportResult resume una importación. Solo se guarda si Committed es true: con dry_run o con
+// referencias rotas la transacción se revierte
+type BackupImportResult struct {
+	DryRun          bool                 `json:"dry_run"`
+	Committed       bool                 `json:"committed"`
+	Entities        []BackupEntityResult `json:"entities"`
+	TotalViolations int64                `json:"total_violations"`
+	Violations      []BackupViolation    `json:"violations"` // Hasta MaxBackupViolations
+	ImportedAt      time.Time            `json:"imported_at"`
+}
+
+// AddViolation registra una referencia rota, guardando el detalle de las primeras MaxBackupViolations
+func (r *BackupImportResult) AddViolation(violation BackupViolation) {
+	r.TotalViolations++
+	if len(r.Violations) < MaxBackupViolations {
+		r.Violations = append(r.Violations, violation)
+	}
+}
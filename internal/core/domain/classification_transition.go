@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TransitionMeasurement es una medición con la medición anterior del mismo paciente; la anterior
+// se busca en todo el historial, aunque quede fuera del rango exportado
+type TransitionMeasurement struct {
+	MeasurementID         uuid.UUID
+	PatientID             uuid.UUID
+	PatientName           string
+	LocalityName          string
+	MuacValue             float64
+	MeasuredAt            time.Time
+	PreviousMeasurementID *uuid.UUID
+	PreviousValue         *float64
+	PreviousMeasuredAt    *time.Time
+}
+
+// ClassificationTransition es un cambio de clasificación MUAC entre dos mediciones seguidas de un paciente
+type ClassificationTransition struct {
+	PatientID             uuid.UUID
+	PatientName           string
+	LocalityName          string
+	PreviousMeasurementID uuid.UUID
+	MeasurementID         uuid.UUID
+	PreviousCode          string
+	Code                  string
+	PreviousValue         float64
+	Value                 float64
+	PreviousMeasuredAt    time.Time
+	MeasuredAt            time.Time
+	Direction             string // MEJORA o DETERIORO
+}
+
+// Transition devuelve el cambio de clasificación respecto de la medición anterior; false si es la
+// primera medición del paciente o si conserva la clasificación
+func (m TransitionMeasurement) Transition() (ClassificationTransition, bool) {
+	if m.PreviousMeasurementID == nil || m.PreviousValue == nil || m.PreviousMeasuredAt == nil {
+		return ClassificationTransition{}, false
+	}
+
+	direction := ClassificationTrend(*m.PreviousValue, m.MuacValue)
+	if direction == SimulationNoChange {
+		return ClassificationTransition{}, false
+	}
+
+	previousCode, _, _ := ClassifyMuacValue(*m.PreviousValue)
+	code, _, _ := ClassifyMuacValue(m.MuacValue)
+	return ClassificationTransition{
+		PatientID:             m.PatientID,
+		PatientName:           m.PatientName,
+		LocalityName:          m.LocalityName,
+		PreviousMeasurementID: *m.PreviousMeasurementID,
+		MeasurementID:         m.MeasurementID,
+		PreviousCode:          previousCode,
+		Code:                  code,
+		PreviousValue:         *m.PreviousValue,
+		Value:                 m.MuacValue,
+		PreviousMeasuredAt:    *m.PreviousMeasuredAt,
+		MeasuredAt:            m.MeasuredAt,
+		Direction:             direction,
+	}, true
+}
+
+// ClassificationTransitionHeader son las columnas de la exportación de transiciones, en el orden de Record
+var ClassificationTransitionHeader = []string{
+	"patient_id", "patient_name", "locality",
+	"previous_measurement_id", "measurement_id",
+	"previous_muac_code", "muac_code", "previous_muac", "muac",
+	"previous_measured_at", "measured_at", "days_between", "direction",
+}
+
+// Record devuelve la transición como texto
+func (t ClassificationTransition) Record() []string {
+	days := t.MeasuredAt.Sub(t.PreviousMeasuredAt).Hours() / 24
+	return []string{
+		t.PatientID.String(), t.PatientName, t.LocalityName,
+		t.PreviousMeasurementID.String(), t.MeasurementID.String(),
+		t.PreviousCode, t.Code,
+		strconv.FormatFloat(t.PreviousValue, 'f', 1, 64), strconv.FormatFloat(t.Value, 'f', 1, 64),
+		t.PreviousMeasuredAt.Format(time.RFC3339), t.MeasuredAt.Format(time.RFC3339),
+		strconv.FormatFloat(days, 'f', 1, 64), t.Direction,
+	}
+}
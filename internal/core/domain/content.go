@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// MuacMapping son los campos MUAC que se asignan a un tag o recomendación según su nombre
+type MuacMapping struct {
+	MuacCode string
+	Color    string
+	Priority int
+}
+
+// TagMuacMappings asigna el código MUAC a los tags del sistema por nombre exacto
+var TagMuacMappings = map[string]MuacMapping{
+	"MUAC-R1":     {MuacCode: MuacCodeRed, Color: ColorRed, Priority: PriorityExtreme},
+	"MUAC-Y1":     {MuacCode: MuacCodeYellow, Color: ColorYellow, Priority: PriorityHigh},
+	"MUAC-G1":     {MuacCode: MuacCodeGreen, Color: ColorGreen, Priority: PriorityLow},
+	"SEGUIMIENTO": {MuacCode: MuacCodeFollow, Color: ColorBlue, Priority: PriorityMedium},
+}
+
+// RecommendationMuacPatterns asigna el código MUAC a las recomendaciones cuyo nombre contiene
+// el patrón; se aplican en orden y, si coinciden varios, prevalece el último
+var RecommendationMuacPatterns = []struct {
+	Pattern string
+	Mapping MuacMapping
+}{
+	{"ALERTA ROJA", MuacMapping{MuacCode: MuacCodeRed, Color: ColorRed, Priority: PriorityUrgent}},
+	{"ALERTA AMARILLA", MuacMapping{MuacCode: MuacCodeYellow, Color: ColorYellow, Priority: PriorityAttention}},
+	{"ZONA VERDE", MuacMapping{MuacCode: MuacCodeGreen, Color: ColorGreen, Priority: PriorityNormal}},
+	{"Seguimiento", MuacMapping{MuacCode: MuacCodeFollow, Color: ColorBlue, Priority: PriorityAttention}},
+}
+
+// InferTagMuacMapping obtiene los campos MUAC de un tag a partir de su nombre
+func InferTagMuacMapping(name string) (MuacMapping, bool) {
+	mapping, ok := TagMuacMappings[strings.TrimSpace(name)]
+	return mapping, ok
+}
+
+// InferRecommendationMuacMapping obtiene los campos MUAC de una recomendación a partir de su nombre
+func InferRecommendationMuacMapping(name string) (MuacMapping, bool) {
+	var mapping MuacMapping
+	found := false
+	for _, p := range RecommendationMuacPatterns {
+		if strings.Contains(name, p.Pattern) {
+			mapping, found = p.Mapping, true
+		}
+	}
+	return mapping, found
+}
+
+// UnmappedContent son los tags y recomendaciones activos sin código MUAC
+type UnmappedContent struct {
+	Total           int               `json:"total"`
+	Tags            []*Tag            `json:"tags"`
+	Recommendations []*Recommendation `json:"recommendations"`
+}
+
+// Tipos de contenido en la reasignación de códigos MUAC
+const (
+	ContentTypeTag            = "tag"
+	ContentTypeRecommendation = "recommendation"
+)
+
+// ContentRemapItem es un tag o recomendación revisado al reasignar códigos MUAC
+type ContentRemapItem struct {
+	Type     string    `json:"type"`
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	MuacCode string    `json:"muac_code,omitempty"` // Vacío si no se pudo inferir del nombre
+}
+
+// ContentRemap es el resultado de reasignar códigos MUAC al contenido sin mapear
+type ContentRemap struct {
+	DryRun          bool               `json:"dry_run"`
+	Tags            int64              `json:"tags"`            // Tags corregidos (o que se corregirían en dry run)
+	Recommendations int64              `json:"recommendations"` // Recomendaciones corregidas
+	Unmatched       int64              `json:"unmatched"`       // Sin código inferible por nombre
+	Items           []ContentRemapItem `json:"items"`
+}
+
+// UnusedTags son las etiquetas activas sin mediciones; Protected son las de códigos MUAC base,
+// que nunca se eliminan aunque no tengan mediciones
+type UnusedTags struct {
+	Tags      []*Tag `json:"tags"`
+	Protected []*Tag `json:"protected"`
+}
+
+// UnusedTagCleanup es el resultado de eliminar las etiquetas sin mediciones
+type UnusedTagCleanup struct {
+	Deleted   int64  `json:"deleted"`
+	Tags      []*Tag `json:"tags"`      // Etiquetas eliminadas
+	Protected []*Tag `json:"protected"` // Etiquetas base omitidas
+}
+
+// SplitUnusedTags separa las etiquetas base de MUAC del resto
+func SplitUnusedTags(tags []*Tag) *UnusedTags {
+	unused := &UnusedTags{Tags: make([]*Tag, 0, len(tags)), Protected: make([]*Tag, 0)}
+	for _, tag := range tags {
+		if tag.IsCoreMuacTag() {
+			unused.Protected = append(unused.Protected, tag)
+			continue
+		}
+		unused.Tags = append(unused.Tags, tag)
+	}
+	return unused
+}
@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Límites por defecto de la región del programa (departamento de Madre de Dios)
+const (
+	DefaultRegionMinLatitude  = -13.5
+	DefaultRegionMaxLatitude  = -9.5
+	DefaultRegionMinLongitude = -72.5
+	DefaultRegionMaxLongitude = -68.5
+)
+
+// RegionBounds es el rectángulo (bounding box) donde se esperan las localidades del programa
+type RegionBounds struct {
+	MinLatitude  float64 `json:"min_latitude"`
+	MaxLatitude  float64 `json:"max_latitude"`
+	MinLongitude float64 `json:"min_longitude"`
+	MaxLongitude float64 `json:"max_longitude"`
+}
+
+// ProgramRegion es la región vigente; se configura al iniciar la aplicación
+var ProgramRegion = RegionBounds{
+	MinLatitude:  DefaultRegionMinLatitude,
+	MaxLatitude:  DefaultRegionMaxLatitude,
+	MinLongitude: DefaultRegionMinLongitude,
+	MaxLongitude: DefaultRegionMaxLongitude,
+}
+
+// SetProgramRegion actualiza la región vigente, ignorando límites incoherentes
+func SetProgramRegion(bounds RegionBounds) {
+	if !validLatitude(bounds.MinLatitude) || !validLatitude(bounds.MaxLatitude) ||
+		!validLongitude(bounds.MinLongitude) || !validLongitude(bounds.MaxLongitude) ||
+		bounds.MinLatitude >= bounds.MaxLatitude || bounds.MinLongitude >= bounds.MaxLongitude {
+		return
+	}
+	ProgramRegion = bounds
+}
+
+// Contains indica si el punto cae dentro de la región (bordes incluidos)
+func (b RegionBounds) Contains(lat, lng float64) bool {
+	return lat >= b.MinLatitude && lat <= b.MaxLatitude &&
+		lng >= b.MinLongitude && lng <= b.MaxLongitude
+}
+
+// CoordinateValidation es el resultado de validar y normalizar un par latitud/longitud
+type CoordinateValidation struct {
+	Latitude        *float64     `json:"latitude"`
+	Longitude       *float64     `json:"longitude"`
+	Valid           bool         `json:"valid"`            // Ambos valores se leyeron y están en rango
+	WithinRegion    bool         `json:"within_region"`    // Además cae dentro de la región del programa
+	PossiblySwapped bool         `json:"possibly_swapped"` // Intercambiando lat/lng sí cae en la región
+	Errors          []string     `json:"errors"`
+	Region          RegionBounds `json:"region"`
+}
+
+// ValidateCoordinates lee latitud y longitud en texto (admite coma decimal), comprueba
+// los rangos geográficos y si el punto cae dentro de ProgramRegion
+func ValidateCoordinates(latitude, longitude string) CoordinateValidation {
+	result := CoordinateValidation{Errors: []string{}, Region: ProgramRegion}
+
+	lat, latErr := parseCoordinate(latitude)
+	switch {
+	case latErr != nil:
+		result.Errors = append(result.Errors, "latitud: "+latErr.Error())
+	case !validLatitude(lat):
+		result.Errors = append(result.Errors, "latitud: debe estar entre -90 y 90")
+	default:
+		result.Latitude = &lat
+	}
+
+	lng, lngErr := parseCoordinate(longitude)
+	switch {
+	case lngErr != nil:
+		result.Errors = append(result.Errors, "longitud: "+lngErr.Error())
+	case !validLongitude(lng):
+		result.Errors = append(result.Errors, "longitud: debe estar entre -180 y 180")
+	default:
+		result.Longitude = &lng
+	}
+
+	if result.Latitude == nil || result.Longitude == nil {
+		return result
+	}
+
+	result.Valid = true
+	result.WithinRegion = ProgramRegion.Contains(lat, lng)
+	if !result.WithinRegion {
+		result.PossiblySwapped = ProgramRegion.Contains(lng, lat)
+		if result.PossiblySwapped {
+			result.Errors = append(result.Errors, "las coordenadas parecen invertidas (latitud y longitud intercambiadas)")
+		} else {
+			result.Errors = append(result.Errors, "las coordenadas están fuera de la región del programa")
+		}
+	}
+	return result
+}
+
+// Err devuelve el error de dominio que corresponde al resultado, o nil si es aceptable
+func (v CoordinateValidation) Err() error {
+	switch {
+	case !v.Valid:
+		return ErrInvalidCoordinates
+	case !v.WithinRegion:
+		return ErrCoordinatesOutsideRegion
+	}
+	return nil
+}
+
+// FormatCoordinate devuelve la representación normalizada que se guarda en la localidad
+func FormatCoordinate(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func parseCoordinate(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, ErrEmptyLocalityLocation
+	}
+	parsed, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	if err != nil {
+		return 0, ErrInvalidCoordinateFormat
+	}
+	return parsed, nil
+}
+
+func validLatitude(value float64) bool {
+	return value >= -90 && value <= 90
+}
+
+func validLongitude(value float64) bool {
+	return value >= -180 && value <= 180
+}
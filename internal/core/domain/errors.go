@@ -9,15 +9,37 @@ var (
 	ErrRoleNotFound  = errors.New("rol no encontrado")
 
 	// Locality errors
-	ErrEmptyLocalityName     = errors.New("el nombre de la localidad no puede estar vacío")
-	ErrEmptyLocalityLocation = errors.New("la ubicación de la localidad no puede estar vacía")
-	ErrLocalityNotFound      = errors.New("localidad no encontrada")
+	ErrEmptyLocalityName        = errors.New("el nombre de la localidad no puede estar vacío")
+	ErrEmptyLocalityLocation    = errors.New("la ubicación de la localidad no puede estar vacía")
+	ErrLocalityNotFound         = errors.New("localidad no encontrada")
+	ErrInvalidCoordinateFormat  = errors.New("no es un número válido")
+	ErrInvalidCoordinates       = errors.New("las coordenadas de la localidad no son válidas")
+	ErrCoordinatesOutsideRegion = errors.New("las coordenadas de la localidad están fuera de la región del programa")
+	ErrInvalidLocalityTarget    = errors.New("la meta mensual debe estar entre 1 y 100000 mediciones")
+	ErrLocalityTargetNotFound   = errors.New("la localidad no tiene meta mensual")
 
 	// Patient errors
 	ErrEmptyPatientName        = errors.New("el nombre del paciente no puede estar vacío")
 	ErrEmptyPatientLastName    = errors.New("el apellido del paciente no puede estar vacío")
 	ErrPatientDNIAlreadyExists = errors.New("el DNI del paciente ya está registrado")
 	ErrPatientNotFound         = errors.New("paciente no encontrado")
+	ErrGrowthPlotBirthDate     = errors.New("el paciente no tiene una fecha de nacimiento válida para ubicar sus mediciones por edad")
+	ErrRecomputeBirthDate      = errors.New("el paciente no tiene una fecha de nacimiento válida (y no futura) para recalcular la edad")
+	ErrPatientAgeOutOfRange    = errors.New("edad del paciente fuera del rango permitido")
+	ErrEmptyAgeOverrideNote    = errors.New("se requiere una nota de auditoría para omitir la validación de edad")
+	ErrAgeOverrideForbidden    = errors.New("solo un ADMINISTRADOR (cabecera X-User-ID) puede registrar una excepción de edad")
+	ErrInvalidExpand           = errors.New("relación a expandir no permitida")
+	ErrEmptyConsentReason      = errors.New("se requiere un motivo para retirar el consentimiento")
+	ErrPatientConsentWithdrawn = errors.New("la familia retiró el consentimiento; no se pueden registrar mediciones del paciente")
+	ErrPatientExportForbidden  = errors.New("el usuario no puede exportar los pacientes solicitados")
+	ErrInvalidDNIFormat        = errors.New("el DNI debe tener 8 dígitos")
+	ErrInvalidApprovalStatus   = errors.New("estado de aprobación inválido: debe ser approved o rejected")
+	ErrApprovalUnchanged       = errors.New("el paciente ya tiene ese estado de aprobación")
+	ErrEmptyRejectionNote      = errors.New("se requiere una nota para rechazar al paciente")
+	ErrApprovalForbidden       = errors.New("solo un SUPERVISOR o ADMINISTRADOR puede aprobar pacientes")
+	ErrInvalidPatientImport    = errors.New("archivo de importación inválido")
+	ErrEmptyPatientImport      = errors.New("el archivo de importación no tiene filas")
+	ErrTooManyImportRows       = errors.New("el archivo de importación supera el máximo de 1000 filas")
 
 	// Tag errors
 	ErrEmptyTagName = errors.New("el nombre de la etiqueta no puede estar vacío")
@@ -30,20 +52,57 @@ var (
 	ErrEmptyUserEmail    = errors.New("el email del usuario no puede estar vacío")
 	ErrEmptyUserPassword = errors.New("la contraseña del usuario no puede estar vacía")
 	ErrUserNotFound      = errors.New("usuario no encontrado")
+	ErrLocalityRequired  = errors.New("el rol del usuario requiere una localidad asignada")
+	ErrUserNoLocality    = errors.New("el usuario no tiene localidad asignada")
+
+	// Caregiver errors
+	ErrInvalidCaregiverSort = errors.New("sort debe ser name, patient_load o last_activity")
+	ErrCaseloadExceeded     = errors.New("el apoderado alcanzó el máximo de pacientes asignados")
 
 	// Recommendation errors
 	ErrEmptyRecommendationName = errors.New("el nombre de la recomendación no puede estar vacío")
 	ErrRecommendationNotFound  = errors.New("recomendación no encontrada")
+	ErrRecommendationInactive  = errors.New("la recomendación está inactiva")
+	ErrRecommendationNoRange   = errors.New("la recomendación no tiene rango MUAC definido")
 
 	// Measurement errors
-	ErrInvalidMuacValue    = errors.New("el valor MUAC debe ser mayor que cero")
-	ErrEmptyPatientID      = errors.New("el ID del paciente no puede estar vacío")
-	ErrEmptyUserID         = errors.New("el ID del usuario no puede estar vacío")
-	ErrMeasurementNotFound = errors.New("medición no encontrada")
+	ErrInvalidMuacValue           = errors.New("el valor MUAC debe ser mayor que cero")
+	ErrEmptyPatientID             = errors.New("el ID del paciente no puede estar vacío")
+	ErrEmptyUserID                = errors.New("el ID del usuario no puede estar vacío")
+	ErrMeasurementNotFound        = errors.New("medición no encontrada")
+	ErrTooManyPatientIDs          = errors.New("se excedió el número máximo de pacientes por consulta")
+	ErrInvalidSyncCursor          = errors.New("el cursor de sincronización debe ser anterior a la hora del servidor")
+	ErrFutureMeasurementTime      = errors.New("la hora de la medición está en el futuro")
+	ErrEmptyRiskDescription       = errors.New("la descripción es obligatoria para mediciones en rojo o amarillo")
+	ErrInvalidMeasurementLocation = errors.New("la ubicación GPS debe incluir latitud (-90 a 90) y longitud (-180 a 180)")
+	ErrInvalidTapeBatch           = errors.New("el lote de la cinta debe tener de 2 a 30 letras, dígitos, guiones o guiones bajos")
+	ErrInvalidOverrideReason      = errors.New("el motivo de la reclasificación no puede superar 100 caracteres")
+
+	// Measurement permission errors
+	ErrMeasurementActorRequired = errors.New("debe identificar al usuario que modifica la medición")
+	ErrMeasurementNotOwner      = errors.New("solo puede modificar sus propias mediciones")
+	ErrMeasurementEditExpired   = errors.New("la ventana para modificar la medición ha vencido")
+	ErrEmptyFlagReason          = errors.New("debe indicar el motivo de la marca")
+
+	// Measurement search errors
+	ErrMeasurementSearchTooShort  = errors.New("patient_name debe tener al menos 3 caracteres")
+	ErrMeasurementSearchForbidden = errors.New("el usuario no puede buscar mediciones fuera de su alcance")
+
+	// Risk stream errors
+	ErrRiskStreamForbidden = errors.New("solo un SUPERVISOR con localidad o un ADMINISTRADOR puede seguir las mediciones en riesgo")
+	ErrRiskStreamFull      = errors.New("se alcanzó el máximo de conexiones al stream de mediciones en riesgo")
 
 	// Notification errors
-	ErrEmptyNotificationTitle = errors.New("el título de la notificación no puede estar vacío")
-	ErrNotificationNotFound   = errors.New("notificación no encontrada")
+	ErrEmptyNotificationTitle    = errors.New("el título de la notificación no puede estar vacío")
+	ErrNotificationNotFound      = errors.New("notificación no encontrada")
+	ErrNotificationNoTarget      = errors.New("la notificación no tiene un destino de entrega")
+	ErrNotificationAlreadySent   = errors.New("la notificación ya fue entregada")
+	ErrNotificationResendLimit   = errors.New("se alcanzó el límite de intentos de entrega")
+	ErrNotificationDelivery      = errors.New("no se pudo entregar la notificación")
+	ErrInvalidNotificationTarget = errors.New("el destino de la notificación debe ser una URL https con un host permitido (WEBHOOK_ALLOWED_HOSTS)")
+	ErrInvalidNotificationKind   = errors.New("tipo de notificación inválido, use red_alert, reminder o broadcast")
+
+	ErrInvalidAnnouncementWindow = errors.New("expires_at debe ser posterior a starts_at")
 
 	// FAQ errors
 	ErrEmptyFAQQuestion   = errors.New("la pregunta no puede estar vacía")
@@ -53,4 +112,28 @@ var (
 
 	//recipe errors
 	ErrInvalidAge = errors.New("edad inválida")
+
+	// Audit errors
+	ErrInvalidAuditEntity    = errors.New("entity debe ser patient, measurement, maintenance o user")
+	ErrInvalidAuditAction    = errors.New("action debe ser update, delete, consent, toggle, approval o recompute")
+	ErrInvalidAuditDateRange = errors.New("start_date debe ser anterior o igual a end_date")
+	ErrAuditForbidden        = errors.New("solo un ADMINISTRADOR puede consultar la auditoría")
+
+	// Backup errors
+	ErrInvalidBackup            = errors.New("archivo de exportación inválido")
+	ErrUnsupportedBackupVersion = errors.New("versión de exportación no soportada")
+	ErrUnknownBackupEntity      = errors.New("entidad desconocida en el archivo de exportación")
+	ErrBackupEntityOrder        = errors.New("las entidades deben venir en el orden de la exportación, sin repetirse")
+	ErrUnknownBackupColumn      = errors.New("columna desconocida en el archivo de exportación")
+	ErrBackupIntegrity          = errors.New("el archivo de exportación tiene referencias a filas inexistentes")
+
+	// Report errors
+	ErrInvalidReportMonth     = errors.New("mes de reporte inválido: use un año desde 2000 y un mes de 1 a 12 que no sea futuro")
+	ErrInvalidReportRange     = errors.New("rango de fechas inválido: start_date debe ser anterior a end_date")
+	ErrPendingReviewForbidden = errors.New("solo un SUPERVISOR con localidad o un ADMINISTRADOR puede ver la cola de revisión de su alcance")
+	ErrDashboardActorRequired = errors.New("debe identificar al usuario activo con la cabecera X-User-ID")
+
+	// Quality alert errors
+	ErrInvalidQualityAlertID = errors.New("ID de alerta inválido: use categoría:clave con una categoría conocida")
+	ErrQualityAlertNotFound  = errors.New("la alerta de calidad no existe o ya se resolvió")
 )
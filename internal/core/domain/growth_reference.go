@@ -0,0 +1,291 @@
+package domain
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sexo del paciente según la referencia OMS
+const (
+	GrowthSexMale   = "male"
+	GrowthSexFemale = "female"
+)
+
+// Rango de edad (en meses) de las curvas de perímetro braquial para la edad
+const (
+	GrowthPlotMinMonths = 6
+	GrowthPlotMaxMonths = 59
+)
+
+// daysPerMonth es la duración media del mes que usa la OMS para pasar de días a meses
+const daysPerMonth = 30.4375
+
+// GrowthCurveZScores son las curvas de referencia que se devuelven (-3 a +3 desviaciones)
+var GrowthCurveZScores = []int{-3, -2, -1, 0, 1, 2, 3}
+
+// GrowthLMS son los parámetros LMS de la OMS para una edad
+type GrowthLMS struct {
+	L, M, S float64
+}
+
+// ValueAt devuelve el MUAC (cm) que corresponde al z-score
+func (p GrowthLMS) ValueAt(z float64) float64 {
+	if p.L == 0 {
+		return p.M * math.Exp(p.S*z)
+	}
+	return p.M * math.Pow(1+p.L*p.S*z, 1/p.L)
+}
+
+// ZScore devuelve el z-score del MUAC (cm)
+func (p GrowthLMS) ZScore(value float64) float64 {
+	if p.L == 0 {
+		return math.Log(value/p.M) / p.S
+	}
+	return (math.Pow(value/p.M, p.L) - 1) / (p.L * p.S)
+}
+
+// MuacReference son las tablas OMS de perímetro braquial para la edad, por sexo y mes cumplido
+type MuacReference map[string]map[int]GrowthLMS
+
+// WHOMuacReference es la referencia vigente; vacía hasta que se carga al iniciar la aplicación
+var WHOMuacReference = MuacReference{}
+
+// SetMuacReference reemplaza la referencia vigente
+func SetMuacReference(reference MuacReference) {
+	WHOMuacReference = reference
+}
+
+// Available indica si la referencia tiene datos para el sexo
+func (r MuacReference) Available(sex string) bool {
+	return len(r[sex]) > 0
+}
+
+// ParseMuacReference lee las tablas OMS separadas por comas o tabuladores con cabecera sex,month,l,m,s
+// o sex,age,l,m,s (edad en días, como acanthro.txt del paquete igrowup). El sexo es 1 (niño) o 2 (niña);
+// solo se guardan los meses cumplidos entre GrowthPlotMinMonths y GrowthPlotMaxMonths
+func ParseMuacReference(r io.Reader) (MuacReference, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer la referencia OMS: %w", err)
+	}
+	reader := newReferenceReader(string(content))
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error al leer la cabecera de la referencia OMS: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	ageColumn, inDays := columns["month"], false
+	if _, ok := columns["month"]; !ok {
+		if ageColumn, inDays = columns["age"]; !inDays {
+			return nil, fmt.Errorf("la referencia OMS debe tener la columna month o age")
+		}
+	}
+	for _, name := range []string{"sex", "l", "m", "s"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("la referencia OMS debe tener la columna %s", name)
+		}
+	}
+
+	reference := MuacReference{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error al leer la referencia OMS (línea %d): %w", line, err)
+		}
+
+		values := make(map[string]float64, 5)
+		for _, name := range []string{"sex", "l", "m", "s"} {
+			if values[name], err = parseReferenceValue(record, columns[name]); err != nil {
+				return nil, fmt.Errorf("referencia OMS línea %d, columna %s: %w", line, name, err)
+			}
+		}
+		age, err := parseReferenceValue(record, ageColumn)
+		if err != nil {
+			return nil, fmt.Errorf("referencia OMS línea %d, edad: %w", line, err)
+		}
+
+		month := int(age)
+		if inDays {
+			// Se toma el día que corresponde a cada mes cumplido
+			month = int(math.Round(age / daysPerMonth))
+			if int(math.Round(float64(month)*daysPerMonth)) != int(age) {
+				continue
+			}
+		}
+		if month < GrowthPlotMinMonths || month > GrowthPlotMaxMonths {
+			continue
+		}
+
+		var sex string
+		switch int(values["sex"]) {
+		case 1:
+			sex = GrowthSexMale
+		case 2:
+			sex = GrowthSexFemale
+		default:
+			return nil, fmt.Errorf("referencia OMS línea %d: sexo %v desconocido", line, values["sex"])
+		}
+		if values["m"] <= 0 || values["s"] <= 0 {
+			return nil, fmt.Errorf("referencia OMS línea %d: M y S deben ser positivos", line)
+		}
+		if reference[sex] == nil {
+			reference[sex] = make(map[int]GrowthLMS)
+		}
+		reference[sex][month] = GrowthLMS{L: values["l"], M: values["m"], S: values["s"]}
+	}
+
+	for _, sex := range []string{GrowthSexMale, GrowthSexFemale} {
+		for month := GrowthPlotMinMonths; month <= GrowthPlotMaxMonths; month++ {
+			if _, ok := reference[sex][month]; !ok {
+				return nil, fmt.Errorf("a la referencia OMS le falta el mes %d (%s)", month, sex)
+			}
+		}
+	}
+	return reference, nil
+}
+
+// newReferenceReader crea un lector de tablas OMS separadas por comas o, si la cabecera los tiene, por tabuladores
+func newReferenceReader(content string) *csv.Reader {
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	if firstLine, _, _ := strings.Cut(content, "\n"); strings.Contains(firstLine, "\t") {
+		reader.Comma = '\t'
+	}
+	return reader
+}
+
+func parseReferenceValue(record []string, column int) (float64, error) {
+	if column >= len(record) {
+		return 0, fmt.Errorf("valor faltante")
+	}
+	return strconv.ParseFloat(strings.TrimSpace(record[column]), 64)
+}
+
+// GrowthSex normaliza el género registrado del paciente; vacío si no se reconoce
+func GrowthSex(gender string) string {
+	switch strings.ToLower(strings.TrimSpace(gender)) {
+	case "m", "masculino", "hombre", "varón", "varon", "niño", "nino", "male":
+		return GrowthSexMale
+	case "f", "femenino", "mujer", "niña", "nina", "female":
+		return GrowthSexFemale
+	}
+	return ""
+}
+
+// GrowthPoint es una medición del paciente ubicada por su edad
+type GrowthPoint struct {
+	MeasurementID uuid.UUID `json:"measurement_id"`
+	MeasuredAt    time.Time `json:"measured_at"`
+	AgeMonths     float64   `json:"age_months"`
+	MuacValue     float64   `json:"muac"`
+	ZScore        *float64  `json:"z_score,omitempty"` // Solo si se conoce el sexo y hay referencia
+}
+
+// GrowthCurvePoint es el valor de una curva de referencia en un mes cumplido
+type GrowthCurvePoint struct {
+	AgeMonths int     `json:"age_months"`
+	MuacValue float64 `json:"muac"`
+}
+
+// GrowthCurve es la curva de referencia de un z-score
+type GrowthCurve struct {
+	ZScore int                `json:"z_score"`
+	Points []GrowthCurvePoint `json:"points"`
+}
+
+// GrowthReferenceSeries son las curvas de referencia de un sexo
+type GrowthReferenceSeries struct {
+	Sex    string        `json:"sex"`
+	Curves []GrowthCurve `json:"curves"`
+}
+
+// GrowthPlotData son los datos para graficar el MUAC del paciente sobre las curvas OMS
+type GrowthPlotData struct {
+	PatientID          uuid.UUID               `json:"patient_id"`
+	Sex                string                  `json:"sex"` // Vacío si el género registrado no se reconoce
+	BirthDate          string                  `json:"birth_date"`
+	ReferenceAvailable bool                    `json:"reference_available"`
+	Points             []GrowthPoint           `json:"points"`
+	Excluded           int                     `json:"excluded"` // Mediciones fuera de 6-59 meses de edad
+	Reference          []GrowthReferenceSeries `json:"reference"`
+}
+
+// NewGrowthPlotData ubica las mediciones por edad y arma las curvas del sexo del paciente, o de ambos
+// sexos si no se reconoce. Exige una fecha de nacimiento válida
+func NewGrowthPlotData(patient *Patient, measurements []*Measurement, reference MuacReference) (*GrowthPlotData, error) {
+	birthDate, ok := ParseBirthDate(patient.BirthDate)
+	if !ok {
+		return nil, ErrGrowthPlotBirthDate
+	}
+
+	sex := GrowthSex(patient.Gender)
+	sexes := []string{GrowthSexMale, GrowthSexFemale}
+	if sex != "" {
+		sexes = []string{sex}
+	}
+
+	data := &GrowthPlotData{
+		PatientID:          patient.ID,
+		Sex:                sex,
+		BirthDate:          birthDate.Format("2006-01-02"),
+		ReferenceAvailable: true,
+		Points:             make([]GrowthPoint, 0, len(measurements)),
+		Reference:          make([]GrowthReferenceSeries, 0, len(sexes)),
+	}
+	for _, s := range sexes {
+		if !reference.Available(s) {
+			data.ReferenceAvailable = false
+		}
+	}
+
+	for _, m := range measurements {
+		ageMonths := m.CreatedAt.Sub(birthDate).Hours() / 24 / daysPerMonth
+		if ageMonths < GrowthPlotMinMonths || ageMonths >= GrowthPlotMaxMonths+1 {
+			data.Excluded++
+			continue
+		}
+		point := GrowthPoint{
+			MeasurementID: m.ID,
+			MeasuredAt:    m.CreatedAt,
+			AgeMonths:     math.Round(ageMonths*100) / 100,
+			MuacValue:     m.MuacValue,
+		}
+		if lms, ok := reference[sex][int(ageMonths)]; ok && sex != "" {
+			z := math.Round(lms.ZScore(m.MuacValue)*100) / 100
+			point.ZScore = &z
+		}
+		data.Points = append(data.Points, point)
+	}
+
+	if !data.ReferenceAvailable {
+		return data, nil
+	}
+	for _, s := range sexes {
+		series := GrowthReferenceSeries{Sex: s, Curves: make([]GrowthCurve, 0, len(GrowthCurveZScores))}
+		for _, z := range GrowthCurveZScores {
+			curve := GrowthCurve{ZScore: z, Points: make([]GrowthCurvePoint, 0, GrowthPlotMaxMonths-GrowthPlotMinMonths+1)}
+			for month := GrowthPlotMinMonths; month <= GrowthPlotMaxMonths; month++ {
+				value := reference[s][month].ValueAt(float64(z))
+				curve.Points = append(curve.Points, GrowthCurvePoint{AgeMonths: month, MuacValue: math.Round(value*100) / 100})
+			}
+			series.Curves = append(series.Curves, curve)
+		}
+		data.Reference = append(data.Reference, series)
+	}
+	return data, nil
+}
@@ -1,6 +1,9 @@
 package domain
 
 import (
+	"math"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -46,6 +49,21 @@ func (l *Locality) Validate() error {
 	return nil
 }
 
+// NormalizeCoordinates valida latitud y longitud si se informó alguna y las guarda en su
+// forma numérica normalizada; una localidad sin coordenadas se acepta como antes
+func (l *Locality) NormalizeCoordinates() (CoordinateValidation, error) {
+	if strings.TrimSpace(l.Latitude) == "" && strings.TrimSpace(l.Longitude) == "" {
+		return CoordinateValidation{}, nil
+	}
+	result := ValidateCoordinates(l.Latitude, l.Longitude)
+	if err := result.Err(); err != nil {
+		return result, err
+	}
+	l.Latitude = FormatCoordinate(*result.Latitude)
+	l.Longitude = FormatCoordinate(*result.Longitude)
+	return result, nil
+}
+
 // Update actualiza los campos de la localidad
 // Update actualiza los campos de la localidad solo si los nuevos valores no están vacíos
 func (l *Locality) Update(name, latitude, longitude, description, phone string, isMedical *bool) {
@@ -76,3 +94,207 @@ func (l *Locality) Update(name, latitude, longitude, description, phone string,
 
 	l.UpdatedAt = time.Now()
 }
+
+// UnassignedLocalityName es el nombre del grupo que reúne, en los reportes por localidad,
+// a los usuarios sin localidad asignada (se usa con LocalityID = uuid.Nil)
+const UnassignedLocalityName = "Sin localidad"
+
+// LocalityMeasurement es una medición de la localidad con los datos que necesita la revisión del supervisor
+type LocalityMeasurement struct {
+	MeasurementID uuid.UUID `json:"measurement_id"`
+	MuacValue     float64   `json:"muac_value"`
+	MuacCode      string    `json:"muac_code"`
+	RiskLevel     string    `json:"risk_level"`
+	ColorCode     string    `json:"color_code"`
+	PatientID     uuid.UUID `json:"patient_id"`
+	PatientName   string    `json:"patient_name"`
+	CaregiverID   uuid.UUID `json:"caregiver_id"`
+	CaregiverName string    `json:"caregiver_name"`
+	MeasuredAt    time.Time `json:"measured_at"`
+}
+
+// Classify completa el código, nivel de riesgo y color a partir del valor MUAC
+func (m *LocalityMeasurement) Classify() {
+	m.MuacCode, m.ColorCode, _ = ClassifyMuacValue(m.MuacValue)
+	m.RiskLevel = GetMuacRiskLevel(m.MuacValue)
+}
+
+// LocalityRosterEntry es un paciente de la localidad con su estado a la fecha de corte
+type LocalityRosterEntry struct {
+	PatientID      uuid.UUID
+	PatientName    string
+	DNI            string
+	Gender         string
+	Age            float64
+	BirthDate      string
+	CaregiverName  string
+	MuacValue      *float64
+	LastMeasuredAt *time.Time
+	MuacCode       string // PatientStatusNoMeasurements si no tiene mediciones a la fecha
+	RiskLevel      string
+	DaysSinceLast  *int
+}
+
+// Classify completa código, nivel de riesgo y días transcurridos respecto a asOf
+func (e *LocalityRosterEntry) Classify(asOf time.Time) {
+	if e.MuacValue == nil || e.LastMeasuredAt == nil {
+		e.MuacCode = PatientStatusNoMeasurements
+		e.RiskLevel = "Sin mediciones"
+		return
+	}
+	e.MuacCode, _, _ = ClassifyMuacValue(*e.MuacValue)
+	e.RiskLevel = GetMuacRiskLevel(*e.MuacValue)
+	days := int(asOf.Sub(*e.LastMeasuredAt).Hours() / 24)
+	e.DaysSinceLast = &days
+}
+
+// LocalityRoster es el padrón de pacientes de una localidad a una fecha de corte
+type LocalityRoster struct {
+	Locality *Locality
+	AsOf     time.Time
+	Patients []LocalityRosterEntry
+}
+
+// Radio de búsqueda de localidades cercanas, en km
+const (
+	DefaultNearbyRadiusKm = 10.0
+	MaxNearbyRadiusKm     = 200.0
+)
+
+// NearbyLocalityRisk es una localidad dentro del radio con los pacientes en riesgo según su última medición
+type NearbyLocalityRisk struct {
+	LocalityID      uuid.UUID `json:"locality_id"`
+	Name            string    `json:"name"`
+	Latitude        string    `json:"latitude"`
+	Longitude       string    `json:"longitude"`
+	IsMedicalCenter bool      `json:"is_medical_center"`
+	DistanceKm      float64   `json:"distance_km"`
+	AtRisk          int64     `json:"at_risk"`
+	Severe          int64     `json:"severe"`
+	Moderate        int64     `json:"moderate"`
+}
+
+// MaxLocalityMonthlyTarget es la meta mensual de mediciones más alta que se acepta para una localidad
+const MaxLocalityMonthlyTarget = 100000
+
+// LocalityTarget es la meta mensual de mediciones de una localidad; se aplica a todos los meses
+type LocalityTarget struct {
+	LocalityID    uuid.UUID  `json:"locality_id" gorm:"type:uuid;primaryKey"`
+	MonthlyTarget int        `json:"monthly_target" gorm:"column:monthly_target;not null"`
+	UpdatedBy     *uuid.UUID `json:"updated_by,omitempty" gorm:"column:updated_by;type:uuid"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+// TableName especifica el nombre de la tabla para GORM
+func (LocalityTarget) TableName() string {
+	return "locality_targets"
+}
+
+// NewLocalityTarget crea la meta mensual de la localidad; debe ser de al menos una medición
+func NewLocalityTarget(localityID uuid.UUID, monthlyTarget int, actorID *uuid.UUID) (*LocalityTarget, error) {
+	if monthlyTarget < 1 || monthlyTarget > MaxLocalityMonthlyTarget {
+		return nil, ErrInvalidLocalityTarget
+	}
+	now := time.Now()
+	return &LocalityTarget{
+		LocalityID:    localityID,
+		MonthlyTarget: monthlyTarget,
+		UpdatedBy:     actorID,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}, nil
+}
+
+// MinPercentileSampleSize es la cantidad de niños medidos bajo la cual los percentiles de la
+// localidad son poco informativos
+const MinPercentileSampleSize = 10
+
+// PatientPercentileMethod describe cómo se calcula el percentil de LocalityPercentileReport
+const PatientPercentileMethod = "Percentil de rango medio: porcentaje de niños de la localidad con MUAC menor más la mitad " +
+	"de los que tienen el mismo valor, según la última medición de cada niño. Un percentil bajo indica más riesgo"
+
+// PatientPercentile es un paciente de la localidad con la posición de su último MUAC entre los niños medidos
+type PatientPercentile struct {
+	PatientID      uuid.UUID `json:"patient_id"`
+	PatientName    string    `json:"patient_name"`
+	CaregiverName  string    `json:"caregiver_name"`
+	MuacValue      float64   `json:"muac_value"`
+	MuacCode       string    `json:"muac_code"`
+	LastMeasuredAt time.Time `json:"last_measured_at"`
+	Rank           int       `json:"rank"`       // 1 = menor MUAC; los empates comparten el rango
+	Tied           int       `json:"tied"`       // Niños con el mismo valor, incluido este
+	Percentile     float64   `json:"percentile"` // 0 a 100
+}
+
+// LocalityPercentileReport ordena a los niños de la localidad por su último MUAC, del peor al mejor
+type LocalityPercentileReport struct {
+	LocalityID          uuid.UUID           `json:"locality_id"`
+	LocalityName        string              `json:"locality_name"`
+	SampleSize          int                 `json:"sample_size"`          // Niños con al menos una medición
+	WithoutMeasurements int                 `json:"without_measurements"` // Niños sin mediciones; no se rankean
+	SmallSample         bool                `json:"small_sample"`         // SampleSize menor a MinPercentileSampleSize
+	Method              string              `json:"method"`
+	Patients            []PatientPercentile `json:"patients"`
+	GeneratedAt         time.Time           `json:"generated_at"`
+}
+
+// NewLocalityPercentileReport calcula el rango y el percentil de cada niño del padrón según su último
+// MUAC. Los empates comparten el rango (el menor) y el percentil; dentro de un empate se ordena por la
+// medición más reciente y luego por nombre
+func NewLocalityPercentileReport(locality *Locality, entries []LocalityRosterEntry, now time.Time) *LocalityPercentileReport {
+	report := &LocalityPercentileReport{
+		LocalityID:   locality.ID,
+		LocalityName: locality.Name,
+		Method:       PatientPercentileMethod,
+		Patients:     make([]PatientPercentile, 0, len(entries)),
+		GeneratedAt:  now,
+	}
+
+	for _, entry := range entries {
+		if entry.MuacValue == nil || entry.LastMeasuredAt == nil {
+			report.WithoutMeasurements++
+			continue
+		}
+		code, _, _ := ClassifyMuacValue(*entry.MuacValue)
+		report.Patients = append(report.Patients, PatientPercentile{
+			PatientID:      entry.PatientID,
+			PatientName:    entry.PatientName,
+			CaregiverName:  entry.CaregiverName,
+			MuacValue:      *entry.MuacValue,
+			MuacCode:       code,
+			LastMeasuredAt: *entry.LastMeasuredAt,
+		})
+	}
+
+	patients := report.Patients
+	sort.Slice(patients, func(i, j int) bool {
+		if patients[i].MuacValue != patients[j].MuacValue {
+			return patients[i].MuacValue < patients[j].MuacValue
+		}
+		if !patients[i].LastMeasuredAt.Equal(patients[j].LastMeasuredAt) {
+			return patients[i].LastMeasuredAt.After(patients[j].LastMeasuredAt)
+		}
+		return patients[i].PatientName < patients[j].PatientName
+	})
+
+	n := len(patients)
+	for start := 0; start < n; {
+		end := start
+		for end < n && patients[end].MuacValue == patients[start].MuacValue {
+			end++
+		}
+		tied := end - start
+		percentile := math.Round((float64(start)+float64(tied)/2)/float64(n)*1000) / 10
+		for i := start; i < end; i++ {
+			patients[i].Rank = start + 1
+			patients[i].Tied = tied
+			patients[i].Percentile = percentile
+		}
+		start = end
+	}
+
+	report.SampleSize = n
+	report.SmallSample = n < MinPercentileSampleSize
+	return report
+}
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// DefaultMaintenanceMessage es el mensaje devuelto a las escrituras mientras dura el mantenimiento
+const DefaultMaintenanceMessage = "El sistema está en mantenimiento: solo se permiten consultas"
+
+// MaintenanceStatus representa el estado del modo de solo lectura
+type MaintenanceStatus struct {
+	Enabled   bool      `json:"enabled"`
+	Message   string    `json:"message,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// maintenance guarda el estado vigente; se lee en cada petición sin bloqueos
+var maintenance atomic.Pointer[MaintenanceStatus]
+
+func init() {
+	maintenance.Store(&MaintenanceStatus{UpdatedAt: time.Now()})
+}
+
+// SetMaintenanceMode activa o desactiva el modo de solo lectura y devuelve el nuevo estado
+func SetMaintenanceMode(enabled bool, message string) MaintenanceStatus {
+	status := &MaintenanceStatus{Enabled: enabled, UpdatedAt: time.Now()}
+	if enabled {
+		status.Message = message
+		if status.Message == "" {
+			status.Message = DefaultMaintenanceMessage
+		}
+	}
+	maintenance.Store(status)
+	return *status
+}
+
+// CurrentMaintenance devuelve el estado vigente del modo de solo lectura
+func CurrentMaintenance() MaintenanceStatus {
+	return *maintenance.Load()
+}
@@ -1,11 +1,72 @@
 package domain
 
 import (
+	"fmt"
+	"math"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
 
+// MaxPatientIDsPerQuery limita cuántos pacientes se pueden consultar en una sola petición
+const MaxPatientIDsPerQuery = 50
+
+// DefaultMaxClockSkew es el adelanto máximo tolerado en la hora enviada por el dispositivo
+const DefaultMaxClockSkew = 5 * time.Minute
+
+// MaxMeasurementClockSkew es la tolerancia vigente; se configura al iniciar la aplicación
+var MaxMeasurementClockSkew = DefaultMaxClockSkew
+
+// SetMaxMeasurementClockSkew actualiza la tolerancia, ignorando valores negativos
+func SetMaxMeasurementClockSkew(skew time.Duration) {
+	if skew < 0 {
+		return
+	}
+	MaxMeasurementClockSkew = skew
+}
+
+// RequireDescriptionForRisk exige descripción en mediciones clasificadas en rojo o amarillo;
+// se configura al iniciar la aplicación
+var RequireDescriptionForRisk = false
+
+// SetRequireDescriptionForRisk activa o desactiva la regla de descripción obligatoria
+func SetRequireDescriptionForRisk(required bool) {
+	RequireDescriptionForRisk = required
+}
+
+// DefaultMeasurementEditWindow es el tiempo durante el cual un apoderado puede corregir sus mediciones
+const DefaultMeasurementEditWindow = 24 * time.Hour
+
+// MeasurementEditWindow es la ventana vigente (0 = sin límite de tiempo); se configura al iniciar la aplicación
+var MeasurementEditWindow = DefaultMeasurementEditWindow
+
+// SetMeasurementEditWindow actualiza la ventana de edición, ignorando valores negativos
+func SetMeasurementEditWindow(window time.Duration) {
+	if window < 0 {
+		return
+	}
+	MeasurementEditWindow = window
+}
+
+// NormalizeMeasurementTime valida la hora de toma enviada por el cliente respecto a la hora del servidor.
+// Una hora vacía toma el valor de now; un adelanto dentro de la tolerancia se ajusta a now
+// (adjusted=true) y uno mayor se rechaza.
+func NormalizeMeasurementTime(measuredAt, now time.Time) (normalized time.Time, adjusted bool, err error) {
+	if measuredAt.IsZero() {
+		return now, false, nil
+	}
+	if measuredAt.After(now.Add(MaxMeasurementClockSkew)) {
+		return time.Time{}, false, fmt.Errorf("%w: %s está %s por delante de la hora del servidor (tolerancia %s)",
+			ErrFutureMeasurementTime, measuredAt.Format(time.RFC3339), measuredAt.Sub(now).Round(time.Second), MaxMeasurementClockSkew)
+	}
+	if measuredAt.After(now) {
+		return now, true, nil
+	}
+	return measuredAt, false, nil
+}
+
 // Measurement representa la entidad de medición en el dominio
 type Measurement struct {
 	ID               uuid.UUID       `json:"id" gorm:"type:uuid;primaryKey"`
@@ -16,13 +77,40 @@ type Measurement struct {
 	TagID            *uuid.UUID      `json:"tag_id,omitempty" gorm:"column:tag_id;type:uuid"`
 	RecommendationID *uuid.UUID      `json:"recommendation_id,omitempty" gorm:"column:recommendation_id;type:uuid"`
 	CreatedAt        time.Time       `json:"created_at" gorm:"column:created_at;autoCreateTime"`
-	UpdatedAt        time.Time       `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+	UpdatedAt        time.Time       `json:"updated_at" gorm:"column:updated_at;autoUpdateTime;index"`
 	Patient          *Patient        `json:"patient,omitempty" gorm:"foreignKey:PatientID"`
 	User             *User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
 	Tag              *Tag            `json:"tag,omitempty" gorm:"foreignKey:TagID"`
 	Recommendation   *Recommendation `json:"recommendation" gorm:"foreignKey:RecommendationID"`
 
 	MeasurementAdvice MeasurementAdvice `json:"measurement_advice,omitempty" gorm:"-"`
+
+	// TimeAdjusted indica que la hora del dispositivo venía adelantada y se ajustó a la del servidor
+	TimeAdjusted bool `json:"time_adjusted,omitempty" gorm:"-"`
+
+	// Hora del servidor en que se recibió la medición (created_at es la hora de toma del dispositivo);
+	// nil en las filas anteriores a este campo
+	ReceivedAt *time.Time `json:"received_at,omitempty" gorm:"column:received_at"`
+
+	// Marca de posible error de registro, pendiente de revisión
+	IsFlagged         bool       `json:"is_flagged" gorm:"column:is_flagged;default:false;index"`
+	FlagReason        string     `json:"flag_reason,omitempty" gorm:"column:flag_reason;type:text"`
+	FlagPreviousValue *float64   `json:"flag_previous_value,omitempty" gorm:"column:flag_previous_value;type:decimal(10,2)"`
+	FlagDelta         *float64   `json:"flag_delta,omitempty" gorm:"column:flag_delta;type:decimal(10,2)"`
+	FlaggedAt         *time.Time `json:"flagged_at,omitempty" gorm:"column:flagged_at"`
+
+	// Ubicación GPS capturada por el dispositivo; nil si se tomó sin GPS y se usa la de la localidad
+	Latitude  *float64 `json:"latitude,omitempty" gorm:"column:latitude;type:decimal(10,7)"`
+	Longitude *float64 `json:"longitude,omitempty" gorm:"column:longitude;type:decimal(10,7)"`
+
+	// Lote de la cinta MUAC usada, para rastrear lotes defectuosos; vacío si no se registró
+	TapeBatch string `json:"tape_batch,omitempty" gorm:"column:tape_batch;type:varchar(30);index"`
+
+	// Motivo indicado al asignar a mano una etiqueta distinta de la clasificación automática
+	OverrideReason string `json:"override_reason,omitempty" gorm:"column:override_reason;type:varchar(100)"`
+
+	// Umbrales vigentes al crear la medición; las filas anteriores a este campo toman los actuales
+	Thresholds MuacThresholdSet `json:"-" gorm:"embedded;embeddedPrefix:threshold_"`
 }
 
 type MeasurementAdvice struct {
@@ -49,8 +137,82 @@ func NewMeasurement(muacValue float64, description string, timestamp time.Time,
 		Description: description,
 		PatientID:   patientID,
 		UserID:      userID,
-		CreatedAt:   time.Now(),
+		CreatedAt:   timestamp,
+	}
+}
+
+// MeasurementLocation son las coordenadas GPS capturadas por el dispositivo al tomar la medición
+type MeasurementLocation struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// NewMeasurementLocation valida las coordenadas enviadas por el dispositivo; devuelve nil si no se
+// envió ninguna. No se exige que caigan en la región del programa: el reporte de GPS las cuenta aparte
+func NewMeasurementLocation(latitude, longitude *float64) (*MeasurementLocation, error) {
+	if latitude == nil && longitude == nil {
+		return nil, nil
+	}
+	if latitude == nil || longitude == nil || !validLatitude(*latitude) || !validLongitude(*longitude) {
+		return nil, ErrInvalidMeasurementLocation
 	}
+	return &MeasurementLocation{Latitude: *latitude, Longitude: *longitude}, nil
+}
+
+// SetLocation asigna la ubicación GPS de la medición; nil la deja sin ubicación
+func (m *Measurement) SetLocation(location *MeasurementLocation) {
+	if location == nil {
+		m.Latitude, m.Longitude = nil, nil
+		return
+	}
+	latitude, longitude := location.Latitude, location.Longitude
+	m.Latitude, m.Longitude = &latitude, &longitude
+}
+
+// MaxTapeBatchLength es la longitud máxima del código de lote de la cinta
+const MaxTapeBatchLength = 30
+
+// MaxOverrideReasonLength es el largo máximo del motivo de una reclasificación manual
+const MaxOverrideReasonLength = 100
+
+// NormalizeTapeBatch valida el código de lote de la cinta y lo pasa a mayúsculas. Se admiten letras,
+// dígitos, guiones y guiones bajos (2 a MaxTapeBatchLength caracteres, empezando por letra o dígito);
+// vacío significa que no se registró
+func NormalizeTapeBatch(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return "", nil
+	}
+	if len(code) < 2 || len(code) > MaxTapeBatchLength {
+		return "", ErrInvalidTapeBatch
+	}
+	for i, c := range code {
+		alphanumeric := (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		if !alphanumeric && (i == 0 || (c != '-' && c != '_')) {
+			return "", ErrInvalidTapeBatch
+		}
+	}
+	return code, nil
+}
+
+// ApplyMeasuredAt valida y asigna la hora de toma de la medición
+func (m *Measurement) ApplyMeasuredAt(measuredAt, now time.Time) error {
+	normalized, adjusted, err := NormalizeMeasurementTime(measuredAt, now)
+	if err != nil {
+		return err
+	}
+	m.CreatedAt = normalized
+	m.TimeAdjusted = adjusted
+	m.ReceivedAt = &now
+	return nil
+}
+
+// receivedAt devuelve la hora de recepción en el servidor, o la de toma si la fila no la registró
+func (m *Measurement) receivedAt() time.Time {
+	if m.ReceivedAt != nil {
+		return *m.ReceivedAt
+	}
+	return m.CreatedAt
 }
 
 // Validate valida que la medición tenga los campos requeridos
@@ -64,6 +226,28 @@ func (m *Measurement) Validate() error {
 	if m.UserID == uuid.Nil {
 		return ErrEmptyUserID
 	}
+
+	if m.Thresholds.IsZero() {
+		m.Thresholds = CurrentMuacThresholds()
+	}
+
+	tapeBatch, err := NormalizeTapeBatch(m.TapeBatch)
+	if err != nil {
+		return err
+	}
+	m.TapeBatch = tapeBatch
+
+	m.OverrideReason = strings.TrimSpace(m.OverrideReason)
+	if utf8.RuneCountInString(m.OverrideReason) > MaxOverrideReasonLength {
+		return ErrInvalidOverrideReason
+	}
+
+	m.Description = strings.TrimSpace(m.Description)
+	if RequireDescriptionForRisk && m.Description == "" {
+		if muacCode, _, _ := ClassifyMuacValue(m.MuacValue); muacCode != MuacCodeGreen {
+			return ErrEmptyRiskDescription
+		}
+	}
 	return nil
 }
 
@@ -76,6 +260,70 @@ func (m *Measurement) Update(muacValue float64, description, location string, ti
 	m.UpdatedAt = time.Now()
 }
 
+// CheckModifiableBy verifica si el usuario puede editar o eliminar la medición.
+// Administradores y supervisores no tienen restricción; un apoderado solo puede modificar
+// sus propias mediciones dentro de MeasurementEditWindow desde que el servidor las recibió (no desde la
+// hora de toma, que envía el dispositivo y puede ser muy anterior en una sincronización offline)
+func (m *Measurement) CheckModifiableBy(actor *User, now time.Time) error {
+	switch actor.Role.Name {
+	case "ADMINISTRADOR", "SUPERVISOR":
+		return nil
+	case "APODERADO":
+		if m.UserID != actor.ID {
+			return ErrMeasurementNotOwner
+		}
+		if MeasurementEditWindow > 0 && now.Sub(m.receivedAt()) > MeasurementEditWindow {
+			return fmt.Errorf("%w: solo se permite hasta %s después de su registro", ErrMeasurementEditExpired, MeasurementEditWindow)
+		}
+		return nil
+	default:
+		return ErrMeasurementNotOwner
+	}
+}
+
+// ImplausibleMuacDelta es la variación (en cm) respecto a la medición anterior a partir de la cual
+// se sospecha un error de registro, si ocurre dentro de ImplausibleMuacWindow
+const (
+	ImplausibleMuacDelta  = 2.0
+	ImplausibleMuacWindow = 30 * 24 * time.Hour
+)
+
+// DetectImplausibleChange marca la medición si el cambio respecto a la anterior es poco probable.
+// previous debe ser la última medición del paciente tomada antes de m.CreatedAt
+func (m *Measurement) DetectImplausibleChange(previous *Measurement) {
+	if previous == nil || m.CreatedAt.Sub(previous.CreatedAt) > ImplausibleMuacWindow {
+		return
+	}
+	delta := m.MuacValue - previous.MuacValue
+	if math.Abs(delta) < ImplausibleMuacDelta {
+		return
+	}
+
+	previousValue := previous.MuacValue
+	m.Flag(fmt.Sprintf("Cambio de %+.1f cm respecto a la medición anterior (%.1f cm) en %d días",
+		delta, previousValue, int(m.CreatedAt.Sub(previous.CreatedAt).Hours()/24)), m.CreatedAt)
+	m.FlagPreviousValue = &previousValue
+	m.FlagDelta = &delta
+}
+
+// Flag marca la medición para revisión
+func (m *Measurement) Flag(reason string, now time.Time) {
+	m.IsFlagged = true
+	m.FlagReason = reason
+	m.FlaggedAt = &now
+	m.UpdatedAt = now
+}
+
+// Unflag quita la marca de revisión
+func (m *Measurement) Unflag() {
+	m.IsFlagged = false
+	m.FlagReason = ""
+	m.FlagPreviousValue = nil
+	m.FlagDelta = nil
+	m.FlaggedAt = nil
+	m.UpdatedAt = time.Now()
+}
+
 // SetTag asigna una etiqueta a la medición
 func (m *Measurement) SetTag(tagID *uuid.UUID) {
 	m.TagID = tagID
@@ -87,3 +335,131 @@ func (m *Measurement) SetRecommendation(recommendationID *uuid.UUID) {
 	m.RecommendationID = recommendationID
 	m.UpdatedAt = time.Now()
 }
+
+// ============= SIMULACIÓN DE MEDICIÓN =============
+const (
+	SimulationImprovement   = "MEJORA"
+	SimulationDeterioration = "DETERIORO"
+	SimulationNoChange      = "SIN-CAMBIO"
+	SimulationNoBaseline    = "SIN-REFERENCIA"
+)
+
+// MeasurementSimulation describe cómo cambiaría la clasificación de un paciente
+// si se registrara una medición con el valor indicado (no se persiste nada)
+type MeasurementSimulation struct {
+	PatientID      uuid.UUID       `json:"patient_id"`
+	SimulatedValue float64         `json:"simulated_value"`
+	MuacCode       string          `json:"muac_code"`
+	ColorCode      string          `json:"color_code"`
+	RiskLevel      string          `json:"risk_level"`
+	Tag            *Tag            `json:"tag,omitempty"`
+	Recommendation *Recommendation `json:"recommendation,omitempty"`
+	Current        *PatientStatus  `json:"current"`
+	CodeChanged    bool            `json:"code_changed"`
+	ValueDelta     *float64        `json:"value_delta,omitempty"`
+	Trend          string          `json:"trend"`
+}
+
+// NewMeasurementSimulation clasifica el valor simulado y lo compara con la última medición (nil si no existe)
+func NewMeasurementSimulation(patientID uuid.UUID, value float64, latest *Measurement) *MeasurementSimulation {
+	muacCode, colorCode, _ := ClassifyMuacValue(value)
+	sim := &MeasurementSimulation{
+		PatientID:      patientID,
+		SimulatedValue: value,
+		MuacCode:       muacCode,
+		ColorCode:      colorCode,
+		RiskLevel:      GetMuacRiskLevel(value),
+		Current:        NewPatientStatus(patientID, latest),
+		Trend:          SimulationNoBaseline,
+	}
+
+	if latest == nil {
+		return sim
+	}
+
+	delta := value - latest.MuacValue
+	sim.ValueDelta = &delta
+
+	sim.Trend = ClassificationTrend(latest.MuacValue, value)
+	sim.CodeChanged = sim.Trend != SimulationNoChange
+
+	return sim
+}
+
+// MeasurementThresholds son los umbrales con que se evaluó una medición y la clasificación resultante,
+// junto con la que tendría con los umbrales vigentes
+type MeasurementThresholds struct {
+	MeasurementID   uuid.UUID                    `json:"measurement_id"`
+	MuacValue       float64                      `json:"muac_value"`
+	MeasuredAt      time.Time                    `json:"measured_at"`
+	Thresholds      MuacThresholdSet             `json:"thresholds"`
+	MuacCode        string                       `json:"muac_code"`
+	Ranges          map[string]MuacThresholdInfo `json:"ranges"` // Por código MUAC, con los umbrales aplicados
+	Current         MuacThresholdSet             `json:"current"`
+	CurrentMuacCode string                       `json:"current_muac_code"`
+	ChangedSince    bool                         `json:"changed_since"` // Los umbrales cambiaron desde la medición
+}
+
+// NewMeasurementThresholds arma los umbrales aplicados a la medición
+func NewMeasurementThresholds(m *Measurement) *MeasurementThresholds {
+	applied := m.Thresholds
+	if applied.IsZero() {
+		applied = CurrentMuacThresholds()
+	}
+	current := CurrentMuacThresholds()
+	ranges := make(map[string]MuacThresholdInfo, 3)
+	for _, code := range []string{MuacCodeRed, MuacCodeYellow, MuacCodeGreen} {
+		ranges[code] = applied.Info(code)
+	}
+	return &MeasurementThresholds{
+		MeasurementID:   m.ID,
+		MuacValue:       m.MuacValue,
+		MeasuredAt:      m.CreatedAt,
+		Thresholds:      applied,
+		MuacCode:        applied.Classify(m.MuacValue),
+		Ranges:          ranges,
+		Current:         current,
+		CurrentMuacCode: current.Classify(m.MuacValue),
+		ChangedSince:    applied != current,
+	}
+}
+
+// NextStepRecommendation es la recomendación que vería el paciente si su clasificación empeorara un
+// paso (verde a amarillo, amarillo a rojo). En rojo se muestra la de seguimiento (MuacCodeFollow)
+type NextStepRecommendation struct {
+	PatientID      uuid.UUID         `json:"patient_id"`
+	Current        *PatientStatus    `json:"current"`
+	NextMuacCode   string            `json:"next_muac_code"`
+	NextRiskLevel  string            `json:"next_risk_level"`
+	Threshold      MuacThresholdInfo `json:"threshold"` // Rango de la clasificación siguiente
+	AtWorstStatus  bool              `json:"at_worst_status"`
+	Recommendation *Recommendation   `json:"recommendation"`
+}
+
+// NextStepMuacValue devuelve el primer valor (a la precisión de la cinta, 0.1 cm) de la clasificación
+// un paso peor que muacCode y su código; false si muacCode ya es la peor
+func NextStepMuacValue(muacCode string) (float64, string, bool) {
+	switch muacCode {
+	case MuacCodeGreen:
+		return math.Round((MuacThresholdNormal-0.1)*10) / 10, MuacCodeYellow, true
+	case MuacCodeYellow:
+		return math.Round((MuacThresholdSevere-0.1)*10) / 10, MuacCodeRed, true
+	default:
+		return 0, "", false
+	}
+}
+
+// ClassificationTrend compara la clasificación MUAC de dos valores: MEJORA si el nuevo tiene menor
+// prioridad que el anterior, DETERIORO si tiene mayor y SIN-CAMBIO si conserva la clasificación
+func ClassificationTrend(previousValue, value float64) string {
+	_, _, previousPriority := ClassifyMuacValue(previousValue)
+	_, _, priority := ClassifyMuacValue(value)
+	switch {
+	case priority < previousPriority:
+		return SimulationImprovement
+	case priority > previousPriority:
+		return SimulationDeterioration
+	default:
+		return SimulationNoChange
+	}
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// MinMeasurementSearchLength es la cantidad mínima de caracteres del nombre a buscar
+const MinMeasurementSearchLength = 3
+
+// MeasurementSearchFilter delimita la búsqueda de mediciones por nombre del paciente.
+// CaregiverID limita la búsqueda a los pacientes de un apoderado
+type MeasurementSearchFilter struct {
+	PatientName string
+	LocalityID  *uuid.UUID
+	CaregiverID *uuid.UUID
+}
+
+// NewMeasurementSearchFilter normaliza el nombre (espacios repetidos) y exige el largo mínimo
+func NewMeasurementSearchFilter(patientName string, localityID *uuid.UUID) (*MeasurementSearchFilter, error) {
+	patientName = strings.Join(strings.Fields(patientName), " ")
+	if utf8.RuneCountInString(patientName) < MinMeasurementSearchLength {
+		return nil, ErrMeasurementSearchTooShort
+	}
+	return &MeasurementSearchFilter{PatientName: patientName, LocalityID: localityID}, nil
+}
+
+// Pattern devuelve el patrón ILIKE del nombre, escapando los comodines que escriba el usuario
+func (f *MeasurementSearchFilter) Pattern() string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(f.PatientName)
+	return "%" + escaped + "%"
+}
+
+// Scope ajusta la búsqueda al alcance del rol del usuario: el administrador busca en todo, el
+// supervisor solo en su localidad y el apoderado solo entre sus pacientes
+func (f *MeasurementSearchFilter) Scope(actor *User) error {
+	switch actor.Role.Name {
+	case "ADMINISTRADOR":
+		return nil
+	case "SUPERVISOR":
+		if actor.LocalityID == nil {
+			return ErrMeasurementSearchForbidden
+		}
+		if f.LocalityID != nil && *f.LocalityID != *actor.LocalityID {
+			return ErrMeasurementSearchForbidden
+		}
+		f.LocalityID = actor.LocalityID
+		return nil
+	case "APODERADO":
+		f.CaregiverID = &actor.ID
+		return nil
+	default:
+		return ErrMeasurementSearchForbidden
+	}
+}
+
+// MeasurementSearchResult es una medición encontrada por el nombre del paciente, con su clasificación
+type MeasurementSearchResult struct {
+	MeasurementID uuid.UUID  `json:"measurement_id"`
+	MuacValue     float64    `json:"muac_value"`
+	MuacCode      string     `json:"muac_code"`
+	RiskLevel     string     `json:"risk_level"`
+	ColorCode     string     `json:"color_code"`
+	PatientID     uuid.UUID  `json:"patient_id"`
+	PatientName   string     `json:"patient_name"`
+	PatientDNI    string     `json:"patient_dni"`
+	CaregiverID   *uuid.UUID `json:"caregiver_id"`
+	CaregiverName string     `json:"caregiver_name"`
+	LocalityID    *uuid.UUID `json:"locality_id"`
+	LocalityName  string     `json:"locality_name"`
+	MeasuredAt    time.Time  `json:"measured_at"`
+}
+
+// Classify completa el código, nivel de riesgo y color a partir del valor MUAC
+func (m *MeasurementSearchResult) Classify() {
+	m.MuacCode, m.ColorCode, _ = ClassifyMuacValue(m.MuacValue)
+	m.RiskLevel = GetMuacRiskLevel(m.MuacValue)
+}
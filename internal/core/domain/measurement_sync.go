@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MeasurementDeletion registra una medición eliminada para que los clientes
+// offline puedan purgarla de su caché local en la siguiente sincronización
+type MeasurementDeletion struct {
+	MeasurementID uuid.UUID  `json:"measurement_id" gorm:"column:measurement_id;type:uuid;primaryKey"`
+	PatientID     uuid.UUID  `json:"patient_id" gorm:"column:patient_id;type:uuid;not null"`
+	LocalityID    *uuid.UUID `json:"locality_id,omitempty" gorm:"column:locality_id;type:uuid;index"` // Localidad al eliminar; solo se usa si ya no existe el paciente
+	DeletedAt     time.Time  `json:"deleted_at" gorm:"column:deleted_at;not null;index"`
+}
+
+// TableName especifica el nombre de la tabla para GORM
+func (MeasurementDeletion) TableName() string {
+	return "measurement_deletions"
+}
+
+// MeasurementRelocation registra que una medición dejó de pertenecer a una localidad porque su paciente
+// o el apoderado de este cambiaron de localidad, para que la sincronización la retire del origen y la
+// entregue al destino
+type MeasurementRelocation struct {
+	MeasurementID  uuid.UUID  `json:"measurement_id" gorm:"column:measurement_id;type:uuid;primaryKey"`
+	FromLocalityID uuid.UUID  `json:"from_locality_id" gorm:"column:from_locality_id;type:uuid;primaryKey"`
+	PatientID      uuid.UUID  `json:"patient_id" gorm:"column:patient_id;type:uuid;not null"`
+	ToLocalityID   *uuid.UUID `json:"to_locality_id,omitempty" gorm:"column:to_locality_id;type:uuid;index"`
+	MovedAt        time.Time  `json:"moved_at" gorm:"column:moved_at;not null;index"`
+}
+
+// TableName especifica el nombre de la tabla para GORM
+func (MeasurementRelocation) TableName() string {
+	return "measurement_relocations"
+}
+
+// MeasurementSyncFilter delimita la ventana de cambios a sincronizar: (Since, Until]
+type MeasurementSyncFilter struct {
+	Since      time.Time
+	Until      time.Time
+	LocalityID *uuid.UUID
+}
+
+// MeasurementSyncDelta contiene los cambios de mediciones dentro de la ventana
+type MeasurementSyncDelta struct {
+	Measurements []*Measurement
+	DeletedIDs   []uuid.UUID
+	// ServerTime es el cursor que el cliente debe enviar como since en la próxima sincronización
+	ServerTime time.Time
+}
+
+// MeasurementClassificationFix asigna el tag y la recomendación faltantes de una medición
+type MeasurementClassificationFix struct {
+	MeasurementID    uuid.UUID
+	TagID            *uuid.UUID
+	RecommendationID *uuid.UUID
+	// NewTag y NewRecommendation se crean junto con la corrección cuando todavía no existen; varias
+	// correcciones pueden compartir el mismo registro nuevo
+	NewTag            *Tag
+	NewRecommendation *Recommendation
+}
+
+// MeasurementBackfill resume la reparación de mediciones sin tag o recomendación
+type MeasurementBackfill struct {
+	DryRun  bool  `json:"dry_run"`
+	Pending int64 `json:"pending"` // Mediciones sin clasificación completa
+	Fixed   int64 `json:"fixed"`   // Corregidas (o que se corregirían en dry run)
+	Skipped int64 `json:"skipped"` // Sin tag ni recomendación aplicable para su valor
+}
+
+// MeasurementStoredClassification son los códigos MUAC del tag y la recomendación asignados a una medición
+type MeasurementStoredClassification struct {
+	MeasurementID          uuid.UUID
+	PatientID              uuid.UUID
+	MuacValue              float64
+	TagMuacCode            *string // nil si no tiene tag
+	RecommendationMuacCode *string // nil si no tiene recomendación
+	CreatedAt              time.Time
+}
+
+// ClassificationMismatch es una medición cuya clasificación guardada no coincide con ClassifyMuacValue
+type ClassificationMismatch struct {
+	MeasurementID          uuid.UUID `json:"measurement_id"`
+	PatientID              uuid.UUID `json:"patient_id"`
+	MuacValue              float64   `json:"muac_value"`
+	ExpectedMuacCode       string    `json:"expected_muac_code"`
+	TagMuacCode            *string   `json:"tag_muac_code"`
+	RecommendationMuacCode *string   `json:"recommendation_muac_code"`
+	TagMismatch            bool      `json:"tag_mismatch"`
+	RecommendationMismatch bool      `json:"recommendation_mismatch"`
+	CreatedAt              time.Time `json:"created_at"`
+}
+
+// Mismatch compara los códigos guardados con la clasificación actual del valor. Los tags o
+// recomendaciones ausentes (los completa el backfill) y los de seguimiento (MuacCodeFollow, que
+// no clasifican) no cuentan como diferencia. Devuelve nil si todo coincide
+func (c MeasurementStoredClassification) Mismatch() *ClassificationMismatch {
+	expected, _, _ := ClassifyMuacValue(c.MuacValue)
+	differs := func(code *string) bool {
+		return code != nil && *code != MuacCodeFollow && *code != expected
+	}
+
+	mismatch := &ClassificationMismatch{
+		MeasurementID:          c.MeasurementID,
+		PatientID:              c.PatientID,
+		MuacValue:              c.MuacValue,
+		ExpectedMuacCode:       expected,
+		TagMuacCode:            c.TagMuacCode,
+		RecommendationMuacCode: c.RecommendationMuacCode,
+		TagMismatch:            differs(c.TagMuacCode),
+		RecommendationMismatch: differs(c.RecommendationMuacCode),
+		CreatedAt:              c.CreatedAt,
+	}
+	if !mismatch.TagMismatch && !mismatch.RecommendationMismatch {
+		return nil
+	}
+	return mismatch
+}
+
+// SyncBundleVersion versiona el formato del paquete inicial; se incrementa si cambia su estructura
+const SyncBundleVersion = 1
+
+// Límites del paquete inicial para no saturar conexiones lentas
+const (
+	MaxBootstrapPatients     = 500
+	MaxBootstrapMeasurements = 2000
+)
+
+// SyncBootstrapBundle agrupa todos los datos de una localidad para la primera sincronización offline
+type SyncBootstrapBundle struct {
+	Version         int               `json:"version"`
+	Locality        *Locality         `json:"locality"`
+	Patients        []*Patient        `json:"patients"`
+	Measurements    []*Measurement    `json:"measurements"`
+	Recommendations []*Recommendation `json:"recommendations"`
+	FAQs            []*FAQGrouped     `json:"faqs"`
+	// Truncated indica que se alcanzó algún límite y el cliente debe completar con /api/sync/measurements.
+	// Measurements solo trae mediciones de los pacientes de Patients
+	Truncated bool `json:"truncated"`
+	// ServerTime es el cursor a enviar como since en la siguiente sincronización
+	ServerTime time.Time `json:"server_time"`
+}
@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestNormalizeMeasurementTime(t *testing.T) {
+	previous := MaxMeasurementClockSkew
+	t.Cleanup(func() { MaxMeasurementClockSkew = previous })
+	SetMaxMeasurementClockSkew(5 * time.Minute)
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		measuredAt   time.Time
+		want         time.Time
+		wantAdjusted bool
+		wantErr      error
+	}{
+		{"hora vacía toma now", time.Time{}, now, false, nil},
+		{"hora pasada se conserva", now.Add(-3 * time.Hour), now.Add(-3 * time.Hour), false, nil},
+		{"igual a now", now, now, false, nil},
+		{"adelanto dentro de la tolerancia se ajusta", now.Add(2 * time.Minute), now, true, nil},
+		{"adelanto igual a la tolerancia se ajusta", now.Add(5 * time.Minute), now, true, nil},
+		{"adelanto mayor a la tolerancia se rechaza", now.Add(6 * time.Minute), time.Time{}, false, ErrFutureMeasurementTime},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, adjusted, err := NormalizeMeasurementTime(tt.measuredAt, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, se esperaba %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("hora = %s, se esperaba %s", got, tt.want)
+			}
+			if adjusted != tt.wantAdjusted {
+				t.Errorf("adjusted = %v, se esperaba %v", adjusted, tt.wantAdjusted)
+			}
+		})
+	}
+}
+
+func TestMeasurementValidateRiskDescription(t *testing.T) {
+	previous := RequireDescriptionForRisk
+	t.Cleanup(func() { RequireDescriptionForRisk = previous })
+
+	tests := []struct {
+		name        string
+		required    bool
+		muacValue   float64
+		description string
+		wantErr     error
+	}{
+		{"rojo sin descripción", true, 10.5, "", ErrEmptyRiskDescription},
+		{"amarillo sin descripción", true, 12.0, "", ErrEmptyRiskDescription},
+		{"amarillo con solo espacios", true, 12.0, "   \t\n", ErrEmptyRiskDescription},
+		{"rojo con descripción", true, 10.5, "derivado al centro de salud", nil},
+		{"verde sin descripción", true, 13.5, "", nil},
+		{"rojo sin descripción con la regla desactivada", false, 10.5, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetRequireDescriptionForRisk(tt.required)
+			m := &Measurement{
+				MuacValue:   tt.muacValue,
+				Description: tt.description,
+				PatientID:   uuid.New(),
+				UserID:      uuid.New(),
+			}
+			if err := m.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, se esperaba %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMeasurementValidateTrimsDescription(t *testing.T) {
+	previous := RequireDescriptionForRisk
+	t.Cleanup(func() { RequireDescriptionForRisk = previous })
+	SetRequireDescriptionForRisk(true)
+
+	m := &Measurement{MuacValue: 10.5, Description: "  edema  ", PatientID: uuid.New(), UserID: uuid.New()}
+	if err := m.Validate(); err != nil {
+		t.Fatalf("err = %v, se esperaba nil", err)
+	}
+	if m.Description != "edema" {
+		t.Fatalf("description = %q, se esperaba %q", m.Description, "edema")
+	}
+}
+
+func TestMeasurementCheckModifiableBy(t *testing.T) {
+	previous := MeasurementEditWindow
+	t.Cleanup(func() { MeasurementEditWindow = previous })
+	SetMeasurementEditWindow(24 * time.Hour)
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	owner := &User{ID: uuid.New(), Role: Role{Name: "APODERADO"}}
+	other := &User{ID: uuid.New(), Role: Role{Name: "APODERADO"}}
+	supervisor := &User{ID: uuid.New(), Role: Role{Name: "SUPERVISOR"}}
+
+	received := func(d time.Duration) *time.Time {
+		t := now.Add(-d)
+		return &t
+	}
+
+	tests := []struct {
+		name       string
+		actor      *User
+		measuredAt time.Time
+		receivedAt *time.Time
+		wantErr    error
+	}{
+		{"dueño dentro de la ventana", owner, now.Add(-2 * time.Hour), received(2 * time.Hour), nil},
+		{"dueño fuera de la ventana", owner, now.Add(-30 * time.Hour), received(30 * time.Hour), ErrMeasurementEditExpired},
+		{"tomada hace días pero sincronizada recién", owner, now.Add(-72 * time.Hour), received(time.Hour), nil},
+		{"fila sin received_at usa la hora de toma", owner, now.Add(-30 * time.Hour), nil, ErrMeasurementEditExpired},
+		{"otro apoderado", other, now.Add(-time.Hour), received(time.Hour), ErrMeasurementNotOwner},
+		{"supervisor fuera de la ventana", supervisor, now.Add(-72 * time.Hour), received(72 * time.Hour), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Measurement{UserID: owner.ID, CreatedAt: tt.measuredAt, ReceivedAt: tt.receivedAt}
+			if err := m.CheckModifiableBy(tt.actor, now); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, se esperaba %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyMeasuredAtRecordsReceipt(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	measuredAt := now.Add(-48 * time.Hour)
+
+	m := &Measurement{}
+	if err := m.ApplyMeasuredAt(measuredAt, now); err != nil {
+		t.Fatalf("err = %v", err)
+	}
+	if !m.CreatedAt.Equal(measuredAt) {
+		t.Errorf("created_at = %s, se esperaba %s", m.CreatedAt, measuredAt)
+	}
+	if m.ReceivedAt == nil || !m.ReceivedAt.Equal(now) {
+		t.Errorf("received_at = %v, se esperaba %s", m.ReceivedAt, now)
+	}
+}
@@ -121,3 +121,104 @@ func GetMuacRiskLevel(muacValue float64) string {
 		return "Sin Clasificar"
 	}
 }
+
+// ============= CLASIFICACIÓN EN LOTE =============
+
+// MaxClassifyBatchSize es la cantidad máxima de valores aceptados en una clasificación en lote
+const MaxClassifyBatchSize = 200
+
+var (
+	ErrEmptyClassifyBatch    = fmt.Errorf("debe enviar al menos un valor MUAC")
+	ErrClassifyBatchTooLarge = fmt.Errorf("se permiten como máximo %d valores por lote", MaxClassifyBatchSize)
+)
+
+// MuacThresholdInfo describe el rango oficial (en cm) de un código MUAC; nil indica sin límite
+type MuacThresholdInfo struct {
+	MinValue    *float64 `json:"min_value"`
+	MaxValue    *float64 `json:"max_value"`
+	Description string   `json:"description"`
+}
+
+// MuacThresholdSet son los umbrales con que se clasifica una medición. Cada medición guarda los
+// vigentes al crearse para que su clasificación se pueda reproducir si los umbrales cambian
+type MuacThresholdSet struct {
+	Severe   float64 `json:"severe" gorm:"type:decimal(4,1);not null;default:11.5"`   // Rojo por debajo
+	Moderate float64 `json:"moderate" gorm:"type:decimal(4,1);not null;default:12.4"` // Límite superior del amarillo
+	Normal   float64 `json:"normal" gorm:"type:decimal(4,1);not null;default:12.5"`   // Verde desde este valor
+}
+
+// CurrentMuacThresholds devuelve los umbrales vigentes
+func CurrentMuacThresholds() MuacThresholdSet {
+	return MuacThresholdSet{Severe: MuacThresholdSevere, Moderate: MuacThresholdModerate, Normal: MuacThresholdNormal}
+}
+
+// IsZero indica que no se registraron umbrales
+func (t MuacThresholdSet) IsZero() bool {
+	return t == MuacThresholdSet{}
+}
+
+// Classify devuelve el código MUAC del valor con estos umbrales
+func (t MuacThresholdSet) Classify(muacValue float64) string {
+	switch {
+	case muacValue >= t.Normal:
+		return MuacCodeGreen
+	case muacValue >= t.Severe:
+		return MuacCodeYellow
+	default:
+		return MuacCodeRed
+	}
+}
+
+// GetMuacThresholdInfo obtiene el rango oficial del código MUAC indicado
+func GetMuacThresholdInfo(muacCode string) MuacThresholdInfo {
+	return CurrentMuacThresholds().Info(muacCode)
+}
+
+// Info obtiene el rango del código MUAC con estos umbrales
+func (t MuacThresholdSet) Info(muacCode string) MuacThresholdInfo {
+	severe, moderate, normal := t.Severe, t.Moderate, t.Normal
+	switch muacCode {
+	case MuacCodeRed:
+		return MuacThresholdInfo{MaxValue: &severe, Description: fmt.Sprintf("< %.1f cm", severe)}
+	case MuacCodeYellow:
+		return MuacThresholdInfo{MinValue: &severe, MaxValue: &moderate, Description: fmt.Sprintf("%.1f-%.1f cm", severe, moderate)}
+	case MuacCodeGreen:
+		return MuacThresholdInfo{MinValue: &normal, Description: fmt.Sprintf("≥ %.1f cm", normal)}
+	default:
+		return MuacThresholdInfo{Description: "Sin rango definido"}
+	}
+}
+
+// MuacClassification es el resultado de clasificar un valor dentro de un lote.
+// Si el valor es inválido solo se informan Index, Value y Error
+type MuacClassification struct {
+	Index     int                `json:"index"`
+	Value     float64            `json:"value"`
+	MuacCode  string             `json:"muac_code,omitempty"`
+	ColorCode string             `json:"color_code,omitempty"`
+	Priority  int                `json:"priority,omitempty"`
+	RiskLevel string             `json:"risk_level,omitempty"`
+	Threshold *MuacThresholdInfo `json:"threshold,omitempty"`
+	Error     string             `json:"error,omitempty"`
+}
+
+// ClassifyMuacBatch clasifica cada valor por separado; los valores fuera de rango se reportan con su error
+func ClassifyMuacBatch(values []float64) []MuacClassification {
+	results := make([]MuacClassification, len(values))
+	for i, value := range values {
+		results[i] = MuacClassification{Index: i, Value: value}
+		if !IsValidMuacValue(value) {
+			results[i].Error = ErrInvalidMuacValue.Error()
+			continue
+		}
+
+		muacCode, colorCode, priority := ClassifyMuacValue(value)
+		threshold := GetMuacThresholdInfo(muacCode)
+		results[i].MuacCode = muacCode
+		results[i].ColorCode = colorCode
+		results[i].Priority = priority
+		results[i].RiskLevel = GetMuacRiskLevel(value)
+		results[i].Threshold = &threshold
+	}
+	return results
+}
@@ -1,11 +1,56 @@
 package domain
 
 import (
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Estados de entrega de una notificación
+const (
+	DeliveryStatusNone    = "NO-APLICA"
+	DeliveryStatusPending = "PENDIENTE"
+	DeliveryStatusSent    = "ENVIADA"
+	DeliveryStatusFailed  = "FALLIDA"
+	DeliveryStatusSkipped = "OMITIDA" // el destinatario desactivó este tipo de notificación
+)
+
+// MaxNotificationAttempts limita los intentos de entrega (incluye reenvíos manuales)
+const MaxNotificationAttempts = 5
+
+// AllowedWebhookHosts son los hosts a los que se pueden entregar notificaciones; se configura al iniciar
+// la aplicación. Sin hosts no se admite ningún destino externo
+var AllowedWebhookHosts []string
+
+// SetAllowedWebhookHosts actualiza los hosts permitidos para los destinos de notificaciones
+func SetAllowedWebhookHosts(hosts []string) {
+	allowed := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			allowed = append(allowed, host)
+		}
+	}
+	AllowedWebhookHosts = allowed
+}
+
+// ValidateNotificationTarget verifica que el destino sea una URL https sin credenciales cuyo host esté
+// en AllowedWebhookHosts. La resolución a IPs privadas se rechaza al conectar
+func ValidateNotificationTarget(target string) error {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "https" || u.User != nil || u.Hostname() == "" {
+		return ErrInvalidNotificationTarget
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range AllowedWebhookHosts {
+		if host == allowed {
+			return nil
+		}
+	}
+	return ErrInvalidNotificationTarget
+}
+
 // Notification representa la entidad de notificación en el dominio
 type Notification struct {
 	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
@@ -14,6 +59,21 @@ type Notification struct {
 	Visible   bool      `json:"visible" gorm:"column:visible;default:false"`
 	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+
+	// Ventana opcional en que la notificación visible se muestra como aviso en la app
+	StartsAt  *time.Time `json:"starts_at,omitempty" gorm:"column:starts_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" gorm:"column:expires_at"`
+
+	// Tipo de notificación y usuario al que se dirige; sin destinatario es un comunicado de sistema
+	Kind        string     `json:"kind" gorm:"column:kind;type:varchar(20);default:broadcast"`
+	RecipientID *uuid.UUID `json:"recipient_id,omitempty" gorm:"column:recipient_id;type:uuid;index"`
+
+	// Entrega a un destino externo (webhook); vacío si la notificación es solo interna
+	Target         string     `json:"target,omitempty" gorm:"column:target;type:text"`
+	DeliveryStatus string     `json:"delivery_status" gorm:"column:delivery_status;type:varchar(20)"`
+	Attempts       int        `json:"attempts" gorm:"column:attempts;default:0"`
+	LastError      string     `json:"last_error,omitempty" gorm:"column:last_error;type:text"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty" gorm:"column:last_attempt_at"`
 }
 
 // TableName especifica el nombre de la tabla para GORM
@@ -21,6 +81,25 @@ func (Notification) TableName() string {
 	return "notifications"
 }
 
+// NotificationRead registra que un usuario leyó una notificación visible; sin fila, está sin leer
+type NotificationRead struct {
+	NotificationID uuid.UUID `json:"notification_id" gorm:"type:uuid;primaryKey"`
+	UserID         uuid.UUID `json:"user_id" gorm:"type:uuid;primaryKey;index"`
+	ReadAt         time.Time `json:"read_at" gorm:"column:read_at;not null"`
+}
+
+// TableName especifica el nombre de la tabla para GORM
+func (NotificationRead) TableName() string {
+	return "notification_reads"
+}
+
+// NotificationsReadResult es el resultado de marcar como leídas las notificaciones de un usuario
+type NotificationsReadResult struct {
+	UserID uuid.UUID `json:"user_id"`
+	Before time.Time `json:"before"`
+	Marked int64     `json:"marked"`
+}
+
 // NewNotification crea una nueva instancia de Notification
 func NewNotification(title, body string, visible bool) *Notification {
 	return &Notification{
@@ -29,7 +108,71 @@ func NewNotification(title, body string, visible bool) *Notification {
 		Body:      body,
 		Visible:   visible,
 		CreatedAt: time.Now(),
+
+		Kind:           NotificationKindBroadcast,
+		DeliveryStatus: DeliveryStatusNone,
+	}
+}
+
+// SetTarget asigna el destino externo y deja la entrega pendiente
+func (n *Notification) SetTarget(target string) {
+	n.Target = target
+	if target == "" {
+		n.DeliveryStatus = DeliveryStatusNone
+		return
+	}
+	n.DeliveryStatus = DeliveryStatusPending
+}
+
+// SetRecipient dirige la notificación a un usuario con el tipo indicado (vacío = broadcast); la entrega
+// respeta sus preferencias de notificación
+func (n *Notification) SetRecipient(recipientID *uuid.UUID, kind string) {
+	if recipientID != nil && *recipientID == uuid.Nil {
+		recipientID = nil
+	}
+	if kind == "" {
+		kind = NotificationKindBroadcast
 	}
+	n.RecipientID = recipientID
+	n.Kind = kind
+}
+
+// SkipDelivery registra que la entrega se omitió porque el destinatario no acepta este tipo de
+// notificación; no cuenta como intento
+func (n *Notification) SkipDelivery() {
+	n.DeliveryStatus = DeliveryStatusSkipped
+	n.LastError = ""
+}
+
+// CanResend valida que la notificación admita un nuevo intento de entrega
+func (n *Notification) CanResend() error {
+	if n.Target == "" {
+		return ErrNotificationNoTarget
+	}
+	if err := ValidateNotificationTarget(n.Target); err != nil {
+		return err
+	}
+	if n.DeliveryStatus == DeliveryStatusSent {
+		return ErrNotificationAlreadySent
+	}
+	if n.Attempts >= MaxNotificationAttempts {
+		return ErrNotificationResendLimit
+	}
+	return nil
+}
+
+// RecordDelivery registra el resultado de un intento de entrega
+func (n *Notification) RecordDelivery(deliveryErr error) {
+	now := time.Now()
+	n.Attempts++
+	n.LastAttemptAt = &now
+	if deliveryErr != nil {
+		n.DeliveryStatus = DeliveryStatusFailed
+		n.LastError = deliveryErr.Error()
+		return
+	}
+	n.DeliveryStatus = DeliveryStatusSent
+	n.LastError = ""
 }
 
 // Validate valida que la notificación tenga los campos requeridos
@@ -37,9 +180,24 @@ func (n *Notification) Validate() error {
 	if n.Title == "" {
 		return ErrEmptyNotificationTitle
 	}
+	if n.StartsAt != nil && n.ExpiresAt != nil && !n.ExpiresAt.After(*n.StartsAt) {
+		return ErrInvalidAnnouncementWindow
+	}
+	if !IsValidNotificationKind(n.Kind) {
+		return ErrInvalidNotificationKind
+	}
+	if n.Target != "" {
+		return ValidateNotificationTarget(n.Target)
+	}
 	return nil
 }
 
+// SetWindow establece desde y hasta cuándo se muestra el aviso; nil deja ese extremo abierto
+func (n *Notification) SetWindow(startsAt, expiresAt *time.Time) {
+	n.StartsAt = startsAt
+	n.ExpiresAt = expiresAt
+}
+
 // Update actualiza los campos de la notificación
 func (n *Notification) Update(title, body string, visible bool) {
 	n.Title = title
@@ -0,0 +1,41 @@
+package domain
+
+// ============= PAGINACIÓN =============
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 200
+)
+
+// Pagination representa los parámetros y el resultado de una consulta paginada
+type Pagination struct {
+	Page     int   `json:"page"`
+	PageSize int   `json:"page_size"`
+	Total    int64 `json:"total"`
+}
+
+// NewPagination crea una paginación normalizando valores fuera de rango
+func NewPagination(page, pageSize int) *Pagination {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+	return &Pagination{Page: page, PageSize: pageSize}
+}
+
+// Offset devuelve el desplazamiento para la consulta
+func (p *Pagination) Offset() int {
+	return (p.Page - 1) * p.PageSize
+}
+
+// TotalPages devuelve el número total de páginas
+func (p *Pagination) TotalPages() int {
+	if p.PageSize == 0 {
+		return 0
+	}
+	return int((p.Total + int64(p.PageSize) - 1) / int64(p.PageSize))
+}
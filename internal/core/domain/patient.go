@@ -1,6 +1,11 @@
 package domain
 
 import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -25,9 +30,27 @@ type Patient struct {
 	CreatedAt    time.Time `json:"created_at,omitempty" gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
 	UpdatedAt    time.Time `json:"updated_at,omitempty" gorm:"column:updated_at"`
 
+	// Auditoría del último cambio de consentimiento (retiro o nuevo consentimiento)
+	ConsentUpdatedAt *time.Time `json:"consent_updated_at,omitempty" gorm:"column:consent_updated_at"`
+	ConsentUpdatedBy *uuid.UUID `json:"consent_updated_by,omitempty" gorm:"column:consent_updated_by;type:uuid"`
+	ConsentReason    string     `json:"consent_reason,omitempty" gorm:"column:consent_reason;type:text"`
+
+	// Aprobación del supervisor; los pacientes anteriores a la regla quedan aprobados
+	ApprovalStatus    string     `json:"approval_status" gorm:"type:varchar(20);not null;default:approved;index"`
+	ApprovalNote      string     `json:"approval_note,omitempty" gorm:"type:text"`
+	ApprovalUpdatedAt *time.Time `json:"approval_updated_at,omitempty" gorm:"column:approval_updated_at"`
+	ApprovalUpdatedBy *uuid.UUID `json:"approval_updated_by,omitempty" gorm:"column:approval_updated_by;type:uuid"`
+
+	// Excepción administrativa al rango de edad, con nota de auditoría obligatoria
+	AgeOverride     bool   `json:"age_override" gorm:"type:boolean;default:false"`
+	AgeOverrideNote string `json:"age_override_note,omitempty" gorm:"type:text"`
+
 	Measurements []Measurement `json:"measurements" gorm:"foreignKey:PatientID"`
 	UserID       *uuid.UUID    `json:"user_id" gorm:"column:user_id;type:uuid"`
 	User         *User         `json:"user,omitempty" gorm:"foreignKey:UserID"`
+
+	// Solo se completa cuando se solicita expand=latest_measurement
+	LatestMeasurement *Measurement `json:"latest_measurement,omitempty" gorm:"-"`
 }
 
 // TableName especifica el nombre de la tabla para GORM
@@ -63,7 +86,8 @@ func NewPatient(
 	}
 }
 
-// Validate valida que el paciente tenga los campos requeridos
+// Validate valida que el paciente tenga los campos requeridos. El rango de edad se valida aparte con
+// ValidateAge, porque el niño lo supera con el tiempo y eso no debe impedir editar su registro
 func (p *Patient) Validate() error {
 	if p.Name == "" {
 		return ErrEmptyPatientName
@@ -71,6 +95,132 @@ func (p *Patient) Validate() error {
 	if p.Lastname == "" {
 		return ErrEmptyPatientLastName
 	}
+	if p.AgeOverride && p.AgeOverrideNote == "" {
+		return ErrEmptyAgeOverrideNote
+	}
+	return nil
+}
+
+// ValidateAge valida la edad que el paciente tiene en now contra el rango configurado, salvo excepción
+// con nota. La edad se calcula desde BirthDate; Age solo se usa si la fecha no se reconoce
+func (p *Patient) ValidateAge(now time.Time) error {
+	if p.AgeOverride {
+		if p.AgeOverrideNote == "" {
+			return ErrEmptyAgeOverrideNote
+		}
+		return nil
+	}
+	if birthDate, ok := ParseBirthDate(p.BirthDate); ok {
+		return ValidateAgeMonths(AgeInMonths(birthDate, now))
+	}
+	return ValidateAgeYears(p.Age)
+}
+
+// AgeCheckRequired indica si una edición debe volver a validar el rango de edad: solo cuando cambia la
+// fecha de nacimiento o se retira la excepción administrativa
+func (p *Patient) AgeCheckRequired(previous *Patient) bool {
+	return p.BirthDate != previous.BirthDate || (previous.AgeOverride && !p.AgeOverride)
+}
+
+// SetAgeOverride marca la excepción administrativa de edad con su nota de auditoría
+func (p *Patient) SetAgeOverride(override bool, note string) {
+	p.AgeOverride = override
+	if override {
+		p.AgeOverrideNote = note
+	} else {
+		p.AgeOverrideNote = ""
+	}
+}
+
+// SetConsent registra el retiro o un nuevo consentimiento con su auditoría. El retiro exige
+// un motivo; al volver a consentir se actualiza ConsentDate. actorID puede ser nil
+func (p *Patient) SetConsent(given bool, reason string, actorID *uuid.UUID, now time.Time) error {
+	reason = strings.TrimSpace(reason)
+	if !given && reason == "" {
+		return ErrEmptyConsentReason
+	}
+	p.ConsentGiven = given
+	if given {
+		p.ConsentDate = now
+	}
+	p.ConsentReason = reason
+	p.ConsentUpdatedAt = &now
+	p.ConsentUpdatedBy = actorID
+	p.UpdatedAt = now
+	return nil
+}
+
+// Estados de aprobación del paciente
+const (
+	PatientApprovalPending  = "pending"
+	PatientApprovalApproved = "approved"
+	PatientApprovalRejected = "rejected"
+)
+
+// PatientApprovalRequired exige que un supervisor apruebe a los pacientes registrados por apoderados;
+// se configura al iniciar la aplicación
+var PatientApprovalRequired = false
+
+// SetPatientApprovalRequired activa o desactiva la aprobación de pacientes
+func SetPatientApprovalRequired(required bool) {
+	PatientApprovalRequired = required
+}
+
+// InitApproval fija el estado inicial: pendiente si la aprobación está activada y el paciente
+// queda asignado a un APODERADO; en cualquier otro caso, aprobado
+func (p *Patient) InitApproval(assignedRole string) {
+	p.ApprovalStatus = PatientApprovalApproved
+	if PatientApprovalRequired && assignedRole == "APODERADO" {
+		p.ApprovalStatus = PatientApprovalPending
+	}
+}
+
+// SetApproval aprueba o rechaza al paciente registrando quién y cuándo. El rechazo exige una nota;
+// un paciente rechazado puede aprobarse después y viceversa, pero no volver a pendiente
+func (p *Patient) SetApproval(status, note string, actorID *uuid.UUID, now time.Time) error {
+	note = strings.TrimSpace(note)
+	if status != PatientApprovalApproved && status != PatientApprovalRejected {
+		return ErrInvalidApprovalStatus
+	}
+	if status == p.ApprovalStatus {
+		return ErrApprovalUnchanged
+	}
+	if status == PatientApprovalRejected && note == "" {
+		return ErrEmptyRejectionNote
+	}
+	p.ApprovalStatus = status
+	p.ApprovalNote = note
+	p.ApprovalUpdatedAt = &now
+	p.ApprovalUpdatedBy = actorID
+	p.UpdatedAt = now
+	return nil
+}
+
+// RecomputeAge vuelve a calcular la edad (años, con dos decimales) a partir de la fecha de nacimiento a
+// la fecha now; devuelve la edad anterior. No valida el rango de edad: la edad recalculada es la real
+func (p *Patient) RecomputeAge(now time.Time) (float64, error) {
+	birthDate, ok := ParseBirthDate(p.BirthDate)
+	if !ok {
+		return p.Age, ErrRecomputeBirthDate
+	}
+	months := AgeInMonths(birthDate, now)
+	if months < 0 {
+		return p.Age, ErrRecomputeBirthDate
+	}
+
+	previous := p.Age
+	p.Age = math.Round(float64(months)/12*100) / 100
+	if p.Age != previous {
+		p.UpdatedAt = now
+	}
+	return previous, nil
+}
+
+// ValidateConsent impide registrar mediciones de un paciente cuya familia retiró el consentimiento
+func (p *Patient) ValidateConsent() error {
+	if !p.ConsentGiven {
+		return ErrPatientConsentWithdrawn
+	}
 	return nil
 }
 
@@ -88,3 +238,446 @@ func (p *Patient) Update(name, lastname, gender, birthDate, armSize, weight, siz
 	p.Description = description
 	p.UpdatedAt = time.Now()
 }
+
+// PatientFieldChange es un campo del paciente con su valor actual y el propuesto
+type PatientFieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// PatientDiff son los cambios que produciría una edición del paciente
+type PatientDiff struct {
+	PatientID uuid.UUID            `json:"patient_id"`
+	Changed   bool                 `json:"changed"`
+	Changes   []PatientFieldChange `json:"changes"`
+}
+
+// DiffPatients compara los campos editables de dos versiones del mismo paciente
+func DiffPatients(current, proposed *Patient) *PatientDiff {
+	diff := &PatientDiff{PatientID: current.ID, Changes: []PatientFieldChange{}}
+	add := func(field string, old, new interface{}) {
+		diff.Changes = append(diff.Changes, PatientFieldChange{Field: field, Old: old, New: new})
+	}
+
+	for _, f := range []struct {
+		field    string
+		old, new string
+	}{
+		{"name", current.Name, proposed.Name},
+		{"lastname", current.Lastname, proposed.Lastname},
+		{"dni", current.DNI, proposed.DNI},
+		{"gender", current.Gender, proposed.Gender},
+		{"birth_date", current.BirthDate, proposed.BirthDate},
+		{"arm_size", current.ArmSize, proposed.ArmSize},
+		{"weight", current.Weight, proposed.Weight},
+		{"size", current.Size, proposed.Size},
+		{"description", current.Description, proposed.Description},
+		{"age_override_note", current.AgeOverrideNote, proposed.AgeOverrideNote},
+	} {
+		if f.old != f.new {
+			add(f.field, f.old, f.new)
+		}
+	}
+	if current.Age != proposed.Age {
+		add("age", current.Age, proposed.Age)
+	}
+	if current.ConsentGiven != proposed.ConsentGiven {
+		add("consent_given", current.ConsentGiven, proposed.ConsentGiven)
+	}
+	if current.AgeOverride != proposed.AgeOverride {
+		add("age_override", current.AgeOverride, proposed.AgeOverride)
+	}
+	if (current.UserID == nil) != (proposed.UserID == nil) ||
+		(current.UserID != nil && *current.UserID != *proposed.UserID) {
+		add("user_id", current.UserID, proposed.UserID)
+	}
+
+	diff.Changed = len(diff.Changes) > 0
+	return diff
+}
+
+// DNILength es la cantidad de dígitos de un DNI peruano
+const DNILength = 8
+
+// NormalizeDNI quita espacios, puntos y guiones del DNI ingresado
+func NormalizeDNI(dni string) string {
+	return strings.NewReplacer(" ", "", ".", "", "-", "").Replace(strings.TrimSpace(dni))
+}
+
+// ValidateDNI comprueba que el DNI (ya normalizado) tenga exactamente DNILength dígitos
+func ValidateDNI(dni string) error {
+	if len(dni) != DNILength {
+		return ErrInvalidDNIFormat
+	}
+	for _, c := range dni {
+		if c < '0' || c > '9' {
+			return ErrInvalidDNIFormat
+		}
+	}
+	return nil
+}
+
+// DNICheck es el resultado de validar un DNI mientras se registra un paciente
+type DNICheck struct {
+	DNI       string `json:"dni"` // DNI normalizado
+	Valid     bool   `json:"valid"`
+	Available bool   `json:"available"`        // Válido y sin paciente registrado
+	Reason    string `json:"reason,omitempty"` // Motivo cuando no es válido o no está disponible
+}
+
+// DuplicateDNIPatient es un registro de paciente que comparte DNI con otros
+type DuplicateDNIPatient struct {
+	PatientID uuid.UUID  `json:"patient_id"`
+	Name      string     `json:"name"`
+	Lastname  string     `json:"lastname"`
+	DNI       string     `json:"dni"` // tal como está guardado
+	UserID    *uuid.UUID `json:"user_id"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// DuplicateDNIGroup agrupa los pacientes cuyo DNI coincide al normalizarlo (sin espacios, puntos
+// ni guiones y en mayúsculas); la columna dni es única, así que los duplicados reales llegan
+// con variaciones de formato
+type DuplicateDNIGroup struct {
+	DNI      string                `json:"dni"` // DNI normalizado
+	Count    int                   `json:"count"`
+	Patients []DuplicateDNIPatient `json:"patients"`
+}
+
+// Motivos por los que un paciente queda sin apoderado responsable
+const (
+	OrphanReasonNoCaregiver       = "no_caregiver"       // Sin user_id
+	OrphanReasonCaregiverDeleted  = "caregiver_deleted"  // El user_id no existe
+	OrphanReasonCaregiverInactive = "caregiver_inactive" // El apoderado está desactivado
+)
+
+// OrphanedPatient es un paciente sin apoderado activo, para reasignarlo. La localidad es la del
+// apoderado desactivado; sin apoderado no se conoce
+type OrphanedPatient struct {
+	PatientID      uuid.UUID  `json:"patient_id"`
+	Name           string     `json:"name"`
+	Lastname       string     `json:"lastname"`
+	DNI            string     `json:"dni"`
+	UserID         *uuid.UUID `json:"user_id"`
+	CaregiverName  string     `json:"caregiver_name"`
+	LocalityID     *uuid.UUID `json:"locality_id"`
+	LocalityName   string     `json:"locality_name"`
+	Reason         string     `json:"reason"`
+	LastMeasuredAt *time.Time `json:"last_measured_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// PatientStatusNoMeasurements indica que el paciente aún no tiene mediciones
+const PatientStatusNoMeasurements = "SIN-MEDICION"
+
+// PatientStatus resume el estado nutricional actual de un paciente
+type PatientStatus struct {
+	PatientID  uuid.UUID  `json:"patient_id"`
+	MuacCode   string     `json:"muac_code"`
+	Color      string     `json:"color"`
+	Label      string     `json:"label"`
+	MuacValue  *float64   `json:"muac_value,omitempty"`
+	MeasuredAt *time.Time `json:"measured_at,omitempty"`
+}
+
+// NewPatientStatus construye el estado a partir de la última medición (nil si no existe)
+func NewPatientStatus(patientID uuid.UUID, latest *Measurement) *PatientStatus {
+	if latest == nil {
+		return &PatientStatus{
+			PatientID: patientID,
+			MuacCode:  PatientStatusNoMeasurements,
+			Color:     ColorGray,
+			Label:     "Sin mediciones",
+		}
+	}
+
+	muacCode, color, _ := ClassifyMuacValue(latest.MuacValue)
+	value := latest.MuacValue
+	measuredAt := latest.CreatedAt
+
+	return &PatientStatus{
+		PatientID:  patientID,
+		MuacCode:   muacCode,
+		Color:      color,
+		Label:      GetMuacRiskLevel(latest.MuacValue),
+		MuacValue:  &value,
+		MeasuredAt: &measuredAt,
+	}
+}
+
+// ============= CUMPLIMIENTO DE CONTROLES =============
+
+// ExpectedMeasurementDays son los días recomendados entre mediciones según la clasificación de la
+// última medición; se configuran al iniciar la aplicación
+var ExpectedMeasurementDays = map[string]int{
+	MuacCodeRed:    7,
+	MuacCodeYellow: 7,
+	MuacCodeGreen:  30,
+}
+
+// SetExpectedMeasurementDays actualiza la frecuencia de una clasificación, ignorando valores no positivos
+func SetExpectedMeasurementDays(muacCode string, days int) {
+	if days <= 0 {
+		return
+	}
+	ExpectedMeasurementDays[muacCode] = days
+}
+
+// PatientCompliance indica si las mediciones del paciente siguen la frecuencia recomendada.
+// Cada intervalo se evalúa con la frecuencia de la clasificación de la medición que lo inicia
+type PatientCompliance struct {
+	PatientID            uuid.UUID `json:"patient_id"`
+	MuacCode             string    `json:"muac_code"`
+	ExpectedIntervalDays int       `json:"expected_interval_days"` // Según la clasificación actual
+	Measurements         int       `json:"measurements"`
+	// Sufficient es false si hay menos de dos mediciones; en ese caso no hay intervalos que evaluar
+	Sufficient          bool     `json:"sufficient"`
+	AverageIntervalDays *float64 `json:"average_interval_days"`
+	OnTime              int      `json:"on_time"`
+	Late                int      `json:"late"`
+	Score               *float64 `json:"score"` // Porcentaje de intervalos a tiempo
+}
+
+// NewPatientCompliance calcula el cumplimiento a partir de las mediciones del paciente
+func NewPatientCompliance(patientID uuid.UUID, measurements []*Measurement) *PatientCompliance {
+	sorted := make([]*Measurement, len(measurements))
+	copy(sorted, measurements)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].CreatedAt.Before(sorted[j].CreatedAt) })
+
+	compliance := &PatientCompliance{
+		PatientID:    patientID,
+		MuacCode:     PatientStatusNoMeasurements,
+		Measurements: len(sorted),
+	}
+	if len(sorted) == 0 {
+		return compliance
+	}
+
+	compliance.MuacCode, _, _ = ClassifyMuacValue(sorted[len(sorted)-1].MuacValue)
+	compliance.ExpectedIntervalDays = ExpectedMeasurementDays[compliance.MuacCode]
+	if len(sorted) < 2 {
+		return compliance
+	}
+
+	var totalDays float64
+	for i := 1; i < len(sorted); i++ {
+		days := sorted[i].CreatedAt.Sub(sorted[i-1].CreatedAt).Hours() / 24
+		totalDays += days
+
+		code, _, _ := ClassifyMuacValue(sorted[i-1].MuacValue)
+		if days <= float64(ExpectedMeasurementDays[code]) {
+			compliance.OnTime++
+		} else {
+			compliance.Late++
+		}
+	}
+
+	intervals := float64(len(sorted) - 1)
+	average := math.Round(totalDays/intervals*10) / 10
+	score := math.Round(float64(compliance.OnTime)/intervals*1000) / 10
+	compliance.Sufficient = true
+	compliance.AverageIntervalDays = &average
+	compliance.Score = &score
+	return compliance
+}
+
+// ============= SPARKLINE =============
+const (
+	DefaultSparklinePoints = 10
+	MaxSparklinePoints     = 100
+)
+
+// SparklinePoint representa un punto de la tendencia compacta de MUAC
+type SparklinePoint struct {
+	MuacValue  float64   `json:"muac_value"`
+	MuacCode   string    `json:"muac_code"`
+	MeasuredAt time.Time `json:"measured_at"`
+}
+
+// ============= EXPANSIÓN DE RELACIONES =============
+const (
+	PatientExpandLatestMeasurement = "latest_measurement"
+	PatientExpandUser              = "user"
+	PatientExpandLocality          = "locality"
+)
+
+// PatientExpand indica qué relaciones cargar al listar pacientes
+type PatientExpand struct {
+	LatestMeasurement bool
+	User              bool
+	Locality          bool // La localidad se anida en user.locality
+}
+
+// ParsePatientExpand valida una lista separada por comas contra las relaciones permitidas
+func ParsePatientExpand(value string) (PatientExpand, error) {
+	var expand PatientExpand
+	for _, token := range strings.Split(value, ",") {
+		switch strings.TrimSpace(token) {
+		case "":
+		case PatientExpandLatestMeasurement:
+			expand.LatestMeasurement = true
+		case PatientExpandUser:
+			expand.User = true
+		case PatientExpandLocality:
+			expand.Locality = true
+		default:
+			return expand, fmt.Errorf("%w: %s", ErrInvalidExpand, strings.TrimSpace(token))
+		}
+	}
+	return expand, nil
+}
+
+// IsEmpty indica si no se solicitó ninguna relación
+func (e PatientExpand) IsEmpty() bool {
+	return !e.LatestMeasurement && !e.User && !e.Locality
+}
+
+// PatientExportRow es una fila de la exportación plana de pacientes
+type PatientExportRow struct {
+	PatientID        uuid.UUID
+	Name             string
+	Lastname         string
+	DNI              string
+	Gender           string
+	Age              float64
+	BirthDate        string
+	ConsentGiven     bool
+	CreatedAt        time.Time
+	CaregiverID      *uuid.UUID
+	CaregiverName    string
+	LocalityName     string
+	MeasurementCount int64
+	LatestMuac       *float64
+	LatestMeasuredAt *time.Time
+}
+
+// PatientExportHeader son las columnas de la exportación, en el orden de Record
+var PatientExportHeader = []string{
+	"patient_id", "name", "lastname", "dni", "gender", "age", "birth_date", "consent_given", "created_at",
+	"caregiver_id", "caregiver_name", "locality", "measurement_count",
+	"latest_muac", "latest_muac_code", "latest_measured_at",
+}
+
+// Record devuelve la fila como texto; sin mediciones la clasificación es PatientStatusNoMeasurements
+func (r PatientExportRow) Record() []string {
+	caregiverID, latestMuac, latestAt := "", "", ""
+	muacCode := PatientStatusNoMeasurements
+	if r.CaregiverID != nil {
+		caregiverID = r.CaregiverID.String()
+	}
+	if r.LatestMuac != nil {
+		latestMuac = strconv.FormatFloat(*r.LatestMuac, 'f', 1, 64)
+		muacCode, _, _ = ClassifyMuacValue(*r.LatestMuac)
+	}
+	if r.LatestMeasuredAt != nil {
+		latestAt = r.LatestMeasuredAt.Format(time.RFC3339)
+	}
+	return []string{
+		r.PatientID.String(), r.Name, r.Lastname, r.DNI, r.Gender,
+		strconv.FormatFloat(r.Age, 'f', -1, 64), r.BirthDate, strconv.FormatBool(r.ConsentGiven),
+		r.CreatedAt.Format(time.RFC3339),
+		caregiverID, r.CaregiverName, r.LocalityName, strconv.FormatInt(r.MeasurementCount, 10),
+		latestMuac, muacCode, latestAt,
+	}
+}
+
+// ScopePatientExport ajusta los filtros de la exportación al alcance del rol del usuario:
+// el administrador exporta todo, el supervisor solo su localidad y el apoderado solo sus pacientes
+func ScopePatientExport(actor *User, filters *ReportFilters) error {
+	switch actor.Role.Name {
+	case "ADMINISTRADOR":
+		return nil
+	case "SUPERVISOR":
+		if actor.LocalityID == nil {
+			return ErrPatientExportForbidden
+		}
+		if filters.LocalityID != nil && *filters.LocalityID != *actor.LocalityID {
+			return ErrPatientExportForbidden
+		}
+		filters.LocalityID = actor.LocalityID
+		return nil
+	case "APODERADO":
+		if filters.UserID != nil && *filters.UserID != actor.ID {
+			return ErrPatientExportForbidden
+		}
+		filters.UserID = &actor.ID
+		return nil
+	default:
+		return ErrPatientExportForbidden
+	}
+}
+
+// ============= FICHA PARA REFERENCIAS =============
+
+// PatientRecordData son los datos del niño que se comparten con el centro de salud; excluye la
+// imagen del DNI y las notas internas de consentimiento, aprobación y excepción de edad
+type PatientRecordData struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	Lastname     string    `json:"lastname"`
+	DNI          string    `json:"dni"`
+	Gender       string    `json:"gender"`
+	Age          float64   `json:"age"`
+	BirthDate    string    `json:"birth_date"`
+	ArmSize      string    `json:"arm_size"`
+	Weight       string    `json:"weight"`
+	Size         string    `json:"size"`
+	ConsentGiven bool      `json:"consent_given"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// CaregiverContact es el contacto del apoderado responsable; sin DNI, correo ni credenciales
+type CaregiverContact struct {
+	UserID                     uuid.UUID `json:"user_id"`
+	Name                       string    `json:"name"`
+	LastName                   string    `json:"lastname"`
+	Phone                      string    `json:"phone"`
+	LocalityName               string    `json:"locality_name,omitempty"`
+	LocalityMedicalCenterPhone string    `json:"locality_medical_center_phone,omitempty"`
+}
+
+// PatientRecord es la ficha del paciente para una referencia: datos, última medición clasificada
+// y contacto del apoderado (nil si no tiene)
+type PatientRecord struct {
+	Patient     PatientRecordData `json:"patient"`
+	Status      *PatientStatus    `json:"status"`
+	Caregiver   *CaregiverContact `json:"caregiver"`
+	GeneratedAt time.Time         `json:"generated_at"`
+}
+
+// NewPatientRecord arma la ficha; latest es la última medición (nil si no tiene) y caregiver puede ser nil
+func NewPatientRecord(patient *Patient, latest *Measurement, caregiver *User, now time.Time) *PatientRecord {
+	record := &PatientRecord{
+		Patient: PatientRecordData{
+			ID:           patient.ID,
+			Name:         patient.Name,
+			Lastname:     patient.Lastname,
+			DNI:          patient.DNI,
+			Gender:       patient.Gender,
+			Age:          patient.Age,
+			BirthDate:    patient.BirthDate,
+			ArmSize:      patient.ArmSize,
+			Weight:       patient.Weight,
+			Size:         patient.Size,
+			ConsentGiven: patient.ConsentGiven,
+			CreatedAt:    patient.CreatedAt,
+		},
+		Status:      NewPatientStatus(patient.ID, latest),
+		GeneratedAt: now,
+	}
+
+	if caregiver != nil {
+		record.Caregiver = &CaregiverContact{
+			UserID:   caregiver.ID,
+			Name:     caregiver.Name,
+			LastName: caregiver.LastName,
+			Phone:    caregiver.Phone,
+		}
+		if caregiver.Locality != nil {
+			record.Caregiver.LocalityName = caregiver.Locality.Name
+			record.Caregiver.LocalityMedicalCenterPhone = caregiver.Locality.PhoneMedicalCenter
+		}
+	}
+	return record
+}
@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Límites del archivo de importación de pacientes; se aplican igual al validar y al importar
+const (
+	PatientImportMaxBytes = 2 << 20 // 2 MB
+	PatientImportMaxRows  = 1000
+)
+
+// PatientImportHeader son las columnas del CSV de importación; dni, name y lastname son obligatorias
+var PatientImportHeader = []string{"dni", "name", "lastname", "gender", "birth_date", "age", "description"}
+
+var patientImportRequired = []string{"dni", "name", "lastname"}
+
+// PatientImportRow es una fila leída del CSV de importación
+type PatientImportRow struct {
+	Line        int // Línea del archivo (la cabecera es la línea 1)
+	DNI         string
+	Name        string
+	Lastname    string
+	Gender      string
+	BirthDate   string
+	Age         string
+	Description string
+}
+
+// PatientImportRowResult es el resultado de validar una fila
+type PatientImportRowResult struct {
+	Line      int      `json:"line"`
+	DNI       string   `json:"dni"` // DNI normalizado
+	Valid     bool     `json:"valid"`
+	Duplicate bool     `json:"duplicate"` // Ya registrado o repetido en el archivo
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// PatientImportValidation es el resultado de validar un archivo de importación completo
+type PatientImportValidation struct {
+	TotalRows  int                      `json:"total_rows"`
+	Valid      int                      `json:"valid"`
+	Duplicates int                      `json:"duplicates"`
+	Errors     int                      `json:"errors"` // Filas con errores distintos de duplicado
+	Rows       []PatientImportRowResult `json:"rows"`
+}
+
+// ReadPatientImport lee el CSV de importación. Las columnas se ubican por nombre en la cabecera
+// (sin importar mayúsculas ni el orden) y las columnas desconocidas se ignoran
+func ReadPatientImport(r io.Reader) ([]PatientImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrEmptyPatientImport
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatientImport, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Excel agrega un BOM al inicio del archivo
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range patientImportRequired {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: falta la columna %s", ErrInvalidPatientImport, name)
+		}
+	}
+
+	var rows []PatientImportRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatientImport, err)
+		}
+		if len(rows) == PatientImportMaxRows {
+			return nil, ErrTooManyImportRows
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		rows = append(rows, PatientImportRow{
+			Line:        line,
+			DNI:         field("dni"),
+			Name:        field("name"),
+			Lastname:    field("lastname"),
+			Gender:      field("gender"),
+			BirthDate:   field("birth_date"),
+			Age:         field("age"),
+			Description: field("description"),
+		})
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrEmptyPatientImport
+	}
+	return rows, nil
+}
+
+// Patient construye el paciente de la fila con las mismas reglas que el registro individual
+func (r PatientImportRow) Patient() (*Patient, error) {
+	age := 0.0
+	if r.Age != "" {
+		parsed, err := strconv.ParseFloat(strings.Replace(r.Age, ",", ".", 1), 64)
+		if err != nil {
+			return nil, fmt.Errorf("edad inválida: %s", r.Age)
+		}
+		age = parsed
+	}
+	return NewPatient(r.Name, r.Lastname, r.Gender, r.BirthDate, "", "", "", r.Description, age, NormalizeDNI(r.DNI), true, nil), nil
+}
+
+// validate devuelve todos los errores de la fila, sin considerar duplicados
+func (r PatientImportRow) validate() []string {
+	var problems []string
+	if err := ValidateDNI(NormalizeDNI(r.DNI)); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if r.Name == "" {
+		problems = append(problems, ErrEmptyPatientName.Error())
+	}
+	if r.Lastname == "" {
+		problems = append(problems, ErrEmptyPatientLastName.Error())
+	}
+	patient, err := r.Patient()
+	if err != nil {
+		problems = append(problems, err.Error())
+	} else if _, ok := ParseBirthDate(r.BirthDate); ok || r.Age != "" {
+		if err := patient.ValidateAge(time.Now()); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	return problems
+}
+
+// ValidatePatientImport valida cada fila y marca como duplicadas las que ya están registradas
+// (existing, por DNI normalizado) o se repiten en el mismo archivo
+func ValidatePatientImport(rows []PatientImportRow, existing map[string]bool) *PatientImportValidation {
+	validation := &PatientImportValidation{
+		TotalRows: len(rows),
+		Rows:      make([]PatientImportRowResult, 0, len(rows)),
+	}
+
+	firstLine := make(map[string]int, len(rows))
+	for _, row := range rows {
+		result := PatientImportRowResult{Line: row.Line, DNI: NormalizeDNI(row.DNI), Errors: row.validate()}
+		hasErrors := len(result.Errors) > 0
+
+		if result.DNI != "" {
+			if existing[result.DNI] {
+				result.Duplicate = true
+				result.Errors = append(result.Errors, ErrPatientDNIAlreadyExists.Error())
+			} else if line, seen := firstLine[result.DNI]; seen {
+				result.Duplicate = true
+				result.Errors = append(result.Errors, fmt.Sprintf("DNI repetido en la línea %d", line))
+			} else {
+				firstLine[result.DNI] = row.Line
+			}
+		}
+
+		switch {
+		case hasErrors:
+			validation.Errors++
+		case result.Duplicate:
+			validation.Duplicates++
+		default:
+			result.Valid = true
+			validation.Valid++
+		}
+		validation.Rows = append(validation.Rows, result)
+	}
+	return validation
+}
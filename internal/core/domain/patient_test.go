@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPatientSetApproval(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		current    string
+		status     string
+		note       string
+		wantErr    error
+		wantStatus string
+		wantNote   string
+	}{
+		{"pendiente a aprobado", PatientApprovalPending, PatientApprovalApproved, "", nil, PatientApprovalApproved, ""},
+		{"pendiente a rechazado con nota", PatientApprovalPending, PatientApprovalRejected, "  DNI duplicado  ", nil, PatientApprovalRejected, "DNI duplicado"},
+		{"pendiente a rechazado sin nota", PatientApprovalPending, PatientApprovalRejected, "   ", ErrEmptyRejectionNote, PatientApprovalPending, ""},
+		{"rechazado a aprobado", PatientApprovalRejected, PatientApprovalApproved, "corregido", nil, PatientApprovalApproved, "corregido"},
+		{"aprobado a rechazado", PatientApprovalApproved, PatientApprovalRejected, "fuera de la zona", nil, PatientApprovalRejected, "fuera de la zona"},
+		{"mismo estado", PatientApprovalApproved, PatientApprovalApproved, "", ErrApprovalUnchanged, PatientApprovalApproved, ""},
+		{"volver a pendiente", PatientApprovalRejected, PatientApprovalPending, "", ErrInvalidApprovalStatus, PatientApprovalRejected, ""},
+		{"estado desconocido", PatientApprovalPending, "archived", "", ErrInvalidApprovalStatus, PatientApprovalPending, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actorID := uuid.New()
+			p := &Patient{ID: uuid.New(), ApprovalStatus: tt.current}
+
+			err := p.SetApproval(tt.status, tt.note, &actorID, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, se esperaba %v", err, tt.wantErr)
+			}
+			if p.ApprovalStatus != tt.wantStatus {
+				t.Errorf("approval_status = %q, se esperaba %q", p.ApprovalStatus, tt.wantStatus)
+			}
+
+			if tt.wantErr != nil {
+				if p.ApprovalUpdatedAt != nil || p.ApprovalUpdatedBy != nil {
+					t.Error("un cambio rechazado no debe registrar revisor ni fecha")
+				}
+				return
+			}
+			if p.ApprovalNote != tt.wantNote {
+				t.Errorf("approval_note = %q, se esperaba %q", p.ApprovalNote, tt.wantNote)
+			}
+			if p.ApprovalUpdatedAt == nil || !p.ApprovalUpdatedAt.Equal(now) {
+				t.Errorf("approval_updated_at = %v, se esperaba %s", p.ApprovalUpdatedAt, now)
+			}
+			if p.ApprovalUpdatedBy == nil || *p.ApprovalUpdatedBy != actorID {
+				t.Errorf("approval_updated_by = %v, se esperaba %s", p.ApprovalUpdatedBy, actorID)
+			}
+		})
+	}
+}
+
+func TestPatientInitApproval(t *testing.T) {
+	previous := PatientApprovalRequired
+	t.Cleanup(func() { PatientApprovalRequired = previous })
+
+	tests := []struct {
+		name     string
+		required bool
+		role     string
+		want     string
+	}{
+		{"aprobación desactivada", false, "APODERADO", PatientApprovalApproved},
+		{"apoderado con aprobación", true, "APODERADO", PatientApprovalPending},
+		{"supervisor con aprobación", true, "SUPERVISOR", PatientApprovalApproved},
+		{"sin apoderado", true, "", PatientApprovalApproved},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetPatientApprovalRequired(tt.required)
+			p := &Patient{}
+			p.InitApproval(tt.role)
+			if p.ApprovalStatus != tt.want {
+				t.Fatalf("approval_status = %q, se esperaba %q", p.ApprovalStatus, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatientValidateAgeUsesBirthDate(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		birthDate string
+		age       float64
+		override  bool
+		wantErr   error
+	}{
+		{"edad actual dentro del rango aunque la registrada no", "2023-03-10", 0.2, false, nil},
+		{"superó el rango desde el registro", "2019-03-09", 2, false, ErrPatientAgeOutOfRange},
+		{"último mes admitido", "2020-03-11", 4.9, false, nil},
+		{"aún no cumple el mínimo", "2024-09-11", 1, false, ErrPatientAgeOutOfRange},
+		{"fecha futura", "2025-05-01", 1, false, ErrPatientAgeOutOfRange},
+		{"sin fecha usa la edad registrada", "", 2, false, nil},
+		{"fecha no reconocida y edad fuera de rango", "marzo", 7, false, ErrPatientAgeOutOfRange},
+		{"excepción administrativa", "2019-03-09", 6, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Patient{BirthDate: tt.birthDate, Age: tt.age}
+			if tt.override {
+				p.SetAgeOverride(true, "autorizado por el centro de salud")
+			}
+			if err := p.ValidateAge(now); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, se esperaba %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPatientAgeCheckRequired(t *testing.T) {
+	previous := &Patient{BirthDate: "2020-01-15", AgeOverride: true, AgeOverrideNote: "autorizado"}
+
+	same := *previous
+	if same.AgeCheckRequired(previous) {
+		t.Fatal("sin cambios de fecha ni de excepción no se debe validar la edad")
+	}
+
+	moved := *previous
+	moved.BirthDate = "2021-01-15"
+	if !moved.AgeCheckRequired(previous) {
+		t.Fatal("al cambiar la fecha de nacimiento se debe validar la edad")
+	}
+
+	withdrawn := *previous
+	withdrawn.SetAgeOverride(false, "")
+	if !withdrawn.AgeCheckRequired(previous) {
+		t.Fatal("al retirar la excepción se debe validar la edad")
+	}
+}
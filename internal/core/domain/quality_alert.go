@@ -0,0 +1,175 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Categorías de las alertas de calidad de datos
+const (
+	QualityCategoryFlaggedMeasurement     = "flagged_measurement"
+	QualityCategoryClassificationMismatch = "classification_mismatch"
+	QualityCategoryDuplicateDNI           = "duplicate_dni"
+	QualityCategoryUnmappedContent        = "unmapped_content"
+)
+
+// QualityAlertCategories son las categorías en el orden en que se muestran, con su etiqueta
+var QualityAlertCategories = []struct {
+	Category string
+	Label    string
+}{
+	{QualityCategoryFlaggedMeasurement, "Mediciones marcadas para revisión"},
+	{QualityCategoryClassificationMismatch, "Clasificaciones que no coinciden con el valor MUAC"},
+	{QualityCategoryDuplicateDNI, "DNIs duplicados"},
+	{QualityCategoryUnmappedContent, "Tags y recomendaciones sin código MUAC"},
+}
+
+// Cantidad de registros de ejemplo por categoría
+const (
+	DefaultQualityAlertSamples = 5
+	MaxQualityAlertSamples     = 50
+)
+
+// QualityAlert es un problema de calidad de datos. El ID es categoría:clave (p.ej. el ID de la
+// medición o el DNI normalizado), así que se mantiene mientras el problema persista
+type QualityAlert struct {
+	ID       string      `json:"id"`
+	Category string      `json:"category"`
+	Summary  string      `json:"summary"`
+	Record   interface{} `json:"record"`
+}
+
+// NewQualityAlert crea la alerta de un registro de la categoría
+func NewQualityAlert(category, key, summary string, record interface{}) QualityAlert {
+	return QualityAlert{ID: category + ":" + key, Category: category, Summary: summary, Record: record}
+}
+
+// QualityAlertCategory devuelve la categoría del ID de alerta
+func QualityAlertCategory(id string) (string, error) {
+	category, key, ok := strings.Cut(id, ":")
+	if !ok || key == "" {
+		return "", ErrInvalidQualityAlertID
+	}
+	for _, c := range QualityAlertCategories {
+		if c.Category == category {
+			return category, nil
+		}
+	}
+	return "", ErrInvalidQualityAlertID
+}
+
+// QualityAlertGroup resume las alertas pendientes de una categoría
+type QualityAlertGroup struct {
+	Category     string         `json:"category"`
+	Label        string         `json:"label"`
+	Count        int            `json:"count"`        // Alertas sin reconocer
+	Acknowledged int            `json:"acknowledged"` // Alertas vigentes ya reconocidas (no se listan)
+	Samples      []QualityAlert `json:"samples"`
+}
+
+// QualityAlertFeed reúne las alertas de calidad pendientes de todas las categorías
+type QualityAlertFeed struct {
+	Total        int                 `json:"total"`
+	Acknowledged int                 `json:"acknowledged"`
+	Categories   []QualityAlertGroup `json:"categories"`
+	GeneratedAt  time.Time           `json:"generated_at"`
+}
+
+// NewQualityAlertFeed agrupa las alertas por categoría, descarta las reconocidas y conserva hasta
+// samples ejemplos de cada una. Las categorías sin alertas se incluyen con cero
+func NewQualityAlertFeed(alerts []QualityAlert, acknowledged map[string]bool, samples int, now time.Time) *QualityAlertFeed {
+	feed := &QualityAlertFeed{
+		Categories:  make([]QualityAlertGroup, 0, len(QualityAlertCategories)),
+		GeneratedAt: now,
+	}
+	groups := make(map[string]*QualityAlertGroup, len(QualityAlertCategories))
+	for _, c := range QualityAlertCategories {
+		feed.Categories = append(feed.Categories, QualityAlertGroup{Category: c.Category, Label: c.Label, Samples: []QualityAlert{}})
+	}
+	for i := range feed.Categories {
+		groups[feed.Categories[i].Category] = &feed.Categories[i]
+	}
+
+	for _, alert := range alerts {
+		group, ok := groups[alert.Category]
+		if !ok {
+			continue
+		}
+		if acknowledged[alert.ID] {
+			group.Acknowledged++
+			feed.Acknowledged++
+			continue
+		}
+		group.Count++
+		feed.Total++
+		if len(group.Samples) < samples {
+			group.Samples = append(group.Samples, alert)
+		}
+	}
+	return feed
+}
+
+// QualityAlertAck registra que un operador revisó una alerta; la alerta deja de aparecer en el feed
+type QualityAlertAck struct {
+	AlertID        string     `json:"alert_id" gorm:"column:alert_id;type:varchar(150);primaryKey"`
+	Category       string     `json:"category" gorm:"column:category;type:varchar(50);not null;index"`
+	Note           string     `json:"note" gorm:"column:note;type:text"`
+	AcknowledgedBy *uuid.UUID `json:"acknowledged_by,omitempty" gorm:"column:acknowledged_by;type:uuid"`
+	AcknowledgedAt time.Time  `json:"acknowledged_at" gorm:"column:acknowledged_at;autoCreateTime"`
+}
+
+// TableName especifica el nombre de la tabla para GORM
+func (QualityAlertAck) TableName() string {
+	return "quality_alert_acks"
+}
+
+// NewQualityAlertAck crea el reconocimiento de la alerta, validando su ID
+func NewQualityAlertAck(alertID, note string, actorID *uuid.UUID) (*QualityAlertAck, error) {
+	category, err := QualityAlertCategory(alertID)
+	if err != nil {
+		return nil, err
+	}
+	return &QualityAlertAck{
+		AlertID:        alertID,
+		Category:       category,
+		Note:           strings.TrimSpace(note),
+		AcknowledgedBy: actorID,
+	}, nil
+}
+
+// flaggedMeasurementSummary describe una medición marcada en el feed de calidad
+func flaggedMeasurementSummary(m FlaggedMeasurement) string {
+	return fmt.Sprintf("Medición de %s (%.1f cm) marcada: %s", m.PatientName, m.MuacValue, m.FlagReason)
+}
+
+// FlaggedMeasurementAlert crea la alerta de una medición marcada para revisión
+func FlaggedMeasurementAlert(m FlaggedMeasurement) QualityAlert {
+	return NewQualityAlert(QualityCategoryFlaggedMeasurement, m.MeasurementID.String(), flaggedMeasurementSummary(m), m)
+}
+
+// ClassificationMismatchAlert crea la alerta de una medición cuya clasificación guardada no coincide
+func ClassificationMismatchAlert(m *ClassificationMismatch) QualityAlert {
+	summary := fmt.Sprintf("La clasificación guardada de la medición (%.1f cm) no coincide con %s", m.MuacValue, m.ExpectedMuacCode)
+	return NewQualityAlert(QualityCategoryClassificationMismatch, m.MeasurementID.String(), summary, m)
+}
+
+// DuplicateDNIAlert crea la alerta de un grupo de pacientes con el mismo DNI normalizado
+func DuplicateDNIAlert(group DuplicateDNIGroup) QualityAlert {
+	summary := fmt.Sprintf("El DNI %s se repite en %d pacientes", group.DNI, group.Count)
+	return NewQualityAlert(QualityCategoryDuplicateDNI, group.DNI, summary, group)
+}
+
+// UnmappedTagAlert crea la alerta de un tag activo sin código MUAC
+func UnmappedTagAlert(tag *Tag) QualityAlert {
+	return NewQualityAlert(QualityCategoryUnmappedContent, ContentTypeTag+"-"+tag.ID.String(),
+		fmt.Sprintf("El tag %q no tiene código MUAC", tag.Name), tag)
+}
+
+// UnmappedRecommendationAlert crea la alerta de una recomendación activa sin código MUAC
+func UnmappedRecommendationAlert(recommendation *Recommendation) QualityAlert {
+	return NewQualityAlert(QualityCategoryUnmappedContent, ContentTypeRecommendation+"-"+recommendation.ID.String(),
+		fmt.Sprintf("La recomendación %q no tiene código MUAC", recommendation.Name), recommendation)
+}
@@ -32,6 +32,53 @@ func (Recommendation) TableName() string {
 	return "recommendations"
 }
 
+// RecommendationPropagation resume la reasignación de mediciones a una recomendación
+type RecommendationPropagation struct {
+	RecommendationID uuid.UUID `json:"recommendation_id"`
+	MinValue         *float64  `json:"min_value,omitempty"`
+	MaxValue         *float64  `json:"max_value,omitempty"`
+	DryRun           bool      `json:"dry_run"`
+	Affected         int64     `json:"affected"`
+}
+
+// RecommendationImpactSampleSize limita las mediciones de ejemplo del análisis de impacto
+const RecommendationImpactSampleSize = 20
+
+// RecommendationMeasurementRef es una medición que hoy referencia a una recomendación
+type RecommendationMeasurementRef struct {
+	MeasurementID uuid.UUID `json:"measurement_id"`
+	PatientID     uuid.UUID `json:"patient_id"`
+	MuacValue     float64   `json:"muac_value"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// RecommendationImpactSample es una medición que dejaría de coincidir con el rango propuesto
+type RecommendationImpactSample struct {
+	RecommendationMeasurementRef
+	NewRecommendationID   *uuid.UUID `json:"new_recommendation_id"` // nil si ninguna otra recomendación aplica
+	NewRecommendationName string     `json:"new_recommendation_name,omitempty"`
+}
+
+// RecommendationImpactTarget cuenta cuántas mediciones pasarían a otra recomendación
+type RecommendationImpactTarget struct {
+	RecommendationID *uuid.UUID `json:"recommendation_id"`
+	Name             string     `json:"name,omitempty"`
+	Count            int        `json:"count"`
+}
+
+// RecommendationImpact es el efecto de cambiar el rango MUAC de una recomendación, sin guardar
+type RecommendationImpact struct {
+	RecommendationID uuid.UUID                    `json:"recommendation_id"`
+	CurrentMinValue  *float64                     `json:"current_min_value"`
+	CurrentMaxValue  *float64                     `json:"current_max_value"`
+	ProposedMinValue *float64                     `json:"proposed_min_value"`
+	ProposedMaxValue *float64                     `json:"proposed_max_value"`
+	Referencing      int                          `json:"referencing"` // Mediciones que hoy la referencian
+	NoLongerMatching int                          `json:"no_longer_matching"`
+	Targets          []RecommendationImpactTarget `json:"targets"`
+	Samples          []RecommendationImpactSample `json:"samples"`
+}
+
 // ============= CONSTRUCTORES =============
 
 // NewRecommendation crea una nueva recomendación básica
@@ -230,6 +277,18 @@ func (r *Recommendation) IsApplicableForMuac(muacValue float64) bool {
 	return true
 }
 
+// CanPropagate valida que la recomendación pueda asignarse masivamente a mediciones
+func (r *Recommendation) CanPropagate() error {
+	if !r.Active {
+		return ErrRecommendationInactive
+	}
+	// Sin rango la recomendación aplicaría a todas las mediciones
+	if !r.HasMuacRange() {
+		return ErrRecommendationNoRange
+	}
+	return nil
+}
+
 // IsUrgent verifica si la recomendación es urgente
 func (r *Recommendation) IsUrgent() bool {
 	return r.Priority >= PriorityUrgent
@@ -325,6 +384,65 @@ func GetRecommendationForMuacValue(muacValue float64, recommendations []*Recomme
 	return nil
 }
 
+// NewRecommendationImpact evalúa con IsApplicableForMuac qué mediciones de refs dejarían de coincidir
+// con el rango propuesto y a qué recomendación pasarían, eligiéndola entre all como al registrar una medición
+func NewRecommendationImpact(current *Recommendation, proposedMin, proposedMax *float64, refs []RecommendationMeasurementRef, all []*Recommendation) *RecommendationImpact {
+	proposed := *current
+	proposed.MinValue = proposedMin
+	proposed.MaxValue = proposedMax
+
+	candidates := make([]*Recommendation, 0, len(all))
+	for _, rec := range all {
+		if rec.ID == current.ID {
+			rec = &proposed
+		}
+		candidates = append(candidates, rec)
+	}
+
+	impact := &RecommendationImpact{
+		RecommendationID: current.ID,
+		CurrentMinValue:  current.MinValue,
+		CurrentMaxValue:  current.MaxValue,
+		ProposedMinValue: proposedMin,
+		ProposedMaxValue: proposedMax,
+		Referencing:      len(refs),
+		Targets:          []RecommendationImpactTarget{},
+		Samples:          []RecommendationImpactSample{},
+	}
+
+	targetIndex := make(map[uuid.UUID]int)
+	for _, ref := range refs {
+		if proposed.IsApplicableForMuac(ref.MuacValue) {
+			continue
+		}
+		impact.NoLongerMatching++
+
+		sample := RecommendationImpactSample{RecommendationMeasurementRef: ref}
+		key := uuid.Nil // Sin recomendación aplicable
+		if target := GetRecommendationForMuacValue(ref.MuacValue, candidates); target != nil {
+			sample.NewRecommendationID = &target.ID
+			sample.NewRecommendationName = target.Name
+			key = target.ID
+		}
+
+		if i, ok := targetIndex[key]; ok {
+			impact.Targets[i].Count++
+		} else {
+			targetIndex[key] = len(impact.Targets)
+			impact.Targets = append(impact.Targets, RecommendationImpactTarget{
+				RecommendationID: sample.NewRecommendationID,
+				Name:             sample.NewRecommendationName,
+				Count:            1,
+			})
+		}
+
+		if len(impact.Samples) < RecommendationImpactSampleSize {
+			impact.Samples = append(impact.Samples, sample)
+		}
+	}
+	return impact
+}
+
 // FilterActiveRecommendations filtra solo recomendaciones activas
 func FilterActiveRecommendations(recommendations []*Recommendation) []*Recommendation {
 	var activeRecs []*Recommendation
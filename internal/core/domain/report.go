@@ -2,7 +2,13 @@
 package domain
 
 import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
@@ -19,6 +25,44 @@ type DashboardReport struct {
 	GeneratedAt        time.Time          `json:"generated_at"`
 }
 
+// CaregiverDashboard - Resumen de inicio del apoderado, limitado a los pacientes que tiene asignados
+type CaregiverDashboard struct {
+	UserID                uuid.UUID          `json:"user_id"`
+	CaregiverName         string             `json:"caregiver_name"`
+	TotalPatients         int64              `json:"total_patients"`
+	PatientsAtRisk        int64              `json:"patients_at_risk"`
+	UnmeasuredPatients    int64              `json:"unmeasured_patients"`
+	StatusDistribution    StatusDistribution `json:"status_distribution"` // Según la última medición de cada paciente
+	TotalMeasurements     int64              `json:"total_measurements"`
+	MeasurementsThisWeek  PeriodValue        `json:"measurements_this_week"`  // Desde el lunes en ProgramTimeZone
+	MeasurementsThisMonth PeriodValue        `json:"measurements_this_month"` // Desde el día 1 en ProgramTimeZone
+	OverdueFollowups      int64              `json:"overdue_followups"`       // Misma regla que las tareas del apoderado
+	GeneratedAt           time.Time          `json:"generated_at"`
+}
+
+// NewCaregiverDashboard arma el resumen a partir del dashboard filtrado por el apoderado
+func NewCaregiverDashboard(user *User, dashboard *DashboardReport, week, month PeriodValue, overdue int64, now time.Time) *CaregiverDashboard {
+	distribution := dashboard.StatusDistribution
+	measured := distribution.Normal.Total + distribution.Moderate.Total + distribution.Severe.Total
+	unmeasured := dashboard.TotalPatients - measured
+	if unmeasured < 0 {
+		unmeasured = 0
+	}
+	return &CaregiverDashboard{
+		UserID:                user.ID,
+		CaregiverName:         fmt.Sprintf("%s %s", user.Name, user.LastName),
+		TotalPatients:         dashboard.TotalPatients,
+		PatientsAtRisk:        dashboard.PatientsAtRisk,
+		UnmeasuredPatients:    unmeasured,
+		StatusDistribution:    distribution,
+		TotalMeasurements:     dashboard.TotalMeasurements,
+		MeasurementsThisWeek:  week,
+		MeasurementsThisMonth: month,
+		OverdueFollowups:      overdue,
+		GeneratedAt:           now,
+	}
+}
+
 // StatusDistribution - Distribución por estado nutricional
 type StatusDistribution struct {
 	Normal   StatusCount `json:"normal"`   // Verde ≥ 12.5 cm
@@ -99,10 +143,1667 @@ type UserStats struct {
 	MeasuresThisWeek int        `json:"measures_this_week"`
 }
 
+// RecommendationUsageReport - Uso de cada recomendación en mediciones
+type RecommendationUsageReport struct {
+	Recommendations []RecommendationUsage `json:"recommendations"`
+	GeneratedAt     time.Time             `json:"generated_at"`
+}
+
+type RecommendationUsage struct {
+	RecommendationID  uuid.UUID       `json:"recommendation_id"`
+	Name              string          `json:"name"`
+	MuacCode          string          `json:"muac_code"`
+	Active            bool            `json:"active"`
+	TotalMeasurements int64           `json:"total_measurements"`
+	ByLocality        []LocalityUsage `json:"by_locality"`
+}
+
+type LocalityUsage struct {
+	LocalityID   *uuid.UUID `json:"locality_id"`
+	LocalityName string     `json:"locality_name"`
+	Total        int64      `json:"total"`
+}
+
+// RecoveryRateReport - Niños en riesgo que recuperaron estado normal
+type RecoveryRateReport struct {
+	AtRisk       int64     `json:"at_risk"`       // Tuvieron al menos una medición roja o amarilla
+	Recovered    int64     `json:"recovered"`     // De ellos, cuya última medición es verde
+	StillAtRisk  int64     `json:"still_at_risk"` // De ellos, cuya última medición sigue en riesgo
+	RecoveryRate float64   `json:"recovery_rate"` // Porcentaje recovered / at_risk
+	Days         int       `json:"days"`
+	GeneratedAt  time.Time `json:"generated_at"`
+}
+
+// Parámetros de las estimaciones de prevalencia
+const (
+	PrevalenceConfidenceLevel = 0.95
+	PrevalenceZScore          = 1.96 // Cuantil normal para PrevalenceConfidenceLevel
+	// MinPrevalenceSampleSize es la muestra bajo la cual se advierte que los intervalos son poco informativos
+	MinPrevalenceSampleSize = 30
+)
+
+// PrevalenceMethod describe el método estadístico usado en PrevalenceReport
+const PrevalenceMethod = "Proporción de niños cuya última medición (dentro de la ventana) es severa o moderada, " +
+	"con intervalo de confianza de Wilson (score) al 95%. Se asume muestreo aleatorio simple: " +
+	"los niños medidos no son necesariamente representativos de toda la población."
+
+// PrevalenceEstimate es una proporción con su intervalo de confianza
+type PrevalenceEstimate struct {
+	Cases      int64   `json:"cases"`
+	Proportion float64 `json:"proportion"`  // Entre 0 y 1
+	LowerBound float64 `json:"lower_bound"` // Límite inferior del intervalo de Wilson
+	UpperBound float64 `json:"upper_bound"` // Límite superior del intervalo de Wilson
+}
+
+// NewPrevalenceEstimate calcula la proporción cases/sampleSize con el intervalo de Wilson:
+// (p + z²/2n ± z·√(p(1-p)/n + z²/4n²)) / (1 + z²/n). A diferencia del intervalo normal
+// (Wald), no se sale de [0, 1] ni colapsa a ancho cero con muestras pequeñas o proporciones
+// extremas. Sin muestra devuelve el intervalo completo [0, 1].
+func NewPrevalenceEstimate(cases, sampleSize int64) PrevalenceEstimate {
+	estimate := PrevalenceEstimate{Cases: cases, UpperBound: 1}
+	if sampleSize <= 0 {
+		return estimate
+	}
+
+	n := float64(sampleSize)
+	p := float64(cases) / n
+	z2 := PrevalenceZScore * PrevalenceZScore
+	denominator := 1 + z2/n
+	center := (p + z2/(2*n)) / denominator
+	margin := PrevalenceZScore * math.Sqrt(p*(1-p)/n+z2/(4*n*n)) / denominator
+
+	estimate.Proportion = p
+	estimate.LowerBound = math.Max(0, center-margin)
+	estimate.UpperBound = math.Min(1, center+margin)
+	return estimate
+}
+
+// PrevalenceReport - Prevalencia de desnutrición aguda severa (SAM) y moderada (MAM)
+type PrevalenceReport struct {
+	SampleSize      int64              `json:"sample_size"`  // Niños con al menos una medición en la ventana
+	Severe          PrevalenceEstimate `json:"severe"`       // SAM: última medición roja
+	Moderate        PrevalenceEstimate `json:"moderate"`     // MAM: última medición amarilla
+	SmallSample     bool               `json:"small_sample"` // Muestra menor a MinPrevalenceSampleSize
+	ConfidenceLevel float64            `json:"confidence_level"`
+	Method          string             `json:"method"`
+	Days            int                `json:"days"`
+	GeneratedAt     time.Time          `json:"generated_at"`
+}
+
+// AgeDistributionBands son las bandas de edad (en meses cumplidos) del reporte de estructura etaria
+var AgeDistributionBands = []AgeBand{
+	{Label: "6-11", MinMonths: 6, MaxMonths: 11},
+	{Label: "12-23", MinMonths: 12, MaxMonths: 23},
+	{Label: "24-35", MinMonths: 24, MaxMonths: 35},
+	{Label: "36-47", MinMonths: 36, MaxMonths: 47},
+	{Label: "48-59", MinMonths: 48, MaxMonths: 59},
+}
+
+// birthDateLayouts son los formatos de fecha de nacimiento aceptados (el campo es texto libre)
+var birthDateLayouts = []string{"2006-01-02", "02/01/2006", "2/1/2006", "02-01-2006", time.RFC3339}
+
+// ParseBirthDate interpreta la fecha de nacimiento registrada; ok es false si está vacía o no se reconoce
+func ParseBirthDate(value string) (birthDate time.Time, ok bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range birthDateLayouts {
+		if parsed, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return parsed, true
+		}
+	}
+	// Fechas guardadas como timestamp (p.ej. "2022-03-15T00:00:00Z" o "2022-03-15 00:00:00")
+	if len(value) > 10 {
+		if parsed, err := time.ParseInLocation("2006-01-02", value[:10], time.Local); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// AgeInMonths devuelve los meses cumplidos entre birthDate y asOf; -1 si birthDate es posterior a asOf
+func AgeInMonths(birthDate, asOf time.Time) int {
+	if birthDate.After(asOf) {
+		return -1
+	}
+	months := (asOf.Year()-birthDate.Year())*12 + int(asOf.Month()-birthDate.Month())
+	if asOf.Day() < birthDate.Day() {
+		months--
+	}
+	return months
+}
+
+// AgeBand - Cantidad de pacientes en una banda de edad
+type AgeBand struct {
+	Label      string  `json:"label"`
+	MinMonths  int     `json:"min_months"`
+	MaxMonths  int     `json:"max_months"`
+	Count      int64   `json:"count"`
+	Percentage float64 `json:"percentage"` // Sobre el total de pacientes registrados
+}
+
+// AgeDistributionReport - Estructura etaria de los pacientes registrados
+type AgeDistributionReport struct {
+	Total       int64     `json:"total"`
+	Bands       []AgeBand `json:"bands"`
+	OutOfRange  int64     `json:"out_of_range"` // Fecha válida pero fuera de 6-59 meses
+	Unknown     int64     `json:"unknown"`      // Sin fecha de nacimiento o con fecha inválida/futura
+	AsOf        time.Time `json:"as_of"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// NewAgeDistributionReport clasifica las fechas de nacimiento en las bandas de AgeDistributionBands a la fecha asOf
+func NewAgeDistributionReport(birthDates []string, asOf time.Time) *AgeDistributionReport {
+	report := &AgeDistributionReport{
+		Total:       int64(len(birthDates)),
+		Bands:       make([]AgeBand, len(AgeDistributionBands)),
+		AsOf:        asOf,
+		GeneratedAt: time.Now(),
+	}
+	copy(report.Bands, AgeDistributionBands)
+
+	for _, value := range birthDates {
+		birthDate, ok := ParseBirthDate(value)
+		if !ok {
+			report.Unknown++
+			continue
+		}
+		months := AgeInMonths(birthDate, asOf)
+		if months < 0 {
+			report.Unknown++
+			continue
+		}
+		matched := false
+		for i := range report.Bands {
+			if months >= report.Bands[i].MinMonths && months <= report.Bands[i].MaxMonths {
+				report.Bands[i].Count++
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			report.OutOfRange++
+		}
+	}
+
+	if report.Total > 0 {
+		for i := range report.Bands {
+			report.Bands[i].Percentage = float64(report.Bands[i].Count) / float64(report.Total) * 100
+		}
+	}
+	return report
+}
+
+// CountersCacheTTL es el tiempo que se reutilizan los contadores antes de recalcularlos
+const CountersCacheTTL = 30 * time.Second
+
+// CountersReport - Contadores ligeros para las insignias de la pantalla de inicio
+type CountersReport struct {
+	TotalPatients     int64     `json:"total_patients"`
+	PatientsAtRisk    int64     `json:"patients_at_risk"`   // Última medición roja o amarilla
+	MeasurementsToday int64     `json:"measurements_today"` // Desde las 00:00 del servidor
+	ActiveCaregivers  int64     `json:"active_caregivers"`  // Apoderados con usuario activo
+	GeneratedAt       time.Time `json:"generated_at"`
+}
+
+// DefaultLeaderboardSize es la cantidad de apoderados mostrados en el ranking
+const DefaultLeaderboardSize = 10
+
+// LeaderboardReport - Posición de un apoderado frente a sus pares de la localidad
+type LeaderboardReport struct {
+	LocalityName    string             `json:"locality_name"`
+	Days            int                `json:"days"`
+	Rank            int                `json:"rank"`
+	Measurements    int64              `json:"measurements"`
+	TotalCaregivers int                `json:"total_caregivers"`
+	Top             []LeaderboardEntry `json:"top"`
+	GeneratedAt     time.Time          `json:"generated_at"`
+}
+
+// LeaderboardEntry muestra solo el nombre abreviado de los pares, sin identificadores
+type LeaderboardEntry struct {
+	Rank          int    `json:"rank"`
+	DisplayName   string `json:"display_name"`
+	Measurements  int64  `json:"measurements"`
+	IsCurrentUser bool   `json:"is_current_user"`
+}
+
+// CaregiverActivity es la actividad de un apoderado usada para construir el ranking
+type CaregiverActivity struct {
+	UserID       uuid.UUID
+	Name         string
+	Lastname     string
+	Measurements int64
+}
+
+// AbbreviateName devuelve el nombre con la inicial del apellido (p.ej. "María G.")
+func AbbreviateName(name, lastname string) string {
+	name = strings.TrimSpace(name)
+	lastname = strings.TrimSpace(lastname)
+	if lastname == "" {
+		return name
+	}
+	initial, _ := utf8.DecodeRuneInString(lastname)
+	return fmt.Sprintf("%s %c.", name, unicode.ToUpper(initial))
+}
+
+// Intervalos admitidos para líneas de tiempo (valores válidos de date_trunc)
+const (
+	TimelineIntervalWeek  = "week"
+	TimelineIntervalMonth = "month"
+)
+
+// IsValidTimelineInterval indica si el intervalo es admitido
+func IsValidTimelineInterval(interval string) bool {
+	return interval == TimelineIntervalWeek || interval == TimelineIntervalMonth
+}
+
+// RegistrationsTimelineReport - Nuevos pacientes registrados por periodo
+type RegistrationsTimelineReport struct {
+	Interval    string           `json:"interval"`
+	Total       int64            `json:"total"`
+	Buckets     []TimelineBucket `json:"buckets"`
+	GeneratedAt time.Time        `json:"generated_at"`
+}
+
+type TimelineBucket struct {
+	Period time.Time `json:"period"` // Inicio del periodo
+	Total  int64     `json:"total"`
+}
+
+// Precisión de la grilla de calor: decimales de latitud/longitud (1 ≈ 11 km, 2 ≈ 1,1 km)
+const (
+	DefaultHeatcellPrecision = 1
+	MaxHeatcellPrecision     = 4
+)
+
+// HeatPoint agrupa las mediciones de una misma coordenada
+type HeatPoint struct {
+	Latitude    string
+	Longitude   string
+	Count       int64
+	SeveritySum float64 // Suma de prioridades MUAC (1 verde, 2 amarillo, 3 rojo)
+}
+
+// HeatCell es una celda de la grilla con el centroide de sus mediciones
+type HeatCell struct {
+	Latitude        float64 `json:"latitude"`
+	Longitude       float64 `json:"longitude"`
+	Count           int64   `json:"count"`
+	AverageSeverity float64 `json:"average_severity"` // Entre 1 (verde) y 3 (rojo)
+}
+
+// MeasurementHeatcellsReport - Densidad de mediciones agregada en celdas para mapas
+type MeasurementHeatcellsReport struct {
+	Precision         int        `json:"precision"`
+	TotalMeasurements int64      `json:"total_measurements"`
+	Cells             []HeatCell `json:"cells"`
+	Days              int        `json:"days"`
+	GeneratedAt       time.Time  `json:"generated_at"`
+}
+
+// UncoveredLocality es una localidad sin usuarios activos asignados
+type UncoveredLocality struct {
+	LocalityID   uuid.UUID `json:"locality_id"`
+	LocalityName string    `json:"locality_name"`
+	Latitude     string    `json:"latitude"`
+	Longitude    string    `json:"longitude"`
+	// Pacientes registrados con usuarios inactivos de la localidad
+	Patients int64 `json:"patients"`
+}
+
+// ActiveLocality es una localidad con mediciones en un rango de fechas, para filtrar el mapa
+type ActiveLocality struct {
+	LocalityID   uuid.UUID `json:"locality_id"`
+	LocalityName string    `json:"locality_name"`
+	Latitude     string    `json:"latitude"`
+	Longitude    string    `json:"longitude"`
+	Measurements int64     `json:"measurements"`
+	Patients     int64     `json:"patients"` // Niños distintos medidos en el rango
+}
+
+// ActiveLocalitiesReport - Localidades con al menos una medición en [StartDate, EndDate)
+type ActiveLocalitiesReport struct {
+	StartDate   time.Time        `json:"start_date"`
+	EndDate     time.Time        `json:"end_date"`
+	Localities  []ActiveLocality `json:"localities"`
+	GeneratedAt time.Time        `json:"generated_at"`
+}
+
+// FlaggedMeasurement es una medición marcada como posible error de registro, pendiente de revisión
+type FlaggedMeasurement struct {
+	MeasurementID uuid.UUID  `json:"measurement_id"`
+	PatientID     uuid.UUID  `json:"patient_id"`
+	PatientName   string     `json:"patient_name"`
+	UserID        uuid.UUID  `json:"user_id"`
+	LocalityID    *uuid.UUID `json:"locality_id"`
+	MuacValue     float64    `json:"muac_value"`
+	PreviousValue *float64   `json:"previous_value"`
+	Delta         *float64   `json:"delta"`
+	FlagReason    string     `json:"flag_reason"`
+	FlaggedAt     *time.Time `json:"flagged_at"`
+	MeasuredAt    time.Time  `json:"measured_at"`
+}
+
+// DefaultPendingReviewLimit es la cantidad de pacientes y de mediciones de la cola de revisión si no se indica limit
+const DefaultPendingReviewLimit = 100
+
+// PendingReviewPatient es un paciente registrado por un apoderado que espera la aprobación del supervisor
+type PendingReviewPatient struct {
+	PatientID     uuid.UUID  `json:"patient_id"`
+	PatientName   string     `json:"patient_name"`
+	DNI           string     `json:"dni"`
+	CaregiverID   *uuid.UUID `json:"caregiver_id"`
+	CaregiverName string     `json:"caregiver_name"`
+	LocalityID    *uuid.UUID `json:"locality_id"`
+	RegisteredAt  time.Time  `json:"registered_at"`
+}
+
+// PendingReviewCounts son los totales de la cola de revisión por estado, sin aplicar limit
+type PendingReviewCounts struct {
+	PendingPatients     int64 `json:"pending_patients"`
+	FlaggedMeasurements int64 `json:"flagged_measurements"`
+	Total               int64 `json:"total"`
+}
+
+// PendingReviewReport - Pacientes y mediciones que esperan una acción del supervisor, del más antiguo al más reciente
+type PendingReviewReport struct {
+	LocalityID   *uuid.UUID             `json:"locality_id"` // nil si el administrador consulta todas
+	Counts       PendingReviewCounts    `json:"counts"`
+	Patients     []PendingReviewPatient `json:"patients"`
+	Measurements []FlaggedMeasurement   `json:"measurements"`
+	GeneratedAt  time.Time              `json:"generated_at"`
+}
+
+// ScopePendingReview devuelve la localidad de la cola de revisión según el rol del usuario: el
+// administrador puede ver todas (nil) o una localidad y el supervisor solo la suya
+func ScopePendingReview(actor *User, localityID *uuid.UUID) (*uuid.UUID, error) {
+	switch actor.Role.Name {
+	case "ADMINISTRADOR":
+		return localityID, nil
+	case "SUPERVISOR":
+		if actor.LocalityID == nil {
+			return nil, ErrPendingReviewForbidden
+		}
+		if localityID != nil && *localityID != *actor.LocalityID {
+			return nil, ErrPendingReviewForbidden
+		}
+		return actor.LocalityID, nil
+	default:
+		return nil, ErrPendingReviewForbidden
+	}
+}
+
+// MeasurementTime es el instante de una medición de un paciente
+type MeasurementTime struct {
+	PatientID  uuid.UUID
+	MeasuredAt time.Time
+}
+
+// IntervalHistogramBounds son los límites superiores (en días, inclusivos) de los tramos del histograma;
+// el último tramo agrupa todo lo que supera el último límite
+var IntervalHistogramBounds = []int{7, 14, 30, 60, 90}
+
+// MeasurementIntervalsReport - Días transcurridos entre mediciones consecutivas de un mismo paciente
+type MeasurementIntervalsReport struct {
+	PatientsIncluded int64            `json:"patients_included"` // Con al menos dos mediciones
+	PatientsExcluded int64            `json:"patients_excluded"` // Con una sola medición
+	Intervals        int64            `json:"intervals"`
+	AverageDays      float64          `json:"average_days"`
+	MedianDays       float64          `json:"median_days"`
+	MinDays          float64          `json:"min_days"`
+	MaxDays          float64          `json:"max_days"`
+	Histogram        []IntervalBucket `json:"histogram"`
+	Days             int              `json:"days"`
+	GeneratedAt      time.Time        `json:"generated_at"`
+}
+
+// IntervalBucket es un tramo del histograma de intervalos
+type IntervalBucket struct {
+	Label   string `json:"label"`
+	MinDays int    `json:"min_days"`
+	MaxDays *int   `json:"max_days"` // nil en el último tramo (sin límite)
+	Count   int64  `json:"count"`
+}
+
+// AlertResponse es una medición en alerta roja con la primera acción posterior sobre el paciente
+type AlertResponse struct {
+	MeasurementID uuid.UUID
+	PatientID     uuid.UUID
+	AlertAt       time.Time
+	RespondedAt   *time.Time // nil si no hubo acción posterior
+}
+
+// AlertResponseTimesReport - Tiempo desde una alerta roja hasta la siguiente acción sobre el paciente.
+// Por ahora la única acción registrada es la medición de seguimiento (no hay derivaciones)
+type AlertResponseTimesReport struct {
+	Alerts      int64            `json:"alerts"`
+	Responded   int64            `json:"responded"`
+	Unanswered  int64            `json:"unanswered"` // Sin acción posterior; no cuentan en los promedios
+	AverageDays float64          `json:"average_days"`
+	MedianDays  float64          `json:"median_days"`
+	MinDays     float64          `json:"min_days"`
+	MaxDays     float64          `json:"max_days"`
+	Histogram   []IntervalBucket `json:"histogram"`
+	Days        int              `json:"days"`
+	GeneratedAt time.Time        `json:"generated_at"`
+}
+
+// TriageRecencyWeight penaliza (en cm por día) la antigüedad de la última medición
+// para ordenar el triaje: 20 días de antigüedad equivalen a 1 cm de MUAC.
+const TriageRecencyWeight = 0.05
+
+// TriagePatient - Paciente priorizado por severidad y recencia
+type TriagePatient struct {
+	PatientID    uuid.UUID `json:"patient_id"`
+	PatientName  string    `json:"patient_name"`
+	Age          float64   `json:"age"`
+	MuacValue    float64   `json:"muac_value"`
+	MuacCode     string    `json:"muac_code"`
+	ColorCode    string    `json:"color_code"`
+	RiskLevel    string    `json:"risk_level"`
+	LocalityName string    `json:"locality_name"`
+	UserName     string    `json:"user_name"`
+	LastMeasure  time.Time `json:"last_measure"`
+	DaysAgo      int       `json:"days_ago"`
+	Score        float64   `json:"score"`
+}
+
 // ============= FILTROS SIMPLES =============
 type ReportFilters struct {
 	LocalityID *uuid.UUID `json:"locality_id,omitempty"`
 	UserID     *uuid.UUID `json:"user_id,omitempty"`
 	Days       int        `json:"days,omitempty"`  // Últimos N días (default: 30)
 	Limit      int        `json:"limit,omitempty"` // Límite de resultados (default: 100)
+	// Solo pacientes aprobados (excluye pendientes y rechazados)
+	ApprovedOnly bool `json:"approved_only,omitempty"`
+}
+
+// DefaultProgramTimeZone es la zona horaria en la que se agrupan los reportes por hora
+const DefaultProgramTimeZone = "America/Lima"
+
+// ProgramTimeZone es la zona horaria vigente; se configura al iniciar la aplicación
+var ProgramTimeZone = DefaultProgramTimeZone
+
+// SetProgramTimeZone actualiza la zona horaria del programa, ignorando nombres desconocidos
+func SetProgramTimeZone(name string) {
+	if name == "" {
+		return
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return
+	}
+	ProgramTimeZone = name
+}
+
+// HourBucket es la cantidad de mediciones registradas en una hora del día (0 a 23)
+type HourBucket struct {
+	Hour  int   `json:"hour"`
+	Count int64 `json:"count"`
+}
+
+// MeasurementsByHourReport - Mediciones por hora del día, para planificar el apoyo a los apoderados
+type MeasurementsByHourReport struct {
+	TimeZone    string       `json:"time_zone"`
+	Days        int          `json:"days"`
+	Total       int64        `json:"total"`
+	PeakHour    *int         `json:"peak_hour"` // nil si no hay mediciones
+	Hours       []HourBucket `json:"hours"`     // Siempre las 24 horas, con ceros
+	GeneratedAt time.Time    `json:"generated_at"`
+}
+
+// NewMeasurementsByHourReport completa las 24 horas a partir de los conteos agrupados;
+// las horas fuera de 0-23 se descartan
+func NewMeasurementsByHourReport(counts []HourBucket, days int, now time.Time) *MeasurementsByHourReport {
+	report := &MeasurementsByHourReport{
+		TimeZone:    ProgramTimeZone,
+		Days:        days,
+		Hours:       make([]HourBucket, 24),
+		GeneratedAt: now,
+	}
+	for hour := range report.Hours {
+		report.Hours[hour].Hour = hour
+	}
+	for _, bucket := range counts {
+		if bucket.Hour < 0 || bucket.Hour > 23 {
+			continue
+		}
+		report.Hours[bucket.Hour].Count += bucket.Count
+		report.Total += bucket.Count
+	}
+	for hour, bucket := range report.Hours {
+		if bucket.Count > 0 && (report.PeakHour == nil || bucket.Count > report.Hours[*report.PeakHour].Count) {
+			peak := hour
+			report.PeakHour = &peak
+		}
+	}
+	return report
+}
+
+// Métricas de la comparación entre periodos
+const (
+	PeriodMetricMeasurements = "measurements" // Mediciones registradas
+	PeriodMetricRisk         = "risk"         // Pacientes con al menos una medición en rojo o amarillo
+)
+
+// IsValidPeriodMetric indica si la métrica es admitida
+func IsValidPeriodMetric(metric string) bool {
+	return metric == PeriodMetricMeasurements || metric == PeriodMetricRisk
+}
+
+// PeriodValue es el valor de la métrica en un periodo [Start, End)
+type PeriodValue struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Value int64     `json:"value"`
+}
+
+// PeriodComparisonReport - Valor del periodo en curso frente al anterior, para flechas de tendencia
+type PeriodComparisonReport struct {
+	Metric        string      `json:"metric"`
+	Period        string      `json:"period"` // week o month
+	Current       PeriodValue `json:"current"`
+	Previous      PeriodValue `json:"previous"`
+	Change        int64       `json:"change"`
+	PercentChange *float64    `json:"percent_change"` // nil si el periodo anterior es 0
+	Trend         string      `json:"trend"`          // up, down o flat
+	GeneratedAt   time.Time   `json:"generated_at"`
+}
+
+// ComparisonPeriods calcula el periodo en curso (desde el inicio de la semana o del mes en
+// ProgramTimeZone hasta now) y el mismo tramo del periodo anterior, para comparar a igual avance
+func ComparisonPeriods(period string, now time.Time) (current, previous PeriodValue) {
+	loc, err := time.LoadLocation(ProgramTimeZone)
+	if err != nil {
+		loc = time.Local
+	}
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+
+	var start, previousStart time.Time
+	if period == TimelineIntervalWeek {
+		// Semanas de lunes a domingo
+		start = today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		previousStart = start.AddDate(0, 0, -7)
+	} else {
+		start = time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, loc)
+		previousStart = start.AddDate(0, -1, 0)
+	}
+
+	previousEnd := previousStart.Add(local.Sub(start))
+	if previousEnd.After(start) {
+		previousEnd = start // El mes anterior es más corto que el avance del mes en curso
+	}
+	return PeriodValue{Start: start, End: local}, PeriodValue{Start: previousStart, End: previousEnd}
+}
+
+// NewPeriodComparisonReport calcula la diferencia y la variación porcentual entre ambos periodos
+func NewPeriodComparisonReport(metric, period string, current, previous PeriodValue, now time.Time) *PeriodComparisonReport {
+	report := &PeriodComparisonReport{
+		Metric:      metric,
+		Period:      period,
+		Current:     current,
+		Previous:    previous,
+		Change:      current.Value - previous.Value,
+		Trend:       "flat",
+		GeneratedAt: now,
+	}
+	if previous.Value > 0 {
+		percent := math.Round(float64(report.Change)/float64(previous.Value)*1000) / 10
+		report.PercentChange = &percent
+	}
+	switch {
+	case report.Change > 0:
+		report.Trend = "up"
+	case report.Change < 0:
+		report.Trend = "down"
+	}
+	return report
+}
+
+// MonthlyStats son las cifras de un mes: mediciones, niños distintos medidos y detecciones en riesgo
+type MonthlyStats struct {
+	Measurements   int64 `json:"measurements"`
+	Children       int64 `json:"children"`
+	RiskDetections int64 `json:"risk_detections"` // Mediciones en rojo o amarillo
+	RedDetections  int64 `json:"red_detections"`
+}
+
+// CaregiverMonthlyStats - Cifras del mes de un apoderado (quien registró las mediciones)
+type CaregiverMonthlyStats struct {
+	CaregiverID   uuid.UUID  `json:"caregiver_id"`
+	CaregiverName string     `json:"caregiver_name"`
+	LocalityID    *uuid.UUID `json:"locality_id"`
+	LocalityName  string     `json:"locality_name"`
+	MonthlyStats
+}
+
+// LocalityMonthlyStats - Cifras del mes de una localidad; los niños se cuentan una vez aunque los midan varios apoderados
+type LocalityMonthlyStats struct {
+	LocalityID   *uuid.UUID `json:"locality_id"`
+	LocalityName string     `json:"locality_name"`
+	Caregivers   int64      `json:"caregivers"`
+	MonthlyStats
+}
+
+// MonthlyReport - Reporte mensual del programa por apoderado y por localidad
+type MonthlyReport struct {
+	Year        int                     `json:"year"`
+	Month       int                     `json:"month"`
+	Start       time.Time               `json:"start"`
+	End         time.Time               `json:"end"`
+	Caregivers  []CaregiverMonthlyStats `json:"caregivers"`
+	Localities  []LocalityMonthlyStats  `json:"localities"`
+	Totals      MonthlyStats            `json:"totals"`
+	GeneratedAt time.Time               `json:"generated_at"`
+}
+
+// MonthBounds devuelve el inicio del mes y el del siguiente en ProgramTimeZone; el mes no puede ser futuro
+func MonthBounds(year, month int, now time.Time) (start, end time.Time, err error) {
+	if month < 1 || month > 12 || year < 2000 {
+		return time.Time{}, time.Time{}, ErrInvalidReportMonth
+	}
+	loc, locErr := time.LoadLocation(ProgramTimeZone)
+	if locErr != nil {
+		loc = time.Local
+	}
+	start = time.Date(year, time.Month(month), 1, 0, 0, 0, 0, loc)
+	if start.After(now) {
+		return time.Time{}, time.Time{}, ErrInvalidReportMonth
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// NewMonthlyReport arma el reporte; los totales se toman de las localidades para no contar dos veces a un niño
+func NewMonthlyReport(year, month int, start, end time.Time, caregivers []CaregiverMonthlyStats, localities []LocalityMonthlyStats, children int64, now time.Time) *MonthlyReport {
+	report := &MonthlyReport{
+		Year:        year,
+		Month:       month,
+		Start:       start,
+		End:         end,
+		Caregivers:  caregivers,
+		Localities:  localities,
+		GeneratedAt: now,
+	}
+	if report.Caregivers == nil {
+		report.Caregivers = []CaregiverMonthlyStats{}
+	}
+	if report.Localities == nil {
+		report.Localities = []LocalityMonthlyStats{}
+	}
+	for _, locality := range localities {
+		report.Totals.Measurements += locality.Measurements
+		report.Totals.RiskDetections += locality.RiskDetections
+		report.Totals.RedDetections += locality.RedDetections
+	}
+	report.Totals.Children = children
+	return report
+}
+
+// LocalityCaseload es la carga de niños por apoderado activo de una localidad
+type LocalityCaseload struct {
+	LocalityID      uuid.UUID `json:"locality_id"`
+	LocalityName    string    `json:"locality_name"`
+	Caregivers      int64     `json:"caregivers"` // Apoderados activos
+	Children        int64     `json:"children"`   // Total de niños de la localidad, incluidos los de apoderados inactivos
+	Assigned        int64     `json:"assigned"`   // Niños a cargo de apoderados activos
+	AverageCaseload float64   `json:"average_per_caregiver"`
+	MinCaseload     int64     `json:"min_per_caregiver"`
+	MaxCaseload     int64     `json:"max_per_caregiver"`
+	NoCaregivers    bool      `json:"no_caregivers"` // Sin apoderados activos: los promedios quedan en 0
+}
+
+// CaseloadDistributionReport - Carga de niños por apoderado en cada localidad, para detectar
+// zonas con exceso o falta de apoderados
+type CaseloadDistributionReport struct {
+	Localities      []LocalityCaseload `json:"localities"` // De mayor a menor promedio; las localidades sin apoderados al final
+	Caregivers      int64              `json:"caregivers"`
+	Children        int64              `json:"children"`
+	AverageCaseload float64            `json:"average_per_caregiver"` // Niños de apoderados activos entre apoderados activos
+	GeneratedAt     time.Time          `json:"generated_at"`
+}
+
+// NewCaseloadDistributionReport ordena las localidades por carga promedio y calcula el promedio general
+func NewCaseloadDistributionReport(localities []LocalityCaseload, now time.Time) *CaseloadDistributionReport {
+	report := &CaseloadDistributionReport{
+		Localities:  make([]LocalityCaseload, 0, len(localities)),
+		GeneratedAt: now,
+	}
+	var assigned int64
+	for _, locality := range localities {
+		locality.NoCaregivers = locality.Caregivers == 0
+		locality.AverageCaseload = math.Round(locality.AverageCaseload*10) / 10
+		report.Caregivers += locality.Caregivers
+		report.Children += locality.Children
+		assigned += locality.Assigned
+		report.Localities = append(report.Localities, locality)
+	}
+
+	sort.SliceStable(report.Localities, func(i, j int) bool {
+		a, b := report.Localities[i], report.Localities[j]
+		if a.NoCaregivers != b.NoCaregivers {
+			return !a.NoCaregivers
+		}
+		if a.AverageCaseload != b.AverageCaseload {
+			return a.AverageCaseload > b.AverageCaseload
+		}
+		return a.LocalityName < b.LocalityName
+	})
+
+	if report.Caregivers > 0 {
+		report.AverageCaseload = math.Round(float64(assigned)/float64(report.Caregivers)*10) / 10
+	}
+	return report
+}
+
+// GPSCoverageCounts son las mediciones según su ubicación: con GPS dentro de la región del programa,
+// con GPS fuera de la región (probable error del dispositivo) o sin GPS, que usan la ubicación de la localidad
+type GPSCoverageCounts struct {
+	Measurements    int64   `json:"measurements"`
+	WithGPS         int64   `json:"with_gps"`
+	OutsideRegion   int64   `json:"outside_region"`
+	Fallback        int64   `json:"fallback"`
+	GPSPercent      float64 `json:"gps_percent"`
+	FallbackPercent float64 `json:"fallback_percent"` // Incluye las de fuera de la región
+}
+
+// CaregiverGPSCoverage es la cobertura GPS de las mediciones de un apoderado
+type CaregiverGPSCoverage struct {
+	CaregiverID       uuid.UUID  `json:"caregiver_id"`
+	CaregiverName     string     `json:"caregiver_name"`
+	LocalityID        *uuid.UUID `json:"locality_id"`
+	LocalityName      string     `json:"locality_name"`
+	GPSCoverageCounts `gorm:"embedded"`
+}
+
+// GPSCoverageReport - Proporción de mediciones con ubicación GPS válida, para la calidad de los mapas
+type GPSCoverageReport struct {
+	Days        int                    `json:"days"`
+	Region      RegionBounds           `json:"region"`
+	Totals      GPSCoverageCounts      `json:"totals"`
+	Caregivers  []CaregiverGPSCoverage `json:"caregivers"` // De menor a mayor porcentaje con GPS
+	GeneratedAt time.Time              `json:"generated_at"`
+}
+
+// fillPercents calcula los porcentajes (con un decimal) sobre el total de mediciones
+func (c *GPSCoverageCounts) fillPercents() {
+	if c.Measurements == 0 {
+		return
+	}
+	c.GPSPercent = math.Round(float64(c.WithGPS)/float64(c.Measurements)*1000) / 10
+	c.FallbackPercent = math.Round(float64(c.OutsideRegion+c.Fallback)/float64(c.Measurements)*1000) / 10
+}
+
+// NewGPSCoverageReport calcula porcentajes y totales y ordena a los apoderados empezando por los que
+// menos usan el GPS, que son a quienes hay que recordarles activarlo
+func NewGPSCoverageReport(caregivers []CaregiverGPSCoverage, days int, region RegionBounds, now time.Time) *GPSCoverageReport {
+	report := &GPSCoverageReport{
+		Days:        days,
+		Region:      region,
+		Caregivers:  make([]CaregiverGPSCoverage, 0, len(caregivers)),
+		GeneratedAt: now,
+	}
+	for _, caregiver := range caregivers {
+		caregiver.fillPercents()
+		report.Totals.Measurements += caregiver.Measurements
+		report.Totals.WithGPS += caregiver.WithGPS
+		report.Totals.OutsideRegion += caregiver.OutsideRegion
+		report.Totals.Fallback += caregiver.Fallback
+		report.Caregivers = append(report.Caregivers, caregiver)
+	}
+	report.Totals.fillPercents()
+
+	sort.SliceStable(report.Caregivers, func(i, j int) bool {
+		a, b := report.Caregivers[i], report.Caregivers[j]
+		if a.GPSPercent != b.GPSPercent {
+			return a.GPSPercent < b.GPSPercent
+		}
+		if a.Measurements != b.Measurements {
+			return a.Measurements > b.Measurements
+		}
+		return a.CaregiverName < b.CaregiverName
+	})
+	return report
+}
+
+// PatientCompletenessCounts son los pacientes con cada campo opcional registrado
+type PatientCompletenessCounts struct {
+	Total     int64
+	BirthDate int64
+	Gender    int64
+	Weight    int64
+	Size      int64
+	ArmSize   int64
+	DNIImage  int64 `gorm:"column:dni_image"`
+}
+
+// MeasurementCompletenessCounts son las mediciones con descripción y con ubicación GPS
+type MeasurementCompletenessCounts struct {
+	Total       int64
+	Description int64
+	GPS         int64 `gorm:"column:gps"`
+}
+
+// FieldCompleteness es la proporción de registros con un campo completo
+type FieldCompleteness struct {
+	Field   string  `json:"field"`
+	Filled  int64   `json:"filled"`
+	Percent float64 `json:"percent"`
+}
+
+// CompletenessGroup es la completitud de los campos de una entidad sobre su total de registros
+type CompletenessGroup struct {
+	Total  int64               `json:"total"` // Denominador de los porcentajes
+	Fields []FieldCompleteness `json:"fields"`
+}
+
+// DataCompletenessReport - Porcentaje de pacientes y mediciones con cada campo opcional completo
+type DataCompletenessReport struct {
+	LocalityID   *uuid.UUID        `json:"locality_id,omitempty"`
+	Patients     CompletenessGroup `json:"patients"`
+	Measurements CompletenessGroup `json:"measurements"`
+	GeneratedAt  time.Time         `json:"generated_at"`
+}
+
+// newCompletenessGroup calcula el porcentaje (con un decimal) de cada campo en el orden recibido
+func newCompletenessGroup(total int64, fields []FieldCompleteness) CompletenessGroup {
+	for i := range fields {
+		if total > 0 {
+			fields[i].Percent = math.Round(float64(fields[i].Filled)/float64(total)*1000) / 10
+		}
+	}
+	return CompletenessGroup{Total: total, Fields: fields}
+}
+
+// NewDataCompletenessReport arma el reporte de completitud a partir de los conteos
+func NewDataCompletenessReport(localityID *uuid.UUID, patients PatientCompletenessCounts, measurements MeasurementCompletenessCounts, now time.Time) *DataCompletenessReport {
+	return &DataCompletenessReport{
+		LocalityID: localityID,
+		Patients: newCompletenessGroup(patients.Total, []FieldCompleteness{
+			{Field: "birth_date", Filled: patients.BirthDate},
+			{Field: "gender", Filled: patients.Gender},
+			{Field: "weight", Filled: patients.Weight},
+			{Field: "size", Filled: patients.Size},
+			{Field: "arm_size", Filled: patients.ArmSize},
+			{Field: "dni_image", Filled: patients.DNIImage},
+		}),
+		Measurements: newCompletenessGroup(measurements.Total, []FieldCompleteness{
+			{Field: "description", Filled: measurements.Description},
+			{Field: "gps", Filled: measurements.GPS},
+		}),
+		GeneratedAt: now,
+	}
+}
+
+// Criterio para señalar un lote de cinta anómalo: con al menos MinTapeBatchSample mediciones, su MUAC
+// promedio se aleja TapeBatchDeviationCm o más del promedio del resto de lotes
+const (
+	MinTapeBatchSample   = 20
+	TapeBatchDeviationCm = 0.5
+)
+
+// TapeBatchStats son las mediciones registradas con un lote de cinta y su distribución de clasificación
+type TapeBatchStats struct {
+	TapeBatch    string  `json:"tape_batch"`
+	Measurements int64   `json:"measurements"`
+	Children     int64   `json:"children"`
+	MuacSum      float64 `json:"-"`
+	AvgMuac      float64 `json:"avg_muac"`
+	Red          int64   `json:"red"`
+	Yellow       int64   `json:"yellow"`
+	Green        int64   `json:"green"`
+	RedPercent   float64 `json:"red_percent"`
+	RiskPercent  float64 `json:"risk_percent"`           // Rojo más amarillo
+	Deviation    float64 `json:"deviation"`              // Promedio del lote menos el del resto de lotes (cm)
+	Anomalous    bool    `json:"anomalous"`              // Ver MinTapeBatchSample y TapeBatchDeviationCm
+	SmallSample  bool    `json:"small_sample,omitempty"` // Menos de MinTapeBatchSample mediciones
+}
+
+// TapeBatchReport - Mediciones y distribución de clasificación por lote de cinta MUAC, para detectar
+// lotes defectuosos que desplazan las mediciones
+type TapeBatchReport struct {
+	Days         int              `json:"days"`
+	Measurements int64            `json:"measurements"` // Con lote registrado
+	Unrecorded   int64            `json:"unrecorded"`   // Mediciones de la ventana sin lote
+	Batches      []TapeBatchStats `json:"batches"`      // Primero los anómalos, luego por mayor desviación
+	GeneratedAt  time.Time        `json:"generated_at"`
+}
+
+// NewTapeBatchReport calcula promedios, porcentajes y desviaciones de cada lote. Las filas con lote vacío
+// se cuentan como mediciones sin lote. La desviación se compara con el resto de lotes para que un lote
+// grande no se tape a sí mismo
+func NewTapeBatchReport(batches []TapeBatchStats, days int, now time.Time) *TapeBatchReport {
+	report := &TapeBatchReport{
+		Days:        days,
+		Batches:     make([]TapeBatchStats, 0, len(batches)),
+		GeneratedAt: now,
+	}
+	var totalSum float64
+	for _, batch := range batches {
+		if batch.TapeBatch == "" {
+			report.Unrecorded += batch.Measurements
+			continue
+		}
+		report.Measurements += batch.Measurements
+		totalSum += batch.MuacSum
+		report.Batches = append(report.Batches, batch)
+	}
+
+	for i := range report.Batches {
+		batch := &report.Batches[i]
+		if batch.Measurements == 0 {
+			continue
+		}
+		batch.AvgMuac = math.Round(batch.MuacSum/float64(batch.Measurements)*100) / 100
+		batch.RedPercent = math.Round(float64(batch.Red)/float64(batch.Measurements)*1000) / 10
+		batch.RiskPercent = math.Round(float64(batch.Red+batch.Yellow)/float64(batch.Measurements)*1000) / 10
+		batch.SmallSample = batch.Measurements < MinTapeBatchSample
+
+		rest := report.Measurements - batch.Measurements
+		if rest == 0 {
+			continue
+		}
+		restAvg := (totalSum - batch.MuacSum) / float64(rest)
+		batch.Deviation = math.Round((batch.MuacSum/float64(batch.Measurements)-restAvg)*100) / 100
+		batch.Anomalous = !batch.SmallSample && math.Abs(batch.Deviation) >= TapeBatchDeviationCm
+	}
+
+	sort.SliceStable(report.Batches, func(i, j int) bool {
+		a, b := report.Batches[i], report.Batches[j]
+		if a.Anomalous != b.Anomalous {
+			return a.Anomalous
+		}
+		if math.Abs(a.Deviation) != math.Abs(b.Deviation) {
+			return math.Abs(a.Deviation) > math.Abs(b.Deviation)
+		}
+		return a.TapeBatch < b.TapeBatch
+	})
+	return report
+}
+
+// LocalityTargetProgress - Mediciones del mes de una localidad frente a su meta mensual
+type LocalityTargetProgress struct {
+	LocalityID      uuid.UUID `json:"locality_id"`
+	LocalityName    string    `json:"locality_name"`
+	MonthlyTarget   int64     `json:"monthly_target"`
+	Actual          int64     `json:"actual"`
+	Remaining       int64     `json:"remaining"` // 0 si ya se alcanzó la meta
+	ProgressPercent float64   `json:"progress_percent"`
+	Reached         bool      `json:"reached"`
+	OnTrack         bool      `json:"on_track"` // El avance es igual o mayor a la parte del mes transcurrida
+}
+
+// TargetProgressReport - Avance de las localidades con meta en un mes, para el seguimiento de metas
+type TargetProgressReport struct {
+	Year           int                      `json:"year"`
+	Month          int                      `json:"month"`
+	Start          time.Time                `json:"start"`
+	End            time.Time                `json:"end"`
+	ElapsedPercent float64                  `json:"elapsed_percent"` // Parte del mes transcurrida (100 en meses pasados)
+	Localities     []LocalityTargetProgress `json:"localities"`      // De menor a mayor avance
+	GeneratedAt    time.Time                `json:"generated_at"`
+}
+
+// NewTargetProgressReport cruza las metas con las cifras mensuales por localidad; las localidades sin
+// mediciones en el mes quedan con avance 0
+func NewTargetProgressReport(year, month int, start, end time.Time, targets []LocalityTargetProgress, stats []LocalityMonthlyStats, now time.Time) *TargetProgressReport {
+	report := &TargetProgressReport{
+		Year:           year,
+		Month:          month,
+		Start:          start,
+		End:            end,
+		ElapsedPercent: 100,
+		Localities:     make([]LocalityTargetProgress, 0, len(targets)),
+		GeneratedAt:    now,
+	}
+	if now.Before(end) {
+		report.ElapsedPercent = math.Round(now.Sub(start).Seconds()/end.Sub(start).Seconds()*1000) / 10
+	}
+
+	actuals := make(map[uuid.UUID]int64, len(stats))
+	for _, locality := range stats {
+		if locality.LocalityID != nil {
+			actuals[*locality.LocalityID] += locality.Measurements
+		}
+	}
+
+	for _, target := range targets {
+		target.Actual = actuals[target.LocalityID]
+		if target.MonthlyTarget > 0 {
+			target.ProgressPercent = math.Round(float64(target.Actual)/float64(target.MonthlyTarget)*1000) / 10
+		}
+		target.Reached = target.Actual >= target.MonthlyTarget
+		if !target.Reached {
+			target.Remaining = target.MonthlyTarget - target.Actual
+		}
+		target.OnTrack = target.Reached || target.ProgressPercent >= report.ElapsedPercent
+		report.Localities = append(report.Localities, target)
+	}
+
+	sort.SliceStable(report.Localities, func(i, j int) bool {
+		a, b := report.Localities[i], report.Localities[j]
+		if a.ProgressPercent != b.ProgressPercent {
+			return a.ProgressPercent < b.ProgressPercent
+		}
+		return a.LocalityName < b.LocalityName
+	})
+	return report
+}
+
+// Ventana para emparejar la medición del apoderado con la del supervisor
+const (
+	DefaultAccuracyWindowHours = 48
+	MaxAccuracyWindowHours     = 168
+)
+
+// AccuracyPair es una medición de un apoderado con la primera medición de un supervisor al mismo
+// paciente dentro de la ventana. El rol se toma del usuario que midió, no se guarda en la medición
+type AccuracyPair struct {
+	CaregiverID     uuid.UUID
+	CaregiverName   string
+	PatientID       uuid.UUID
+	CaregiverValue  float64
+	SupervisorValue float64
+	CaregiverAt     time.Time
+	SupervisorAt    time.Time
+}
+
+// AccuracyStats resume las diferencias entre las lecturas del apoderado y las del supervisor (en cm)
+type AccuracyStats struct {
+	Pairs                  int     `json:"pairs"`
+	MeanAbsoluteDifference float64 `json:"mean_absolute_difference"`
+	Bias                   float64 `json:"bias"` // Promedio de apoderado - supervisor: positivo si el apoderado mide de más
+	MaxAbsoluteDifference  float64 `json:"max_absolute_difference"`
+}
+
+// CaregiverAccuracy es la precisión de un apoderado frente a las re-mediciones de supervisores
+type CaregiverAccuracy struct {
+	CaregiverID   uuid.UUID `json:"caregiver_id"`
+	CaregiverName string    `json:"caregiver_name"`
+	Patients      int       `json:"patients"`
+	AccuracyStats
+}
+
+// CaregiverAccuracyReport - Precisión de los apoderados frente a las re-mediciones de supervisores;
+// las mediciones sin re-medición dentro de la ventana no se consideran
+type CaregiverAccuracyReport struct {
+	WindowHours int                 `json:"window_hours"`
+	Days        int                 `json:"days"`
+	Overall     AccuracyStats       `json:"overall"`
+	Caregivers  []CaregiverAccuracy `json:"caregivers"` // De mayor a menor diferencia media
+	GeneratedAt time.Time           `json:"generated_at"`
+}
+
+// accuracyAccumulator acumula diferencias para calcular AccuracyStats
+type accuracyAccumulator struct {
+	pairs             int
+	sumAbs, sumSigned float64
+	maxAbs            float64
+}
+
+func (a *accuracyAccumulator) add(difference float64) {
+	a.pairs++
+	a.sumAbs += math.Abs(difference)
+	a.sumSigned += difference
+	a.maxAbs = math.Max(a.maxAbs, math.Abs(difference))
+}
+
+func (a *accuracyAccumulator) stats() AccuracyStats {
+	if a.pairs == 0 {
+		return AccuracyStats{}
+	}
+	return AccuracyStats{
+		Pairs:                  a.pairs,
+		MeanAbsoluteDifference: math.Round(a.sumAbs/float64(a.pairs)*100) / 100,
+		Bias:                   math.Round(a.sumSigned/float64(a.pairs)*100) / 100,
+		MaxAbsoluteDifference:  math.Round(a.maxAbs*100) / 100,
+	}
+}
+
+// NewCaregiverAccuracyReport agrupa los pares por apoderado y calcula diferencia media absoluta, sesgo y máximo
+func NewCaregiverAccuracyReport(pairs []AccuracyPair, windowHours, days int, now time.Time) *CaregiverAccuracyReport {
+	type caregiverTotals struct {
+		name     string
+		acc      accuracyAccumulator
+		patients map[uuid.UUID]bool
+	}
+
+	var overall accuracyAccumulator
+	byCaregiver := make(map[uuid.UUID]*caregiverTotals)
+	for _, pair := range pairs {
+		difference := pair.CaregiverValue - pair.SupervisorValue
+		overall.add(difference)
+
+		totals, ok := byCaregiver[pair.CaregiverID]
+		if !ok {
+			totals = &caregiverTotals{name: pair.CaregiverName, patients: make(map[uuid.UUID]bool)}
+			byCaregiver[pair.CaregiverID] = totals
+		}
+		totals.acc.add(difference)
+		totals.patients[pair.PatientID] = true
+	}
+
+	report := &CaregiverAccuracyReport{
+		WindowHours: windowHours,
+		Days:        days,
+		Overall:     overall.stats(),
+		Caregivers:  make([]CaregiverAccuracy, 0, len(byCaregiver)),
+		GeneratedAt: now,
+	}
+	for id, totals := range byCaregiver {
+		report.Caregivers = append(report.Caregivers, CaregiverAccuracy{
+			CaregiverID:   id,
+			CaregiverName: totals.name,
+			Patients:      len(totals.patients),
+			AccuracyStats: totals.acc.stats(),
+		})
+	}
+	sort.Slice(report.Caregivers, func(i, j int) bool {
+		a, b := report.Caregivers[i], report.Caregivers[j]
+		if a.MeanAbsoluteDifference != b.MeanAbsoluteDifference {
+			return a.MeanAbsoluteDifference > b.MeanAbsoluteDifference
+		}
+		return a.CaregiverName < b.CaregiverName
+	})
+	return report
+}
+
+// SustainedRecoveryMeasurements es la cantidad de mediciones verdes seguidas, hasta la última, que
+// confirman la recuperación de un niño
+const SustainedRecoveryMeasurements = 2
+
+// MinRecoverySampleSize es la cantidad de recuperados por debajo de la cual los promedios son poco representativos
+const MinRecoverySampleSize = 10
+
+// MuacHistoryPoint es una medición del historial de un paciente
+type MuacHistoryPoint struct {
+	PatientID  uuid.UUID
+	MuacValue  float64
+	MeasuredAt time.Time
+}
+
+// TimeToRecoveryReport - Días desde la primera detección en rojo o amarillo hasta alcanzar un verde sostenido
+type TimeToRecoveryReport struct {
+	PatientsAtRisk        int64     `json:"patients_at_risk"` // Con alguna medición en rojo o amarillo
+	Recovered             int64     `json:"recovered"`        // Tamaño de la muestra de los promedios
+	Unconfirmed           int64     `json:"unconfirmed"`      // Última medición verde, sin suficientes verdes seguidas
+	Ongoing               int64     `json:"ongoing"`          // Última medición en rojo o amarillo
+	AverageDays           float64   `json:"average_days"`
+	MedianDays            float64   `json:"median_days"`
+	MinDays               float64   `json:"min_days"`
+	MaxDays               float64   `json:"max_days"`
+	SustainedMeasurements int       `json:"sustained_measurements"`
+	SmallSample           bool      `json:"small_sample"` // Menos de MinRecoverySampleSize recuperados
+	GeneratedAt           time.Time `json:"generated_at"`
+}
+
+// Pesos por defecto del índice de severidad: un caso rojo pesa tres veces lo que un caso amarillo
+const (
+	DefaultSeverityWeightSevere   = 3.0
+	DefaultSeverityWeightModerate = 1.0
+)
+
+// SeverityWeights son los pesos vigentes del índice de severidad por localidad
+var SeverityWeights = SeverityIndexWeights{
+	Severe:   DefaultSeverityWeightSevere,
+	Moderate: DefaultSeverityWeightModerate,
+}
+
+// SeverityIndexWeights son los pesos aplicados al porcentaje de casos rojos y amarillos
+type SeverityIndexWeights struct {
+	Severe   float64 `json:"severe"`
+	Moderate float64 `json:"moderate"`
+}
+
+// SetSeverityWeights actualiza los pesos vigentes, ignorando valores no positivos
+func SetSeverityWeights(severe, moderate float64) {
+	if severe > 0 {
+		SeverityWeights.Severe = severe
+	}
+	if moderate > 0 {
+		SeverityWeights.Moderate = moderate
+	}
+}
+
+// LocalitySeverityIndex es el índice de severidad de una localidad con sus componentes
+type LocalitySeverityIndex struct {
+	LocalityID        uuid.UUID `json:"locality_id"`
+	LocalityName      string    `json:"locality_name"`
+	Patients          int       `json:"patients"`
+	Measured          int64     `json:"measured"` // Pacientes con al menos una medición, base de los porcentajes
+	Severe            int64     `json:"severe"`
+	Moderate          int64     `json:"moderate"`
+	Normal            int64     `json:"normal"`
+	SeverePercent     float64   `json:"severe_percent"`
+	ModeratePercent   float64   `json:"moderate_percent"`
+	SevereComponent   float64   `json:"severe_component"`   // Peso rojo × % rojo
+	ModerateComponent float64   `json:"moderate_component"` // Peso amarillo × % amarillo
+	Score             float64   `json:"score"`
+}
+
+// LocalitySeverityReport ordena las localidades por índice de severidad, de mayor a menor
+type LocalitySeverityReport struct {
+	Weights     SeverityIndexWeights    `json:"weights"`
+	Localities  []LocalitySeverityIndex `json:"localities"`
+	GeneratedAt time.Time               `json:"generated_at"`
+}
+
+// NewLocalitySeverityReport calcula el índice de cada localidad a partir de la distribución de la última
+// medición de sus pacientes: score = peso rojo × % rojo + peso amarillo × % amarillo, con los porcentajes
+// sobre los pacientes medidos. Las localidades sin pacientes medidos quedan con score 0
+func NewLocalitySeverityReport(localities []LocalityData, weights SeverityIndexWeights, now time.Time) *LocalitySeverityReport {
+	report := &LocalitySeverityReport{
+		Weights:     weights,
+		Localities:  make([]LocalitySeverityIndex, 0, len(localities)),
+		GeneratedAt: now,
+	}
+
+	for _, locality := range localities {
+		distribution := locality.Distribution
+		index := LocalitySeverityIndex{
+			LocalityID:   locality.LocalityID,
+			LocalityName: locality.LocalityName,
+			Patients:     locality.Total,
+			Severe:       distribution.Severe.Total,
+			Moderate:     distribution.Moderate.Total,
+			Normal:       distribution.Normal.Total,
+		}
+		index.Measured = index.Severe + index.Moderate + index.Normal
+		if index.Measured > 0 {
+			index.SeverePercent = math.Round(float64(index.Severe)/float64(index.Measured)*1000) / 10
+			index.ModeratePercent = math.Round(float64(index.Moderate)/float64(index.Measured)*1000) / 10
+			index.SevereComponent = math.Round(weights.Severe*index.SeverePercent*100) / 100
+			index.ModerateComponent = math.Round(weights.Moderate*index.ModeratePercent*100) / 100
+			index.Score = math.Round((index.SevereComponent+index.ModerateComponent)*100) / 100
+		}
+		report.Localities = append(report.Localities, index)
+	}
+
+	sort.SliceStable(report.Localities, func(i, j int) bool {
+		a, b := report.Localities[i], report.Localities[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Severe != b.Severe {
+			return a.Severe > b.Severe
+		}
+		return a.LocalityName < b.LocalityName
+	})
+	return report
+}
+
+// Egreso del programa por edad: a los 60 meses el niño deja el monitoreo MUAC
+const (
+	AgingOutMonths            = 60
+	DefaultAgingOutWithinDays = 30
+	MaxAgingOutWithinDays     = 365
+)
+
+// AgingOutCandidate es un paciente con su fecha de nacimiento (texto tal como se registró) y su
+// última medición, si la tiene
+type AgingOutCandidate struct {
+	PatientID      uuid.UUID
+	PatientName    string
+	BirthDate      string
+	UserID         *uuid.UUID
+	UserName       string
+	LocalityID     *uuid.UUID
+	LocalityName   string
+	LastMuacValue  *float64
+	LastMeasuredAt *time.Time
+}
+
+// AgingOutPatient es un paciente que cumple 60 meses dentro de la ventana
+type AgingOutPatient struct {
+	PatientID      uuid.UUID  `json:"patient_id"`
+	PatientName    string     `json:"patient_name"`
+	BirthDate      time.Time  `json:"birth_date"`
+	AgingOutDate   time.Time  `json:"aging_out_date"` // Día en que cumple 60 meses
+	DaysRemaining  int        `json:"days_remaining"`
+	UserID         *uuid.UUID `json:"user_id,omitempty"`
+	UserName       string     `json:"user_name"`
+	LocalityID     *uuid.UUID `json:"locality_id,omitempty"`
+	LocalityName   string     `json:"locality_name"`
+	LastMuacValue  *float64   `json:"last_muac_value,omitempty"`
+	LastMuacCode   string     `json:"last_muac_code,omitempty"` // Vacío si nunca fue medido
+	LastMeasuredAt *time.Time `json:"last_measured_at,omitempty"`
+}
+
+// AgingOutReport lista los pacientes que egresan por edad en los próximos días, del más próximo al
+// más lejano. Los pacientes sin fecha de nacimiento válida no se pueden evaluar y solo se cuentan
+type AgingOutReport struct {
+	WithinDays       int               `json:"within_days"`
+	Patients         []AgingOutPatient `json:"patients"`
+	AlreadyAgedOut   int64             `json:"already_aged_out"`   // Ya cumplieron 60 meses
+	MissingBirthDate int64             `json:"missing_birth_date"` // Excluidos: sin fecha o con fecha inválida/futura
+	GeneratedAt      time.Time         `json:"generated_at"`
+}
+
+// NewAgingOutReport calcula el día en que cada paciente cumple 60 meses y conserva los que lo hacen
+// entre hoy y withinDays días
+func NewAgingOutReport(candidates []AgingOutCandidate, withinDays int, now time.Time) *AgingOutReport {
+	report := &AgingOutReport{
+		WithinDays:  withinDays,
+		Patients:    make([]AgingOutPatient, 0),
+		GeneratedAt: now,
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	limit := today.AddDate(0, 0, withinDays)
+
+	for _, candidate := range candidates {
+		birthDate, ok := ParseBirthDate(candidate.BirthDate)
+		if !ok || birthDate.After(today) {
+			report.MissingBirthDate++
+			continue
+		}
+		agingOut := birthDate.AddDate(0, AgingOutMonths, 0)
+		if agingOut.Before(today) {
+			report.AlreadyAgedOut++
+			continue
+		}
+		if agingOut.After(limit) {
+			continue
+		}
+
+		patient := AgingOutPatient{
+			PatientID:      candidate.PatientID,
+			PatientName:    candidate.PatientName,
+			BirthDate:      birthDate,
+			AgingOutDate:   agingOut,
+			DaysRemaining:  int(math.Round(agingOut.Sub(today).Hours() / 24)),
+			UserID:         candidate.UserID,
+			UserName:       candidate.UserName,
+			LocalityID:     candidate.LocalityID,
+			LocalityName:   candidate.LocalityName,
+			LastMuacValue:  candidate.LastMuacValue,
+			LastMeasuredAt: candidate.LastMeasuredAt,
+		}
+		if candidate.LastMuacValue != nil {
+			patient.LastMuacCode, _, _ = ClassifyMuacValue(*candidate.LastMuacValue)
+		}
+		report.Patients = append(report.Patients, patient)
+	}
+
+	sort.SliceStable(report.Patients, func(i, j int) bool {
+		a, b := report.Patients[i], report.Patients[j]
+		if !a.AgingOutDate.Equal(b.AgingOutDate) {
+			return a.AgingOutDate.Before(b.AgingOutDate)
+		}
+		return a.PatientName < b.PatientName
+	})
+	return report
+}
+
+// NoOverrideReason agrupa las reclasificaciones registradas sin motivo
+const NoOverrideReason = "Sin motivo"
+
+// ClassificationOverrideCount son las mediciones con una combinación de clasificación automática
+// (según los umbrales guardados en la medición), etiqueta asignada y motivo
+type ClassificationOverrideCount struct {
+	ComputedCode string
+	AssignedCode string
+	Reason       string
+	Measurements int64
+}
+
+// OverrideReasonCount es la cantidad de reclasificaciones con un mismo motivo
+type OverrideReasonCount struct {
+	Reason     string  `json:"reason"`
+	Count      int64   `json:"count"`
+	Percentage float64 `json:"percentage"` // Sobre el total de reclasificaciones
+}
+
+// OverrideAnalysisReport resume cuántas mediciones llevan una etiqueta distinta de la clasificación
+// automática, en qué dirección y con qué motivos
+type OverrideAnalysisReport struct {
+	Days              int                   `json:"days"`
+	Classified        int64                 `json:"classified"` // Mediciones con etiqueta roja, amarilla o verde
+	Overridden        int64                 `json:"overridden"`
+	OverrideRate      float64               `json:"override_rate"` // Porcentaje de Classified
+	MoreSevere        int64                 `json:"more_severe"`   // Etiqueta más grave que la automática
+	LessSevere        int64                 `json:"less_severe"`   // Etiqueta menos grave que la automática
+	MoreSeverePercent float64               `json:"more_severe_percent"`
+	LessSeverePercent float64               `json:"less_severe_percent"`
+	Reasons           []OverrideReasonCount `json:"reasons"` // De más a menos frecuente
+	GeneratedAt       time.Time             `json:"generated_at"`
+}
+
+// muacCodePriority devuelve la gravedad de un código de clasificación; 0 si no es rojo, amarillo ni verde
+func muacCodePriority(muacCode string) int {
+	switch muacCode {
+	case MuacCodeRed:
+		return PriorityUrgent
+	case MuacCodeYellow:
+		return PriorityAttention
+	case MuacCodeGreen:
+		return PriorityNormal
+	default:
+		return 0
+	}
+}
+
+// NewOverrideAnalysisReport compara la etiqueta asignada con la clasificación automática de cada
+// grupo; las etiquetas de seguimiento u otros códigos no cuentan como clasificación
+func NewOverrideAnalysisReport(counts []ClassificationOverrideCount, days int, now time.Time) *OverrideAnalysisReport {
+	report := &OverrideAnalysisReport{
+		Days:        days,
+		Reasons:     make([]OverrideReasonCount, 0),
+		GeneratedAt: now,
+	}
+
+	reasons := make(map[string]int64)
+	for _, count := range counts {
+		assigned := muacCodePriority(count.AssignedCode)
+		computed := muacCodePriority(count.ComputedCode)
+		if assigned == 0 || computed == 0 {
+			continue
+		}
+		report.Classified += count.Measurements
+		if assigned == computed {
+			continue
+		}
+
+		report.Overridden += count.Measurements
+		if assigned > computed {
+			report.MoreSevere += count.Measurements
+		} else {
+			report.LessSevere += count.Measurements
+		}
+		reason := strings.TrimSpace(count.Reason)
+		if reason == "" {
+			reason = NoOverrideReason
+		}
+		reasons[reason] += count.Measurements
+	}
+
+	if report.Classified > 0 {
+		report.OverrideRate = math.Round(float64(report.Overridden)/float64(report.Classified)*1000) / 10
+	}
+	if report.Overridden > 0 {
+		report.MoreSeverePercent = math.Round(float64(report.MoreSevere)/float64(report.Overridden)*1000) / 10
+		report.LessSeverePercent = math.Round(float64(report.LessSevere)/float64(report.Overridden)*1000) / 10
+	}
+	for reason, count := range reasons {
+		report.Reasons = append(report.Reasons, OverrideReasonCount{
+			Reason:     reason,
+			Count:      count,
+			Percentage: math.Round(float64(count)/float64(report.Overridden)*1000) / 10,
+		})
+	}
+	sort.Slice(report.Reasons, func(i, j int) bool {
+		a, b := report.Reasons[i], report.Reasons[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Reason < b.Reason
+	})
+	return report
+}
+
+// DefaultReliabilityTrendDays es la ventana por defecto de la tendencia de confiabilidad de un apoderado
+const DefaultReliabilityTrendDays = 180
+
+// ReliabilityPeriod son las métricas de confiabilidad de un apoderado en un periodo
+type ReliabilityPeriod struct {
+	Period       time.Time     `json:"period"` // Inicio del periodo
+	Measurements int64         `json:"measurements"`
+	Flagged      int64         `json:"flagged"`      // Marcadas como posible error y aún sin desmarcar
+	FlaggedRate  float64       `json:"flagged_rate"` // Porcentaje de Measurements
+	Accuracy     AccuracyStats `json:"accuracy"`     // Frente a re-mediciones de supervisores; pairs 0 si no hubo
+}
+
+// ReliabilityTrendReport - Evolución de la confiabilidad de un apoderado por periodo, del más antiguo al más reciente
+type ReliabilityTrendReport struct {
+	UserID      uuid.UUID           `json:"user_id"`
+	UserName    string              `json:"user_name"`
+	Interval    string              `json:"interval"`
+	Days        int                 `json:"days"`
+	WindowHours int                 `json:"window_hours"`
+	Periods     []ReliabilityPeriod `json:"periods"`
+	GeneratedAt time.Time           `json:"generated_at"`
+}
+
+// NewReliabilityTrendReport completa cada periodo con la tasa de marcas y la precisión de los pares
+// apoderado-supervisor cuya medición del apoderado cae en el periodo. periods debe venir ordenado
+func NewReliabilityTrendReport(user *User, interval string, days, windowHours int, periods []ReliabilityPeriod, pairs []AccuracyPair, now time.Time) *ReliabilityTrendReport {
+	report := &ReliabilityTrendReport{
+		UserID:      user.ID,
+		UserName:    strings.TrimSpace(user.Name + " " + user.LastName),
+		Interval:    interval,
+		Days:        days,
+		WindowHours: windowHours,
+		Periods:     make([]ReliabilityPeriod, 0, len(periods)),
+		GeneratedAt: now,
+	}
+
+	accumulators := make([]accuracyAccumulator, len(periods))
+	for _, pair := range pairs {
+		// Último periodo que empieza antes o en el momento de la medición
+		i := sort.Search(len(periods), func(i int) bool {
+			return periods[i].Period.After(pair.CaregiverAt)
+		}) - 1
+		if i >= 0 {
+			accumulators[i].add(pair.CaregiverValue - pair.SupervisorValue)
+		}
+	}
+
+	for i, period := range periods {
+		if period.Measurements > 0 {
+			period.FlaggedRate = math.Round(float64(period.Flagged)/float64(period.Measurements)*1000) / 10
+		}
+		period.Accuracy = accumulators[i].stats()
+		report.Periods = append(report.Periods, period)
+	}
+	return report
+}
+
+// Años de historia del reporte estacional
+const (
+	DefaultSeasonalTrendYears = 3
+	MaxSeasonalTrendYears     = 10
+)
+
+// SeasonalTrendMethod describe cómo se calcula SeasonalTrendReport
+const SeasonalTrendMethod = "En cada mes de cada año se toma la última medición del mes de cada niño medido; " +
+	"la prevalencia de riesgo del mes es la proporción de esos niños en estado severo o moderado. " +
+	"average_risk_prevalence promedia esa prevalencia entre los años con datos del mes calendario y " +
+	"pooled_risk_prevalence la calcula sobre todos los niños medidos del mes calendario sumando los años"
+
+// monthNames son los nombres de los meses calendario, de enero a diciembre
+var monthNames = [12]string{
+	"enero", "febrero", "marzo", "abril", "mayo", "junio",
+	"julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre",
+}
+
+// SeasonalMonthCount cuenta, en un mes de un año (en ProgramTimeZone), los niños medidos y cuántos
+// estaban en riesgo según su última medición del mes
+type SeasonalMonthCount struct {
+	Year     int
+	Month    int
+	Patients int64
+	Severe   int64
+	Moderate int64
+}
+
+// SeasonalMonth es la prevalencia de riesgo de un mes calendario agregada sobre los años del reporte
+type SeasonalMonth struct {
+	Month                 int     `json:"month"` // 1 = enero
+	Name                  string  `json:"name"`
+	YearsWithData         int     `json:"years_with_data"`
+	SampleSize            int64   `json:"sample_size"` // Niños medidos en el mes, sumando los años
+	Severe                int64   `json:"severe"`
+	Moderate              int64   `json:"moderate"`
+	AverageRiskPrevalence float64 `json:"average_risk_prevalence"` // Porcentaje; promedio entre años
+	PooledRiskPrevalence  float64 `json:"pooled_risk_prevalence"`  // Porcentaje sobre SampleSize
+	SmallSample           bool    `json:"small_sample"`            // SampleSize menor a MinPrevalenceSampleSize
+}
+
+// SeasonalTrendReport - Prevalencia de riesgo por mes calendario en los últimos años, para ver
+// patrones estacionales y planificar el momento de las intervenciones
+type SeasonalTrendReport struct {
+	Years       int             `json:"years"`
+	From        time.Time       `json:"from"` // Inicio del primer mes incluido
+	TimeZone    string          `json:"time_zone"`
+	PeakMonth   *int            `json:"peak_month"` // Mes con mayor prevalencia promedio; nil sin datos
+	Months      []SeasonalMonth `json:"months"`     // Siempre los 12 meses, de enero a diciembre
+	Method      string          `json:"method"`
+	GeneratedAt time.Time       `json:"generated_at"`
+}
+
+// SeasonalTrendStart devuelve el inicio del primer mes de los últimos years años en ProgramTimeZone:
+// el reporte abarca years*12 meses contando el mes en curso, así cada mes calendario aparece years veces
+func SeasonalTrendStart(years int, now time.Time) time.Time {
+	loc, err := time.LoadLocation(ProgramTimeZone)
+	if err != nil {
+		loc = time.Local
+	}
+	local := now.In(loc)
+	return time.Date(local.Year()-years, local.Month()+1, 1, 0, 0, 0, 0, loc)
+}
+
+// NewSeasonalTrendReport agrega los conteos de cada año-mes en los 12 meses calendario; los meses
+// fuera de 1-12 y los años-mes sin niños medidos se descartan
+func NewSeasonalTrendReport(counts []SeasonalMonthCount, years int, from, now time.Time) *SeasonalTrendReport {
+	report := &SeasonalTrendReport{
+		Years:       years,
+		From:        from,
+		TimeZone:    ProgramTimeZone,
+		Months:      make([]SeasonalMonth, 12),
+		Method:      SeasonalTrendMethod,
+		GeneratedAt: now,
+	}
+
+	prevalenceSums := make([]float64, 12)
+	for i := range report.Months {
+		report.Months[i].Month = i + 1
+		report.Months[i].Name = monthNames[i]
+	}
+	for _, count := range counts {
+		if count.Month < 1 || count.Month > 12 || count.Patients <= 0 {
+			continue
+		}
+		month := &report.Months[count.Month-1]
+		month.YearsWithData++
+		month.SampleSize += count.Patients
+		month.Severe += count.Severe
+		month.Moderate += count.Moderate
+		prevalenceSums[count.Month-1] += float64(count.Severe+count.Moderate) / float64(count.Patients)
+	}
+
+	for i := range report.Months {
+		month := &report.Months[i]
+		month.SmallSample = month.SampleSize < MinPrevalenceSampleSize
+		if month.YearsWithData == 0 {
+			continue
+		}
+		month.AverageRiskPrevalence = math.Round(prevalenceSums[i]/float64(month.YearsWithData)*1000) / 10
+		month.PooledRiskPrevalence = math.Round(float64(month.Severe+month.Moderate)/float64(month.SampleSize)*1000) / 10
+
+		if report.PeakMonth == nil || month.AverageRiskPrevalence > report.Months[*report.PeakMonth-1].AverageRiskPrevalence {
+			peak := month.Month
+			report.PeakMonth = &peak
+		}
+	}
+	return report
 }
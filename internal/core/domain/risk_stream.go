@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Valores por defecto del stream de mediciones en riesgo
+const (
+	DefaultRiskStreamMaxClients = 100
+	DefaultRiskStreamHeartbeat  = 25 * time.Second
+	RiskStreamBufferSize        = 16 // Eventos pendientes por cliente antes de descartar
+)
+
+// RiskMeasurementEvent se emite al registrar una medición en rojo o amarillo
+type RiskMeasurementEvent struct {
+	MeasurementID uuid.UUID  `json:"measurement_id"`
+	PatientID     uuid.UUID  `json:"patient_id"`
+	PatientName   string     `json:"patient_name"`
+	CaregiverID   *uuid.UUID `json:"caregiver_id"`
+	LocalityID    *uuid.UUID `json:"locality_id"`
+	MuacValue     float64    `json:"muac_value"`
+	MuacCode      string     `json:"muac_code"`
+	ColorCode     string     `json:"color_code"`
+	MeasuredAt    time.Time  `json:"measured_at"`
+}
+
+// IsRiskMuacCode indica si el código MUAC corresponde a riesgo (rojo o amarillo)
+func IsRiskMuacCode(muacCode string) bool {
+	return muacCode == MuacCodeRed || muacCode == MuacCodeYellow
+}
+
+// NewRiskMeasurementEvent arma el evento de la medición; caregiver es el apoderado del paciente y puede ser nil
+func NewRiskMeasurementEvent(measurement *Measurement, patient *Patient, caregiver *User) RiskMeasurementEvent {
+	muacCode, colorCode, _ := ClassifyMuacValue(measurement.MuacValue)
+	event := RiskMeasurementEvent{
+		MeasurementID: measurement.ID,
+		PatientID:     patient.ID,
+		PatientName:   patient.Name + " " + patient.Lastname,
+		CaregiverID:   patient.UserID,
+		MuacValue:     measurement.MuacValue,
+		MuacCode:      muacCode,
+		ColorCode:     colorCode,
+		MeasuredAt:    measurement.CreatedAt,
+	}
+	if caregiver != nil {
+		event.LocalityID = caregiver.LocalityID
+	}
+	return event
+}
+
+// RiskStreamFilter limita los eventos que recibe un suscriptor; LocalityID nil recibe todos
+type RiskStreamFilter struct {
+	LocalityID *uuid.UUID
+}
+
+// Matches indica si el evento corresponde al filtro
+func (f RiskStreamFilter) Matches(event RiskMeasurementEvent) bool {
+	if f.LocalityID == nil {
+		return true
+	}
+	return event.LocalityID != nil && *event.LocalityID == *f.LocalityID
+}
+
+// ScopeRiskStream arma el filtro según el rol del usuario: el administrador puede ver todo o
+// una localidad y el supervisor solo la suya
+func ScopeRiskStream(actor *User, localityID *uuid.UUID) (RiskStreamFilter, error) {
+	switch actor.Role.Name {
+	case "ADMINISTRADOR":
+		return RiskStreamFilter{LocalityID: localityID}, nil
+	case "SUPERVISOR":
+		if actor.LocalityID == nil {
+			return RiskStreamFilter{}, ErrRiskStreamForbidden
+		}
+		if localityID != nil && *localityID != *actor.LocalityID {
+			return RiskStreamFilter{}, ErrRiskStreamForbidden
+		}
+		return RiskStreamFilter{LocalityID: actor.LocalityID}, nil
+	default:
+		return RiskStreamFilter{}, ErrRiskStreamForbidden
+	}
+}
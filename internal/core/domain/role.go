@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -44,3 +45,28 @@ func (r *Role) Update(name, description string) {
 	r.Description = description
 	r.UpdatedAt = time.Now()
 }
+
+// RolesRequiringLocality son los roles cuyos usuarios deben tener una localidad asignada;
+// se configura al iniciar la aplicación
+var RolesRequiringLocality = []string{"APODERADO", "SUPERVISOR"}
+
+// SetRolesRequiringLocality actualiza los roles que exigen localidad (lista vacía desactiva la regla)
+func SetRolesRequiringLocality(roleNames []string) {
+	roles := make([]string, 0, len(roleNames))
+	for _, name := range roleNames {
+		if name = strings.ToUpper(strings.TrimSpace(name)); name != "" {
+			roles = append(roles, name)
+		}
+	}
+	RolesRequiringLocality = roles
+}
+
+// RequiresLocality indica si los usuarios con este rol deben tener localidad
+func (r *Role) RequiresLocality() bool {
+	for _, name := range RolesRequiringLocality {
+		if strings.EqualFold(r.Name, name) {
+			return true
+		}
+	}
+	return false
+}
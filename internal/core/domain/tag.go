@@ -29,6 +29,12 @@ func (Tag) TableName() string {
 	return "tags"
 }
 
+// TagOverview resume una etiqueta con el número de mediciones que clasifica
+type TagOverview struct {
+	*Tag
+	MeasurementCount int64 `json:"measurement_count"`
+}
+
 // ============= CONSTRUCTORES =============
 
 // NewTag crea una nueva instancia de Tag básica
@@ -103,9 +109,20 @@ func (t *Tag) Update(name, description string) {
 	t.UpdatedAt = time.Now()
 }
 
-// UpdateMuacTag actualiza una etiqueta MUAC completa
+// UpdateMuacTag actualiza una etiqueta MUAC completa. Los valores vacíos (o prioridad 0)
+// conservan el valor actual; si algún valor es inválido la etiqueta no se modifica
 func (t *Tag) UpdateMuacTag(name, description, color, muacCode string, priority int) error {
 	// Validar antes de actualizar
+	if color != "" && !IsValidHexColor(color) {
+		return fmt.Errorf("%w: %s", ErrInvalidTagColor, color)
+	}
+	if muacCode != "" && !IsValidMuacCode(muacCode) {
+		return fmt.Errorf("%w: %s", ErrInvalidMuacCode, muacCode)
+	}
+	if priority != 0 && (priority < 1 || priority > 10) {
+		return ErrInvalidTagPriority
+	}
+
 	if name != "" {
 		t.Name = name
 	}
@@ -113,18 +130,12 @@ func (t *Tag) UpdateMuacTag(name, description, color, muacCode string, priority
 		t.Description = description
 	}
 	if color != "" {
-		if !IsValidHexColor(color) {
-			return fmt.Errorf("%w: %s", ErrInvalidTagColor, color)
-		}
 		t.Color = color
 	}
 	if muacCode != "" {
-		if !IsValidMuacCode(muacCode) {
-			return fmt.Errorf("%w: %s", ErrInvalidMuacCode, muacCode)
-		}
 		t.MuacCode = muacCode
 	}
-	if priority > 0 && priority <= 10 {
+	if priority != 0 {
 		t.Priority = priority
 	}
 
@@ -186,6 +197,16 @@ func (t *Tag) IsMuacTag() bool {
 	return t.MuacCode != ""
 }
 
+// IsCoreMuacTag indica si es una de las etiquetas base de clasificación MUAC (rojo, amarillo,
+// verde y seguimiento), que el sistema necesita aunque no tengan mediciones
+func (t *Tag) IsCoreMuacTag() bool {
+	switch t.MuacCode {
+	case MuacCodeRed, MuacCodeYellow, MuacCodeGreen, MuacCodeFollow:
+		return true
+	}
+	return false
+}
+
 // IsUrgent verifica si es una etiqueta de alta prioridad
 func (t *Tag) IsUrgent() bool {
 	return t.Priority >= PriorityExtreme || t.MuacCode == MuacCodeRed
@@ -0,0 +1,187 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tipos de tarea de la lista de pendientes del apoderado
+const (
+	TaskTypeOverdueFollowup   = "overdue_followup"   // control vencido según la frecuencia recomendada
+	TaskTypeUnmeasuredPatient = "unmeasured_patient" // paciente asignado sin ninguna medición
+	TaskTypeAlert             = "alert"              // comunicado visible reciente
+)
+
+// Severidades de las tareas, de mayor a menor urgencia
+const (
+	TaskSeverityCritical = "critical"
+	TaskSeverityHigh     = "high"
+	TaskSeverityMedium   = "medium"
+	TaskSeverityLow      = "low"
+)
+
+// AlertTaskWindow es la antigüedad máxima de los comunicados que se muestran como tarea.
+// Las notificaciones no registran lectura por usuario, por lo que se consideran pendientes
+// los comunicados visibles de este periodo.
+const AlertTaskWindow = 7 * 24 * time.Hour
+
+// taskSeverityRank ordena las severidades de mayor a menor urgencia
+var taskSeverityRank = map[string]int{
+	TaskSeverityCritical: 0,
+	TaskSeverityHigh:     1,
+	TaskSeverityMedium:   2,
+	TaskSeverityLow:      3,
+}
+
+// CaregiverTask es un pendiente accionable de un apoderado
+type CaregiverTask struct {
+	Type           string     `json:"type"`
+	Severity       string     `json:"severity"`
+	Title          string     `json:"title"`
+	PatientID      *uuid.UUID `json:"patient_id,omitempty"`
+	PatientName    string     `json:"patient_name,omitempty"`
+	MuacCode       string     `json:"muac_code,omitempty"`
+	NotificationID *uuid.UUID `json:"notification_id,omitempty"`
+	DueDate        *time.Time `json:"due_date,omitempty"`
+	DaysOverdue    int        `json:"days_overdue,omitempty"`
+}
+
+// NewPatientTask devuelve la tarea pendiente del paciente según su última medición, o nil si está al día.
+// Sin mediciones la tarea vence desde el registro del paciente; con mediciones, al cumplirse la
+// frecuencia recomendada para su clasificación (ExpectedMeasurementDays).
+func NewPatientTask(patient *Patient, latest *Measurement, now time.Time) *CaregiverTask {
+	patientID := patient.ID
+	task := &CaregiverTask{
+		PatientID:   &patientID,
+		PatientName: fmt.Sprintf("%s %s", patient.Name, patient.Lastname),
+	}
+
+	if latest == nil {
+		due := patient.CreatedAt
+		task.Type = TaskTypeUnmeasuredPatient
+		task.Severity = TaskSeverityHigh
+		task.Title = "Registrar la primera medición"
+		task.MuacCode = PatientStatusNoMeasurements
+		task.DueDate = &due
+		task.DaysOverdue = daysBetween(due, now)
+		return task
+	}
+
+	code, _, _ := ClassifyMuacValue(latest.MuacValue)
+	due := latest.CreatedAt.AddDate(0, 0, ExpectedMeasurementDays[code])
+	if now.Before(due) {
+		return nil
+	}
+
+	task.Type = TaskTypeOverdueFollowup
+	task.Title = "Control de seguimiento vencido"
+	task.MuacCode = code
+	task.DueDate = &due
+	task.DaysOverdue = daysBetween(due, now)
+	switch code {
+	case MuacCodeRed:
+		task.Severity = TaskSeverityCritical
+	case MuacCodeYellow:
+		task.Severity = TaskSeverityHigh
+	default:
+		task.Severity = TaskSeverityMedium
+	}
+	return task
+}
+
+// NewAlertTask convierte un comunicado visible en tarea informativa
+func NewAlertTask(notification *Notification) *CaregiverTask {
+	notificationID := notification.ID
+	created := notification.CreatedAt
+	return &CaregiverTask{
+		Type:           TaskTypeAlert,
+		Severity:       TaskSeverityLow,
+		Title:          notification.Title,
+		NotificationID: &notificationID,
+		DueDate:        &created,
+	}
+}
+
+// SortCaregiverTasks ordena por urgencia: severidad, luego días de atraso y luego fecha de vencimiento
+func SortCaregiverTasks(tasks []CaregiverTask) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		if taskSeverityRank[a.Severity] != taskSeverityRank[b.Severity] {
+			return taskSeverityRank[a.Severity] < taskSeverityRank[b.Severity]
+		}
+		if a.DaysOverdue != b.DaysOverdue {
+			return a.DaysOverdue > b.DaysOverdue
+		}
+		if a.DueDate == nil || b.DueDate == nil {
+			return a.DueDate != nil
+		}
+		return a.DueDate.Before(*b.DueDate)
+	})
+}
+
+// SessionRosterEntry es un paciente de la lista imprimible para una jornada de medición
+type SessionRosterEntry struct {
+	PatientID      uuid.UUID  `json:"patient_id"`
+	PatientName    string     `json:"patient_name"`
+	DNI            string     `json:"dni"`
+	Age            float64    `json:"age"`
+	LastMuacValue  *float64   `json:"last_muac_value"`
+	LastMuacCode   string     `json:"last_muac_code"` // PatientStatusNoMeasurements si nunca se midió
+	LastMeasuredAt *time.Time `json:"last_measured_at"`
+	DueDate        time.Time  `json:"due_date"`
+	DaysOverdue    int        `json:"days_overdue"` // negativo: días que faltan para el próximo control
+}
+
+// NewSessionRosterEntry calcula el vencimiento del control con la misma regla que NewPatientTask
+func NewSessionRosterEntry(patient *Patient, latest *Measurement, now time.Time) SessionRosterEntry {
+	entry := SessionRosterEntry{
+		PatientID:    patient.ID,
+		PatientName:  fmt.Sprintf("%s %s", patient.Name, patient.Lastname),
+		DNI:          patient.DNI,
+		Age:          patient.Age,
+		LastMuacCode: PatientStatusNoMeasurements,
+		DueDate:      patient.CreatedAt,
+	}
+	if latest != nil {
+		value, measuredAt := latest.MuacValue, latest.CreatedAt
+		entry.LastMuacValue = &value
+		entry.LastMeasuredAt = &measuredAt
+		entry.LastMuacCode, _, _ = ClassifyMuacValue(value)
+		entry.DueDate = measuredAt.AddDate(0, 0, ExpectedMeasurementDays[entry.LastMuacCode])
+	}
+	if now.Before(entry.DueDate) {
+		entry.DaysOverdue = -daysBetween(now, entry.DueDate)
+	} else {
+		entry.DaysOverdue = daysBetween(entry.DueDate, now)
+	}
+	return entry
+}
+
+// SortSessionRoster ordena de más a menos atrasado y, a igual atraso, por nombre
+func SortSessionRoster(entries []SessionRosterEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].DaysOverdue != entries[j].DaysOverdue {
+			return entries[i].DaysOverdue > entries[j].DaysOverdue
+		}
+		return entries[i].PatientName < entries[j].PatientName
+	})
+}
+
+// SessionRoster es la lista de pacientes que un apoderado lleva a la jornada de medición
+type SessionRoster struct {
+	UserID        uuid.UUID            `json:"user_id"`
+	CaregiverName string               `json:"caregiver_name"`
+	GeneratedAt   time.Time            `json:"generated_at"`
+	Patients      []SessionRosterEntry `json:"patients"`
+}
+
+// daysBetween devuelve los días completos transcurridos de from a to (0 si to es anterior)
+func daysBetween(from, to time.Time) int {
+	if to.Before(from) {
+		return 0
+	}
+	return int(to.Sub(from).Hours() / 24)
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,6 +28,8 @@ type User struct {
 
 	Patients []Patient `json:"patients" gorm:"foreignKey:UserID"`
 
+	NotificationPreferences NotificationPreferences `json:"notification_preferences" gorm:"embedded"`
+
 	CreatedAt time.Time  `json:"created_at,omitempty" gorm:"column:created_at;autoCreateTime"`
 	UpdatedAt *time.Time `json:"updated_at,omitempty" gorm:"column:updated_at;autoUpdateTime"`
 }
@@ -55,7 +58,8 @@ func NewUser(
 		RoleID:       roleID,
 		LocalityID:   localityID,
 		// Patients:     patients,
-		CreatedAt: time.Now(),
+		NotificationPreferences: DefaultNotificationPreferences(),
+		CreatedAt:               time.Now(),
 	}
 }
 
@@ -133,3 +137,147 @@ func (u *User) UpdateRole(roleID uuid.UUID) {
 	now := time.Now()
 	u.UpdatedAt = &now
 }
+
+// UpdateNotificationPreferences actualiza las preferencias de notificación del usuario
+func (u *User) UpdateNotificationPreferences(preferences NotificationPreferences) {
+	u.NotificationPreferences = preferences
+
+	now := time.Now()
+	u.UpdatedAt = &now
+}
+
+// Tipos de notificación que el usuario puede desactivar
+const (
+	NotificationKindRedAlert  = "red_alert" // alertas de pacientes en rojo
+	NotificationKindReminder  = "reminder"  // recordatorios de seguimiento
+	NotificationKindBroadcast = "broadcast" // comunicados generales
+)
+
+// IsValidNotificationKind verifica si el tipo de notificación es uno de los conocidos
+func IsValidNotificationKind(kind string) bool {
+	switch kind {
+	case NotificationKindRedAlert, NotificationKindReminder, NotificationKindBroadcast:
+		return true
+	default:
+		return false
+	}
+}
+
+// NotificationPreferences indica qué tipos de notificación acepta el usuario.
+// Todo envío a un usuario debe consultar Allows antes de despacharse.
+type NotificationPreferences struct {
+	RedAlerts  bool `json:"red_alerts" gorm:"column:notify_red_alerts;default:true"`
+	Reminders  bool `json:"reminders" gorm:"column:notify_reminders;default:true"`
+	Broadcasts bool `json:"broadcasts" gorm:"column:notify_broadcasts;default:true"`
+}
+
+// DefaultNotificationPreferences devuelve las preferencias iniciales: todo habilitado
+func DefaultNotificationPreferences() NotificationPreferences {
+	return NotificationPreferences{RedAlerts: true, Reminders: true, Broadcasts: true}
+}
+
+// Allows indica si el usuario acepta notificaciones del tipo indicado
+func (p NotificationPreferences) Allows(kind string) bool {
+	switch kind {
+	case NotificationKindRedAlert:
+		return p.RedAlerts
+	case NotificationKindReminder:
+		return p.Reminders
+	case NotificationKindBroadcast:
+		return p.Broadcasts
+	default:
+		return true
+	}
+}
+
+// Criterios de orden para la lista de apoderados de una localidad
+const (
+	CaregiverSortName         = "name"          // alfabético
+	CaregiverSortPatientLoad  = "patient_load"  // más pacientes primero
+	CaregiverSortLastActivity = "last_activity" // actividad más reciente primero
+)
+
+// IsValidCaregiverSort verifica si el criterio de orden es válido
+func IsValidCaregiverSort(sortBy string) bool {
+	switch sortBy {
+	case CaregiverSortName, CaregiverSortPatientLoad, CaregiverSortLastActivity:
+		return true
+	}
+	return false
+}
+
+// CaregiverLoad resume la carga de trabajo de un apoderado
+type CaregiverLoad struct {
+	UserID         uuid.UUID  `json:"user_id"`
+	Name           string     `json:"name"`
+	LastName       string     `json:"lastname"`
+	Phone          string     `json:"phone"`
+	Email          string     `json:"email"`
+	Active         bool       `json:"active"`
+	PatientCount   int64      `json:"patient_count"`
+	LastActivityAt *time.Time `json:"last_activity_at"` // última medición registrada; nil si nunca midió
+}
+
+// LocalityMove es el resultado de cambiar la localidad de un apoderado. Los pacientes no tienen
+// localidad propia: los reportes la obtienen del apoderado, por lo que sus pacientes (y su historial)
+// pasan a contarse en la nueva localidad
+type LocalityMove struct {
+	UserID             uuid.UUID  `json:"user_id"`
+	PreviousLocalityID *uuid.UUID `json:"previous_locality_id"`
+	LocalityID         uuid.UUID  `json:"locality_id"`
+	AffectedPatients   int64      `json:"affected_patients"`
+	MovedAt            time.Time  `json:"moved_at"`
+}
+
+// ============= CARGA DE PACIENTES POR APODERADO =============
+const (
+	CaseloadModeWarn  = "warn"  // Se asigna igual y se devuelve una advertencia
+	CaseloadModeBlock = "block" // Se rechaza la asignación con 409
+)
+
+// MaxPatientsPerCaregiver es el máximo de pacientes por apoderado (0 = sin límite);
+// se configura al iniciar la aplicación junto con CaseloadMode
+var (
+	MaxPatientsPerCaregiver = 0
+	CaseloadMode            = CaseloadModeWarn
+)
+
+// SetCaseloadLimit actualiza el límite y el modo; un modo desconocido se trata como advertencia
+func SetCaseloadLimit(maxPatients int, mode string) {
+	if maxPatients < 0 {
+		maxPatients = 0
+	}
+	if mode != CaseloadModeBlock {
+		mode = CaseloadModeWarn
+	}
+	MaxPatientsPerCaregiver = maxPatients
+	CaseloadMode = mode
+}
+
+// Caseload es la cantidad de pacientes asignados a un apoderado frente al límite vigente
+type Caseload struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Patients int64     `json:"patients"`
+	Limit    int       `json:"limit"` // 0 = sin límite
+	Mode     string    `json:"mode"`
+	Exceeded bool      `json:"exceeded"`
+}
+
+// NewCaseload construye la carga del apoderado con el límite vigente
+func NewCaseload(userID uuid.UUID, patients int64) *Caseload {
+	return &Caseload{
+		UserID:   userID,
+		Patients: patients,
+		Limit:    MaxPatientsPerCaregiver,
+		Mode:     CaseloadMode,
+		Exceeded: MaxPatientsPerCaregiver > 0 && patients > int64(MaxPatientsPerCaregiver),
+	}
+}
+
+// CheckAssignment valida si se puede asignar un paciente más; solo falla en modo block
+func (c *Caseload) CheckAssignment() error {
+	if c.Limit > 0 && c.Mode == CaseloadModeBlock && c.Patients >= int64(c.Limit) {
+		return fmt.Errorf("%w: %d de %d", ErrCaseloadExceeded, c.Patients, c.Limit)
+	}
+	return nil
+}
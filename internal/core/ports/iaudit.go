@@ -0,0 +1,22 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+)
+
+// IAuditRepository define las operaciones para el repositorio de auditoría
+type IAuditRepository interface {
+	Create(ctx context.Context, entry *domain.AuditEntry) error
+	List(ctx context.Context, filter *domain.AuditFilter, page *domain.Pagination) ([]*domain.AuditEntry, error)
+}
+
+// IAuditService define las operaciones del servicio de auditoría
+type IAuditService interface {
+	// Record guarda la entrada; un fallo se registra en el log sin interrumpir la operación auditada
+	Record(ctx context.Context, entry *domain.AuditEntry)
+	// List devuelve las entradas más recientes primero; actorID debe ser un ADMINISTRADOR
+	List(ctx context.Context, actorID uuid.UUID, filter *domain.AuditFilter, page *domain.Pagination) ([]*domain.AuditEntry, error)
+}
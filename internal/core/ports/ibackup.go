@@ -0,0 +1,38 @@
+package ports
+
+import (
+	"context"
+	"io"
+
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+)
+
+// IBackupImporter opera sobre las tablas dentro de la transacción de una importación
+type IBackupImporter interface {
+	// ExistingIDs devuelve cuáles de los ids existen en la tabla de la entidad
+	ExistingIDs(entity domain.BackupEntity, ids []string) (map[string]bool, error)
+	// Upsert inserta las filas o, si su id ya existe, sobrescribe las columnas que traen; las
+	// ImportDefaults de la entidad solo se aplican a las filas nuevas
+	Upsert(entity domain.BackupEntity, rows []map[string]interface{}) (inserted, updated int64, err error)
+}
+
+// IBackupExporter lee las tablas dentro de la transacción de una exportación
+type IBackupExporter interface {
+	// StreamTable recorre las filas de la entidad ordenadas por id, columna por columna y sin las omitidas
+	StreamTable(entity domain.BackupEntity, fn func(row map[string]interface{}) error) error
+}
+
+// IBackupRepository define el acceso a las tablas para la exportación completa y su importación
+type IBackupRepository interface {
+	// Export ejecuta fn en una transacción de solo lectura: todas las tablas se leen de la misma
+	// instantánea, aunque otras escrituras terminen mientras dura la exportación
+	Export(ctx context.Context, fn func(exporter IBackupExporter) error) error
+	// Import ejecuta fn en una transacción: si fn devuelve error no se guarda nada
+	Import(ctx context.Context, fn func(importer IBackupImporter) error) error
+}
+
+// IBackupService define la exportación completa de los datos del programa y su importación
+type IBackupService interface {
+	Export(ctx context.Context, w io.Writer) error
+	Import(ctx context.Context, r io.Reader, opts domain.BackupImportOptions) (*domain.BackupImportResult, error)
+}
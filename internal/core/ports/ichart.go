@@ -0,0 +1,11 @@
+package ports
+
+import (
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+)
+
+// IChartRenderer define el dibujo de los gráficos que se descargan como imagen
+type IChartRenderer interface {
+	// RenderMuacChart dibuja la serie MUAC como PNG, con las zonas de clasificación y los umbrales
+	RenderMuacChart(series []domain.SparklinePoint) ([]byte, error)
+}
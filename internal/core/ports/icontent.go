@@ -0,0 +1,15 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+)
+
+// IContentService define las operaciones de integridad del contenido (tags y recomendaciones)
+type IContentService interface {
+	GetUnmapped(ctx context.Context) (*domain.UnmappedContent, error)
+	RemapMuacCodes(ctx context.Context, dryRun bool) (*domain.ContentRemap, error)
+	GetUnusedTags(ctx context.Context) (*domain.UnusedTags, error)
+	DeleteUnusedTags(ctx context.Context) (*domain.UnusedTagCleanup, error)
+}
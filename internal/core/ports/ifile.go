@@ -49,4 +49,19 @@ type IFileService interface {
 
 	// GenerateRiskPatientsReport genera un reporte de pacientes en riesgo
 	GenerateRiskPatientsReport(ctx context.Context, report *domain.RiskPatientsReport) ([]byte, error)
+
+	// GenerateFAQsReport genera un Excel imprimible con las FAQs agrupadas por categoría
+	GenerateFAQsReport(ctx context.Context, groups []*domain.FAQGrouped) ([]byte, error)
+
+	// GenerateLocalityRosterReport genera el padrón de pacientes de una localidad
+	GenerateLocalityRosterReport(ctx context.Context, roster *domain.LocalityRoster) ([]byte, error)
+
+	// GeneratePatientMuacChart genera un gráfico PNG de la serie MUAC con las zonas de clasificación
+	GeneratePatientMuacChart(ctx context.Context, series []domain.SparklinePoint) ([]byte, error)
+
+	// GenerateSessionRosterReport genera la lista imprimible de pacientes para una jornada de medición
+	GenerateSessionRosterReport(ctx context.Context, roster *domain.SessionRoster) ([]byte, error)
+
+	// GenerateMonthlyReport genera el reporte mensual por apoderado con una hoja resumen por localidad
+	GenerateMonthlyReport(ctx context.Context, report *domain.MonthlyReport) ([]byte, error)
 }
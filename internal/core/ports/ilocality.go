@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
@@ -16,6 +17,10 @@ type ILocalityRepository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetByName(ctx context.Context, name string) (*domain.Locality, error)
 	FindNearby(ctx context.Context, lat, lng float64, radiusKm float64) ([]domain.Locality, error)
+	FindNearbyWithRisk(ctx context.Context, lat, lng float64, radiusKm float64) ([]domain.NearbyLocalityRisk, error)
+	GetMeasurements(ctx context.Context, localityID uuid.UUID, days int, page *domain.Pagination) ([]domain.LocalityMeasurement, error)
+	GetRoster(ctx context.Context, localityID uuid.UUID, asOf time.Time) ([]domain.LocalityRosterEntry, error)
+	SaveTarget(ctx context.Context, target *domain.LocalityTarget) error
 }
 
 // ILocalityService define las operaciones del servicio para localidades
@@ -27,4 +32,9 @@ type ILocalityService interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetByName(ctx context.Context, name string) (*domain.Locality, error)
 	FindNearbyLocalities(ctx context.Context, lat, lng float64, radiusKm float64) ([]domain.Locality, error)
+	FindNearbyWithRisk(ctx context.Context, lat, lng float64, radiusKm float64) ([]domain.NearbyLocalityRisk, error)
+	GetMeasurements(ctx context.Context, localityID uuid.UUID, days int, page *domain.Pagination) ([]domain.LocalityMeasurement, error)
+	GetRoster(ctx context.Context, localityID uuid.UUID, asOf time.Time) (*domain.LocalityRoster, error)
+	GetPatientPercentiles(ctx context.Context, localityID uuid.UUID) (*domain.LocalityPercentileReport, error)
+	SetTarget(ctx context.Context, localityID uuid.UUID, monthlyTarget int, actorID *uuid.UUID) (*domain.LocalityTarget, error)
 }
@@ -16,10 +16,21 @@ type IMeasurementRepository interface {
 	Update(ctx context.Context, measurement *domain.Measurement) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetByPatientID(ctx context.Context, patientID uuid.UUID) ([]*domain.Measurement, error)
+	GetByPatientIDs(ctx context.Context, patientIDs []uuid.UUID) ([]*domain.Measurement, error)
+	GetLatestByPatientID(ctx context.Context, patientID uuid.UUID) (*domain.Measurement, error)
+	// GetLatestBeforeByPatientID obtiene la última medición del paciente tomada antes de before
+	GetLatestBeforeByPatientID(ctx context.Context, patientID uuid.UUID, before time.Time) (*domain.Measurement, error)
+	GetLatestByPatientIDs(ctx context.Context, patientIDs []uuid.UUID) (map[uuid.UUID]*domain.Measurement, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Measurement, error)
 	GetByTagID(ctx context.Context, tagID uuid.UUID) ([]*domain.Measurement, error)
 	GetByRecommendationID(ctx context.Context, recommendationID uuid.UUID) ([]*domain.Measurement, error)
 	GetByDateRange(ctx context.Context, startDate, endDate time.Time) ([]*domain.Measurement, error)
+	GetChangedSince(ctx context.Context, filter *domain.MeasurementSyncFilter, page *domain.Pagination) ([]*domain.Measurement, error)
+	GetDeletedSince(ctx context.Context, filter *domain.MeasurementSyncFilter) ([]uuid.UUID, error)
+	GetUnclassified(ctx context.Context, page *domain.Pagination) ([]*domain.Measurement, error)
+	GetStoredClassifications(ctx context.Context, localityID *uuid.UUID) ([]domain.MeasurementStoredClassification, error)
+	ApplyClassificationFixes(ctx context.Context, fixes []domain.MeasurementClassificationFix) (int64, error)
+	Search(ctx context.Context, filter *domain.MeasurementSearchFilter, page *domain.Pagination) ([]domain.MeasurementSearchResult, error)
 }
 
 // IMeasurementService define las operaciones del servicio para mediciones (ACTUALIZADO)
@@ -27,16 +38,38 @@ type IMeasurementService interface {
 	Create(ctx context.Context, measurement *domain.Measurement) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Measurement, error)
 	GetAll(ctx context.Context) ([]*domain.Measurement, error)
-	Update(ctx context.Context, measurement *domain.Measurement) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	Update(ctx context.Context, actorID uuid.UUID, measurement *domain.Measurement) error
+	Delete(ctx context.Context, actorID uuid.UUID, id uuid.UUID) error
 	GetByPatientID(ctx context.Context, patientID uuid.UUID) ([]*domain.Measurement, error)
+	GetByPatientIDs(ctx context.Context, patientIDs []uuid.UUID) ([]*domain.Measurement, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Measurement, error)
 	GetByTagID(ctx context.Context, tagID uuid.UUID) ([]*domain.Measurement, error)
 	GetByRecommendationID(ctx context.Context, recommendationID uuid.UUID) ([]*domain.Measurement, error)
 	GetByDateRange(ctx context.Context, startDate, endDate time.Time) ([]*domain.Measurement, error)
 	AssignTag(ctx context.Context, measurementID, tagID uuid.UUID) error
 	AssignRecommendation(ctx context.Context, measurementID, recommendationID uuid.UUID) error
+	Simulate(ctx context.Context, patientID uuid.UUID, muacValue float64) (*domain.MeasurementSimulation, error)
+	GetNextStepRecommendation(ctx context.Context, patientID uuid.UUID) (*domain.NextStepRecommendation, error)
+	GetThresholds(ctx context.Context, id uuid.UUID) (*domain.MeasurementThresholds, error)
+	ClassifyBatch(values []float64) ([]domain.MuacClassification, error)
+	Flag(ctx context.Context, id uuid.UUID, reason string) (*domain.Measurement, error)
+	Unflag(ctx context.Context, id uuid.UUID) (*domain.Measurement, error)
+	GetUnclassified(ctx context.Context, page *domain.Pagination) ([]*domain.Measurement, error)
+	BackfillClassification(ctx context.Context, dryRun bool) (*domain.MeasurementBackfill, error)
+	GetClassificationMismatches(ctx context.Context, localityID *uuid.UUID, page *domain.Pagination) ([]*domain.ClassificationMismatch, error)
+	GetSyncDelta(ctx context.Context, filter *domain.MeasurementSyncFilter, page *domain.Pagination) (*domain.MeasurementSyncDelta, error)
+	Search(ctx context.Context, actorID uuid.UUID, filter *domain.MeasurementSearchFilter, page *domain.Pagination) ([]domain.MeasurementSearchResult, error)
 
 	// ============= NUEVO MÉTODO PARA AUTO-ASIGNACIÓN =============
-	CreateWithAutoAssignment(ctx context.Context, muacValue float64, description string, patientID, userID uuid.UUID) (*domain.Measurement, error)
+	CreateWithAutoAssignment(ctx context.Context, muacValue float64, description string, patientID, userID uuid.UUID, measuredAt time.Time, location *domain.MeasurementLocation, tapeBatch string) (*domain.Measurement, error)
+
+	// SubscribeRiskStream suscribe al usuario a las mediciones en riesgo que puede ver; llamar a la función devuelta al desconectarse
+	SubscribeRiskStream(ctx context.Context, actorID uuid.UUID, localityID *uuid.UUID) (<-chan domain.RiskMeasurementEvent, func(), error)
+}
+
+// IRiskEventBroker distribuye en el proceso los eventos de mediciones en riesgo a los suscriptores
+type IRiskEventBroker interface {
+	Publish(event domain.RiskMeasurementEvent)
+	Subscribe(filter domain.RiskStreamFilter) (<-chan domain.RiskMeasurementEvent, func(), error)
+	HasSubscribers() bool
 }
@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
@@ -14,6 +15,9 @@ type INotificationRepository interface {
 	GetAll(ctx context.Context) ([]*domain.Notification, error)
 	Update(ctx context.Context, notification *domain.Notification) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	GetActive(ctx context.Context, now time.Time) ([]*domain.Notification, error)
+	MarkAllRead(ctx context.Context, userID uuid.UUID, before, readAt time.Time) (int64, error)
+	GetUnreadSince(ctx context.Context, userID uuid.UUID, since time.Time) ([]*domain.Notification, error)
 }
 
 // INotificationService define las operaciones del servicio para notificaciones
@@ -23,4 +27,13 @@ type INotificationService interface {
 	GetAll(ctx context.Context) ([]*domain.Notification, error)
 	Update(ctx context.Context, notification *domain.Notification) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	Resend(ctx context.Context, id uuid.UUID) (*domain.Notification, error)
+	GetActiveAnnouncements(ctx context.Context) ([]*domain.Notification, error)
+	MarkAllRead(ctx context.Context, userID uuid.UUID, before *time.Time) (*domain.NotificationsReadResult, error)
+	GetUnreadSince(ctx context.Context, userID uuid.UUID, since time.Time) ([]*domain.Notification, error)
+}
+
+// INotificationSender entrega una notificación a su destino externo
+type INotificationSender interface {
+	Send(ctx context.Context, notification *domain.Notification) error
 }
@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
@@ -12,12 +13,25 @@ type IPatientRepository interface {
 	Create(ctx context.Context, patient *domain.Patient) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Patient, error)
 	GetByDNI(ctx context.Context, dni string) (*domain.Patient, error)
+	ExistsByDNI(ctx context.Context, dni string) (bool, error)
+	GetExistingDNIs(ctx context.Context, dnis []string) (map[string]bool, error)
 	GetAll(ctx context.Context) ([]*domain.Patient, error)
+	GetAllWithRelations(ctx context.Context, expand domain.PatientExpand) ([]*domain.Patient, error)
 	Update(ctx context.Context, patient *domain.Patient) error
+	UpdateConsent(ctx context.Context, patient *domain.Patient) error
+	UpdateAge(ctx context.Context, patient *domain.Patient) error
+	UpdateApproval(ctx context.Context, patient *domain.Patient) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetByFatherID(ctx context.Context, fatherID uuid.UUID) ([]*domain.Patient, error)
+	GetByLocalityID(ctx context.Context, localityID uuid.UUID, limit int) ([]*domain.Patient, error)
+	CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Patient, error)
 	GetMeasurements(ctx context.Context, patientID uuid.UUID) ([]*domain.Measurement, error)
 	GetUsersWithRiskPatients(ctx context.Context, filters *domain.ReportFilters) ([]*domain.User, error)
+	GetTriage(ctx context.Context, filters *domain.ReportFilters, page *domain.Pagination) ([]domain.TriagePatient, error)
+	GetDuplicateDNIs(ctx context.Context) ([]domain.DuplicateDNIGroup, error)
+	GetOrphaned(ctx context.Context, localityID *uuid.UUID, page *domain.Pagination) ([]domain.OrphanedPatient, error)
+	StreamExport(ctx context.Context, filters *domain.ReportFilters, fn func(domain.PatientExportRow) error) error
 }
 
 // IPatientService define las operaciones del servicio para pacientes
@@ -26,10 +40,32 @@ type IPatientService interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Patient, error)
 	GetByDNI(ctx context.Context, dni string) (*domain.Patient, error)
 	GetAll(ctx context.Context) ([]*domain.Patient, error)
+	GetAllWithRelations(ctx context.Context, expand domain.PatientExpand) ([]*domain.Patient, error)
 	Update(ctx context.Context, patient *domain.Patient) error
+	UpdateConsent(ctx context.Context, patientID uuid.UUID, given bool, reason string, actorID *uuid.UUID) (*domain.Patient, error)
+	UpdateApproval(ctx context.Context, patientID uuid.UUID, status, note string, actorID uuid.UUID) (*domain.Patient, error)
+	Recompute(ctx context.Context, patientID uuid.UUID, actorID *uuid.UUID) (*domain.Patient, error)
+	AuthorizeAgeOverride(ctx context.Context, actorID uuid.UUID) error
+	RecordAgeOverride(ctx context.Context, patient *domain.Patient, actorID uuid.UUID)
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetByFatherID(ctx context.Context, fatherID uuid.UUID) ([]*domain.Patient, error)
+	GetByLocalityID(ctx context.Context, localityID uuid.UUID, limit int) ([]*domain.Patient, error)
 	GetMeasurements(ctx context.Context, patientID uuid.UUID) ([]*domain.Measurement, error)
 	AddMeasurement(ctx context.Context, patientID uuid.UUID, measurement *domain.Measurement) error
 	GetUsersWithRiskPatients(ctx context.Context, filters *domain.ReportFilters) ([]*domain.User, error)
+	GetStatus(ctx context.Context, patientID uuid.UUID) (*domain.PatientStatus, error)
+	GetRecord(ctx context.Context, patientID uuid.UUID) (*domain.PatientRecord, error)
+	GetTriage(ctx context.Context, filters *domain.ReportFilters, page *domain.Pagination) ([]domain.TriagePatient, error)
+	GetSparkline(ctx context.Context, patientID uuid.UUID, points int) ([]domain.SparklinePoint, error)
+	GetGrowthPlotData(ctx context.Context, patientID uuid.UUID) (*domain.GrowthPlotData, error)
+	GetAnthropometryCheck(ctx context.Context, patientID uuid.UUID) (*domain.AnthropometryCheck, error)
+	GetCompliance(ctx context.Context, patientID uuid.UUID) (*domain.PatientCompliance, error)
+	GetCaseload(ctx context.Context, userID uuid.UUID) (*domain.Caseload, error)
+	GetFollowupTasks(ctx context.Context, userID uuid.UUID, now time.Time) ([]domain.CaregiverTask, error)
+	GetSessionRoster(ctx context.Context, userID uuid.UUID, now time.Time) ([]domain.SessionRosterEntry, error)
+	GetDuplicateDNIs(ctx context.Context) ([]domain.DuplicateDNIGroup, error)
+	GetOrphaned(ctx context.Context, localityID *uuid.UUID, page *domain.Pagination) ([]domain.OrphanedPatient, error)
+	CheckDNI(ctx context.Context, dni string) (*domain.DNICheck, error)
+	ValidatePatientImport(ctx context.Context, rows []domain.PatientImportRow) (*domain.PatientImportValidation, error)
+	ExportPatients(ctx context.Context, actorID uuid.UUID, filters *domain.ReportFilters, fn func(domain.PatientExportRow) error) error
 }
@@ -0,0 +1,20 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+)
+
+// IQualityAlertRepository define las operaciones para los reconocimientos de alertas de calidad
+type IQualityAlertRepository interface {
+	GetAcknowledgedIDs(ctx context.Context) (map[string]bool, error)
+	Acknowledge(ctx context.Context, ack *domain.QualityAlertAck) error
+}
+
+// IQualityAlertService define las operaciones del feed de alertas de calidad de datos
+type IQualityAlertService interface {
+	GetFeed(ctx context.Context, samples int) (*domain.QualityAlertFeed, error)
+	Acknowledge(ctx context.Context, alertID, note string, actorID *uuid.UUID) (*domain.QualityAlertAck, error)
+}
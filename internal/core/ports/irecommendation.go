@@ -16,6 +16,10 @@ type IRecommendationRepository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetByName(ctx context.Context, name string) (*domain.Recommendation, error)
 	GetByUmbral(ctx context.Context, umbral string) ([]*domain.Recommendation, error)
+	PropagateToMeasurements(ctx context.Context, recommendation *domain.Recommendation, dryRun bool) (int64, error)
+	GetUnmapped(ctx context.Context) ([]*domain.Recommendation, error)
+	UpdateMuacMapping(ctx context.Context, id uuid.UUID, mapping domain.MuacMapping) error
+	GetReferencingMeasurements(ctx context.Context, id uuid.UUID) ([]domain.RecommendationMeasurementRef, error)
 }
 
 // IRecommendationService define las operaciones del servicio para recomendaciones
@@ -27,4 +31,6 @@ type IRecommendationService interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetByName(ctx context.Context, name string) (*domain.Recommendation, error)
 	GetByUmbral(ctx context.Context, umbral string) ([]*domain.Recommendation, error)
-}
\ No newline at end of file
+	Propagate(ctx context.Context, id uuid.UUID, dryRun bool) (*domain.RecommendationPropagation, error)
+	Impact(ctx context.Context, id uuid.UUID, minValue, maxValue *float64) (*domain.RecommendationImpact, error)
+}
@@ -3,7 +3,9 @@ package ports
 
 import (
 	"context"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
 )
 
@@ -25,6 +27,70 @@ type IReportRepository interface {
 	GetUserActivity(ctx context.Context, filters *domain.ReportFilters) (*domain.UserActivityReport, error)
 
 	GetRiskPatientsCoordinates(ctx context.Context, filters *domain.ReportFilters) ([][]float64, error)
+
+	// Uso de recomendaciones
+	GetRecommendationUsage(ctx context.Context, filters *domain.ReportFilters) (*domain.RecommendationUsageReport, error)
+
+	// Tasa de recuperación
+	GetRecoveryRate(ctx context.Context, filters *domain.ReportFilters) (*domain.RecoveryRateReport, error)
+
+	// Casos severos y moderados según la última medición de cada niño
+	GetPrevalenceCounts(ctx context.Context, filters *domain.ReportFilters) (*domain.PrevalenceReport, error)
+	GetSeasonalCounts(ctx context.Context, filters *domain.ReportFilters, since time.Time) ([]domain.SeasonalMonthCount, error)
+	GetPatientBirthDates(ctx context.Context, filters *domain.ReportFilters) ([]string, error)
+	GetAgingOutCandidates(ctx context.Context, filters *domain.ReportFilters) ([]domain.AgingOutCandidate, error)
+
+	// Registros de pacientes en el tiempo
+	GetRegistrationsTimeline(ctx context.Context, filters *domain.ReportFilters, interval string) (*domain.RegistrationsTimelineReport, error)
+
+	// Mediciones agrupadas por coordenada
+	GetMeasurementHeatPoints(ctx context.Context, filters *domain.ReportFilters) ([]domain.HeatPoint, error)
+	GetMeasurementCountsByHour(ctx context.Context, filters *domain.ReportFilters) ([]domain.HourBucket, error)
+	GetRedAlertResponses(ctx context.Context, filters *domain.ReportFilters) ([]domain.AlertResponse, error)
+	CountPeriodMetric(ctx context.Context, metric string, filters *domain.ReportFilters, from, to time.Time) (int64, error)
+	GetMonthlyStats(ctx context.Context, filters *domain.ReportFilters, from, to time.Time) ([]domain.CaregiverMonthlyStats, []domain.LocalityMonthlyStats, int64, error)
+
+	// Mediciones con la medición anterior del paciente, para detectar cambios de clasificación
+	StreamTransitionMeasurements(ctx context.Context, filters *domain.ReportFilters, from, to time.Time, fn func(domain.TransitionMeasurement) error) error
+
+	// Mediciones con y sin ubicación GPS por apoderado
+	GetGPSCoverageByCaregiver(ctx context.Context, filters *domain.ReportFilters, region domain.RegionBounds) ([]domain.CaregiverGPSCoverage, error)
+	GetTapeBatchStats(ctx context.Context, filters *domain.ReportFilters) ([]domain.TapeBatchStats, error)
+	GetClassificationOverrideCounts(ctx context.Context, filters *domain.ReportFilters) ([]domain.ClassificationOverrideCount, error)
+	CountOverdueFollowups(ctx context.Context, filters *domain.ReportFilters, now time.Time) (int64, error)
+	GetDataCompleteness(ctx context.Context, filters *domain.ReportFilters) (domain.PatientCompletenessCounts, domain.MeasurementCompletenessCounts, error)
+
+	// Mediciones de apoderados emparejadas con la re-medición de un supervisor dentro de la ventana
+	GetAccuracyPairs(ctx context.Context, filters *domain.ReportFilters, window time.Duration) ([]domain.AccuracyPair, error)
+	GetReliabilityPeriods(ctx context.Context, userID uuid.UUID, interval string, since time.Time) ([]domain.ReliabilityPeriod, error)
+
+	// Metas mensuales de mediciones por localidad
+	GetLocalityTargets(ctx context.Context, localityID *uuid.UUID) ([]domain.LocalityTargetProgress, error)
+
+	// Niños por apoderado activo en cada localidad
+	GetCaseloadByLocality(ctx context.Context, filters *domain.ReportFilters) ([]domain.LocalityCaseload, error)
+
+	// Localidades sin usuarios activos
+	GetUncoveredLocalities(ctx context.Context) ([]domain.UncoveredLocality, error)
+	GetActiveLocalities(ctx context.Context, filters *domain.ReportFilters, from, to time.Time) ([]domain.ActiveLocality, error)
+
+	// Mediciones marcadas para revisión
+	GetFlaggedMeasurements(ctx context.Context, filters *domain.ReportFilters, page *domain.Pagination) ([]domain.FlaggedMeasurement, error)
+
+	// Pacientes pendientes de aprobación y mediciones marcadas, del más antiguo al más reciente
+	GetPendingReview(ctx context.Context, localityID *uuid.UUID, limit int) (*domain.PendingReviewReport, error)
+
+	// Historial completo de los pacientes con alguna medición en riesgo, ordenado por paciente y fecha
+	GetRiskPatientHistories(ctx context.Context, filters *domain.ReportFilters) ([]domain.MuacHistoryPoint, error)
+
+	// Instantes de medición por paciente, ordenados por paciente y fecha
+	GetMeasurementTimes(ctx context.Context, filters *domain.ReportFilters) ([]domain.MeasurementTime, error)
+
+	// Contadores para insignias
+	GetCounters(ctx context.Context, filters *domain.ReportFilters) (*domain.CountersReport, error)
+
+	// Actividad de los apoderados de la localidad de un usuario
+	GetLocalityCaregiverActivity(ctx context.Context, userID uuid.UUID, filters *domain.ReportFilters) (string, []domain.CaregiverActivity, error)
 }
 
 // IReportService define las operaciones del servicio para reportes
@@ -35,6 +101,37 @@ type IReportService interface {
 	GetRecentMeasurementsReport(ctx context.Context, filters *domain.ReportFilters) (*domain.RecentMeasurementsReport, error)
 	GetRiskPatientsReport(ctx context.Context, filters *domain.ReportFilters) (*domain.RiskPatientsReport, error)
 	GetUserActivityReport(ctx context.Context, filters *domain.ReportFilters) (*domain.UserActivityReport, error)
+	GetRecommendationUsageReport(ctx context.Context, filters *domain.ReportFilters) (*domain.RecommendationUsageReport, error)
+	GetRecoveryRateReport(ctx context.Context, filters *domain.ReportFilters) (*domain.RecoveryRateReport, error)
+	GetPrevalenceReport(ctx context.Context, filters *domain.ReportFilters) (*domain.PrevalenceReport, error)
+	GetSeasonalTrend(ctx context.Context, filters *domain.ReportFilters, years int) (*domain.SeasonalTrendReport, error)
+	GetAgeDistribution(ctx context.Context, filters *domain.ReportFilters) (*domain.AgeDistributionReport, error)
+	GetAgingOut(ctx context.Context, filters *domain.ReportFilters, withinDays int) (*domain.AgingOutReport, error)
+	GetRegistrationsTimelineReport(ctx context.Context, filters *domain.ReportFilters, interval string) (*domain.RegistrationsTimelineReport, error)
+	GetCaregiverLeaderboard(ctx context.Context, userID uuid.UUID, filters *domain.ReportFilters) (*domain.LeaderboardReport, error)
+	GetCountersReport(ctx context.Context, filters *domain.ReportFilters) (*domain.CountersReport, error)
+	GetMeasurementIntervalsReport(ctx context.Context, filters *domain.ReportFilters) (*domain.MeasurementIntervalsReport, error)
+	GetFlaggedMeasurements(ctx context.Context, filters *domain.ReportFilters, page *domain.Pagination) ([]domain.FlaggedMeasurement, error)
+	GetUncoveredLocalities(ctx context.Context) ([]domain.UncoveredLocality, error)
+	GetMeasurementHeatcells(ctx context.Context, filters *domain.ReportFilters, precision int) (*domain.MeasurementHeatcellsReport, error)
+	GetMeasurementsByHour(ctx context.Context, filters *domain.ReportFilters) (*domain.MeasurementsByHourReport, error)
+	GetAlertResponseTimes(ctx context.Context, filters *domain.ReportFilters) (*domain.AlertResponseTimesReport, error)
+	GetTimeToRecovery(ctx context.Context, filters *domain.ReportFilters) (*domain.TimeToRecoveryReport, error)
+	GetPeriodComparison(ctx context.Context, filters *domain.ReportFilters, metric, period string) (*domain.PeriodComparisonReport, error)
+	GetMonthlyReport(ctx context.Context, filters *domain.ReportFilters, year, month int) (*domain.MonthlyReport, error)
+	GetCaseloadDistribution(ctx context.Context, filters *domain.ReportFilters) (*domain.CaseloadDistributionReport, error)
+	GetTargetProgress(ctx context.Context, filters *domain.ReportFilters, year, month int) (*domain.TargetProgressReport, error)
+	GetGPSCoverage(ctx context.Context, filters *domain.ReportFilters) (*domain.GPSCoverageReport, error)
+	GetTapeBatchReport(ctx context.Context, filters *domain.ReportFilters) (*domain.TapeBatchReport, error)
+	GetOverrideAnalysis(ctx context.Context, filters *domain.ReportFilters) (*domain.OverrideAnalysisReport, error)
+	GetLocalitySeverityIndex(ctx context.Context, filters *domain.ReportFilters, weights domain.SeverityIndexWeights) (*domain.LocalitySeverityReport, error)
+	GetActiveLocalities(ctx context.Context, filters *domain.ReportFilters, from, to time.Time) (*domain.ActiveLocalitiesReport, error)
+	GetMyDashboard(ctx context.Context, actorID uuid.UUID) (*domain.CaregiverDashboard, error)
+	GetDataCompleteness(ctx context.Context, filters *domain.ReportFilters) (*domain.DataCompletenessReport, error)
+	GetCaregiverAccuracy(ctx context.Context, filters *domain.ReportFilters, windowHours int) (*domain.CaregiverAccuracyReport, error)
+	GetReliabilityTrend(ctx context.Context, userID uuid.UUID, filters *domain.ReportFilters, interval string, windowHours int) (*domain.ReliabilityTrendReport, error)
+	GetPendingReview(ctx context.Context, actorID uuid.UUID, localityID *uuid.UUID, limit int) (*domain.PendingReviewReport, error)
+	ExportClassificationTransitions(ctx context.Context, filters *domain.ReportFilters, from, to time.Time, fn func(domain.ClassificationTransition) error) error
 
 	// Validación
 	ValidateFilters(filters *domain.ReportFilters) error
@@ -0,0 +1,13 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+)
+
+// ISyncService define las operaciones del servicio de sincronización offline
+type ISyncService interface {
+	GetBootstrap(ctx context.Context, localityID uuid.UUID) (*domain.SyncBootstrapBundle, error)
+}
@@ -15,6 +15,11 @@ type ITagRepository interface {
 	Update(ctx context.Context, tag *domain.Tag) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetByName(ctx context.Context, name string) (*domain.Tag, error)
+	CountMeasurementsByTag(ctx context.Context) (map[uuid.UUID]int64, error)
+	GetUnmapped(ctx context.Context) ([]*domain.Tag, error)
+	UpdateMuacMapping(ctx context.Context, id uuid.UUID, mapping domain.MuacMapping) error
+	GetUnused(ctx context.Context) ([]*domain.Tag, error)
+	DeleteUnused(ctx context.Context, ids []uuid.UUID) (int64, error)
 }
 
 // ITagService define las operaciones del servicio para etiquetas
@@ -25,4 +30,5 @@ type ITagService interface {
 	Update(ctx context.Context, tag *domain.Tag) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetByName(ctx context.Context, name string) (*domain.Tag, error)
-}
\ No newline at end of file
+	GetOverview(ctx context.Context, active *bool) ([]*domain.TagOverview, error)
+}
@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+)
+
+// ITaskService define las operaciones del servicio de pendientes del apoderado
+type ITaskService interface {
+	GetCaregiverTasks(ctx context.Context, userID uuid.UUID) ([]domain.CaregiverTask, error)
+	GetSessionRoster(ctx context.Context, userID uuid.UUID) (*domain.SessionRoster, error)
+}
@@ -15,8 +15,11 @@ type IUserRepository interface {
 	GetByUsernameOrEmail(ctx context.Context, usernameOrEmail string) (*domain.User, error)
 	GetAll(ctx context.Context, localityID *uuid.UUID) ([]*domain.User, error)
 	Update(ctx context.Context, user *domain.User) error
+	UpdateLocality(ctx context.Context, id uuid.UUID, localityID uuid.UUID) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetByRole(ctx context.Context, roleName string, localityID *uuid.UUID) ([]*domain.User, error)
+	GetWithoutLocality(ctx context.Context) ([]*domain.User, error)
+	GetCaregiverLoads(ctx context.Context, roleName string, localityID uuid.UUID, sortBy string) ([]domain.CaregiverLoad, error)
 }
 
 // IUserService define las operaciones del servicio para usuarios
@@ -30,5 +33,9 @@ type IUserService interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 	UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error
 	UpdateRole(ctx context.Context, id uuid.UUID, roleID uuid.UUID) error
+	UpdateLocality(ctx context.Context, id uuid.UUID, localityID uuid.UUID, actorID *uuid.UUID) (*domain.LocalityMove, error)
+	UpdateNotificationPreferences(ctx context.Context, id uuid.UUID, preferences domain.NotificationPreferences) error
 	GetApoderados(ctx context.Context, localityID *uuid.UUID) ([]*domain.User, error)
+	GetWithoutLocality(ctx context.Context) ([]*domain.User, error)
+	GetCaregiverLoads(ctx context.Context, localityID uuid.UUID, sortBy string) ([]domain.CaregiverLoad, error)
 }
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+)
+
+// auditService implementa la lógica de negocio para la auditoría
+type auditService struct {
+	auditRepo ports.IAuditRepository
+	userRepo  ports.IUserRepository
+	logger    *slog.Logger
+}
+
+// NewAuditService crea una nueva instancia de AuditService
+func NewAuditService(auditRepo ports.IAuditRepository, userRepo ports.IUserRepository, logger *slog.Logger) ports.IAuditService {
+	return &auditService{
+		auditRepo: auditRepo,
+		userRepo:  userRepo,
+		logger:    logger,
+	}
+}
+
+// Record guarda la entrada de auditoría; si falla se registra en el log para no
+// revertir una operación que ya se completó
+func (s *auditService) Record(ctx context.Context, entry *domain.AuditEntry) {
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		s.logger.ErrorContext(ctx, "no se pudo guardar la entrada de auditoría",
+			"entity", entry.Entity, "entity_id", entry.EntityID, "action", entry.Action, "error", err)
+	}
+}
+
+// List obtiene las entradas de auditoría filtradas; solo disponible para administradores
+func (s *auditService) List(ctx context.Context, actorID uuid.UUID, filter *domain.AuditFilter, page *domain.Pagination) ([]*domain.AuditEntry, error) {
+	if actorID == uuid.Nil {
+		return nil, domain.ErrAuditForbidden
+	}
+	actor, err := s.userRepo.GetByID(ctx, actorID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil, domain.ErrAuditForbidden
+		}
+		return nil, err
+	}
+	if actor.Role.Name != "ADMINISTRADOR" {
+		return nil, domain.ErrAuditForbidden
+	}
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	return s.auditRepo.List(ctx, filter, page)
+}
@@ -0,0 +1,317 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+)
+
+// backupService implementa la interfaz IBackupService
+type backupService struct {
+	backupRepo ports.IBackupRepository
+}
+
+// NewBackupService crea una nueva instancia de BackupService
+func NewBackupService(backupRepo ports.IBackupRepository) ports.IBackupService {
+	return &backupService{
+		backupRepo: backupRepo,
+	}
+}
+
+// Export escribe todas las entidades de BackupEntities en un único JSON:
+//
+//	{"format":"muac-export","version":1,"exported_at":"...","entities":{"roles":[{...}],...}}
+//
+// Cada fila es un objeto con las columnas de la tabla; se escribe fila por fila sin cargar las tablas
+// en memoria. Todas las tablas se leen en una misma transacción de solo lectura, así el archivo no
+// mezcla estados de la base aunque haya escrituras durante la exportación. Si falla a mitad de
+// camino el JSON queda incompleto y la importación lo rechaza
+func (s *backupService) Export(ctx context.Context, w io.Writer) error {
+	out := bufio.NewWriter(w)
+
+	header, err := json.Marshal(domain.BackupHeader{
+		Format:     domain.BackupFormat,
+		Version:    domain.BackupVersion,
+		ExportedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	// Se reemplaza la llave de cierre del encabezado para continuar con las entidades
+	out.Write(header[:len(header)-1])
+	out.WriteString(`,"entities":{`)
+
+	err = s.backupRepo.Export(ctx, func(exporter ports.IBackupExporter) error {
+		for i, entity := range domain.BackupEntities {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			name, _ := json.Marshal(entity.Name)
+			out.Write(name)
+			out.WriteString(":[")
+
+			first := true
+			err := exporter.StreamTable(entity, func(row map[string]interface{}) error {
+				encoded, err := json.Marshal(row)
+				if err != nil {
+					return fmt.Errorf("error al codificar fila de %s: %w", entity.Name, err)
+				}
+				if !first {
+					out.WriteByte(',')
+				}
+				first = false
+				_, err = out.Write(encoded)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			out.WriteByte(']')
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	out.WriteString("}}\n")
+	return out.Flush()
+}
+
+// errBackupRollback revierte la transacción de una importación que no debe guardarse
+var errBackupRollback = errors.New("importación revertida")
+
+// Import lee un archivo de Export y lo aplica en una sola transacción, en el orden de BackupEntities.
+// Las filas se insertan o, si su ID ya existe, se sobrescriben, de modo que importar dos veces el mismo
+// archivo deja el mismo resultado. Antes de guardar cada lote se verifica que sus referencias apunten a
+// filas existentes o ya importadas; si alguna está rota no se guarda nada y se devuelve
+// ErrBackupIntegrity junto con el resultado. Con DryRun se hace todo y se revierte al final
+func (s *backupService) Import(ctx context.Context, r io.Reader, opts domain.BackupImportOptions) (*domain.BackupImportResult, error) {
+	result := &domain.BackupImportResult{
+		DryRun:     opts.DryRun,
+		Entities:   []domain.BackupEntityResult{},
+		Violations: []domain.BackupViolation{},
+	}
+
+	err := s.backupRepo.Import(ctx, func(importer ports.IBackupImporter) error {
+		dec := json.NewDecoder(r)
+		dec.UseNumber()
+
+		if err := expectDelim(dec, '{'); err != nil {
+			return err
+		}
+		var header domain.BackupHeader
+		for dec.More() {
+			key, err := readKey(dec)
+			if err != nil {
+				return err
+			}
+			switch key {
+			case "format":
+				err = dec.Decode(&header.Format)
+			case "version":
+				err = dec.Decode(&header.Version)
+			case "entities":
+				if header.Format != domain.BackupFormat {
+					return fmt.Errorf("%w: format debe ser %q y preceder a entities", domain.ErrInvalidBackup, domain.BackupFormat)
+				}
+				if header.Version != domain.BackupVersion {
+					return fmt.Errorf("%w: %d", domain.ErrUnsupportedBackupVersion, header.Version)
+				}
+				err = s.importEntities(ctx, dec, importer, opts, result)
+			default:
+				// Metadatos informativos (exported_at) o agregados en versiones futuras
+				var skip json.RawMessage
+				err = dec.Decode(&skip)
+			}
+			if err != nil {
+				return invalidBackup(err)
+			}
+		}
+		if err := expectDelim(dec, '}'); err != nil {
+			return err
+		}
+
+		if result.TotalViolations > 0 || opts.DryRun {
+			return errBackupRollback
+		}
+		return nil
+	})
+
+	result.ImportedAt = time.Now()
+	switch {
+	case err == nil:
+		result.Committed = true
+		return result, nil
+	case errors.Is(err, errBackupRollback):
+		if result.TotalViolations > 0 {
+			return result, domain.ErrBackupIntegrity
+		}
+		return result, nil
+	default:
+		return nil, err
+	}
+}
+
+// importEntities recorre el objeto entities importando las filas por lotes
+func (s *backupService) importEntities(ctx context.Context, dec *json.Decoder, importer ports.IBackupImporter, opts domain.BackupImportOptions, result *domain.BackupImportResult) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	last := -1
+	for dec.More() {
+		name, err := readKey(dec)
+		if err != nil {
+			return err
+		}
+		index := domain.BackupEntityIndex(name)
+		if index < 0 {
+			return fmt.Errorf("%w: %s", domain.ErrUnknownBackupEntity, name)
+		}
+		if index <= last {
+			return fmt.Errorf("%w: %s", domain.ErrBackupEntityOrder, name)
+		}
+		last = index
+		entity := domain.BackupEntities[index]
+
+		if err := expectDelim(dec, '['); err != nil {
+			return err
+		}
+		entityResult := domain.BackupEntityResult{Entity: entity.Name}
+		batch := make([]map[string]interface{}, 0, domain.BackupImportBatchSize)
+		flush := func() error {
+			valid, err := checkReferences(importer, entity, batch, result)
+			if err != nil {
+				return err
+			}
+			entityResult.Rejected += int64(len(batch) - len(valid))
+
+			inserted, updated, err := importer.Upsert(entity, valid)
+			if err != nil {
+				return err
+			}
+			entityResult.Inserted += inserted
+			entityResult.Updated += updated
+			batch = batch[:0]
+			return nil
+		}
+
+		for dec.More() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			var row map[string]interface{}
+			if err := dec.Decode(&row); err != nil {
+				return invalidBackup(err)
+			}
+			if err := entity.PrepareImportRow(row, opts.AllowSensitiveColumns); err != nil {
+				return invalidBackup(err)
+			}
+			entityResult.Read++
+			batch = append(batch, row)
+			if len(batch) == domain.BackupImportBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			return err
+		}
+
+		result.Entities = append(result.Entities, entityResult)
+	}
+	return expectDelim(dec, '}')
+}
+
+// checkReferences devuelve las filas del lote cuyas referencias existen en la transacción (las
+// entidades anteriores ya se importaron) y registra en result las que no
+func checkReferences(importer ports.IBackupImporter, entity domain.BackupEntity, batch []map[string]interface{}, result *domain.BackupImportResult) ([]map[string]interface{}, error) {
+	broken := make(map[int]bool)
+	for _, ref := range entity.References {
+		ids := make([]string, 0, len(batch))
+		for _, row := range batch {
+			if id := ref.ReferencedID(row); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		target := domain.BackupEntities[domain.BackupEntityIndex(ref.Entity)]
+		existing, err := importer.ExistingIDs(target, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		for n, row := range batch {
+			id := ref.ReferencedID(row)
+			if id == "" || existing[id] {
+				continue
+			}
+			broken[n] = true
+			rowID, _ := row["id"].(string)
+			result.AddViolation(domain.BackupViolation{
+				Entity:           entity.Name,
+				RowID:            rowID,
+				Column:           ref.Column,
+				ReferencedEntity: ref.Entity,
+				MissingID:        id,
+			})
+		}
+	}
+	if len(broken) == 0 {
+		return batch, nil
+	}
+
+	valid := make([]map[string]interface{}, 0, len(batch)-len(broken))
+	for n, row := range batch {
+		if !broken[n] {
+			valid = append(valid, row)
+		}
+	}
+	return valid, nil
+}
+
+// expectDelim lee el siguiente token y verifica que sea el delimitador indicado
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return invalidBackup(err)
+	}
+	if got, ok := token.(json.Delim); !ok || got != delim {
+		return fmt.Errorf("%w: se esperaba %q", domain.ErrInvalidBackup, delim)
+	}
+	return nil
+}
+
+// readKey lee la clave de la siguiente propiedad de un objeto
+func readKey(dec *json.Decoder) (string, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return "", invalidBackup(err)
+	}
+	key, ok := token.(string)
+	if !ok {
+		return "", fmt.Errorf("%w: se esperaba una clave", domain.ErrInvalidBackup)
+	}
+	return key, nil
+}
+
+// invalidBackup envuelve los errores de lectura del JSON; los errores ya clasificados se devuelven tal cual
+func invalidBackup(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: %v", domain.ErrInvalidBackup, err)
+	}
+	return err
+}
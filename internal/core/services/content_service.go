@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+)
+
+// contentService revisa la integridad de los códigos MUAC de tags y recomendaciones
+type contentService struct {
+	tagRepo            ports.ITagRepository
+	recommendationRepo ports.IRecommendationRepository
+}
+
+// NewContentService crea una nueva instancia de ContentService
+func NewContentService(tagRepo ports.ITagRepository, recommendationRepo ports.IRecommendationRepository) ports.IContentService {
+	return &contentService{
+		tagRepo:            tagRepo,
+		recommendationRepo: recommendationRepo,
+	}
+}
+
+// GetUnmapped obtiene los tags y recomendaciones activos sin código MUAC
+func (s *contentService) GetUnmapped(ctx context.Context) (*domain.UnmappedContent, error) {
+	tags, err := s.tagRepo.GetUnmapped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recommendations, err := s.recommendationRepo.GetUnmapped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.UnmappedContent{
+		Total:           len(tags) + len(recommendations),
+		Tags:            tags,
+		Recommendations: recommendations,
+	}, nil
+}
+
+// RemapMuacCodes infiere el código MUAC del contenido sin mapear a partir de su nombre (las mismas
+// reglas que la reparación al iniciar) y lo guarda; con dryRun solo informa qué cambiaría
+func (s *contentService) RemapMuacCodes(ctx context.Context, dryRun bool) (*domain.ContentRemap, error) {
+	unmapped, err := s.GetUnmapped(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	remap := &domain.ContentRemap{DryRun: dryRun, Items: make([]domain.ContentRemapItem, 0, unmapped.Total)}
+
+	for _, tag := range unmapped.Tags {
+		item := domain.ContentRemapItem{Type: domain.ContentTypeTag, ID: tag.ID, Name: tag.Name}
+		mapping, ok := domain.InferTagMuacMapping(tag.Name)
+		if !ok {
+			remap.Unmatched++
+			remap.Items = append(remap.Items, item)
+			continue
+		}
+		if !dryRun {
+			if err := s.tagRepo.UpdateMuacMapping(ctx, tag.ID, mapping); err != nil {
+				return nil, err
+			}
+		}
+		item.MuacCode = mapping.MuacCode
+		remap.Tags++
+		remap.Items = append(remap.Items, item)
+	}
+
+	for _, recommendation := range unmapped.Recommendations {
+		item := domain.ContentRemapItem{Type: domain.ContentTypeRecommendation, ID: recommendation.ID, Name: recommendation.Name}
+		mapping, ok := domain.InferRecommendationMuacMapping(recommendation.Name)
+		if !ok {
+			remap.Unmatched++
+			remap.Items = append(remap.Items, item)
+			continue
+		}
+		if !dryRun {
+			if err := s.recommendationRepo.UpdateMuacMapping(ctx, recommendation.ID, mapping); err != nil {
+				return nil, err
+			}
+		}
+		item.MuacCode = mapping.MuacCode
+		remap.Recommendations++
+		remap.Items = append(remap.Items, item)
+	}
+
+	return remap, nil
+}
+
+// GetUnusedTags obtiene las etiquetas activas sin mediciones, separando las etiquetas base de MUAC
+func (s *contentService) GetUnusedTags(ctx context.Context) (*domain.UnusedTags, error) {
+	tags, err := s.tagRepo.GetUnused(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return domain.SplitUnusedTags(tags), nil
+}
+
+// DeleteUnusedTags elimina las etiquetas activas sin mediciones, excepto las etiquetas base de MUAC
+func (s *contentService) DeleteUnusedTags(ctx context.Context) (*domain.UnusedTagCleanup, error) {
+	unused, err := s.GetUnusedTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(unused.Tags))
+	for _, tag := range unused.Tags {
+		ids = append(ids, tag.ID)
+	}
+
+	deleted, err := s.tagRepo.DeleteUnused(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.UnusedTagCleanup{
+		Deleted:   deleted,
+		Tags:      unused.Tags,
+		Protected: unused.Protected,
+	}, nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+)
+
+// Dobles en memoria compartidos por las pruebas del paquete. Embeben el puerto para cumplir la interfaz;
+// invocar un método no implementado hace fallar la prueba con un panic
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// fakeUserRepo devuelve los usuarios registrados en memoria
+type fakeUserRepo struct {
+	ports.IUserRepository
+	users map[uuid.UUID]*domain.User
+}
+
+func (f *fakeUserRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	user, ok := f.users[id]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (f *fakeUserRepo) UpdateLocality(ctx context.Context, id uuid.UUID, localityID uuid.UUID) error {
+	user, ok := f.users[id]
+	if !ok {
+		return domain.ErrUserNotFound
+	}
+	user.LocalityID = &localityID
+	return nil
+}
+
+// fakePatientRepo cuenta los pacientes por apoderado, devuelve los registrados y guarda creados y editados
+type fakePatientRepo struct {
+	ports.IPatientRepository
+	counts   map[uuid.UUID]int64
+	patients map[uuid.UUID]*domain.Patient
+	created  []*domain.Patient
+	updated  []*domain.Patient
+}
+
+func (f *fakePatientRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Patient, error) {
+	patient, ok := f.patients[id]
+	if !ok {
+		return nil, domain.ErrPatientNotFound
+	}
+	return patient, nil
+}
+
+func (f *fakePatientRepo) Update(ctx context.Context, patient *domain.Patient) error {
+	f.updated = append(f.updated, patient)
+	return nil
+}
+
+func (f *fakePatientRepo) CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return f.counts[userID], nil
+}
+
+func (f *fakePatientRepo) GetByDNI(ctx context.Context, dni string) (*domain.Patient, error) {
+	return nil, domain.ErrPatientNotFound
+}
+
+func (f *fakePatientRepo) Create(ctx context.Context, patient *domain.Patient) error {
+	f.created = append(f.created, patient)
+	return nil
+}
+
+// fakeLocalityRepo devuelve las localidades registradas en memoria
+type fakeLocalityRepo struct {
+	ports.ILocalityRepository
+	localities map[uuid.UUID]*domain.Locality
+}
+
+func (f *fakeLocalityRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Locality, error) {
+	locality, ok := f.localities[id]
+	if !ok {
+		return nil, domain.ErrLocalityNotFound
+	}
+	return locality, nil
+}
+
+// fakeAuditService guarda las entradas registradas
+type fakeAuditService struct {
+	ports.IAuditService
+	entries []*domain.AuditEntry
+}
+
+func (f *fakeAuditService) Record(ctx context.Context, entry *domain.AuditEntry) {
+	f.entries = append(f.entries, entry)
+}
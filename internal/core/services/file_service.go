@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"mime/multipart"
 	"os"
 	"path/filepath"
@@ -22,10 +23,12 @@ type FileService struct {
 	baseURL      string
 	maxSize      int64
 	allowedTypes map[string]bool
+	charts       ports.IChartRenderer
+	logger       *slog.Logger
 }
 
 // NewFileService crea una nueva instancia del servicio de archivos
-func NewFileService(uploadPath, baseURL string) ports.IFileService {
+func NewFileService(uploadPath, baseURL string, charts ports.IChartRenderer, logger *slog.Logger) ports.IFileService {
 	// Tipos de archivo permitidos
 	allowedTypes := map[string]bool{
 		"image/jpeg":      true,
@@ -41,6 +44,8 @@ func NewFileService(uploadPath, baseURL string) ports.IFileService {
 		baseURL:      baseURL,          // Asegúrate de pasar https://nutriradar.unamad.edu.pe aquí
 		maxSize:      10 * 1024 * 1024, // 10MB máximo
 		allowedTypes: allowedTypes,
+		charts:       charts,
+		logger:       logger,
 	}
 }
 
@@ -175,7 +180,8 @@ func (fs *FileService) DeleteFile(ctx context.Context, fileID string) error {
 	// Eliminar metadata (no fallar si no existe)
 	if err := os.Remove(metadataPath); err != nil && !os.IsNotExist(err) {
 		// Log pero no fallar por metadata
-		fmt.Printf("Warning: no se pudo eliminar metadata %s: %v\n", metadataPath, err)
+		fs.logger.WarnContext(ctx, "no se pudo eliminar la metadata del archivo",
+			"entity_id", fileID, "path", metadataPath, "error", err)
 	}
 
 	return nil
@@ -586,3 +592,340 @@ func (s *FileService) createAllRiskPatientsSheet(f *excelize.File, report *domai
 
 	return nil
 }
+
+// GenerateFAQsReport genera un Excel con las FAQs agrupadas por categoría para imprimir
+func (s *FileService) GenerateFAQsReport(ctx context.Context, groups []*domain.FAQGrouped) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheetName := "Preguntas Frecuentes"
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		return nil, fmt.Errorf("error creando hoja de FAQs: %w", err)
+	}
+	f.SetActiveSheet(index)
+	f.DeleteSheet("Sheet1")
+
+	headers := []string{"Categoría", "N°", "Pregunta", "Respuesta"}
+	for i, header := range headers {
+		cell := fmt.Sprintf("%c1", 'A'+i)
+		f.SetCellValue(sheetName, cell, header)
+	}
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Color: "FFFFFF"},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"4472C4"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+	})
+	f.SetCellStyle(sheetName, "A1", fmt.Sprintf("%c1", 'A'+len(headers)-1), headerStyle)
+
+	categoryStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"D9E1F2"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Vertical: "top", WrapText: true},
+	})
+	textStyle, _ := f.NewStyle(&excelize.Style{
+		Alignment: &excelize.Alignment{Vertical: "top", WrapText: true},
+	})
+
+	row := 2
+	for _, group := range groups {
+		for i, faq := range group.FAQs {
+			f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), group.Category)
+			f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), i+1)
+			f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), faq.Question)
+			f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), faq.Answer)
+			f.SetCellStyle(sheetName, fmt.Sprintf("A%d", row), fmt.Sprintf("A%d", row), categoryStyle)
+			f.SetCellStyle(sheetName, fmt.Sprintf("B%d", row), fmt.Sprintf("D%d", row), textStyle)
+			row++
+		}
+	}
+
+	f.SetColWidth(sheetName, "A", "A", 30)
+	f.SetColWidth(sheetName, "B", "B", 5)
+	f.SetColWidth(sheetName, "C", "C", 45)
+	f.SetColWidth(sheetName, "D", "D", 80)
+	f.SetPanes(sheetName, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"})
+
+	buffer, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("error generando archivo Excel: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// GenerateLocalityRosterReport genera un Excel con los pacientes de la localidad y su último estado,
+// coloreando cada fila según la clasificación como en el reporte de riesgo
+func (s *FileService) GenerateLocalityRosterReport(ctx context.Context, roster *domain.LocalityRoster) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheetName := "Padrón"
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		return nil, fmt.Errorf("error creando hoja de padrón: %w", err)
+	}
+	f.SetActiveSheet(index)
+	f.DeleteSheet("Sheet1")
+
+	titleStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Size: 14},
+	})
+	f.SetCellValue(sheetName, "A1", fmt.Sprintf("Padrón de pacientes - %s", roster.Locality.Name))
+	f.SetCellValue(sheetName, "A2", fmt.Sprintf("Estado al %s", roster.AsOf.Format("2006-01-02")))
+	f.SetCellStyle(sheetName, "A1", "A1", titleStyle)
+
+	headers := []string{"Paciente", "DNI", "Género", "Edad", "Fecha Nacimiento", "Valor MUAC",
+		"Código MUAC", "Nivel Riesgo", "Última Medición", "Días Transcurridos", "Apoderado"}
+	const headerRow = 4
+	for i, header := range headers {
+		f.SetCellValue(sheetName, fmt.Sprintf("%c%d", 'A'+i, headerRow), header)
+	}
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"CCCCCC"}, Pattern: 1},
+	})
+	f.SetCellStyle(sheetName, fmt.Sprintf("A%d", headerRow), fmt.Sprintf("%c%d", 'A'+len(headers)-1, headerRow), headerStyle)
+
+	// Mismos colores de fila que el reporte de pacientes en riesgo
+	criticalRowStyle, _ := f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"FFEBEE"}, Pattern: 1},
+	})
+	moderateRowStyle, _ := f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"FFF8E1"}, Pattern: 1},
+	})
+	normalRowStyle, _ := f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"E8F5E9"}, Pattern: 1},
+	})
+
+	for i, patient := range roster.Patients {
+		row := headerRow + 1 + i
+
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), patient.PatientName)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), patient.DNI)
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), patient.Gender)
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), patient.Age)
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), patient.BirthDate)
+		if patient.MuacValue != nil {
+			f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), *patient.MuacValue)
+		}
+		f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), patient.MuacCode)
+		f.SetCellValue(sheetName, fmt.Sprintf("H%d", row), patient.RiskLevel)
+		if patient.LastMeasuredAt != nil {
+			f.SetCellValue(sheetName, fmt.Sprintf("I%d", row), patient.LastMeasuredAt.Format("2006-01-02 15:04:05"))
+		}
+		if patient.DaysSinceLast != nil {
+			f.SetCellValue(sheetName, fmt.Sprintf("J%d", row), *patient.DaysSinceLast)
+		}
+		f.SetCellValue(sheetName, fmt.Sprintf("K%d", row), patient.CaregiverName)
+
+		switch patient.MuacCode {
+		case domain.MuacCodeRed:
+			f.SetCellStyle(sheetName, fmt.Sprintf("A%d", row), fmt.Sprintf("K%d", row), criticalRowStyle)
+		case domain.MuacCodeYellow:
+			f.SetCellStyle(sheetName, fmt.Sprintf("A%d", row), fmt.Sprintf("K%d", row), moderateRowStyle)
+		case domain.MuacCodeGreen:
+			f.SetCellStyle(sheetName, fmt.Sprintf("A%d", row), fmt.Sprintf("K%d", row), normalRowStyle)
+		}
+	}
+
+	f.SetColWidth(sheetName, "A", "A", 30)
+	f.SetColWidth(sheetName, "B", "J", 15)
+	f.SetColWidth(sheetName, "K", "K", 30)
+	f.SetPanes(sheetName, &excelize.Panes{Freeze: true, YSplit: headerRow, TopLeftCell: fmt.Sprintf("A%d", headerRow+1), ActivePane: "bottomLeft"})
+
+	buffer, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("error generando archivo Excel: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// GenerateSessionRosterReport genera la lista de pacientes de un apoderado para imprimir antes de
+// una jornada de medición, con columnas en blanco para anotar la lectura del día
+func (s *FileService) GenerateSessionRosterReport(ctx context.Context, roster *domain.SessionRoster) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheetName := "Jornada"
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		return nil, fmt.Errorf("error creando hoja de jornada: %w", err)
+	}
+	f.SetActiveSheet(index)
+	f.DeleteSheet("Sheet1")
+
+	titleStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Size: 14},
+	})
+	f.SetCellValue(sheetName, "A1", fmt.Sprintf("Jornada de medición - %s", roster.CaregiverName))
+	f.SetCellValue(sheetName, "A2", fmt.Sprintf("Generado el %s · Fecha de jornada: ____________", roster.GeneratedAt.Format("2006-01-02")))
+	f.SetCellStyle(sheetName, "A1", "A1", titleStyle)
+
+	headers := []string{"Paciente", "DNI", "Edad", "Último MUAC", "Código MUAC", "Última Medición",
+		"Días de Atraso", "Lectura de Hoy (cm)", "Observaciones"}
+	const headerRow = 4
+	for i, header := range headers {
+		f.SetCellValue(sheetName, fmt.Sprintf("%c%d", 'A'+i, headerRow), header)
+	}
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"CCCCCC"}, Pattern: 1},
+	})
+	f.SetCellStyle(sheetName, fmt.Sprintf("A%d", headerRow), fmt.Sprintf("%c%d", 'A'+len(headers)-1, headerRow), headerStyle)
+
+	// Celdas con borde para escribir a mano
+	blankStyle, _ := f.NewStyle(&excelize.Style{
+		Border: []excelize.Border{
+			{Type: "left", Color: "000000", Style: 1},
+			{Type: "top", Color: "000000", Style: 1},
+			{Type: "bottom", Color: "000000", Style: 1},
+			{Type: "right", Color: "000000", Style: 1},
+		},
+	})
+
+	for i, patient := range roster.Patients {
+		row := headerRow + 1 + i
+
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), patient.PatientName)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), patient.DNI)
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), patient.Age)
+		if patient.LastMuacValue != nil {
+			f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), *patient.LastMuacValue)
+		}
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), patient.LastMuacCode)
+		if patient.LastMeasuredAt != nil {
+			f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), patient.LastMeasuredAt.Format("2006-01-02"))
+		}
+		f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), patient.DaysOverdue)
+		f.SetCellStyle(sheetName, fmt.Sprintf("H%d", row), fmt.Sprintf("I%d", row), blankStyle)
+		f.SetRowHeight(sheetName, row, 24)
+	}
+
+	f.SetColWidth(sheetName, "A", "A", 30)
+	f.SetColWidth(sheetName, "B", "G", 14)
+	f.SetColWidth(sheetName, "H", "H", 20)
+	f.SetColWidth(sheetName, "I", "I", 35)
+	f.SetPanes(sheetName, &excelize.Panes{Freeze: true, YSplit: headerRow, TopLeftCell: fmt.Sprintf("A%d", headerRow+1), ActivePane: "bottomLeft"})
+	orientation := "landscape"
+	f.SetPageLayout(sheetName, &excelize.PageLayoutOptions{Orientation: &orientation})
+
+	buffer, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("error generando archivo Excel: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// GenerateMonthlyReport genera el reporte mensual del programa: una hoja con las cifras de cada apoderado
+// y una hoja resumen por localidad, con los mismos estilos de encabezado que los demás reportes
+func (s *FileService) GenerateMonthlyReport(ctx context.Context, report *domain.MonthlyReport) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	period := fmt.Sprintf("%04d-%02d", report.Year, report.Month)
+
+	titleStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Size: 14},
+	})
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"CCCCCC"}, Pattern: 1},
+	})
+	totalStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+	})
+	const headerRow = 4
+
+	// Hoja por apoderado
+	sheetName := "Apoderados"
+	index, err := f.NewSheet(sheetName)
+	if err != nil {
+		return nil, fmt.Errorf("error creando hoja de apoderados: %w", err)
+	}
+	f.SetActiveSheet(index)
+	f.DeleteSheet("Sheet1")
+
+	f.SetCellValue(sheetName, "A1", fmt.Sprintf("Reporte mensual por apoderado - %s", period))
+	f.SetCellValue(sheetName, "A2", fmt.Sprintf("Generado el %s", report.GeneratedAt.Format("2006-01-02 15:04:05")))
+	f.SetCellStyle(sheetName, "A1", "A1", titleStyle)
+
+	headers := []string{"Apoderado", "Localidad", "Mediciones", "Niños Medidos", "Detecciones en Riesgo", "Detecciones en Rojo"}
+	for i, header := range headers {
+		f.SetCellValue(sheetName, fmt.Sprintf("%c%d", 'A'+i, headerRow), header)
+	}
+	f.SetCellStyle(sheetName, fmt.Sprintf("A%d", headerRow), fmt.Sprintf("%c%d", 'A'+len(headers)-1, headerRow), headerStyle)
+
+	for i, caregiver := range report.Caregivers {
+		row := headerRow + 1 + i
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), caregiver.CaregiverName)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), caregiver.LocalityName)
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), caregiver.Measurements)
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), caregiver.Children)
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), caregiver.RiskDetections)
+		f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), caregiver.RedDetections)
+	}
+
+	f.SetColWidth(sheetName, "A", "B", 30)
+	f.SetColWidth(sheetName, "C", "F", 20)
+	f.SetPanes(sheetName, &excelize.Panes{Freeze: true, YSplit: headerRow, TopLeftCell: fmt.Sprintf("A%d", headerRow+1), ActivePane: "bottomLeft"})
+
+	// Hoja resumen por localidad
+	sheetName = "Localidades"
+	if _, err := f.NewSheet(sheetName); err != nil {
+		return nil, fmt.Errorf("error creando hoja de localidades: %w", err)
+	}
+
+	f.SetCellValue(sheetName, "A1", fmt.Sprintf("Resumen por localidad - %s", period))
+	f.SetCellStyle(sheetName, "A1", "A1", titleStyle)
+
+	headers = []string{"Localidad", "Apoderados", "Mediciones", "Niños Medidos", "Detecciones en Riesgo", "Detecciones en Rojo"}
+	for i, header := range headers {
+		f.SetCellValue(sheetName, fmt.Sprintf("%c%d", 'A'+i, headerRow), header)
+	}
+	f.SetCellStyle(sheetName, fmt.Sprintf("A%d", headerRow), fmt.Sprintf("%c%d", 'A'+len(headers)-1, headerRow), headerStyle)
+
+	row := headerRow + 1
+	for _, locality := range report.Localities {
+		name := locality.LocalityName
+		if name == "" {
+			name = "Sin localidad"
+		}
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), name)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), locality.Caregivers)
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), locality.Measurements)
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), locality.Children)
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), locality.RiskDetections)
+		f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), locality.RedDetections)
+		row++
+	}
+
+	f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), "Total")
+	f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), len(report.Caregivers))
+	f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), report.Totals.Measurements)
+	f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), report.Totals.Children)
+	f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), report.Totals.RiskDetections)
+	f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), report.Totals.RedDetections)
+	f.SetCellStyle(sheetName, fmt.Sprintf("A%d", row), fmt.Sprintf("F%d", row), totalStyle)
+
+	f.SetColWidth(sheetName, "A", "A", 30)
+	f.SetColWidth(sheetName, "B", "F", 20)
+
+	buffer, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("error generando archivo Excel: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// GeneratePatientMuacChart dibuja la serie MUAC del paciente como PNG con el generador de gráficos
+// configurado, con las zonas de clasificación coloreadas y líneas en los umbrales
+func (s *FileService) GeneratePatientMuacChart(ctx context.Context, series []domain.SparklinePoint) ([]byte, error) {
+	return s.charts.RenderMuacChart(series)
+}
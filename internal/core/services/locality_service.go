@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
@@ -25,6 +26,9 @@ func (s *localityService) Create(ctx context.Context, locality *domain.Locality)
 	if err := locality.Validate(); err != nil {
 		return err
 	}
+	if _, err := locality.NormalizeCoordinates(); err != nil {
+		return err
+	}
 	return s.localityRepo.Create(ctx, locality)
 }
 
@@ -60,3 +64,68 @@ func (s *localityService) Delete(ctx context.Context, id uuid.UUID) error {
 func (s *localityService) FindNearbyLocalities(ctx context.Context, lat, lng float64, radiusKm float64) ([]domain.Locality, error) {
 	return s.localityRepo.FindNearby(ctx, lat, lng, radiusKm)
 }
+
+// FindNearbyWithRisk obtiene las localidades dentro del radio, de la más cercana a la más lejana,
+// con sus pacientes en riesgo
+func (s *localityService) FindNearbyWithRisk(ctx context.Context, lat, lng float64, radiusKm float64) ([]domain.NearbyLocalityRisk, error) {
+	return s.localityRepo.FindNearbyWithRisk(ctx, lat, lng, radiusKm)
+}
+
+// GetMeasurements obtiene las mediciones de la localidad para la revisión del supervisor
+func (s *localityService) GetMeasurements(ctx context.Context, localityID uuid.UUID, days int, page *domain.Pagination) ([]domain.LocalityMeasurement, error) {
+	if _, err := s.localityRepo.GetByID(ctx, localityID); err != nil {
+		return nil, err
+	}
+	return s.localityRepo.GetMeasurements(ctx, localityID, days, page)
+}
+
+// GetRoster obtiene el padrón de pacientes de la localidad con su estado a la fecha de corte
+func (s *localityService) GetRoster(ctx context.Context, localityID uuid.UUID, asOf time.Time) (*domain.LocalityRoster, error) {
+	locality, err := s.localityRepo.GetByID(ctx, localityID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.localityRepo.GetRoster(ctx, localityID, asOf)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		entries[i].Classify(asOf)
+	}
+
+	return &domain.LocalityRoster{Locality: locality, AsOf: asOf, Patients: entries}, nil
+}
+
+// GetPatientPercentiles ordena a los niños de la localidad por su último MUAC con su rango y
+// percentil, a partir del mismo padrón que la exportación a Excel
+func (s *localityService) GetPatientPercentiles(ctx context.Context, localityID uuid.UUID) (*domain.LocalityPercentileReport, error) {
+	locality, err := s.localityRepo.GetByID(ctx, localityID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entries, err := s.localityRepo.GetRoster(ctx, localityID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewLocalityPercentileReport(locality, entries, now), nil
+}
+
+// SetTarget fija la meta mensual de mediciones de la localidad
+func (s *localityService) SetTarget(ctx context.Context, localityID uuid.UUID, monthlyTarget int, actorID *uuid.UUID) (*domain.LocalityTarget, error) {
+	if _, err := s.localityRepo.GetByID(ctx, localityID); err != nil {
+		return nil, err
+	}
+
+	target, err := domain.NewLocalityTarget(localityID, monthlyTarget, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.localityRepo.SaveTarget(ctx, target); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
@@ -3,8 +3,9 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
 	"time"
 
@@ -18,6 +19,11 @@ type measurementService struct {
 	measurementRepo ports.IMeasurementRepository
 	tagRepo         ports.ITagRepository
 	recommendRepo   ports.IRecommendationRepository
+	patientRepo     ports.IPatientRepository
+	userRepo        ports.IUserRepository
+	auditService    ports.IAuditService
+	riskEvents      ports.IRiskEventBroker
+	logger          *slog.Logger
 }
 
 // NewMeasurementService crea una nueva instancia de MeasurementService
@@ -25,11 +31,21 @@ func NewMeasurementService(
 	measurementRepo ports.IMeasurementRepository,
 	tagRepo ports.ITagRepository,
 	recommendRepo ports.IRecommendationRepository,
+	patientRepo ports.IPatientRepository,
+	userRepo ports.IUserRepository,
+	auditService ports.IAuditService,
+	riskEvents ports.IRiskEventBroker,
+	logger *slog.Logger,
 ) ports.IMeasurementService {
 	return &measurementService{
 		measurementRepo: measurementRepo,
 		tagRepo:         tagRepo,
 		recommendRepo:   recommendRepo,
+		patientRepo:     patientRepo,
+		userRepo:        userRepo,
+		auditService:    auditService,
+		riskEvents:      riskEvents,
+		logger:          logger,
 	}
 }
 
@@ -38,16 +54,118 @@ func (s *measurementService) Create(ctx context.Context, measurement *domain.Mea
 	if err := measurement.Validate(); err != nil {
 		return err
 	}
-	return s.measurementRepo.Create(ctx, measurement)
+	if err := measurement.ApplyMeasuredAt(measurement.CreatedAt, time.Now()); err != nil {
+		return err
+	}
+	if err := s.validatePatient(ctx, measurement.PatientID); err != nil {
+		return err
+	}
+	if err := s.detectImplausibleChange(ctx, measurement); err != nil {
+		return err
+	}
+	if err := s.measurementRepo.Create(ctx, measurement); err != nil {
+		return err
+	}
+	s.publishRisk(ctx, measurement)
+	return nil
+}
+
+// detectImplausibleChange marca la medición para revisión si el cambio respecto a la medición anterior
+// a su fecha es poco probable; así una medición registrada con fecha pasada se compara con la que la
+// precede y no con la más reciente
+func (s *measurementService) detectImplausibleChange(ctx context.Context, measurement *domain.Measurement) error {
+	previous, err := s.measurementRepo.GetLatestBeforeByPatientID(ctx, measurement.PatientID, measurement.CreatedAt)
+	if err != nil && !errors.Is(err, domain.ErrMeasurementNotFound) {
+		return err
+	}
+	measurement.DetectImplausibleChange(previous)
+	return nil
+}
+
+// publishRisk emite el evento de una medición en rojo o amarillo recién creada. Solo consulta
+// el paciente y su apoderado si hay suscriptores; un error se registra sin afectar la creación
+func (s *measurementService) publishRisk(ctx context.Context, measurement *domain.Measurement) {
+	if s.riskEvents == nil || !s.riskEvents.HasSubscribers() {
+		return
+	}
+	if muacCode, _, _ := domain.ClassifyMuacValue(measurement.MuacValue); !domain.IsRiskMuacCode(muacCode) {
+		return
+	}
+
+	patient, err := s.patientRepo.GetByID(ctx, measurement.PatientID)
+	if err != nil {
+		s.logger.WarnContext(ctx, "no se pudo emitir el evento de riesgo", "measurement_id", measurement.ID, "error", err)
+		return
+	}
+	var caregiver *domain.User
+	if patient.UserID != nil {
+		if caregiver, err = s.userRepo.GetByID(ctx, *patient.UserID); err != nil {
+			s.logger.WarnContext(ctx, "no se pudo emitir el evento de riesgo", "measurement_id", measurement.ID, "error", err)
+			return
+		}
+	}
+	s.riskEvents.Publish(domain.NewRiskMeasurementEvent(measurement, patient, caregiver))
+}
+
+// SubscribeRiskStream valida el alcance del usuario y lo suscribe a las mediciones en riesgo
+func (s *measurementService) SubscribeRiskStream(ctx context.Context, actorID uuid.UUID, localityID *uuid.UUID) (<-chan domain.RiskMeasurementEvent, func(), error) {
+	if actorID == uuid.Nil {
+		return nil, nil, domain.ErrRiskStreamForbidden
+	}
+	actor, err := s.userRepo.GetByID(ctx, actorID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil, nil, domain.ErrRiskStreamForbidden
+		}
+		return nil, nil, err
+	}
+
+	filter, err := domain.ScopeRiskStream(actor, localityID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.riskEvents.Subscribe(filter)
+}
+
+// validatePatient verifica que el paciente mantenga el consentimiento y que su edad actual esté dentro del
+// rango admitido
+func (s *measurementService) validatePatient(ctx context.Context, patientID uuid.UUID) error {
+	patient, err := s.patientRepo.GetByID(ctx, patientID)
+	if err != nil {
+		return err
+	}
+	if err := patient.ValidateConsent(); err != nil {
+		return err
+	}
+	if err := patient.ValidateAge(time.Now()); err != nil {
+		return err
+	}
+	if patient.AgeOverride {
+		s.logger.InfoContext(ctx, "auditoría: medición de paciente fuera de rango de edad",
+			"patient_id", patient.ID, "age", patient.Age, "note", patient.AgeOverrideNote)
+	}
+	return nil
 }
 
 // CreateWithAutoAssignment crea una nueva medición con asignación automática de tag y recomendación (ACTUALIZADO)
-func (s *measurementService) CreateWithAutoAssignment(ctx context.Context, muacValue float64, description string, patientID, userID uuid.UUID) (*domain.Measurement, error) {
+func (s *measurementService) CreateWithAutoAssignment(ctx context.Context, muacValue float64, description string, patientID, userID uuid.UUID, measuredAt time.Time, location *domain.MeasurementLocation, tapeBatch string) (*domain.Measurement, error) {
 	// Validar valor MUAC
 	if !domain.IsValidMuacValue(muacValue) {
 		return nil, fmt.Errorf("valor MUAC inválido: %.2f", muacValue)
 	}
 
+	// Validar la hora enviada por el dispositivo antes de tocar tags o recomendaciones
+	now := time.Now()
+	measuredAt, timeAdjusted, err := domain.NormalizeMeasurementTime(measuredAt, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validar edad del paciente
+	if err := s.validatePatient(ctx, patientID); err != nil {
+		return nil, err
+	}
+
 	// Clasificar el valor MUAC
 	muacCode, colorCode, priority := domain.ClassifyMuacValue(muacValue)
 
@@ -72,19 +190,30 @@ func (s *measurementService) CreateWithAutoAssignment(ctx context.Context, muacV
 		UserID:           userID,
 		TagID:            &tag.ID,
 		RecommendationID: &recommendation.ID,
-		CreatedAt:        time.Now(),
-		UpdatedAt:        time.Now(),
+		CreatedAt:        measuredAt,
+		UpdatedAt:        now,
+		ReceivedAt:       &now,
+		TimeAdjusted:     timeAdjusted,
+		TapeBatch:        tapeBatch,
 	}
+	measurement.SetLocation(location)
 
 	// Validar y crear
 	if err := measurement.Validate(); err != nil {
 		return nil, err
 	}
 
+	// Marcar para revisión si el cambio respecto a la medición anterior es poco probable
+	if err := s.detectImplausibleChange(ctx, measurement); err != nil {
+		return nil, err
+	}
+
 	if err := s.measurementRepo.Create(ctx, measurement); err != nil {
 		return nil, err
 	}
 
+	s.publishRisk(ctx, measurement)
+
 	// Cargar relaciones para retornar
 	measurement.Tag = tag
 	measurement.Recommendation = recommendation
@@ -92,6 +221,279 @@ func (s *measurementService) CreateWithAutoAssignment(ctx context.Context, muacV
 	return measurement, nil
 }
 
+// Simulate calcula la clasificación que obtendría el paciente con el valor indicado, sin persistir nada
+func (s *measurementService) Simulate(ctx context.Context, patientID uuid.UUID, muacValue float64) (*domain.MeasurementSimulation, error) {
+	if !domain.IsValidMuacValue(muacValue) {
+		return nil, domain.ErrInvalidMuacValue
+	}
+
+	if _, err := s.patientRepo.GetByID(ctx, patientID); err != nil {
+		return nil, err
+	}
+
+	latest, err := s.measurementRepo.GetLatestByPatientID(ctx, patientID)
+	if err != nil && !errors.Is(err, domain.ErrMeasurementNotFound) {
+		return nil, err
+	}
+
+	simulation := domain.NewMeasurementSimulation(patientID, muacValue, latest)
+	simulation.Tag = s.findMuacTag(ctx, simulation.MuacCode)
+	simulation.Recommendation = s.findMuacRecommendation(ctx, muacValue, simulation.MuacCode)
+
+	return simulation, nil
+}
+
+// GetThresholds obtiene los umbrales con que se clasificó la medición
+func (s *measurementService) GetThresholds(ctx context.Context, id uuid.UUID) (*domain.MeasurementThresholds, error) {
+	measurement, err := s.measurementRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewMeasurementThresholds(measurement), nil
+}
+
+// GetNextStepRecommendation obtiene la recomendación de la clasificación un paso peor que la última
+// medición del paciente, con la misma búsqueda que la auto-asignación y sin persistir nada
+func (s *measurementService) GetNextStepRecommendation(ctx context.Context, patientID uuid.UUID) (*domain.NextStepRecommendation, error) {
+	if _, err := s.patientRepo.GetByID(ctx, patientID); err != nil {
+		return nil, err
+	}
+
+	latest, err := s.measurementRepo.GetLatestByPatientID(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	current := domain.NewPatientStatus(patientID, latest)
+	next := &domain.NextStepRecommendation{PatientID: patientID, Current: current}
+
+	value, nextCode, ok := domain.NextStepMuacValue(current.MuacCode)
+	if !ok {
+		next.AtWorstStatus = true
+		next.NextMuacCode = domain.MuacCodeFollow
+		next.NextRiskLevel = current.Label
+		next.Threshold = domain.GetMuacThresholdInfo(current.MuacCode)
+		next.Recommendation = s.findFollowUpRecommendation(ctx, latest.MuacValue)
+		return next, nil
+	}
+
+	next.NextMuacCode = nextCode
+	next.NextRiskLevel = domain.GetMuacRiskLevel(value)
+	next.Threshold = domain.GetMuacThresholdInfo(nextCode)
+	next.Recommendation = s.findMuacRecommendation(ctx, value, nextCode)
+	return next, nil
+}
+
+// findFollowUpRecommendation busca la recomendación de seguimiento activa; si no hay, la aplicable al valor
+func (s *measurementService) findFollowUpRecommendation(ctx context.Context, muacValue float64) *domain.Recommendation {
+	allRecommendations, err := s.recommendRepo.GetAll(ctx)
+	if err != nil {
+		return nil
+	}
+	for _, rec := range domain.FilterActiveRecommendations(allRecommendations) {
+		if rec.MuacCode == domain.MuacCodeFollow {
+			return rec
+		}
+	}
+	return s.findMuacRecommendation(ctx, muacValue, domain.MuacCodeRed)
+}
+
+// ClassifyBatch clasifica varios valores MUAC sin consultar ni persistir nada
+func (s *measurementService) ClassifyBatch(values []float64) ([]domain.MuacClassification, error) {
+	if len(values) == 0 {
+		return nil, domain.ErrEmptyClassifyBatch
+	}
+	if len(values) > domain.MaxClassifyBatchSize {
+		return nil, domain.ErrClassifyBatchTooLarge
+	}
+	return domain.ClassifyMuacBatch(values), nil
+}
+
+// Flag marca manualmente una medición como posible error de registro
+func (s *measurementService) Flag(ctx context.Context, id uuid.UUID, reason string) (*domain.Measurement, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, domain.ErrEmptyFlagReason
+	}
+
+	measurement, err := s.measurementRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	measurement.Flag(reason, time.Now())
+	if err := s.measurementRepo.Update(ctx, measurement); err != nil {
+		return nil, err
+	}
+	return measurement, nil
+}
+
+// Unflag quita la marca de revisión de una medición
+func (s *measurementService) Unflag(ctx context.Context, id uuid.UUID) (*domain.Measurement, error) {
+	measurement, err := s.measurementRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	measurement.Unflag()
+	if err := s.measurementRepo.Update(ctx, measurement); err != nil {
+		return nil, err
+	}
+	return measurement, nil
+}
+
+// GetUnclassified obtiene las mediciones que quedaron sin tag o sin recomendación
+func (s *measurementService) GetUnclassified(ctx context.Context, page *domain.Pagination) ([]*domain.Measurement, error) {
+	return s.measurementRepo.GetUnclassified(ctx, page)
+}
+
+// GetClassificationMismatches obtiene la página de mediciones cuyo tag o recomendación no coincide con
+// ClassifyMuacValue para su valor (p. ej. tras cambiar umbrales o contenido). La comparación se hace
+// aquí, así que se recorren todas las mediciones clasificadas y se pagina sobre las diferencias
+func (s *measurementService) GetClassificationMismatches(ctx context.Context, localityID *uuid.UUID, page *domain.Pagination) ([]*domain.ClassificationMismatch, error) {
+	rows, err := s.measurementRepo.GetStoredClassifications(ctx, localityID)
+	if err != nil {
+		return nil, err
+	}
+
+	mismatches := make([]*domain.ClassificationMismatch, 0)
+	for _, row := range rows {
+		if mismatch := row.Mismatch(); mismatch != nil {
+			mismatches = append(mismatches, mismatch)
+		}
+	}
+
+	page.Total = int64(len(mismatches))
+	start := page.Offset()
+	if start > len(mismatches) {
+		start = len(mismatches)
+	}
+	end := start + page.PageSize
+	if end > len(mismatches) {
+		end = len(mismatches)
+	}
+	return mismatches[start:end], nil
+}
+
+// BackfillClassification asigna el tag y la recomendación que correspondan según ClassifyMuacValue
+// a las mediciones registradas antes de la auto-asignación. Los tags y recomendaciones que falten se
+// crean en la misma transacción que las correcciones, así que un error no deja registros a medias.
+// En dry run no crea ni modifica registros y cuenta como corregidas las que necesitarían crearlos
+func (s *measurementService) BackfillClassification(ctx context.Context, dryRun bool) (*domain.MeasurementBackfill, error) {
+	measurements, err := s.measurementRepo.GetUnclassified(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	backfill := &domain.MeasurementBackfill{DryRun: dryRun, Pending: int64(len(measurements))}
+	tags := make(map[string]*domain.Tag)
+	recommendations := make(map[float64]*domain.Recommendation)
+	defaults := make(map[string]*domain.Recommendation) // Recomendaciones por defecto a crear, por código MUAC
+	pending := make(map[uuid.UUID]bool)                 // Tags y recomendaciones que todavía no existen
+	fixes := make([]domain.MeasurementClassificationFix, 0, len(measurements))
+
+	for _, m := range measurements {
+		muacCode, colorCode, priority := domain.ClassifyMuacValue(m.MuacValue)
+
+		tag, ok := tags[muacCode]
+		if !ok {
+			if tag = s.findMuacTag(ctx, muacCode); tag == nil {
+				tag = domain.NewMuacTag(s.getMuacTagName(muacCode), s.getMuacTagDescription(muacCode), colorCode, muacCode, priority)
+				pending[tag.ID] = true
+			}
+			tags[muacCode] = tag
+		}
+
+		recommendation, ok := recommendations[m.MuacValue]
+		if !ok {
+			recommendation = s.findMuacRecommendation(ctx, m.MuacValue, muacCode)
+			if recommendation == nil {
+				if recommendation, ok = defaults[muacCode]; !ok {
+					recommendation = newDefaultMuacRecommendation(muacCode)
+					if existing, exists := s.recommendationExists(ctx, recommendation.Name, muacCode); exists {
+						recommendation = existing
+					} else {
+						pending[recommendation.ID] = true
+					}
+					defaults[muacCode] = recommendation
+				}
+			}
+			recommendations[m.MuacValue] = recommendation
+		}
+
+		fix := domain.MeasurementClassificationFix{MeasurementID: m.ID}
+		if m.TagID == nil {
+			fix.TagID = &tag.ID
+			if pending[tag.ID] {
+				fix.NewTag = tag
+			}
+		}
+		if m.RecommendationID == nil {
+			fix.RecommendationID = &recommendation.ID
+			if pending[recommendation.ID] {
+				fix.NewRecommendation = recommendation
+			}
+		}
+		if fix.TagID == nil && fix.RecommendationID == nil {
+			backfill.Skipped++
+			continue
+		}
+		fixes = append(fixes, fix)
+	}
+
+	if dryRun {
+		backfill.Fixed = int64(len(fixes))
+		return backfill, nil
+	}
+
+	backfill.Fixed, err = s.measurementRepo.ApplyClassificationFixes(ctx, fixes)
+	if err != nil {
+		return nil, err
+	}
+	return backfill, nil
+}
+
+// findMuacTag busca el tag activo del código MUAC sin crear ni modificar registros
+func (s *measurementService) findMuacTag(ctx context.Context, muacCode string) *domain.Tag {
+	allTags, err := s.tagRepo.GetAll(ctx)
+	if err != nil {
+		return nil
+	}
+	expectedName := s.getMuacTagName(muacCode)
+	for _, tag := range allTags {
+		if tag.Active && (tag.MuacCode == muacCode || tag.Name == expectedName) {
+			return tag
+		}
+	}
+	for _, tag := range allTags {
+		if tag.Active && s.isTagNameSimilar(tag.Name, expectedName) {
+			return tag
+		}
+	}
+	return nil
+}
+
+// findMuacRecommendation busca la recomendación aplicable sin crear ni modificar registros
+func (s *measurementService) findMuacRecommendation(ctx context.Context, muacValue float64, muacCode string) *domain.Recommendation {
+	allRecommendations, err := s.recommendRepo.GetAll(ctx)
+	if err != nil {
+		return nil
+	}
+	activeRecs := domain.FilterActiveRecommendations(allRecommendations)
+
+	for _, rec := range activeRecs {
+		if rec.MuacCode == muacCode && rec.IsApplicableForMuac(muacValue) {
+			return rec
+		}
+	}
+	for _, rec := range activeRecs {
+		if rec.IsApplicableForMuac(muacValue) {
+			return rec
+		}
+	}
+	return nil
+}
+
 // getOrCreateMuacTag obtiene o crea el tag apropiado para el código MUAC (MÉTODO CORREGIDO)
 func (s *measurementService) getOrCreateMuacTag(ctx context.Context, muacCode, colorCode string, priority int) (*domain.Tag, error) {
 	// PASO 1: Intentar obtener tag existente por código MUAC si el repo lo soporta
@@ -127,7 +529,7 @@ func (s *measurementService) getOrCreateMuacTag(ctx context.Context, muacCode, c
 
 					// Actualizar en la base de datos
 					if updateErr := s.tagRepo.Update(ctx, tag); updateErr != nil {
-						log.Printf("Warning: No se pudo actualizar tag existente: %v", updateErr)
+						s.logger.WarnContext(ctx, "no se pudo actualizar tag existente", "tag_id", tag.ID, "error", updateErr)
 					}
 				}
 				return tag, nil
@@ -145,7 +547,7 @@ func (s *measurementService) getOrCreateMuacTag(ctx context.Context, muacCode, c
 					tag.UpdatedAt = time.Now()
 
 					if updateErr := s.tagRepo.Update(ctx, tag); updateErr != nil {
-						log.Printf("Warning: No se pudo actualizar tag similar: %v", updateErr)
+						s.logger.WarnContext(ctx, "no se pudo actualizar tag similar", "tag_id", tag.ID, "error", updateErr)
 					}
 				}
 				return tag, nil
@@ -162,7 +564,7 @@ func (s *measurementService) getOrCreateMuacTag(ctx context.Context, muacCode, c
 	if err := s.tagRepo.Create(ctx, newTag); err != nil {
 		// Si hay error de duplicado, intentar buscar nuevamente
 		if strings.Contains(err.Error(), "duplicate key") || strings.Contains(err.Error(), "UNIQUE constraint") {
-			log.Printf("Tag duplicado detectado, buscando tag existente para: %s", muacCode)
+			s.logger.DebugContext(ctx, "tag duplicado detectado, buscando tag existente", "muac_code", muacCode)
 
 			// Reintentiar búsqueda por si acaso otro proceso lo creó
 			if allTags, retryErr := s.tagRepo.GetAll(ctx); retryErr == nil {
@@ -237,7 +639,7 @@ func (s *measurementService) getOrCreateMuacRecommendation(ctx context.Context,
 					rec.MuacCode = muacCode
 					rec.UpdatedAt = time.Now()
 					if updateErr := s.recommendRepo.Update(ctx, rec); updateErr != nil {
-						log.Printf("Warning: No se pudo actualizar recomendación: %v", updateErr)
+						s.logger.WarnContext(ctx, "no se pudo actualizar recomendación", "recommendation_id", rec.ID, "error", updateErr)
 					}
 				}
 				return rec, nil
@@ -246,11 +648,35 @@ func (s *measurementService) getOrCreateMuacRecommendation(ctx context.Context,
 	}
 
 	// PASO 3: Si no hay recomendaciones aplicables, crear una por defecto
-	return s.createDefaultRecommendation(ctx, muacCode, muacValue)
+	return s.createDefaultRecommendation(ctx, muacCode)
 }
 
 // createDefaultRecommendation crea una recomendación por defecto completa y contextualizada (MEJORADO)
-func (s *measurementService) createDefaultRecommendation(ctx context.Context, muacCode string, muacValue float64) (*domain.Recommendation, error) {
+func (s *measurementService) createDefaultRecommendation(ctx context.Context, muacCode string) (*domain.Recommendation, error) {
+	recommendation := newDefaultMuacRecommendation(muacCode)
+
+	// Verificar si ya existe una recomendación similar
+	if existingRec, exists := s.recommendationExists(ctx, recommendation.Name, muacCode); exists {
+		return existingRec, nil
+	}
+
+	if err := s.recommendRepo.Create(ctx, recommendation); err != nil {
+		// Si hay error de duplicado, intentar buscar la existente
+		if strings.Contains(err.Error(), "duplicate key") || strings.Contains(err.Error(), "UNIQUE constraint") {
+			s.logger.DebugContext(ctx, "recomendación duplicada detectada, buscando existente", "muac_code", muacCode)
+
+			if existingRec, exists := s.recommendationExists(ctx, recommendation.Name, muacCode); exists {
+				return existingRec, nil
+			}
+		}
+		return nil, fmt.Errorf("error al crear recomendación por defecto: %w", err)
+	}
+
+	return recommendation, nil
+}
+
+// newDefaultMuacRecommendation arma la recomendación por defecto del código MUAC sin guardarla
+func newDefaultMuacRecommendation(muacCode string) *domain.Recommendation {
 	var name, description string
 	var minValue, maxValue *float64
 	var priority int
@@ -344,13 +770,7 @@ func (s *measurementService) createDefaultRecommendation(ctx context.Context, mu
 		colorCode = domain.ColorGray
 	}
 
-	// Verificar si ya existe una recomendación similar
-	if existingRec, exists := s.recommendationExists(ctx, name, muacCode); exists {
-		return existingRec, nil
-	}
-
-	// Crear nueva recomendación
-	recommendation := domain.NewMuacRecommendation(
+	return domain.NewMuacRecommendation(
 		name,
 		description,
 		minValue,
@@ -359,20 +779,6 @@ func (s *measurementService) createDefaultRecommendation(ctx context.Context, mu
 		colorCode,
 		muacCode,
 	)
-
-	if err := s.recommendRepo.Create(ctx, recommendation); err != nil {
-		// Si hay error de duplicado, intentar buscar la existente
-		if strings.Contains(err.Error(), "duplicate key") || strings.Contains(err.Error(), "UNIQUE constraint") {
-			log.Printf("Recomendación duplicada detectada, buscando existente para: %s", muacCode)
-
-			if existingRec, exists := s.recommendationExists(ctx, name, muacCode); exists {
-				return existingRec, nil
-			}
-		}
-		return nil, fmt.Errorf("error al crear recomendación por defecto: %w", err)
-	}
-
-	return recommendation, nil
 }
 
 // ============= MÉTODOS HELPER PRIVADOS =============
@@ -468,6 +874,65 @@ func (s *measurementService) GetByPatientID(ctx context.Context, patientID uuid.
 	return s.measurementRepo.GetByPatientID(ctx, patientID)
 }
 
+// GetByPatientIDs obtiene mediciones de varios pacientes a la vez
+func (s *measurementService) GetByPatientIDs(ctx context.Context, patientIDs []uuid.UUID) ([]*domain.Measurement, error) {
+	if len(patientIDs) == 0 {
+		return []*domain.Measurement{}, nil
+	}
+	if len(patientIDs) > domain.MaxPatientIDsPerQuery {
+		return nil, domain.ErrTooManyPatientIDs
+	}
+	return s.measurementRepo.GetByPatientIDs(ctx, patientIDs)
+}
+
+// Search busca las mediciones por nombre del paciente. Si se identifica al usuario (actorID distinto
+// de uuid.Nil) la búsqueda se limita a su alcance según el rol
+func (s *measurementService) Search(ctx context.Context, actorID uuid.UUID, filter *domain.MeasurementSearchFilter, page *domain.Pagination) ([]domain.MeasurementSearchResult, error) {
+	if actorID != uuid.Nil {
+		actor, err := s.userRepo.GetByID(ctx, actorID)
+		if err != nil {
+			if errors.Is(err, domain.ErrUserNotFound) {
+				return nil, domain.ErrMeasurementSearchForbidden
+			}
+			return nil, err
+		}
+		if err := filter.Scope(actor); err != nil {
+			return nil, err
+		}
+	}
+	return s.measurementRepo.Search(ctx, filter, page)
+}
+
+// GetSyncDelta obtiene las mediciones modificadas y eliminadas desde el cursor del cliente.
+// Las eliminaciones solo se incluyen en la primera página para no repetirlas.
+func (s *measurementService) GetSyncDelta(ctx context.Context, filter *domain.MeasurementSyncFilter, page *domain.Pagination) (*domain.MeasurementSyncDelta, error) {
+	if filter.Until.IsZero() {
+		filter.Until = time.Now()
+	}
+	if !filter.Since.Before(filter.Until) {
+		return nil, domain.ErrInvalidSyncCursor
+	}
+
+	measurements, err := s.measurementRepo.GetChangedSince(ctx, filter, page)
+	if err != nil {
+		return nil, err
+	}
+
+	deletedIDs := []uuid.UUID{}
+	if page.Page == 1 {
+		deletedIDs, err = s.measurementRepo.GetDeletedSince(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &domain.MeasurementSyncDelta{
+		Measurements: measurements,
+		DeletedIDs:   deletedIDs,
+		ServerTime:   filter.Until,
+	}, nil
+}
+
 // GetByUserID obtiene mediciones por ID de usuario
 func (s *measurementService) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Measurement, error) {
 	return s.measurementRepo.GetByUserID(ctx, userID)
@@ -494,16 +959,53 @@ func (s *measurementService) GetAll(ctx context.Context) ([]*domain.Measurement,
 }
 
 // Update actualiza una medición existente
-func (s *measurementService) Update(ctx context.Context, measurement *domain.Measurement) error {
+func (s *measurementService) Update(ctx context.Context, actorID uuid.UUID, measurement *domain.Measurement) error {
+	if err := s.checkModifiable(ctx, actorID, measurement.ID); err != nil {
+		return err
+	}
 	if err := measurement.Validate(); err != nil {
 		return err
 	}
-	return s.measurementRepo.Update(ctx, measurement)
+	if err := s.measurementRepo.Update(ctx, measurement); err != nil {
+		return err
+	}
+	s.auditService.Record(ctx, domain.NewAuditEntry(domain.AuditEntityMeasurement, &measurement.ID, domain.AuditActionUpdate, &actorID,
+		map[string]interface{}{"muac_value": measurement.MuacValue, "description": measurement.Description}))
+	return nil
 }
 
 // Delete elimina una medición por su ID
-func (s *measurementService) Delete(ctx context.Context, id uuid.UUID) error {
-	return s.measurementRepo.Delete(ctx, id)
+func (s *measurementService) Delete(ctx context.Context, actorID uuid.UUID, id uuid.UUID) error {
+	if err := s.checkModifiable(ctx, actorID, id); err != nil {
+		return err
+	}
+	if err := s.measurementRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.auditService.Record(ctx, domain.NewAuditEntry(domain.AuditEntityMeasurement, &id, domain.AuditActionDelete, &actorID, nil))
+	return nil
+}
+
+// checkModifiable valida contra la medición guardada que el usuario pueda modificarla
+func (s *measurementService) checkModifiable(ctx context.Context, actorID, measurementID uuid.UUID) error {
+	if actorID == uuid.Nil {
+		return domain.ErrMeasurementActorRequired
+	}
+
+	actor, err := s.userRepo.GetByID(ctx, actorID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return domain.ErrMeasurementActorRequired
+		}
+		return err
+	}
+
+	stored, err := s.measurementRepo.GetByID(ctx, measurementID)
+	if err != nil {
+		return err
+	}
+
+	return stored.CheckModifiableBy(actor, time.Now())
 }
 
 // AssignTag asigna una etiqueta a una medición
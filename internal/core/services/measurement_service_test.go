@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+)
+
+// fakeMeasurementRepo devuelve el historial y las mediciones sin clasificar, y guarda las creadas y
+// las correcciones aplicadas
+type fakeMeasurementRepo struct {
+	ports.IMeasurementRepository
+	history      []*domain.Measurement
+	unclassified []*domain.Measurement
+	created      []*domain.Measurement
+	applied      []domain.MeasurementClassificationFix
+}
+
+func (f *fakeMeasurementRepo) GetLatestBeforeByPatientID(ctx context.Context, patientID uuid.UUID, before time.Time) (*domain.Measurement, error) {
+	var latest *domain.Measurement
+	for _, m := range f.history {
+		if m.PatientID == patientID && m.CreatedAt.Before(before) && (latest == nil || m.CreatedAt.After(latest.CreatedAt)) {
+			latest = m
+		}
+	}
+	if latest == nil {
+		return nil, domain.ErrMeasurementNotFound
+	}
+	return latest, nil
+}
+
+func (f *fakeMeasurementRepo) Create(ctx context.Context, measurement *domain.Measurement) error {
+	f.created = append(f.created, measurement)
+	return nil
+}
+
+func (f *fakeMeasurementRepo) GetUnclassified(ctx context.Context, page *domain.Pagination) ([]*domain.Measurement, error) {
+	return f.unclassified, nil
+}
+
+func (f *fakeMeasurementRepo) ApplyClassificationFixes(ctx context.Context, fixes []domain.MeasurementClassificationFix) (int64, error) {
+	f.applied = append(f.applied, fixes...)
+	return int64(len(fixes)), nil
+}
+
+// fakeTagRepo y fakeRecommendationRepo solo se consultan: crear fuera de ApplyClassificationFixes
+// hace fallar la prueba con un panic
+type fakeTagRepo struct {
+	ports.ITagRepository
+	tags []*domain.Tag
+}
+
+func (f *fakeTagRepo) GetAll(ctx context.Context) ([]*domain.Tag, error) {
+	return f.tags, nil
+}
+
+type fakeRecommendationRepo struct {
+	ports.IRecommendationRepository
+	recommendations []*domain.Recommendation
+}
+
+func (f *fakeRecommendationRepo) GetAll(ctx context.Context) ([]*domain.Recommendation, error) {
+	return f.recommendations, nil
+}
+
+func TestMeasurementServiceBackfillCreatesMissingTagsInFixes(t *testing.T) {
+	for _, dryRun := range []bool{true, false} {
+		measurements := &fakeMeasurementRepo{unclassified: []*domain.Measurement{
+			{ID: uuid.New(), MuacValue: 11.0},
+			{ID: uuid.New(), MuacValue: 11.2},
+			{ID: uuid.New(), MuacValue: 13.5},
+		}}
+		service := NewMeasurementService(measurements, &fakeTagRepo{}, &fakeRecommendationRepo{}, nil, nil, nil, nil, discardLogger())
+
+		backfill, err := service.BackfillClassification(context.Background(), dryRun)
+		if err != nil {
+			t.Fatalf("BackfillClassification(dry_run=%v): %v", dryRun, err)
+		}
+		if backfill.Fixed != 3 || backfill.Skipped != 0 {
+			t.Errorf("dry_run=%v: corregidas = %d, omitidas = %d; se esperaban 3 y 0", dryRun, backfill.Fixed, backfill.Skipped)
+		}
+		if dryRun {
+			if len(measurements.applied) != 0 {
+				t.Errorf("en dry run no se deben aplicar correcciones, se aplicaron %d", len(measurements.applied))
+			}
+			continue
+		}
+
+		if len(measurements.applied) != 3 {
+			t.Fatalf("correcciones = %d, se esperaban 3", len(measurements.applied))
+		}
+		red, green := measurements.applied[0], measurements.applied[2]
+		if red.NewTag == nil || red.NewRecommendation == nil || green.NewTag == nil {
+			t.Fatal("los tags y recomendaciones que no existen deben crearse junto con las correcciones")
+		}
+		if *red.TagID != red.NewTag.ID || red.NewTag.MuacCode != domain.MuacCodeRed {
+			t.Errorf("tag de la medición roja = %v, se esperaba el tag nuevo %s", *red.TagID, domain.MuacCodeRed)
+		}
+		if measurements.applied[1].NewTag != red.NewTag || measurements.applied[1].NewRecommendation != red.NewRecommendation {
+			t.Error("las mediciones del mismo código deben compartir el tag y la recomendación nuevos")
+		}
+	}
+}
+
+func TestMeasurementServiceCreateComparesWithPrecedingMeasurement(t *testing.T) {
+	now := time.Now()
+	patient := &domain.Patient{ID: uuid.New(), Name: "Ana", Lastname: "Quispe", Age: 2, ConsentGiven: true}
+	measurements := &fakeMeasurementRepo{history: []*domain.Measurement{
+		{ID: uuid.New(), PatientID: patient.ID, MuacValue: 11.0, CreatedAt: now.AddDate(0, 0, -40)},
+		{ID: uuid.New(), PatientID: patient.ID, MuacValue: 14.0, CreatedAt: now.AddDate(0, 0, -5)},
+	}}
+	patients := &fakePatientRepo{patients: map[uuid.UUID]*domain.Patient{patient.ID: patient}}
+	service := NewMeasurementService(measurements, nil, nil, patients, nil, nil, nil, discardLogger())
+
+	// Registrada con fecha pasada: se compara con la de hace 40 días, no con la más reciente
+	backdated := &domain.Measurement{ID: uuid.New(), PatientID: patient.ID, UserID: uuid.New(), MuacValue: 13.5, CreatedAt: now.AddDate(0, 0, -35)}
+	if err := service.Create(context.Background(), backdated); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !backdated.IsFlagged || backdated.FlagPreviousValue == nil || *backdated.FlagPreviousValue != 11.0 {
+		t.Fatalf("marcada = %v, anterior = %v; se esperaba marcar el cambio respecto a 11.0 cm", backdated.IsFlagged, backdated.FlagPreviousValue)
+	}
+
+	current := &domain.Measurement{ID: uuid.New(), PatientID: patient.ID, UserID: uuid.New(), MuacValue: 13.8}
+	if err := service.Create(context.Background(), current); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if current.IsFlagged {
+		t.Errorf("un cambio de 0.2 cm respecto a la última medición no debe marcarse: %s", current.FlagReason)
+	}
+}
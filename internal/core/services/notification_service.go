@@ -2,6 +2,9 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
@@ -11,12 +14,18 @@ import (
 // NotificationService implementa la lógica de negocio para notificaciones
 type notificationService struct {
 	notificationRepo ports.INotificationRepository
+	userRepo         ports.IUserRepository
+	sender           ports.INotificationSender
+	logger           *slog.Logger
 }
 
 // NewNotificationService crea una nueva instancia de NotificationService
-func NewNotificationService(notificationRepo ports.INotificationRepository) ports.INotificationService {
+func NewNotificationService(notificationRepo ports.INotificationRepository, userRepo ports.IUserRepository, sender ports.INotificationSender, logger *slog.Logger) ports.INotificationService {
 	return &notificationService{
 		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		sender:           sender,
+		logger:           logger,
 	}
 }
 
@@ -25,7 +34,24 @@ func (s *notificationService) Create(ctx context.Context, notification *domain.N
 	if err := notification.Validate(); err != nil {
 		return err
 	}
-	return s.notificationRepo.Create(ctx, notification)
+	if notification.RecipientID != nil {
+		if _, err := s.userRepo.GetByID(ctx, *notification.RecipientID); err != nil {
+			return err
+		}
+	}
+	if err := s.notificationRepo.Create(ctx, notification); err != nil {
+		return err
+	}
+
+	// La entrega externa no invalida la creación; su resultado queda registrado
+	if notification.Target != "" {
+		s.deliver(ctx, notification)
+		if err := s.notificationRepo.Update(ctx, notification); err != nil {
+			s.logger.WarnContext(ctx, "no se pudo registrar la entrega de la notificación",
+				"notification_id", notification.ID, "error", err)
+		}
+	}
+	return nil
 }
 
 // GetByID obtiene una notificación por su ID
@@ -50,3 +76,88 @@ func (s *notificationService) Update(ctx context.Context, notification *domain.N
 func (s *notificationService) Delete(ctx context.Context, id uuid.UUID) error {
 	return s.notificationRepo.Delete(ctx, id)
 }
+
+// GetActiveAnnouncements obtiene los avisos que la app debe mostrar ahora
+func (s *notificationService) GetActiveAnnouncements(ctx context.Context) ([]*domain.Notification, error) {
+	return s.notificationRepo.GetActive(ctx, time.Now())
+}
+
+// MarkAllRead marca como leídas los comunicados y las notificaciones dirigidas al usuario, visibles y
+// creadas hasta before (nil = ahora), que no había leído
+func (s *notificationService) MarkAllRead(ctx context.Context, userID uuid.UUID, before *time.Time) (*domain.NotificationsReadResult, error) {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cutoff := now
+	if before != nil && before.Before(now) {
+		cutoff = *before
+	}
+
+	marked, err := s.notificationRepo.MarkAllRead(ctx, userID, cutoff, now)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.NotificationsReadResult{UserID: userID, Before: cutoff, Marked: marked}, nil
+}
+
+// GetUnreadSince obtiene los comunicados y las notificaciones dirigidas al usuario, visibles y creadas
+// después de since, que aún no leyó
+func (s *notificationService) GetUnreadSince(ctx context.Context, userID uuid.UUID, since time.Time) ([]*domain.Notification, error) {
+	return s.notificationRepo.GetUnreadSince(ctx, userID, since)
+}
+
+// Resend reintenta la entrega de una notificación fallida a su destino registrado
+func (s *notificationService) Resend(ctx context.Context, id uuid.UUID) (*domain.Notification, error) {
+	notification, err := s.notificationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := notification.CanResend(); err != nil {
+		return notification, err
+	}
+
+	deliveryErr := s.deliver(ctx, notification)
+	if err := s.notificationRepo.Update(ctx, notification); err != nil {
+		return nil, err
+	}
+
+	if deliveryErr != nil {
+		return notification, fmt.Errorf("%w: %v", domain.ErrNotificationDelivery, deliveryErr)
+	}
+	return notification, nil
+}
+
+// deliver intenta la entrega y registra el resultado en la notificación. Si va dirigida a un usuario
+// que desactivó ese tipo de notificación, la entrega se omite
+func (s *notificationService) deliver(ctx context.Context, notification *domain.Notification) error {
+	if notification.RecipientID != nil {
+		recipient, err := s.userRepo.GetByID(ctx, *notification.RecipientID)
+		if err != nil {
+			notification.RecordDelivery(err)
+			return err
+		}
+		if !recipient.NotificationPreferences.Allows(notification.Kind) {
+			notification.SkipDelivery()
+			s.logger.InfoContext(ctx, "entrega omitida por las preferencias del destinatario",
+				"notification_id", notification.ID, "user_id", recipient.ID, "kind", notification.Kind)
+			return nil
+		}
+	}
+
+	if s.sender == nil {
+		err := fmt.Errorf("no hay un emisor de notificaciones configurado")
+		notification.RecordDelivery(err)
+		return err
+	}
+
+	err := s.sender.Send(ctx, notification)
+	notification.RecordDelivery(err)
+	if err != nil {
+		s.logger.WarnContext(ctx, "falló la entrega de la notificación",
+			"notification_id", notification.ID, "attempt", notification.Attempts, "error", err)
+	}
+	return err
+}
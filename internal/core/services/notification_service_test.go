@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+)
+
+// fakeNotificationRepo guarda las notificaciones en memoria
+type fakeNotificationRepo struct {
+	ports.INotificationRepository
+	saved map[uuid.UUID]*domain.Notification
+}
+
+func (f *fakeNotificationRepo) Create(ctx context.Context, notification *domain.Notification) error {
+	f.saved[notification.ID] = notification
+	return nil
+}
+
+func (f *fakeNotificationRepo) Update(ctx context.Context, notification *domain.Notification) error {
+	f.saved[notification.ID] = notification
+	return nil
+}
+
+// fakeSender cuenta los envíos realizados
+type fakeSender struct {
+	sent []*domain.Notification
+}
+
+func (f *fakeSender) Send(ctx context.Context, notification *domain.Notification) error {
+	f.sent = append(f.sent, notification)
+	return nil
+}
+
+func TestNotificationServiceCreateRespectsPreferences(t *testing.T) {
+	previous := domain.AllowedWebhookHosts
+	t.Cleanup(func() { domain.AllowedWebhookHosts = previous })
+	domain.SetAllowedWebhookHosts([]string{"hooks.example.org"})
+
+	tests := []struct {
+		name        string
+		preferences domain.NotificationPreferences
+		kind        string
+		wantSent    bool
+		wantStatus  string
+	}{
+		{"alerta roja habilitada", domain.DefaultNotificationPreferences(), domain.NotificationKindRedAlert, true, domain.DeliveryStatusSent},
+		{"alerta roja desactivada", domain.NotificationPreferences{Reminders: true, Broadcasts: true}, domain.NotificationKindRedAlert, false, domain.DeliveryStatusSkipped},
+		{"recordatorio desactivado", domain.NotificationPreferences{RedAlerts: true, Broadcasts: true}, domain.NotificationKindReminder, false, domain.DeliveryStatusSkipped},
+		{"otro tipo desactivado no afecta", domain.NotificationPreferences{RedAlerts: true, Broadcasts: true}, domain.NotificationKindBroadcast, true, domain.DeliveryStatusSent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recipient := &domain.User{ID: uuid.New(), NotificationPreferences: tt.preferences}
+			repo := &fakeNotificationRepo{saved: map[uuid.UUID]*domain.Notification{}}
+			sender := &fakeSender{}
+			service := NewNotificationService(repo, &fakeUserRepo{users: map[uuid.UUID]*domain.User{recipient.ID: recipient}}, sender, discardLogger())
+
+			notification := domain.NewNotification("Paciente en rojo", "Revisar hoy", false)
+			notification.SetTarget("https://hooks.example.org/muac")
+			notification.SetRecipient(&recipient.ID, tt.kind)
+
+			if err := service.Create(context.Background(), notification); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if sent := len(sender.sent) > 0; sent != tt.wantSent {
+				t.Fatalf("enviada = %v, se esperaba %v", sent, tt.wantSent)
+			}
+			stored := repo.saved[notification.ID]
+			if stored.DeliveryStatus != tt.wantStatus {
+				t.Errorf("delivery_status = %q, se esperaba %q", stored.DeliveryStatus, tt.wantStatus)
+			}
+			if !tt.wantSent && stored.Attempts != 0 {
+				t.Errorf("attempts = %d, una entrega omitida no cuenta como intento", stored.Attempts)
+			}
+		})
+	}
+}
+
+func TestNotificationServiceCreateWithoutRecipientIgnoresPreferences(t *testing.T) {
+	previous := domain.AllowedWebhookHosts
+	t.Cleanup(func() { domain.AllowedWebhookHosts = previous })
+	domain.SetAllowedWebhookHosts([]string{"hooks.example.org"})
+
+	repo := &fakeNotificationRepo{saved: map[uuid.UUID]*domain.Notification{}}
+	sender := &fakeSender{}
+	service := NewNotificationService(repo, &fakeUserRepo{}, sender, discardLogger())
+
+	notification := domain.NewNotification("Comunicado", "Mantenimiento programado", true)
+	notification.SetTarget("https://hooks.example.org/muac")
+
+	if err := service.Create(context.Background(), notification); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if len(sender.sent) != 1 {
+		t.Fatalf("envíos = %d, se esperaba 1", len(sender.sent))
+	}
+}
+
+func TestNotificationServiceCreateUnknownRecipient(t *testing.T) {
+	repo := &fakeNotificationRepo{saved: map[uuid.UUID]*domain.Notification{}}
+	service := NewNotificationService(repo, &fakeUserRepo{}, &fakeSender{}, discardLogger())
+
+	recipientID := uuid.New()
+	notification := domain.NewNotification("Recordatorio", "Control pendiente", false)
+	notification.SetRecipient(&recipientID, domain.NotificationKindReminder)
+
+	if err := service.Create(context.Background(), notification); err != domain.ErrUserNotFound {
+		t.Fatalf("err = %v, se esperaba ErrUserNotFound", err)
+	}
+	if len(repo.saved) != 0 {
+		t.Fatal("se guardó una notificación con un destinatario inexistente")
+	}
+}
@@ -2,7 +2,10 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
@@ -13,22 +16,28 @@ import (
 type patientService struct {
 	patientRepo     ports.IPatientRepository
 	measurementRepo ports.IMeasurementRepository
+	userRepo        ports.IUserRepository
 	tipService      ports.ITipService
 	recipeService   ports.IRecipeService
+	auditService    ports.IAuditService
 }
 
 // NewPatientService crea una nueva instancia de PatientService
 func NewPatientService(
 	patientRepo ports.IPatientRepository,
 	measurementRepo ports.IMeasurementRepository,
+	userRepo ports.IUserRepository,
 	tipService ports.ITipService,
 	recipeService ports.IRecipeService,
+	auditService ports.IAuditService,
 ) ports.IPatientService {
 	return &patientService{
 		patientRepo:     patientRepo,
 		measurementRepo: measurementRepo,
+		userRepo:        userRepo,
 		tipService:      tipService,
 		recipeService:   recipeService,
+		auditService:    auditService,
 	}
 }
 
@@ -37,9 +46,26 @@ func (s *patientService) Create(ctx context.Context, patient *domain.Patient) er
 	if err := patient.Validate(); err != nil {
 		return err
 	}
+	if err := patient.ValidateAge(time.Now()); err != nil {
+		return err
+	}
 	//validar que no se repita el dni con otro registro
 	_, err := s.patientRepo.GetByDNI(ctx, patient.DNI)
 	if err != nil {
+		assignedRole := ""
+		if patient.UserID != nil {
+			if err := s.checkCaseload(ctx, *patient.UserID); err != nil {
+				return err
+			}
+			if domain.PatientApprovalRequired {
+				user, err := s.userRepo.GetByID(ctx, *patient.UserID)
+				if err != nil {
+					return err
+				}
+				assignedRole = user.Role.Name
+			}
+		}
+		patient.InitApproval(assignedRole)
 		return s.patientRepo.Create(ctx, patient)
 	}
 	return domain.ErrPatientDNIAlreadyExists
@@ -77,14 +103,301 @@ func (s *patientService) GetAll(ctx context.Context) ([]*domain.Patient, error)
 	return s.patientRepo.GetAll(ctx)
 }
 
-// Update actualiza un paciente existente
+// GetAllWithRelations obtiene todos los pacientes con las relaciones solicitadas
+func (s *patientService) GetAllWithRelations(ctx context.Context, expand domain.PatientExpand) ([]*domain.Patient, error) {
+	patients, err := s.patientRepo.GetAllWithRelations(ctx, expand)
+	if err != nil {
+		return nil, err
+	}
+
+	if expand.LatestMeasurement && len(patients) > 0 {
+		ids := make([]uuid.UUID, len(patients))
+		for i, p := range patients {
+			ids[i] = p.ID
+		}
+
+		latest, err := s.measurementRepo.GetLatestByPatientIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range patients {
+			p.LatestMeasurement = latest[p.ID]
+		}
+	}
+
+	return patients, nil
+}
+
+// Update actualiza un paciente existente. El rango de edad solo se vuelve a validar si cambia la fecha
+// de nacimiento, para que un niño que ya lo superó siga siendo editable
 func (s *patientService) Update(ctx context.Context, patient *domain.Patient) error {
 	if err := patient.Validate(); err != nil {
 		return err
 	}
+
+	existing, err := s.patientRepo.GetByID(ctx, patient.ID)
+	if err != nil {
+		return err
+	}
+	if patient.AgeCheckRequired(existing) {
+		if err := patient.ValidateAge(time.Now()); err != nil {
+			return err
+		}
+	}
+
+	// Al reasignar a otro apoderado se valida la carga del nuevo
+	if domain.MaxPatientsPerCaregiver > 0 && patient.UserID != nil {
+		if existing.UserID == nil || *existing.UserID != *patient.UserID {
+			if err := s.checkCaseload(ctx, *patient.UserID); err != nil {
+				return err
+			}
+		}
+	}
 	return s.patientRepo.Update(ctx, patient)
 }
 
+// UpdateConsent registra el retiro o un nuevo consentimiento del paciente y devuelve el paciente actualizado
+func (s *patientService) UpdateConsent(ctx context.Context, patientID uuid.UUID, given bool, reason string, actorID *uuid.UUID) (*domain.Patient, error) {
+	patient, err := s.patientRepo.GetByID(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	if err := patient.SetConsent(given, reason, actorID, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.patientRepo.UpdateConsent(ctx, patient); err != nil {
+		return nil, err
+	}
+	s.auditService.Record(ctx, domain.NewAuditEntry(domain.AuditEntityPatient, &patient.ID, domain.AuditActionConsent, actorID,
+		map[string]interface{}{"consent_given": patient.ConsentGiven, "reason": patient.ConsentReason}))
+	return patient, nil
+}
+
+// Recompute vuelve a derivar la edad desde la fecha de nacimiento y carga la última medición del
+// paciente. Es idempotente: solo escribe si la edad cambió, pero siempre deja registro en la auditoría
+func (s *patientService) Recompute(ctx context.Context, patientID uuid.UUID, actorID *uuid.UUID) (*domain.Patient, error) {
+	patient, err := s.patientRepo.GetByID(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	previousAge, err := patient.RecomputeAge(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if patient.Age != previousAge {
+		if err := s.patientRepo.UpdateAge(ctx, patient); err != nil {
+			return nil, err
+		}
+	}
+
+	latest, err := s.measurementRepo.GetLatestByPatientID(ctx, patientID)
+	if err != nil && !errors.Is(err, domain.ErrMeasurementNotFound) {
+		return nil, err
+	}
+	patient.LatestMeasurement = latest
+
+	s.auditService.Record(ctx, domain.NewAuditEntry(domain.AuditEntityPatient, &patient.ID, domain.AuditActionRecompute, actorID,
+		map[string]interface{}{"birth_date": patient.BirthDate, "previous_age": previousAge, "age": patient.Age}))
+	return patient, nil
+}
+
+// UpdateApproval aprueba o rechaza al paciente; solo supervisores y administradores
+func (s *patientService) UpdateApproval(ctx context.Context, patientID uuid.UUID, status, note string, actorID uuid.UUID) (*domain.Patient, error) {
+	if actorID == uuid.Nil {
+		return nil, domain.ErrApprovalForbidden
+	}
+	actor, err := s.userRepo.GetByID(ctx, actorID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil, domain.ErrApprovalForbidden
+		}
+		return nil, err
+	}
+	if actor.Role.Name != "SUPERVISOR" && actor.Role.Name != "ADMINISTRADOR" {
+		return nil, domain.ErrApprovalForbidden
+	}
+
+	patient, err := s.patientRepo.GetByID(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	previous := patient.ApprovalStatus
+	if err := patient.SetApproval(status, note, &actorID, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.patientRepo.UpdateApproval(ctx, patient); err != nil {
+		return nil, err
+	}
+	s.auditService.Record(ctx, domain.NewAuditEntry(domain.AuditEntityPatient, &patient.ID, domain.AuditActionApproval, &actorID,
+		map[string]interface{}{"from": previous, "to": patient.ApprovalStatus, "note": patient.ApprovalNote}))
+	return patient, nil
+}
+
+// AuthorizeAgeOverride verifica que el usuario pueda registrar una excepción al rango de edad;
+// solo los administradores
+func (s *patientService) AuthorizeAgeOverride(ctx context.Context, actorID uuid.UUID) error {
+	if actorID == uuid.Nil {
+		return domain.ErrAgeOverrideForbidden
+	}
+	actor, err := s.userRepo.GetByID(ctx, actorID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return domain.ErrAgeOverrideForbidden
+		}
+		return err
+	}
+	if actor.Role.Name != "ADMINISTRADOR" {
+		return domain.ErrAgeOverrideForbidden
+	}
+	return nil
+}
+
+// RecordAgeOverride registra en la auditoría la excepción de edad guardada en el paciente
+func (s *patientService) RecordAgeOverride(ctx context.Context, patient *domain.Patient, actorID uuid.UUID) {
+	s.auditService.Record(ctx, domain.NewAuditEntry(domain.AuditEntityPatient, &patient.ID, domain.AuditActionAgeOverride, &actorID,
+		map[string]interface{}{"enabled": patient.AgeOverride, "note": patient.AgeOverrideNote, "age": patient.Age}))
+}
+
+// GetCaseload obtiene la cantidad de pacientes del apoderado frente al límite configurado
+func (s *patientService) GetCaseload(ctx context.Context, userID uuid.UUID) (*domain.Caseload, error) {
+	count, err := s.patientRepo.CountByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewCaseload(userID, count), nil
+}
+
+// GetFollowupTasks obtiene los controles vencidos y los pacientes sin medir asignados al apoderado
+func (s *patientService) GetFollowupTasks(ctx context.Context, userID uuid.UUID, now time.Time) ([]domain.CaregiverTask, error) {
+	patients, latest, err := s.getAssignedWithLatest(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := []domain.CaregiverTask{}
+	for _, patient := range patients {
+		if task := domain.NewPatientTask(patient, latest[patient.ID], now); task != nil {
+			tasks = append(tasks, *task)
+		}
+	}
+	return tasks, nil
+}
+
+// GetSessionRoster obtiene los pacientes asignados al apoderado con su última medición,
+// ordenados de más a menos atrasados para la jornada de medición
+func (s *patientService) GetSessionRoster(ctx context.Context, userID uuid.UUID, now time.Time) ([]domain.SessionRosterEntry, error) {
+	patients, latest, err := s.getAssignedWithLatest(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]domain.SessionRosterEntry, 0, len(patients))
+	for _, patient := range patients {
+		entries = append(entries, domain.NewSessionRosterEntry(patient, latest[patient.ID], now))
+	}
+	domain.SortSessionRoster(entries)
+	return entries, nil
+}
+
+// GetOrphaned obtiene los pacientes sin apoderado activo, para reasignarlos
+func (s *patientService) GetOrphaned(ctx context.Context, localityID *uuid.UUID, page *domain.Pagination) ([]domain.OrphanedPatient, error) {
+	return s.patientRepo.GetOrphaned(ctx, localityID, page)
+}
+
+// GetDuplicateDNIs obtiene los grupos de pacientes que comparten DNI, para revisarlos o fusionarlos
+func (s *patientService) GetDuplicateDNIs(ctx context.Context) ([]domain.DuplicateDNIGroup, error) {
+	return s.patientRepo.GetDuplicateDNIs(ctx)
+}
+
+// CheckDNI valida el formato del DNI y, si es válido, si ya hay un paciente registrado con él
+func (s *patientService) CheckDNI(ctx context.Context, dni string) (*domain.DNICheck, error) {
+	check := &domain.DNICheck{DNI: domain.NormalizeDNI(dni)}
+	if err := domain.ValidateDNI(check.DNI); err != nil {
+		check.Reason = err.Error()
+		return check, nil
+	}
+	check.Valid = true
+
+	exists, err := s.patientRepo.ExistsByDNI(ctx, check.DNI)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		check.Reason = domain.ErrPatientDNIAlreadyExists.Error()
+		return check, nil
+	}
+	check.Available = true
+	return check, nil
+}
+
+// ValidatePatientImport valida las filas de un archivo de importación sin guardar nada
+func (s *patientService) ValidatePatientImport(ctx context.Context, rows []domain.PatientImportRow) (*domain.PatientImportValidation, error) {
+	dnis := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if dni := domain.NormalizeDNI(row.DNI); dni != "" {
+			dnis = append(dnis, dni)
+		}
+	}
+
+	existing, err := s.patientRepo.GetExistingDNIs(ctx, dnis)
+	if err != nil {
+		return nil, err
+	}
+	return domain.ValidatePatientImport(rows, existing), nil
+}
+
+// ExportPatients recorre los pacientes visibles para el usuario (según su rol) llamando a fn por cada fila
+func (s *patientService) ExportPatients(ctx context.Context, actorID uuid.UUID, filters *domain.ReportFilters, fn func(domain.PatientExportRow) error) error {
+	if actorID == uuid.Nil {
+		return domain.ErrPatientExportForbidden
+	}
+	actor, err := s.userRepo.GetByID(ctx, actorID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return domain.ErrPatientExportForbidden
+		}
+		return err
+	}
+	if err := domain.ScopePatientExport(actor, filters); err != nil {
+		return err
+	}
+	return s.patientRepo.StreamExport(ctx, filters, fn)
+}
+
+// getAssignedWithLatest obtiene los pacientes asignados al apoderado y la última medición de cada uno
+func (s *patientService) getAssignedWithLatest(ctx context.Context, userID uuid.UUID) ([]*domain.Patient, map[uuid.UUID]*domain.Measurement, error) {
+	patients, err := s.patientRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(patients) == 0 {
+		return patients, map[uuid.UUID]*domain.Measurement{}, nil
+	}
+
+	patientIDs := make([]uuid.UUID, len(patients))
+	for i, patient := range patients {
+		patientIDs[i] = patient.ID
+	}
+	latest, err := s.measurementRepo.GetLatestByPatientIDs(ctx, patientIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return patients, latest, nil
+}
+
+// checkCaseload rechaza la asignación si el apoderado está en su límite y el modo es block
+func (s *patientService) checkCaseload(ctx context.Context, userID uuid.UUID) error {
+	if domain.MaxPatientsPerCaregiver == 0 {
+		return nil
+	}
+	caseload, err := s.GetCaseload(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return caseload.CheckAssignment()
+}
+
 // Delete elimina un paciente por su ID
 func (s *patientService) Delete(ctx context.Context, id uuid.UUID) error {
 	return s.patientRepo.Delete(ctx, id)
@@ -95,6 +408,11 @@ func (s *patientService) GetByFatherID(ctx context.Context, fatherID uuid.UUID)
 	return s.patientRepo.GetByFatherID(ctx, fatherID)
 }
 
+// GetByLocalityID obtiene los pacientes de una localidad, hasta limit registros
+func (s *patientService) GetByLocalityID(ctx context.Context, localityID uuid.UUID, limit int) ([]*domain.Patient, error) {
+	return s.patientRepo.GetByLocalityID(ctx, localityID, limit)
+}
+
 // GetMeasurements obtiene todas las mediciones de un paciente específico
 func (s *patientService) GetMeasurements(ctx context.Context, patientID uuid.UUID) ([]*domain.Measurement, error) {
 	return s.patientRepo.GetMeasurements(ctx, patientID)
@@ -102,11 +420,14 @@ func (s *patientService) GetMeasurements(ctx context.Context, patientID uuid.UUI
 
 // AddMeasurement añade una nueva medición a un paciente
 func (s *patientService) AddMeasurement(ctx context.Context, patientID uuid.UUID, measurement *domain.Measurement) error {
-	// Verificar que el paciente existe
-	_, err := s.patientRepo.GetByID(ctx, patientID)
+	// Verificar que el paciente existe y mantiene el consentimiento
+	patient, err := s.patientRepo.GetByID(ctx, patientID)
 	if err != nil {
 		return err
 	}
+	if err := patient.ValidateConsent(); err != nil {
+		return err
+	}
 
 	// Asignar el ID del paciente a la medición
 	measurement.PatientID = patientID
@@ -133,3 +454,196 @@ func (s *patientService) GetUsersWithRiskPatients(ctx context.Context, filters *
 
 	return users, nil
 }
+
+// GetStatus obtiene el estado actual del paciente según su última medición
+func (s *patientService) GetStatus(ctx context.Context, patientID uuid.UUID) (*domain.PatientStatus, error) {
+	if _, err := s.patientRepo.GetByID(ctx, patientID); err != nil {
+		return nil, err
+	}
+
+	latest, err := s.measurementRepo.GetLatestByPatientID(ctx, patientID)
+	if err != nil {
+		if errors.Is(err, domain.ErrMeasurementNotFound) {
+			return domain.NewPatientStatus(patientID, nil), nil
+		}
+		return nil, err
+	}
+
+	return domain.NewPatientStatus(patientID, latest), nil
+}
+
+// GetRecord arma la ficha del paciente para una referencia con el contacto de su apoderado
+func (s *patientService) GetRecord(ctx context.Context, patientID uuid.UUID) (*domain.PatientRecord, error) {
+	patient, err := s.patientRepo.GetByID(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := s.measurementRepo.GetLatestByPatientID(ctx, patientID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrMeasurementNotFound) {
+			return nil, err
+		}
+		latest = nil
+	}
+
+	// Un paciente sin apoderado (o con el apoderado eliminado) se devuelve sin contacto
+	var caregiver *domain.User
+	if patient.UserID != nil {
+		caregiver, err = s.userRepo.GetByID(ctx, *patient.UserID)
+		if err != nil {
+			if !errors.Is(err, domain.ErrUserNotFound) {
+				return nil, err
+			}
+			caregiver = nil
+		}
+	}
+
+	return domain.NewPatientRecord(patient, latest, caregiver, time.Now()), nil
+}
+
+// GetCompliance calcula si las mediciones del paciente siguen la frecuencia recomendada
+func (s *patientService) GetCompliance(ctx context.Context, patientID uuid.UUID) (*domain.PatientCompliance, error) {
+	if _, err := s.patientRepo.GetByID(ctx, patientID); err != nil {
+		return nil, err
+	}
+
+	measurements, err := s.measurementRepo.GetByPatientID(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewPatientCompliance(patientID, measurements), nil
+}
+
+// GetTriage obtiene pacientes priorizados por severidad y recencia de su última medición
+func (s *patientService) GetTriage(ctx context.Context, filters *domain.ReportFilters, page *domain.Pagination) ([]domain.TriagePatient, error) {
+	patients, err := s.patientRepo.GetTriage(ctx, filters, page)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener triaje de pacientes: %w", err)
+	}
+
+	for i := range patients {
+		patients[i].MuacCode, patients[i].ColorCode, _ = domain.ClassifyMuacValue(patients[i].MuacValue)
+		patients[i].RiskLevel = domain.GetMuacRiskLevel(patients[i].MuacValue)
+	}
+
+	return patients, nil
+}
+
+// GetSparkline obtiene la tendencia compacta de MUAC del paciente reducida a un máximo de puntos
+func (s *patientService) GetSparkline(ctx context.Context, patientID uuid.UUID, points int) ([]domain.SparklinePoint, error) {
+	if _, err := s.patientRepo.GetByID(ctx, patientID); err != nil {
+		return nil, err
+	}
+
+	if points <= 0 {
+		points = domain.DefaultSparklinePoints
+	}
+	if points > domain.MaxSparklinePoints {
+		points = domain.MaxSparklinePoints
+	}
+
+	measurements, err := s.measurementRepo.GetByPatientID(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(measurements, func(i, j int) bool {
+		return measurements[i].CreatedAt.Before(measurements[j].CreatedAt)
+	})
+
+	series := make([]domain.SparklinePoint, 0, len(measurements))
+	for _, m := range measurements {
+		code, _, _ := domain.ClassifyMuacValue(m.MuacValue)
+		series = append(series, domain.SparklinePoint{
+			MuacValue:  m.MuacValue,
+			MuacCode:   code,
+			MeasuredAt: m.CreatedAt,
+		})
+	}
+
+	return downsampleSparkline(series, points), nil
+}
+
+// GetGrowthPlotData obtiene las mediciones del paciente por edad junto a las curvas OMS de referencia
+func (s *patientService) GetGrowthPlotData(ctx context.Context, patientID uuid.UUID) (*domain.GrowthPlotData, error) {
+	patient, err := s.patientRepo.GetByID(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	measurements, err := s.measurementRepo.GetByPatientID(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(measurements, func(i, j int) bool {
+		return measurements[i].CreatedAt.Before(measurements[j].CreatedAt)
+	})
+
+	return domain.NewGrowthPlotData(patient, measurements, domain.WHOMuacReference)
+}
+
+// GetAnthropometryCheck contrasta el peso para la talla del paciente con su última medición MUAC
+func (s *patientService) GetAnthropometryCheck(ctx context.Context, patientID uuid.UUID) (*domain.AnthropometryCheck, error) {
+	patient, err := s.patientRepo.GetByID(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	measurements, err := s.measurementRepo.GetByPatientID(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	var latest *domain.Measurement
+	for _, m := range measurements {
+		if latest == nil || m.CreatedAt.After(latest.CreatedAt) {
+			latest = m
+		}
+	}
+
+	return domain.NewAnthropometryCheck(patient, latest, domain.WHOWeightForHeightReference, time.Now()), nil
+}
+
+// downsampleSparkline reduce la serie a n puntos conservando el primero, el último
+// y los cruces de umbral; el resto se completa con puntos equiespaciados
+func downsampleSparkline(series []domain.SparklinePoint, n int) []domain.SparklinePoint {
+	if len(series) <= n {
+		return series
+	}
+
+	last := len(series) - 1
+	keep := map[int]bool{0: true, last: true}
+	crossings := []int{}
+	for i := 1; i < len(series); i++ {
+		if series[i].MuacCode != series[i-1].MuacCode && i != last {
+			crossings = append(crossings, i)
+		}
+	}
+
+	// Si los cruces superan el límite se toman de forma equiespaciada
+	slots := n - 2
+	if len(crossings) > slots {
+		for k := 0; k < slots; k++ {
+			keep[crossings[k*len(crossings)/slots]] = true
+		}
+	} else {
+		for _, i := range crossings {
+			keep[i] = true
+		}
+		for k := 1; len(keep) < n && k < n-1; k++ {
+			keep[k*last/(n-1)] = true
+		}
+		for i := 1; len(keep) < n && i < last; i++ {
+			keep[i] = true
+		}
+	}
+
+	result := make([]domain.SparklinePoint, 0, n)
+	for i := range series {
+		if keep[i] {
+			result = append(result, series[i])
+		}
+	}
+	return result
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+)
+
+// withCaseloadLimit fija el límite de pacientes por apoderado durante la prueba
+func withCaseloadLimit(t *testing.T, maxPatients int, mode string) {
+	t.Helper()
+	previousMax, previousMode := domain.MaxPatientsPerCaregiver, domain.CaseloadMode
+	t.Cleanup(func() { domain.MaxPatientsPerCaregiver, domain.CaseloadMode = previousMax, previousMode })
+	domain.SetCaseloadLimit(maxPatients, mode)
+}
+
+func TestPatientServiceCheckCaseload(t *testing.T) {
+	caregiverID := uuid.New()
+
+	tests := []struct {
+		name     string
+		limit    int
+		mode     string
+		patients int64
+		wantErr  bool
+	}{
+		{"sin límite", 0, domain.CaseloadModeBlock, 100, false},
+		{"warn bajo el límite", 3, domain.CaseloadModeWarn, 2, false},
+		{"warn sobre el límite", 3, domain.CaseloadModeWarn, 5, false},
+		{"block bajo el límite", 3, domain.CaseloadModeBlock, 2, false},
+		{"block en el límite", 3, domain.CaseloadModeBlock, 3, true},
+		{"block sobre el límite", 3, domain.CaseloadModeBlock, 5, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withCaseloadLimit(t, tt.limit, tt.mode)
+			service := &patientService{patientRepo: &fakePatientRepo{counts: map[uuid.UUID]int64{caregiverID: tt.patients}}}
+
+			err := service.checkCaseload(context.Background(), caregiverID)
+			if tt.wantErr && !errors.Is(err, domain.ErrCaseloadExceeded) {
+				t.Fatalf("err = %v, se esperaba ErrCaseloadExceeded", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("err = %v, se esperaba nil", err)
+			}
+		})
+	}
+}
+
+func TestPatientServiceCreateBlockedByCaseload(t *testing.T) {
+	withCaseloadLimit(t, 2, domain.CaseloadModeBlock)
+
+	caregiverID := uuid.New()
+	repo := &fakePatientRepo{counts: map[uuid.UUID]int64{caregiverID: 2}}
+	service := &patientService{patientRepo: repo}
+
+	patient := &domain.Patient{ID: uuid.New(), Name: "Ana", Lastname: "Quispe", DNI: "12345678", Age: 2, UserID: &caregiverID}
+	if err := service.Create(context.Background(), patient); !errors.Is(err, domain.ErrCaseloadExceeded) {
+		t.Fatalf("err = %v, se esperaba ErrCaseloadExceeded", err)
+	}
+	if len(repo.created) != 0 {
+		t.Fatal("se creó el paciente pese a superar el límite")
+	}
+}
+
+func TestPatientServiceUpdateAgeRange(t *testing.T) {
+	now := time.Now()
+	outgrown := now.AddDate(-6, 0, 0).Format("2006-01-02") // Ya superó los 59 meses
+	inRange := now.AddDate(-2, 0, 0).Format("2006-01-02")
+
+	tests := []struct {
+		name         string
+		birthDate    string
+		newBirthDate string
+		wantErr      error
+	}{
+		{"superó el rango y se edita otro dato", outgrown, outgrown, nil},
+		{"corrige la fecha a una dentro del rango", outgrown, inRange, nil},
+		{"cambia la fecha a una fuera del rango", inRange, outgrown, domain.ErrPatientAgeOutOfRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := &domain.Patient{ID: uuid.New(), Name: "Ana", Lastname: "Quispe", BirthDate: tt.birthDate, Age: 2}
+			repo := &fakePatientRepo{patients: map[uuid.UUID]*domain.Patient{existing.ID: existing}}
+			service := &patientService{patientRepo: repo}
+
+			edited := *existing
+			edited.Description = "vive con la abuela"
+			edited.BirthDate = tt.newBirthDate
+
+			err := service.Update(context.Background(), &edited)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, se esperaba %v", err, tt.wantErr)
+			}
+			if (len(repo.updated) == 1) != (tt.wantErr == nil) {
+				t.Fatalf("se guardaron %d ediciones", len(repo.updated))
+			}
+		})
+	}
+}
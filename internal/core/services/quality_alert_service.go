@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+)
+
+// qualityAlertService compone el feed de calidad de datos a partir de las revisiones existentes
+// (mediciones marcadas, clasificaciones, DNIs duplicados y contenido sin código MUAC)
+type qualityAlertService struct {
+	qualityAlertRepo   ports.IQualityAlertRepository
+	reportService      ports.IReportService
+	measurementService ports.IMeasurementService
+	patientService     ports.IPatientService
+	contentService     ports.IContentService
+}
+
+// NewQualityAlertService crea una nueva instancia de QualityAlertService
+func NewQualityAlertService(
+	qualityAlertRepo ports.IQualityAlertRepository,
+	reportService ports.IReportService,
+	measurementService ports.IMeasurementService,
+	patientService ports.IPatientService,
+	contentService ports.IContentService,
+) ports.IQualityAlertService {
+	return &qualityAlertService{
+		qualityAlertRepo:   qualityAlertRepo,
+		reportService:      reportService,
+		measurementService: measurementService,
+		patientService:     patientService,
+		contentService:     contentService,
+	}
+}
+
+// GetFeed obtiene las alertas vigentes sin reconocer, agrupadas por categoría con hasta samples ejemplos
+func (s *qualityAlertService) GetFeed(ctx context.Context, samples int) (*domain.QualityAlertFeed, error) {
+	if samples <= 0 {
+		samples = domain.DefaultQualityAlertSamples
+	}
+	if samples > domain.MaxQualityAlertSamples {
+		samples = domain.MaxQualityAlertSamples
+	}
+
+	alerts, err := s.collectAlerts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	acknowledged, err := s.qualityAlertRepo.GetAcknowledgedIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewQualityAlertFeed(alerts, acknowledged, samples, time.Now()), nil
+}
+
+// Acknowledge reconoce una alerta vigente para que deje de aparecer en el feed
+func (s *qualityAlertService) Acknowledge(ctx context.Context, alertID, note string, actorID *uuid.UUID) (*domain.QualityAlertAck, error) {
+	ack, err := domain.NewQualityAlertAck(alertID, note, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts, err := s.collectAlerts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, alert := range alerts {
+		if alert.ID == alertID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, domain.ErrQualityAlertNotFound
+	}
+
+	if err := s.qualityAlertRepo.Acknowledge(ctx, ack); err != nil {
+		return nil, err
+	}
+	return ack, nil
+}
+
+// collectAlerts ejecuta cada revisión de calidad y convierte sus registros en alertas
+func (s *qualityAlertService) collectAlerts(ctx context.Context) ([]domain.QualityAlert, error) {
+	alerts := make([]domain.QualityAlert, 0)
+
+	flagged, err := fetchAllPages(func(page *domain.Pagination) ([]domain.FlaggedMeasurement, error) {
+		return s.reportService.GetFlaggedMeasurements(ctx, nil, page)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error al revisar mediciones marcadas: %w", err)
+	}
+	for _, m := range flagged {
+		alerts = append(alerts, domain.FlaggedMeasurementAlert(m))
+	}
+
+	mismatches, err := fetchAllPages(func(page *domain.Pagination) ([]*domain.ClassificationMismatch, error) {
+		return s.measurementService.GetClassificationMismatches(ctx, nil, page)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error al revisar clasificaciones: %w", err)
+	}
+	for _, m := range mismatches {
+		alerts = append(alerts, domain.ClassificationMismatchAlert(m))
+	}
+
+	duplicates, err := s.patientService.GetDuplicateDNIs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error al revisar DNIs duplicados: %w", err)
+	}
+	for _, group := range duplicates {
+		alerts = append(alerts, domain.DuplicateDNIAlert(group))
+	}
+
+	unmapped, err := s.contentService.GetUnmapped(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error al revisar contenido sin código MUAC: %w", err)
+	}
+	for _, tag := range unmapped.Tags {
+		alerts = append(alerts, domain.UnmappedTagAlert(tag))
+	}
+	for _, recommendation := range unmapped.Recommendations {
+		alerts = append(alerts, domain.UnmappedRecommendationAlert(recommendation))
+	}
+
+	return alerts, nil
+}
+
+// fetchAllPages obtiene todos los registros de una consulta paginada: pide la primera página con el
+// tamaño máximo y, si el total es mayor, repite la consulta con todos en una sola página
+func fetchAllPages[T any](fetch func(page *domain.Pagination) ([]T, error)) ([]T, error) {
+	page := domain.NewPagination(1, domain.MaxPageSize)
+	items, err := fetch(page)
+	if err != nil {
+		return nil, err
+	}
+	if page.Total <= int64(len(items)) {
+		return items, nil
+	}
+	return fetch(&domain.Pagination{Page: 1, PageSize: int(page.Total)})
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
@@ -59,4 +60,57 @@ func (s *recommendationService) Update(ctx context.Context, recommendation *doma
 // Delete elimina una recomendación por su ID
 func (s *recommendationService) Delete(ctx context.Context, id uuid.UUID) error {
 	return s.recommendationRepo.Delete(ctx, id)
-}
\ No newline at end of file
+}
+
+// Propagate reasigna la recomendación a las mediciones que caen en su rango MUAC
+func (s *recommendationService) Propagate(ctx context.Context, id uuid.UUID, dryRun bool) (*domain.RecommendationPropagation, error) {
+	recommendation, err := s.recommendationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := recommendation.CanPropagate(); err != nil {
+		return nil, err
+	}
+
+	affected, err := s.recommendationRepo.PropagateToMeasurements(ctx, recommendation, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.RecommendationPropagation{
+		RecommendationID: recommendation.ID,
+		MinValue:         recommendation.MinValue,
+		MaxValue:         recommendation.MaxValue,
+		DryRun:           dryRun,
+		Affected:         affected,
+	}, nil
+}
+
+// Impact calcula qué mediciones que hoy referencian la recomendación dejarían de coincidir
+// con el rango propuesto y a qué recomendación pasarían, sin guardar cambios
+func (s *recommendationService) Impact(ctx context.Context, id uuid.UUID, minValue, maxValue *float64) (*domain.RecommendationImpact, error) {
+	if minValue != nil && maxValue != nil && *minValue > *maxValue {
+		return nil, domain.ErrInvalidMuacRange
+	}
+	if minValue != nil && !domain.IsValidMuacValue(*minValue) {
+		return nil, fmt.Errorf("%w: valor mínimo inválido", domain.ErrInvalidMuacValue)
+	}
+	if maxValue != nil && !domain.IsValidMuacValue(*maxValue) {
+		return nil, fmt.Errorf("%w: valor máximo inválido", domain.ErrInvalidMuacValue)
+	}
+
+	recommendation, err := s.recommendationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := s.recommendationRepo.GetReferencingMeasurements(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	all, err := s.recommendationRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewRecommendationImpact(recommendation, minValue, maxValue, refs, all), nil
+}
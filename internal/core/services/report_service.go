@@ -3,9 +3,16 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
 	"github.com/luispfcanales/api-muac/internal/core/ports"
 )
@@ -13,14 +20,24 @@ import (
 // reportService implementa la lógica de negocio para reportes
 type reportService struct {
 	reportRepo   ports.IReportRepository
+	userRepo     ports.IUserRepository
+	localityRepo ports.ILocalityRepository
 	excelService ports.IFileService
+
+	// Contadores recientes por localidad existente ("" = todas) y approved_only, válidos durante
+	// CountersCacheTTL; las entradas vencidas se descartan al guardar una nueva
+	countersMu    sync.Mutex
+	countersCache map[string]*domain.CountersReport
 }
 
 // NewReportService crea una nueva instancia de ReportService
-func NewReportService(reportRepo ports.IReportRepository, excelService ports.IFileService) ports.IReportService {
+func NewReportService(reportRepo ports.IReportRepository, userRepo ports.IUserRepository, localityRepo ports.ILocalityRepository, excelService ports.IFileService) ports.IReportService {
 	return &reportService{
-		reportRepo:   reportRepo,
-		excelService: excelService,
+		reportRepo:    reportRepo,
+		userRepo:      userRepo,
+		localityRepo:  localityRepo,
+		excelService:  excelService,
+		countersCache: make(map[string]*domain.CountersReport),
 	}
 }
 
@@ -39,6 +56,47 @@ func (s *reportService) GetDashboardReport(ctx context.Context, filters *domain.
 	return report, nil
 }
 
+// GetMyDashboard arma el resumen de inicio del usuario que consulta, con las consultas del dashboard
+// filtradas por sus pacientes asignados. El usuario debe existir y estar activo
+func (s *reportService) GetMyDashboard(ctx context.Context, actorID uuid.UUID) (*domain.CaregiverDashboard, error) {
+	if actorID == uuid.Nil {
+		return nil, domain.ErrDashboardActorRequired
+	}
+	user, err := s.userRepo.GetByID(ctx, actorID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil, domain.ErrDashboardActorRequired
+		}
+		return nil, err
+	}
+	if !user.Active {
+		return nil, domain.ErrDashboardActorRequired
+	}
+
+	filters := &domain.ReportFilters{UserID: &user.ID}
+	dashboard, err := s.reportRepo.GetDashboardData(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar el dashboard del apoderado: %w", err)
+	}
+
+	now := time.Now()
+	week, _ := domain.ComparisonPeriods(domain.TimelineIntervalWeek, now)
+	month, _ := domain.ComparisonPeriods(domain.TimelineIntervalMonth, now)
+	for _, period := range []*domain.PeriodValue{&week, &month} {
+		period.Value, err = s.reportRepo.CountPeriodMetric(ctx, domain.PeriodMetricMeasurements, filters, period.Start, period.End)
+		if err != nil {
+			return nil, fmt.Errorf("error al generar el dashboard del apoderado: %w", err)
+		}
+	}
+
+	overdue, err := s.reportRepo.CountOverdueFollowups(ctx, filters, now)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar el dashboard del apoderado: %w", err)
+	}
+
+	return domain.NewCaregiverDashboard(user, dashboard, week, month, overdue, now), nil
+}
+
 // GetPatientsByLocalityReport obtiene pacientes agrupados por localidad
 func (s *reportService) GetPatientsByLocalityReport(ctx context.Context, filters *domain.ReportFilters) (*domain.PatientsByLocalityReport, error) {
 	if err := s.ValidateFilters(filters); err != nil {
@@ -136,6 +194,797 @@ func (s *reportService) GetUserActivityReport(ctx context.Context, filters *doma
 	return report, nil
 }
 
+// GetRecommendationUsageReport obtiene cuántas mediciones activaron cada recomendación
+func (s *reportService) GetRecommendationUsageReport(ctx context.Context, filters *domain.ReportFilters) (*domain.RecommendationUsageReport, error) {
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+
+	report, err := s.reportRepo.GetRecommendationUsage(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte de uso de recomendaciones: %w", err)
+	}
+
+	report.GeneratedAt = time.Now()
+	return report, nil
+}
+
+// GetRecoveryRateReport obtiene la proporción de niños en riesgo que recuperaron estado normal
+func (s *reportService) GetRecoveryRateReport(ctx context.Context, filters *domain.ReportFilters) (*domain.RecoveryRateReport, error) {
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+
+	report, err := s.reportRepo.GetRecoveryRate(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte de tasa de recuperación: %w", err)
+	}
+
+	if filters != nil {
+		report.Days = filters.Days
+	}
+	report.GeneratedAt = time.Now()
+	return report, nil
+}
+
+// GetPrevalenceReport estima la prevalencia de SAM y MAM entre los niños medidos en la ventana,
+// con intervalos de confianza de Wilson (ver domain.NewPrevalenceEstimate)
+func (s *reportService) GetPrevalenceReport(ctx context.Context, filters *domain.ReportFilters) (*domain.PrevalenceReport, error) {
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+
+	report, err := s.reportRepo.GetPrevalenceCounts(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte de prevalencia: %w", err)
+	}
+
+	report.Severe = domain.NewPrevalenceEstimate(report.Severe.Cases, report.SampleSize)
+	report.Moderate = domain.NewPrevalenceEstimate(report.Moderate.Cases, report.SampleSize)
+	report.SmallSample = report.SampleSize < domain.MinPrevalenceSampleSize
+	report.ConfidenceLevel = domain.PrevalenceConfidenceLevel
+	report.Method = domain.PrevalenceMethod
+
+	if filters != nil {
+		report.Days = filters.Days
+	}
+	report.GeneratedAt = time.Now()
+	return report, nil
+}
+
+// GetSeasonalTrend obtiene la prevalencia de riesgo por mes calendario en los últimos years años.
+// El reporte usa su propia ventana, así que filters.Days no se aplica
+func (s *reportService) GetSeasonalTrend(ctx context.Context, filters *domain.ReportFilters, years int) (*domain.SeasonalTrendReport, error) {
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	from := domain.SeasonalTrendStart(years, now)
+	counts, err := s.reportRepo.GetSeasonalCounts(ctx, filters, from)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte estacional: %w", err)
+	}
+
+	return domain.NewSeasonalTrendReport(counts, years, from, now), nil
+}
+
+// GetAgeDistribution agrupa a los pacientes registrados por meses de edad cumplidos a hoy,
+// calculados a partir de la fecha de nacimiento
+func (s *reportService) GetAgeDistribution(ctx context.Context, filters *domain.ReportFilters) (*domain.AgeDistributionReport, error) {
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+
+	birthDates, err := s.reportRepo.GetPatientBirthDates(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar distribución por edad: %w", err)
+	}
+
+	return domain.NewAgeDistributionReport(birthDates, time.Now()), nil
+}
+
+// GetAgingOut obtiene los pacientes que cumplen 60 meses en los próximos withinDays días con el estado
+// de su última medición, para planificar egresos y derivaciones
+func (s *reportService) GetAgingOut(ctx context.Context, filters *domain.ReportFilters, withinDays int) (*domain.AgingOutReport, error) {
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.reportRepo.GetAgingOutCandidates(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte de egreso por edad: %w", err)
+	}
+
+	return domain.NewAgingOutReport(candidates, withinDays, time.Now()), nil
+}
+
+// GetCountersReport obtiene los contadores de la pantalla de inicio, reutilizando
+// el último cálculo con los mismos filtros mientras no supere CountersCacheTTL. Una
+// localidad inexistente devuelve ErrLocalityNotFound
+func (s *reportService) GetCountersReport(ctx context.Context, filters *domain.ReportFilters) (*domain.CountersReport, error) {
+	key := ""
+	if filters != nil && filters.LocalityID != nil {
+		key = filters.LocalityID.String()
+	}
+	if filters != nil && filters.ApprovedOnly {
+		key += "|approved"
+	}
+
+	s.countersMu.Lock()
+	cached, ok := s.countersCache[key]
+	s.countersMu.Unlock()
+	if ok && time.Since(cached.GeneratedAt) < domain.CountersCacheTTL {
+		return cached, nil
+	}
+
+	// Solo se guardan localidades existentes, para que el caché no crezca con IDs arbitrarios
+	if filters != nil && filters.LocalityID != nil {
+		if _, err := s.localityRepo.GetByID(ctx, *filters.LocalityID); err != nil {
+			return nil, err
+		}
+	}
+
+	report, err := s.reportRepo.GetCounters(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar contadores: %w", err)
+	}
+	report.GeneratedAt = time.Now()
+
+	s.countersMu.Lock()
+	for cachedKey, entry := range s.countersCache {
+		if time.Since(entry.GeneratedAt) >= domain.CountersCacheTTL {
+			delete(s.countersCache, cachedKey)
+		}
+	}
+	s.countersCache[key] = report
+	s.countersMu.Unlock()
+
+	return report, nil
+}
+
+// GetMeasurementsByHour obtiene las mediciones por hora del día (zona horaria del programa),
+// con las 24 horas aunque no tengan mediciones
+func (s *reportService) GetMeasurementsByHour(ctx context.Context, filters *domain.ReportFilters) (*domain.MeasurementsByHourReport, error) {
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+
+	counts, err := s.reportRepo.GetMeasurementCountsByHour(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte de mediciones por hora: %w", err)
+	}
+
+	return domain.NewMeasurementsByHourReport(counts, filters.Days, time.Now()), nil
+}
+
+// GetMeasurementHeatcells agrupa las mediciones en una grilla redondeando las coordenadas a
+// precision decimales; cada celda devuelve el centroide ponderado por cantidad de mediciones
+func (s *reportService) GetMeasurementHeatcells(ctx context.Context, filters *domain.ReportFilters, precision int) (*domain.MeasurementHeatcellsReport, error) {
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+
+	points, err := s.reportRepo.GetMeasurementHeatPoints(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte de celdas de calor: %w", err)
+	}
+
+	type cellKey struct{ lat, lng int64 }
+	type cellAcc struct {
+		latSum, lngSum, severitySum float64
+		count                       int64
+	}
+
+	scale := math.Pow10(precision)
+	cells := make(map[cellKey]*cellAcc)
+	order := make([]cellKey, 0)
+	report := &domain.MeasurementHeatcellsReport{Precision: precision}
+
+	for _, point := range points {
+		lat, errLat := strconv.ParseFloat(strings.TrimSpace(point.Latitude), 64)
+		lng, errLng := strconv.ParseFloat(strings.TrimSpace(point.Longitude), 64)
+		if errLat != nil || errLng != nil {
+			continue // Coordenadas mal registradas en la localidad
+		}
+
+		key := cellKey{int64(math.Floor(lat * scale)), int64(math.Floor(lng * scale))}
+		acc, ok := cells[key]
+		if !ok {
+			acc = &cellAcc{}
+			cells[key] = acc
+			order = append(order, key)
+		}
+		acc.latSum += lat * float64(point.Count)
+		acc.lngSum += lng * float64(point.Count)
+		acc.severitySum += point.SeveritySum
+		acc.count += point.Count
+		report.TotalMeasurements += point.Count
+	}
+
+	report.Cells = make([]domain.HeatCell, 0, len(order))
+	for _, key := range order {
+		acc := cells[key]
+		report.Cells = append(report.Cells, domain.HeatCell{
+			Latitude:        acc.latSum / float64(acc.count),
+			Longitude:       acc.lngSum / float64(acc.count),
+			Count:           acc.count,
+			AverageSeverity: math.Round(acc.severitySum/float64(acc.count)*100) / 100,
+		})
+	}
+
+	if filters != nil {
+		report.Days = filters.Days
+	}
+	report.GeneratedAt = time.Now()
+	return report, nil
+}
+
+// GetUncoveredLocalities obtiene las localidades donde no opera ningún usuario activo
+func (s *reportService) GetUncoveredLocalities(ctx context.Context) ([]domain.UncoveredLocality, error) {
+	localities, err := s.reportRepo.GetUncoveredLocalities(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte de localidades sin cobertura: %w", err)
+	}
+	return localities, nil
+}
+
+// GetFlaggedMeasurements obtiene la cola de mediciones marcadas como posible error de registro
+func (s *reportService) GetFlaggedMeasurements(ctx context.Context, filters *domain.ReportFilters, page *domain.Pagination) ([]domain.FlaggedMeasurement, error) {
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+
+	flagged, err := s.reportRepo.GetFlaggedMeasurements(ctx, filters, page)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte de mediciones marcadas: %w", err)
+	}
+	return flagged, nil
+}
+
+// GetTargetProgress compara las mediciones del mes de cada localidad con su meta mensual; sin
+// locality_id incluye todas las localidades con meta
+func (s *reportService) GetTargetProgress(ctx context.Context, filters *domain.ReportFilters, year, month int) (*domain.TargetProgressReport, error) {
+	now := time.Now()
+	start, end, err := domain.MonthBounds(year, month, now)
+	if err != nil {
+		return nil, err
+	}
+
+	targets, err := s.reportRepo.GetLocalityTargets(ctx, filters.LocalityID)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte de avance de metas: %w", err)
+	}
+	if filters.LocalityID != nil && len(targets) == 0 {
+		return nil, domain.ErrLocalityTargetNotFound
+	}
+
+	_, localities, _, err := s.reportRepo.GetMonthlyStats(ctx, filters, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte de avance de metas: %w", err)
+	}
+
+	return domain.NewTargetProgressReport(year, month, start, end, targets, localities, now), nil
+}
+
+// GetGPSCoverage obtiene la proporción de mediciones con ubicación GPS dentro de la región del programa,
+// en total y por apoderado
+func (s *reportService) GetGPSCoverage(ctx context.Context, filters *domain.ReportFilters) (*domain.GPSCoverageReport, error) {
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+
+	region := domain.ProgramRegion
+	caregivers, err := s.reportRepo.GetGPSCoverageByCaregiver(ctx, filters, region)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte de cobertura GPS: %w", err)
+	}
+
+	return domain.NewGPSCoverageReport(caregivers, filters.Days, region, time.Now()), nil
+}
+
+// GetTapeBatchReport obtiene las mediciones y la distribución de clasificación por lote de cinta,
+// señalando los lotes cuyo MUAC promedio se aleja del resto
+func (s *reportService) GetTapeBatchReport(ctx context.Context, filters *domain.ReportFilters) (*domain.TapeBatchReport, error) {
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+
+	batches, err := s.reportRepo.GetTapeBatchStats(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte por lote de cinta: %w", err)
+	}
+
+	return domain.NewTapeBatchReport(batches, filters.Days, time.Now()), nil
+}
+
+// GetActiveLocalities obtiene las localidades con al menos una medición en [from, to), con sus
+// coordenadas, para mostrar en el mapa solo las activas del periodo
+func (s *reportService) GetActiveLocalities(ctx context.Context, filters *domain.ReportFilters, from, to time.Time) (*domain.ActiveLocalitiesReport, error) {
+	if !from.Before(to) {
+		return nil, domain.ErrInvalidReportRange
+	}
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+
+	localities, err := s.reportRepo.GetActiveLocalities(ctx, filters, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte de localidades activas: %w", err)
+	}
+	if localities == nil {
+		localities = []domain.ActiveLocality{}
+	}
+
+	return &domain.ActiveLocalitiesReport{
+		StartDate:   from,
+		EndDate:     to,
+		Localities:  localities,
+		GeneratedAt: time.Now(),
+	}, nil
+}
+
+// GetOverrideAnalysis obtiene cuántas mediciones llevan una etiqueta asignada a mano distinta de la
+// clasificación automática, la dirección del cambio y los motivos indicados
+func (s *reportService) GetOverrideAnalysis(ctx context.Context, filters *domain.ReportFilters) (*domain.OverrideAnalysisReport, error) {
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+
+	counts, err := s.reportRepo.GetClassificationOverrideCounts(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar análisis de reclasificaciones: %w", err)
+	}
+
+	return domain.NewOverrideAnalysisReport(counts, filters.Days, time.Now()), nil
+}
+
+// GetLocalitySeverityIndex calcula el índice de severidad ponderado de cada localidad a partir de la
+// distribución de la última medición de sus pacientes
+func (s *reportService) GetLocalitySeverityIndex(ctx context.Context, filters *domain.ReportFilters, weights domain.SeverityIndexWeights) (*domain.LocalitySeverityReport, error) {
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+
+	report, err := s.reportRepo.GetPatientsByLocality(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar índice de severidad por localidad: %w", err)
+	}
+
+	return domain.NewLocalitySeverityReport(report.LocalityData, weights, time.Now()), nil
+}
+
+// GetDataCompleteness obtiene el porcentaje de pacientes con cada campo opcional y de mediciones con
+// descripción y GPS; los filtros de días no aplican, se evalúan todos los registros
+func (s *reportService) GetDataCompleteness(ctx context.Context, filters *domain.ReportFilters) (*domain.DataCompletenessReport, error) {
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+
+	patients, measurements, err := s.reportRepo.GetDataCompleteness(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte de completitud: %w", err)
+	}
+
+	var localityID *uuid.UUID
+	if filters != nil {
+		localityID = filters.LocalityID
+	}
+	return domain.NewDataCompletenessReport(localityID, patients, measurements, time.Now()), nil
+}
+
+// GetCaregiverAccuracy compara las mediciones de los apoderados con las re-mediciones de supervisores
+// dentro de la ventana; sin ventana se usa DefaultAccuracyWindowHours
+func (s *reportService) GetCaregiverAccuracy(ctx context.Context, filters *domain.ReportFilters, windowHours int) (*domain.CaregiverAccuracyReport, error) {
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+	if windowHours <= 0 {
+		windowHours = domain.DefaultAccuracyWindowHours
+	}
+
+	pairs, err := s.reportRepo.GetAccuracyPairs(ctx, filters, time.Duration(windowHours)*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte de precisión de apoderados: %w", err)
+	}
+
+	return domain.NewCaregiverAccuracyReport(pairs, windowHours, filters.Days, time.Now()), nil
+}
+
+// GetReliabilityTrend obtiene por periodo las mediciones del apoderado, la tasa de mediciones marcadas
+// y la precisión frente a las re-mediciones de supervisores, para seguir su evolución
+func (s *reportService) GetReliabilityTrend(ctx context.Context, userID uuid.UUID, filters *domain.ReportFilters, interval string, windowHours int) (*domain.ReliabilityTrendReport, error) {
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+	if windowHours <= 0 {
+		windowHours = domain.DefaultAccuracyWindowHours
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	days := domain.DefaultReliabilityTrendDays
+	if filters != nil && filters.Days > 0 {
+		days = filters.Days
+	}
+	periods, err := s.reportRepo.GetReliabilityPeriods(ctx, userID, interval, now.AddDate(0, 0, -days))
+	if err != nil {
+		return nil, fmt.Errorf("error al generar tendencia de confiabilidad: %w", err)
+	}
+
+	pairs, err := s.reportRepo.GetAccuracyPairs(ctx, &domain.ReportFilters{UserID: &userID, Days: days}, time.Duration(windowHours)*time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar tendencia de confiabilidad: %w", err)
+	}
+
+	return domain.NewReliabilityTrendReport(user, interval, days, windowHours, periods, pairs, now), nil
+}
+
+// GetPendingReview obtiene la cola de revisión del supervisor: pacientes pendientes de aprobación y
+// mediciones marcadas de su localidad, del más antiguo al más reciente; el administrador puede ver todas
+func (s *reportService) GetPendingReview(ctx context.Context, actorID uuid.UUID, localityID *uuid.UUID, limit int) (*domain.PendingReviewReport, error) {
+	if actorID == uuid.Nil {
+		return nil, domain.ErrPendingReviewForbidden
+	}
+	actor, err := s.userRepo.GetByID(ctx, actorID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil, domain.ErrPendingReviewForbidden
+		}
+		return nil, err
+	}
+	scope, err := domain.ScopePendingReview(actor, localityID)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = domain.DefaultPendingReviewLimit
+	}
+	report, err := s.reportRepo.GetPendingReview(ctx, scope, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar la cola de revisión: %w", err)
+	}
+
+	report.LocalityID = scope
+	report.GeneratedAt = time.Now()
+	if report.Patients == nil {
+		report.Patients = []domain.PendingReviewPatient{}
+	}
+	if report.Measurements == nil {
+		report.Measurements = []domain.FlaggedMeasurement{}
+	}
+	return report, nil
+}
+
+// GetMeasurementIntervalsReport calcula los días entre mediciones consecutivas de cada paciente.
+// Los pacientes con una sola medición en la ventana no aportan intervalos y se excluyen.
+func (s *reportService) GetMeasurementIntervalsReport(ctx context.Context, filters *domain.ReportFilters) (*domain.MeasurementIntervalsReport, error) {
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+
+	times, err := s.reportRepo.GetMeasurementTimes(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte de intervalos entre mediciones: %w", err)
+	}
+
+	report := &domain.MeasurementIntervalsReport{}
+	var intervals []float64
+
+	// Las mediciones llegan ordenadas por paciente y fecha
+	for start := 0; start < len(times); {
+		end := start + 1
+		for end < len(times) && times[end].PatientID == times[start].PatientID {
+			intervals = append(intervals, times[end].MeasuredAt.Sub(times[end-1].MeasuredAt).Hours()/24)
+			end++
+		}
+		if end-start < 2 {
+			report.PatientsExcluded++
+		} else {
+			report.PatientsIncluded++
+		}
+		start = end
+	}
+
+	report.Intervals = int64(len(intervals))
+	report.Histogram = buildIntervalHistogram(intervals)
+	report.AverageDays, report.MedianDays, report.MinDays, report.MaxDays = summarizeDays(intervals)
+
+	if filters != nil {
+		report.Days = filters.Days
+	}
+	report.GeneratedAt = time.Now()
+	return report, nil
+}
+
+// GetAlertResponseTimes mide el tiempo entre cada alerta roja y la siguiente medición del paciente;
+// las alertas sin acción posterior se cuentan aparte y no entran en los promedios
+func (s *reportService) GetAlertResponseTimes(ctx context.Context, filters *domain.ReportFilters) (*domain.AlertResponseTimesReport, error) {
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+
+	responses, err := s.reportRepo.GetRedAlertResponses(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte de respuesta a alertas: %w", err)
+	}
+
+	report := &domain.AlertResponseTimesReport{Alerts: int64(len(responses))}
+	var delays []float64
+	for _, response := range responses {
+		if response.RespondedAt == nil {
+			report.Unanswered++
+			continue
+		}
+		delays = append(delays, response.RespondedAt.Sub(response.AlertAt).Hours()/24)
+	}
+
+	report.Responded = int64(len(delays))
+	report.Histogram = buildIntervalHistogram(delays)
+	report.AverageDays, report.MedianDays, report.MinDays, report.MaxDays = summarizeDays(delays)
+
+	if filters != nil {
+		report.Days = filters.Days
+	}
+	report.GeneratedAt = time.Now()
+	return report, nil
+}
+
+// GetTimeToRecovery mide los días desde la primera medición en rojo o amarillo de cada niño hasta el
+// inicio de su racha final de mediciones verdes. Solo cuentan como recuperados los niños cuya racha
+// tiene al menos SustainedRecoveryMeasurements mediciones; el resto se cuenta aparte
+func (s *reportService) GetTimeToRecovery(ctx context.Context, filters *domain.ReportFilters) (*domain.TimeToRecoveryReport, error) {
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+
+	history, err := s.reportRepo.GetRiskPatientHistories(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte de tiempo de recuperación: %w", err)
+	}
+
+	report := &domain.TimeToRecoveryReport{SustainedMeasurements: domain.SustainedRecoveryMeasurements}
+	var durations []float64
+
+	// Las mediciones llegan ordenadas por paciente y fecha
+	for start := 0; start < len(history); {
+		end := start
+		firstRisk := -1
+		for end < len(history) && history[end].PatientID == history[start].PatientID {
+			if firstRisk < 0 && history[end].MuacValue < domain.MuacThresholdNormal {
+				firstRisk = end
+			}
+			end++
+		}
+
+		// Racha final de mediciones verdes, hasta la última del paciente
+		streak := end
+		for streak > start && history[streak-1].MuacValue >= domain.MuacThresholdNormal {
+			streak--
+		}
+
+		switch {
+		case firstRisk < 0:
+			// Sin mediciones en riesgo dentro del alcance filtrado
+		case streak == end:
+			report.PatientsAtRisk++
+			report.Ongoing++
+		case end-streak < domain.SustainedRecoveryMeasurements:
+			report.PatientsAtRisk++
+			report.Unconfirmed++
+		default:
+			report.PatientsAtRisk++
+			durations = append(durations, history[streak].MeasuredAt.Sub(history[firstRisk].MeasuredAt).Hours()/24)
+		}
+		start = end
+	}
+
+	report.Recovered = int64(len(durations))
+	report.AverageDays, report.MedianDays, report.MinDays, report.MaxDays = summarizeDays(durations)
+	report.SmallSample = report.Recovered < domain.MinRecoverySampleSize
+	report.GeneratedAt = time.Now()
+	return report, nil
+}
+
+// GetPeriodComparison compara la métrica del periodo en curso con el mismo tramo del periodo anterior
+func (s *reportService) GetPeriodComparison(ctx context.Context, filters *domain.ReportFilters, metric, period string) (*domain.PeriodComparisonReport, error) {
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+	if !domain.IsValidPeriodMetric(metric) {
+		return nil, fmt.Errorf("métrica inválida: %s (use measurements o risk)", metric)
+	}
+	if !domain.IsValidTimelineInterval(period) {
+		return nil, fmt.Errorf("periodo inválido: %s (use week o month)", period)
+	}
+
+	now := time.Now()
+	current, previous := domain.ComparisonPeriods(period, now)
+	var err error
+	if current.Value, err = s.reportRepo.CountPeriodMetric(ctx, metric, filters, current.Start, current.End); err != nil {
+		return nil, fmt.Errorf("error al generar comparación de periodos: %w", err)
+	}
+	if previous.Value, err = s.reportRepo.CountPeriodMetric(ctx, metric, filters, previous.Start, previous.End); err != nil {
+		return nil, fmt.Errorf("error al generar comparación de periodos: %w", err)
+	}
+
+	return domain.NewPeriodComparisonReport(metric, period, current, previous, now), nil
+}
+
+// GetMonthlyReport genera el reporte de un mes calendario en la zona horaria del programa
+func (s *reportService) GetMonthlyReport(ctx context.Context, filters *domain.ReportFilters, year, month int) (*domain.MonthlyReport, error) {
+	now := time.Now()
+	start, end, err := domain.MonthBounds(year, month, now)
+	if err != nil {
+		return nil, err
+	}
+
+	caregivers, localities, children, err := s.reportRepo.GetMonthlyStats(ctx, filters, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte mensual: %w", err)
+	}
+
+	return domain.NewMonthlyReport(year, month, start, end, caregivers, localities, children, now), nil
+}
+
+// GetCaseloadDistribution obtiene la carga de niños por apoderado activo en cada localidad,
+// ordenada de mayor a menor promedio
+func (s *reportService) GetCaseloadDistribution(ctx context.Context, filters *domain.ReportFilters) (*domain.CaseloadDistributionReport, error) {
+	localities, err := s.reportRepo.GetCaseloadByLocality(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte de carga por apoderado: %w", err)
+	}
+
+	return domain.NewCaseloadDistributionReport(localities, time.Now()), nil
+}
+
+// ExportClassificationTransitions recorre las mediciones de [from, to) que cambiaron de clasificación
+// respecto de la medición anterior del paciente, llamando a fn por cada transición
+func (s *reportService) ExportClassificationTransitions(ctx context.Context, filters *domain.ReportFilters, from, to time.Time, fn func(domain.ClassificationTransition) error) error {
+	if !from.Before(to) {
+		return domain.ErrInvalidReportRange
+	}
+	return s.reportRepo.StreamTransitionMeasurements(ctx, filters, from, to, func(measurement domain.TransitionMeasurement) error {
+		transition, ok := measurement.Transition()
+		if !ok {
+			return nil
+		}
+		return fn(transition)
+	})
+}
+
+// summarizeDays calcula promedio, mediana, mínimo y máximo (redondeados) de una lista de días;
+// ordena la lista recibida
+func summarizeDays(values []float64) (average, median, min, max float64) {
+	if len(values) == 0 {
+		return 0, 0, 0, 0
+	}
+	sort.Float64s(values)
+	var sum float64
+	for _, days := range values {
+		sum += days
+	}
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		median = (values[mid-1] + values[mid]) / 2
+	} else {
+		median = values[mid]
+	}
+	return roundDays(sum / float64(len(values))), roundDays(median), roundDays(values[0]), roundDays(values[len(values)-1])
+}
+
+// buildIntervalHistogram reparte los intervalos (en días) en los tramos de IntervalHistogramBounds
+func buildIntervalHistogram(intervals []float64) []domain.IntervalBucket {
+	buckets := make([]domain.IntervalBucket, 0, len(domain.IntervalHistogramBounds)+1)
+	lower := 0
+	for _, upper := range domain.IntervalHistogramBounds {
+		upper := upper
+		buckets = append(buckets, domain.IntervalBucket{
+			Label:   fmt.Sprintf("%d-%d días", lower, upper),
+			MinDays: lower,
+			MaxDays: &upper,
+		})
+		lower = upper + 1
+	}
+	buckets = append(buckets, domain.IntervalBucket{
+		Label:   fmt.Sprintf("más de %d días", lower-1),
+		MinDays: lower,
+	})
+
+	for _, days := range intervals {
+		whole := int(math.Floor(days))
+		index := len(buckets) - 1
+		for i, upper := range domain.IntervalHistogramBounds {
+			if whole <= upper {
+				index = i
+				break
+			}
+		}
+		buckets[index].Count++
+	}
+	return buckets
+}
+
+// roundDays redondea a un decimal
+func roundDays(days float64) float64 {
+	return math.Round(days*10) / 10
+}
+
+// GetRegistrationsTimelineReport obtiene los nuevos pacientes por semana o mes
+func (s *reportService) GetRegistrationsTimelineReport(ctx context.Context, filters *domain.ReportFilters, interval string) (*domain.RegistrationsTimelineReport, error) {
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+	if !domain.IsValidTimelineInterval(interval) {
+		return nil, fmt.Errorf("intervalo inválido: %s (use week o month)", interval)
+	}
+
+	report, err := s.reportRepo.GetRegistrationsTimeline(ctx, filters, interval)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar reporte de registros de pacientes: %w", err)
+	}
+
+	report.GeneratedAt = time.Now()
+	return report, nil
+}
+
+// GetCaregiverLeaderboard obtiene la posición del usuario entre los apoderados de su localidad.
+// Los pares se muestran solo con nombre abreviado y empatan en la misma posición
+func (s *reportService) GetCaregiverLeaderboard(ctx context.Context, userID uuid.UUID, filters *domain.ReportFilters) (*domain.LeaderboardReport, error) {
+	if err := s.ValidateFilters(filters); err != nil {
+		return nil, err
+	}
+
+	localityName, activity, err := s.reportRepo.GetLocalityCaregiverActivity(ctx, userID, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	size := domain.DefaultLeaderboardSize
+	report := &domain.LeaderboardReport{
+		LocalityName:    localityName,
+		TotalCaregivers: len(activity),
+		Top:             []domain.LeaderboardEntry{},
+		GeneratedAt:     time.Now(),
+	}
+	if filters != nil {
+		report.Days = filters.Days
+		if filters.Limit > 0 {
+			size = filters.Limit
+		}
+	}
+
+	rank := 0
+	for i, a := range activity {
+		// El orden viene de la consulta (mediciones DESC); los empates comparten posición
+		if i == 0 || a.Measurements != activity[i-1].Measurements {
+			rank = i + 1
+		}
+
+		isCurrent := a.UserID == userID
+		if isCurrent {
+			report.Rank = rank
+			report.Measurements = a.Measurements
+		}
+
+		if i < size {
+			report.Top = append(report.Top, domain.LeaderboardEntry{
+				Rank:          rank,
+				DisplayName:   domain.AbbreviateName(a.Name, a.Lastname),
+				Measurements:  a.Measurements,
+				IsCurrentUser: isCurrent,
+			})
+		}
+	}
+
+	return report, nil
+}
+
 // ValidateFilters valida los filtros de entrada
 func (s *reportService) ValidateFilters(filters *domain.ReportFilters) error {
 	if filters == nil {
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+)
+
+// caregiverData son las cifras de los pacientes de un apoderado en fakeReportRepo
+type caregiverData struct {
+	patients     int64
+	atRisk       int64
+	measurements int64
+	overdue      int64
+}
+
+// fakeReportRepo responde con las cifras del apoderado indicado en filters.UserID y con la suma de
+// todos si no hay filtro, registrando cada consulta sin filtro de usuario
+type fakeReportRepo struct {
+	ports.IReportRepository
+	data     map[uuid.UUID]caregiverData
+	unscoped []string
+}
+
+func (f *fakeReportRepo) scoped(filters *domain.ReportFilters, query string) caregiverData {
+	if filters == nil || filters.UserID == nil {
+		f.unscoped = append(f.unscoped, query)
+		var total caregiverData
+		for _, d := range f.data {
+			total.patients += d.patients
+			total.atRisk += d.atRisk
+			total.measurements += d.measurements
+			total.overdue += d.overdue
+		}
+		return total
+	}
+	return f.data[*filters.UserID]
+}
+
+func (f *fakeReportRepo) GetDashboardData(ctx context.Context, filters *domain.ReportFilters) (*domain.DashboardReport, error) {
+	d := f.scoped(filters, "GetDashboardData")
+	return &domain.DashboardReport{
+		TotalPatients:     d.patients,
+		PatientsAtRisk:    d.atRisk,
+		TotalMeasurements: d.measurements,
+		StatusDistribution: domain.StatusDistribution{
+			Severe: domain.StatusCount{Total: d.atRisk},
+			Normal: domain.StatusCount{Total: d.patients - d.atRisk - 1},
+		},
+	}, nil
+}
+
+func (f *fakeReportRepo) CountPeriodMetric(ctx context.Context, metric string, filters *domain.ReportFilters, from, to time.Time) (int64, error) {
+	return f.scoped(filters, "CountPeriodMetric").measurements, nil
+}
+
+func (f *fakeReportRepo) CountOverdueFollowups(ctx context.Context, filters *domain.ReportFilters, now time.Time) (int64, error) {
+	return f.scoped(filters, "CountOverdueFollowups").overdue, nil
+}
+
+func TestReportServiceGetMyDashboardScopedToCaregiver(t *testing.T) {
+	ana := &domain.User{ID: uuid.New(), Name: "Ana", LastName: "Quispe", Active: true}
+	rosa := &domain.User{ID: uuid.New(), Name: "Rosa", LastName: "Huamán", Active: true}
+
+	repo := &fakeReportRepo{data: map[uuid.UUID]caregiverData{
+		ana.ID:  {patients: 5, atRisk: 2, measurements: 9, overdue: 1},
+		rosa.ID: {patients: 40, atRisk: 11, measurements: 120, overdue: 7},
+	}}
+	users := &fakeUserRepo{users: map[uuid.UUID]*domain.User{ana.ID: ana, rosa.ID: rosa}}
+	service := NewReportService(repo, users, nil, nil)
+
+	dashboard, err := service.GetMyDashboard(context.Background(), ana.ID)
+	if err != nil {
+		t.Fatalf("GetMyDashboard: %v", err)
+	}
+
+	if len(repo.unscoped) > 0 {
+		t.Fatalf("consultas sin filtrar por el apoderado: %v", repo.unscoped)
+	}
+	if dashboard.UserID != ana.ID || dashboard.CaregiverName != "Ana Quispe" {
+		t.Errorf("usuario = %s %q, se esperaba %s %q", dashboard.UserID, dashboard.CaregiverName, ana.ID, "Ana Quispe")
+	}
+	if dashboard.TotalPatients != 5 || dashboard.PatientsAtRisk != 2 || dashboard.TotalMeasurements != 9 {
+		t.Errorf("cifras = %d pacientes, %d en riesgo, %d mediciones; se esperaban solo las de Ana (5, 2, 9)",
+			dashboard.TotalPatients, dashboard.PatientsAtRisk, dashboard.TotalMeasurements)
+	}
+	if dashboard.MeasurementsThisWeek.Value != 9 || dashboard.MeasurementsThisMonth.Value != 9 {
+		t.Errorf("mediciones del periodo = %d/%d, se esperaban las de Ana", dashboard.MeasurementsThisWeek.Value, dashboard.MeasurementsThisMonth.Value)
+	}
+	if dashboard.OverdueFollowups != 1 {
+		t.Errorf("controles vencidos = %d, se esperaba 1", dashboard.OverdueFollowups)
+	}
+	if dashboard.UnmeasuredPatients != 1 {
+		t.Errorf("pacientes sin medir = %d, se esperaba 1", dashboard.UnmeasuredPatients)
+	}
+}
+
+func TestReportServiceGetMyDashboardRequiresActiveUser(t *testing.T) {
+	inactive := &domain.User{ID: uuid.New(), Active: false}
+	users := &fakeUserRepo{users: map[uuid.UUID]*domain.User{inactive.ID: inactive}}
+	service := NewReportService(&fakeReportRepo{}, users, nil, nil)
+
+	for name, actorID := range map[string]uuid.UUID{
+		"sin usuario":       uuid.Nil,
+		"usuario no existe": uuid.New(),
+		"usuario inactivo":  inactive.ID,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := service.GetMyDashboard(context.Background(), actorID); !errors.Is(err, domain.ErrDashboardActorRequired) {
+				t.Fatalf("err = %v, se esperaba ErrDashboardActorRequired", err)
+			}
+		})
+	}
+}
+
+// fakeCountersRepo cuenta las consultas de contadores y devuelve menos pacientes con approved_only
+type fakeCountersRepo struct {
+	ports.IReportRepository
+	calls int
+}
+
+func (f *fakeCountersRepo) GetCounters(ctx context.Context, filters *domain.ReportFilters) (*domain.CountersReport, error) {
+	f.calls++
+	if filters != nil && filters.ApprovedOnly {
+		return &domain.CountersReport{TotalPatients: 3}, nil
+	}
+	return &domain.CountersReport{TotalPatients: 5}, nil
+}
+
+func TestReportServiceCountersCacheSeparatesApprovedOnly(t *testing.T) {
+	repo := &fakeCountersRepo{}
+	service := NewReportService(repo, nil, nil, nil)
+	ctx := context.Background()
+
+	all, err := service.GetCountersReport(ctx, &domain.ReportFilters{})
+	if err != nil {
+		t.Fatalf("GetCountersReport: %v", err)
+	}
+	approved, err := service.GetCountersReport(ctx, &domain.ReportFilters{ApprovedOnly: true})
+	if err != nil {
+		t.Fatalf("GetCountersReport: %v", err)
+	}
+	if all.TotalPatients != 5 || approved.TotalPatients != 3 {
+		t.Fatalf("pacientes = %d/%d, se esperaba 5 en total y 3 aprobados", all.TotalPatients, approved.TotalPatients)
+	}
+	if _, err := service.GetCountersReport(ctx, &domain.ReportFilters{ApprovedOnly: true}); err != nil {
+		t.Fatalf("GetCountersReport: %v", err)
+	}
+	if repo.calls != 2 {
+		t.Fatalf("consultas = %d, se esperaba 2 (la tercera desde el caché)", repo.calls)
+	}
+}
+
+func TestReportServiceCountersCacheOnlyKnownLocalities(t *testing.T) {
+	locality := &domain.Locality{ID: uuid.New(), Name: "Alerta"}
+	repo := &fakeCountersRepo{}
+	service := NewReportService(repo, nil, &fakeLocalityRepo{localities: map[uuid.UUID]*domain.Locality{locality.ID: locality}}, nil)
+	cache := service.(*reportService).countersCache
+	ctx := context.Background()
+
+	unknown := uuid.New()
+	if _, err := service.GetCountersReport(ctx, &domain.ReportFilters{LocalityID: &unknown}); !errors.Is(err, domain.ErrLocalityNotFound) {
+		t.Fatalf("error = %v, se esperaba ErrLocalityNotFound", err)
+	}
+	if repo.calls != 0 || len(cache) != 0 {
+		t.Fatalf("consultas = %d, entradas = %d; una localidad inexistente no debe consultarse ni guardarse", repo.calls, len(cache))
+	}
+
+	cache["vencida"] = &domain.CountersReport{GeneratedAt: time.Now().Add(-domain.CountersCacheTTL)}
+	if _, err := service.GetCountersReport(ctx, &domain.ReportFilters{LocalityID: &locality.ID}); err != nil {
+		t.Fatalf("GetCountersReport: %v", err)
+	}
+	if _, ok := cache["vencida"]; ok {
+		t.Error("la entrada vencida debe descartarse al guardar una nueva")
+	}
+	if _, ok := cache[locality.ID.String()]; !ok {
+		t.Error("se esperaba en caché la localidad existente")
+	}
+}
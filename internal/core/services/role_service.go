@@ -2,7 +2,7 @@ package services
 
 import (
 	"context"
-	"fmt"
+	"log/slog"
 
 	"github.com/google/uuid"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
@@ -12,12 +12,14 @@ import (
 // roleService implementa la interfaz RoleService
 type roleService struct {
 	roleRepo ports.IRoleRepository
+	logger   *slog.Logger
 }
 
 // NewRoleService crea una nueva instancia de RoleService
-func NewRoleService(roleRepo ports.IRoleRepository) ports.IRoleService {
+func NewRoleService(roleRepo ports.IRoleRepository, logger *slog.Logger) ports.IRoleService {
 	return &roleService{
 		roleRepo: roleRepo,
+		logger:   logger,
 	}
 }
 
@@ -38,10 +40,10 @@ func (s *roleService) CreateRole(ctx context.Context, name, description string)
 
 // GetRoleByID obtiene un rol por su ID
 func (s *roleService) GetRoleByID(ctx context.Context, id uuid.UUID) (*domain.Role, error) {
-	fmt.Printf("Buscando rol con ID: %s\n", id.String())
+	s.logger.DebugContext(ctx, "buscando rol", "entity_id", id)
 	role, err := s.roleRepo.GetByID(ctx, id)
 	if err != nil {
-		fmt.Printf("Error al buscar rol: %v\n", err)
+		s.logger.WarnContext(ctx, "error al buscar rol", "entity_id", id, "error", err)
 	}
 	return role, err
 }
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+)
+
+// syncService compone los datos de varios servicios para la sincronización offline
+type syncService struct {
+	localityService       ports.ILocalityService
+	patientService        ports.IPatientService
+	measurementService    ports.IMeasurementService
+	recommendationService ports.IRecommendationService
+	faqService            ports.IFAQService
+}
+
+// NewSyncService crea una nueva instancia de SyncService
+func NewSyncService(
+	localityService ports.ILocalityService,
+	patientService ports.IPatientService,
+	measurementService ports.IMeasurementService,
+	recommendationService ports.IRecommendationService,
+	faqService ports.IFAQService,
+) ports.ISyncService {
+	return &syncService{
+		localityService:       localityService,
+		patientService:        patientService,
+		measurementService:    measurementService,
+		recommendationService: recommendationService,
+		faqService:            faqService,
+	}
+}
+
+// GetBootstrap arma el paquete inicial de una localidad consultando sus partes en paralelo. Solo
+// incluye las mediciones de los pacientes que entran en el paquete
+func (s *syncService) GetBootstrap(ctx context.Context, localityID uuid.UUID) (*domain.SyncBootstrapBundle, error) {
+	locality, err := s.localityService.GetByID(ctx, localityID)
+	if err != nil {
+		return nil, err
+	}
+
+	// El cursor se fija antes de consultar para no perder cambios hechos durante el armado
+	bundle := &domain.SyncBootstrapBundle{
+		Version:    domain.SyncBundleVersion,
+		Locality:   locality,
+		ServerTime: time.Now(),
+	}
+
+	measurementsPage := &domain.Pagination{Page: 1, PageSize: domain.MaxBootstrapMeasurements}
+	parts := []struct {
+		name  string
+		fetch func() error
+	}{
+		{"pacientes", func() error {
+			patients, err := s.patientService.GetByLocalityID(ctx, localityID, domain.MaxBootstrapPatients)
+			bundle.Patients = patients
+			return err
+		}},
+		{"mediciones", func() error {
+			delta, err := s.measurementService.GetSyncDelta(ctx, &domain.MeasurementSyncFilter{
+				Until:      bundle.ServerTime,
+				LocalityID: &localityID,
+			}, measurementsPage)
+			if err != nil {
+				return err
+			}
+			bundle.Measurements = delta.Measurements
+			return nil
+		}},
+		{"recomendaciones", func() error {
+			recommendations, err := s.recommendationService.GetAll(ctx)
+			bundle.Recommendations = domain.FilterActiveRecommendations(recommendations)
+			return err
+		}},
+		{"preguntas frecuentes", func() error {
+			faqs, err := s.faqService.GetAllGroupedByCategory(ctx)
+			bundle.FAQs = faqs
+			return err
+		}},
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(parts))
+	for i, part := range parts {
+		wg.Add(1)
+		go func(i int, name string, fetch func() error) {
+			defer wg.Done()
+			if err := fetch(); err != nil {
+				errs[i] = fmt.Errorf("error al obtener %s: %w", name, err)
+			}
+		}(i, part.name, part.fetch)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("error al generar paquete de sincronización: %w", err)
+	}
+
+	bundle.Truncated = len(bundle.Patients) >= domain.MaxBootstrapPatients ||
+		measurementsPage.Total > int64(len(bundle.Measurements))
+
+	// Si se recortaron los pacientes, sus mediciones no deben llegar sin el paciente al que pertenecen
+	included := make(map[uuid.UUID]bool, len(bundle.Patients))
+	for _, patient := range bundle.Patients {
+		included[patient.ID] = true
+	}
+	measurements := make([]*domain.Measurement, 0, len(bundle.Measurements))
+	for _, measurement := range bundle.Measurements {
+		if included[measurement.PatientID] {
+			measurements = append(measurements, measurement)
+		}
+	}
+	bundle.Measurements = measurements
+
+	return bundle, nil
+}
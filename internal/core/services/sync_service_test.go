@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+)
+
+// Partes fijas del paquete inicial
+type fakeSyncLocalities struct {
+	ports.ILocalityService
+	locality *domain.Locality
+}
+
+func (f *fakeSyncLocalities) GetByID(ctx context.Context, id uuid.UUID) (*domain.Locality, error) {
+	return f.locality, nil
+}
+
+type fakeSyncPatients struct {
+	ports.IPatientService
+	patients []*domain.Patient
+}
+
+func (f *fakeSyncPatients) GetByLocalityID(ctx context.Context, localityID uuid.UUID, limit int) ([]*domain.Patient, error) {
+	return f.patients, nil
+}
+
+type fakeSyncMeasurements struct {
+	ports.IMeasurementService
+	measurements []*domain.Measurement
+}
+
+func (f *fakeSyncMeasurements) GetSyncDelta(ctx context.Context, filter *domain.MeasurementSyncFilter, page *domain.Pagination) (*domain.MeasurementSyncDelta, error) {
+	page.Total = int64(len(f.measurements))
+	return &domain.MeasurementSyncDelta{Measurements: f.measurements}, nil
+}
+
+type fakeSyncRecommendations struct {
+	ports.IRecommendationService
+}
+
+func (f *fakeSyncRecommendations) GetAll(ctx context.Context) ([]*domain.Recommendation, error) {
+	return nil, nil
+}
+
+type fakeSyncFAQs struct {
+	ports.IFAQService
+}
+
+func (f *fakeSyncFAQs) GetAllGroupedByCategory(ctx context.Context) ([]*domain.FAQGrouped, error) {
+	return nil, nil
+}
+
+func TestSyncServiceBootstrapOnlyIncludedPatientMeasurements(t *testing.T) {
+	locality := &domain.Locality{ID: uuid.New()}
+	included := &domain.Patient{ID: uuid.New()}
+	measurements := &fakeSyncMeasurements{measurements: []*domain.Measurement{
+		{ID: uuid.New(), PatientID: included.ID},
+		{ID: uuid.New(), PatientID: uuid.New()}, // Paciente que no entró en el paquete
+	}}
+	service := NewSyncService(
+		&fakeSyncLocalities{locality: locality},
+		&fakeSyncPatients{patients: []*domain.Patient{included}},
+		measurements,
+		&fakeSyncRecommendations{},
+		&fakeSyncFAQs{},
+	)
+
+	bundle, err := service.GetBootstrap(context.Background(), locality.ID)
+	if err != nil {
+		t.Fatalf("GetBootstrap: %v", err)
+	}
+	if len(bundle.Measurements) != 1 || bundle.Measurements[0].PatientID != included.ID {
+		t.Fatalf("mediciones = %v, se esperaba solo la del paciente incluido", bundle.Measurements)
+	}
+	if bundle.Truncated {
+		t.Error("no se alcanzó ningún límite, se esperaba truncated = false")
+	}
+}
@@ -54,4 +54,30 @@ func (s *tagService) Update(ctx context.Context, tag *domain.Tag) error {
 // Delete elimina una etiqueta por su ID
 func (s *tagService) Delete(ctx context.Context, id uuid.UUID) error {
 	return s.tagRepo.Delete(ctx, id)
-}
\ No newline at end of file
+}
+
+// GetOverview obtiene las etiquetas ordenadas por prioridad con su número de mediciones.
+// active nil devuelve todas; en otro caso filtra por estado
+func (s *tagService) GetOverview(ctx context.Context, active *bool) ([]*domain.TagOverview, error) {
+	tags, err := s.tagRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.tagRepo.CountMeasurementsByTag(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	overview := make([]*domain.TagOverview, 0, len(tags))
+	for _, tag := range domain.SortTagsByPriority(tags) {
+		if active != nil && tag.Active != *active {
+			continue
+		}
+		overview = append(overview, &domain.TagOverview{
+			Tag:              tag,
+			MeasurementCount: counts[tag.ID],
+		})
+	}
+	return overview, nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+)
+
+// taskService compone la lista de pendientes del apoderado a partir de pacientes y notificaciones
+type taskService struct {
+	userService         ports.IUserService
+	patientService      ports.IPatientService
+	notificationService ports.INotificationService
+}
+
+// NewTaskService crea una nueva instancia de TaskService
+func NewTaskService(
+	userService ports.IUserService,
+	patientService ports.IPatientService,
+	notificationService ports.INotificationService,
+) ports.ITaskService {
+	return &taskService{
+		userService:         userService,
+		patientService:      patientService,
+		notificationService: notificationService,
+	}
+}
+
+// GetCaregiverTasks arma los pendientes del apoderado (controles vencidos, pacientes sin medir y
+// notificaciones recientes sin leer) ordenados por urgencia. Solo incluye los comunicados y las
+// notificaciones dirigidas al usuario cuyo tipo acepta según sus preferencias.
+func (s *taskService) GetCaregiverTasks(ctx context.Context, userID uuid.UUID) ([]domain.CaregiverTask, error) {
+	user, err := s.userService.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	tasks, err := s.patientService.GetFollowupTasks(ctx, userID, now)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener controles pendientes: %w", err)
+	}
+
+	notifications, err := s.notificationService.GetUnreadSince(ctx, userID, now.Add(-domain.AlertTaskWindow))
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener comunicados: %w", err)
+	}
+	for _, notification := range notifications {
+		if user.NotificationPreferences.Allows(notification.Kind) {
+			tasks = append(tasks, *domain.NewAlertTask(notification))
+		}
+	}
+
+	domain.SortCaregiverTasks(tasks)
+	return tasks, nil
+}
+
+// GetSessionRoster arma la lista imprimible de pacientes del apoderado para una jornada de medición
+func (s *taskService) GetSessionRoster(ctx context.Context, userID uuid.UUID) (*domain.SessionRoster, error) {
+	user, err := s.userService.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	patients, err := s.patientService.GetSessionRoster(ctx, userID, now)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener pacientes del apoderado: %w", err)
+	}
+
+	return &domain.SessionRoster{
+		UserID:        user.ID,
+		CaregiverName: fmt.Sprintf("%s %s", user.Name, user.LastName),
+		GeneratedAt:   now,
+		Patients:      patients,
+	}, nil
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/core/ports"
+)
+
+// fakeTaskUserService devuelve el usuario registrado; el resto de IUserService no se invoca
+type fakeTaskUserService struct {
+	ports.IUserService
+	user *domain.User
+}
+
+func (f *fakeTaskUserService) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	if f.user == nil || f.user.ID != id {
+		return nil, domain.ErrUserNotFound
+	}
+	return f.user, nil
+}
+
+// fakeTaskPatientService no tiene controles pendientes
+type fakeTaskPatientService struct {
+	ports.IPatientService
+}
+
+func (f *fakeTaskPatientService) GetFollowupTasks(ctx context.Context, userID uuid.UUID, now time.Time) ([]domain.CaregiverTask, error) {
+	return nil, nil
+}
+
+// fakeUnreadNotificationService filtra las notificaciones recientes por destinatario y con las lecturas
+// registradas por usuario; GetAll no está implementado para que falle si el servicio vuelve a listar todo
+type fakeUnreadNotificationService struct {
+	ports.INotificationService
+	notifications []*domain.Notification
+	reads         map[uuid.UUID]map[uuid.UUID]bool
+}
+
+func (f *fakeUnreadNotificationService) GetUnreadSince(ctx context.Context, userID uuid.UUID, since time.Time) ([]*domain.Notification, error) {
+	var unread []*domain.Notification
+	for _, n := range f.notifications {
+		forUser := n.RecipientID == nil || *n.RecipientID == userID
+		if forUser && n.Visible && n.CreatedAt.After(since) && !f.reads[userID][n.ID] {
+			unread = append(unread, n)
+		}
+	}
+	return unread, nil
+}
+
+func TestTaskServiceGetCaregiverTasksSkipsReadNotifications(t *testing.T) {
+	now := time.Now()
+	user := &domain.User{ID: uuid.New(), NotificationPreferences: domain.NotificationPreferences{Broadcasts: true}}
+
+	read := &domain.Notification{ID: uuid.New(), Title: "Leído", Kind: domain.NotificationKindBroadcast, Visible: true, CreatedAt: now.Add(-time.Hour)}
+	unread := &domain.Notification{ID: uuid.New(), Title: "Sin leer", Kind: domain.NotificationKindBroadcast, Visible: true, CreatedAt: now.Add(-2 * time.Hour)}
+	old := &domain.Notification{ID: uuid.New(), Title: "Antiguo", Kind: domain.NotificationKindBroadcast, Visible: true, CreatedAt: now.Add(-domain.AlertTaskWindow - time.Hour)}
+
+	notifications := &fakeUnreadNotificationService{
+		notifications: []*domain.Notification{read, unread, old},
+		reads:         map[uuid.UUID]map[uuid.UUID]bool{user.ID: {read.ID: true}},
+	}
+	service := NewTaskService(&fakeTaskUserService{user: user}, &fakeTaskPatientService{}, notifications)
+
+	tasks, err := service.GetCaregiverTasks(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("GetCaregiverTasks: %v", err)
+	}
+	if len(tasks) != 1 {
+		t.Fatalf("se obtuvieron %d pendientes, se esperaba solo el comunicado sin leer: %+v", len(tasks), tasks)
+	}
+	if tasks[0].Type != domain.TaskTypeAlert || tasks[0].NotificationID == nil || *tasks[0].NotificationID != unread.ID {
+		t.Fatalf("pendiente = %+v, se esperaba el comunicado %s", tasks[0], unread.ID)
+	}
+}
+
+func TestTaskServiceGetCaregiverTasksWithoutBroadcasts(t *testing.T) {
+	user := &domain.User{ID: uuid.New()}
+	notifications := &fakeUnreadNotificationService{
+		notifications: []*domain.Notification{{ID: uuid.New(), Kind: domain.NotificationKindBroadcast, Visible: true, CreatedAt: time.Now()}},
+	}
+	service := NewTaskService(&fakeTaskUserService{user: user}, &fakeTaskPatientService{}, notifications)
+
+	tasks, err := service.GetCaregiverTasks(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("GetCaregiverTasks: %v", err)
+	}
+	if len(tasks) != 0 {
+		t.Fatalf("se obtuvieron %d pendientes, se esperaba ninguno sin broadcasts", len(tasks))
+	}
+}
+
+func TestTaskServiceGetCaregiverTasksTargetedNotifications(t *testing.T) {
+	now := time.Now()
+	user := &domain.User{ID: uuid.New(), NotificationPreferences: domain.NotificationPreferences{RedAlerts: false, Reminders: true, Broadcasts: true}}
+	otherID := uuid.New()
+
+	notification := func(title, kind string, recipientID *uuid.UUID) *domain.Notification {
+		n := &domain.Notification{ID: uuid.New(), Title: title, Visible: true, CreatedAt: now.Add(-time.Hour)}
+		n.SetRecipient(recipientID, kind)
+		return n
+	}
+	reminder := notification("Control de Ana", domain.NotificationKindReminder, &user.ID)
+	notifications := &fakeUnreadNotificationService{notifications: []*domain.Notification{
+		reminder,
+		notification("Alerta roja de Ana", domain.NotificationKindRedAlert, &user.ID),
+		notification("Control de otro niño", domain.NotificationKindReminder, &otherID),
+		notification("Alerta roja de otro niño", domain.NotificationKindRedAlert, &otherID),
+	}}
+	service := NewTaskService(&fakeTaskUserService{user: user}, &fakeTaskPatientService{}, notifications)
+
+	tasks, err := service.GetCaregiverTasks(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("GetCaregiverTasks: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].NotificationID == nil || *tasks[0].NotificationID != reminder.ID {
+		t.Fatalf("pendientes = %+v, se esperaba solo el recordatorio dirigido al usuario", tasks)
+	}
+}
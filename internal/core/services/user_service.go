@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
@@ -10,15 +12,27 @@ import (
 
 // UserService implementa la lógica de negocio para usuarios
 type userService struct {
-	userRepo ports.IUserRepository
-	roleRepo ports.IRoleRepository
+	userRepo     ports.IUserRepository
+	roleRepo     ports.IRoleRepository
+	localityRepo ports.ILocalityRepository
+	patientRepo  ports.IPatientRepository
+	auditService ports.IAuditService
 }
 
 // NewUserService crea una nueva instancia de UserService
-func NewUserService(userRepo ports.IUserRepository, roleRepo ports.IRoleRepository) ports.IUserService {
+func NewUserService(
+	userRepo ports.IUserRepository,
+	roleRepo ports.IRoleRepository,
+	localityRepo ports.ILocalityRepository,
+	patientRepo ports.IPatientRepository,
+	auditService ports.IAuditService,
+) ports.IUserService {
 	return &userService{
-		userRepo: userRepo,
-		roleRepo: roleRepo,
+		userRepo:     userRepo,
+		roleRepo:     roleRepo,
+		localityRepo: localityRepo,
+		patientRepo:  patientRepo,
+		auditService: auditService,
 	}
 }
 
@@ -41,6 +55,9 @@ func (s *userService) Create(ctx context.Context, user *domain.User) error {
 
 		for _, r := range allroles {
 			if r.Name == "APODERADO" {
+				if err := validateRoleLocality(r, user); err != nil {
+					return err
+				}
 				user.RoleID = r.ID
 				return s.userRepo.Create(ctx, user)
 			}
@@ -49,15 +66,26 @@ func (s *userService) Create(ctx context.Context, user *domain.User) error {
 
 	// Verificar que el rol existe
 	if user.RoleID != uuid.Nil {
-		_, err := s.roleRepo.GetByID(ctx, user.RoleID)
+		role, err := s.roleRepo.GetByID(ctx, user.RoleID)
 		if err != nil {
 			return err
 		}
+		if err := validateRoleLocality(role, user); err != nil {
+			return err
+		}
 	}
 
 	return s.userRepo.Create(ctx, user)
 }
 
+// validateRoleLocality exige localidad a los usuarios cuyo rol la requiere
+func validateRoleLocality(role *domain.Role, user *domain.User) error {
+	if role.RequiresLocality() && (user.LocalityID == nil || *user.LocalityID == uuid.Nil) {
+		return fmt.Errorf("%w: %s", domain.ErrLocalityRequired, role.Name)
+	}
+	return nil
+}
+
 // GetByID obtiene un usuario por su ID
 func (s *userService) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
 	return s.userRepo.GetByID(ctx, id)
@@ -78,6 +106,22 @@ func (s *userService) GetApoderados(ctx context.Context, localityID *uuid.UUID)
 	return s.userRepo.GetByRole(ctx, "APODERADO", localityID)
 }
 
+// GetCaregiverLoads obtiene los apoderados de una localidad con su cantidad de pacientes y última actividad
+func (s *userService) GetCaregiverLoads(ctx context.Context, localityID uuid.UUID, sortBy string) ([]domain.CaregiverLoad, error) {
+	if sortBy == "" {
+		sortBy = domain.CaregiverSortName
+	}
+	if !domain.IsValidCaregiverSort(sortBy) {
+		return nil, domain.ErrInvalidCaregiverSort
+	}
+	return s.userRepo.GetCaregiverLoads(ctx, "APODERADO", localityID, sortBy)
+}
+
+// GetWithoutLocality obtiene los usuarios que no tienen localidad asignada
+func (s *userService) GetWithoutLocality(ctx context.Context) ([]*domain.User, error) {
+	return s.userRepo.GetWithoutLocality(ctx)
+}
+
 // Update actualiza un usuario existente
 func (s *userService) Update(ctx context.Context, user *domain.User) error {
 	if err := user.Validate(); err != nil {
@@ -86,15 +130,56 @@ func (s *userService) Update(ctx context.Context, user *domain.User) error {
 
 	// Verificar que el rol existe
 	if user.RoleID != uuid.Nil {
-		_, err := s.roleRepo.GetByID(ctx, user.RoleID)
+		role, err := s.roleRepo.GetByID(ctx, user.RoleID)
 		if err != nil {
 			return err
 		}
+		if err := validateRoleLocality(role, user); err != nil {
+			return err
+		}
 	}
 
 	return s.userRepo.Update(ctx, user)
 }
 
+// UpdateLocality cambia la localidad del apoderado. Sus pacientes lo siguen sin cambios propios porque
+// los reportes derivan la localidad de users.locality_id; el repositorio registra sus mediciones como
+// trasladadas para que la sincronización las retire de la localidad anterior
+func (s *userService) UpdateLocality(ctx context.Context, id uuid.UUID, localityID uuid.UUID, actorID *uuid.UUID) (*domain.LocalityMove, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.localityRepo.GetByID(ctx, localityID); err != nil {
+		return nil, err
+	}
+
+	move := &domain.LocalityMove{
+		UserID:             user.ID,
+		PreviousLocalityID: user.LocalityID,
+		LocalityID:         localityID,
+		MovedAt:            time.Now(),
+	}
+
+	if err := s.userRepo.UpdateLocality(ctx, id, localityID); err != nil {
+		return nil, err
+	}
+
+	move.AffectedPatients, err = s.patientRepo.CountByUserID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditService.Record(ctx, domain.NewAuditEntry(domain.AuditEntityUser, &user.ID, domain.AuditActionUpdate, actorID,
+		map[string]interface{}{
+			"field":                "locality_id",
+			"previous_locality_id": move.PreviousLocalityID,
+			"locality_id":          localityID,
+			"affected_patients":    move.AffectedPatients,
+		}))
+	return move, nil
+}
+
 // Delete elimina un usuario por su ID
 func (s *userService) Delete(ctx context.Context, id uuid.UUID) error {
 	return s.userRepo.Delete(ctx, id)
@@ -110,6 +195,16 @@ func (s *userService) UpdatePassword(ctx context.Context, id uuid.UUID, password
 	return s.userRepo.Update(ctx, user)
 }
 
+// UpdateNotificationPreferences actualiza las preferencias de notificación de un usuario
+func (s *userService) UpdateNotificationPreferences(ctx context.Context, id uuid.UUID, preferences domain.NotificationPreferences) error {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	user.UpdateNotificationPreferences(preferences)
+	return s.userRepo.Update(ctx, user)
+}
+
 // UpdateRole actualiza el rol de un usuario
 func (s *userService) UpdateRole(ctx context.Context, id uuid.UUID, roleID uuid.UUID) error {
 	user, err := s.userRepo.GetByID(ctx, id)
@@ -119,10 +214,13 @@ func (s *userService) UpdateRole(ctx context.Context, id uuid.UUID, roleID uuid.
 
 	// Verificar que el rol existe
 	if roleID != uuid.Nil {
-		_, err := s.roleRepo.GetByID(ctx, roleID)
+		role, err := s.roleRepo.GetByID(ctx, roleID)
 		if err != nil {
 			return err
 		}
+		if err := validateRoleLocality(role, user); err != nil {
+			return err
+		}
 	}
 
 	user.UpdateRole(roleID)
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+)
+
+func TestValidateRoleLocality(t *testing.T) {
+	previous := domain.RolesRequiringLocality
+	t.Cleanup(func() { domain.RolesRequiringLocality = previous })
+	domain.SetRolesRequiringLocality([]string{"apoderado"})
+
+	localityID := uuid.New()
+	nilLocality := uuid.Nil
+
+	tests := []struct {
+		name     string
+		role     string
+		locality *uuid.UUID
+		wantErr  bool
+	}{
+		{"apoderado con localidad", "APODERADO", &localityID, false},
+		{"apoderado sin localidad", "APODERADO", nil, true},
+		{"apoderado con uuid nulo", "APODERADO", &nilLocality, true},
+		{"administrador sin localidad", "ADMINISTRADOR", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRoleLocality(&domain.Role{Name: tt.role}, &domain.User{LocalityID: tt.locality})
+			if tt.wantErr && !errors.Is(err, domain.ErrLocalityRequired) {
+				t.Fatalf("err = %v, se esperaba ErrLocalityRequired", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("err = %v, se esperaba nil", err)
+			}
+		})
+	}
+}
+
+func TestUserServiceUpdateLocality(t *testing.T) {
+	previousLocality, newLocality := uuid.New(), uuid.New()
+	caregiver := &domain.User{ID: uuid.New(), LocalityID: &previousLocality}
+	actorID := uuid.New()
+
+	users := &fakeUserRepo{users: map[uuid.UUID]*domain.User{caregiver.ID: caregiver}}
+	localities := &fakeLocalityRepo{localities: map[uuid.UUID]*domain.Locality{newLocality: {ID: newLocality, Name: "Tambopata"}}}
+	patients := &fakePatientRepo{counts: map[uuid.UUID]int64{caregiver.ID: 4}}
+	audit := &fakeAuditService{}
+	service := NewUserService(users, nil, localities, patients, audit)
+
+	move, err := service.UpdateLocality(context.Background(), caregiver.ID, newLocality, &actorID)
+	if err != nil {
+		t.Fatalf("UpdateLocality: %v", err)
+	}
+
+	if caregiver.LocalityID == nil || *caregiver.LocalityID != newLocality {
+		t.Fatalf("locality_id = %v, se esperaba %s", caregiver.LocalityID, newLocality)
+	}
+	if move.PreviousLocalityID == nil || *move.PreviousLocalityID != previousLocality {
+		t.Errorf("previous_locality_id = %v, se esperaba %s", move.PreviousLocalityID, previousLocality)
+	}
+	if move.LocalityID != newLocality || move.AffectedPatients != 4 {
+		t.Errorf("move = %+v, se esperaba locality %s y 4 pacientes", move, newLocality)
+	}
+
+	if len(audit.entries) != 1 {
+		t.Fatalf("entradas de auditoría = %d, se esperaba 1", len(audit.entries))
+	}
+	entry := audit.entries[0]
+	if entry.Entity != domain.AuditEntityUser || entry.Action != domain.AuditActionUpdate {
+		t.Errorf("entrada = %s/%s, se esperaba %s/%s", entry.Entity, entry.Action, domain.AuditEntityUser, domain.AuditActionUpdate)
+	}
+	if entry.EntityID == nil || *entry.EntityID != caregiver.ID || entry.ActorID == nil || *entry.ActorID != actorID {
+		t.Errorf("entity_id/actor_id = %v/%v, se esperaba %s/%s", entry.EntityID, entry.ActorID, caregiver.ID, actorID)
+	}
+	var details map[string]interface{}
+	if err := json.Unmarshal([]byte(entry.Details), &details); err != nil {
+		t.Fatalf("details no es JSON: %v", err)
+	}
+	if details["previous_locality_id"] != previousLocality.String() || details["locality_id"] != newLocality.String() {
+		t.Errorf("details = %v, se esperaba el cambio %s -> %s", details, previousLocality, newLocality)
+	}
+	if details["affected_patients"] != float64(4) {
+		t.Errorf("affected_patients = %v, se esperaba 4", details["affected_patients"])
+	}
+}
+
+func TestUserServiceUpdateLocalityUnknownLocality(t *testing.T) {
+	caregiver := &domain.User{ID: uuid.New()}
+	users := &fakeUserRepo{users: map[uuid.UUID]*domain.User{caregiver.ID: caregiver}}
+	audit := &fakeAuditService{}
+	service := NewUserService(users, nil, &fakeLocalityRepo{}, &fakePatientRepo{}, audit)
+
+	_, err := service.UpdateLocality(context.Background(), caregiver.ID, uuid.New(), nil)
+	if !errors.Is(err, domain.ErrLocalityNotFound) {
+		t.Fatalf("err = %v, se esperaba ErrLocalityNotFound", err)
+	}
+	if caregiver.LocalityID != nil {
+		t.Error("se cambió la localidad pese al error")
+	}
+	if len(audit.entries) != 0 {
+		t.Error("se registró auditoría de un cambio que no ocurrió")
+	}
+}
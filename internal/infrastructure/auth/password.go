@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// cost es el costo bcrypt vigente para nuevos hashes
+var cost = bcrypt.DefaultCost
+
+// SetCost configura el costo bcrypt, ignorando valores fuera del rango permitido
+func SetCost(c int) {
+	if c < bcrypt.MinCost || c > bcrypt.MaxCost {
+		return
+	}
+	cost = c
+}
+
+// Cost devuelve el costo bcrypt vigente
+func Cost() int {
+	return cost
+}
+
+// HashPassword genera el hash de una contraseña con el costo vigente
+func HashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return "", fmt.Errorf("error al hashear la contraseña: %w", err)
+	}
+	return string(hashed), nil
+}
+
+// CheckPassword compara una contraseña con su hash
+func CheckPassword(hash, password string) error {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+}
+
+// NeedsRehash indica si el hash fue generado con un costo menor al vigente
+func NeedsRehash(hash string) bool {
+	c, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return false
+	}
+	return c < cost
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// withCost fija el costo vigente durante la prueba y lo restaura al terminar
+func withCost(t *testing.T, c int) {
+	t.Helper()
+	previous := Cost()
+	SetCost(c)
+	t.Cleanup(func() { cost = previous })
+}
+
+func TestNeedsRehashAfterRaisingCost(t *testing.T) {
+	withCost(t, bcrypt.MinCost)
+
+	hash, err := HashPassword("secreto")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	if NeedsRehash(hash) {
+		t.Fatal("NeedsRehash = true con el mismo costo, se esperaba false")
+	}
+
+	SetCost(bcrypt.MinCost + 1)
+	if !NeedsRehash(hash) {
+		t.Fatal("NeedsRehash = false tras subir el costo, se esperaba true")
+	}
+	if err := CheckPassword(hash, "secreto"); err != nil {
+		t.Fatalf("el hash anterior debe seguir siendo válido: %v", err)
+	}
+
+	rehashed, err := HashPassword("secreto")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	if NeedsRehash(rehashed) {
+		t.Fatal("NeedsRehash = true para un hash con el costo vigente")
+	}
+}
+
+func TestSetCostIgnoresOutOfRange(t *testing.T) {
+	withCost(t, bcrypt.MinCost)
+
+	SetCost(bcrypt.MinCost - 1)
+	SetCost(bcrypt.MaxCost + 1)
+	if got := Cost(); got != bcrypt.MinCost {
+		t.Fatalf("Cost() = %d, se esperaba %d", got, bcrypt.MinCost)
+	}
+}
+
+func TestNeedsRehashInvalidHash(t *testing.T) {
+	if NeedsRehash("no-es-un-hash") {
+		t.Fatal("NeedsRehash = true para un hash inválido")
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql" // Driver para MySQL
 	_ "github.com/lib/pq"              // Driver para PostgreSQL
+	"github.com/luispfcanales/api-muac/internal/core/domain"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -34,6 +37,79 @@ type Config struct {
 	DBName     string
 	ServerPort int
 	DNS        string
+
+	// DSN de una réplica de solo lectura para los reportes (vacío usa la primaria)
+	DBReplicaDSN string
+
+	// Rango de edad admitido para pacientes (en meses)
+	MinPatientAgeMonths int
+	MaxPatientAgeMonths int
+
+	// Tiempo máximo por petición; exportaciones y archivos tienen uno mayor
+	RequestTimeout time.Duration
+	ExportTimeout  time.Duration
+
+	// Costo bcrypt para el hash de contraseñas
+	BcryptCost int
+
+	// Tiempo máximo de espera al entregar notificaciones vía webhook
+	WebhookTimeout time.Duration
+
+	// Hosts a los que se pueden entregar notificaciones (vacío no admite destinos externos)
+	WebhookAllowedHosts []string
+
+	// Adelanto máximo tolerado en la hora de medición enviada por el dispositivo
+	MaxClockSkew time.Duration
+
+	// Exigir descripción en mediciones en rojo o amarillo
+	RequireRiskDescription bool
+
+	// Tiempo en que un apoderado puede editar o eliminar sus mediciones (0 = sin límite)
+	MeasurementEditWindow time.Duration
+
+	// Días recomendados entre mediciones por código MUAC
+	ExpectedMeasurementDays map[string]int
+
+	// Máximo de pacientes por apoderado (0 = sin límite) y modo al superarlo: warn o block
+	MaxPatientsPerCaregiver int
+	CaseloadMode            string
+
+	// Roles cuyos usuarios deben tener localidad (vacío desactiva la regla)
+	LocalityRequiredRoles []string
+
+	// Exigir aprobación de supervisor para los pacientes registrados por apoderados
+	PatientApprovalRequired bool
+
+	// Iniciar en modo mantenimiento (solo lectura)
+	MaintenanceMode bool
+
+	// Token para los endpoints de administración (vacío los desactiva)
+	AdminToken string
+
+	// Región esperada de las localidades (bounding box en grados decimales)
+	ProgramRegion domain.RegionBounds
+
+	// Zona horaria de los reportes por hora del día (nombre IANA)
+	ProgramTimeZone string
+
+	// Archivo con las tablas OMS de perímetro braquial para la edad (vacío = la tabla incluida en el binario)
+	WHOMuacReferencePath string
+
+	// Archivos separados por comas con las tablas OMS de peso para la longitud y la talla (vacío = las
+	// tablas incluidas en el binario)
+	WHOWeightForHeightReferencePath string
+
+	// Máximo de conexiones al stream de mediciones en riesgo (0 = sin límite) e intervalo del heartbeat
+	RiskStreamMaxClients int
+	RiskStreamHeartbeat  time.Duration
+
+	// Pesos del índice de severidad por localidad para los casos rojos y amarillos
+	SeverityWeightSevere   float64
+	SeverityWeightModerate float64
+
+	// Nivel (debug, info, warn, error) y formato (text o json) de los logs
+	LogLevel  string
+	LogFormat string
 }
 
 // LoadConfig carga la configuración desde variables de entorno
@@ -42,6 +118,44 @@ func LoadConfig() *Config {
 	serverPort, _ := strconv.Atoi(getEnv("SERVER_PORT", "8003"))
 	dbType := DBType(getEnv("DB_TYPE", string(PostgreSQL)))
 	dns := getEnv("DNS", "http://localhost:"+strconv.Itoa(serverPort))
+	minAgeMonths, _ := strconv.Atoi(getEnv("PATIENT_MIN_AGE_MONTHS", strconv.Itoa(domain.DefaultMinAgeMonths)))
+	maxAgeMonths, _ := strconv.Atoi(getEnv("PATIENT_MAX_AGE_MONTHS", strconv.Itoa(domain.DefaultMaxAgeMonths)))
+	requestTimeout, _ := strconv.Atoi(getEnv("REQUEST_TIMEOUT_SECONDS", "30"))
+	exportTimeout, _ := strconv.Atoi(getEnv("EXPORT_TIMEOUT_SECONDS", "120"))
+	bcryptCost, _ := strconv.Atoi(getEnv("BCRYPT_COST", "12"))
+	webhookTimeout, _ := strconv.Atoi(getEnv("WEBHOOK_TIMEOUT_SECONDS", "10"))
+	maintenanceMode, _ := strconv.ParseBool(getEnv("MAINTENANCE_MODE", "false"))
+	patientApprovalRequired, _ := strconv.ParseBool(getEnv("PATIENT_APPROVAL_REQUIRED", "false"))
+	requireRiskDescription, _ := strconv.ParseBool(getEnv("MEASUREMENT_REQUIRE_RISK_DESCRIPTION", "false"))
+	maxClockSkew, _ := strconv.Atoi(getEnv("MEASUREMENT_MAX_CLOCK_SKEW_SECONDS", strconv.Itoa(int(domain.DefaultMaxClockSkew.Seconds()))))
+	measurementEditWindow, _ := strconv.Atoi(getEnv("MEASUREMENT_EDIT_WINDOW_HOURS", strconv.Itoa(int(domain.DefaultMeasurementEditWindow.Hours()))))
+	maxPatientsPerCaregiver, _ := strconv.Atoi(getEnv("MAX_PATIENTS_PER_CAREGIVER", "0"))
+	riskStreamMaxClients, _ := strconv.Atoi(getEnv("RISK_STREAM_MAX_CLIENTS", strconv.Itoa(domain.DefaultRiskStreamMaxClients)))
+	riskStreamHeartbeat, _ := strconv.Atoi(getEnv("RISK_STREAM_HEARTBEAT_SECONDS", strconv.Itoa(int(domain.DefaultRiskStreamHeartbeat.Seconds()))))
+	severityWeightSevere, _ := strconv.ParseFloat(getEnv("SEVERITY_WEIGHT_SEVERE", strconv.FormatFloat(domain.DefaultSeverityWeightSevere, 'f', -1, 64)), 64)
+	severityWeightModerate, _ := strconv.ParseFloat(getEnv("SEVERITY_WEIGHT_MODERATE", strconv.FormatFloat(domain.DefaultSeverityWeightModerate, 'f', -1, 64)), 64)
+	expectedMeasurementDays := map[string]int{}
+	for muacCode, env := range map[string]string{
+		domain.MuacCodeRed:    "MEASUREMENT_INTERVAL_RED_DAYS",
+		domain.MuacCodeYellow: "MEASUREMENT_INTERVAL_YELLOW_DAYS",
+		domain.MuacCodeGreen:  "MEASUREMENT_INTERVAL_GREEN_DAYS",
+	} {
+		expectedMeasurementDays[muacCode], _ = strconv.Atoi(getEnv(env, strconv.Itoa(domain.ExpectedMeasurementDays[muacCode])))
+	}
+	regionMinLat, _ := strconv.ParseFloat(getEnv("REGION_MIN_LATITUDE", domain.FormatCoordinate(domain.DefaultRegionMinLatitude)), 64)
+	regionMaxLat, _ := strconv.ParseFloat(getEnv("REGION_MAX_LATITUDE", domain.FormatCoordinate(domain.DefaultRegionMaxLatitude)), 64)
+	regionMinLng, _ := strconv.ParseFloat(getEnv("REGION_MIN_LONGITUDE", domain.FormatCoordinate(domain.DefaultRegionMinLongitude)), 64)
+	regionMaxLng, _ := strconv.ParseFloat(getEnv("REGION_MAX_LONGITUDE", domain.FormatCoordinate(domain.DefaultRegionMaxLongitude)), 64)
+	// En producción los logs salen en JSON salvo que LOG_FORMAT indique otra cosa
+	logFormat := "text"
+	if strings.EqualFold(getEnv("APP_ENV", "development"), "production") {
+		logFormat = "json"
+	}
+	// Se usa LookupEnv para permitir LOCALITY_REQUIRED_ROLES="" y desactivar la regla
+	localityRequiredRoles := strings.Join(domain.RolesRequiringLocality, ",")
+	if value, ok := os.LookupEnv("LOCALITY_REQUIRED_ROLES"); ok {
+		localityRequiredRoles = value
+	}
 
 	return &Config{
 		DBType: dbType,
@@ -56,6 +170,55 @@ func LoadConfig() *Config {
 		DBName:     getEnv("DB_NAME", "muac_db"),
 		ServerPort: serverPort,
 		DNS:        dns,
+
+		DBReplicaDSN: getEnv("DB_REPLICA_DSN", ""),
+
+		MinPatientAgeMonths: minAgeMonths,
+		MaxPatientAgeMonths: maxAgeMonths,
+
+		RequestTimeout: time.Duration(requestTimeout) * time.Second,
+		ExportTimeout:  time.Duration(exportTimeout) * time.Second,
+
+		BcryptCost: bcryptCost,
+
+		WebhookTimeout:      time.Duration(webhookTimeout) * time.Second,
+		WebhookAllowedHosts: strings.Split(getEnv("WEBHOOK_ALLOWED_HOSTS", ""), ","),
+
+		MaxClockSkew:           time.Duration(maxClockSkew) * time.Second,
+		RequireRiskDescription: requireRiskDescription,
+		MeasurementEditWindow:  time.Duration(measurementEditWindow) * time.Hour,
+
+		ExpectedMeasurementDays: expectedMeasurementDays,
+
+		MaxPatientsPerCaregiver: maxPatientsPerCaregiver,
+		CaseloadMode:            getEnv("CASELOAD_LIMIT_MODE", domain.CaseloadModeWarn),
+
+		LocalityRequiredRoles: strings.Split(localityRequiredRoles, ","),
+
+		PatientApprovalRequired: patientApprovalRequired,
+
+		MaintenanceMode: maintenanceMode,
+		AdminToken:      getEnv("ADMIN_TOKEN", ""),
+
+		ProgramRegion: domain.RegionBounds{
+			MinLatitude:  regionMinLat,
+			MaxLatitude:  regionMaxLat,
+			MinLongitude: regionMinLng,
+			MaxLongitude: regionMaxLng,
+		},
+		ProgramTimeZone: getEnv("PROGRAM_TIMEZONE", domain.DefaultProgramTimeZone),
+
+		WHOMuacReferencePath:            getEnv("WHO_MUAC_REFERENCE_PATH", ""),
+		WHOWeightForHeightReferencePath: getEnv("WHO_WFH_REFERENCE_PATH", ""),
+
+		RiskStreamMaxClients: riskStreamMaxClients,
+		RiskStreamHeartbeat:  time.Duration(riskStreamHeartbeat) * time.Second,
+
+		SeverityWeightSevere:   severityWeightSevere,
+		SeverityWeightModerate: severityWeightModerate,
+
+		LogLevel:  getEnv("LOG_LEVEL", "info"),
+		LogFormat: getEnv("LOG_FORMAT", logFormat),
 	}
 }
 
@@ -70,29 +233,45 @@ func getEnv(key, defaultValue string) string {
 
 // NewGormDBConnection crea una nueva conexión a la base de datos usando GORM
 func NewGormDBConnection(config *Config) (*gorm.DB, error) {
-	var db *gorm.DB
-	var err error
+	var dsn string
 
 	switch config.DBType {
 	case PostgreSQL:
-		dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
+		dsn = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
 			config.DBHost, config.DBPort, config.DBUser, config.DBPassword, config.DBName)
-		db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-			Logger: logger.Default.LogMode(logger.Info),
-		})
 	case MySQL:
-		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 			config.DBUser, config.DBPassword, config.DBHost, config.DBPort, config.DBName)
-		db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
-			Logger: logger.Default.LogMode(logger.Info),
-		})
 	default:
 		return nil, fmt.Errorf("tipo de base de datos no soportado: %s", config.DBType)
 	}
 
-	if err != nil {
-		return nil, err
+	return openGormDB(config.DBType, dsn)
+}
+
+// NewGormReadConnection crea la conexión de solo lectura usada por los reportes.
+// Devuelve la conexión primaria cuando no hay réplica configurada.
+func NewGormReadConnection(config *Config, primary *gorm.DB) (*gorm.DB, error) {
+	if config.DBReplicaDSN == "" {
+		return primary, nil
+	}
+	return openGormDB(config.DBType, config.DBReplicaDSN)
+}
+
+// openGormDB abre una conexión GORM con el driver correspondiente al tipo de base de datos
+func openGormDB(dbType DBType, dsn string) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+
+	switch dbType {
+	case PostgreSQL:
+		dialector = postgres.Open(dsn)
+	case MySQL:
+		dialector = mysql.Open(dsn)
+	default:
+		return nil, fmt.Errorf("tipo de base de datos no soportado: %s", dbType)
 	}
 
-	return db, nil
+	return gorm.Open(dialector, &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Info),
+	})
 }
@@ -2,18 +2,18 @@ package config
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/luispfcanales/api-muac/internal/core/domain"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/luispfcanales/api-muac/internal/infrastructure/auth"
 	"gorm.io/gorm"
 )
 
 // SeedDatabase inserta datos iniciales basados en estándares OMS/UNICEF/Sphere Handbook
 func SeedDatabase(db *gorm.DB) error {
-	log.Println("🌱 Iniciando siembra de datos para Sistema MUAC (OMS/UNICEF/Sphere)")
+	slog.Info("iniciando siembra de datos para Sistema MUAC (OMS/UNICEF/Sphere)")
 
 	// Verificar si ya existen datos
 	var roleCount int64
@@ -22,7 +22,7 @@ func SeedDatabase(db *gorm.DB) error {
 	}
 
 	if roleCount > 0 {
-		log.Println("📋 Roles existentes detectados, verificando datos complementarios...")
+		slog.Info("roles existentes detectados, verificando datos complementarios")
 		return seedAdditionalData(db)
 	}
 
@@ -35,7 +35,7 @@ func SeedDatabase(db *gorm.DB) error {
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
-			log.Printf("Transacción revertida debido a panic: %v", r)
+			slog.Error("transacción de siembra revertida debido a panic", "panic", r)
 		}
 	}()
 
@@ -93,7 +93,7 @@ func SeedDatabase(db *gorm.DB) error {
 
 // seedRoles crea los roles del sistema MUAC
 func seedRoles(tx *gorm.DB) error {
-	log.Println("👥 Creando roles del sistema...")
+	slog.Info("creando roles del sistema")
 
 	roles := []domain.Role{
 		{
@@ -120,13 +120,13 @@ func seedRoles(tx *gorm.DB) error {
 		return fmt.Errorf("error creando roles: %w", err)
 	}
 
-	log.Printf("✅ %d roles creados exitosamente", len(roles))
+	slog.Info("roles creados", "count", len(roles))
 	return nil
 }
 
 // seedTags crea los tags MUAC según estándares oficiales
 func seedTags(tx *gorm.DB) error {
-	log.Println("🏷️  Creando tags de clasificación MUAC...")
+	slog.Info("creando tags de clasificación MUAC")
 
 	tags := []domain.Tag{
 		{
@@ -179,13 +179,13 @@ func seedTags(tx *gorm.DB) error {
 		return fmt.Errorf("error creando tags: %w", err)
 	}
 
-	log.Printf("✅ %d tags MUAC oficiales creados", len(tags))
+	slog.Info("tags MUAC oficiales creados", "count", len(tags))
 	return nil
 }
 
 // seedRecommendations crea las recomendaciones nutricionales contextualizadas
 func seedRecommendations(tx *gorm.DB) error {
-	log.Println("💡 Creando recomendaciones nutricionales para comunidades amazónicas...")
+	slog.Info("creando recomendaciones nutricionales para comunidades amazónicas")
 
 	// Valores según estándares OMS/UNICEF
 	valorSevere := domain.MuacThresholdSevere
@@ -292,13 +292,13 @@ func seedRecommendations(tx *gorm.DB) error {
 		return fmt.Errorf("error creando recomendaciones: %w", err)
 	}
 
-	log.Printf("✅ %d recomendaciones contextualizadas creadas", len(recommendations))
+	slog.Info("recomendaciones contextualizadas creadas", "count", len(recommendations))
 	return nil
 }
 
 // seedAdminUser crea el usuario administrador inicial
 func seedAdminUser(tx *gorm.DB) error {
-	log.Println("👤 Creando usuario administrador inicial...")
+	slog.Info("creando usuario administrador inicial")
 
 	// Obtener rol de administrador
 	var adminRole domain.Role
@@ -308,7 +308,7 @@ func seedAdminUser(tx *gorm.DB) error {
 
 	// Hashear contraseña
 	password := "admin123"
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := auth.HashPassword(password)
 	if err != nil {
 		return fmt.Errorf("error hasheando contraseña: %w", err)
 	}
@@ -321,7 +321,7 @@ func seedAdminUser(tx *gorm.DB) error {
 		Email:        "admin@muac.org",
 		DNI:          "00000000",
 		Phone:        "999000000",
-		PasswordHash: string(hashedPassword),
+		PasswordHash: hashedPassword,
 		Active:       true,
 		RoleID:       adminRole.ID,
 		CreatedAt:    time.Now(),
@@ -331,13 +331,30 @@ func seedAdminUser(tx *gorm.DB) error {
 		return fmt.Errorf("error creando usuario admin: %w", err)
 	}
 
-	log.Println("✅ Usuario administrador creado exitosamente")
+	slog.Info("usuario administrador creado exitosamente")
 	return nil
 }
 
+// Preguntas frecuentes cuya respuesta menciona el rango de edad configurado
+const (
+	faqQuestionAnyChild          = "¿Puedo usar el app con cualquier niño/a?"
+	faqQuestionCommunityChildren = "¿Puedo usar esta app para otros niños de la comunidad?"
+)
+
+// ageRangeFAQAnswers arma, por pregunta, las respuestas que dependen de domain.PatientAgeRange
+func ageRangeFAQAnswers() map[string]string {
+	r := domain.PatientAgeRange
+	return map[string]string{
+		faqQuestionAnyChild: fmt.Sprintf("Sí, siempre que tenga %s (de %.1f a %.1f años de edad). No se recomienda para niños/as fuera de ese rango.",
+			r.Describe(), r.MinYears(), r.MaxYears()),
+		faqQuestionCommunityChildren: fmt.Sprintf("Sí. Puedes usar la cinta y la app con cualquier niño %s. Solo asegúrate de no confundir las mediciones si lo haces con varios.",
+			r.Describe()),
+	}
+}
+
 // seedFAQs crea las preguntas frecuentes iniciales del sistema
 func seedFAQs(tx *gorm.DB) error {
-	log.Println("❓ Creando preguntas frecuentes (FAQs)...")
+	slog.Info("creando preguntas frecuentes (FAQs)")
 
 	faqs := []domain.FAQ{
 		// SOBRE EL USO DE LA CINTA Y EL APP
@@ -364,8 +381,8 @@ func seedFAQs(tx *gorm.DB) error {
 			Category: domain.FAQCategoryAppInfo,
 		},
 		{
-			Question: "¿Puedo usar el app con cualquier niño/a?",
-			Answer:   "Sí, siempre que tenga entre 6 y 59 meses (de 0.5 a 5 años de edad). No se recomienda para bebés menores de 6 meses ni para niños mayores de 5 años.",
+			Question: faqQuestionAnyChild,
+			Answer:   ageRangeFAQAnswers()[faqQuestionAnyChild],
 			Category: domain.FAQCategoryAppInfo,
 		},
 
@@ -404,8 +421,8 @@ func seedFAQs(tx *gorm.DB) error {
 			Category: domain.FAQCategoryHealthCenters,
 		},
 		{
-			Question: "¿Puedo usar esta app para otros niños de la comunidad?",
-			Answer:   "Sí. Puedes usar la cinta y la app con cualquier niño de entre 6 y 59 meses. Solo asegúrate de no confundir las mediciones si lo haces con varios.",
+			Question: faqQuestionCommunityChildren,
+			Answer:   ageRangeFAQAnswers()[faqQuestionCommunityChildren],
 			Category: domain.FAQCategoryHealthCenters,
 		},
 
@@ -444,13 +461,13 @@ func seedFAQs(tx *gorm.DB) error {
 		return fmt.Errorf("error creando FAQs: %w", err)
 	}
 
-	log.Printf("✅ %d preguntas frecuentes creadas en %d categorías", len(faqs), len(domain.ValidFAQCategories))
+	slog.Info("preguntas frecuentes creadas", "count", len(faqs), "categories", len(domain.ValidFAQCategories))
 	return nil
 }
 
 // seedTips crea los consejos iniciales del sistema
 func seedTips(tx *gorm.DB) error {
-	log.Println("💡 Creando consejos (Tips)...")
+	slog.Info("creando consejos (Tips)")
 
 	tips := []domain.Tip{
 		{
@@ -490,12 +507,12 @@ func seedTips(tx *gorm.DB) error {
 		return fmt.Errorf("error creando Tips: %w", err)
 	}
 
-	log.Printf("✅ %d consejos creados", len(tips))
+	slog.Info("consejos creados", "count", len(tips))
 	return nil
 }
 
 func seedRecipes(tx *gorm.DB) error {
-	log.Println("🍳 Creando recetas recomendadas...")
+	slog.Info("creando recetas recomendadas")
 
 	recipes := []domain.Recipe{
 		// Para 6-12 meses (0.6-1 año)
@@ -551,7 +568,7 @@ func seedRecipes(tx *gorm.DB) error {
 		return fmt.Errorf("error creando recetas: %w", err)
 	}
 
-	log.Printf("✅ %d recetas creadas", len(recipes))
+	slog.Info("recetas creadas", "count", len(recipes))
 	return nil
 }
 
@@ -559,7 +576,7 @@ func seedRecipes(tx *gorm.DB) error {
 
 // seedAdditionalData agrega datos faltantes si los roles ya existen
 func seedAdditionalData(db *gorm.DB) error {
-	log.Println("🔍 Verificando y completando datos del sistema...")
+	slog.Info("verificando y completando datos del sistema")
 
 	if err := checkAndCreateTags(db); err != nil {
 		return fmt.Errorf("error verificando tags: %w", err)
@@ -587,7 +604,7 @@ func seedAdditionalData(db *gorm.DB) error {
 		return fmt.Errorf("error actualizando datos existentes: %w", err)
 	}
 
-	log.Println("✅ Verificación de datos completada")
+	slog.Info("verificación de datos completada")
 	return nil
 }
 
@@ -598,11 +615,11 @@ func checkAndCreateFAQs(db *gorm.DB) error {
 	}
 
 	if faqCount == 0 {
-		log.Println("❓ No se encontraron FAQs, creando preguntas frecuentes...")
+		slog.Info("no se encontraron FAQs, creando preguntas frecuentes")
 		return seedFAQs(db)
 	}
 
-	log.Println("✅ FAQs verificadas - OK")
+	slog.Info("FAQs verificadas")
 	return nil
 }
 
@@ -613,11 +630,11 @@ func checkAndCreateTips(db *gorm.DB) error {
 	}
 
 	if TipCount == 0 {
-		log.Println("❓ No se encontraron Tips, creando Tips...")
+		slog.Info("no se encontraron Tips, creando Tips")
 		return seedTips(db)
 	}
 
-	log.Println("✅ Tips - OK")
+	slog.Info("tips verificados")
 	return nil
 }
 
@@ -628,11 +645,11 @@ func checkAndCreateRecipes(db *gorm.DB) error {
 	}
 
 	if RecipeCount == 0 {
-		log.Println("❓ No se encontraron Recipes, creando Recipes...")
+		slog.Info("no se encontraron Recipes, creando Recipes")
 		return seedRecipes(db)
 	}
 
-	log.Println("✅ Recipes - OK")
+	slog.Info("recetas verificadas")
 	return nil
 }
 
@@ -644,7 +661,7 @@ func checkAndCreateTags(db *gorm.DB) error {
 	}
 
 	if tagCount == 0 {
-		log.Println("🏷️  Creando tags MUAC faltantes...")
+		slog.Info("creando tags MUAC faltantes")
 		return seedTags(db)
 	}
 
@@ -653,11 +670,11 @@ func checkAndCreateTags(db *gorm.DB) error {
 	db.Model(&domain.Tag{}).Where("muac_code IS NULL OR muac_code = ''").Count(&tagsWithoutMuacCode)
 
 	if tagsWithoutMuacCode > 0 {
-		log.Printf("🔧 Actualizando %d tags con códigos MUAC...", tagsWithoutMuacCode)
+		slog.Info("actualizando tags con códigos MUAC", "count", tagsWithoutMuacCode)
 		return updateTagsWithMuacCodes(db)
 	}
 
-	log.Println("✅ Tags MUAC verificados - OK")
+	slog.Info("tags MUAC verificados")
 	return nil
 }
 
@@ -669,7 +686,7 @@ func checkAndCreateRecommendations(db *gorm.DB) error {
 	}
 
 	if recCount == 0 {
-		log.Println("💡 Creando recomendaciones nutricionales faltantes...")
+		slog.Info("creando recomendaciones nutricionales faltantes")
 		return seedRecommendations(db)
 	}
 
@@ -678,11 +695,11 @@ func checkAndCreateRecommendations(db *gorm.DB) error {
 	db.Model(&domain.Recommendation{}).Where("muac_code IS NULL OR muac_code = ''").Count(&recsWithoutMuacCode)
 
 	if recsWithoutMuacCode > 0 {
-		log.Printf("🔧 Actualizando %d recomendaciones con códigos MUAC...", recsWithoutMuacCode)
+		slog.Info("actualizando recomendaciones con códigos MUAC", "count", recsWithoutMuacCode)
 		return updateRecommendationsWithMuacCodes(db)
 	}
 
-	log.Println("✅ Recomendaciones verificadas - OK")
+	slog.Info("recomendaciones verificadas")
 	return nil
 }
 
@@ -690,45 +707,43 @@ func checkAndCreateRecommendations(db *gorm.DB) error {
 func updateExistingData(db *gorm.DB) error {
 	// Activar tags que puedan estar inactivos
 	if err := db.Model(&domain.Tag{}).Where("active IS NULL").Update("active", true).Error; err != nil {
-		log.Printf("Warning: Error activando tags: %v", err)
+		slog.Warn("error activando tags", "error", err)
 	}
 
 	// Activar recomendaciones que puedan estar inactivas
 	if err := db.Model(&domain.Recommendation{}).Where("active IS NULL").Update("active", true).Error; err != nil {
-		log.Printf("Warning: Error activando recomendaciones: %v", err)
+		slog.Warn("error activando recomendaciones", "error", err)
 	}
 
+	updateAgeRangeFAQs(db)
 	return nil
 }
 
-// updateTagsWithMuacCodes actualiza tags existentes con códigos MUAC
-func updateTagsWithMuacCodes(db *gorm.DB) error {
-	updates := map[string]map[string]interface{}{
-		"MUAC-R1": {
-			"muac_code": domain.MuacCodeRed,
-			"color":     domain.ColorRed,
-			"priority":  domain.PriorityExtreme,
-		},
-		"MUAC-Y1": {
-			"muac_code": domain.MuacCodeYellow,
-			"color":     domain.ColorYellow,
-			"priority":  domain.PriorityHigh,
-		},
-		"MUAC-G1": {
-			"muac_code": domain.MuacCodeGreen,
-			"color":     domain.ColorGreen,
-			"priority":  domain.PriorityLow,
-		},
-		"SEGUIMIENTO": {
-			"muac_code": domain.MuacCodeFollow,
-			"color":     domain.ColorBlue,
-			"priority":  domain.PriorityMedium,
-		},
+// updateAgeRangeFAQs reescribe las respuestas que mencionan el rango de edad para que coincidan con el
+// rango configurado, ya sea que las FAQs se sembraron con otro valor o el valor cambió desde entonces
+func updateAgeRangeFAQs(db *gorm.DB) {
+	for question, answer := range ageRangeFAQAnswers() {
+		result := db.Model(&domain.FAQ{}).Where("question = ? AND answer <> ?", question, answer).Update("answer", answer)
+		if result.Error != nil {
+			slog.Warn("error actualizando FAQ del rango de edad", "question", question, "error", result.Error)
+			continue
+		}
+		if result.RowsAffected > 0 {
+			slog.Info("FAQ actualizada con el rango de edad vigente", "question", question)
+		}
 	}
+}
 
-	for name, fields := range updates {
+// updateTagsWithMuacCodes actualiza tags existentes con códigos MUAC (ver domain.TagMuacMappings)
+func updateTagsWithMuacCodes(db *gorm.DB) error {
+	for name, mapping := range domain.TagMuacMappings {
+		fields := map[string]interface{}{
+			"muac_code": mapping.MuacCode,
+			"color":     mapping.Color,
+			"priority":  mapping.Priority,
+		}
 		if err := db.Model(&domain.Tag{}).Where("name = ?", name).Updates(fields).Error; err != nil {
-			log.Printf("Warning: Error actualizando tag %s: %v", name, err)
+			slog.Warn("error actualizando tag", "name", name, "error", err)
 		}
 	}
 
@@ -736,49 +751,17 @@ func updateTagsWithMuacCodes(db *gorm.DB) error {
 }
 
 // updateRecommendationsWithMuacCodes actualiza recomendaciones existentes
+// buscando patrones en el nombre (ver domain.RecommendationMuacPatterns)
 func updateRecommendationsWithMuacCodes(db *gorm.DB) error {
-	// Buscar recomendaciones por patrones en el nombre
-	updates := []struct {
-		pattern string
-		fields  map[string]interface{}
-	}{
-		{
-			pattern: "%ALERTA ROJA%",
-			fields: map[string]interface{}{
-				"muac_code":  domain.MuacCodeRed,
-				"color_code": domain.ColorRed,
-				"priority":   domain.PriorityUrgent,
-			},
-		},
-		{
-			pattern: "%ALERTA AMARILLA%",
-			fields: map[string]interface{}{
-				"muac_code":  domain.MuacCodeYellow,
-				"color_code": domain.ColorYellow,
-				"priority":   domain.PriorityAttention,
-			},
-		},
-		{
-			pattern: "%ZONA VERDE%",
-			fields: map[string]interface{}{
-				"muac_code":  domain.MuacCodeGreen,
-				"color_code": domain.ColorGreen,
-				"priority":   domain.PriorityNormal,
-			},
-		},
-		{
-			pattern: "%Seguimiento%",
-			fields: map[string]interface{}{
-				"muac_code":  domain.MuacCodeFollow,
-				"color_code": domain.ColorBlue,
-				"priority":   domain.PriorityAttention,
-			},
-		},
-	}
-
-	for _, update := range updates {
-		if err := db.Model(&domain.Recommendation{}).Where("name LIKE ?", update.pattern).Updates(update.fields).Error; err != nil {
-			log.Printf("Warning: Error actualizando recomendaciones con patrón %s: %v", update.pattern, err)
+	for _, update := range domain.RecommendationMuacPatterns {
+		fields := map[string]interface{}{
+			"muac_code":  update.Mapping.MuacCode,
+			"color_code": update.Mapping.Color,
+			"priority":   update.Mapping.Priority,
+		}
+		pattern := "%" + update.Pattern + "%"
+		if err := db.Model(&domain.Recommendation{}).Where("name LIKE ?", pattern).Updates(fields).Error; err != nil {
+			slog.Warn("error actualizando recomendaciones", "pattern", pattern, "error", err)
 		}
 	}
 
@@ -807,30 +790,21 @@ func logSeedingSummary(db *gorm.DB) {
 	db.Model(&domain.Measurement{}).Count(&counts.Measurements)
 	db.Model(&domain.FAQ{}).Count(&counts.FAQs)
 
-	log.Println("")
-	log.Println("🎉 ============= SISTEMA MUAC INICIALIZADO =============")
-	log.Println("📊 Resumen de datos:")
-	log.Printf("   👥 Usuarios: %d", counts.Users)
-	log.Printf("   🔐 Roles: %d", counts.Roles)
-	log.Printf("   🏷️  Tags MUAC: %d", counts.Tags)
-	log.Printf("   💡 Recomendaciones: %d", counts.Recommendations)
-	log.Printf("   ❓ Preguntas frecuentes: %d", counts.FAQs)
-	log.Printf("   🧒 Pacientes: %d", counts.Patients)
-	log.Printf("   📏 Mediciones: %d", counts.Measurements)
-	log.Println("")
-	log.Println("🌍 Clasificación MUAC según estándares OMS/UNICEF/Sphere:")
-	log.Printf("   🔴 MUAC-R1: < %.1f cm  (Desnutrición Aguda Severa - SAM)", domain.MuacThresholdSevere)
-	log.Printf("   🟡 MUAC-Y1: %.1f-%.1f cm (Desnutrición Aguda Moderada - MAM)", domain.MuacThresholdSevere, domain.MuacThresholdModerate)
-	log.Printf("   🟢 MUAC-G1: ≥ %.1f cm  (Estado Nutricional Adecuado)", domain.MuacThresholdNormal)
-	log.Println("   🔵 SEGUIMIENTO: Pacientes en recuperación")
-	log.Println("")
-	log.Println("🔑 Credenciales administrador:")
-	log.Println("   📧 Email: admin@muac.org")
-	log.Println("   🔒 Password: admin123")
-	log.Println("")
-	log.Println("✅ Sistema listo para registro de mediciones con")
-	log.Println("   clasificación automática y recomendaciones contextualizadas")
-	log.Println("=========================================================")
+	slog.Info("sistema MUAC inicializado",
+		"users", counts.Users,
+		"roles", counts.Roles,
+		"tags", counts.Tags,
+		"recommendations", counts.Recommendations,
+		"faqs", counts.FAQs,
+		"patients", counts.Patients,
+		"measurements", counts.Measurements,
+	)
+	slog.Info("clasificación MUAC según estándares OMS/UNICEF/Sphere",
+		domain.MuacCodeRed, fmt.Sprintf("< %.1f cm", domain.MuacThresholdSevere),
+		domain.MuacCodeYellow, fmt.Sprintf("%.1f-%.1f cm", domain.MuacThresholdSevere, domain.MuacThresholdModerate),
+		domain.MuacCodeGreen, fmt.Sprintf("≥ %.1f cm", domain.MuacThresholdNormal),
+	)
+	slog.Warn("el administrador inicial usa la contraseña por defecto; cambiarla tras el primer ingreso", "email", "admin@muac.org")
 }
 
 // ============= FUNCIONES DE UTILIDAD =============
@@ -914,7 +888,7 @@ func ValidateSeedData(db *gorm.DB) error {
 
 // CleanSeedData limpia todos los datos sembrados (útil para testing)
 func CleanSeedData(db *gorm.DB) error {
-	log.Println("🧹 Limpiando datos sembrados...")
+	slog.Info("limpiando datos sembrados")
 
 	// Orden inverso por dependencias
 	tables := []string{
@@ -928,10 +902,10 @@ func CleanSeedData(db *gorm.DB) error {
 
 	for _, table := range tables {
 		if err := db.Exec(fmt.Sprintf("DELETE FROM %s", table)).Error; err != nil {
-			log.Printf("Warning: Error limpiando tabla %s: %v", table, err)
+			slog.Warn("error limpiando tabla", "table", table, "error", err)
 		}
 	}
 
-	log.Println("✅ Datos limpiados")
+	slog.Info("datos limpiados")
 	return nil
 }
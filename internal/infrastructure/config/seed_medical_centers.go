@@ -2,7 +2,7 @@ package config
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,7 +11,7 @@ import (
 )
 
 func seedMedicalCenters(tx *gorm.DB) error {
-	log.Println("🏥 Creando centros de salud...")
+	slog.Info("creando centros de salud")
 
 	medicalCenters := []domain.Locality{
 		{
@@ -756,7 +756,7 @@ func seedMedicalCenters(tx *gorm.DB) error {
 		return fmt.Errorf("error creando centros de salud: %w", err)
 	}
 
-	log.Printf("✅ %d centros de salud creados", len(medicalCenters))
+	slog.Info("centros de salud creados", "count", len(medicalCenters))
 	return nil
 }
 
@@ -767,10 +767,10 @@ func checkAndCreateMedicalCenter(db *gorm.DB) error {
 	}
 
 	if medicalCenter == 0 {
-		log.Println("❓ No se encontraron centros medicos, creando preguntas frecuentes...")
+		slog.Info("no se encontraron centros medicos, creando preguntas frecuentes")
 		return seedFAQs(db)
 	}
 
-	log.Println("✅ FAQs verificadas - OK")
+	slog.Info("FAQs verificadas")
 	return nil
 }
@@ -0,0 +1,98 @@
+// Package growth carga las tablas de referencia OMS que usan las curvas de crecimiento y el WHZ.
+// Las tablas publicadas del paquete igrowup se incluyen en el binario; una ruta configurada las reemplaza
+package growth
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+)
+
+// Archivos del paquete igrowup de la OMS incluidos en data/
+const (
+	muacReferenceFile = "data/acanthro.txt"
+	weightLengthFile  = "data/wflanthro.txt"
+	weightHeightFile  = "data/wfhanthro.txt"
+)
+
+// weightForHeightFiles son las tablas de peso para la longitud y para la talla incluidas
+var weightForHeightFiles = []string{weightLengthFile, weightHeightFile}
+
+// ErrReferenceNotEmbedded indica que el binario se compiló sin la tabla OMS incluida
+var ErrReferenceNotEmbedded = errors.New("la tabla OMS no está incluida en el binario")
+
+//go:embed data
+var embedded embed.FS
+
+// LoadMuacReference carga las tablas OMS de perímetro braquial para la edad desde path o, si está vacío,
+// desde la tabla incluida en el binario
+func LoadMuacReference(path string) (domain.MuacReference, error) {
+	var reference domain.MuacReference
+	err := readTable(path, muacReferenceFile, func(r io.Reader) error {
+		parsed, err := domain.ParseMuacReference(r)
+		reference = parsed
+		return err
+	})
+	return reference, err
+}
+
+// LoadWeightForHeightReference carga las tablas OMS de peso para la longitud y la talla desde los
+// archivos de paths (separados por comas) o, si está vacío, desde las tablas incluidas en el binario
+func LoadWeightForHeightReference(paths string) (domain.WeightForHeightReference, error) {
+	reference := domain.WeightForHeightReference{}
+	parse := func(r io.Reader) error {
+		return domain.ParseWeightForHeightReference(r, reference)
+	}
+
+	if strings.TrimSpace(paths) == "" {
+		for _, file := range weightForHeightFiles {
+			if err := readTable("", file, parse); err != nil {
+				return nil, err
+			}
+		}
+		return reference, nil
+	}
+	for _, path := range strings.Split(paths, ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		if err := readTable(path, "", parse); err != nil {
+			return nil, err
+		}
+	}
+	return reference, nil
+}
+
+// readTable abre path si se indica o, si no, el archivo incluido, y lo entrega a parse
+func readTable(path, embeddedFile string, parse func(io.Reader) error) error {
+	var (
+		file io.ReadCloser
+		err  error
+	)
+	if path != "" {
+		file, err = os.Open(path)
+	} else {
+		file, err = embedded.Open(embeddedFile)
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("%w: %s", ErrReferenceNotEmbedded, embeddedFile)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := parse(file); err != nil {
+		if path == "" {
+			path = embeddedFile
+		}
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
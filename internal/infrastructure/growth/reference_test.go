@@ -0,0 +1,105 @@
+package growth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+)
+
+// writeMuacTable escribe una tabla con el formato de la OMS y valores de prueba (no son los publicados)
+func writeMuacTable(t *testing.T) string {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString("sex,month,l,m,s\n")
+	for sex := 1; sex <= 2; sex++ {
+		for month := domain.GrowthPlotMinMonths; month <= domain.GrowthPlotMaxMonths; month++ {
+			fmt.Fprintf(&b, "%d,%d,0.3,%.2f,0.08\n", sex, month, 14+float64(month)/30)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "acanthro.csv")
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestLoadMuacReferenceFromPath(t *testing.T) {
+	reference, err := LoadMuacReference(writeMuacTable(t))
+	if err != nil {
+		t.Fatalf("LoadMuacReference: %v", err)
+	}
+	if !reference.Available(domain.GrowthSexMale) || !reference.Available(domain.GrowthSexFemale) {
+		t.Fatal("la referencia cargada desde la ruta no tiene ambos sexos")
+	}
+}
+
+func TestLoadMuacReferenceMissingPath(t *testing.T) {
+	if _, err := LoadMuacReference(filepath.Join(t.TempDir(), "no-existe.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("err = %v, se esperaba que no exista el archivo", err)
+	}
+}
+
+func TestLoadMuacReferenceEmbedded(t *testing.T) {
+	reference, err := LoadMuacReference("")
+	if errors.Is(err, ErrReferenceNotEmbedded) {
+		t.Skipf("%v: agregar %s para incluirla", err, muacReferenceFile)
+	}
+	if err != nil {
+		t.Fatalf("la tabla incluida no se pudo leer: %v", err)
+	}
+	if !reference.Available(domain.GrowthSexMale) || !reference.Available(domain.GrowthSexFemale) {
+		t.Fatal("la tabla incluida no tiene ambos sexos")
+	}
+}
+
+// writeWeightTable escribe una tabla de peso para la longitud o la talla con valores de prueba
+func writeWeightTable(t *testing.T, measure string, from, to int) string {
+	t.Helper()
+	var b strings.Builder
+	fmt.Fprintf(&b, "sex\t%s\tl\tm\ts\n", measure)
+	for sex := 1; sex <= 2; sex++ {
+		for cm := from; cm <= to; cm++ {
+			fmt.Fprintf(&b, "%d\t%d.0\t-0.35\t%.3f\t0.09\n", sex, cm, float64(cm)/7)
+		}
+	}
+	path := filepath.Join(t.TempDir(), measure+".txt")
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestLoadWeightForHeightReferenceFromPaths(t *testing.T) {
+	paths := writeWeightTable(t, "length", 45, 110) + ", " + writeWeightTable(t, "height", 65, 120)
+
+	reference, err := LoadWeightForHeightReference(paths)
+	if err != nil {
+		t.Fatalf("LoadWeightForHeightReference: %v", err)
+	}
+	if _, ok := reference.Lookup(domain.WFHTableLength, domain.GrowthSexFemale, 70); !ok {
+		t.Error("falta la tabla de longitud cargada")
+	}
+	if _, ok := reference.Lookup(domain.WFHTableHeight, domain.GrowthSexMale, 100); !ok {
+		t.Error("falta la tabla de talla cargada")
+	}
+}
+
+func TestLoadWeightForHeightReferenceEmbedded(t *testing.T) {
+	reference, err := LoadWeightForHeightReference("")
+	if errors.Is(err, ErrReferenceNotEmbedded) {
+		t.Skipf("%v: agregar %v para incluirlas", err, weightForHeightFiles)
+	}
+	if err != nil {
+		t.Fatalf("las tablas incluidas no se pudieron leer: %v", err)
+	}
+	for _, table := range []string{domain.WFHTableLength, domain.WFHTableHeight} {
+		if _, ok := reference.Lookup(table, domain.GrowthSexMale, 80); !ok {
+			t.Errorf("falta la tabla incluida %s", table)
+		}
+	}
+}
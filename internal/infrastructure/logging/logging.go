@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Formatos de salida del logger
+const (
+	FormatText = "text" // legible, para desarrollo
+	FormatJSON = "json" // una línea JSON por registro, para producción
+)
+
+// New crea el logger de la aplicación con el nivel y formato indicados.
+// Cada registro incluye request_id y user_id cuando el contexto los trae.
+func New(level, format string) *slog.Logger {
+	options := &slog.HandlerOptions{Level: ParseLevel(level)}
+
+	var handler slog.Handler
+	if strings.EqualFold(format, FormatJSON) {
+		handler = slog.NewJSONHandler(os.Stdout, options)
+	} else {
+		handler = slog.NewTextHandler(os.Stdout, options)
+	}
+	return slog.New(contextHandler{Handler: handler})
+}
+
+// ParseLevel convierte debug, info, warn o error en un nivel de slog (info por defecto)
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	userIDKey
+)
+
+// WithRequestID guarda el identificador de la petición en el contexto
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID obtiene el identificador de la petición del contexto (vacío si no existe)
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// WithUserID guarda el usuario que origina la petición en el contexto
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserID obtiene el usuario que origina la petición del contexto (vacío si no existe)
+func UserID(ctx context.Context) string {
+	userID, _ := ctx.Value(userIDKey).(string)
+	return userID
+}
+
+// contextHandler agrega a cada registro los campos de la petición guardados en el contexto
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle agrega request_id y user_id antes de delegar en el handler de salida
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	if userID := UserID(ctx); userID != "" {
+		record.AddAttrs(slog.String("user_id", userID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs conserva el contextHandler al derivar loggers con campos fijos
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup conserva el contextHandler al derivar loggers con grupo
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
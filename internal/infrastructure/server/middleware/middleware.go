@@ -1,54 +1,121 @@
 package middleware
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
 	"runtime/debug"
+	"strings"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/luispfcanales/api-muac/internal/core/domain"
+	"github.com/luispfcanales/api-muac/internal/infrastructure/config"
+	"github.com/luispfcanales/api-muac/internal/infrastructure/logging"
 )
 
 // ApplyMiddlewares aplica todos los middlewares necesarios
-func ApplyMiddlewares(handler http.Handler) http.Handler {
+func ApplyMiddlewares(handler http.Handler, cfg *config.Config, logger *slog.Logger) http.Handler {
+	// Middleware de timeout por petición
+	handler = TimeoutMiddleware(cfg.RequestTimeout, cfg.ExportTimeout)(handler)
+
+	// Middleware de modo mantenimiento (solo lectura)
+	handler = MaintenanceMiddleware(handler)
+
 	// Middleware de logging
-	handler = LoggingMiddleware(handler)
+	handler = LoggingMiddleware(logger)(handler)
 
 	// Middleware CORS
 	handler = CorsMiddleware(handler)
 
 	// Middleware de recuperación de pánico
-	handler = RecoveryMiddleware(handler)
+	handler = RecoveryMiddleware(logger)(handler)
+
+	// Middleware de identificación de la petición (el más externo, para que todo log la incluya)
+	handler = RequestIDMiddleware(handler)
 
 	return handler
 }
 
-// LoggingMiddleware registra información sobre cada solicitud
-func LoggingMiddleware(next http.Handler) http.Handler {
+// requestIDHeader es la cabecera con el identificador de la petición
+const requestIDHeader = "X-Request-ID"
+
+// RequestIDMiddleware asigna un identificador a cada petición (o reutiliza el recibido en
+// X-Request-ID), lo devuelve en la respuesta y lo guarda en el contexto junto con X-User-ID
+// para que los logs lo incluyan
+func RequestIDMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
+		requestID := r.Header.Get(requestIDHeader)
+		if requestID == "" || len(requestID) > 64 {
+			requestID = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, requestID)
 
-		// Llamar al siguiente handler
-		next.ServeHTTP(w, r)
+		ctx := logging.WithRequestID(r.Context(), requestID)
+		if userID, err := uuid.Parse(r.Header.Get("X-User-ID")); err == nil {
+			ctx = logging.WithUserID(ctx, userID.String())
+		}
 
-		// Registrar la solicitud después de procesarla
-		duration := time.Since(start)
-
-		// Log de la solicitud
-		log.Printf(
-			"%s %s %s %s",
-			r.Method,
-			r.RequestURI,
-			r.RemoteAddr,
-			duration,
-		)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// statusRecorder guarda el código de estado escrito por el handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	sr.status = code
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+// Flush permite respuestas en streaming a través del middleware
+func (sr *statusRecorder) Flush() {
+	if f, ok := sr.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap permite a http.ResponseController llegar a la conexión (p. ej. para SetWriteDeadline)
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
+
+// LoggingMiddleware registra información sobre cada solicitud
+func LoggingMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			// Llamar al siguiente handler
+			next.ServeHTTP(recorder, r)
+
+			level := slog.LevelInfo
+			if recorder.status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			logger.Log(r.Context(), level, "petición atendida",
+				"method", r.Method,
+				"path", r.RequestURI,
+				"status", recorder.status,
+				"remote_addr", r.RemoteAddr,
+				"duration", time.Since(start),
+			)
+		})
+	}
+}
+
 func CorsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Configurar cabeceras CORS
 		w.Header().Set("Access-Control-Allow-Origin", "*") // o "*" para desarrollo
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, X-Admin-Token, X-User-ID, X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 		w.Header().Set("Access-Control-Max-Age", "86400") // 24 horas
 
@@ -64,19 +131,138 @@ func CorsMiddleware(next http.Handler) http.Handler {
 }
 
 // RecoveryMiddleware recupera de pánicos y devuelve un error 500
-func RecoveryMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if err := recover(); err != nil {
-				// Registrar el pánico
-				log.Printf("Pánico recuperado: %v", err)
-				log.Printf("Stack trace: %s", debug.Stack())
-
-				// Devolver error 500
-				http.Error(w, "Error interno del servidor", http.StatusInternalServerError)
+func RecoveryMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if err := recover(); err != nil {
+					// Registrar el pánico
+					logger.ErrorContext(r.Context(), "pánico recuperado",
+						"panic", err,
+						"method", r.Method,
+						"path", r.RequestURI,
+						"stack", string(debug.Stack()),
+					)
+
+					// Devolver error 500
+					http.Error(w, "Error interno del servidor", http.StatusInternalServerError)
+				}
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// TimeoutMiddleware cancela el contexto de la petición al superar el tiempo límite.
+// Las rutas de archivos y exportaciones usan un límite mayor y los streams no tienen límite.
+func TimeoutMiddleware(timeout, exportTimeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isStreamingRoute(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
 			}
-		}()
 
-		next.ServeHTTP(w, r)
+			limit := timeout
+			if isLongRunningRoute(r.URL.Path) {
+				limit = exportTimeout
+			}
+			if limit <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), limit)
+			defer cancel()
+
+			tw := &timeoutWriter{ResponseWriter: w, ctx: ctx}
+			next.ServeHTTP(tw, r.WithContext(ctx))
+
+			// El handler terminó sin responder tras el timeout
+			if !tw.wroteHeader && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				http.Error(w, "Tiempo de espera agotado", http.StatusGatewayTimeout)
+			}
+		})
+	}
+}
+
+// maintenanceExemptRoutes siguen aceptando escrituras en modo mantenimiento:
+// el propio interruptor, el login, que no modifica datos, y la importación de respaldos,
+// pensada para cargar datos con la API cerrada a los usuarios
+var maintenanceExemptRoutes = map[string]bool{
+	"/api/admin/maintenance": true,
+	"/api/admin/import":      true,
+	"/api/users/login":       true,
+}
+
+// MaintenanceMiddleware rechaza con 503 las peticiones que modifican datos mientras
+// el modo de solo lectura está activo; las consultas siguen funcionando
+func MaintenanceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		status := domain.CurrentMaintenance()
+		if !status.Enabled || maintenanceExemptRoutes[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Retry-After", "120")
+		http.Error(w, status.Message, http.StatusServiceUnavailable)
 	})
 }
+
+// isLongRunningRoute identifica rutas de descarga o exportación
+func isLongRunningRoute(path string) bool {
+	return strings.HasPrefix(path, "/files/") ||
+		strings.Contains(path, "/excel") ||
+		strings.Contains(path, "/export") ||
+		path == "/api/admin/import"
+}
+
+// isStreamingRoute identifica los streams Server-Sent Events, que quedan abiertos
+func isStreamingRoute(path string) bool {
+	return strings.HasSuffix(path, "-stream")
+}
+
+// timeoutWriter convierte los errores 5xx provocados por el timeout en 504
+type timeoutWriter struct {
+	http.ResponseWriter
+	ctx         context.Context
+	wroteHeader bool
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	if tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	if status >= http.StatusInternalServerError && errors.Is(tw.ctx.Err(), context.DeadlineExceeded) {
+		status = http.StatusGatewayTimeout
+	}
+	tw.ResponseWriter.WriteHeader(status)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	if !tw.wroteHeader {
+		tw.WriteHeader(http.StatusOK)
+	}
+	return tw.ResponseWriter.Write(b)
+}
+
+// Flush permite respuestas en streaming a través del middleware
+func (tw *timeoutWriter) Flush() {
+	if f, ok := tw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap permite a http.ResponseController llegar a la conexión
+func (tw *timeoutWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
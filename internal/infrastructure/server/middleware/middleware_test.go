@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCorsMiddlewarePreflightAllowsPatch(t *testing.T) {
+	called := false
+	handler := CorsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/recommendations/1/muac", nil)
+	req.Header.Set("Origin", "https://app.example.org")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPatch)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, se esperaba 200", rec.Code)
+	}
+	if called {
+		t.Fatal("el preflight no debe llegar al handler")
+	}
+	methods := strings.Split(rec.Header().Get("Access-Control-Allow-Methods"), ",")
+	for _, want := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		found := false
+		for _, method := range methods {
+			if strings.TrimSpace(method) == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Access-Control-Allow-Methods = %q, falta %s", rec.Header().Get("Access-Control-Allow-Methods"), want)
+		}
+	}
+}
+
+func TestCorsMiddlewareHeadersOnPatch(t *testing.T) {
+	called := false
+	handler := CorsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/tags/1/muac", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if !called {
+		t.Fatal("la petición PATCH debe llegar al handler")
+	}
+	if !strings.Contains(rec.Header().Get("Access-Control-Allow-Methods"), http.MethodPatch) {
+		t.Fatalf("Access-Control-Allow-Methods = %q, falta PATCH", rec.Header().Get("Access-Control-Allow-Methods"))
+	}
+}
@@ -3,7 +3,7 @@ package server
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -18,25 +18,36 @@ import (
 type Server struct {
 	server *http.Server
 	config *config.Config
+	logger *slog.Logger
 }
 
 // NewServer crea una nueva instancia del servidor
-func NewServer(config *config.Config, handler http.Handler) *Server {
+func NewServer(config *config.Config, handler http.Handler, logger *slog.Logger) *Server {
 
-	handler = middleware.ApplyMiddlewares(handler)
+	handler = middleware.ApplyMiddlewares(handler, config, logger)
 
 	return &Server{
 		server: &http.Server{
 			Addr:         fmt.Sprintf(":%d", config.ServerPort),
 			Handler:      handler,
 			ReadTimeout:  15 * time.Second,
-			WriteTimeout: 15 * time.Second,
+			WriteTimeout: writeTimeout(config),
 			IdleTimeout:  60 * time.Second,
 		},
 		config: config,
+		logger: logger,
 	}
 }
 
+// writeTimeout debe cubrir el timeout más largo de las peticiones
+func writeTimeout(config *config.Config) time.Duration {
+	timeout := 15 * time.Second
+	if longest := config.ExportTimeout + 5*time.Second; longest > timeout {
+		timeout = longest
+	}
+	return timeout
+}
+
 // Start inicia el servidor HTTP
 func (s *Server) Start() error {
 	// Canal para capturar señales del sistema operativo
@@ -48,21 +59,21 @@ func (s *Server) Start() error {
 
 	// Iniciar el servidor en una goroutine
 	go func() {
-		log.Printf("Servidor iniciado en http://localhost:%d", s.config.ServerPort)
+		s.logger.Info("servidor iniciado", "addr", fmt.Sprintf("http://localhost:%d", s.config.ServerPort))
 		errCh <- s.server.ListenAndServe()
 	}()
 
 	// Esperar a que ocurra un error o se reciba una señal de parada
 	select {
 	case <-stop:
-		log.Println("Apagando servidor...")
+		s.logger.Info("apagando servidor")
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
 		if err := s.server.Shutdown(ctx); err != nil {
 			return fmt.Errorf("error al apagar el servidor: %w", err)
 		}
-		log.Println("Servidor apagado correctamente")
+		s.logger.Info("servidor apagado correctamente")
 		return nil
 	case err := <-errCh:
 		if err != http.ErrServerClosed {